@@ -0,0 +1,169 @@
+package agenda
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/1set/quote0"
+)
+
+// ErrNoUpcomingEvent indicates no event is in progress or starts within the search horizon.
+var ErrNoUpcomingEvent = errors.New("agenda: no upcoming event")
+
+// horizon bounds recurrence expansion when looking for the next event.
+const horizon = 366 * 24 * time.Hour
+
+// maxInstances caps the expansion of a single recurring event.
+const maxInstances = 5000
+
+// Rough per-line limits for the built-in text layout; longer values are cut with an ellipsis.
+const (
+	maxTitleRunes = 28
+	maxLineRunes  = 40
+)
+
+// Occurrences expands events into concrete instances overlapping [from, to), sorted by start.
+// Non-recurring events are included when they overlap the window.
+func Occurrences(events []Event, from, to time.Time) []Event {
+	var out []Event
+	for _, ev := range events {
+		length := ev.End.Sub(ev.Start)
+		if ev.Repeat == nil {
+			if overlaps(ev.Start, ev.End, from, to) {
+				out = append(out, ev)
+			}
+			continue
+		}
+		step := func(t time.Time, n int) time.Time {
+			if ev.Repeat.Freq == Weekly {
+				return t.AddDate(0, 0, 7*ev.Repeat.Interval*n)
+			}
+			return t.AddDate(0, 0, ev.Repeat.Interval*n)
+		}
+		for n := 0; n < maxInstances; n++ {
+			if ev.Repeat.Count > 0 && n >= ev.Repeat.Count {
+				break
+			}
+			start := step(ev.Start, n)
+			if !ev.Repeat.Until.IsZero() && start.After(ev.Repeat.Until) {
+				break
+			}
+			if !start.Before(to) {
+				break
+			}
+			if excluded(ev.Exclude, start) || !overlaps(start, start.Add(length), from, to) {
+				continue
+			}
+			inst := ev
+			inst.Start, inst.End, inst.Repeat, inst.Exclude = start, start.Add(length), nil, nil
+			out = append(out, inst)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Start.Before(out[j].Start) })
+	return out
+}
+
+// overlaps reports whether [start, end) intersects [from, to). Zero-length events count as
+// overlapping when their start lies inside the window.
+func overlaps(start, end, from, to time.Time) bool {
+	if !end.After(start) {
+		return !start.Before(from) && start.Before(to)
+	}
+	return start.Before(to) && end.After(from)
+}
+
+func excluded(list []time.Time, t time.Time) bool {
+	for _, x := range list {
+		if x.Equal(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// NextEvent returns the event in progress at now, or else the next one to start.
+// Among simultaneous candidates, timed events win over all-day ones.
+func NextEvent(events []Event, now time.Time) (Event, bool) {
+	occ := Occurrences(events, now, now.Add(horizon))
+	if len(occ) == 0 {
+		return Event{}, false
+	}
+	best := occ[0]
+	for _, ev := range occ[1:] {
+		if !ev.Start.Equal(best.Start) && ev.Start.After(now) && best.Start.After(now) {
+			break
+		}
+		if best.AllDay && !ev.AllDay {
+			best = ev
+		}
+	}
+	return best, true
+}
+
+// BuildNextEvent formats the event in progress (or the next upcoming one) as a text screen:
+// the summary as title, then "Now"/"Next" with the day and time range, and the location.
+// Times are shown in now's location. The output only changes when the shown event or its
+// state does, so repeated calls can be compared to skip redundant sends.
+func BuildNextEvent(events []Event, now time.Time) (quote0.TextRequest, error) {
+	ev, ok := NextEvent(events, now)
+	if !ok {
+		return quote0.TextRequest{}, ErrNoUpcomingEvent
+	}
+	loc := now.Location()
+	start, end := ev.Start.In(loc), ev.End.In(loc)
+
+	label := "Next"
+	if !start.After(now) {
+		label = "Now"
+	}
+	var when string
+	switch {
+	case ev.AllDay:
+		when = start.Format("Mon 01-02") + " all day"
+	case sameDay(start, end) || !end.After(start):
+		when = start.Format("Mon 01-02 15:04")
+		if end.After(start) {
+			when += "–" + end.Format("15:04")
+		}
+	default:
+		when = start.Format("Mon 01-02 15:04") + " – " + end.Format("Mon 01-02 15:04")
+	}
+
+	lines := []string{label + ": " + when}
+	if loc := strings.TrimSpace(ev.Location); loc != "" {
+		lines = append(lines, clip("@ "+firstLine(loc), maxLineRunes))
+	}
+	title := clip(firstLine(ev.Summary), maxTitleRunes)
+	if title == "" {
+		title = "(untitled event)"
+	}
+	return quote0.TextRequest{
+		RefreshNow: quote0.Bool(true),
+		Title:      title,
+		Message:    strings.Join(lines, "\n"),
+	}, nil
+}
+
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
+
+func firstLine(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[:i]
+	}
+	return strings.TrimSpace(s)
+}
+
+func clip(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return strings.TrimSpace(string(r[:n-1])) + "…"
+}
@@ -0,0 +1,264 @@
+package agenda
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/1set/quote0"
+)
+
+// googleExport is trimmed from a Google Calendar export: CRLF endings, a VTIMEZONE block,
+// a folded DESCRIPTION, and escaped commas in LOCATION.
+const googleExport = "BEGIN:VCALENDAR\r\n" +
+	"PRODID:-//Google Inc//Google Calendar 70.9054//EN\r\n" +
+	"VERSION:2.0\r\n" +
+	"BEGIN:VTIMEZONE\r\n" +
+	"TZID:Europe/Berlin\r\n" +
+	"BEGIN:STANDARD\r\n" +
+	"DTSTART:19701025T030000\r\n" +
+	"END:STANDARD\r\n" +
+	"END:VTIMEZONE\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"DTSTART;TZID=Europe/Berlin:20251110T093000\r\n" +
+	"DTEND;TZID=Europe/Berlin:20251110T100000\r\n" +
+	"UID:abc123@google.com\r\n" +
+	"DESCRIPTION:Agenda:\\n1. status\\n2. risks and a very long line that Google\r\n" +
+	"  folds at seventy-five octets\r\n" +
+	"LOCATION:Room 4\\, Building B\\; 2nd floor\r\n" +
+	"SUMMARY:Weekly sync\r\n" +
+	"BEGIN:VALARM\r\n" +
+	"ACTION:DISPLAY\r\n" +
+	"END:VALARM\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func parse(t *testing.T, doc string) []Event {
+	t.Helper()
+	events, err := ParseICS(strings.NewReader(doc), time.UTC)
+	if err != nil {
+		t.Fatalf("ParseICS: %v", err)
+	}
+	return events
+}
+
+func TestParseICS(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("tzdata unavailable:", err)
+	}
+	cases := []struct {
+		name string
+		doc  string
+		want Event
+	}{
+		{
+			name: "google export",
+			doc:  googleExport,
+			want: Event{
+				UID:      "abc123@google.com",
+				Summary:  "Weekly sync",
+				Location: "Room 4, Building B; 2nd floor",
+				Start:    time.Date(2025, 11, 10, 9, 30, 0, 0, berlin),
+				End:      time.Date(2025, 11, 10, 10, 0, 0, 0, berlin),
+			},
+		},
+		{
+			name: "utc with duration and LF endings",
+			doc:  "BEGIN:VEVENT\nSUMMARY:Standup\nDTSTART:20251110T083000Z\nDURATION:PT15M\nEND:VEVENT\n",
+			want: Event{
+				Summary: "Standup",
+				Start:   time.Date(2025, 11, 10, 8, 30, 0, 0, time.UTC),
+				End:     time.Date(2025, 11, 10, 8, 45, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "folded summary with tab continuation",
+			doc:  "BEGIN:VEVENT\r\nSUMMARY:Quarterly plan\r\n\tning review\r\nDTSTART:20251110T120000Z\r\nEND:VEVENT\r\n",
+			want: Event{
+				Summary: "Quarterly planning review",
+				Start:   time.Date(2025, 11, 10, 12, 0, 0, 0, time.UTC),
+				End:     time.Date(2025, 11, 10, 12, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "all-day date",
+			doc:  "BEGIN:VEVENT\nSUMMARY:Offsite\nDTSTART;VALUE=DATE:20251111\nEND:VEVENT\n",
+			want: Event{
+				Summary: "Offsite",
+				Start:   time.Date(2025, 11, 11, 0, 0, 0, 0, time.UTC),
+				End:     time.Date(2025, 11, 12, 0, 0, 0, 0, time.UTC),
+				AllDay:  true,
+			},
+		},
+		{
+			name: "quoted parameter and floating time",
+			doc:  "BEGIN:VEVENT\nSUMMARY;LANGUAGE=en:Lunch\nLOCATION;ALTREP=\"http://x/y:z\":Cafe\nDTSTART:20251110T123000\nDTEND:20251110T133000\nEND:VEVENT\n",
+			want: Event{
+				Summary:  "Lunch",
+				Location: "Cafe",
+				Start:    time.Date(2025, 11, 10, 12, 30, 0, 0, time.UTC),
+				End:      time.Date(2025, 11, 10, 13, 30, 0, 0, time.UTC),
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			events := parse(t, tc.doc)
+			if len(events) != 1 {
+				t.Fatalf("events=%d", len(events))
+			}
+			got := events[0]
+			if got.UID != tc.want.UID || got.Summary != tc.want.Summary || got.Location != tc.want.Location || got.AllDay != tc.want.AllDay {
+				t.Fatalf("got %+v\nwant %+v", got, tc.want)
+			}
+			if !got.Start.Equal(tc.want.Start) || !got.End.Equal(tc.want.End) {
+				t.Fatalf("times %v–%v, want %v–%v", got.Start, got.End, tc.want.Start, tc.want.End)
+			}
+		})
+	}
+}
+
+func TestParseICS_Errors(t *testing.T) {
+	if _, err := ParseICS(strings.NewReader("BEGIN:VCALENDAR\nEND:VCALENDAR\n"), nil); err != ErrNoEvents {
+		t.Fatalf("want ErrNoEvents, got %v", err)
+	}
+	if _, err := ParseICS(strings.NewReader("BEGIN:VEVENT\nSUMMARY:x\nEND:VEVENT\n"), nil); err == nil {
+		t.Fatal("missing DTSTART should fail")
+	}
+	if _, err := ParseICS(strings.NewReader("BEGIN:VEVENT\nDTSTART:2025-11-10\nEND:VEVENT\n"), nil); err == nil {
+		t.Fatal("malformed DTSTART should fail")
+	}
+}
+
+func TestOccurrences_Recurrence(t *testing.T) {
+	doc := "BEGIN:VEVENT\nSUMMARY:Standup\nDTSTART:20251103T090000Z\nDTEND:20251103T091500Z\n" +
+		"RRULE:FREQ=DAILY;COUNT=5\nEXDATE:20251105T090000Z\nEND:VEVENT\n" +
+		"BEGIN:VEVENT\nSUMMARY:1:1\nDTSTART:20251104T140000Z\nDTEND:20251104T143000Z\n" +
+		"RRULE:FREQ=WEEKLY;INTERVAL=2;UNTIL=20251220T000000Z\nEND:VEVENT\n" +
+		"BEGIN:VEVENT\nSUMMARY:Gym\nDTSTART:20251103T180000Z\nRRULE:FREQ=WEEKLY;BYDAY=MO,WE\nEND:VEVENT\n"
+	events := parse(t, doc)
+	if events[2].Repeat != nil {
+		t.Fatal("BY* rules are not expanded")
+	}
+	from := time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC)
+	occ := Occurrences(events, from, from.AddDate(0, 2, 0))
+	var got []string
+	for _, ev := range occ {
+		got = append(got, ev.Start.Format("01-02 ")+ev.Summary)
+	}
+	want := "11-03 Standup,11-03 Gym,11-04 Standup,11-04 1:1,11-06 Standup,11-07 Standup,11-18 1:1,12-02 1:1,12-16 1:1"
+	if strings.Join(got, ",") != want {
+		t.Fatalf("occurrences:\n got %s\nwant %s", strings.Join(got, ","), want)
+	}
+}
+
+func TestBuildNextEvent(t *testing.T) {
+	events := parse(t, "BEGIN:VEVENT\nSUMMARY:Design review for the new onboarding flow\nLOCATION:Room 4\nDTSTART:20251110T100000Z\nDTEND:20251110T110000Z\nEND:VEVENT\n"+
+		"BEGIN:VEVENT\nSUMMARY:Holiday\nDTSTART;VALUE=DATE:20251111\nEND:VEVENT\n")
+	cases := []struct {
+		now          time.Time
+		title, msg   string
+		wantNoneLeft bool
+	}{
+		{now: time.Date(2025, 11, 10, 8, 0, 0, 0, time.UTC), title: "Design review for the new o…", msg: "Next: Mon 11-10 10:00–11:00\n@ Room 4"},
+		{now: time.Date(2025, 11, 10, 10, 30, 0, 0, time.UTC), title: "Design review for the new o…", msg: "Now: Mon 11-10 10:00–11:00\n@ Room 4"},
+		{now: time.Date(2025, 11, 10, 11, 0, 0, 0, time.UTC), title: "Holiday", msg: "Next: Tue 11-11 all day"},
+		{now: time.Date(2025, 11, 12, 0, 0, 0, 0, time.UTC), wantNoneLeft: true},
+	}
+	for _, tc := range cases {
+		req, err := BuildNextEvent(events, tc.now)
+		if tc.wantNoneLeft {
+			if err != ErrNoUpcomingEvent {
+				t.Fatalf("%v: want ErrNoUpcomingEvent, got %v", tc.now, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%v: %v", tc.now, err)
+		}
+		if req.Title != tc.title || req.Message != tc.msg {
+			t.Fatalf("%v: got %q / %q", tc.now, req.Title, req.Message)
+		}
+	}
+}
+
+func TestNextWake(t *testing.T) {
+	events := parse(t, "BEGIN:VEVENT\nSUMMARY:a\nDTSTART:20251110T100000Z\nDTEND:20251110T110000Z\nEND:VEVENT\n")
+	base := time.Date(2025, 11, 10, 0, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		now  string
+		want time.Duration
+	}{
+		{"09:00", 55 * time.Minute},
+		{"09:55", 5 * time.Minute},
+		{"10:00", time.Hour},
+		{"10:30", 30 * time.Minute},
+	} {
+		at, _ := time.Parse("15:04", tc.now)
+		now := base.Add(time.Duration(at.Hour())*time.Hour + time.Duration(at.Minute())*time.Minute)
+		if d, ok := nextWake(events, now, 5*time.Minute); !ok || d != tc.want {
+			t.Errorf("%s: wake in %v, want %v", tc.now, d, tc.want)
+		}
+	}
+	if _, ok := nextWake(events, base.Add(12*time.Hour), 5*time.Minute); ok {
+		t.Error("no wake expected after the last event")
+	}
+}
+
+func TestRunAgenda_SendsOnChangeOnly(t *testing.T) {
+	var sent []quote0.TextRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req quote0.TextRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		sent = append(sent, req)
+		_, _ = io.WriteString(w, `{"code":0}`)
+	}))
+	defer srv.Close()
+	client, err := quote0.NewClient("test", quote0.WithBaseURL(srv.URL), quote0.WithRateLimiter(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := parse(t, "BEGIN:VEVENT\nSUMMARY:Sync\nDTSTART:20251110T100000Z\nDTEND:20251110T103000Z\nEND:VEVENT\n")
+
+	now := time.Date(2025, 11, 10, 9, 0, 0, 0, time.UTC)
+	var waits []time.Duration
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	fake := func(r *runner) {
+		r.now = func() time.Time { return now }
+		r.after = func(d time.Duration) <-chan time.Time {
+			waits = append(waits, d)
+			now = now.Add(d)
+			if len(waits) == 5 {
+				cancel()
+				return nil
+			}
+			ch := make(chan time.Time, 1)
+			ch <- now
+			return ch
+		}
+	}
+	fetch := func(context.Context) ([]Event, error) { return events, nil }
+	err = RunAgenda(ctx, client, fetch, WithDeviceID("D"), WithLocation(time.UTC), fake)
+	if err != context.Canceled {
+		t.Fatalf("RunAgenda returned %v", err)
+	}
+
+	// 09:00 -> 09:15 (refresh cap) -> 09:30 -> 09:45 -> 09:55 (lead) -> 10:00 (start)
+	want := []time.Duration{15 * time.Minute, 15 * time.Minute, 15 * time.Minute, 10 * time.Minute, 5 * time.Minute}
+	for i := range want {
+		if waits[i] != want[i] {
+			t.Fatalf("waits=%v, want %v", waits, want)
+		}
+	}
+	// "Next" is sent once; the later wake-ups before the start produce the same screen.
+	if len(sent) != 1 || sent[0].DeviceID != "D" || sent[0].Message != "Next: Mon 11-10 10:00–10:30" {
+		t.Fatalf("sent=%+v", sent)
+	}
+}
@@ -0,0 +1,305 @@
+// Package agenda shows the next calendar event on a Quote/0 display.
+//
+// ParseICS is a deliberately small iCalendar reader: it understands VEVENT blocks with
+// DTSTART/DTEND (UTC, TZID, floating, and all-day DATE forms), DURATION, SUMMARY, LOCATION,
+// EXDATE, and simple RRULEs (FREQ=DAILY or WEEKLY with INTERVAL, COUNT, or UNTIL). Rules
+// that use BY* parts or other frequencies are not expanded; only their first instance is
+// kept. BuildNextEvent formats the upcoming event and RunAgenda keeps a device up to date.
+package agenda
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequency is a supported RRULE frequency.
+type Frequency string
+
+const (
+	// Daily repeats every INTERVAL days.
+	Daily Frequency = "DAILY"
+	// Weekly repeats every INTERVAL weeks on the start weekday.
+	Weekly Frequency = "WEEKLY"
+)
+
+// Recurrence is the subset of RRULE that ParseICS expands.
+type Recurrence struct {
+	Freq     Frequency
+	Interval int
+	// Count limits the number of instances (0 means unlimited).
+	Count int
+	// Until is the last allowed start time (zero means unlimited).
+	Until time.Time
+}
+
+// Event is one VEVENT. Recurring events keep their rule and exclusions; use Occurrences
+// to expand them into concrete instances.
+type Event struct {
+	UID      string
+	Summary  string
+	Location string
+	Start    time.Time
+	End      time.Time
+	// AllDay is set when DTSTART is a DATE value.
+	AllDay bool
+	// Repeat is non-nil for events with a supported RRULE.
+	Repeat *Recurrence
+	// Exclude lists instance start times removed by EXDATE.
+	Exclude []time.Time
+}
+
+// ErrNoEvents indicates the calendar contained no VEVENT blocks.
+var ErrNoEvents = errors.New("agenda: calendar has no events")
+
+// ParseICS reads the VEVENTs of an iCalendar document. Folded lines, CRLF or LF line endings,
+// and escaped text (\, \; \n \\) are handled. Timezones named by TZID are resolved with
+// time.LoadLocation; unknown zones fall back to loc (floating times use loc as well).
+func ParseICS(r io.Reader, loc *time.Location) ([]Event, error) {
+	if loc == nil {
+		loc = time.Local
+	}
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		events []Event
+		cur    *Event
+		dur    time.Duration
+	)
+	for i, line := range lines {
+		name, params, value, ok := splitProperty(line)
+		if !ok {
+			continue
+		}
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VEVENT"):
+			cur, dur = &Event{}, 0
+		case name == "END" && strings.EqualFold(value, "VEVENT") && cur != nil:
+			if cur.Start.IsZero() {
+				return nil, fmt.Errorf("agenda: line %d: event %q has no DTSTART", i+1, cur.Summary)
+			}
+			finishEvent(cur, dur)
+			events = append(events, *cur)
+			cur = nil
+		case cur == nil:
+			// Properties outside VEVENT (calendar headers, VTIMEZONE, VALARM) are ignored.
+		case name == "UID":
+			cur.UID = value
+		case name == "SUMMARY":
+			cur.Summary = unescapeText(value)
+		case name == "LOCATION":
+			cur.Location = unescapeText(value)
+		case name == "DTSTART":
+			t, allDay, err := parseDateTime(value, params, loc)
+			if err != nil {
+				return nil, fmt.Errorf("agenda: line %d: DTSTART: %w", i+1, err)
+			}
+			cur.Start, cur.AllDay = t, allDay
+		case name == "DTEND":
+			t, _, err := parseDateTime(value, params, loc)
+			if err != nil {
+				return nil, fmt.Errorf("agenda: line %d: DTEND: %w", i+1, err)
+			}
+			cur.End = t
+		case name == "DURATION":
+			d, err := parseDuration(value)
+			if err != nil {
+				return nil, fmt.Errorf("agenda: line %d: DURATION: %w", i+1, err)
+			}
+			dur = d
+		case name == "RRULE":
+			cur.Repeat = parseRRule(value, loc)
+		case name == "EXDATE":
+			for _, v := range strings.Split(value, ",") {
+				if t, _, err := parseDateTime(v, params, loc); err == nil {
+					cur.Exclude = append(cur.Exclude, t)
+				}
+			}
+		}
+	}
+	if len(events) == 0 {
+		return nil, ErrNoEvents
+	}
+	return events, nil
+}
+
+// finishEvent fills End from DURATION or the iCalendar defaults (one day for all-day events,
+// zero length otherwise).
+func finishEvent(ev *Event, dur time.Duration) {
+	if !ev.End.IsZero() {
+		return
+	}
+	switch {
+	case dur > 0:
+		ev.End = ev.Start.Add(dur)
+	case ev.AllDay:
+		ev.End = ev.Start.AddDate(0, 0, 1)
+	default:
+		ev.End = ev.Start
+	}
+}
+
+// unfold joins continuation lines (those starting with a space or tab) and drops CRs.
+func unfold(r io.Reader) ([]string, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1<<20)
+	var lines []string
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("agenda: read calendar: %w", err)
+	}
+	return lines, nil
+}
+
+// splitProperty splits "NAME;P1=V1;P2=\"a:b\":VALUE" into its parts. Parameter values may
+// be quoted and contain ':' or ';'.
+func splitProperty(line string) (string, map[string]string, string, bool) {
+	inQuote := false
+	colon := -1
+	for i, r := range line {
+		if r == '"' {
+			inQuote = !inQuote
+		} else if r == ':' && !inQuote {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return "", nil, "", false
+	}
+	head, value := line[:colon], line[colon+1:]
+	parts := splitUnquoted(head, ';')
+	params := make(map[string]string, len(parts)-1)
+	for _, p := range parts[1:] {
+		if k, v, ok := strings.Cut(p, "="); ok {
+			params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+		}
+	}
+	return strings.ToUpper(parts[0]), params, value, true
+}
+
+func splitUnquoted(s string, sep rune) []string {
+	var out []string
+	inQuote := false
+	start := 0
+	for i, r := range s {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+		case r == sep && !inQuote:
+			out = append(out, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(out, s[start:])
+}
+
+var textUnescaper = strings.NewReplacer(`\\`, `\`, `\,`, `,`, `\;`, `;`, `\n`, "\n", `\N`, "\n")
+
+func unescapeText(s string) string {
+	return strings.TrimSpace(textUnescaper.Replace(s))
+}
+
+// parseDateTime handles DATE (all-day), UTC ("...Z"), TZID-qualified, and floating values.
+func parseDateTime(value string, params map[string]string, loc *time.Location) (time.Time, bool, error) {
+	value = strings.TrimSpace(value)
+	if strings.EqualFold(params["VALUE"], "DATE") || len(value) == 8 {
+		t, err := time.ParseInLocation("20060102", value, loc)
+		return t, true, err
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse("20060102T150405Z", value)
+		return t, false, err
+	}
+	zone := loc
+	if tzid := params["TZID"]; tzid != "" {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			zone = l
+		}
+	}
+	t, err := time.ParseInLocation("20060102T150405", value, zone)
+	return t, false, err
+}
+
+// parseDuration understands the common RFC 5545 forms such as PT1H30M, P1D, and P1W.
+func parseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(s), "+"))
+	if !strings.HasPrefix(s, "P") {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	var total time.Duration
+	inTime := false
+	num := ""
+	for _, r := range s[1:] {
+		switch {
+		case r == 'T':
+			inTime = true
+		case r >= '0' && r <= '9':
+			num += string(r)
+		default:
+			n, err := strconv.Atoi(num)
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration %q", s)
+			}
+			num = ""
+			unit := map[rune]time.Duration{'W': 7 * 24 * time.Hour, 'D': 24 * time.Hour, 'H': time.Hour, 'S': time.Second}[r]
+			if r == 'M' {
+				if !inTime {
+					return 0, fmt.Errorf("month durations are not supported: %q", s)
+				}
+				unit = time.Minute
+			}
+			if unit == 0 {
+				return 0, fmt.Errorf("invalid duration %q", s)
+			}
+			total += time.Duration(n) * unit
+		}
+	}
+	return total, nil
+}
+
+// parseRRule returns nil for rules outside the supported subset.
+func parseRRule(value string, loc *time.Location) *Recurrence {
+	rule := &Recurrence{Interval: 1}
+	for _, part := range strings.Split(value, ";") {
+		k, v, _ := strings.Cut(part, "=")
+		switch strings.ToUpper(k) {
+		case "FREQ":
+			rule.Freq = Frequency(strings.ToUpper(v))
+		case "INTERVAL":
+			if n, err := strconv.Atoi(v); err == nil && n > 0 {
+				rule.Interval = n
+			}
+		case "COUNT":
+			if n, err := strconv.Atoi(v); err == nil && n > 0 {
+				rule.Count = n
+			}
+		case "UNTIL":
+			if t, _, err := parseDateTime(v, nil, loc); err == nil {
+				rule.Until = t
+			}
+		case "WKST":
+		default:
+			return nil // BY* parts and anything else are out of scope.
+		}
+	}
+	if rule.Freq != Daily && rule.Freq != Weekly {
+		return nil
+	}
+	return rule
+}
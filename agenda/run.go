@@ -0,0 +1,148 @@
+package agenda
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/1set/quote0"
+)
+
+const (
+	defaultLead    = 5 * time.Minute
+	defaultRefresh = 15 * time.Minute
+)
+
+// Option configures RunAgenda.
+type Option func(*runner)
+
+// WithDeviceID targets a specific device instead of the client's default.
+func WithDeviceID(deviceID string) Option {
+	return func(r *runner) { r.deviceID = deviceID }
+}
+
+// WithLead sets how long before an event starts the screen is refreshed (default 5 minutes),
+// so late calendar changes are picked up before the meeting.
+func WithLead(d time.Duration) Option {
+	return func(r *runner) {
+		if d >= 0 {
+			r.lead = d
+		}
+	}
+}
+
+// WithRefreshInterval caps the time between calendar fetches (default 15 minutes).
+func WithRefreshInterval(d time.Duration) Option {
+	return func(r *runner) {
+		if d > 0 {
+			r.refresh = d
+		}
+	}
+}
+
+// WithLocation renders event times in loc (default time.Local).
+func WithLocation(loc *time.Location) Option {
+	return func(r *runner) {
+		if loc != nil {
+			r.loc = loc
+		}
+	}
+}
+
+// WithErrorHandler receives fetch and send errors, which otherwise do not stop RunAgenda.
+func WithErrorHandler(fn func(error)) Option {
+	return func(r *runner) { r.onError = fn }
+}
+
+type runner struct {
+	deviceID string
+	lead     time.Duration
+	refresh  time.Duration
+	loc      *time.Location
+	onError  func(error)
+
+	// now and after are replaced in tests.
+	now   func() time.Time
+	after func(time.Duration) <-chan time.Time
+}
+
+// RunAgenda keeps a device showing the current or next event until ctx ends, returning ctx.Err().
+// fetch is called on every wake-up; wake-ups happen shortly before each event starts, when it
+// starts, and when it ends, and at least every refresh interval. The screen is only sent when
+// its content changes, and nothing is sent while the calendar has no upcoming events.
+func RunAgenda(ctx context.Context, client *quote0.Client, fetch func(context.Context) ([]Event, error), opts ...Option) error {
+	r := &runner{
+		lead:    defaultLead,
+		refresh: defaultRefresh,
+		loc:     time.Local,
+		now:     time.Now,
+		after:   time.After,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(r)
+		}
+	}
+
+	var last *quote0.TextRequest
+	for {
+		now := r.now().In(r.loc)
+		wait := r.refresh
+		events, err := fetch(ctx)
+		if err != nil {
+			r.report(err)
+		} else {
+			if d, ok := nextWake(events, now, r.lead); ok && d < wait {
+				wait = d
+			}
+			req, err := BuildNextEvent(events, now)
+			switch {
+			case errors.Is(err, ErrNoUpcomingEvent):
+			case err != nil:
+				r.report(err)
+			case last == nil || !sameScreen(*last, req):
+				req.DeviceID = r.deviceID
+				if _, err := client.SendText(ctx, req); err != nil {
+					r.report(err)
+				} else {
+					last = &req
+				}
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-r.after(wait):
+		}
+	}
+}
+
+func (r *runner) report(err error) {
+	if r.onError != nil {
+		r.onError(err)
+	}
+}
+
+func sameScreen(a, b quote0.TextRequest) bool {
+	return a.Title == b.Title && a.Message == b.Message
+}
+
+// nextWake returns the time until the next event boundary after now: start minus lead,
+// start, or end.
+func nextWake(events []Event, now time.Time, lead time.Duration) (time.Duration, bool) {
+	var best time.Time
+	for _, ev := range Occurrences(events, now, now.Add(horizon)) {
+		for _, t := range []time.Time{ev.Start.Add(-lead), ev.Start, ev.End} {
+			if t.After(now) && (best.IsZero() || t.Before(best)) {
+				best = t
+			}
+		}
+		if !best.IsZero() && ev.Start.Add(-lead).After(best) {
+			break // occurrences are sorted by start; later ones cannot be earlier
+		}
+	}
+	if best.IsZero() {
+		return 0, false
+	}
+	return best.Sub(now), true
+}
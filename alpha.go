@@ -0,0 +1,40 @@
+package quote0
+
+import (
+	"image"
+	"image/color"
+)
+
+// WithAlphaBackground sets the color transparent pixels are composited over before anything
+// else runs. By default they take the WithBackground color (White unless set), so transparent
+// areas of a logo or sticker match the padding around it and, like it, are flipped by
+// WithInvert: a dark-theme image made with WithInvert shows them black. Pass Black to keep
+// them black without inverting, e.g. next to a BorderBlack border.
+func WithAlphaBackground(col color.Gray) ProcessOption {
+	return func(cfg *processConfig) { cfg.matte, cfg.matteSet = col, true }
+}
+
+// flattenAlpha composites src over bg and returns it as gray. Images that report themselves
+// opaque are returned unchanged.
+//
+// Color.RGBA is alpha-premultiplied, so each channel over bg is c + bg*(1-a); without this,
+// transparent pixels convert as black whatever their stored color.
+func flattenAlpha(src image.Image, bg color.Gray) image.Image {
+	if o, ok := src.(interface{ Opaque() bool }); ok && o.Opaque() {
+		return src
+	}
+	b := src.Bounds()
+	out := image.NewGray(b)
+	back := uint32(bg.Y) * 0x101
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		row := out.Pix[out.PixOffset(b.Min.X, y):]
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r, g, bl, a := src.At(x, y).RGBA()
+			under := back * (0xffff - a) / 0xffff
+			row[x-b.Min.X] = color.GrayModel.Convert(color.RGBA64{
+				R: uint16(r + under), G: uint16(g + under), B: uint16(bl + under), A: 0xffff,
+			}).(color.Gray).Y
+		}
+	}
+	return out
+}
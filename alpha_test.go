@@ -0,0 +1,61 @@
+package quote0
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// alphaFixture is a 296x152 NRGBA image in four bands: fully transparent red (the color a
+// naive conversion would keep), half-transparent black, half-transparent white, and opaque
+// mid-gray.
+func alphaFixture() *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, ScreenWidth, ScreenHeight))
+	bands := []color.NRGBA{{255, 0, 0, 0}, {0, 0, 0, 128}, {255, 255, 255, 128}, {100, 100, 100, 255}}
+	for y := 0; y < ScreenHeight; y++ {
+		for x := 0; x < ScreenWidth; x++ {
+			img.SetNRGBA(x, y, bands[x*len(bands)/ScreenWidth])
+		}
+	}
+	return img
+}
+
+func TestProcessImage_FlattensAlpha(t *testing.T) {
+	bandX := []int{10, 84, 158, 232}
+	for _, tt := range []struct {
+		name string
+		opts []ProcessOption
+		want [4]uint8
+	}{
+		{"default white", nil, [4]uint8{255, 127, 255, 100}},
+		{"follows background", []ProcessOption{WithBackground(Black)}, [4]uint8{0, 0, 128, 100}},
+		{"explicit black", []ProcessOption{WithAlphaBackground(Black)}, [4]uint8{0, 0, 128, 100}},
+		{"explicit over background", []ProcessOption{WithBackground(Black), WithAlphaBackground(White)}, [4]uint8{255, 127, 255, 100}},
+		{"inverted dark theme", []ProcessOption{WithInvert()}, [4]uint8{0, 128, 0, 155}},
+		// Turned upside down, the bands run in reverse.
+		{"fitted", []ProcessOption{WithFit(FitStretch), WithRotation(180), WithAlphaBackground(color.Gray{Y: 200})}, [4]uint8{100, 228, 99, 200}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := ProcessImage(alphaFixture(), tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, x := range bandX {
+				if got := img.GrayAt(x, 70).Y; got != tt.want[i] {
+					t.Errorf("band %d: got %d, want %d", i, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestFlattenAlpha_OpaqueUnchanged(t *testing.T) {
+	src := noise(20, 10)
+	if flattenAlpha(src, Black) != image.Image(src) {
+		t.Fatal("opaque gray image was copied")
+	}
+	icon := image.NewNRGBA(image.Rect(0, 0, 80, 80))
+	if got := FitIcon(icon).GrayAt(20, 20).Y; got != 255 {
+		t.Fatalf("transparent icon pixel %d, want white", got)
+	}
+}
@@ -0,0 +1,238 @@
+package quote0
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrBatchItemInvalid indicates a batch item sets neither or both of Text and Image.
+	ErrBatchItemInvalid = errors.New("quote0: batch item must set exactly one of Text or Image")
+	// ErrBatchAborted is reported for items skipped after a failure under WithStopOnError.
+	ErrBatchAborted = errors.New("quote0: batch aborted after an earlier item failed")
+)
+
+// BatchItem is one send in a batch. Exactly one of Text or Image must be set.
+// Items with an empty DeviceID target the client's default device.
+type BatchItem struct {
+	// Text sends the request through SendText when non-nil.
+	Text *TextRequest
+	// Image sends the request through SendImage when non-nil.
+	Image *ImageRequest
+	// Delay is waited before sending the item, after the previous item for the same device.
+	Delay time.Duration
+}
+
+// BatchResult reports the outcome of one batch item. Results are returned in the same
+// order as the input items regardless of the order in which they completed.
+type BatchResult struct {
+	// Index is the position of the item in the input slice.
+	Index int
+	// DeviceID is the resolved target device (empty if resolution failed).
+	DeviceID string
+	// Response is the API response on success.
+	Response *APIResponse
+	// Err is the failure for this item, if any.
+	Err error
+}
+
+// BatchError summarizes the failed items of a batch.
+type BatchError struct {
+	// Failed lists the results whose Err is non-nil, in input order.
+	Failed []BatchResult
+	// Total is the number of items in the batch.
+	Total int
+}
+
+func (e *BatchError) Error() string {
+	b := strings.Builder{}
+	b.WriteString("quote0: ")
+	b.WriteString(strconv.Itoa(len(e.Failed)))
+	b.WriteString(" of ")
+	b.WriteString(strconv.Itoa(e.Total))
+	b.WriteString(" batch items failed")
+	if len(e.Failed) > 0 {
+		first := e.Failed[0]
+		b.WriteString(" (first: item ")
+		b.WriteString(strconv.Itoa(first.Index))
+		if first.DeviceID != "" {
+			b.WriteString(" device ")
+			b.WriteString(first.DeviceID)
+		}
+		b.WriteString(": ")
+		b.WriteString(first.Err.Error())
+		b.WriteString(")")
+	}
+	return b.String()
+}
+
+// BatchOption tunes how SendBatch and the Broadcast helpers schedule sends.
+type BatchOption func(*batchConfig)
+
+type batchConfig struct {
+	concurrency int
+	stopOnError bool
+}
+
+// WithConcurrency runs at most n sends in flight at once (default 1, i.e. sequential).
+// Items targeting the same device are always sent in input order by a single worker,
+// so concurrency only applies across distinct devices. The client's rate limiter still
+// gates every call; with the default shared 1 QPS limiter, workers simply queue on it.
+func WithConcurrency(n int) BatchOption {
+	return func(cfg *batchConfig) {
+		if n > 0 {
+			cfg.concurrency = n
+		}
+	}
+}
+
+// WithStopOnError stops sending after the first failed send; items not yet started report
+// ErrBatchAborted. Items already in flight on other devices still complete, and items
+// rejected before sending (see ErrBatchItemInvalid) do not trigger the stop.
+func WithStopOnError() BatchOption {
+	return func(cfg *batchConfig) { cfg.stopOnError = true }
+}
+
+// deviceQueue holds the indexes of items targeting one device, in input order.
+type deviceQueue struct {
+	deviceID string
+	indexes  []int
+}
+
+// SendBatch sends every item and returns one result per item in input order.
+// The returned error is nil when all items succeeded, ctx.Err() when the context ended
+// before the batch completed, and a *BatchError otherwise. Items not yet started when the
+// context ends are not sent and report the context error.
+func (c *Client) SendBatch(ctx context.Context, items []BatchItem, opts ...BatchOption) ([]BatchResult, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	cfg := batchConfig{concurrency: 1}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&cfg)
+		}
+	}
+
+	results := make([]BatchResult, len(items))
+	queues := make([]*deviceQueue, 0, len(items))
+	byDevice := make(map[string]*deviceQueue)
+	for i, item := range items {
+		results[i].Index = i
+		did, err := c.batchDeviceID(ctx, item)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		results[i].DeviceID = did
+		q, ok := byDevice[did]
+		if !ok {
+			q = &deviceQueue{deviceID: did}
+			byDevice[did] = q
+			queues = append(queues, q)
+		}
+		q.indexes = append(q.indexes, i)
+	}
+
+	workers := cfg.concurrency
+	if workers > len(queues) {
+		workers = len(queues)
+	}
+	var aborted int32
+	work := make(chan *deviceQueue)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for q := range work {
+				for _, idx := range q.indexes {
+					// Each worker writes only to the results of its own device queue.
+					if atomic.LoadInt32(&aborted) == 1 {
+						results[idx].Err = ErrBatchAborted
+						continue
+					}
+					if err := c.clock.Sleep(ctx, items[idx].Delay); err != nil {
+						results[idx].Err = err
+						continue
+					}
+					results[idx].Response, results[idx].Err = c.sendBatchItem(ctx, items[idx], q.deviceID)
+					if results[idx].Err != nil && cfg.stopOnError {
+						atomic.StoreInt32(&aborted, 1)
+					}
+				}
+			}
+		}()
+	}
+	for _, q := range queues {
+		work <- q
+	}
+	close(work)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return results, err
+	}
+	var failed []BatchResult
+	for _, r := range results {
+		if r.Err != nil {
+			failed = append(failed, r)
+		}
+	}
+	if len(failed) > 0 {
+		return results, &BatchError{Failed: failed, Total: len(items)}
+	}
+	return results, nil
+}
+
+// BroadcastText sends the same text request to every device in deviceIDs.
+// Results are returned in the order of deviceIDs.
+func (c *Client) BroadcastText(ctx context.Context, deviceIDs []string, payload TextRequest, opts ...BatchOption) ([]BatchResult, error) {
+	items := make([]BatchItem, len(deviceIDs))
+	for i, id := range deviceIDs {
+		req := payload
+		req.DeviceID = id
+		items[i] = BatchItem{Text: &req}
+	}
+	return c.SendBatch(ctx, items, opts...)
+}
+
+// BroadcastImage sends the same image request to every device in deviceIDs.
+// Image data is encoded once and shared across devices.
+// Results are returned in the order of deviceIDs.
+func (c *Client) BroadcastImage(ctx context.Context, deviceIDs []string, payload ImageRequest, opts ...BatchOption) ([]BatchResult, error) {
+	if err := payload.normalizeImage(); err != nil {
+		return nil, err
+	}
+	items := make([]BatchItem, len(deviceIDs))
+	for i, id := range deviceIDs {
+		req := payload
+		req.DeviceID = id
+		items[i] = BatchItem{Image: &req}
+	}
+	return c.SendBatch(ctx, items, opts...)
+}
+
+// batchDeviceID validates the item shape and resolves its target device.
+func (c *Client) batchDeviceID(ctx context.Context, item BatchItem) (string, error) {
+	switch {
+	case item.Text != nil && item.Image == nil:
+		return c.resolveDeviceID(ctx, item.Text.DeviceID)
+	case item.Image != nil && item.Text == nil:
+		return c.resolveDeviceID(ctx, item.Image.DeviceID)
+	default:
+		return "", ErrBatchItemInvalid
+	}
+}
+
+func (c *Client) sendBatchItem(ctx context.Context, item BatchItem, deviceID string) (*APIResponse, error) {
+	if item.Text != nil {
+		return c.SendTextToDevice(ctx, deviceID, *item.Text)
+	}
+	return c.SendImageToDevice(ctx, deviceID, *item.Image)
+}
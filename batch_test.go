@@ -0,0 +1,245 @@
+package quote0
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// countingServer records the peak number of concurrent requests and the order of
+// messages received per device.
+type countingServer struct {
+	mu       sync.Mutex
+	inFlight int
+	peak     int
+	total    int
+	byDevice map[string][]string
+	delay    time.Duration
+}
+
+func (s *countingServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req TextRequest
+	_ = json.NewDecoder(r.Body).Decode(&req)
+	s.mu.Lock()
+	s.inFlight++
+	s.total++
+	if s.inFlight > s.peak {
+		s.peak = s.inFlight
+	}
+	s.byDevice[req.DeviceID] = append(s.byDevice[req.DeviceID], req.Message)
+	s.mu.Unlock()
+
+	time.Sleep(s.delay)
+
+	s.mu.Lock()
+	s.inFlight--
+	s.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = io.WriteString(w, `{"code":0,"message":"ok"}`)
+}
+
+func TestSendBatch_ConcurrencyCeiling(t *testing.T) {
+	cs := &countingServer{byDevice: map[string][]string{}, delay: 20 * time.Millisecond}
+	srv := httptest.NewServer(cs)
+	defer srv.Close()
+
+	c, err := NewClient("test", WithBaseURL(srv.URL), WithRateLimiter(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var items []BatchItem
+	for d := 0; d < 8; d++ {
+		for m := 0; m < 3; m++ {
+			items = append(items, BatchItem{Text: &TextRequest{
+				DeviceID: fmt.Sprintf("D%d", d),
+				Message:  fmt.Sprintf("m%d", m),
+			}})
+		}
+	}
+	results, err := c.SendBatch(context.Background(), items, WithConcurrency(3))
+	if err != nil {
+		t.Fatalf("SendBatch: %v", err)
+	}
+	if cs.peak > 3 {
+		t.Fatalf("peak in-flight=%d, want <= 3", cs.peak)
+	}
+	if cs.peak < 2 {
+		t.Fatalf("peak in-flight=%d, expected parallel sends", cs.peak)
+	}
+	if cs.total != len(items) {
+		t.Fatalf("server saw %d requests, want %d", cs.total, len(items))
+	}
+	for i, r := range results {
+		if r.Index != i || r.Err != nil || r.Response == nil {
+			t.Fatalf("result %d: %+v", i, r)
+		}
+		if want := items[i].Text.DeviceID; r.DeviceID != want {
+			t.Fatalf("result %d device=%s want %s", i, r.DeviceID, want)
+		}
+	}
+	for dev, msgs := range cs.byDevice {
+		if len(msgs) != 3 || msgs[0] != "m0" || msgs[1] != "m1" || msgs[2] != "m2" {
+			t.Fatalf("device %s order=%v", dev, msgs)
+		}
+	}
+}
+
+func TestSendBatch_ErrorsAndDefaultDevice(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req TextRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req.DeviceID == "BAD" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, "device not found")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"code":0}`)
+	}))
+	defer srv.Close()
+
+	c, err := NewClient("test", WithBaseURL(srv.URL), WithRateLimiter(nil), WithDefaultDeviceID("DEF"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := []BatchItem{
+		{Text: &TextRequest{Message: "a"}},
+		{Text: &TextRequest{DeviceID: "BAD"}},
+		{},
+		{Image: &ImageRequest{DeviceID: "IMG", Image: "aGVsbG8="}},
+	}
+	results, err := c.SendBatch(context.Background(), items, WithConcurrency(4))
+	var be *BatchError
+	if !errors.As(err, &be) {
+		t.Fatalf("want *BatchError, got %v", err)
+	}
+	if len(be.Failed) != 2 || be.Failed[0].Index != 1 || be.Failed[1].Index != 2 || be.Total != 4 {
+		t.Fatalf("unexpected batch error: %+v", be)
+	}
+	if results[0].DeviceID != "DEF" || results[0].Err != nil {
+		t.Fatalf("default device result: %+v", results[0])
+	}
+	if _, ok := results[1].Err.(*APIError); !ok {
+		t.Fatalf("want APIError, got %v", results[1].Err)
+	}
+	if results[2].Err != ErrBatchItemInvalid {
+		t.Fatalf("want ErrBatchItemInvalid, got %v", results[2].Err)
+	}
+	if results[3].Err != nil || results[3].DeviceID != "IMG" {
+		t.Fatalf("image result: %+v", results[3])
+	}
+}
+
+func TestSendBatch_StopOnErrorAndDelay(t *testing.T) {
+	var mu sync.Mutex
+	var sent []string
+	var times []time.Time
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req TextRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		mu.Lock()
+		sent = append(sent, req.Message)
+		times = append(times, time.Now())
+		mu.Unlock()
+		if req.Message == "fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = io.WriteString(w, `{"code":0}`)
+	}))
+	defer srv.Close()
+	c, err := NewClient("test", WithBaseURL(srv.URL), WithRateLimiter(nil), WithDefaultDeviceID("D"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := []BatchItem{
+		{Text: &TextRequest{Message: "a"}},
+		{Text: &TextRequest{Message: "b"}, Delay: 30 * time.Millisecond},
+		{Text: &TextRequest{Message: "fail"}},
+		{Text: &TextRequest{Message: "never"}},
+	}
+	results, err := c.SendBatch(context.Background(), items, WithStopOnError())
+	var be *BatchError
+	if !errors.As(err, &be) || len(be.Failed) != 2 {
+		t.Fatalf("err %v", err)
+	}
+	if results[3].Err != ErrBatchAborted {
+		t.Fatalf("item 3: %v", results[3].Err)
+	}
+	if fmt.Sprint(sent) != "[a b fail]" {
+		t.Fatalf("sent %v", sent)
+	}
+	if gap := times[1].Sub(times[0]); gap < 30*time.Millisecond {
+		t.Fatalf("delay not honored: %v", gap)
+	}
+}
+
+func TestSendBatch_ContextCancelDrains(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var mu sync.Mutex
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		cancel()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"code":0}`)
+	}))
+	defer srv.Close()
+
+	c, err := NewClient("test", WithBaseURL(srv.URL), WithRateLimiter(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := make([]BatchItem, 10)
+	for i := range items {
+		items[i] = BatchItem{Text: &TextRequest{DeviceID: "D"}}
+	}
+	results, err := c.SendBatch(ctx, items, WithConcurrency(2))
+	if err != context.Canceled {
+		t.Fatalf("want context.Canceled, got %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if calls != 1 {
+		t.Fatalf("server saw %d calls after cancel, want 1", calls)
+	}
+	for _, r := range results[1:] {
+		if r.Err != context.Canceled {
+			t.Fatalf("item %d: want context.Canceled, got %v", r.Index, r.Err)
+		}
+	}
+}
+
+func TestBroadcastText_OrderedResults(t *testing.T) {
+	cs := &countingServer{byDevice: map[string][]string{}}
+	srv := httptest.NewServer(cs)
+	defer srv.Close()
+
+	c, err := NewClient("test", WithBaseURL(srv.URL), WithRateLimiter(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	devices := []string{"A", "B", "C", "D"}
+	results, err := c.BroadcastText(context.Background(), devices, TextRequest{Message: "hi"}, WithConcurrency(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, r := range results {
+		if r.DeviceID != devices[i] || r.Err != nil {
+			t.Fatalf("result %d: %+v", i, r)
+		}
+	}
+	if len(cs.byDevice) != 4 {
+		t.Fatalf("devices reached: %v", cs.byDevice)
+	}
+}
@@ -0,0 +1,59 @@
+package quote0
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// DefaultMaxResponseBytes is the response body limit unless WithMaxResponseBytes changes it.
+const DefaultMaxResponseBytes = maxResponseBodySize
+
+// ErrResponseTooLarge matches, through errors.Is, the *ResponseTooLargeError returned when a
+// response body exceeds the WithMaxResponseBytes limit.
+var ErrResponseTooLarge = errors.New("quote0: response body too large")
+
+// ResponseTooLargeError reports a response body longer than the configured limit. The body
+// is not parsed, since a cut JSON document would only fail later with a confusing error.
+type ResponseTooLargeError struct {
+	// StatusCode is the HTTP status of the response.
+	StatusCode int
+	// Limit is the limit in bytes that was exceeded.
+	Limit int64
+	// Prefix holds the first Limit bytes of the body.
+	Prefix []byte
+}
+
+func (e *ResponseTooLargeError) Error() string {
+	return fmt.Sprintf("quote0: response body too large (status=%d): more than %d bytes", e.StatusCode, e.Limit)
+}
+
+// Unwrap returns ErrResponseTooLarge.
+func (e *ResponseTooLargeError) Unwrap() error {
+	return ErrResponseTooLarge
+}
+
+// WithMaxResponseBytes caps how much of a response body the client reads (default 4 MiB).
+// A longer body fails the call with a *ResponseTooLargeError instead of being cut short
+// silently. Zero or negative n restores the default.
+func WithMaxResponseBytes(n int64) ClientOption {
+	return func(c *Client) {
+		if n <= 0 {
+			n = DefaultMaxResponseBytes
+		}
+		c.maxResponse = n
+	}
+}
+
+// readBody reads at most limit bytes of r, reading one byte more to tell a body that merely
+// fills the limit from one that exceeds it.
+func readBody(r io.Reader, status int, limit int64) ([]byte, error) {
+	raw, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("quote0: read response: %w", err)
+	}
+	if int64(len(raw)) > limit {
+		return nil, &ResponseTooLargeError{StatusCode: status, Limit: limit, Prefix: raw[:limit]}
+	}
+	return raw, nil
+}
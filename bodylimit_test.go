@@ -0,0 +1,58 @@
+package quote0
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWithMaxResponseBytes(t *testing.T) {
+	var size int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		// Stream the body in chunks so no Content-Length is known up front.
+		body := `{"code":0,"message":"` + strings.Repeat("x", size-len(`{"code":0,"message":""}`)) + `"}`
+		for i := 0; i < len(body); i += 100 {
+			end := i + 100
+			if end > len(body) {
+				end = len(body)
+			}
+			_, _ = w.Write([]byte(body[i:end]))
+			w.(http.Flusher).Flush()
+		}
+	}))
+	defer srv.Close()
+	c, err := NewClient("test", WithBaseURL(srv.URL), WithRateLimiter(nil), WithDefaultDeviceID("D"), WithMaxResponseBytes(1000))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	size = 1000 // exactly the limit is fine
+	resp, err := c.SendText(context.Background(), TextRequest{Title: "t"})
+	if err != nil || len(resp.RawBody) != 1000 {
+		t.Fatalf("at the limit: %v", err)
+	}
+
+	size = 5000
+	_, err = c.SendText(context.Background(), TextRequest{Title: "t"})
+	var tl *ResponseTooLargeError
+	if !errors.Is(err, ErrResponseTooLarge) || !errors.As(err, &tl) {
+		t.Fatalf("err %v", err)
+	}
+	if tl.StatusCode != 200 || tl.Limit != 1000 || len(tl.Prefix) != 1000 || !bytes.HasPrefix(tl.Prefix, []byte(`{"code":0`)) {
+		t.Fatalf("error %+v", tl)
+	}
+
+	// The default allows 4 MiB.
+	def, _ := NewClient("test", WithBaseURL(srv.URL), WithRateLimiter(nil), WithDefaultDeviceID("D"), WithMaxResponseBytes(0))
+	if def.maxResponse != DefaultMaxResponseBytes {
+		t.Fatalf("limit %d", def.maxResponse)
+	}
+	if _, err := def.SendText(context.Background(), TextRequest{Title: "t"}); err != nil {
+		t.Fatal(err)
+	}
+}
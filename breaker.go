@@ -0,0 +1,127 @@
+package quote0
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned, wrapped with the remaining cooldown, for calls that
+// WithCircuitBreaker rejected without sending them.
+var ErrCircuitOpen = errors.New("quote0: circuit breaker open")
+
+// BreakerState is the state of the WithCircuitBreaker breaker.
+type BreakerState string
+
+// Breaker states.
+const (
+	// BreakerClosed lets every call through; it is also reported when no breaker is set.
+	BreakerClosed BreakerState = "closed"
+	// BreakerOpen rejects calls with ErrCircuitOpen until the cooldown passes.
+	BreakerOpen BreakerState = "open"
+	// BreakerHalfOpen lets a single probe call through; its outcome closes or reopens the breaker.
+	BreakerHalfOpen BreakerState = "half-open"
+)
+
+// breaker is the WithCircuitBreaker state machine.
+type breaker struct {
+	threshold int
+	cooldown  time.Duration
+	// clock is the client's Clock, set by NewClient.
+	clock Clock
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// WithCircuitBreaker stops calling a service that is down. After threshold consecutive calls
+// fail with a 5xx or a network error, later calls return ErrCircuitOpen at once, without
+// waiting for the limiter or sending anything. Once cooldown has passed a single probe call
+// is let through (others keep failing fast while it runs): success closes the breaker,
+// failure opens it for another cooldown. Any success resets the count, and 4xx answers, such
+// as a bad token or 429, count as the service being up. Calls that end because their own
+// context was canceled or timed out count neither way. With WithRetry, a call counts once,
+// after its last attempt.
+//
+// threshold below 1 or a non-positive cooldown makes NewClient return an error. BreakerState
+// reports the current state.
+func WithCircuitBreaker(threshold int, cooldown time.Duration) ClientOption {
+	return func(c *Client) {
+		if threshold < 1 || cooldown <= 0 {
+			c.optionError(fmt.Errorf("quote0: circuit breaker needs a threshold of at least 1 and a positive cooldown, got %d and %v", threshold, cooldown))
+			return
+		}
+		c.breaker = &breaker{threshold: threshold, cooldown: cooldown, state: BreakerClosed}
+	}
+}
+
+// BreakerState returns the circuit breaker's state, BreakerClosed when none is configured.
+// An open breaker whose cooldown has passed reports BreakerHalfOpen: the next call probes.
+func (c *Client) BreakerState() BreakerState {
+	if c.breaker == nil {
+		return BreakerClosed
+	}
+	b := c.breaker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == BreakerOpen && !b.clock.Now().Before(b.openedAt.Add(b.cooldown)) {
+		return BreakerHalfOpen
+	}
+	return b.state
+}
+
+// allow reports whether a call may proceed, admitting one probe once the cooldown is over.
+func (b *breaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.clock.Now()
+	switch b.state {
+	case BreakerOpen:
+		if rest := b.openedAt.Add(b.cooldown).Sub(now); rest > 0 {
+			return fmt.Errorf("%w, retry in %v", ErrCircuitOpen, rest.Round(time.Millisecond))
+		}
+		b.state = BreakerHalfOpen
+		b.probing = true
+	case BreakerHalfOpen:
+		if b.probing {
+			return fmt.Errorf("%w, probe in flight", ErrCircuitOpen)
+		}
+		b.probing = true
+	}
+	return nil
+}
+
+// record updates the breaker with the outcome of a call that allow let through.
+func (b *breaker) record(ctx context.Context, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch {
+	case breakerFailure(ctx, err):
+		b.failures++
+		if b.state == BreakerHalfOpen || b.failures >= b.threshold {
+			b.state, b.openedAt = BreakerOpen, b.clock.Now()
+		}
+	case err == nil || errors.As(err, new(*APIError)):
+		b.state, b.failures = BreakerClosed, 0
+	}
+	// Anything else leaves the state alone; a half-open breaker waits for the next probe.
+	b.probing = false
+}
+
+// breakerFailure reports whether err says the service is down: a 5xx or a transport failure
+// not caused by the caller's own context.
+func breakerFailure(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil {
+		return false
+	}
+	var ae *APIError
+	if errors.As(err, &ae) {
+		return ae.StatusCode >= 500
+	}
+	return IsNetworkError(err)
+}
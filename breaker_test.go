@@ -0,0 +1,159 @@
+package quote0
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/1set/quote0/quote0test"
+)
+
+// breakerClient returns a client with a 3-failure breaker on a fake clock, a server answering
+// with *status, and counters of requests and limiter waits.
+func breakerClient(t *testing.T, status *int32) (c *Client, clock *quote0test.FakeClock, hits, waits *int32) {
+	t.Helper()
+	hits, waits = new(int32), new(int32)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(hits, 1)
+		w.WriteHeader(int(atomic.LoadInt32(status)))
+		_, _ = io.WriteString(w, `{"code":0}`)
+	}))
+	t.Cleanup(srv.Close)
+	clock = quote0test.NewFakeClock(time.Date(2025, 11, 10, 9, 0, 0, 0, time.UTC))
+	limiter := RateLimiterFunc(func(context.Context) error { atomic.AddInt32(waits, 1); return nil })
+	c, err := NewClient("test", WithBaseURL(srv.URL), WithRateLimiter(limiter), WithDefaultDeviceID("D"),
+		WithCircuitBreaker(3, time.Minute), WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c, clock, hits, waits
+}
+
+func TestCircuitBreaker_TripsAndRecovers(t *testing.T) {
+	status := int32(http.StatusServiceUnavailable)
+	c, clock, hits, waits := breakerClient(t, &status)
+	send := func() error {
+		_, err := c.SendText(context.Background(), TextRequest{Title: "t"})
+		return err
+	}
+	for i := 0; i < 3; i++ {
+		if err := send(); errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("call %d short-circuited", i)
+		}
+	}
+	if c.BreakerState() != BreakerOpen {
+		t.Fatalf("state %s", c.BreakerState())
+	}
+	if err := send(); !errors.Is(err, ErrCircuitOpen) || *hits != 3 || *waits != 3 {
+		t.Fatalf("err %v after %d requests, %d limiter waits", err, *hits, *waits)
+	}
+
+	// A failed probe reopens the breaker for another cooldown.
+	clock.Advance(time.Minute)
+	if c.BreakerState() != BreakerHalfOpen {
+		t.Fatalf("state %s", c.BreakerState())
+	}
+	if err := send(); errors.Is(err, ErrCircuitOpen) || *hits != 4 {
+		t.Fatalf("probe: %v after %d requests", err, *hits)
+	}
+	if err := send(); !errors.Is(err, ErrCircuitOpen) || c.BreakerState() != BreakerOpen {
+		t.Fatalf("after failed probe: %v, %s", err, c.BreakerState())
+	}
+
+	// A successful probe closes it.
+	clock.Advance(time.Minute)
+	atomic.StoreInt32(&status, http.StatusOK)
+	if err := send(); err != nil || c.BreakerState() != BreakerClosed {
+		t.Fatalf("probe: %v, %s", err, c.BreakerState())
+	}
+}
+
+func TestCircuitBreaker_ClientErrorsAndResets(t *testing.T) {
+	status := int32(http.StatusInternalServerError)
+	c, _, _, _ := breakerClient(t, &status)
+	send := func(s int32) {
+		atomic.StoreInt32(&status, s)
+		_, _ = c.SendText(context.Background(), TextRequest{Title: "t"})
+	}
+	send(500)
+	send(500)
+	send(200) // resets
+	send(500)
+	send(500)
+	send(401) // the service answered
+	send(429)
+	send(500)
+	send(500)
+	if c.BreakerState() != BreakerClosed {
+		t.Fatalf("state %s", c.BreakerState())
+	}
+	// The caller's own cancellation does not count.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _ = c.SendText(ctx, TextRequest{Title: "t"})
+	if c.BreakerState() != BreakerClosed || c.breaker.failures != 2 {
+		t.Fatalf("state %s with %d failures", c.BreakerState(), c.breaker.failures)
+	}
+	send(500)
+	if c.BreakerState() != BreakerOpen {
+		t.Fatalf("state %s", c.BreakerState())
+	}
+
+	noBreaker, _ := NewClient("test")
+	if noBreaker.BreakerState() != BreakerClosed {
+		t.Fatal("no breaker should report closed")
+	}
+	for _, bad := range []ClientOption{WithCircuitBreaker(0, time.Second), WithCircuitBreaker(3, 0)} {
+		if _, err := NewClient("test", bad); err == nil {
+			t.Error("invalid breaker accepted")
+		}
+	}
+}
+
+func TestCircuitBreaker_SingleProbe(t *testing.T) {
+	release := make(chan struct{})
+	arrived := make(chan struct{}, 8)
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		arrived <- struct{}{}
+		<-release
+		okHandler(w, r)
+	}))
+	defer srv.Close()
+	c, _ := NewClient("test", WithBaseURL(srv.URL), WithRateLimiter(nil), WithDefaultDeviceID("D"),
+		WithCircuitBreaker(1, time.Millisecond))
+	c.breaker.state, c.breaker.openedAt = BreakerOpen, time.Now().Add(-time.Second)
+
+	probe := make(chan error, 1)
+	go func() {
+		_, err := c.SendText(context.Background(), TextRequest{Title: "probe"})
+		probe <- err
+	}()
+	<-arrived
+	var wg sync.WaitGroup
+	var rejected int32
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.SendText(context.Background(), TextRequest{Title: "t"}); errors.Is(err, ErrCircuitOpen) {
+				atomic.AddInt32(&rejected, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	close(release)
+	if err := <-probe; err != nil {
+		t.Fatal(err)
+	}
+	if rejected != 5 || atomic.LoadInt32(&hits) != 1 || c.BreakerState() != BreakerClosed {
+		t.Fatalf("%d rejected, %d requests, state %s", rejected, hits, c.BreakerState())
+	}
+}
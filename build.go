@@ -0,0 +1,92 @@
+package quote0
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+)
+
+// PreparedRequest is a validated API call that has not been sent.
+type PreparedRequest struct {
+	// URL is the endpoint the payload would be posted to on the primary host.
+	URL string
+	// DeviceID is the resolved target device.
+	DeviceID string
+	// Payload is the *TextRequest or *ImageRequest exactly as it would be JSON-encoded.
+	Payload interface{}
+}
+
+// BuildText resolves the device, decodes an icon data URI, encodes IconBytes, and validates
+// payload as SendText does, without sending it. Having no context, it skips
+// ContextWithDeviceID and falls back to the client's default device.
+func (c *Client) BuildText(payload TextRequest) (*PreparedRequest, error) {
+	return c.buildText(context.Background(), payload)
+}
+
+// buildText is BuildText with ctx consulted for the device (see ContextWithDeviceID).
+func (c *Client) buildText(ctx context.Context, payload TextRequest) (*PreparedRequest, error) {
+	did, err := c.resolveDeviceID(ctx, payload.DeviceID)
+	if err != nil {
+		return nil, err
+	}
+	payload.DeviceID = did
+	if isDataURI(payload.Icon) {
+		// The decoded PNG goes through the IconBytes checks.
+		if payload.IconBytes, err = pngFromDataURI("icon", payload.Icon); err != nil {
+			return nil, err
+		}
+		payload.Icon = ""
+	}
+	if err := payload.normalizeIcon(); err != nil {
+		return nil, err
+	}
+	if err := payload.validate(); err != nil {
+		return nil, err
+	}
+	return &PreparedRequest{URL: endpointURL(c.baseURL, textEndpoint), DeviceID: did, Payload: &payload}, nil
+}
+
+// BuildImage resolves the device, decodes an image data URI, loads and base64-encodes
+// ImageBytes or ImagePath, and validates payload as SendImage does, without sending it. Like
+// BuildText, it falls back to the client's default device, not a context one.
+func (c *Client) BuildImage(payload ImageRequest) (*PreparedRequest, error) {
+	return c.buildImage(context.Background(), payload)
+}
+
+// buildImage is BuildImage with ctx consulted for the device (see ContextWithDeviceID).
+func (c *Client) buildImage(ctx context.Context, payload ImageRequest) (*PreparedRequest, error) {
+	did, err := c.resolveDeviceID(ctx, payload.DeviceID)
+	if err != nil {
+		return nil, err
+	}
+	payload.DeviceID = did
+	if isDataURI(payload.Image) {
+		if payload.Image, err = c.imageFromDataURI(payload.Image); err != nil {
+			return nil, err
+		}
+	}
+	if err := payload.normalizeImage(); err != nil {
+		return nil, err
+	}
+	if err := payload.validate(); err != nil {
+		return nil, err
+	}
+	payload.ImageBytes, payload.ImagePath = nil, ""
+	return &PreparedRequest{URL: endpointURL(c.baseURL, imageEndpoint), DeviceID: did, Payload: &payload}, nil
+}
+
+// Hash returns a canonical SHA-256 of the request, hex encoded: the kind of endpoint and the
+// JSON payload, whose field order is fixed. Requests that would show the same content on the
+// same device hash the same, so a caller can store the hash and skip identical resends.
+// Time-dependent fields, such as an auto signature, make every request differ.
+func (p *PreparedRequest) Hash() string {
+	kind := "text"
+	if _, ok := p.Payload.(*ImageRequest); ok {
+		kind = "image"
+	}
+	// TextRequest and ImageRequest always encode.
+	data, _ := json.Marshal(p.Payload)
+	sum := sha256.Sum256(append([]byte(kind+"\n"), data...))
+	return hex.EncodeToString(sum[:])
+}
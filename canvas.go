@@ -0,0 +1,249 @@
+package quote0
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+)
+
+// Screen dimensions of the Quote/0 display in pixels.
+const (
+	ScreenWidth  = 296
+	ScreenHeight = 152
+)
+
+// Colors used by local renderers. The panel is monochrome, so drawing sticks to these two.
+var (
+	Black = color.Gray{Y: 0x00}
+	White = color.Gray{Y: 0xff}
+)
+
+// Canvas is a grayscale drawing surface for composing screens locally before sending them
+// with the Image API. All drawing is clipped to the canvas bounds.
+type Canvas struct {
+	img *image.Gray
+}
+
+// NewCanvas returns a white canvas of the full screen size.
+func NewCanvas() *Canvas {
+	return NewCanvasSize(ScreenWidth, ScreenHeight)
+}
+
+// NewCanvasSize returns a white canvas of the given size, e.g. for 40x40 icons.
+func NewCanvasSize(w, h int) *Canvas {
+	c := &Canvas{img: image.NewGray(image.Rect(0, 0, w, h))}
+	for i := range c.img.Pix {
+		c.img.Pix[i] = White.Y
+	}
+	return c
+}
+
+// Image returns the underlying image; later drawing is reflected in it.
+func (c *Canvas) Image() *image.Gray { return c.img }
+
+// Bounds returns the canvas rectangle.
+func (c *Canvas) Bounds() image.Rectangle { return c.img.Rect }
+
+// Set paints a single pixel.
+func (c *Canvas) Set(x, y int, col color.Gray) {
+	if image.Pt(x, y).In(c.img.Rect) {
+		c.img.SetGray(x, y, col)
+	}
+}
+
+// FillRect paints r.
+func (c *Canvas) FillRect(r image.Rectangle, col color.Gray) {
+	r = r.Intersect(c.img.Rect)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			c.img.SetGray(x, y, col)
+		}
+	}
+}
+
+// StrokeRect draws the outline of r with the given line width, inside r.
+func (c *Canvas) StrokeRect(r image.Rectangle, width int, col color.Gray) {
+	if width < 1 {
+		width = 1
+	}
+	c.FillRect(image.Rect(r.Min.X, r.Min.Y, r.Max.X, r.Min.Y+width), col)
+	c.FillRect(image.Rect(r.Min.X, r.Max.Y-width, r.Max.X, r.Max.Y), col)
+	c.FillRect(image.Rect(r.Min.X, r.Min.Y, r.Min.X+width, r.Max.Y), col)
+	c.FillRect(image.Rect(r.Max.X-width, r.Min.Y, r.Max.X, r.Max.Y), col)
+}
+
+// Line draws a one-pixel line between two points (Bresenham).
+func (c *Canvas) Line(x0, y0, x1, y1 int, col color.Gray) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	err := dx + dy
+	for {
+		c.Set(x0, y0, col)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		if e2 := 2 * err; e2 >= dy {
+			err += dy
+			x0 += sx
+		} else {
+			err += dx
+			y0 += sy
+		}
+	}
+}
+
+// FillCircle paints a disc centred at (cx, cy).
+func (c *Canvas) FillCircle(cx, cy, radius int, col color.Gray) {
+	for y := -radius; y <= radius; y++ {
+		for x := -radius; x <= radius; x++ {
+			if x*x+y*y <= radius*radius {
+				c.Set(cx+x, cy+y, col)
+			}
+		}
+	}
+}
+
+// DrawText draws s with the built-in bitmap font, top-left at (x, y), each font pixel
+// enlarged to scale x scale. It returns the drawn width (see TextWidth).
+func (c *Canvas) DrawText(x, y int, s string, scale int, col color.Gray) int {
+	if scale < 1 {
+		scale = 1
+	}
+	cx := x
+	for _, r := range s {
+		g := glyph(r)
+		for gx := 0; gx < glyphW; gx++ {
+			bits := g[gx]
+			for gy := 0; gy < glyphH; gy++ {
+				if bits&(1<<gy) != 0 {
+					c.FillRect(image.Rect(cx+gx*scale, y+gy*scale, cx+(gx+1)*scale, y+(gy+1)*scale), col)
+				}
+			}
+		}
+		cx += glyphAdv * scale
+	}
+	return TextWidth(s, scale)
+}
+
+// DrawImage scales src into r with nearest-neighbour sampling, converting it to gray.
+func (c *Canvas) DrawImage(r image.Rectangle, src image.Image) {
+	sb := src.Bounds()
+	if r.Empty() || sb.Empty() {
+		return
+	}
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		sy := sb.Min.Y + (y-r.Min.Y)*sb.Dy()/r.Dy()
+		for x := r.Min.X; x < r.Max.X; x++ {
+			sx := sb.Min.X + (x-r.Min.X)*sb.Dx()/r.Dx()
+			c.Set(x, y, color.GrayModel.Convert(src.At(sx, sy)).(color.Gray))
+		}
+	}
+}
+
+// DrawSparkline plots values as a polyline scaled to fill r, with a dot on the last point.
+// Fewer than two finite values draw nothing. NaN and ±Inf values are skipped.
+func (c *Canvas) DrawSparkline(r image.Rectangle, values []float64, col color.Gray) {
+	lo, hi := seriesRange(values)
+	if finiteCount(values) < 2 {
+		return
+	}
+	c.drawSeries(r, values, lo, hi, col)
+}
+
+// drawSeries is DrawSparkline over a fixed lo..hi range; values outside it are clamped.
+func (c *Canvas) drawSeries(r image.Rectangle, values []float64, lo, hi float64, col color.Gray) {
+	if finiteCount(values) < 1 || r.Dx() < 2 || r.Dy() < 2 {
+		return
+	}
+	span := hi - lo
+	steps := len(values) - 1
+	if steps < 1 {
+		steps = 1
+	}
+	point := func(i int, v float64) (int, int) {
+		x := r.Min.X + int(math.Round(float64(i)*float64(r.Dx()-1)/float64(steps)))
+		y := r.Max.Y - 1 - (r.Dy()-1)/2
+		if span > 0 {
+			y = r.Max.Y - 1 - int(math.Round(clampUnit((v-lo)/span)*float64(r.Dy()-1)))
+		}
+		return x, y
+	}
+	px, py, have := 0, 0, false
+	for i, v := range values {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			continue
+		}
+		x, y := point(i, v)
+		if have {
+			c.Line(px, py, x, y, col)
+		}
+		px, py, have = x, y, true
+	}
+	c.FillRect(image.Rect(px-1, py-1, px+2, py+2), col)
+}
+
+// seriesRange returns the smallest and largest finite values (+Inf, -Inf when there are none).
+func seriesRange(values []float64) (lo, hi float64) {
+	lo, hi = math.Inf(1), math.Inf(-1)
+	for _, v := range values {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			continue
+		}
+		lo, hi = math.Min(lo, v), math.Max(hi, v)
+	}
+	return lo, hi
+}
+
+func clampUnit(f float64) float64 {
+	return math.Max(0, math.Min(1, f))
+}
+
+// Invert swaps black and white (and mirrors gray levels) inside r.
+func (c *Canvas) Invert(r image.Rectangle) {
+	r = r.Intersect(c.img.Rect)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			i := c.img.PixOffset(x, y)
+			c.img.Pix[i] = 0xff - c.img.Pix[i]
+		}
+	}
+}
+
+// PNG encodes the canvas. Full-screen canvases can be sent directly via ImageRequest.ImageBytes.
+func (c *Canvas) PNG() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, c.img); err != nil {
+		return nil, fmt.Errorf("quote0: encode png: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,190 @@
+package quote0
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ChartType selects how RenderChart draws a series.
+type ChartType string
+
+const (
+	// ChartSparkline draws the series as a line with a dot on the last point (default).
+	ChartSparkline ChartType = "sparkline"
+	// ChartBar draws one bar per value, rising from zero (or the bottom of the axis).
+	ChartBar ChartType = "bar"
+)
+
+// ErrEmptySeries is returned by RenderChart when the series has no finite values.
+var ErrEmptySeries = errors.New("quote0: chart series has no numeric values")
+
+// ChartOption configures RenderChart.
+type ChartOption func(*chartConfig)
+
+type chartConfig struct {
+	kind   ChartType
+	title  string
+	lo, hi float64
+	zero   bool
+}
+
+// WithChartType selects ChartSparkline (default) or ChartBar.
+func WithChartType(t ChartType) ChartOption {
+	return func(cfg *chartConfig) { cfg.kind = t }
+}
+
+// WithChartTitle sets the header text; the last value is shown on the right of it.
+func WithChartTitle(title string) ChartOption {
+	return func(cfg *chartConfig) { cfg.title = title }
+}
+
+// WithChartRange fixes the bottom and top of the value axis. Pass NaN for an end that should
+// follow the data. Values outside the range are clamped to the plot edges.
+func WithChartRange(min, max float64) ChartOption {
+	return func(cfg *chartConfig) { cfg.lo, cfg.hi = min, max }
+}
+
+// WithChartZero extends an automatic axis to include zero, so sizes compare honestly. By
+// default the axis is fitted to the data.
+func WithChartZero(on bool) ChartOption {
+	return func(cfg *chartConfig) { cfg.zero = on }
+}
+
+// Chart layout metrics, in pixels.
+const (
+	chartMargin      = 6
+	chartHeaderH     = 22
+	chartHeaderScale = 2
+	chartLabelGap    = 4
+)
+
+// RenderChart draws values as a full-screen chart: a header with the title and the last
+// value, the axis top and bottom values on the left, and the plot. NaN and ±Inf values are
+// gaps. A series without finite values returns ErrEmptySeries rather than a blank chart.
+func RenderChart(values []float64, opts ...ChartOption) (image.Image, error) {
+	cfg := chartConfig{kind: ChartSparkline, lo: math.NaN(), hi: math.NaN()}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&cfg)
+		}
+	}
+	switch cfg.kind {
+	case ChartSparkline, ChartBar:
+	default:
+		return nil, fmt.Errorf("quote0: unknown chart type %q (want %s or %s)", cfg.kind, ChartSparkline, ChartBar)
+	}
+	if finiteCount(values) == 0 {
+		return nil, ErrEmptySeries
+	}
+	lo, hi := chartAxis(values, cfg)
+	if !(lo < hi) {
+		return nil, fmt.Errorf("quote0: chart range min %s must be below max %s", formatChartValue(lo), formatChartValue(hi))
+	}
+
+	c := NewCanvas()
+	last := formatChartValue(lastFinite(values))
+	lastW := TextWidth(last, chartHeaderScale)
+	ty := (chartHeaderH - glyphH*chartHeaderScale) / 2
+	if title := strings.TrimSpace(cfg.title); title != "" {
+		width := ScreenWidth - 2*chartMargin - lastW - 2*chartLabelGap
+		c.DrawText(chartMargin, ty, FitText(title, width, chartHeaderScale), chartHeaderScale, Black)
+	}
+	c.DrawText(ScreenWidth-chartMargin-lastW, ty, last, chartHeaderScale, Black)
+	c.FillRect(image.Rect(chartMargin, chartHeaderH, ScreenWidth-chartMargin, chartHeaderH+1), Black)
+
+	top, bottom := formatChartValue(hi), formatChartValue(lo)
+	labelW := TextWidth(top, 1)
+	if w := TextWidth(bottom, 1); w > labelW {
+		labelW = w
+	}
+	plot := image.Rect(chartMargin+labelW+chartLabelGap, chartHeaderH+chartMargin, ScreenWidth-chartMargin, ScreenHeight-chartMargin)
+	c.DrawText(chartMargin, plot.Min.Y, top, 1, Black)
+	c.DrawText(chartMargin, plot.Max.Y-glyphH, bottom, 1, Black)
+	c.FillRect(image.Rect(plot.Min.X-2, plot.Min.Y, plot.Min.X-1, plot.Max.Y), Black)
+
+	if cfg.kind == ChartBar {
+		drawBars(c, plot, values, lo, hi)
+	} else {
+		c.drawSeries(plot, values, lo, hi, Black)
+	}
+	return c.Image(), nil
+}
+
+// chartAxis picks the axis range: fixed ends win, the rest follows the data (and zero with
+// WithChartZero). A flat series gets a unit of room so it draws as a level line.
+func chartAxis(values []float64, cfg chartConfig) (lo, hi float64) {
+	lo, hi = seriesRange(values)
+	if cfg.zero {
+		lo, hi = math.Min(lo, 0), math.Max(hi, 0)
+	}
+	fixedLo, fixedHi := !math.IsNaN(cfg.lo), !math.IsNaN(cfg.hi)
+	if fixedLo {
+		lo = cfg.lo
+	}
+	if fixedHi {
+		hi = cfg.hi
+	}
+	if lo == hi && !(fixedLo && fixedHi) {
+		switch {
+		case fixedLo:
+			hi = lo + 1
+		case fixedHi:
+			lo = hi - 1
+		default:
+			lo, hi = lo-0.5, hi+0.5
+		}
+	}
+	return lo, hi
+}
+
+// drawBars draws one bar per value from the zero line (or the bottom when zero is off-axis).
+func drawBars(c *Canvas, r image.Rectangle, values []float64, lo, hi float64) {
+	y := func(v float64) int {
+		return r.Max.Y - int(math.Round(clampUnit((v-lo)/(hi-lo))*float64(r.Dy())))
+	}
+	base := y(math.Max(lo, math.Min(0, hi)))
+	gap := 1
+	if r.Dx()/len(values) < 3 {
+		gap = 0
+	}
+	for i, v := range values {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			continue
+		}
+		x0 := r.Min.X + i*r.Dx()/len(values)
+		x1 := r.Min.X + (i+1)*r.Dx()/len(values) - gap
+		if x1 <= x0 {
+			x1 = x0 + 1
+		}
+		top, bot := y(v), base
+		if top > bot {
+			top, bot = bot, top
+		}
+		if top == bot {
+			bot++ // keep values on the baseline visible
+		}
+		c.FillRect(image.Rect(x0, top, x1, bot), Black)
+	}
+}
+
+func lastFinite(values []float64) float64 {
+	for i := len(values) - 1; i >= 0; i-- {
+		if v := values[i]; !math.IsNaN(v) && !math.IsInf(v, 0) {
+			return v
+		}
+	}
+	return math.NaN()
+}
+
+// formatChartValue rounds to two decimals and drops trailing zeros.
+func formatChartValue(v float64) string {
+	s := strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
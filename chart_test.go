@@ -0,0 +1,90 @@
+package quote0
+
+import (
+	"errors"
+	"image"
+	"math"
+	"testing"
+)
+
+var chartTemps = []float64{12.5, 13, 14.25, math.NaN(), 16, 18.5, 21, 20, 17.5, 15, 13.75, 12}
+
+func TestRenderChart_Sparkline(t *testing.T) {
+	img, err := RenderChart(chartTemps, WithChartTitle("Outdoor °C"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertGolden(t, "chart_sparkline", img)
+}
+
+func TestRenderChart_Bars(t *testing.T) {
+	img, err := RenderChart([]float64{3, -1, 4, 1, 5, 0, 2}, WithChartType(ChartBar), WithChartTitle("Deploys"), WithChartZero(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertGolden(t, "chart_bars", img)
+}
+
+func TestRenderChart_Errors(t *testing.T) {
+	if _, err := RenderChart([]float64{math.NaN(), math.Inf(1)}); !errors.Is(err, ErrEmptySeries) {
+		t.Fatalf("empty series: %v", err)
+	}
+	if _, err := RenderChart(nil); !errors.Is(err, ErrEmptySeries) {
+		t.Fatalf("nil series: %v", err)
+	}
+	if _, err := RenderChart([]float64{1, 2}, WithChartRange(5, 5)); err == nil {
+		t.Fatal("equal fixed range accepted")
+	}
+	if _, err := RenderChart([]float64{1, 2}, WithChartType("pie")); err == nil {
+		t.Fatal("unknown type accepted")
+	}
+}
+
+func TestChartAxis(t *testing.T) {
+	nan := math.NaN()
+	tests := []struct {
+		name   string
+		values []float64
+		cfg    chartConfig
+		lo, hi float64
+	}{
+		{"fit", []float64{3, 7}, chartConfig{lo: nan, hi: nan}, 3, 7},
+		{"zero", []float64{3, 7}, chartConfig{lo: nan, hi: nan, zero: true}, 0, 7},
+		{"fixed min", []float64{3, 7}, chartConfig{lo: -10, hi: nan}, -10, 7},
+		{"fixed both", []float64{3, 7}, chartConfig{lo: 0, hi: 100}, 0, 100},
+		{"flat", []float64{4, 4}, chartConfig{lo: nan, hi: nan}, 3.5, 4.5},
+		{"flat at fixed max", []float64{4}, chartConfig{lo: nan, hi: 4}, 3, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lo, hi := chartAxis(tt.values, tt.cfg)
+			if lo != tt.lo || hi != tt.hi {
+				t.Fatalf("axis %v..%v, want %v..%v", lo, hi, tt.lo, tt.hi)
+			}
+		})
+	}
+}
+
+func TestRenderChart_ClampsToRange(t *testing.T) {
+	img, err := RenderChart([]float64{-50, 50}, WithChartRange(0, 10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := img.(*image.Gray)
+	// Nothing is drawn below the plot area or in the bottom margin.
+	for x := 0; x < ScreenWidth; x++ {
+		for y := ScreenHeight - chartMargin + 1; y < ScreenHeight; y++ {
+			if g.GrayAt(x, y) != White {
+				t.Fatalf("pixel (%d,%d) drawn outside the plot", x, y)
+			}
+		}
+	}
+}
+
+func TestFormatChartValue(t *testing.T) {
+	for v, want := range map[float64]string{21.349: "21.35", 3: "3", -0.001: "0", 1e6: "1000000"} {
+		if got := formatChartValue(v); got != want {
+			t.Errorf("formatChartValue(%v) = %q, want %q", v, got, want)
+		}
+	}
+}
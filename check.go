@@ -0,0 +1,147 @@
+package quote0
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+)
+
+// Severity grades a Problem found by CheckText or CheckImage.
+type Severity string
+
+const (
+	// SeverityError marks content the device would reject, cut, or show wrongly.
+	SeverityError Severity = "error"
+	// SeverityWarning marks settings that have no effect.
+	SeverityWarning Severity = "warning"
+)
+
+// Problem is one finding of the local content checks.
+type Problem struct {
+	// Field names the request field, using its JSON name ("title", "image", "ditherKernel", ...).
+	Field    string   `json:"field"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+// HasErrors reports whether any problem has SeverityError.
+func HasErrors(problems []Problem) bool {
+	for _, p := range problems {
+		if p.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckText runs every local check on a text request and returns all problems found, in
+// field order; nil means the request is clean. It needs no device or network access, so
+// DeviceID is not checked. Text longer than the layout budgets (MaxTitleRunes,
+// MaxMessageRunes, MaxSignatureRunes, three message lines) is reported as an error because
+// the device would cut it.
+func CheckText(req TextRequest) []Problem {
+	var ps problems
+	for _, f := range []struct {
+		name, value string
+		max         int
+	}{
+		{"title", req.Title, MaxTitleRunes},
+		{"message", req.Message, MaxMessageRunes},
+		{"signature", req.Signature, MaxSignatureRunes},
+	} {
+		if !utf8.ValidString(f.value) {
+			ps.add(f.name, SeverityError, ErrInvalidText.Error())
+			continue
+		}
+		if n := utf8.RuneCountInString(f.value); n > f.max {
+			ps.add(f.name, SeverityError, fmt.Sprintf("%d characters, at most %d fit", n, f.max))
+		}
+	}
+	if strings.Contains(strings.TrimSpace(req.Title), "\n") {
+		ps.add("title", SeverityError, "the title is a single line; put further lines in the message")
+	}
+	if n := strings.Count(strings.TrimSpace(strings.ReplaceAll(req.Message, "\r\n", "\n")), "\n") + 1; n > textMessageLines {
+		ps.add("message", SeverityError, fmt.Sprintf("%d lines, at most %d fit", n, textMessageLines))
+	}
+	if strings.Contains(strings.TrimSpace(req.Signature), "\n") {
+		ps.add("signature", SeverityError, "the signature is a single line")
+	}
+
+	if s := strings.TrimSpace(req.Icon); s != "" {
+		if img, err := decodePNGBase64("icon", s); err != nil {
+			ps.add("icon", SeverityError, err.Error())
+		} else if b := img.Bounds(); b.Dx() != IconSize || b.Dy() != IconSize {
+			ps.add("icon", SeverityError, fmt.Sprintf("%v: got %dx%d", ErrIconSize, b.Dx(), b.Dy()))
+		}
+	} else if len(req.IconBytes) > 0 {
+		if err := req.normalizeIcon(); err != nil {
+			ps.add("icon", SeverityError, err.Error())
+		}
+	}
+	ps.checkLink(req.Link)
+	return ps
+}
+
+// CheckImage runs every local check on an image request and returns all problems found;
+// nil means the request is clean. The payload is loaded as SendImage would (Image, then
+// ImageBytes, then ImagePath) and must be a 296x152 PNG. Unknown dither types and kernels
+// are errors; a kernel with a dither type other than DIFFUSION is a warning because the
+// server ignores it. DeviceID is not checked.
+func CheckImage(req ImageRequest) []Problem {
+	var ps problems
+	if err := req.normalizeImage(); err != nil {
+		ps.add("image", SeverityError, err.Error())
+	} else if strings.TrimSpace(req.Image) == "" {
+		ps.add("image", SeverityError, ErrImagePayloadMissing.Error())
+	} else if img, err := decodePNGBase64("image", req.Image); err != nil {
+		ps.add("image", SeverityError, err.Error())
+	} else if b := img.Bounds(); b.Dx() != ScreenWidth || b.Dy() != ScreenHeight {
+		ps.add("image", SeverityError, fmt.Sprintf("%v: got %dx%d", ErrImageSize, b.Dx(), b.Dy()))
+	}
+
+	if req.Border != BorderWhite && req.Border != BorderBlack {
+		ps.add("border", SeverityError, fmt.Sprintf("unknown border color %d (want 0=white or 1=black)", int(req.Border)))
+	}
+	// Accept either case, as the CLI does.
+	req.DitherType = DitherType(strings.ToUpper(strings.TrimSpace(string(req.DitherType))))
+	req.DitherKernel = DitherKernel(strings.ToUpper(strings.TrimSpace(string(req.DitherKernel))))
+	switch req.DitherType {
+	case "", DitherDiffusion, DitherOrdered, DitherNone:
+	default:
+		ps.add("ditherType", SeverityError, fmt.Sprintf("unknown dither type %q (want NONE, DIFFUSION, or ORDERED)", req.DitherType))
+	}
+	if req.DitherKernel != "" {
+		if isCustomKernel(req.DitherKernel) {
+			ps.add("ditherKernel", SeverityError, fmt.Sprintf("%s is a local kernel the server does not know; dither with WithLocalDither and send with ditherType NONE", req.DitherKernel))
+		} else if _, ok := diffusionKernels[req.DitherKernel]; !ok {
+			ps.add("ditherKernel", SeverityError, fmt.Sprintf("unknown dither kernel %q", req.DitherKernel))
+		} else if req.DitherType == DitherOrdered || req.DitherType == DitherNone {
+			ps.add("ditherKernel", SeverityWarning, fmt.Sprintf("ignored with ditherType %s (kernels apply to DIFFUSION only)", req.DitherType))
+		}
+	}
+	ps.checkLink(req.Link)
+	return ps
+}
+
+type problems []Problem
+
+func (ps *problems) add(field string, sev Severity, msg string) {
+	*ps = append(*ps, Problem{Field: field, Severity: sev, Message: msg})
+}
+
+// checkLink requires an absolute URL with a scheme and host, such as https://example.com.
+func (ps *problems) checkLink(link string) {
+	link = strings.TrimSpace(link)
+	if link == "" {
+		return
+	}
+	u, err := url.Parse(link)
+	if err != nil {
+		ps.add("link", SeverityError, err.Error())
+		return
+	}
+	if u.Scheme == "" || (u.Host == "" && u.Opaque == "") {
+		ps.add("link", SeverityError, fmt.Sprintf("%q is not an absolute URL", link))
+	}
+}
@@ -0,0 +1,67 @@
+package quote0
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func pngBytes(t *testing.T, w, h int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, w, h))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// problemFields lists "severity field" for each problem, in order.
+func problemFields(ps []Problem) string {
+	var out []string
+	for _, p := range ps {
+		out = append(out, string(p.Severity)+" "+p.Field)
+	}
+	return strings.Join(out, ", ")
+}
+
+func TestCheckText(t *testing.T) {
+	clean := TextRequest{Title: "Build", Message: "line 1\nline 2\nline 3", Signature: "ci", IconBytes: pngBytes(t, 40, 40), Link: "https://ci.example.com/1"}
+	if ps := CheckText(clean); ps != nil || HasErrors(ps) {
+		t.Fatalf("clean request: %v", ps)
+	}
+
+	ps := CheckText(TextRequest{
+		Title:     strings.Repeat("x", MaxTitleRunes+1),
+		Message:   "1\n2\n3\n4",
+		Signature: "bad \xff",
+		IconBytes: pngBytes(t, 32, 32),
+		Link:      "example.com/path",
+	})
+	if got := problemFields(ps); got != "error title, error signature, error message, error icon, error link" {
+		t.Fatalf("problems %s: %v", got, ps)
+	}
+	if !strings.Contains(ps[3].Message, "got 32x32") {
+		t.Fatalf("icon message %q", ps[3].Message)
+	}
+}
+
+func TestCheckImage(t *testing.T) {
+	if ps := CheckImage(ImageRequest{ImageBytes: pngBytes(t, ScreenWidth, ScreenHeight), DitherType: "diffusion", DitherKernel: "atkinson"}); ps != nil {
+		t.Fatalf("clean request: %v", ps)
+	}
+
+	ps := CheckImage(ImageRequest{ImageBytes: pngBytes(t, 100, 50), DitherType: DitherOrdered, DitherKernel: KernelStucki, Border: 2})
+	if got := problemFields(ps); got != "error image, error border, warning ditherKernel" {
+		t.Fatalf("problems %s: %v", got, ps)
+	}
+	if !HasErrors(ps) || HasErrors(ps[2:]) {
+		t.Fatal("HasErrors")
+	}
+
+	ps = CheckImage(ImageRequest{DitherType: "HALFTONE", DitherKernel: "FANCY"})
+	if got := problemFields(ps); got != "error image, error ditherType, error ditherKernel" {
+		t.Fatalf("problems %s: %v", got, ps)
+	}
+}
@@ -3,14 +3,18 @@ package quote0
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"net/http/httptrace"
+	"net/url"
 	"os"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -42,50 +46,160 @@ type APIResponse struct {
 	StatusCode int `json:"-"`
 	// RawBody contains the exact response bytes for troubleshooting or custom parsing.
 	RawBody []byte `json:"-"`
+	// Attempts is how many times the request was sent; above 1 only with WithRetry.
+	Attempts int `json:"-"`
+	// RequestBody is the JSON that would have been sent; set only with WithDryRun.
+	RequestBody []byte `json:"-"`
+	// RequestID is the X-Request-ID the call was sent with (see WithRequestID).
+	RequestID string `json:"-"`
+	// Timing breaks down the last HTTP attempt; nil unless WithTiming is set.
+	Timing *Timing `json:"-"`
 }
 
 // Client exposes the Quote/0 APIs with proper authentication and rate limiting.
 type Client struct {
+	// urgentCalls counts calls that skipped the limiter (see WithUrgent); accessed atomically.
+	// It comes first to stay 64-bit aligned on 32-bit platforms.
+	urgentCalls int64
+
 	baseURL   string
-	apiKey    string
 	http      *http.Client
 	limiter   RateLimiter
 	userAgent string
-	debug     bool
+	debug     DebugLevel
+	debugOut  io.Writer
+
+	// uaSuffixes are appended to userAgent by NewClient (see WithUserAgentSuffix).
+	uaSuffixes []string
 
+	// mu guards defaultDevice and apiKey, which may change while calls are in flight.
 	mu            sync.RWMutex
 	defaultDevice string
+	apiKey        string
+	tokenSource   func(ctx context.Context) (string, error)
+
+	fallbackURLs []string
+	failover     failoverState
+	extraHeaders http.Header
+	traceHeaders []traceHeader
+	signer       RequestSigner
+	hooks        []Hooks
+	retry        retryPolicy
+	breaker      *breaker
+	urgentHooks  []func(ctx context.Context, endpoint, deviceID string)
+
+	// dryRun skips the network entirely (see WithDryRun).
+	dryRun bool
+	// clock schedules limiter waits, backoff, cooldowns, and failback (see WithClock).
+	clock Clock
+	// compress is nil unless WithRequestCompression is set.
+	compress *compressState
+	// strictCode turns a 2xx answer with a non-zero code into an APIError (see WithStrictCode).
+	strictCode bool
+	// timing attaches an httptrace.ClientTrace to each attempt (see WithTiming).
+	timing bool
+
+	// logger is nil unless WithLogger is set.
+	logger callLogger
+
+	// penalty overrides DefaultRateLimitPenalty when penaltySet (see WithRateLimitPenalty).
+	penalty    time.Duration
+	penaltySet bool
+
+	// convertDataURI enables dataURIProcess for JPEG data URIs (see WithDataURIConversion).
+	convertDataURI bool
+	dataURIProcess []ProcessOption
+
+	sentMu   sync.RWMutex
+	lastSent map[string]SentRecord
+	// history is nil unless WithHistory is set.
+	history *history
+
+	// httpTimeout replaces the http.Client timeout when httpTimeoutSet (see WithHTTPTimeout).
+	httpTimeout    time.Duration
+	httpTimeoutSet bool
+	// maxResponse caps the response body read (see WithMaxResponseBytes).
+	maxResponse int64
+
+	// proxy is installed on a copy of the transport when set (see WithProxy).
+	proxy *url.URL
+	// tlsConfig is installed on a copy of the transport when set (see WithTLSConfig).
+	tlsConfig *tls.Config
+
+	// initErr records the first invalid option so NewClient can report it.
+	initErr error
 }
 
 // ClientOption mutates the client during construction.
 type ClientOption func(*Client)
 
-// NewClient builds a client. apiKey is required (format: dot_app_xxx).
+// NewClient builds a client. apiKey is required (format: dot_app_xxx) unless WithDryRun or
+// WithTokenSource is set.
 func NewClient(apiKey string, opts ...ClientOption) (*Client, error) {
 	apiKey = strings.TrimSpace(apiKey)
-	if apiKey == "" {
-		return nil, errors.New("quote0: API token is required")
-	}
 	c := &Client{
-		baseURL:   DefaultBaseURL,
-		apiKey:    apiKey,
-		userAgent: buildDefaultUserAgent(),
-		http:      &http.Client{Timeout: defaultHTTPTimeout},
-		limiter:   NewFixedIntervalLimiter(time.Second), // 1 QPS
+		baseURL:     DefaultBaseURL,
+		apiKey:      apiKey,
+		userAgent:   buildDefaultUserAgent(),
+		http:        &http.Client{Timeout: defaultHTTPTimeout},
+		limiter:     NewFixedIntervalLimiter(time.Second), // 1 QPS
+		failover:    failoverState{interval: defaultFailbackInterval},
+		maxResponse: DefaultMaxResponseBytes,
+		clock:       systemClock{},
 	}
 	for _, opt := range opts {
 		if opt != nil {
 			opt(c)
 		}
 	}
+	if c.initErr != nil {
+		return nil, c.initErr
+	}
+	if apiKey == "" && !c.dryRun && c.tokenSource == nil {
+		return nil, errors.New("quote0: API token is required")
+	}
+	if _, real := c.clock.(systemClock); !real {
+		if l, ok := c.limiter.(*FixedIntervalLimiter); ok && !l.hasClock() {
+			l.SetClock(c.clock)
+		}
+	}
+	if c.breaker != nil {
+		c.breaker.clock = c.clock
+	}
+	if len(c.uaSuffixes) > 0 {
+		c.userAgent = strings.TrimSpace(c.userAgent + " " + strings.Join(c.uaSuffixes, " "))
+	}
 	if c.http == nil {
 		c.http = &http.Client{Timeout: defaultHTTPTimeout}
 	}
-	c.baseURL = sanitizeBaseURL(c.baseURL)
+	if c.httpTimeoutSet {
+		hc := *c.http
+		hc.Timeout = c.httpTimeout
+		c.http = &hc
+	}
+	if c.proxy != nil || c.tlsConfig != nil {
+		if err := c.buildTransport(); err != nil {
+			return nil, err
+		}
+	}
+	base, err := sanitizeBaseURL(c.baseURL)
+	if err != nil {
+		return nil, err
+	}
+	c.baseURL = base
 	return c, nil
 }
 
-// WithBaseURL overrides the API host (useful for staging/tests). No trailing slash required.
+// optionError records err as the construction error unless an earlier option already failed.
+func (c *Client) optionError(err error) {
+	if c.initErr == nil {
+		c.initErr = err
+	}
+}
+
+// WithBaseURL overrides the API host (useful for staging/tests). It may include a path
+// prefix for a reverse proxy; a trailing slash is optional. NewClient rejects a URL that is
+// not absolute http(s) or that has a query or fragment.
 func WithBaseURL(baseURL string) ClientOption {
 	return func(c *Client) {
 		c.baseURL = baseURL
@@ -97,6 +211,20 @@ func WithHTTPClient(hc *http.Client) ClientOption {
 	return func(c *Client) { c.http = hc }
 }
 
+// WithHTTPTimeout sets the per-request timeout of the client's http.Client (default 30s)
+// without replacing its transport. It wins over WithHTTPClient in either order: a client
+// passed there is copied with the new timeout, never modified. A zero or negative d means
+// the 30s default, not an unlimited client. For a deadline on a single call use the
+// WithTimeout SendOption instead.
+func WithHTTPTimeout(d time.Duration) ClientOption {
+	return func(c *Client) {
+		if d <= 0 {
+			d = defaultHTTPTimeout
+		}
+		c.httpTimeout, c.httpTimeoutSet = d, true
+	}
+}
+
 // WithRateLimiter replaces the default limiter. Pass nil to disable (not recommended).
 func WithRateLimiter(l RateLimiter) ClientOption {
 	return func(c *Client) { c.limiter = l }
@@ -109,10 +237,61 @@ func WithUserAgent(ua string) ClientOption {
 	return func(c *Client) { c.userAgent = ua }
 }
 
+// WithUserAgentSuffix appends s, e.g. "officeboard/2.3", to the User-Agent so an app can
+// identify itself while keeping the SDK's product and version. It applies to the final
+// User-Agent whatever the option order, WithUserAgent included; repeated suffixes are
+// appended in order. Runs of whitespace in s collapse to one space, and a blank s is ignored.
+func WithUserAgentSuffix(s string) ClientOption {
+	return func(c *Client) {
+		if s = strings.Join(strings.Fields(s), " "); s != "" {
+			c.uaSuffixes = append(c.uaSuffixes, s)
+		}
+	}
+}
+
+// WithStrictCode makes a 2xx JSON answer whose code is not 0, such as
+// {"code":500,"message":"device offline"}, fail with an *APIError carrying the HTTP status,
+// the code as a string, and the message. The parsed APIResponse is still returned next to
+// the error so Result can be inspected. Plain-text bodies have no code and still succeed.
+// By default such answers are returned as successes with APIResponse.Code set.
+func WithStrictCode() ClientOption {
+	return func(c *Client) { c.strictCode = true }
+}
+
 // WithDebug enables debug mode which logs request details (method, URL, headers, body) to stderr.
 // Useful for debugging and verifying SDK behavior.
 func WithDebug(debug bool) ClientOption {
-	return func(c *Client) { c.debug = debug }
+	return func(c *Client) {
+		c.debug = DebugOff
+		if debug {
+			c.debug = DebugFull
+		}
+	}
+}
+
+// DebugLevel selects how much a debugging client logs about each HTTP exchange.
+type DebugLevel int
+
+const (
+	// DebugOff disables debug logging.
+	DebugOff DebugLevel = iota
+	// DebugSummary logs one line per request and per response.
+	DebugSummary
+	// DebugFull also logs headers and pretty-printed bodies.
+	DebugFull
+	// DebugWire dumps each request and response as it appears on the wire, including the
+	// headers the transport adds (Host, Content-Length, Accept-Encoding).
+	DebugWire
+)
+
+// WithDebugWriter logs HTTP exchanges at level to w instead of stderr (nil keeps stderr).
+// The Authorization token is always masked and base64 image and icon fields are replaced
+// by their length.
+func WithDebugWriter(w io.Writer, level DebugLevel) ClientOption {
+	return func(c *Client) {
+		c.debugOut = w
+		c.debug = level
+	}
 }
 
 // WithDefaultDeviceID sets a default device serial number used when request omits deviceId.
@@ -139,19 +318,63 @@ func (c *Client) GetDefaultDeviceID() string {
 	return id
 }
 
-func sanitizeBaseURL(baseURL string) string {
+// HTTPClient returns the http.Client used for API calls, so helpers that fetch auxiliary
+// content (feeds, remote images) share its timeout and transport settings.
+func (c *Client) HTTPClient() *http.Client {
+	return c.http
+}
+
+// BaseURL returns the primary API base URL the client sends to (fallback hosts aside).
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
+
+// sanitizeBaseURL validates baseURL and drops the trailing slashes of its path, so a path
+// prefix works with or without one. Empty means DefaultBaseURL. Query strings and fragments
+// are rejected: endpoints are joined onto the path and would end up after them.
+func sanitizeBaseURL(baseURL string) (string, error) {
 	baseURL = strings.TrimSpace(baseURL)
 	if baseURL == "" {
-		return DefaultBaseURL
+		return DefaultBaseURL, nil
 	}
-	return strings.TrimRight(baseURL, "/")
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return "", fmt.Errorf("quote0: invalid base URL: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("quote0: base URL %q is not an absolute http(s) URL", baseURL)
+	}
+	if strings.ContainsAny(baseURL, "?#") {
+		return "", fmt.Errorf("quote0: base URL %q must not have a query or fragment", baseURL)
+	}
+	u.Path = strings.TrimRight(u.Path, "/")
+	u.RawPath = strings.TrimRight(u.RawPath, "/")
+	return u.String(), nil
+}
+
+// endpointURL joins endpoint onto the path of baseURL, a value from sanitizeBaseURL.
+func endpointURL(baseURL, endpoint string) string {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return baseURL + endpoint
+	}
+	u.Path += endpoint
+	if u.RawPath != "" {
+		u.RawPath += endpoint
+	}
+	return u.String()
 }
 
-func (c *Client) resolveDeviceID(explicit string) (string, error) {
+// resolveDeviceID picks the target device: the explicit ID, then the one set by
+// ContextWithDeviceID on ctx, then the client default.
+func (c *Client) resolveDeviceID(ctx context.Context, explicit string) (string, error) {
 	explicit = strings.TrimSpace(explicit)
 	if explicit != "" {
 		return explicit, nil
 	}
+	if id := DeviceIDFromContext(ctx); id != "" {
+		return id, nil
+	}
 	c.mu.RLock()
 	id := c.defaultDevice
 	c.mu.RUnlock()
@@ -163,62 +386,205 @@ func (c *Client) resolveDeviceID(explicit string) (string, error) {
 }
 
 // doJSON encodes the payload, executes the POST, and normalizes the response.
-func (c *Client) doJSON(ctx context.Context, endpoint string, payload interface{}) (*APIResponse, error) {
+// deviceID is the resolved target, passed to limiters that implement KeyedRateLimiter.
+// When fallback base URLs are configured, transport failures move on to the next host;
+// with WithRetry, transient failures send the whole call again after a backoff.
+func (c *Client) doJSON(ctx context.Context, endpoint, deviceID string, payload interface{}) (*APIResponse, error) {
 	if ctx == nil {
 		ctx = context.Background()
 	}
-	if c.limiter != nil {
-		if err := c.limiter.Wait(ctx); err != nil {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("quote0: encode request: %w", err)
+	}
+	call := &apiCall{endpoint: endpoint, deviceID: deviceID, body: body, trace: c.traceValues(ctx), requestID: callRequestID(ctx)}
+	if !c.dryRun {
+		if call.token, err = c.currentToken(ctx); err != nil {
 			return nil, err
 		}
+		if c.breaker != nil {
+			if err := c.breaker.allow(); err != nil {
+				return nil, err
+			}
+		}
 	}
+	start := time.Now()
+	var resp *APIResponse
+	attempts := 0
+	for !c.dryRun {
+		attempts++
+		if len(c.fallbackURLs) == 0 {
+			resp, _, err = c.attempt(ctx, c.baseURL, call)
+		} else {
+			resp, err = c.doWithFailover(ctx, call)
+		}
+		if err == nil || attempts >= c.retry.attempts || !retryable(ctx, err) {
+			break
+		}
+		if c.clock.Sleep(ctx, c.retry.delay(attempts)) != nil {
+			break
+		}
+	}
+	if c.dryRun {
+		resp = &APIResponse{Message: dryRunMessage, RequestBody: body}
+	}
+	if resp != nil {
+		resp.Attempts = attempts
+		resp.RequestID = call.requestID
+	}
+	if err != nil && attempts > 1 {
+		err = &RetryError{Attempts: attempts, Err: err}
+	}
+	if c.breaker != nil && !c.dryRun {
+		c.breaker.record(ctx, err)
+	}
+	c.recordHistory(call, start, resp, err)
+	if c.logger != nil {
+		c.logger.logCall(ctx, &callLog{
+			endpoint: endpoint, deviceID: deviceID, requestID: call.requestID, trace: call.trace, refreshNow: refreshNowOf(payload), payloadSize: len(body),
+			attempts: attempts, elapsed: time.Since(start), resp: resp, err: err,
+		})
+	}
+	return resp, err
+}
 
-	body, err := json.Marshal(payload)
-	if err != nil {
-		return nil, fmt.Errorf("quote0: encode request: %w", err)
+// apiCall carries the per-call state shared by every attempt of one doJSON invocation.
+type apiCall struct {
+	endpoint string
+	deviceID string
+	body     []byte
+	// trace holds the non-empty values produced by the registered trace extractors.
+	trace map[string]string
+	// token is the API token for every attempt, read once per call (see WithTokenSource).
+	token string
+	// requestID is sent as X-Request-ID by every attempt.
+	requestID string
+	// gzipped caches the compressed body and compressed records whether the latest attempt
+	// sent it (see WithRequestCompression).
+	gzipped    []byte
+	compressed bool
+	// answered records whether the latest attempt got a response from the host, even one
+	// that ended in an error.
+	answered bool
+}
+
+// attempt waits for the limiter, unless ctx is urgent, and performs one POST against baseURL.
+// The boolean result reports whether err is a transport failure eligible for failover.
+func (c *Client) attempt(ctx context.Context, baseURL string, call *apiCall) (*APIResponse, bool, error) {
+	if IsUrgent(ctx) {
+		c.skipLimiter(ctx, call)
+	} else {
+		var waitStart time.Time
+		if c.logger != nil {
+			waitStart = time.Now()
+		}
+		if err := c.waitLimiter(ctx, call.deviceID); err != nil {
+			return nil, false, err
+		}
+		if c.logger != nil {
+			if waited := time.Since(waitStart); waited > slowLimiterWait {
+				c.logger.logLimiterWait(ctx, call.endpoint, call.deviceID, waited)
+			}
+		}
 	}
+	resp, transport, err := c.observe(ctx, call, func() (*APIResponse, bool, error) { return c.roundTrip(ctx, baseURL, call) })
+	if c.compressionRejected(call, err) {
+		return c.attempt(ctx, baseURL, call)
+	}
+	return resp, transport, err
+}
 
-	url := c.baseURL + endpoint
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+// roundTrip builds, signs, and sends one POST to baseURL and reads the response. The boolean
+// result reports whether err is a transport failure eligible for failover.
+func (c *Client) roundTrip(ctx context.Context, baseURL string, call *apiCall) (*APIResponse, bool, error) {
+	var timer *timingTrace
+	reqCtx := ctx
+	if c.timing {
+		timer = &timingTrace{}
+		reqCtx = httptrace.WithClientTrace(ctx, timer.clientTrace())
+	}
+	call.answered = false
+	body, gzipped := c.wireBody(call)
+	call.compressed = gzipped
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodPost, endpointURL(baseURL, call.endpoint), bytes.NewReader(body))
 	if err != nil {
-		return nil, fmt.Errorf("quote0: build request: %w", err)
+		return nil, false, fmt.Errorf("quote0: build request: %w", err)
 	}
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	req.Header.Set("Authorization", "Bearer "+call.token)
 	req.Header.Set("Content-Type", "application/json")
+	if gzipped {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
 	// Always set User-Agent, even if empty, to give users full control.
 	// If empty, it sends an empty UA instead of Go's default "Go-http-client/1.1".
 	req.Header.Set("User-Agent", c.userAgent)
+	for name, values := range c.extraHeaders {
+		req.Header[name] = append([]string(nil), values...)
+	}
+	for name, value := range call.trace {
+		req.Header.Set(name, value)
+	}
+	for name, values := range callHeaders(ctx) {
+		req.Header[name] = append([]string(nil), values...)
+	}
+	if call.requestID != "" {
+		req.Header.Set(RequestIDHeader, call.requestID)
+	}
+	if c.signer != nil {
+		if err := c.signer(req, body); err != nil {
+			return nil, false, fmt.Errorf("quote0: sign request: %w", err)
+		}
+	}
 
 	// Record start time for debug logging
 	var startTime time.Time
-	if c.debug {
+	if c.debug > DebugOff {
 		startTime = time.Now()
-		c.logRequest(req, body, startTime)
+		c.logRequest(req, call.body, startTime)
 	}
 
+	timer.begin()
 	resp, err := c.http.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("quote0: execute request: %w", err)
+		// Failures caused by the caller's context are not host problems.
+		return nil, ctx.Err() == nil, timer.wrap(fmt.Errorf("quote0: execute request: %w", err))
 	}
 	defer resp.Body.Close()
+	call.answered = true
 
-	limited := io.LimitReader(resp.Body, maxResponseBodySize)
-	raw, err := io.ReadAll(limited)
+	raw, err := readBody(resp.Body, resp.StatusCode, c.maxResponse)
 	if err != nil {
-		return nil, fmt.Errorf("quote0: read response: %w", err)
+		return nil, false, timer.wrap(err)
 	}
 
 	// Debug logging: print response details with timing
-	if c.debug {
+	if c.debug > DebugOff {
 		endTime := time.Now()
 		c.logResponse(resp, raw, startTime, endTime)
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, buildAPIError(resp.StatusCode, raw)
+		apiErr := buildAPIError(resp.StatusCode, raw)
+		if ae, ok := apiErr.(*APIError); ok {
+			ae.Trace = call.trace
+			ae.RequestID = call.requestID
+			ae.Timing = timer.result()
+			if isRateLimited(resp.StatusCode, raw) {
+				ae.RetryAfter = c.penalize(resp.Header, c.clock.Now())
+			}
+		}
+		return nil, false, apiErr
 	}
 
-	return parseResponse(resp, raw), nil
+	out := parseResponse(resp, raw)
+	out.Timing = timer.result()
+	if c.strictCode && out.Code != 0 {
+		return out, false, &APIError{
+			StatusCode: out.StatusCode, Code: strconv.Itoa(out.Code), Message: out.Message, RawBody: raw,
+			Trace: call.trace, RequestID: call.requestID, Timing: out.Timing,
+		}
+	}
+	return out, false, nil
 }
 
 // parseResponse converts raw HTTP response into APIResponse.
@@ -248,42 +614,57 @@ func buildDefaultUserAgent() string {
 		userAgentProduct, userAgentVersion, goVer, runtime.GOOS, runtime.GOARCH)
 }
 
-// logRequest prints HTTP request details to stderr for debugging.
-func (*Client) logRequest(req *http.Request, body []byte, startTime time.Time) {
-	logger := log.New(os.Stderr, "[quote0-debug] ", 0)
+// debugLogger returns the logger used for debug output.
+func (c *Client) debugLogger() *log.Logger {
+	out := c.debugOut
+	if out == nil {
+		out = os.Stderr
+	}
+	return log.New(out, "[quote0-debug] ", 0)
+}
+
+// logRequest prints HTTP request details for debugging.
+func (c *Client) logRequest(req *http.Request, body []byte, startTime time.Time) {
+	if c.debug >= DebugWire {
+		c.dumpRequest(req, body)
+		return
+	}
+	logger := c.debugLogger()
+	if c.debug < DebugFull {
+		logger.Printf("%s %s %s (%d bytes)", startTime.Format("15:04:05.000"), req.Method, req.URL.String(), len(body))
+		return
+	}
 	logger.Println("========== REQUEST ==========")
 	logger.Printf("Time: %s", startTime.Format("2006-01-02 15:04:05.000"))
 	logger.Printf("%s %s", req.Method, req.URL.String())
 	logger.Println("Headers:")
 	for key, values := range req.Header {
 		for _, value := range values {
-			// Mask the API key for security
-			if key == "Authorization" && strings.HasPrefix(value, "Bearer ") {
-				token := value[7:]
-				if len(token) > 16 {
-					value = "Bearer " + token[:8] + "..." + token[len(token)-8:]
-				}
+			if key == "Authorization" {
+				value = maskAuthorization(value)
 			}
 			logger.Printf("  %s: %s", key, value)
 		}
 	}
 	logger.Println("Body:")
-	// Pretty print JSON
-	var prettyJSON bytes.Buffer
-	if err := json.Indent(&prettyJSON, body, "  ", "  "); err == nil {
-		logger.Println(prettyJSON.String())
-	} else {
-		logger.Println(string(body))
-	}
+	logger.Println(debugBody(elideBase64Fields(body)))
 	logger.Println("=============================")
 }
 
-// logResponse prints HTTP response details to stderr for debugging.
-func (*Client) logResponse(resp *http.Response, body []byte, startTime, endTime time.Time) {
-	logger := log.New(os.Stderr, "[quote0-debug] ", 0)
+// logResponse prints HTTP response details for debugging.
+func (c *Client) logResponse(resp *http.Response, body []byte, startTime, endTime time.Time) {
+	if c.debug >= DebugWire {
+		c.dumpResponse(resp, body, endTime.Sub(startTime))
+		return
+	}
+	logger := c.debugLogger()
+	duration := endTime.Sub(startTime)
+	if c.debug < DebugFull {
+		logger.Printf("%s %s in %v (%d bytes)", endTime.Format("15:04:05.000"), resp.Status, duration, len(body))
+		return
+	}
 	logger.Println("========== RESPONSE ==========")
 	logger.Printf("Time: %s", endTime.Format("2006-01-02 15:04:05.000"))
-	duration := endTime.Sub(startTime)
 	logger.Printf("Duration: %v", duration)
 	logger.Printf("Status: %s (%d)", resp.Status, resp.StatusCode)
 	logger.Println("Headers:")
@@ -293,12 +674,54 @@ func (*Client) logResponse(resp *http.Response, body []byte, startTime, endTime
 		}
 	}
 	logger.Println("Body:")
-	// Pretty print JSON if possible
+	logger.Println(debugBody(body))
+	logger.Println("==============================")
+}
+
+// apiKeyPrefix starts every Quote/0 API key; it is not secret.
+const apiKeyPrefix = "dot_app_"
+
+// maskAuthorization hides the secret part of a bearer token: an API key keeps only its
+// dot_app_ prefix, anything else is hidden entirely.
+func maskAuthorization(value string) string {
+	if strings.HasPrefix(strings.TrimPrefix(value, "Bearer "), apiKeyPrefix) {
+		return "Bearer " + apiKeyPrefix + "***"
+	}
+	return "Bearer [redacted]"
+}
+
+// debugBody pretty-prints JSON bodies and returns anything else unchanged.
+func debugBody(body []byte) string {
 	var prettyJSON bytes.Buffer
 	if err := json.Indent(&prettyJSON, body, "  ", "  "); err == nil {
-		logger.Println(prettyJSON.String())
-	} else {
-		logger.Println(string(body))
+		return prettyJSON.String()
 	}
-	logger.Println("==============================")
+	return string(body)
+}
+
+// elideBase64Fields replaces the image and icon fields of a JSON request body with their
+// length so debug logs stay readable.
+func elideBase64Fields(body []byte) []byte {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return body
+	}
+	changed := false
+	for _, k := range []string{"image", "icon"} {
+		var s string
+		if raw, ok := fields[k]; ok && json.Unmarshal(raw, &s) == nil {
+			fields[k] = json.RawMessage(fmt.Sprintf(`"<%d bytes base64>"`, len(s)))
+			changed = true
+		}
+	}
+	if !changed {
+		return body
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(fields); err != nil {
+		return body
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n")
 }
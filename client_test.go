@@ -1,12 +1,16 @@
 package quote0
 
 import (
+	"bytes"
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
 	"strings"
 	"testing"
@@ -170,6 +174,34 @@ func TestSendText_AllFieldsOptional(t *testing.T) {
 	}
 }
 
+func TestRefresh_EmptyPayload(t *testing.T) {
+	var got map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = nil
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		_, _ = io.WriteString(w, `{"code":0}`)
+	}))
+	defer srv.Close()
+
+	c, err := NewClient("test", WithBaseURL(srv.URL), WithDefaultDeviceID("DEF"), WithRateLimiter(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.SendText(context.Background(), TextRequest{Title: "keep"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Refresh(context.Background(), ""); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got["refreshNow"] != true || got["deviceId"] != "DEF" {
+		t.Fatalf("payload %v", got)
+	}
+	// A refresh does not replace the tracked content.
+	if rec, ok := c.LastSent("DEF"); !ok || rec.Text == nil || rec.Text.Title != "keep" {
+		t.Fatalf("last sent %+v", rec)
+	}
+}
+
 func TestSendImage_MissingPayload(t *testing.T) {
 	c, err := NewClient("test", WithDefaultDeviceID("DEF"), WithRateLimiter(nil))
 	if err != nil {
@@ -180,6 +212,142 @@ func TestSendImage_MissingPayload(t *testing.T) {
 	}
 }
 
+func TestSendText_InvalidUTF8(t *testing.T) {
+	c, err := NewClient("test", WithBaseURL("http://127.0.0.1:0"), WithDefaultDeviceID("DEF"), WithRateLimiter(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.SendText(context.Background(), TextRequest{Message: "ok\xff\xfe"})
+	if !errors.Is(err, ErrInvalidText) || !IsValidationError(err) {
+		t.Fatalf("want ErrInvalidText, got %v", err)
+	}
+}
+
+func TestBuildRequests(t *testing.T) {
+	c, err := NewClient("test", WithBaseURL("http://example.test/"), WithDefaultDeviceID("DEF"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := c.BuildText(TextRequest{Title: "t"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.URL != "http://example.test/api/open/text" || req.DeviceID != "DEF" || req.Payload.(*TextRequest).DeviceID != "DEF" {
+		t.Fatalf("got %+v", req)
+	}
+	req, err = c.BuildImage(ImageRequest{DeviceID: "X", ImageBytes: []byte("hi")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img := req.Payload.(*ImageRequest); req.DeviceID != "X" || img.Image != "aGk=" || img.ImageBytes != nil {
+		t.Fatalf("got %+v", img)
+	}
+	if _, err := c.BuildImage(ImageRequest{}); !errors.Is(err, ErrImagePayloadMissing) {
+		t.Fatalf("want ErrImagePayloadMissing, got %v", err)
+	}
+}
+
+func TestBaseURLPathPrefix(t *testing.T) {
+	for _, tt := range []struct {
+		base, want string
+	}{
+		{"", DefaultBaseURL + textEndpoint},
+		{"https://gw.example.com", "https://gw.example.com/api/open/text"},
+		{"https://gw.example.com/", "https://gw.example.com/api/open/text"},
+		{"https://gw.example.com/quote0", "https://gw.example.com/quote0/api/open/text"},
+		{"https://gw.example.com/quote0/", "https://gw.example.com/quote0/api/open/text"},
+		{" https://gw.example.com/quote0// ", "https://gw.example.com/quote0/api/open/text"},
+		{"http://gw.example.com:8080/a/b/", "http://gw.example.com:8080/a/b/api/open/text"},
+		{"https://gw.example.com/team%2Fone/", "https://gw.example.com/team%2Fone/api/open/text"},
+	} {
+		c, err := NewClient("test", WithBaseURL(tt.base), WithDefaultDeviceID("D"))
+		if err != nil {
+			t.Errorf("%q: %v", tt.base, err)
+			continue
+		}
+		if req, _ := c.BuildText(TextRequest{}); req.URL != tt.want {
+			t.Errorf("%q: URL %s, want %s", tt.base, req.URL, tt.want)
+		}
+	}
+
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.URL.Path
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"code":0}`)
+	}))
+	defer srv.Close()
+	c, _ := NewClient("test", WithBaseURL(srv.URL+"/quote0/"), WithRateLimiter(nil), WithDefaultDeviceID("D"))
+	if _, err := c.SendText(context.Background(), TextRequest{}); err != nil || got != "/quote0/api/open/text" {
+		t.Fatalf("path %q, err %v", got, err)
+	}
+
+	for _, bad := range []string{"https://gw.example.com/quote0?x=1", "https://gw.example.com/?", "https://gw.example.com/#top", "gw.example.com/quote0", "ftp://gw.example.com"} {
+		if _, err := NewClient("test", WithBaseURL(bad)); err == nil {
+			t.Errorf("base URL %q accepted", bad)
+		}
+		if _, err := NewClient("test", WithFallbackBaseURLs(bad)); err == nil {
+			t.Errorf("fallback URL %q accepted", bad)
+		}
+	}
+}
+
+func TestPreparedRequestHash(t *testing.T) {
+	c, err := NewClient("test", WithDefaultDeviceID("DEF"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := func(req *PreparedRequest, err error) string {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return req.Hash()
+	}
+	base := hash(c.BuildText(TextRequest{Title: "t", Message: "m"}))
+	if len(base) != 64 {
+		t.Fatalf("hash %q", base)
+	}
+	if h := hash(c.BuildText(TextRequest{Title: "t", Message: "m", DeviceID: "DEF"})); h != base {
+		t.Fatal("same content on the same device hashed differently")
+	}
+	for name, h := range map[string]string{
+		"message": hash(c.BuildText(TextRequest{Title: "t", Message: "m2"})),
+		"device":  hash(c.BuildText(TextRequest{Title: "t", Message: "m", DeviceID: "OTHER"})),
+		"refresh": hash(c.BuildText(TextRequest{Title: "t", Message: "m", RefreshNow: Bool(false)})),
+	} {
+		if h == base {
+			t.Errorf("changed %s kept the hash", name)
+		}
+	}
+	img := hash(c.BuildImage(ImageRequest{ImageBytes: []byte("hi")}))
+	if img2 := hash(c.BuildImage(ImageRequest{Image: "aGk="})); img2 != img {
+		t.Fatal("raw bytes and the same base64 hashed differently")
+	}
+}
+
+func TestBuildText_IconBytes(t *testing.T) {
+	c, err := NewClient("test", WithDefaultDeviceID("DEF"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	icon, _ := NewCanvasSize(IconSize, IconSize).PNG()
+	req, err := c.BuildText(TextRequest{IconBytes: icon})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := req.Payload.(*TextRequest); got.Icon != base64.StdEncoding.EncodeToString(icon) || got.IconBytes != nil {
+		t.Fatalf("got %+v", got)
+	}
+	big, _ := NewCanvas().PNG()
+	if _, err := c.BuildText(TextRequest{IconBytes: big}); !errors.Is(err, ErrIconSize) || !IsValidationError(err) {
+		t.Fatalf("want ErrIconSize, got %v", err)
+	}
+	if _, err := c.BuildText(TextRequest{IconBytes: []byte("nope")}); !errors.Is(err, ErrInvalidImage) {
+		t.Fatalf("want ErrInvalidImage, got %v", err)
+	}
+}
+
 func TestSendTextSimple_VariadicSignature(t *testing.T) {
 	sigs := make([]string, 0, 2)
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -215,6 +383,138 @@ func TestNewClientRequiresToken(t *testing.T) {
 	}
 }
 
+func TestWithHTTPTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	custom := &http.Client{Timeout: time.Hour}
+	for name, opts := range map[string][]ClientOption{
+		"own client":    {WithHTTPTimeout(50 * time.Millisecond)},
+		"after custom":  {WithHTTPClient(custom), WithHTTPTimeout(50 * time.Millisecond)},
+		"before custom": {WithHTTPTimeout(50 * time.Millisecond), WithHTTPClient(custom)},
+	} {
+		c, err := NewClient("test", append(opts, WithBaseURL(srv.URL), WithRateLimiter(nil), WithDefaultDeviceID("D"))...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		start := time.Now()
+		_, err = c.SendText(context.Background(), TextRequest{Title: "t"})
+		if !IsNetworkError(err) || time.Since(start) > 5*time.Second {
+			t.Fatalf("%s: err %v after %v", name, err, time.Since(start))
+		}
+	}
+	if custom.Timeout != time.Hour {
+		t.Fatalf("custom client modified: %v", custom.Timeout)
+	}
+	for _, d := range []time.Duration{0, -time.Second} {
+		c, _ := NewClient("test", WithHTTPClient(&http.Client{}), WithHTTPTimeout(d))
+		if c.http.Timeout != defaultHTTPTimeout {
+			t.Fatalf("WithHTTPTimeout(%v) gave %v", d, c.http.Timeout)
+		}
+	}
+}
+
+func TestWithStrictCode(t *testing.T) {
+	var body, contentType string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		_, _ = io.WriteString(w, body)
+	}))
+	defer srv.Close()
+	strict, _ := NewClient("test", WithBaseURL(srv.URL), WithRateLimiter(nil), WithDefaultDeviceID("D"), WithStrictCode())
+	lenient, _ := NewClient("test", WithBaseURL(srv.URL), WithRateLimiter(nil), WithDefaultDeviceID("D"))
+	ctx := context.Background()
+
+	body, contentType = `{"code":500,"message":"device offline","result":{"deviceId":"D"}}`, "application/json"
+	resp, err := strict.SendText(ctx, TextRequest{Title: "t"})
+	var ae *APIError
+	if !errors.As(err, &ae) || ae.StatusCode != 200 || ae.Code != "500" || ae.Message != "device offline" || string(ae.RawBody) != body {
+		t.Fatalf("strict err %v", err)
+	}
+	if resp == nil || resp.Code != 500 || string(resp.Result) != `{"deviceId":"D"}` || ae.RequestID != resp.RequestID {
+		t.Fatalf("strict resp %+v", resp)
+	}
+	if _, ok := strict.LastSent("D"); ok {
+		t.Fatal("rejected content tracked by LastSent")
+	}
+	if resp, err := lenient.SendText(ctx, TextRequest{Title: "t"}); err != nil || resp.Code != 500 {
+		t.Fatalf("lenient: %+v, %v", resp, err)
+	}
+
+	for _, ok := range []struct{ body, contentType string }{
+		{`{"code":0,"message":"ok"}`, "application/json"},
+		{"发送成功", "text/plain; charset=utf-8"},
+		{`{"code":7}`, "text/plain"}, // not declared JSON, so parsed as text
+	} {
+		body, contentType = ok.body, ok.contentType
+		if _, err := strict.SendText(ctx, TextRequest{Title: "t"}); err != nil {
+			t.Errorf("%q: %v", ok.body, err)
+		}
+	}
+}
+
+func TestCheckAuth(t *testing.T) {
+	var status int
+	var body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := io.ReadAll(r.Body)
+		body = string(raw)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = io.WriteString(w, `{"code":0}`)
+	}))
+	defer srv.Close()
+	var waits int
+	limiter := RateLimiterFunc(func(context.Context) error { waits++; return nil })
+	c, _ := NewClient("test", WithBaseURL(srv.URL), WithRateLimiter(limiter), WithDefaultDeviceID("D"))
+	ctx := context.Background()
+
+	for _, tt := range []struct {
+		status int
+		want   error
+	}{
+		{200, nil}, {401, ErrUnauthorized}, {403, ErrUnauthorized}, {404, ErrDeviceNotFound},
+	} {
+		status = tt.status
+		err := c.CheckAuth(ctx)
+		if tt.want == nil && err != nil || tt.want != nil && !errors.Is(err, tt.want) {
+			t.Errorf("status %d: %v", tt.status, err)
+		}
+		if errors.Is(err, ErrDeviceNotFound) && tt.want == ErrUnauthorized {
+			t.Errorf("status %d matched both sentinels", tt.status)
+		}
+	}
+	if body != `{"refreshNow":false,"deviceId":"D"}` || waits != 4 {
+		t.Fatalf("sent %s after %d limiter waits", body, waits)
+	}
+	if _, ok := c.LastSent("D"); ok {
+		t.Fatal("CheckAuth tracked by LastSent")
+	}
+
+	status = 500
+	if err := c.CheckAuth(ctx); errors.Is(err, ErrUnauthorized) || errors.Is(err, ErrDeviceNotFound) || err == nil {
+		t.Fatalf("500: %v", err)
+	}
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	blocking, _ := NewClient("test", WithBaseURL(srv.URL), WithDefaultDeviceID("D"),
+		WithRateLimiter(RateLimiterFunc(func(ctx context.Context) error { <-ctx.Done(); return ctx.Err() })))
+	if err := blocking.CheckAuth(canceled); !errors.Is(err, context.Canceled) {
+		t.Fatalf("canceled: %v", err)
+	}
+	noDevice, _ := NewClient("test", WithBaseURL(srv.URL))
+	if err := noDevice.CheckAuth(ctx); !errors.Is(err, ErrDeviceIDMissing) {
+		t.Fatalf("no device: %v", err)
+	}
+}
+
 func TestBuildAPIErrorJSON(t *testing.T) {
 	err := buildAPIError(400, []byte(`{"code":"E100","message":"boom"}`))
 	apiErr, ok := err.(*APIError)
@@ -226,6 +526,32 @@ func TestBuildAPIErrorJSON(t *testing.T) {
 	}
 }
 
+func TestErrorClassification(t *testing.T) {
+	wrap := func(err error) error { return fmt.Errorf("send: %w", err) }
+	netErr := &url.Error{Op: "Post", URL: "http://x", Err: errors.New("connection refused")}
+	cases := []struct {
+		err                                     error
+		rate, auth, device, validation, network bool
+	}{
+		{err: wrap(buildAPIError(429, nil)), rate: true},
+		{err: buildAPIError(401, nil), auth: true},
+		{err: wrap(buildAPIError(403, nil)), auth: true},
+		{err: buildAPIError(404, []byte("device not found")), device: true},
+		{err: ErrDeviceIDMissing, validation: true},
+		{err: fmt.Errorf("%w: got 1x1", ErrImageSize), validation: true},
+		{err: wrap(netErr), network: true},
+		{err: context.DeadlineExceeded, network: true},
+		{err: buildAPIError(500, nil)},
+	}
+	for _, tc := range cases {
+		got := [5]bool{IsRateLimitError(tc.err), IsAuthError(tc.err), IsDeviceError(tc.err), IsValidationError(tc.err), IsNetworkError(tc.err)}
+		want := [5]bool{tc.rate, tc.auth, tc.device, tc.validation, tc.network}
+		if got != want {
+			t.Errorf("%v: got %v want %v", tc.err, got, want)
+		}
+	}
+}
+
 func TestBorderColor_JSONSerialization(t *testing.T) {
 	// Test that BorderColor serializes to int in JSON
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -267,6 +593,19 @@ func TestBorderColor_Constants(t *testing.T) {
 	}
 }
 
+func TestParseBorderColor(t *testing.T) {
+	for in, want := range map[string]BorderColor{"white": BorderWhite, "BLACK": BorderBlack, " Black ": BorderBlack, "0": BorderWhite, "1": BorderBlack} {
+		if got, err := ParseBorderColor(in); err != nil || got != want {
+			t.Errorf("ParseBorderColor(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "red", "2"} {
+		if _, err := ParseBorderColor(in); err == nil || !strings.Contains(err.Error(), "want white, black, 0, or 1") {
+			t.Errorf("ParseBorderColor(%q) err %v", in, err)
+		}
+	}
+}
+
 func TestTextRequest_OmitEmptyFields(t *testing.T) {
 	// Verify that empty title and signature are omitted from JSON
 	var capturedBody []byte
@@ -384,6 +723,38 @@ func TestDefaultUserAgent(t *testing.T) {
 	}
 }
 
+func TestUserAgentSuffix(t *testing.T) {
+	var receivedUA string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedUA = r.Header.Get("User-Agent")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"code":0,"message":"ok"}`)
+	}))
+	defer srv.Close()
+
+	for _, tt := range []struct {
+		opts []ClientOption
+		want string
+	}{
+		{[]ClientOption{WithUserAgentSuffix("  officeboard/2.3 ")}, buildDefaultUserAgent() + " officeboard/2.3"},
+		{[]ClientOption{WithUserAgentSuffix("office\tboard/2.3"), WithUserAgentSuffix(""), WithUserAgentSuffix("(kiosk  7)")},
+			buildDefaultUserAgent() + " office board/2.3 (kiosk 7)"},
+		{[]ClientOption{WithUserAgentSuffix("app/1"), WithUserAgent("custom/9")}, "custom/9 app/1"},
+		{[]ClientOption{WithUserAgent(""), WithUserAgentSuffix("app/1")}, "app/1"},
+	} {
+		c, err := NewClient("test-token", append(tt.opts, WithBaseURL(srv.URL), WithRateLimiter(nil), WithDefaultDeviceID("TEST"))...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := c.SendText(context.Background(), TextRequest{Message: "ua"}); err != nil {
+			t.Fatal(err)
+		}
+		if receivedUA != tt.want {
+			t.Errorf("User-Agent %q, want %q", receivedUA, tt.want)
+		}
+	}
+}
+
 // TestAllWithOptionsNil tests that the client handles all With options being set to nil/empty gracefully.
 // This ensures defensive coding and proper fallback to defaults.
 func TestAllWithOptionsNil(t *testing.T) {
@@ -480,6 +851,75 @@ func TestAllWithOptionsNil(t *testing.T) {
 	}
 }
 
+func TestDebugWriter(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"code":0,"message":"ok"}`)
+	}))
+	defer srv.Close()
+	const token = "short_secret"
+	image := strings.Repeat("QUFB", 100)
+
+	for _, level := range []DebugLevel{DebugSummary, DebugFull, DebugWire} {
+		var buf bytes.Buffer
+		c, err := NewClient(token, WithBaseURL(srv.URL), WithRateLimiter(nil), WithDefaultDeviceID("D"), WithDebugWriter(&buf, level))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := c.SendImage(context.Background(), ImageRequest{Image: image}); err != nil {
+			t.Fatal(err)
+		}
+		out := buf.String()
+		if strings.Contains(out, token) || strings.Contains(out, image) {
+			t.Fatalf("level %d leaked token or image: %s", level, out)
+		}
+		lines := strings.Count(out, "\n")
+		switch level {
+		case DebugSummary:
+			if lines != 2 || !strings.Contains(out, "POST "+srv.URL+"/api/open/image") || !strings.Contains(out, "200 OK in") {
+				t.Fatalf("summary: %s", out)
+			}
+		case DebugFull:
+			if !strings.Contains(out, "Bearer [redacted]") || !strings.Contains(out, `"image": "<400 bytes base64>"`) {
+				t.Fatalf("full: %s", out)
+			}
+		case DebugWire:
+			for _, want := range []string{
+				"POST /api/open/image HTTP/1.1\r\n", "Host: " + strings.TrimPrefix(srv.URL, "http://"), "Content-Length: 4",
+				"Authorization: Bearer [redacted]", `"image":"<400 bytes base64>"`,
+				"HTTP/1.1 200 OK\r\n", "Content-Type: application/json", `{"code":0,"message":"ok"}`,
+			} {
+				if !strings.Contains(out, want) {
+					t.Fatalf("wire dump lacks %q: %s", want, out)
+				}
+			}
+		}
+	}
+
+	// A real API key keeps its prefix; no character of the secret part may appear.
+	const key = "dot_app_A1b2C3d4E5f6G7h8I9j0K1l2M3n4O5p6"
+	for _, level := range []DebugLevel{DebugFull, DebugWire} {
+		var buf bytes.Buffer
+		c, err := NewClient(key, WithBaseURL(srv.URL), WithRateLimiter(nil), WithDefaultDeviceID("D"), WithDebugWriter(&buf, level))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := c.SendText(context.Background(), TextRequest{Title: "t"}); err != nil {
+			t.Fatal(err)
+		}
+		out := buf.String()
+		if !strings.Contains(out, "Bearer dot_app_***") {
+			t.Fatalf("level %d: masked key missing: %s", level, out)
+		}
+		secret := strings.TrimPrefix(key, "dot_app_")
+		for i := 0; i+4 <= len(secret); i++ {
+			if strings.Contains(out, secret[i:i+4]) {
+				t.Fatalf("level %d leaked %q of the key: %s", level, secret[i:i+4], out)
+			}
+		}
+	}
+}
+
 // TestDebugMode tests that debug mode logs request and response details.
 func TestDebugMode(t *testing.T) {
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
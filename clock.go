@@ -0,0 +1,52 @@
+package quote0
+
+import (
+	"context"
+	"time"
+)
+
+// Clock is the time source for the client's scheduling: rate limiter waits, retry backoff,
+// rate-limit cooldowns, the circuit breaker, failover re-probing, and BatchItem.Delay. Tests
+// can supply a fake one, such as quote0test.FakeClock, to advance time without sleeping.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// Sleep waits d, returning early with ctx.Err() if ctx ends first. A non-positive d
+	// returns at once unless ctx has already ended.
+	Sleep(ctx context.Context, d time.Duration) error
+}
+
+// systemClock is the real Clock used when none is given.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+func (systemClock) Sleep(ctx context.Context, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if d <= 0 {
+		return nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// WithClock makes the client schedule by clk instead of the real clock. It also becomes the
+// clock of the client's limiter when that is a *FixedIntervalLimiter without one of its own
+// (see SetClock), including the default limiter. Durations the client measures, such as
+// Timing, History, hook, and log durations, and timestamps like SentRecord.SentAt still come
+// from the real clock. A nil clk keeps the real clock.
+func WithClock(clk Clock) ClientOption {
+	return func(c *Client) {
+		if clk != nil {
+			c.clock = clk
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package quote0
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/1set/quote0/quote0test"
+)
+
+func TestWithClock_LimiterAndBackoff(t *testing.T) {
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&hits, 1) == 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_, _ = io.WriteString(w, `{"code":0}`)
+	}))
+	defer srv.Close()
+	clk := quote0test.NewFakeClock(time.Date(2025, 11, 10, 9, 0, 0, 0, time.UTC))
+	c, err := NewClient("test", WithBaseURL(srv.URL), WithDefaultDeviceID("D"),
+		WithRetry(2, time.Hour), WithClock(clk))
+	if err != nil {
+		t.Fatal(err)
+	}
+	send := func() chan error {
+		done := make(chan error, 1)
+		go func() {
+			_, err := c.SendText(context.Background(), TextRequest{Title: "t"})
+			done <- err
+		}()
+		return done
+	}
+	if err := <-send(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The default limiter waits its 1s interval on the fake clock.
+	done := send()
+	clk.BlockUntil(1)
+	if n := atomic.LoadInt32(&hits); n != 1 {
+		t.Fatalf("limiter let call through early: %d hits", n)
+	}
+	clk.Advance(time.Second)
+
+	// The 503 is then retried after an hour of fake backoff.
+	clk.BlockUntil(1)
+	if n := atomic.LoadInt32(&hits); n != 2 {
+		t.Fatalf("hits before backoff = %d, want 2", n)
+	}
+	clk.Advance(time.Hour)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("send did not finish after Advance")
+	}
+	if n := atomic.LoadInt32(&hits); n != 3 {
+		t.Fatalf("hits = %d, want 3", n)
+	}
+}
+
+func TestWithClock_KeepsLimiterClock(t *testing.T) {
+	own := quote0test.NewFakeClock(time.Unix(0, 0))
+	l := NewIntervalLimiter(time.Second)
+	l.SetClock(own)
+	if _, err := NewClient("test", WithRateLimiter(l), WithClock(quote0test.NewFakeClock(time.Unix(5, 0)))); err != nil {
+		t.Fatal(err)
+	}
+	if l.clk != own {
+		t.Fatal("WithClock replaced the limiter's own clock")
+	}
+}
+
+func TestWithClock_BatchDelay(t *testing.T) {
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		_, _ = io.WriteString(w, `{"code":0}`)
+	}))
+	defer srv.Close()
+	clk := quote0test.NewFakeClock(time.Date(2025, 11, 10, 9, 0, 0, 0, time.UTC))
+	c, err := NewClient("test", WithBaseURL(srv.URL), WithRateLimiter(nil), WithDefaultDeviceID("D"), WithClock(clk))
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := []BatchItem{
+		{Text: &TextRequest{Message: "a"}},
+		{Text: &TextRequest{Message: "b"}, Delay: time.Hour},
+	}
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.SendBatch(context.Background(), items)
+		done <- err
+	}()
+
+	blockUntil(t, clk, 1)
+	if n := atomic.LoadInt32(&hits); n != 1 {
+		t.Fatalf("%d sends before the delay elapsed, want 1", n)
+	}
+	clk.Advance(time.Hour)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("batch did not finish after Advance")
+	}
+	if n := atomic.LoadInt32(&hits); n != 2 {
+		t.Fatalf("%d sends, want 2", n)
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/1set/quote0"
+)
+
+// newBaseURLCLI is newTestCLI without the base URL override, so the environment decides.
+func newBaseURLCLI(env map[string]string) (*cli, *bytes.Buffer) {
+	var stdout, stderr bytes.Buffer
+	return &cli{
+		stdin:         strings.NewReader(""),
+		stdout:        &stdout,
+		stderr:        &stderr,
+		getenv:        func(k string) string { return env[k] },
+		clientOptions: []quote0.ClientOption{quote0.WithRateLimiter(nil)},
+	}, &stderr
+}
+
+func TestBaseURL_Env(t *testing.T) {
+	api := &fakeAPI{}
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+	c, stderr := newBaseURLCLI(map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D", "QUOTE0_BASE_URL": srv.URL + "/"})
+	if code := c.run([]string{"text", "-title", "staging", "-v"}); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if api.count() != 1 || api.body(0)["title"] != "staging" {
+		t.Fatalf("bodies %v", api.bodies)
+	}
+	if !strings.Contains(stderr.String(), "API host: "+strings.TrimPrefix(srv.URL, "http://")) {
+		t.Fatalf("stderr %q", stderr)
+	}
+}
+
+func TestBaseURL_FlagOverridesEnv(t *testing.T) {
+	api := &fakeAPI{}
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+	c, stderr := newBaseURLCLI(map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D", "QUOTE0_BASE_URL": "http://127.0.0.1:1"})
+	data, _ := quote0.NewCanvas().PNG()
+	c.stdin = bytes.NewReader(data)
+	if code := c.run([]string{"image", "-image-file", "-", "-base-url", srv.URL}); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if api.count() != 1 {
+		t.Fatalf("requests %d", api.count())
+	}
+}
+
+// failReader fails the test if anything reads stdin.
+type failReader struct{ t *testing.T }
+
+func (r failReader) Read([]byte) (int, error) {
+	r.t.Error("stdin was read")
+	return 0, nil
+}
+
+func TestBaseURL_Invalid(t *testing.T) {
+	for _, tc := range []struct {
+		env, flag, want string
+	}{
+		{"ftp://relay", "", "QUOTE0_BASE_URL: \"ftp://relay\" is not an http(s) URL"},
+		{"", "relay.example.com", "-base-url: \"relay.example.com\" is not an http(s) URL"},
+		{"https://relay.example.com", "https://relay?x=1", "must not have a query"},
+	} {
+		c, stderr := newBaseURLCLI(map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D", "QUOTE0_BASE_URL": tc.env})
+		c.stdin = failReader{t}
+		args := []string{"text", "-message-file", "-"}
+		if tc.flag != "" {
+			args = append(args, "-base-url", tc.flag)
+		}
+		if code := c.run(args); code != exitUsage || !strings.Contains(stderr.String(), tc.want) {
+			t.Errorf("%+v: exit %d, stderr %q", tc, code, stderr)
+		}
+	}
+}
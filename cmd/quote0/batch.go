@@ -0,0 +1,257 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/1set/quote0"
+)
+
+// maxPlanLine bounds one JSONL line; image requests carry base64 PNGs.
+const maxPlanLine = 4 << 20
+
+// planEntry is one line of a batch plan.
+type planEntry struct {
+	Type    string          `json:"type"`
+	Request json.RawMessage `json:"request"`
+	Delay   string          `json:"delay,omitempty"`
+}
+
+// planItem is a validated plan line.
+type planItem struct {
+	line int
+	kind string
+	item quote0.BatchItem
+}
+
+// batchLine is the per-line outcome printed by the summary.
+type batchLine struct {
+	Line    int    `json:"line"`
+	Type    string `json:"type"`
+	Device  string `json:"device,omitempty"`
+	OK      bool   `json:"ok"`
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+func (c *cli) runBatch(args []string) error {
+	fs, cf := c.newFlagSet("batch")
+	file := fs.String("file", "", "JSONL plan path, or - for stdin")
+	keepGoing := fs.Bool("continue-on-error", false, "Keep sending after a failed item")
+	asJSON := fs.Bool("json", false, "Print the summary as JSON")
+	if err := c.parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return usagef("batch needs -file PLAN.jsonl (or -file - for stdin)")
+	}
+
+	var r io.Reader = c.stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	device, err := cf.device.single("batch")
+	if err != nil {
+		return err
+	}
+	plan, err := readPlan(r, *file, device != "")
+	if err != nil {
+		return err
+	}
+	client, err := c.newClientAnyDevice(cf)
+	if err != nil {
+		return err
+	}
+
+	if *cf.dryRun {
+		return c.dryRunBatch(client, plan)
+	}
+
+	items := make([]quote0.BatchItem, len(plan))
+	for i, p := range plan {
+		items[i] = p.item
+	}
+	var opts []quote0.BatchOption
+	if !*keepGoing {
+		opts = append(opts, quote0.WithStopOnError())
+	}
+	ctx, cancel := c.commandContext(cf)
+	defer cancel()
+	results, _ := client.SendBatch(ctx, items, opts...)
+
+	lines := make([]batchLine, len(plan))
+	var firstErr error
+	failed := 0
+	for i, res := range results {
+		l := batchLine{Line: plan[i].line, Type: plan[i].kind, Device: res.DeviceID, OK: res.Err == nil}
+		if res.Response != nil {
+			l.Code, l.Message = res.Response.Code, res.Response.Message
+		}
+		if res.Err != nil {
+			l.Error = res.Err.Error()
+			failed++
+			if firstErr == nil && !errors.Is(res.Err, quote0.ErrBatchAborted) {
+				firstErr = fmt.Errorf("line %d: %w", plan[i].line, res.Err)
+			}
+		}
+		lines[i] = l
+	}
+	if err := c.printBatchSummary(lines, *asJSON); err != nil {
+		return err
+	}
+	if failed > 0 {
+		if firstErr == nil {
+			firstErr = quote0.ErrBatchAborted
+		}
+		return fmt.Errorf("%d of %d plan items failed; first: %w", failed, len(plan), firstErr)
+	}
+	return nil
+}
+
+// dryRunBatch prints every plan item as it would be sent. All items are printed even when
+// some fail validation; the first failure is returned.
+func (c *cli) dryRunBatch(client *quote0.Client, plan []planItem) error {
+	var firstErr error
+	failed := 0
+	for _, p := range plan {
+		fmt.Fprintf(c.stdout, "# line %d: %s\n", p.line, p.kind)
+		var req *quote0.PreparedRequest
+		var err error
+		if p.item.Text != nil {
+			req, err = client.BuildText(*p.item.Text)
+		} else {
+			req, err = client.BuildImage(*p.item.Image)
+		}
+		if err == nil {
+			if p.item.Delay > 0 {
+				fmt.Fprintf(c.stdout, "(after %s)\n", p.item.Delay)
+			}
+			err = c.dryRun(req, nil)
+		}
+		if err != nil {
+			fmt.Fprintf(c.stdout, "INVALID: %v\n", err)
+			failed++
+			if firstErr == nil {
+				firstErr = fmt.Errorf("line %d: %w", p.line, err)
+			}
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d plan items failed; first: %w", failed, len(plan), firstErr)
+	}
+	return nil
+}
+
+// readPlan parses and validates every line before anything is sent, so a typo on the last
+// line does not leave a half-applied plan. Blank lines are skipped.
+func readPlan(r io.Reader, name string, haveDefaultDevice bool) ([]planItem, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64<<10), maxPlanLine)
+	var plan []planItem
+	for n := 1; sc.Scan(); n++ {
+		raw := bytes.TrimSpace(sc.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+		item, err := parsePlanLine(raw, haveDefaultDevice)
+		if err != nil {
+			return nil, usagef("%s:%d: %v", name, n, err)
+		}
+		item.line = n
+		plan = append(plan, item)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, usagef("%s: %v", name, err)
+	}
+	if len(plan) == 0 {
+		return nil, usagef("%s: plan is empty", name)
+	}
+	return plan, nil
+}
+
+func parsePlanLine(raw []byte, haveDefaultDevice bool) (planItem, error) {
+	var e planEntry
+	if err := strictJSON(raw, &e); err != nil {
+		return planItem{}, err
+	}
+	var p planItem
+	if e.Delay != "" {
+		d, err := time.ParseDuration(e.Delay)
+		if err != nil || d < 0 {
+			return planItem{}, fmt.Errorf("invalid delay %q", e.Delay)
+		}
+		p.item.Delay = d
+	}
+	if len(e.Request) == 0 {
+		return planItem{}, errors.New(`missing "request"`)
+	}
+	var device string
+	switch e.Type {
+	case "text":
+		var req quote0.TextRequest
+		if err := strictJSON(e.Request, &req); err != nil {
+			return planItem{}, fmt.Errorf("request: %v", err)
+		}
+		device, p.item.Text = req.DeviceID, &req
+	case "image":
+		var req quote0.ImageRequest
+		if err := strictJSON(e.Request, &req); err != nil {
+			return planItem{}, fmt.Errorf("request: %v", err)
+		}
+		if strings.TrimSpace(req.Image) == "" {
+			return planItem{}, quote0.ErrImagePayloadMissing
+		}
+		device, p.item.Image = req.DeviceID, &req
+	default:
+		return planItem{}, fmt.Errorf(`invalid type %q (want "text" or "image")`, e.Type)
+	}
+	if strings.TrimSpace(device) == "" && !haveDefaultDevice {
+		return planItem{}, errors.New("request has no deviceId and no -device or QUOTE0_DEVICE is set")
+	}
+	p.kind = e.Type
+	return p, nil
+}
+
+func strictJSON(raw []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if dec.More() {
+		return errors.New("unexpected data after the JSON object")
+	}
+	return nil
+}
+
+func (c *cli) printBatchSummary(lines []batchLine, asJSON bool) error {
+	if asJSON {
+		enc := json.NewEncoder(c.stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(lines)
+	}
+	tw := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "LINE\tTYPE\tDEVICE\tRESULT")
+	for _, l := range lines {
+		result := fmt.Sprintf("ok (code=%d message=%s)", l.Code, l.Message)
+		if !l.OK {
+			result = "FAILED: " + l.Error
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", l.Line, l.Type, l.Device, result)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/1set/quote0"
+)
+
+const testPlan = `{"type":"text","request":{"title":"one"}}
+
+{"type":"text","request":{"message":"fail"},"delay":"1ms"}
+{"type":"image","request":{"deviceId":"OTHER","image":"aGVsbG8="}}
+`
+
+// newBatchCLI returns a cli whose fake server answers 500 for text messages equal to "fail".
+func newBatchCLI(t *testing.T, plan string) (*cli, *[]string, *bytes.Buffer, *bytes.Buffer) {
+	t.Helper()
+	var sent []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		sent = append(sent, r.URL.Path+" "+body["deviceId"].(string))
+		if body["message"] == "fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"code":0,"message":"ok"}`)
+	}))
+	t.Cleanup(srv.Close)
+	var stdout, stderr bytes.Buffer
+	env := map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"}
+	return &cli{
+		stdin:         strings.NewReader(plan),
+		stdout:        &stdout,
+		stderr:        &stderr,
+		getenv:        func(k string) string { return env[k] },
+		clientOptions: []quote0.ClientOption{quote0.WithBaseURL(srv.URL), quote0.WithRateLimiter(nil)},
+	}, &sent, &stdout, &stderr
+}
+
+func TestBatch_StopsOnError(t *testing.T) {
+	c, sent, stdout, _ := newBatchCLI(t, testPlan)
+	if code := c.run([]string{"batch", "-file", "-"}); code != exitError {
+		t.Fatalf("exit %d", code)
+	}
+	if len(*sent) != 2 {
+		t.Fatalf("sent %v", *sent)
+	}
+	out := stdout.String()
+	for _, want := range []string{"LINE", "1     text   D       ok (code=0 message=ok)", "3     text   D       FAILED", "4     image  OTHER   FAILED: quote0: batch aborted"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("summary missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestBatch_ContinueOnErrorJSON(t *testing.T) {
+	c, sent, stdout, stderr := newBatchCLI(t, testPlan)
+	if code := c.run([]string{"batch", "-file", "-", "-continue-on-error", "-json"}); code != exitError {
+		t.Fatalf("exit %d", code)
+	}
+	if len(*sent) != 3 || (*sent)[2] != "/api/open/image OTHER" {
+		t.Fatalf("sent %v", *sent)
+	}
+	var lines []batchLine
+	if err := json.Unmarshal(stdout.Bytes(), &lines); err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 3 || !lines[0].OK || lines[1].OK || lines[1].Line != 3 || !lines[2].OK {
+		t.Fatalf("summary %+v", lines)
+	}
+	if !strings.Contains(stderr.String(), "1 of 3 plan items failed; first: line 3") {
+		t.Fatalf("stderr %q", stderr)
+	}
+}
+
+func TestBatch_ValidatesUpFront(t *testing.T) {
+	for _, tc := range []struct {
+		plan, want string
+	}{
+		{`{"type":"text","request":{}}` + "\n" + `{"type":"video","request":{}}`, "-:2: invalid type"},
+		{`{"type":"text","request":{"titel":"x"}}`, `-:1: request: json: unknown field "titel"`},
+		{`{"type":"text","request":{},"delay":"soon"}`, `-:1: invalid delay "soon"`},
+		{`{"type":"image","request":{}}`, "-:1: quote0: image payload is required"},
+		{`{"type":"text"}`, `-:1: missing "request"`},
+		{"\n\n", "plan is empty"},
+	} {
+		c, sent, _, stderr := newBatchCLI(t, tc.plan)
+		if code := c.run([]string{"batch", "-file", "-"}); code != exitUsage || !strings.Contains(stderr.String(), tc.want) {
+			t.Errorf("%q: exit %d, stderr %q", tc.plan, code, stderr)
+		}
+		if len(*sent) != 0 {
+			t.Errorf("%q: sent %v", tc.plan, *sent)
+		}
+	}
+}
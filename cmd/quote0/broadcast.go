@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/1set/quote0"
+)
+
+// broadcastFlags are the options `broadcast` adds to the text or image command it runs.
+type broadcastFlags struct {
+	devicesFrom *string
+	concurrency *int
+	bestEffort  *bool
+}
+
+func addBroadcastFlags(fs *flag.FlagSet) *broadcastFlags {
+	return &broadcastFlags{
+		devicesFrom: fs.String("devices-from", "", "Read device serials from this file, one per line (# comments), or - for stdin"),
+		concurrency: fs.Int("concurrency", 1, "Sends in flight at once; useful only when the rate limit allows it (-rate 0 on a relay)"),
+		bestEffort:  fs.Bool("best-effort", false, "Exit 0 even if some or all devices failed; the table still shows them"),
+	}
+}
+
+// runBroadcast runs `text` or `image` for a list of devices, from -device (or QUOTE0_DEVICE)
+// or -devices-from, and prints a table with a row per device. The command fails if any
+// device failed unless -best-effort; -json prints an array even for one device.
+func (c *cli) runBroadcast(args []string) error {
+	if len(args) == 0 || (args[0] != "text" && args[0] != "image") {
+		return usagef("usage: quote0 broadcast text|image [-devices-from FILE] [-concurrency N] [-best-effort] [flags]")
+	}
+	fs := flag.NewFlagSet("broadcast", flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	bf := addBroadcastFlags(fs)
+	own, rest := takeFlags(fs, args[1:])
+	if err := c.parseFlags(fs, own); err != nil {
+		return err
+	}
+	if *bf.concurrency < 1 {
+		return usagef("-concurrency must be at least 1")
+	}
+	if *bf.devicesFrom != "" {
+		ids, err := readDeviceList(*bf.devicesFrom, c.stdin)
+		if err != nil {
+			return usagef("-devices-from: %v", err)
+		}
+		for _, arg := range rest {
+			if name := strings.TrimLeft(strings.SplitN(arg, "=", 2)[0], "-"); strings.HasPrefix(arg, "-") && name == "device" {
+				return usagef("use -device or -devices-from, not both")
+			}
+		}
+		rest = append([]string{"-device", strings.Join(ids, ",")}, rest...)
+	}
+	c.broadcast = bf
+	defer func() { c.broadcast = nil }()
+	if args[0] == "text" {
+		return c.runText(rest)
+	}
+	return c.runImage(rest)
+}
+
+// batchOptions are the SDK options for fan-out sends: -concurrency under broadcast.
+func (c *cli) batchOptions() []quote0.BatchOption {
+	if c.broadcast == nil {
+		return nil
+	}
+	return []quote0.BatchOption{quote0.WithConcurrency(*c.broadcast.concurrency)}
+}
+
+// reportBroadcast prints the per-device table (or JSON array) of a broadcast.
+func (c *cli) reportBroadcast(lines []deviceResult, sf *sendFlags, kind string, failed int, firstErr error) error {
+	if *sf.asJSON {
+		if err := c.printJSON(lines); err != nil {
+			return err
+		}
+	} else {
+		tw := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "DEVICE\tRESULT\tDETAIL")
+		for _, l := range lines {
+			result, detail := "ok", fmt.Sprintf("code=%d message=%s", l.Code, l.Message)
+			switch {
+			case l.Skipped:
+				result, detail = "skipped", "unchanged"
+			case !l.OK:
+				result, detail = "FAILED", l.Error
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", l.Device, result, detail)
+		}
+		tw.Flush()
+		fmt.Fprintf(c.stdout, "%s: %d of %d devices ok\n", kind, len(lines)-failed, len(lines))
+	}
+	if failed == 0 || *c.broadcast.bestEffort {
+		return nil
+	}
+	return fmt.Errorf("%d of %d devices failed; first: %w", failed, len(lines), firstErr)
+}
+
+// takeFlags removes the flags defined in fs from args, with their values, and returns them
+// apart from the remaining arguments. Other flags are left in place for the inner command.
+func takeFlags(fs *flag.FlagSet, args []string) (own, rest []string) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		var f *flag.Flag
+		if strings.HasPrefix(arg, "-") && arg != "-" && arg != "--" {
+			f = fs.Lookup(strings.SplitN(strings.TrimLeft(arg, "-"), "=", 2)[0])
+		}
+		if f == nil {
+			rest = append(rest, arg)
+			continue
+		}
+		own = append(own, arg)
+		if b, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && b.IsBoolFlag() || strings.Contains(arg, "=") {
+			continue
+		}
+		if i+1 < len(args) {
+			i++
+			own = append(own, args[i])
+		}
+	}
+	return own, rest
+}
+
+// readDeviceList reads serials one per line from path (- for stdin), skipping blank lines and
+// # comments.
+func readDeviceList(path string, stdin io.Reader) ([]string, error) {
+	r := stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	var ids []string
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		if line != "" {
+			ids = append(ids, line)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("%s lists no devices", path)
+	}
+	return ids, nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/1set/quote0"
+)
+
+func newBroadcastCLI(t *testing.T, missing ...string) (*cli, *[]string, *bytes.Buffer) {
+	t.Helper()
+	c, _, stdout, _ := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "ENV"})
+	return c, deviceAPI(t, c, missing...), stdout
+}
+
+func TestBroadcast_DevicesFrom(t *testing.T) {
+	list := filepath.Join(t.TempDir(), "devices.txt")
+	if err := os.WriteFile(list, []byte("# office\nA1\n\nB2  # lobby\nC3\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c, api, stdout := newBroadcastCLI(t)
+	if code := c.run([]string{"broadcast", "text", "-title", "Fire drill 15:00", "-devices-from", list, "-concurrency", "2"}); code != 0 {
+		t.Fatalf("exit %d\n%s", code, stdout)
+	}
+	if len(*api) != 3 {
+		t.Fatalf("sent to %v", *api)
+	}
+	out := stdout.String()
+	for _, want := range []string{"DEVICE  RESULT  DETAIL", "A1      ok", "C3      ok", "Text: 3 of 3 devices ok"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestBroadcast_Failures(t *testing.T) {
+	c, _, stdout := newBroadcastCLI(t, "B2")
+	if code := c.run([]string{"broadcast", "text", "-title", "x", "-device", "A1,B2"}); code != exitDevice {
+		t.Fatalf("exit %d\n%s", code, stdout)
+	}
+	if !strings.Contains(stdout.String(), "B2      FAILED") || !strings.Contains(stdout.String(), "1 of 2 devices ok") {
+		t.Errorf("output:\n%s", stdout)
+	}
+
+	c, _, stdout = newBroadcastCLI(t, "A1", "B2")
+	if code := c.run([]string{"broadcast", "text", "-best-effort", "-title", "x", "-device", "A1,B2"}); code != 0 {
+		t.Fatalf("-best-effort: exit %d\n%s", code, stdout)
+	}
+}
+
+func TestBroadcast_JSON(t *testing.T) {
+	screen, _ := quote0.NewCanvas().PNG()
+	path := filepath.Join(t.TempDir(), "screen.png")
+	if err := os.WriteFile(path, screen, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// One device from QUOTE0_DEVICE still prints an array.
+	c, api, stdout := newBroadcastCLI(t)
+	if code := c.run([]string{"broadcast", "image", "-image-file", path, "-json"}); code != 0 {
+		t.Fatalf("exit %d\n%s", code, stdout)
+	}
+	var results []deviceResult
+	if err := json.Unmarshal(stdout.Bytes(), &results); err != nil {
+		t.Fatalf("%v: %s", err, stdout)
+	}
+	if len(results) != 1 || results[0].Device != "ENV" || !results[0].OK || len(*api) != 1 {
+		t.Fatalf("results %+v", results)
+	}
+}
+
+func TestBroadcast_Usage(t *testing.T) {
+	list := filepath.Join(t.TempDir(), "empty.txt")
+	if err := os.WriteFile(list, []byte("# nobody\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"broadcast"},
+		{"broadcast", "refresh"},
+		{"broadcast", "text", "-title", "x", "-concurrency", "0"},
+		{"broadcast", "text", "-title", "x", "-devices-from", list},
+		{"broadcast", "text", "-title", "x", "-devices-from", "missing.txt"},
+		{"broadcast", "text", "-title", "x", "-devices-from", list, "-device", "A1"},
+	} {
+		c, api, _ := newBroadcastCLI(t)
+		if code := c.run(args); code != exitUsage || len(*api) != 0 {
+			t.Errorf("%v: exit %d, sent to %v", args, code, *api)
+		}
+	}
+}
+
+func TestTakeFlags(t *testing.T) {
+	fs := flag.NewFlagSet("broadcast", flag.ContinueOnError)
+	addBroadcastFlags(fs)
+	own, rest := takeFlags(fs, []string{"-title", "a", "-best-effort", "-concurrency", "3", "-devices-from=f", "-", "--", "-x"})
+	if strings.Join(own, " ") != "-best-effort -concurrency 3 -devices-from=f" || strings.Join(rest, " ") != "-title a - -- -x" {
+		t.Fatalf("own %q, rest %q", own, rest)
+	}
+}
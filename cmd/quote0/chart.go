@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"image/png"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/1set/quote0"
+)
+
+// maxChartInput caps the CSV read by `chart`; a chart shows a few hundred points at most.
+const maxChartInput = 16 << 20
+
+// runChart plots one CSV column with the SDK chart renderer and sends it as an image, or
+// writes the PNG with -out.
+func (c *cli) runChart(args []string) error {
+	fs, cf := c.newFlagSet("chart")
+	input := fs.String("input", "-", "CSV file, or - for stdin")
+	column := fs.String("column", "1", "Column to plot: 1-based index or header name")
+	delimiter := fs.String("delimiter", ",", "Field separator, e.g. ';' or '\\t'")
+	title := fs.String("title", "", "Chart title")
+	kind := fs.String("type", string(quote0.ChartSparkline), "Chart type: sparkline|bar")
+	minFlag := fs.String("min", "", "Fix the bottom of the axis (default: fit the data)")
+	maxFlag := fs.String("max", "", "Fix the top of the axis (default: fit the data)")
+	autoScale := fs.Bool("auto-scale", true, "Fit the axis to the data; false starts it at zero")
+	out := fs.String("out", "", "Write the chart PNG here instead of sending it (no token needed)")
+	link := fs.String("link", "", "Optional URL")
+	border := addBorderFlag(fs)
+	refresh := addRefreshFlag(fs)
+	sf := addSendFlags(fs)
+	if err := c.parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return usagef("chart takes no arguments, got %q", fs.Arg(0))
+	}
+	chartType := quote0.ChartType(strings.ToLower(strings.TrimSpace(*kind)))
+	switch chartType {
+	case quote0.ChartSparkline, quote0.ChartBar:
+	default:
+		return usagef("invalid -type %q (want sparkline or bar)", *kind)
+	}
+	lo, err := parseAxisFlag("min", *minFlag)
+	if err != nil {
+		return err
+	}
+	hi, err := parseAxisFlag("max", *maxFlag)
+	if err != nil {
+		return err
+	}
+	if !math.IsNaN(lo) && !math.IsNaN(hi) && lo >= hi {
+		return usagef("-min %s must be below -max %s", *minFlag, *maxFlag)
+	}
+	comma, err := parseDelimiter(*delimiter)
+	if err != nil {
+		return err
+	}
+
+	var data []byte
+	if *input == "-" {
+		if data, err = readStdinBytes(c.stdin, maxChartInput); err != nil {
+			return usagef("-input: %v", err)
+		}
+	} else if data, err = os.ReadFile(*input); err != nil {
+		return err
+	}
+	values, skipped, err := readSeries(bytes.NewReader(data), *column, comma)
+	if err != nil {
+		return err
+	}
+	if *cf.verbose > 0 || *cf.debug {
+		fmt.Fprintf(c.stderr, "Read %d values from column %s; skipped %d non-numeric cells\n", len(values), *column, skipped)
+	}
+	if len(values) == 0 {
+		return fmt.Errorf("%w in column %s (%d non-numeric cells skipped)", quote0.ErrEmptySeries, *column, skipped)
+	}
+	img, err := quote0.RenderChart(values,
+		quote0.WithChartType(chartType),
+		quote0.WithChartTitle(*title),
+		quote0.WithChartRange(lo, hi),
+		quote0.WithChartZero(!*autoScale))
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return err
+	}
+	if *out != "" {
+		if err := os.WriteFile(*out, buf.Bytes(), 0o644); err != nil {
+			return err
+		}
+		fmt.Fprintf(c.stderr, "Wrote %s (%d values)\n", *out, len(values))
+		return nil
+	}
+
+	req := quote0.ImageRequest{
+		RefreshNow: quote0.Bool(*refresh),
+		ImageBytes: buf.Bytes(),
+		Link:       *link,
+		Border:     quote0.BorderColor(*border),
+		DitherType: quote0.DitherNone, // the chart is already black and white
+	}
+	client, devices, err := c.newClientDevices(cf)
+	if err != nil {
+		return err
+	}
+	if *cf.dryRun {
+		return c.dryRunDevices(devices, sf, func(id string) (*quote0.PreparedRequest, error) {
+			req.DeviceID = id
+			return client.BuildImage(req)
+		})
+	}
+	ctx, cancel := c.commandContext(cf)
+	defer cancel()
+	return c.deliver(ctx, cf, devices, sf, imageOutgoing("Chart", client, req))
+}
+
+// parseAxisFlag parses -min or -max; empty means NaN (follow the data).
+func parseAxisFlag(name, s string) (float64, error) {
+	if strings.TrimSpace(s) == "" {
+		return math.NaN(), nil
+	}
+	v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, usagef("invalid -%s %q (want a number)", name, s)
+	}
+	return v, nil
+}
+
+// parseDelimiter accepts a single character, or \t for tab.
+func parseDelimiter(s string) (rune, error) {
+	if s == `\t` {
+		return '\t', nil
+	}
+	r, size := utf8.DecodeRuneInString(s)
+	if size == 0 || size != len(s) || r == '"' || r == '\r' || r == '\n' || r == utf8.RuneError {
+		return 0, usagef("invalid -delimiter %q (want one character, e.g. ';' or '\\t')", s)
+	}
+	return r, nil
+}
+
+// readSeries extracts the numeric cells of column (a 1-based index or a header name) from
+// CSV. The first row is a header when the column is named, or when its cell is not a number.
+// Cells that are missing or not finite numbers are skipped and counted.
+func readSeries(r io.Reader, column string, comma rune) (values []float64, skipped int, err error) {
+	cr := csv.NewReader(r)
+	cr.Comma = comma
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	cr.ReuseRecord = true
+
+	idx, byName := -1, true
+	if n, err := strconv.Atoi(strings.TrimSpace(column)); err == nil {
+		if n < 1 {
+			return nil, 0, usagef("invalid -column %d (columns are numbered from 1)", n)
+		}
+		idx, byName = n-1, false
+	}
+	for row := 0; ; row++ {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, 0, fmt.Errorf("csv: %w", err)
+		}
+		if row == 0 && byName {
+			for i, name := range record {
+				if strings.EqualFold(strings.TrimSpace(name), strings.TrimSpace(column)) {
+					idx = i
+				}
+			}
+			if idx < 0 {
+				return nil, 0, usagef("column %q not found in header %q", column, strings.Join(record, string(comma)))
+			}
+			continue
+		}
+		var cell string
+		if idx < len(record) {
+			cell = strings.TrimSpace(record[idx])
+		}
+		v, err := strconv.ParseFloat(cell, 64)
+		if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+			if row > 0 || cell == "" {
+				skipped++
+			}
+			continue // a non-numeric first row is the header
+		}
+		values = append(values, v)
+	}
+	return values, skipped, nil
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image/png"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/1set/quote0"
+)
+
+const tempsCSV = "time,outdoor,indoor\n08:00,12.5,21\n09:00,n/a,21.5\n10:00,14,22\n11:00,15.5,\n"
+
+func TestChart_SendsImage(t *testing.T) {
+	c, api, stdout, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D1"})
+	c.stdin = strings.NewReader(tempsCSV)
+	if code := c.run([]string{"chart", "-column", "outdoor", "-title", "Outdoor °C", "-v"}); code != exitOK {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if !strings.Contains(stderr.String(), "Read 3 values from column outdoor; skipped 1 non-numeric cells") {
+		t.Fatalf("stderr %q", stderr)
+	}
+	if !strings.Contains(stdout.String(), "Chart sent") {
+		t.Fatalf("stdout %q", stdout)
+	}
+	if api.count() != 1 {
+		t.Fatalf("requests %d", api.count())
+	}
+	body := api.body(0)
+	if body["ditherType"] != "NONE" {
+		t.Fatalf("ditherType %v", body["ditherType"])
+	}
+	data, err := base64.StdEncoding.DecodeString(body["image"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != quote0.ScreenWidth || b.Dy() != quote0.ScreenHeight {
+		t.Fatalf("size %v", b)
+	}
+}
+
+func TestChart_OutNeedsNoToken(t *testing.T) {
+	c, api, _, stderr := newTestCLI(t, nil)
+	dir := t.TempDir()
+	in := filepath.Join(dir, "deploys.csv")
+	if err := os.WriteFile(in, []byte("3;1;4;1;5\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "chart.png")
+	args := []string{"chart", "-input", in, "-delimiter", ";", "-type", "bar", "-min", "0", "-out", out}
+	if code := c.run(args); code != exitOK {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if api.count() != 0 {
+		t.Fatalf("sent %d requests", api.count())
+	}
+	if data, err := os.ReadFile(out); err != nil || !bytes.HasPrefix(data, []byte("\x89PNG")) {
+		t.Fatalf("output %v", err)
+	}
+}
+
+func TestChart_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		args  []string
+		code  int
+		msg   string
+	}{
+		{"empty series", "name\nn/a\n\n-\n", nil, exitError, "no numeric values in column 1 (2 non-numeric cells skipped)"},
+		{"unknown column", tempsCSV, []string{"-column", "humidity"}, exitUsage, `column "humidity" not found`},
+		{"bad type", tempsCSV, []string{"-type", "pie"}, exitUsage, `invalid -type "pie"`},
+		{"bad min", tempsCSV, []string{"-min", "low"}, exitUsage, `invalid -min "low"`},
+		{"min above max", tempsCSV, []string{"-min", "5", "-max", "1"}, exitUsage, "must be below -max"},
+		{"bad delimiter", tempsCSV, []string{"-delimiter", "::"}, exitUsage, "invalid -delimiter"},
+		{"column zero", tempsCSV, []string{"-column", "0"}, exitUsage, "numbered from 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, api, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D1"})
+			c.stdin = strings.NewReader(tt.input)
+			if code := c.run(append([]string{"chart"}, tt.args...)); code != tt.code {
+				t.Fatalf("exit %d, want %d: %s", code, tt.code, stderr)
+			}
+			if !strings.Contains(stderr.String(), tt.msg) {
+				t.Fatalf("stderr %q, want %q", stderr, tt.msg)
+			}
+			if api.count() != 0 {
+				t.Fatalf("sent %d requests", api.count())
+			}
+		})
+	}
+}
+
+func TestReadSeries(t *testing.T) {
+	tests := []struct {
+		name    string
+		csv     string
+		column  string
+		values  []float64
+		skipped int
+	}{
+		{"header detected by index", "t,v\n1,10\n2,20\n", "2", []float64{10, 20}, 0},
+		{"no header", "1,10\n2,20\n", "2", []float64{10, 20}, 0},
+		{"named column", "T, Value\n1, 10\n2, x\n", "value", []float64{10}, 1},
+		{"short rows", "1,10\n2\n3,30\n", "2", []float64{10, 30}, 1},
+		{"non-finite skipped", "v\nNaN\n+Inf\n4\n", "1", []float64{4}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, skipped, err := readSeries(strings.NewReader(tt.csv), tt.column, ',')
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(values, tt.values) || skipped != tt.skipped {
+				t.Fatalf("values %v skipped %d, want %v and %d", values, skipped, tt.values, tt.skipped)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"image/png"
+	"os"
+	"strings"
+
+	"github.com/1set/quote0"
+)
+
+// maxConvertInput bounds -in -; camera photos are well under this.
+const maxConvertInput = 64 << 20
+
+// runConvert runs the local image pipeline (decode, rotate, fit, dither) and writes the
+// result without sending it; no token or device is needed.
+func (c *cli) runConvert(args []string) error {
+	fs := flag.NewFlagSet("convert", flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	in := fs.String("in", "", "Input PNG or JPEG, or - for stdin")
+	out := fs.String("out", "", "Output path, or - for stdout")
+	fit := fs.String("fit", "", "Resize to 296x152: contain|cover|stretch (default: input must be 296x152)")
+	bg := fs.String("bg", "white", "Padding color for -fit contain: white|black")
+	resample := fs.String("resample", "", "Scaling filter for -fit: nearest|box|bilinear (default box)")
+	rotation := fs.Int("rotate", 0, "Rotate clockwise by 90, 180, or 270 degrees before fitting")
+	fs.Bool("grayscale", true, "Convert to grayscale (always done; accepted for readable pipelines)")
+	dither := fs.String("dither", "", "Dither to black and white: none, ordered, diffusion, or a kernel such as floyd_steinberg or atkinson (default: keep gray levels)")
+	serpentine := fs.Bool("serpentine", false, "Scan alternate rows right to left with -dither diffusion kernels, which breaks up streaks")
+	format := fs.String("format", "png", "Output format: png, or raw for packed 1-bit rows (MSB first, 1 = black)")
+	if err := c.parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *in == "" || *out == "" {
+		return usagef("convert needs -in FILE and -out FILE")
+	}
+	if fs.NArg() > 0 {
+		return usagef("convert takes no arguments, got %q", fs.Arg(0))
+	}
+	*format = strings.ToLower(strings.TrimSpace(*format))
+	if *format != "png" && *format != "raw" {
+		return usagef("invalid -format %q (want png or raw)", *format)
+	}
+	ditherType, kernel, err := parseDither(*dither)
+	if err != nil {
+		return err
+	}
+	if *rotation%90 != 0 {
+		return usagef("invalid -rotate %d (want a multiple of 90)", *rotation)
+	}
+	if err := checkFit(*fit, *bg); err != nil {
+		return err
+	}
+	filter := quote0.WithResample(quote0.ResampleFilter(*resample))
+	if ps := quote0.CheckProcessing(quote0.DitherNone, filter); quote0.HasErrors(ps) {
+		return usagef("invalid -resample: %s", ps[0].Message)
+	}
+
+	var data []byte
+	if *in == "-" {
+		data, err = readStdinBytes(c.stdin, maxConvertInput)
+		if err != nil {
+			return usagef("-in: %v", err)
+		}
+	} else if data, err = os.ReadFile(*in); err != nil {
+		return err
+	}
+	src, _, err := quote0.DecodeImage(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", *in, err)
+	}
+	fitMode := quote0.FitMode(strings.ToLower(strings.TrimSpace(*fit)))
+	bgColor := quote0.White
+	if strings.EqualFold(strings.TrimSpace(*bg), "black") {
+		bgColor = quote0.Black
+	}
+	opts := []quote0.ProcessOption{quote0.WithRotation(*rotation), quote0.WithFit(fitMode), quote0.WithBackground(bgColor), filter}
+	if ditherType != "" {
+		opts = append(opts, quote0.WithLocalDither(ditherType, kernel, quote0.WithSerpentine(*serpentine)))
+	}
+	img, err := quote0.ProcessImage(src, opts...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", *in, err)
+	}
+
+	var result []byte
+	if *format == "raw" {
+		result = quote0.PackMonochrome(img)
+	} else {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			return err
+		}
+		result = buf.Bytes()
+	}
+	if *out == "-" {
+		_, err = c.stdout.Write(result)
+		return err
+	}
+	if err := os.WriteFile(*out, result, 0o644); err != nil {
+		return err
+	}
+	fmt.Fprintf(c.stderr, "Wrote %s (%s, %d bytes)\n", *out, *format, len(result))
+	return nil
+}
+
+// parseDither maps -dither to a dither type and kernel; empty means no dithering.
+func parseDither(s string) (quote0.DitherType, quote0.DitherKernel, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	switch quote0.DitherType(s) {
+	case "":
+		return "", "", nil
+	case quote0.DitherNone, quote0.DitherOrdered, quote0.DitherDiffusion:
+		return quote0.DitherType(s), "", nil
+	}
+	kernel := quote0.DitherKernel(s)
+	for _, k := range quote0.DitherKernels() {
+		if kernel == k {
+			return quote0.DitherDiffusion, kernel, nil
+		}
+	}
+	return "", "", usagef("invalid -dither %q (want none, ordered, diffusion, or a kernel such as floyd_steinberg)", strings.ToLower(s))
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/1set/quote0"
+	"github.com/1set/quote0/quote0test"
+)
+
+func writeTestJPEG(t *testing.T, w, h int) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, w, h)), nil); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "photo.jpg")
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestConvert_PNG(t *testing.T) {
+	c, api, _, stderr := newTestCLI(t, nil)
+	in := writeTestJPEG(t, 200, 600)
+	out := filepath.Join(t.TempDir(), "frame.png")
+	args := []string{"convert", "-in", in, "-out", out, "-fit", "cover", "-grayscale", "-dither", "floyd_steinberg", "-rotate", "90"}
+	if code := c.run(args); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if api.count() != 0 {
+		t.Fatalf("convert sent %d requests", api.count())
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != quote0.ScreenWidth || b.Dy() != quote0.ScreenHeight {
+		t.Fatalf("size %v", b)
+	}
+	if !strings.Contains(stderr.String(), "Wrote "+out) {
+		t.Fatalf("stderr %q", stderr)
+	}
+}
+
+func TestConvert_RawToStdout(t *testing.T) {
+	c, _, stdout, stderr := newTestCLI(t, nil)
+	in := writeTestJPEG(t, 64, 48)
+	if code := c.run([]string{"convert", "-in", in, "-out", "-", "-fit", "contain", "-format", "raw"}); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if want := (quote0.ScreenWidth + 7) / 8 * quote0.ScreenHeight; stdout.Len() != want {
+		t.Fatalf("raw output %d bytes, want %d", stdout.Len(), want)
+	}
+}
+
+func TestConvert_Serpentine(t *testing.T) {
+	c, _, _, stderr := newTestCLI(t, nil)
+	in := writeGradientPNG(t)
+	out := filepath.Join(t.TempDir(), "frame.png")
+	if code := c.run([]string{"convert", "-in", in, "-out", out, "-fit", "stretch", "-dither", "floyd_steinberg", "-serpentine"}); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	data, _ := os.ReadFile(out)
+	got, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := os.ReadFile(in)
+	src, _, _ := quote0.DecodeImage(raw)
+	screen, _ := quote0.ProcessImage(src, quote0.WithFit(quote0.FitStretch))
+	want, _ := quote0.Dither(screen, quote0.DitherDiffusion, quote0.KernelFloydSteinberg, quote0.WithSerpentine(true))
+	quote0test.AssertImagesEqual(t, got, want, 0)
+}
+
+func TestConvert_Resample(t *testing.T) {
+	c, _, _, stderr := newTestCLI(t, nil)
+	in := writeTestJPEG(t, 64, 48)
+	out := filepath.Join(t.TempDir(), "frame.png")
+	if code := c.run([]string{"convert", "-in", in, "-out", out, "-fit", "cover", "-resample", "Bilinear"}); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	data, _ := os.ReadFile(out)
+	got, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := os.ReadFile(in)
+	src, _, _ := quote0.DecodeImage(raw)
+	want, _ := quote0.ProcessImage(src, quote0.WithFit(quote0.FitCover), quote0.WithResample(quote0.ResampleBilinear))
+	quote0test.AssertImagesEqual(t, got, want, 0)
+}
+
+func TestConvert_Errors(t *testing.T) {
+	jpg := writeTestJPEG(t, 64, 48)
+	var gifData bytes.Buffer
+	if err := gif.Encode(&gifData, image.NewGray(image.Rect(0, 0, 8, 8)), nil); err != nil {
+		t.Fatal(err)
+	}
+	gifPath := filepath.Join(t.TempDir(), "anim.gif")
+	if err := os.WriteFile(gifPath, gifData.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(t.TempDir(), "out.png")
+	tests := []struct {
+		name string
+		args []string
+		code int
+		msg  string
+	}{
+		{"no out", []string{"-in", jpg}, exitUsage, "needs -in FILE and -out FILE"},
+		{"bad format", []string{"-in", jpg, "-out", out, "-format", "bmp"}, exitUsage, `invalid -format "bmp"`},
+		{"bad dither", []string{"-in", jpg, "-out", out, "-dither", "blur"}, exitUsage, `invalid -dither "blur"`},
+		{"bad fit", []string{"-in", jpg, "-out", out, "-fit", "zoom"}, exitUsage, `invalid -fit "zoom"`},
+		{"gif", []string{"-in", gifPath, "-out", out, "-fit", "cover"}, exitValidation, "anim.gif"},
+		{"wrong size", []string{"-in", jpg, "-out", out}, exitValidation, "photo.jpg"},
+		{"bad resample", []string{"-in", jpg, "-out", out, "-fit", "cover", "-resample", "lanczos"}, exitUsage, `invalid -resample: unknown filter "lanczos"`},
+		{"bad rotation", []string{"-in", jpg, "-out", out, "-fit", "cover", "-rotate", "45"}, exitUsage, "invalid -rotate 45"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _, _, stderr := newTestCLI(t, nil)
+			if code := c.run(append([]string{"convert"}, tt.args...)); code != tt.code {
+				t.Fatalf("exit %d, want %d: %s", code, tt.code, stderr)
+			}
+			if !strings.Contains(stderr.String(), tt.msg) {
+				t.Fatalf("stderr %q, want %q", stderr, tt.msg)
+			}
+		})
+	}
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Fatalf("output written on failure: %v", err)
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image/png"
+	"os"
+	"strings"
+
+	"github.com/1set/quote0"
+)
+
+// runDemo sends a built-in test pattern, or writes it with -out, to tell panel artifacts from
+// pipeline ones.
+func (c *cli) runDemo(args []string) error {
+	fs, cf := c.newFlagSet("demo")
+	pattern := fs.String("pattern", string(quote0.PatternGrid), "Test pattern: "+patternNames())
+	cell := fs.Int("cell", 0, "Checkerboard cell size or grid spacing in pixels (default 8 and 16)")
+	ditherType := fs.String("dither-type", "", "Dither type (default NONE, or the server default for gradients)")
+	out := fs.String("out", "", "Write the pattern PNG here instead of sending it (no token needed)")
+	link := fs.String("link", "", "Optional URL")
+	border := addBorderFlag(fs)
+	refresh := addRefreshFlag(fs)
+	sf := addSendFlags(fs)
+	if err := c.parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return usagef("demo takes no arguments, got %q", fs.Arg(0))
+	}
+	kind := quote0.PatternKind(strings.ToLower(strings.TrimSpace(*pattern)))
+	img, err := quote0.TestPattern(kind, quote0.WithPatternCell(*cell))
+	if errors.Is(err, quote0.ErrInvalidPattern) {
+		return usagef("-pattern %s -cell %d: %v", *pattern, *cell, err)
+	} else if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return err
+	}
+	if *out != "" {
+		if err := os.WriteFile(*out, buf.Bytes(), 0o644); err != nil {
+			return err
+		}
+		fmt.Fprintf(c.stderr, "Wrote %s (%s)\n", *out, kind)
+		return nil
+	}
+
+	req := quote0.ImageRequest{
+		RefreshNow: quote0.Bool(*refresh),
+		ImageBytes: buf.Bytes(),
+		Link:       *link,
+		Border:     quote0.BorderColor(*border),
+		DitherType: quote0.DitherType(strings.ToUpper(strings.TrimSpace(*ditherType))),
+	}
+	if req.DitherType == "" && kind != quote0.PatternGradientH && kind != quote0.PatternGradientV {
+		req.DitherType = quote0.DitherNone // the pattern is already black and white
+	}
+	client, devices, err := c.newClientDevices(cf)
+	if err != nil {
+		return err
+	}
+	if *cf.dryRun {
+		return c.dryRunDevices(devices, sf, func(id string) (*quote0.PreparedRequest, error) {
+			req.DeviceID = id
+			return client.BuildImage(req)
+		})
+	}
+	ctx, cancel := c.commandContext(cf)
+	defer cancel()
+	return c.deliver(ctx, cf, devices, sf, imageOutgoing("Pattern", client, req))
+}
+
+// patternNames lists the test patterns for help text, separated by |.
+func patternNames() string {
+	kinds := quote0.PatternKinds()
+	names := make([]string, len(kinds))
+	for i, k := range kinds {
+		names[i] = string(k)
+	}
+	return strings.Join(names, "|")
+}
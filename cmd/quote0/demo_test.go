@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/1set/quote0"
+	"github.com/1set/quote0/quote0test"
+)
+
+func TestDemo_SendsGrid(t *testing.T) {
+	c, api, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D1"})
+	if code := c.run([]string{"demo", "-pattern", "Grid", "-border", "black"}); code != exitOK {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if api.count() != 1 || api.paths[0] != "/api/open/image" {
+		t.Fatalf("requests %v", api.paths)
+	}
+	body := api.body(0)
+	if body["ditherType"] != "NONE" || body["border"] != float64(1) {
+		t.Fatalf("body %v", body)
+	}
+	data, _ := base64.StdEncoding.DecodeString(body["image"].(string))
+	got, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := quote0.TestPattern(quote0.PatternGrid)
+	quote0test.AssertImagesEqual(t, got, want, 0)
+}
+
+func TestDemo_GradientKeepsServerDither(t *testing.T) {
+	c, api, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D1"})
+	if code := c.run([]string{"demo", "-pattern", "gradient-h"}); code != exitOK {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if _, ok := api.body(0)["ditherType"]; ok {
+		t.Fatalf("body %v", api.body(0))
+	}
+}
+
+func TestDemo_Out(t *testing.T) {
+	c, api, _, stderr := newTestCLI(t, nil)
+	out := filepath.Join(t.TempDir(), "checker.png")
+	if code := c.run([]string{"demo", "-pattern", "checkerboard", "-cell", "2", "-out", out}); code != exitOK {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if api.count() != 0 {
+		t.Fatalf("sent %d requests", api.count())
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := quote0.TestPattern(quote0.PatternCheckerboard, quote0.WithPatternCell(2))
+	quote0test.AssertImagesEqual(t, got, want, 0)
+}
+
+func TestDemo_Usage(t *testing.T) {
+	for _, tt := range []struct {
+		args []string
+		msg  string
+	}{
+		{[]string{"demo", "-pattern", "zigzag"}, `unknown kind "zigzag"`},
+		{[]string{"demo", "-cell", "100"}, "cell size 100, want 1 to 76"},
+		{[]string{"demo", "grid"}, "demo takes no arguments"},
+	} {
+		c, api, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D1"})
+		if code := c.run(tt.args); code != exitUsage {
+			t.Fatalf("%v: exit %d: %s", tt.args, code, stderr)
+		}
+		if api.count() != 0 || !strings.Contains(stderr.String(), tt.msg) {
+			t.Fatalf("%v: %d requests, stderr %q", tt.args, api.count(), stderr)
+		}
+	}
+}
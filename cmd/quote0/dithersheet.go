@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/1set/quote0"
+)
+
+// ditherVariant is one tile of a dither sheet.
+type ditherVariant struct {
+	label  string
+	typ    quote0.DitherType
+	kernel quote0.DitherKernel
+}
+
+// ditherVariants lists every local dither mode: ordered, plain threshold, and each kernel.
+func ditherVariants() []ditherVariant {
+	vs := []ditherVariant{
+		{label: "ordered", typ: quote0.DitherOrdered},
+		{label: "none", typ: quote0.DitherNone},
+	}
+	for _, k := range quote0.DitherKernels() {
+		vs = append(vs, ditherVariant{label: strings.ToLower(string(k)), typ: quote0.DitherDiffusion, kernel: k})
+	}
+	return vs
+}
+
+// Sheet layout, in pixels. The local sheet shows every variant at full screen size; the
+// device sheet fits small tiles of all of them on one screen.
+const (
+	sheetCols   = 4
+	sheetGap    = 6
+	sheetLabelH = quote0.LineHeight + 4
+	deviceTileW = quote0.ScreenWidth / sheetCols
+)
+
+// runDitherSheet dithers one picture with every mode and kernel and writes the results as a
+// labeled contact sheet, optionally ranking them by tone error or sending a small version.
+func (c *cli) runDitherSheet(args []string) error {
+	fs, cf := c.newFlagSet("dither-sheet")
+	in := fs.String("in", "", "Input PNG or JPEG, or - for stdin")
+	out := fs.String("out", "", "Write the contact sheet PNG here")
+	fit := fs.String("fit", string(quote0.FitCover), "Resize to 296x152 first: contain|cover|stretch")
+	bg := fs.String("bg", "white", "Padding color for -fit contain: white|black")
+	rank := fs.Bool("rank", false, "Print the variants ranked by tone error (lower keeps gray levels better)")
+	send := fs.Bool("send", false, "Also send a one-screen sheet with small tiles to the device")
+	sf := addSendFlags(fs)
+	if err := c.parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *in == "" || (*out == "" && !*send && !*rank) {
+		return usagef("dither-sheet needs -in FILE and at least one of -out FILE, -rank, or -send")
+	}
+	if fs.NArg() > 0 {
+		return usagef("dither-sheet takes no arguments, got %q", fs.Arg(0))
+	}
+	if quote0.FitMode(strings.ToLower(strings.TrimSpace(*fit))) == quote0.FitNone {
+		return usagef("invalid -fit %q (want contain, cover, or stretch)", *fit)
+	}
+	if err := checkFit(*fit, *bg); err != nil {
+		return err
+	}
+
+	var data []byte
+	var err error
+	if *in == "-" {
+		if data, err = readStdinBytes(c.stdin, maxConvertInput); err != nil {
+			return usagef("-in: %v", err)
+		}
+	} else if data, err = os.ReadFile(*in); err != nil {
+		return err
+	}
+	src, _, err := quote0.DecodeImage(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", *in, err)
+	}
+	bgColor := quote0.White
+	if strings.EqualFold(strings.TrimSpace(*bg), "black") {
+		bgColor = quote0.Black
+	}
+	screen, err := quote0.ProcessImage(src,
+		quote0.WithFit(quote0.FitMode(strings.ToLower(strings.TrimSpace(*fit)))),
+		quote0.WithBackground(bgColor))
+	if err != nil {
+		return fmt.Errorf("%s: %w", *in, err)
+	}
+
+	variants := ditherVariants()
+	tiles := make([]*image.Gray, len(variants))
+	scores := make([]float64, len(variants))
+	for i, v := range variants {
+		if tiles[i], err = quote0.Dither(screen, v.typ, v.kernel); err != nil {
+			return err
+		}
+		if scores[i], err = quote0.ToneError(screen, tiles[i]); err != nil {
+			return err
+		}
+	}
+
+	if *out != "" {
+		labels := make([]string, len(variants))
+		for i, v := range variants {
+			labels[i] = v.label
+		}
+		if err := writePNGFile(*out, contactSheet(labels, tiles, sheetCols)); err != nil {
+			return err
+		}
+		fmt.Fprintf(c.stderr, "Wrote %s (%d variants)\n", *out, len(variants))
+	}
+	if *rank {
+		order := make([]int, len(variants))
+		for i := range order {
+			order[i] = i
+		}
+		sort.SliceStable(order, func(a, b int) bool { return scores[order[a]] < scores[order[b]] })
+		for n, i := range order {
+			fmt.Fprintf(c.stdout, "%2d. %-20s %6.2f\n", n+1, variants[i].label, scores[i])
+		}
+	}
+	if !*send {
+		return nil
+	}
+
+	sheet, err := deviceSheet(variants, screen)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, sheet); err != nil {
+		return err
+	}
+	req := quote0.ImageRequest{ImageBytes: buf.Bytes(), DitherType: quote0.DitherNone}
+	client, devices, err := c.newClientDevices(cf)
+	if err != nil {
+		return err
+	}
+	if *cf.dryRun {
+		return c.dryRunDevices(devices, sf, func(id string) (*quote0.PreparedRequest, error) {
+			req.DeviceID = id
+			return client.BuildImage(req)
+		})
+	}
+	ctx, cancel := c.commandContext(cf)
+	defer cancel()
+	return c.deliver(ctx, cf, devices, sf, imageOutgoing("Sheet", client, req))
+}
+
+// contactSheet lays out tiles of one size in a grid of cols columns, each labeled below.
+// Cells widen to fit the longest label.
+func contactSheet(labels []string, tiles []*image.Gray, cols int) *image.Gray {
+	tileW, tileH := tiles[0].Rect.Dx(), tiles[0].Rect.Dy()
+	cellW := tileW
+	for _, l := range labels {
+		if w := quote0.TextWidth(l, 1); w > cellW {
+			cellW = w
+		}
+	}
+	cellW += sheetGap
+	cellH := tileH + sheetLabelH + sheetGap
+	rows := (len(tiles) + cols - 1) / cols
+	c := quote0.NewCanvasSize(sheetGap+cols*cellW, sheetGap+rows*cellH)
+	for i, tile := range tiles {
+		x := sheetGap + i%cols*cellW
+		y := sheetGap + i/cols*cellH
+		r := image.Rect(x, y, x+tileW, y+tileH)
+		c.DrawImage(r, tile)
+		c.StrokeRect(r.Inset(-1), 1, quote0.Black)
+		c.DrawText(x, r.Max.Y+3, labels[i], 1, quote0.Black)
+	}
+	return c.Image()
+}
+
+// deviceSheet fits every variant on one screen: each tile is the picture shrunk to the tile,
+// dithered at that size so the pattern is the real one, with its label above.
+func deviceSheet(variants []ditherVariant, screen *image.Gray) (*image.Gray, error) {
+	c := quote0.NewCanvas()
+	tileH := quote0.ScreenHeight / ((len(variants) + sheetCols - 1) / sheetCols)
+	imgH := tileH - quote0.LineHeight - 1
+	small := quote0.NewCanvasSize(deviceTileW-2, imgH)
+	small.DrawImage(small.Bounds(), screen)
+	for i, v := range variants {
+		tile, err := quote0.Dither(small.Image(), v.typ, v.kernel)
+		if err != nil {
+			return nil, err
+		}
+		x := i % sheetCols * deviceTileW
+		y := i / sheetCols * tileH
+		c.DrawText(x+1, y, quote0.FitText(v.label, deviceTileW-2, 1), 1, quote0.Black)
+		c.DrawImage(image.Rect(x+1, y+quote0.LineHeight, x+deviceTileW-1, y+quote0.LineHeight+imgH), tile)
+	}
+	return c.Image(), nil
+}
+
+// writePNGFile encodes img to path.
+func writePNGFile(path string, img image.Image) error {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
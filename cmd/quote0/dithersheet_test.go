@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/1set/quote0"
+)
+
+func writeGradientPNG(t *testing.T) string {
+	t.Helper()
+	img := image.NewGray(image.Rect(0, 0, 320, 160))
+	for y := 0; y < 160; y++ {
+		for x := 0; x < 320; x++ {
+			img.SetGray(x, y, color.Gray{Y: uint8(x * 255 / 319)})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "gradient.png")
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestDitherSheet_LocalWithRank(t *testing.T) {
+	c, api, stdout, stderr := newTestCLI(t, nil)
+	out := filepath.Join(t.TempDir(), "sheet.png")
+	if code := c.run([]string{"dither-sheet", "-in", writeGradientPNG(t), "-out", out, "-rank"}); code != exitOK {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if api.count() != 0 {
+		t.Fatalf("sent %d requests", api.count())
+	}
+	f, err := os.Open(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	cfg, err := png.DecodeConfig(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Width < sheetCols*quote0.ScreenWidth || cfg.Height < 3*quote0.ScreenHeight {
+		t.Fatalf("sheet %dx%d too small for 12 full-size tiles", cfg.Width, cfg.Height)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) != len(ditherVariants()) {
+		t.Fatalf("ranking has %d lines:\n%s", len(lines), stdout)
+	}
+	// Plain thresholding loses every mid tone of a gradient, so it ranks last.
+	last := lines[len(lines)-2:]
+	for _, l := range last {
+		if !strings.Contains(l, "none") && !strings.Contains(l, "threshold") {
+			t.Fatalf("ranking ends with %q", last)
+		}
+	}
+}
+
+func TestDitherSheet_Send(t *testing.T) {
+	c, api, stdout, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D1"})
+	if code := c.run([]string{"dither-sheet", "-in", writeGradientPNG(t), "-send"}); code != exitOK {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if !strings.Contains(stdout.String(), "Sheet sent") || api.count() != 1 {
+		t.Fatalf("stdout %q, %d requests", stdout, api.count())
+	}
+	data, err := base64.StdEncoding.DecodeString(api.body(0)["image"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != quote0.ScreenWidth || b.Dy() != quote0.ScreenHeight {
+		t.Fatalf("device sheet %v", b)
+	}
+}
+
+func TestDitherSheet_Errors(t *testing.T) {
+	in := writeGradientPNG(t)
+	tests := []struct {
+		name string
+		args []string
+		msg  string
+	}{
+		{"no output", []string{"-in", in}, "at least one of -out FILE, -rank, or -send"},
+		{"no input", []string{"-rank"}, "needs -in FILE"},
+		{"fit none", []string{"-in", in, "-rank", "-fit", ""}, "invalid -fit"},
+		{"send without token", []string{"-in", in, "-send"}, "missing API token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, api, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_DEVICE": "D1"})
+			if code := c.run(append([]string{"dither-sheet"}, tt.args...)); code != exitUsage {
+				t.Fatalf("exit %d: %s", code, stderr)
+			}
+			if !strings.Contains(stderr.String(), tt.msg) {
+				t.Fatalf("stderr %q, want %q", stderr, tt.msg)
+			}
+			if api.count() != 0 {
+				t.Fatalf("sent %d requests", api.count())
+			}
+		})
+	}
+}
@@ -0,0 +1,329 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/1set/quote0"
+)
+
+// Results of a doctor check, from best to worst.
+const (
+	checkPass = "pass"
+	checkSkip = "skip"
+	checkWarn = "warn"
+	checkFail = "fail"
+)
+
+// doctorCheck is one row of the doctor table. code is the exit code a failure maps to.
+type doctorCheck struct {
+	name, status, detail, hint string
+	code                       int
+}
+
+func pass(name, detail string) doctorCheck {
+	return doctorCheck{name: name, status: checkPass, detail: detail}
+}
+
+func skip(name, detail string) doctorCheck {
+	return doctorCheck{name: name, status: checkSkip, detail: detail}
+}
+
+// runDoctor checks the configuration and the path to the API in order (token, device, DNS,
+// TLS, then an authenticated ping) and prints a table with hints. The exit code is 0 when
+// everything passed, 1 when the worst finding is a warning, and for a failure the usual
+// code of its kind (2 configuration, 4 auth, 6 device, 7 network).
+func (c *cli) runDoctor(args []string) error {
+	fs, cf := c.newFlagSet("doctor")
+	offline := fs.Bool("offline", false, "Only check the configuration; no DNS, TLS, or API requests")
+	skipPing := fs.Bool("skip-ping", false, "Check DNS and TLS but skip the authenticated ping, which refreshes the display")
+	if err := c.parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return usagef("doctor takes no arguments, got %q", fs.Arg(0))
+	}
+	token := strings.TrimSpace(*cf.token)
+	devices := cf.device.ids
+
+	level := quote0.DebugLevel(*cf.verbose)
+	if *cf.debug {
+		level = quote0.DebugFull
+	}
+	limiter := quote0.RateLimiter(nil)
+	if *cf.rate > 0 {
+		limiter = quote0.NewFixedIntervalLimiter(*cf.rate)
+	}
+	opts := []quote0.ClientOption{quote0.WithDebugWriter(c.stderr, level), quote0.WithRateLimiter(limiter)}
+	if base := strings.TrimSpace(*cf.baseURL); base != "" {
+		opts = append(opts, quote0.WithBaseURL(base))
+	}
+	if len(devices) > 0 {
+		opts = append(opts, quote0.WithDefaultDeviceID(devices[0]))
+	}
+	apiKey := token
+	if apiKey == "" {
+		apiKey = "doctor" // never sent: the ping is skipped without a token
+	}
+	client, err := quote0.NewClient(apiKey, append(opts, c.clientOptions...)...)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(c.stdout, "Configuration:")
+	cw := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(cw, "  token\t%s\t%s\n", redactToken(token), c.flagSource(fs, "token"))
+	fmt.Fprintf(cw, "  device\t%s\t%s\n", orNone(strings.Join(devices, ",")), c.flagSource(fs, "device"))
+	baseSource := c.flagSource(fs, "base-url")
+	if baseSource == "(not set)" {
+		baseSource = "(default)"
+	}
+	fmt.Fprintf(cw, "  base URL\t%s\t%s\n", client.BaseURL(), baseSource)
+	cw.Flush()
+	fmt.Fprintln(c.stdout)
+
+	ctx, cancel := c.commandContext(cf)
+	defer cancel()
+	checks := []doctorCheck{checkToken(token), checkDevices(devices)}
+	host := ""
+	if u, err := url.Parse(client.BaseURL()); err == nil {
+		host = u.Hostname()
+	}
+	switch {
+	case *offline:
+		checks = append(checks, skip("dns", "-offline"), skip("tls", "-offline"), skip("ping", "-offline"))
+	default:
+		dns := c.checkDNS(ctx, host)
+		checks = append(checks, dns)
+		if dns.status == checkFail {
+			checks = append(checks, skip("tls", "DNS failed"), skip("ping", "DNS failed"))
+			break
+		}
+		reach := checkReach(ctx, client.HTTPClient(), client.BaseURL())
+		checks = append(checks, reach)
+		switch {
+		case reach.status == checkFail:
+			checks = append(checks, skip("ping", "API host unreachable"))
+		case *skipPing:
+			checks = append(checks, skip("ping", "-skip-ping"))
+		case token == "" || len(devices) == 0:
+			checks = append(checks, skip("ping", "needs a token and a device"))
+		default:
+			for _, id := range devices {
+				checks = append(checks, checkPing(ctx, client, id, len(devices) > 1))
+			}
+		}
+	}
+
+	tw := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CHECK\tRESULT\tDETAIL")
+	worst := checkPass
+	var failure *doctorCheck
+	for i, ch := range checks {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", ch.name, strings.ToUpper(ch.status), ch.detail)
+		if ch.hint != "" {
+			fmt.Fprintf(tw, "\t\t-> %s\n", ch.hint)
+		}
+		switch {
+		case ch.status == checkFail && failure == nil:
+			failure = &checks[i]
+			worst = checkFail
+		case ch.status == checkWarn && worst != checkFail:
+			worst = checkWarn
+		}
+	}
+	tw.Flush()
+	fmt.Fprintln(c.stdout, "\nThe ping sends a refresh, so the display may repaint once; -skip-ping avoids it.")
+	switch worst {
+	case checkFail:
+		return doctorFailed{check: *failure}
+	case checkWarn:
+		return doctorFailed{}
+	}
+	return nil
+}
+
+// doctorFailed carries the worst doctor finding to the exit code.
+type doctorFailed struct{ check doctorCheck }
+
+func (e doctorFailed) Error() string {
+	if e.check.name == "" {
+		return "doctor found warnings"
+	}
+	return fmt.Sprintf("doctor: %s check failed", e.check.name)
+}
+
+func (e doctorFailed) exitCode() int {
+	if e.check.name == "" {
+		return exitError
+	}
+	return e.check.code
+}
+
+// flagSource says where a flag's value came from: the command line, its variable, or neither.
+func (c *cli) flagSource(fs *flag.FlagSet, name string) string {
+	explicit := false
+	fs.Visit(func(f *flag.Flag) { explicit = explicit || f.Name == name })
+	switch {
+	case explicit:
+		return "(from -" + name + ")"
+	case c.getenv(envName(name)) != "":
+		return "(from " + envName(name) + ")"
+	default:
+		return "(not set)"
+	}
+}
+
+// redactToken keeps the prefix and the last four characters, enough to tell tokens apart.
+func redactToken(token string) string {
+	switch {
+	case token == "":
+		return "(none)"
+	case len(token) <= 16:
+		return "[redacted]"
+	default:
+		return token[:8] + "…" + token[len(token)-4:]
+	}
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	return s
+}
+
+// checkToken checks that a token is set and looks like one (dot_app_ followed by the key).
+func checkToken(token string) doctorCheck {
+	switch {
+	case token == "":
+		return doctorCheck{name: "token", status: checkFail, detail: "no API token", code: exitUsage,
+			hint: "create one in the Dot. app (More > API key) and pass -token or set QUOTE0_TOKEN"}
+	case strings.ContainsAny(token, " \t\r\n\"'"):
+		return doctorCheck{name: "token", status: checkFail, detail: "contains spaces or quotes", code: exitUsage,
+			hint: "copy the key again without surrounding quotes or line breaks"}
+	case !strings.HasPrefix(token, "dot_app_") || len(token) < 16:
+		return doctorCheck{name: "token", status: checkWarn, detail: "does not look like an API key (dot_app_...)",
+			hint: "API keys start with dot_app_; the ping below shows whether this one works"}
+	}
+	return pass("token", "format ok")
+}
+
+// checkDevices checks that at least one device serial is set and that each is hexadecimal.
+func checkDevices(ids []string) doctorCheck {
+	if len(ids) == 0 {
+		return doctorCheck{name: "device", status: checkFail, detail: "no device serial", code: exitUsage,
+			hint: "find the serial in the Dot. app device settings and pass -device or set QUOTE0_DEVICE"}
+	}
+	for _, id := range ids {
+		if !isHex(id) {
+			return doctorCheck{name: "device", status: checkWarn, detail: fmt.Sprintf("%q is not a hexadecimal serial", id),
+				hint: "serials are hexadecimal, e.g. 1A2B3C4D5E6F; check for a typo or a pasted label"}
+		}
+	}
+	return pass("device", fmt.Sprintf("%d serial(s), format ok", len(ids)))
+}
+
+func isHex(s string) bool {
+	if len(s) < 4 {
+		return false
+	}
+	for _, r := range s {
+		if !(r >= '0' && r <= '9' || r >= 'a' && r <= 'f' || r >= 'A' && r <= 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+// checkDNS resolves the API host through c.lookupHost (net.DefaultResolver when nil).
+func (c *cli) checkDNS(ctx context.Context, host string) doctorCheck {
+	if net.ParseIP(host) != nil {
+		return pass("dns", host+" is an IP address")
+	}
+	lookup := c.lookupHost
+	if lookup == nil {
+		lookup = net.DefaultResolver.LookupHost
+	}
+	addrs, err := lookup(ctx, host)
+	if err != nil || len(addrs) == 0 {
+		return doctorCheck{name: "dns", status: checkFail, detail: fmt.Sprintf("%s: %v", host, err), code: exitNetwork,
+			hint: "check the network and DNS settings, or the host in -base-url"}
+	}
+	return pass("dns", fmt.Sprintf("%s -> %s", host, strings.Join(addrs, ", ")))
+}
+
+// checkReach requests the base URL and reports the TLS session; any HTTP status counts as
+// reachable. A plain-HTTP base URL is a warning because the token travels unencrypted.
+func checkReach(ctx context.Context, hc *http.Client, base string) doctorCheck {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, base+"/", nil)
+	if err != nil {
+		return doctorCheck{name: "tls", status: checkFail, detail: err.Error(), code: exitUsage}
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		hint := "check the network, a proxy, or a firewall blocking HTTPS"
+		var certErr *tls.CertificateVerificationError
+		if errors.As(err, &certErr) || strings.Contains(err.Error(), "x509") {
+			hint = "the certificate is not trusted: check the system clock and CA certificates, or a TLS-intercepting proxy"
+		}
+		return doctorCheck{name: "tls", status: checkFail, detail: err.Error(), code: exitNetwork, hint: hint}
+	}
+	resp.Body.Close()
+	if resp.TLS == nil {
+		return doctorCheck{name: "tls", status: checkWarn, detail: fmt.Sprintf("plain HTTP (HTTP %d)", resp.StatusCode),
+			hint: "the token is sent unencrypted; use an https:// base URL unless this is a local relay"}
+	}
+	detail := tlsVersion(resp.TLS.Version)
+	if certs := resp.TLS.PeerCertificates; len(certs) > 0 {
+		detail += ", certificate valid until " + certs[0].NotAfter.Format("2006-01-02")
+	}
+	return pass("tls", detail)
+}
+
+func tlsVersion(v uint16) string {
+	switch v {
+	case tls.VersionTLS13:
+		return "TLS 1.3"
+	case tls.VersionTLS12:
+		return "TLS 1.2"
+	default:
+		return fmt.Sprintf("TLS 0x%04x", v)
+	}
+}
+
+// checkPing refreshes device, which proves the token and the serial together.
+func checkPing(ctx context.Context, client *quote0.Client, device string, named bool) doctorCheck {
+	name := "ping"
+	if named {
+		name += " " + device
+	}
+	resp, err := client.Refresh(ctx, device)
+	switch {
+	case err == nil:
+		return pass(name, fmt.Sprintf("refresh accepted (code=%d message=%s)", resp.Code, resp.Message))
+	case quote0.IsAuthError(err):
+		return doctorCheck{name: name, status: checkFail, detail: err.Error(), code: exitAuth,
+			hint: "the token was rejected: check it is current and belongs to the device's account"}
+	case quote0.IsDeviceError(err):
+		return doctorCheck{name: name, status: checkFail, detail: err.Error(), code: exitDevice,
+			hint: "the device is unknown to this account: check the serial and that the device is bound"}
+	case quote0.IsRateLimitError(err):
+		return doctorCheck{name: name, status: checkWarn, detail: err.Error(),
+			hint: "the API limits requests to 1 per second; another sender may be running"}
+	case quote0.IsNetworkError(err):
+		return doctorCheck{name: name, status: checkFail, detail: err.Error(), code: exitNetwork,
+			hint: "the request did not complete: check the network and -timeout"}
+	default:
+		return doctorCheck{name: name, status: checkFail, detail: err.Error(), code: exitError,
+			hint: "run with -vv to see the request and response"}
+	}
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/1set/quote0"
+)
+
+const doctorToken = "dot_app_0123456789abcdefSECRET"
+
+func TestCheckToken(t *testing.T) {
+	for _, tc := range []struct {
+		token, status string
+	}{
+		{doctorToken, checkPass},
+		{"", checkFail},
+		{"dot_app_0123 456789abcdef", checkFail},
+		{`"` + doctorToken + `"`, checkFail},
+		{"sk-0123456789abcdef", checkWarn},
+		{"dot_app_1", checkWarn},
+	} {
+		if got := checkToken(tc.token); got.status != tc.status {
+			t.Errorf("checkToken(%q) = %s %q, want %s", tc.token, got.status, got.detail, tc.status)
+		}
+	}
+	if got := checkToken(""); got.code != exitUsage || got.hint == "" {
+		t.Errorf("empty token: %+v", got)
+	}
+}
+
+func TestCheckDevices(t *testing.T) {
+	for _, tc := range []struct {
+		ids    []string
+		status string
+	}{
+		{[]string{"1A2B3C4D5E6F"}, checkPass},
+		{[]string{"abcdef12", "ABCDEF34"}, checkPass},
+		{nil, checkFail},
+		{[]string{"1A2B3C4D5E6F", "kitchen"}, checkWarn},
+		{[]string{"AB"}, checkWarn},
+	} {
+		if got := checkDevices(tc.ids); got.status != tc.status {
+			t.Errorf("checkDevices(%v) = %s %q, want %s", tc.ids, got.status, got.detail, tc.status)
+		}
+	}
+}
+
+func TestCheckDNS(t *testing.T) {
+	c := &cli{lookupHost: func(ctx context.Context, host string) ([]string, error) {
+		if host == "dot.example" {
+			return []string{"192.0.2.7"}, nil
+		}
+		return nil, errors.New("no such host")
+	}}
+	if got := c.checkDNS(context.Background(), "dot.example"); got.status != checkPass || !strings.Contains(got.detail, "192.0.2.7") {
+		t.Errorf("resolvable: %+v", got)
+	}
+	if got := c.checkDNS(context.Background(), "127.0.0.1"); got.status != checkPass {
+		t.Errorf("IP host: %+v", got)
+	}
+	got := c.checkDNS(context.Background(), "missing.example")
+	if got.status != checkFail || got.code != exitNetwork || !strings.Contains(got.detail, "no such host") {
+		t.Errorf("unresolvable: %+v", got)
+	}
+}
+
+func TestCheckReach(t *testing.T) {
+	tlsSrv := httptest.NewTLSServer(http.NotFoundHandler())
+	defer tlsSrv.Close()
+	if got := checkReach(context.Background(), tlsSrv.Client(), tlsSrv.URL); got.status != checkPass || !strings.Contains(got.detail, "TLS 1.") || !strings.Contains(got.detail, "valid until") {
+		t.Errorf("TLS server: %+v", got)
+	}
+	// The default client does not trust the test certificate.
+	got := checkReach(context.Background(), &http.Client{}, tlsSrv.URL)
+	if got.status != checkFail || got.code != exitNetwork || !strings.Contains(got.hint, "certificate") {
+		t.Errorf("untrusted certificate: %+v", got)
+	}
+
+	plain := httptest.NewServer(http.NotFoundHandler())
+	defer plain.Close()
+	if got := checkReach(context.Background(), plain.Client(), plain.URL); got.status != checkWarn || !strings.Contains(got.detail, "plain HTTP") {
+		t.Errorf("plain HTTP: %+v", got)
+	}
+	url := plain.URL
+	plain.Close()
+	if got := checkReach(context.Background(), &http.Client{}, url); got.status != checkFail || got.code != exitNetwork {
+		t.Errorf("closed server: %+v", got)
+	}
+}
+
+func TestCheckPing(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		status int
+		result string
+		code   int
+	}{
+		{"ok", http.StatusOK, checkPass, 0},
+		{"auth", http.StatusUnauthorized, checkFail, exitAuth},
+		{"device", http.StatusNotFound, checkFail, exitDevice},
+		{"rate limit", http.StatusTooManyRequests, checkWarn, 0},
+		{"server", http.StatusInternalServerError, checkFail, exitError},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			requests := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				w.WriteHeader(tc.status)
+				_, _ = w.Write([]byte(`{"code":0,"message":"ok"}`))
+			}))
+			defer srv.Close()
+			client, err := quote0.NewClient(doctorToken, quote0.WithBaseURL(srv.URL), quote0.WithRateLimiter(nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := checkPing(context.Background(), client, "D1", false)
+			if got.status != tc.result || got.code != tc.code {
+				t.Fatalf("got %+v", got)
+			}
+			if got.status != checkPass && got.hint == "" {
+				t.Errorf("no hint: %+v", got)
+			}
+			if requests == 0 {
+				t.Errorf("%d requests", requests)
+			}
+		})
+	}
+}
+
+func TestDoctor(t *testing.T) {
+	c, api, stdout, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": doctorToken})
+	if code := c.run([]string{"doctor", "-device", "1A2B3C4D5E6F"}); code != exitError {
+		t.Fatalf("exit %d, want 1 for the plain-HTTP warning (stderr %q)", code, stderr)
+	}
+	out := stdout.String()
+	for _, want := range []string{
+		"dot_app_…CRET", "(from QUOTE0_TOKEN)", "1A2B3C4D5E6F", "(from -device)",
+		"token   PASS", "device  PASS", "dns     PASS", "tls     WARN", "ping    PASS",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out+stderr.String(), "SECRET") {
+		t.Errorf("token leaked:\n%s%s", out, stderr)
+	}
+	var refreshes int
+	for i := 0; i < api.count(); i++ {
+		if api.body(i)["refreshNow"] == true {
+			refreshes++
+		}
+	}
+	if refreshes != 1 {
+		t.Errorf("%d refreshes, want 1", refreshes)
+	}
+}
+
+func TestDoctor_TLS(t *testing.T) {
+	api := &fakeAPI{}
+	srv := httptest.NewTLSServer(api)
+	defer srv.Close()
+	c, _, stdout, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": doctorToken, "QUOTE0_DEVICE": "1A2B3C4D5E6F"})
+	c.clientOptions = []quote0.ClientOption{quote0.WithBaseURL(srv.URL), quote0.WithHTTPClient(srv.Client()), quote0.WithRateLimiter(nil)}
+	if code := c.run([]string{"doctor"}); code != exitOK {
+		t.Fatalf("exit %d (stderr %q)\n%s", code, stderr, stdout)
+	}
+	if !strings.Contains(stdout.String(), "tls     PASS") {
+		t.Errorf("output:\n%s", stdout)
+	}
+}
+
+func TestDoctor_Offline(t *testing.T) {
+	c, api, stdout, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": doctorToken, "QUOTE0_DEVICE": "1A2B3C4D5E6F"})
+	if code := c.run([]string{"doctor", "-offline"}); code != exitOK {
+		t.Fatalf("exit %d (stderr %q)", code, stderr)
+	}
+	if api.count() != 0 {
+		t.Errorf("%d requests with -offline", api.count())
+	}
+	if !strings.Contains(stdout.String(), "ping    SKIP") {
+		t.Errorf("output:\n%s", stdout)
+	}
+}
+
+func TestDoctor_Failures(t *testing.T) {
+	// No token: the configuration check fails and the ping is skipped.
+	c, api, stdout, _ := newTestCLI(t, map[string]string{"QUOTE0_DEVICE": "1A2B3C4D5E6F"})
+	if code := c.run([]string{"doctor", "-skip-ping"}); code != exitUsage {
+		t.Fatalf("no token: exit %d\n%s", code, stdout)
+	}
+	if strings.Contains(stdout.String(), "ping    PASS") || api.count() > 1 {
+		t.Errorf("pinged without a token:\n%s", stdout)
+	}
+
+	// A rejected token fails the ping with the auth exit code.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"code":401,"message":"bad key"}`))
+	}))
+	defer srv.Close()
+	c, _, stdout, _ = newTestCLI(t, map[string]string{"QUOTE0_TOKEN": doctorToken, "QUOTE0_DEVICE": "1A2B3C4D5E6F"})
+	c.clientOptions = []quote0.ClientOption{quote0.WithBaseURL(srv.URL), quote0.WithRateLimiter(nil)}
+	if code := c.run([]string{"doctor"}); code != exitAuth {
+		t.Fatalf("rejected token: exit %d\n%s", code, stdout)
+	}
+	if !strings.Contains(stdout.String(), "the token was rejected") {
+		t.Errorf("no hint:\n%s", stdout)
+	}
+
+	// An unreachable host fails TLS with the network exit code and skips the ping.
+	closed := httptest.NewServer(http.NotFoundHandler())
+	url := closed.URL
+	closed.Close()
+	c, _, stdout, _ = newTestCLI(t, map[string]string{"QUOTE0_TOKEN": doctorToken, "QUOTE0_DEVICE": "1A2B3C4D5E6F"})
+	c.clientOptions = []quote0.ClientOption{quote0.WithBaseURL(url), quote0.WithRateLimiter(nil)}
+	if code := c.run([]string{"doctor"}); code != exitNetwork {
+		t.Fatalf("unreachable: exit %d\n%s", code, stdout)
+	}
+	if !strings.Contains(stdout.String(), "ping    SKIP") {
+		t.Errorf("output:\n%s", stdout)
+	}
+}
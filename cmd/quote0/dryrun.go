@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/1set/quote0"
+)
+
+// dryRun prints a request built by the SDK instead of sending it. Validation errors from the
+// build are returned unchanged so they map to the usual exit codes.
+func (c *cli) dryRun(req *quote0.PreparedRequest, err error) error {
+	if err != nil {
+		return err
+	}
+	out, err := dryRunJSON(req.Payload)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(c.stdout, "POST %s (device %s)\n%s\n", req.URL, req.DeviceID, out)
+	return nil
+}
+
+// dryRunDevices prints the request built for each device. With -json the complete payloads
+// are printed instead, nothing abbreviated (an array with several devices), so a single
+// device's output can be saved and replayed with -from-json.
+func (c *cli) dryRunDevices(devices []string, sf *sendFlags, build func(device string) (*quote0.PreparedRequest, error)) error {
+	var payloads []interface{}
+	for _, id := range devices {
+		req, err := build(id)
+		if err != nil {
+			return err
+		}
+		if *sf.asJSON {
+			payloads = append(payloads, req.Payload)
+		} else if err := c.dryRun(req, nil); err != nil {
+			return err
+		}
+	}
+	switch {
+	case !*sf.asJSON:
+		return nil
+	case len(payloads) == 1:
+		return c.printJSON(payloads[0])
+	default:
+		return c.printJSON(payloads)
+	}
+}
+
+// dryRunJSON indents the payload with its base64 image and icon fields abbreviated.
+func dryRunJSON(payload interface{}) ([]byte, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+	for _, k := range []string{"image", "icon"} {
+		if s, ok := fields[k].(string); ok {
+			fields[k] = fmt.Sprintf("<%d bytes base64>", len(s))
+		}
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(fields); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
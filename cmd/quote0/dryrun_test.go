@@ -0,0 +1,68 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/1set/quote0"
+)
+
+func TestDryRun_Text(t *testing.T) {
+	// No token: dry-run never needs one.
+	c, api, stdout, stderr := newTestCLI(t, map[string]string{"QUOTE0_DEVICE": "D"})
+	icon, _ := quote0.IconBase64(quote0.Icons()[0])
+	if code := c.run([]string{"text", "-dry-run", "-title", "Hi", "-icon", icon}); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if api.count() != 0 {
+		t.Fatalf("sent %v", api.bodies)
+	}
+	out := stdout.String()
+	for _, want := range []string{"/api/open/text (device D)", `"title": "Hi"`, `"deviceId": "D"`, `"refreshNow": true`, `"icon": "<`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("stdout %q lacks %q", out, want)
+		}
+	}
+	if strings.Contains(out, icon) {
+		t.Errorf("icon not abbreviated: %q", out)
+	}
+}
+
+func TestDryRun_ImageAndValidation(t *testing.T) {
+	data, _ := quote0.NewCanvas().PNG()
+	path := filepath.Join(t.TempDir(), "s.png")
+	_ = os.WriteFile(path, data, 0o644)
+	c, api, stdout, stderr := newTestCLI(t, map[string]string{"QUOTE0_DEVICE": "D"})
+	if code := c.run([]string{"image", "--dry-run", "-image-file", path}); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if api.count() != 0 || !strings.Contains(stdout.String(), `"image": "<`) || !strings.Contains(stdout.String(), "/api/open/image") {
+		t.Fatalf("stdout %q", stdout)
+	}
+
+	// The device is still required, and invalid text fails locally.
+	c, _, _, _ = newTestCLI(t, nil)
+	if code := c.run([]string{"text", "-dry-run", "-title", "x"}); code != exitUsage {
+		t.Fatalf("no device: exit %d", code)
+	}
+	c, _, _, _ = newTestCLI(t, map[string]string{"QUOTE0_DEVICE": "D"})
+	if code := c.run([]string{"text", "-dry-run", "-title", "bad\xff"}); code != exitValidation {
+		t.Fatalf("invalid text: exit %d", code)
+	}
+}
+
+func TestDryRun_Batch(t *testing.T) {
+	c, api, stdout, stderr := newTestCLI(t, map[string]string{"QUOTE0_DEVICE": "D"})
+	c.stdin = strings.NewReader(`{"type":"text","request":{"title":"one"}}
+{"type":"text","request":{"title":"two","deviceId":"E"},"delay":"5s"}
+`)
+	if code := c.run([]string{"batch", "-dry-run", "-file", "-"}); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	out := stdout.String()
+	if api.count() != 0 || !strings.Contains(out, "# line 2: text\n(after 5s)\nPOST") || !strings.Contains(out, "(device E)") {
+		t.Fatalf("stdout %q", out)
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"flag"
+	"strings"
+)
+
+// envPrefix starts the environment variable of every flag: -image-file falls back to
+// QUOTE0_IMAGE_FILE.
+const envPrefix = "QUOTE0_"
+
+// envDefaultFlags take their default from the environment when they are registered.
+var envDefaultFlags = map[string]bool{"token": true, "base-url": true, "device": true, "lock": true}
+
+// envExclusive groups flags that set the same thing; an explicit flag of a group also
+// suppresses the variables of the others, so -message-file wins over QUOTE0_MESSAGE.
+var envExclusive = [][]string{
+	{"title", "title-file"},
+	{"message", "message-file", "kv"},
+	{"signature", "signature-file"},
+	{"icon", "icon-file", "icon-url", "icon-text"},
+	{"image", "image-file"},
+	{"v", "verbose", "vv"},
+	{"at", "in"},
+}
+
+// envName returns the variable a flag falls back to.
+func envName(name string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// annotateEnv appends the variable name to each flag's help text.
+func annotateEnv(fs *flag.FlagSet) {
+	fs.VisitAll(func(f *flag.Flag) {
+		if !envDefaultFlags[f.Name] && !strings.Contains(f.Usage, envPrefix) {
+			f.Usage += " [$" + envName(f.Name) + "]"
+		}
+	})
+}
+
+// applyEnv sets the flags not given on the command line from their non-empty variables. An
+// empty variable counts as unset, like QUOTE0_TOKEN.
+func (c *cli) applyEnv(fs *flag.FlagSet) error {
+	explicit := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+	for _, group := range envExclusive {
+		for _, name := range group {
+			if explicit[name] {
+				for _, other := range group {
+					explicit[other] = true
+				}
+				break
+			}
+		}
+	}
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		if err != nil || explicit[f.Name] || envDefaultFlags[f.Name] {
+			return
+		}
+		name := envName(f.Name)
+		value := c.getenv(name)
+		if value == "" {
+			return
+		}
+		if serr := f.Value.Set(value); serr != nil {
+			err = usagef("%s: invalid value %q for -%s: %v", name, value, f.Name, serr)
+		}
+	})
+	return err
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/1set/quote0"
+)
+
+func TestEnvFallbacks(t *testing.T) {
+	env := map[string]string{
+		"QUOTE0_TOKEN":     "tok",
+		"QUOTE0_DEVICE":    "D1",
+		"QUOTE0_TITLE":     "From env",
+		"QUOTE0_MESSAGE":   "env message",
+		"QUOTE0_SIGNATURE": "",
+		"QUOTE0_REFRESH":   "no",
+	}
+	tests := []struct {
+		name string
+		args []string
+		want map[string]interface{}
+	}{
+		{"env only", nil, map[string]interface{}{"title": "From env", "message": "env message", "refreshNow": false}},
+		{"flag wins", []string{"-title", "From flag", "-refresh"}, map[string]interface{}{"title": "From flag", "refreshNow": true}},
+		{"explicit empty flag wins", []string{"-title="}, map[string]interface{}{"title": nil}},
+		{"excluded by kv", []string{"-kv", "CPU=1%"}, map[string]interface{}{"message": "CPU: 1%"}},
+		{"empty variable is unset", nil, map[string]interface{}{"signature": nil}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, api, _, stderr := newTestCLI(t, env)
+			if code := c.run(append([]string{"text"}, tt.args...)); code != exitOK {
+				t.Fatalf("exit %d: %s", code, stderr)
+			}
+			body := api.body(0)
+			for k, want := range tt.want {
+				if got := body[k]; got != want {
+					t.Errorf("%s = %#v, want %#v", k, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestEnvFallbacks_ExclusiveFileFlag(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "msg.txt")
+	if err := os.WriteFile(path, []byte("from file\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c, api, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D1", "QUOTE0_MESSAGE": "env"})
+	if code := c.run([]string{"text", "-message-file", path}); code != exitOK {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if got := api.body(0)["message"]; got != "from file" {
+		t.Fatalf("message %v", got)
+	}
+
+	// Both variables of one group set is the same conflict as both flags.
+	c, _, _, stderr = newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D1", "QUOTE0_MESSAGE": "env", "QUOTE0_MESSAGE_FILE": path})
+	if code := c.run([]string{"text"}); code != exitUsage {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+}
+
+func TestEnvFallbacks_ImageAndErrors(t *testing.T) {
+	data, err := quote0.NewCanvas().PNG()
+	if err != nil {
+		t.Fatal(err)
+	}
+	png := filepath.Join(t.TempDir(), "screen.png")
+	if err := os.WriteFile(png, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c, api, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D1", "QUOTE0_IMAGE_FILE": png, "QUOTE0_DITHER_TYPE": "none"})
+	if code := c.run([]string{"image"}); code != exitOK {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if got := api.body(0)["ditherType"]; got != "NONE" {
+		t.Fatalf("ditherType %v", got)
+	}
+
+	c, api, _, stderr = newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D1", "QUOTE0_DRY_RUN": "maybe"})
+	if code := c.run([]string{"text"}); code != exitUsage {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if !strings.Contains(stderr.String(), `QUOTE0_DRY_RUN: invalid value "maybe" for -dry-run`) {
+		t.Fatalf("stderr %q", stderr)
+	}
+	if api.count() != 0 {
+		t.Fatalf("sent %d requests", api.count())
+	}
+}
+
+func TestEnvFallbacks_Help(t *testing.T) {
+	c, _, _, stderr := newTestCLI(t, nil)
+	if code := c.run([]string{"image", "-h"}); code != exitOK {
+		t.Fatalf("exit %d", code)
+	}
+	out := stderr.String()
+	for _, want := range []string{"[$QUOTE0_IMAGE_FILE]", "[$QUOTE0_DITHER_TYPE]", "or set QUOTE0_TOKEN"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("help lacks %q", want)
+		}
+	}
+	if strings.Contains(out, "$QUOTE0_TOKEN") {
+		t.Error("token variable listed twice")
+	}
+}
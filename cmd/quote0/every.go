@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// repeatFlags re-run a `text` or `image` send on an interval.
+type repeatFlags struct {
+	every    *time.Duration
+	failFast *bool
+}
+
+func addRepeatFlags(fs *flag.FlagSet) *repeatFlags {
+	return &repeatFlags{
+		every:    fs.Duration("every", 0, "Send again after this long, re-reading the content files, until interrupted"),
+		failFast: fs.Bool("fail-fast", false, "With -every, stop at the first failed send"),
+	}
+}
+
+// check rejects flags -every cannot repeat: stdin is read once, and a terminal prompt or a
+// single JSON document does not fit a stream of sends.
+func (f *repeatFlags) check(sf *sendFlags, files ...*string) error {
+	if *f.every < 0 {
+		return usagef("-every must not be negative")
+	}
+	if *f.every == 0 {
+		return nil
+	}
+	for _, path := range files {
+		if *path == "-" {
+			return usagef("-every reads the content again on each send; stdin (-) can be read only once")
+		}
+	}
+	if *sf.asJSON {
+		return usagef("-json does not combine with -every")
+	}
+	return nil
+}
+
+// repeat sends what next builds every -every until interrupted, printing a line per device and
+// send and a summary at the end. next runs each time, so changed files are picked up, and
+// -if-changed skips the unchanged ones. A failure ends the loop only with -fail-fast.
+func (c *cli) repeat(ctx context.Context, cf *commonFlags, rf *repeatFlags, devices []string, next func(ctx context.Context) (outgoing, error)) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	var sent, skipped, failed int
+	var err error
+	for ctx.Err() == nil {
+		var lines []deviceResult
+		out, buildErr := next(ctx)
+		if buildErr == nil {
+			lines, buildErr = c.fanOut(ctx, cf, devices, out)
+		}
+		stamp := c.clock().Format("15:04:05")
+		if ctx.Err() != nil {
+			break // interrupted mid-send; not counted
+		}
+		if buildErr != nil {
+			failed++
+			err = buildErr
+			fmt.Fprintf(c.stderr, "%s %v\n", stamp, buildErr)
+		}
+		for _, l := range lines {
+			prefix := stamp + " "
+			if len(devices) > 1 {
+				prefix += l.Device + ": "
+			}
+			switch {
+			case l.err != nil:
+				failed++
+				err = l.err
+				fmt.Fprintln(c.stderr, prefix+l.summary(out.kind))
+				continue
+			case l.Skipped:
+				skipped++
+			default:
+				sent++
+			}
+			fmt.Fprintln(c.stdout, prefix+l.summary(out.kind))
+		}
+		if err != nil && *rf.failFast {
+			break
+		}
+		err = nil
+		select {
+		case <-ctx.Done():
+		case <-c.timer(*rf.every):
+		}
+	}
+	fmt.Fprintf(c.stdout, "Stopped: %d sent, %d skipped, %d failed\n", sent, skipped, failed)
+	if err != nil {
+		return err
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil && !errors.Is(ctxErr, context.Canceled) {
+		return ctxErr
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newEveryCLI returns a cli whose -every timer calls tick with the number of waits so far
+// and is interrupted after the given number of sends.
+func newEveryCLI(t *testing.T, sends int, tick func(n int)) (*cli, *fakeAPI, *strings.Builder, *strings.Builder) {
+	c, api, _, _ := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D1"})
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	c.ctx = ctx
+	clk := &fakeClock{t: time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC)}
+	c.now = clk.now
+	c.after = func(d time.Duration) <-chan time.Time {
+		n := len(clk.sleeps) + 1
+		if n == sends {
+			cancel()
+		} else if tick != nil {
+			tick(n)
+		}
+		return clk.after(d)
+	}
+	var stdout, stderr strings.Builder
+	c.stdout, c.stderr = &stdout, &stderr
+	return c, api, &stdout, &stderr
+}
+
+func TestEvery_RereadsFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "msg.txt")
+	write := func(s string) {
+		if err := os.WriteFile(path, []byte(s), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("one")
+	c, api, stdout, stderr := newEveryCLI(t, 3, func(n int) { write([]string{"", "two", "three"}[n]) })
+	if code := c.run([]string{"text", "-message-file", path, "-every", "10m"}); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if api.count() != 3 {
+		t.Fatalf("%d requests", api.count())
+	}
+	for i, want := range []string{"one", "two", "three"} {
+		if got := api.body(i)["message"]; got != want {
+			t.Errorf("send %d: message %v, want %s", i, got, want)
+		}
+	}
+	if !strings.Contains(stdout.String(), "08:10:00 Text sent") || !strings.HasSuffix(stdout.String(), "Stopped: 3 sent, 0 skipped, 0 failed\n") {
+		t.Fatalf("output %q", stdout)
+	}
+}
+
+func TestEvery_IfChanged(t *testing.T) {
+	c, api, stdout, stderr := newEveryCLI(t, 3, nil)
+	args := []string{"text", "-title", "same", "-every", "1m", "-if-changed", "-state-dir", t.TempDir()}
+	if code := c.run(args); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if api.count() != 1 || !strings.HasSuffix(stdout.String(), "Stopped: 1 sent, 2 skipped, 0 failed\n") {
+		t.Fatalf("%d requests, output %q", api.count(), stdout)
+	}
+}
+
+func TestEvery_FailuresContinue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "msg.txt")
+	if err := os.WriteFile(path, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tick := func(n int) {
+		if n == 1 {
+			os.Remove(path) // the second send fails, the third finds the file again
+		} else {
+			_ = os.WriteFile(path, []byte("y"), 0o644)
+		}
+	}
+	c, api, stdout, stderr := newEveryCLI(t, 3, tick)
+	if code := c.run([]string{"text", "-message-file", path, "-every", "1m"}); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if api.count() != 2 || !strings.Contains(stderr.String(), "msg.txt") {
+		t.Fatalf("%d requests, stderr %q", api.count(), stderr)
+	}
+	if !strings.HasSuffix(stdout.String(), "Stopped: 2 sent, 0 skipped, 1 failed\n") {
+		t.Fatalf("output %q", stdout)
+	}
+}
+
+func TestEvery_FailFast(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "msg.txt")
+	if err := os.WriteFile(path, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c, api, stdout, _ := newEveryCLI(t, 5, func(int) { os.Remove(path) })
+	if code := c.run([]string{"text", "-message-file", path, "-every", "1m", "-fail-fast"}); code != exitUsage {
+		t.Fatalf("exit %d, want %d", code, exitUsage)
+	}
+	if api.count() != 1 || !strings.HasSuffix(stdout.String(), "Stopped: 1 sent, 0 skipped, 1 failed\n") {
+		t.Fatalf("%d requests, output %q", api.count(), stdout)
+	}
+}
+
+func TestEvery_Rejects(t *testing.T) {
+	for _, args := range [][]string{
+		{"-message-file", "-", "-every", "1m"},
+		{"-json", "-every", "1m"},
+		{"-i", "-every", "1m"},
+		{"-every", "-1m"},
+	} {
+		c, api, _, stderr := newEveryCLI(t, 2, nil)
+		if code := c.run(append([]string{"text"}, args...)); code != exitUsage {
+			t.Errorf("%v: exit %d: %s", args, code, stderr)
+		}
+		if api.count() != 0 {
+			t.Errorf("%v: sent", args)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/1set/quote0"
+)
+
+func TestExitCodes(t *testing.T) {
+	env := map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"}
+	for _, tc := range []struct {
+		name   string
+		status int
+		args   []string
+		want   int
+	}{
+		{"success", http.StatusOK, []string{"text", "-title", "x"}, exitOK},
+		{"help", 0, []string{"text", "-h"}, exitOK},
+		{"unknown flag", 0, []string{"text", "-nope"}, exitUsage},
+		{"unknown command", 0, []string{"paint"}, exitUsage},
+		{"no command", 0, nil, exitUsage},
+		{"validation", 0, []string{"preview", "image", "-image", "aGVsbG8=", "-out", "-"}, exitValidation},
+		{"auth", http.StatusUnauthorized, []string{"refresh"}, exitAuth},
+		{"rate limit", http.StatusTooManyRequests, []string{"refresh"}, exitRateLimit},
+		{"device", http.StatusNotFound, []string{"refresh"}, exitDevice},
+		{"server", http.StatusInternalServerError, []string{"refresh"}, exitError},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.status)
+				_, _ = w.Write([]byte(`{"code":0}`))
+			}))
+			defer srv.Close()
+			var out, errOut bytes.Buffer
+			c := &cli{stdout: &out, stderr: &errOut, getenv: func(k string) string { return env[k] },
+				clientOptions: []quote0.ClientOption{quote0.WithBaseURL(srv.URL), quote0.WithRateLimiter(nil)}}
+			if got := c.run(tc.args); got != tc.want {
+				t.Fatalf("exit %d, want %d (stderr %q)", got, tc.want, errOut.String())
+			}
+		})
+	}
+}
+
+func TestExitCodes_Network(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close() // nothing listens on url any more
+	c, _, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+	c.clientOptions = append(c.clientOptions, quote0.WithBaseURL(url))
+	if got := c.run([]string{"refresh"}); got != exitNetwork {
+		t.Fatalf("exit %d (stderr %q)", got, stderr)
+	}
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/1set/quote0"
+)
+
+// deviceList is the repeatable -device flag. Each value may be a comma-separated list; the
+// first explicit -device replaces the QUOTE0_DEVICE default. Duplicates are dropped.
+type deviceList struct {
+	ids      []string
+	explicit bool
+}
+
+func newDeviceList(def string) *deviceList {
+	d := &deviceList{}
+	d.add(def)
+	return d
+}
+
+func (d *deviceList) String() string {
+	if d == nil {
+		return ""
+	}
+	return strings.Join(d.ids, ",")
+}
+
+func (d *deviceList) Set(s string) error {
+	if !d.explicit {
+		d.ids, d.explicit = nil, true
+	}
+	if d.add(s) == 0 {
+		return fmt.Errorf("empty device serial %q", s)
+	}
+	return nil
+}
+
+// add appends the serials in the comma-separated list s and reports how many it contained.
+func (d *deviceList) add(s string) int {
+	n := 0
+	for _, id := range strings.Split(s, ",") {
+		if id = strings.TrimSpace(id); id == "" {
+			continue
+		}
+		n++
+		if !d.contains(id) {
+			d.ids = append(d.ids, id)
+		}
+	}
+	return n
+}
+
+func (d *deviceList) contains(id string) bool {
+	for _, have := range d.ids {
+		if have == id {
+			return true
+		}
+	}
+	return false
+}
+
+// single returns the only device, or "" when none is set. More than one is a usage error for
+// commands that target a single device.
+func (d *deviceList) single(command string) (string, error) {
+	if len(d.ids) > 1 {
+		return "", usagef("%s targets one device; -device lists %d (%s)", command, len(d.ids), d)
+	}
+	if len(d.ids) == 0 {
+		return "", nil
+	}
+	return d.ids[0], nil
+}
+
+// sendFlags control how `text` and `image` report per-device results.
+type sendFlags struct {
+	anySuccess *bool
+	asJSON     *bool
+}
+
+func addSendFlags(fs *flag.FlagSet) *sendFlags {
+	return &sendFlags{
+		anySuccess: fs.Bool("any-success", false, "With several devices, succeed if at least one device accepted the send"),
+		asJSON:     fs.Bool("json", false, "Print the result as JSON (an array with several devices)"),
+	}
+}
+
+// deviceResult is the outcome for one device of a `text` or `image` send.
+type deviceResult struct {
+	Device string `json:"device"`
+	OK     bool   `json:"ok"`
+	// Skipped is set when -if-changed found the content unchanged and nothing was sent.
+	Skipped bool   `json:"skipped,omitempty"`
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+	Error   string `json:"error,omitempty"`
+	err     error
+}
+
+// outgoing is the content of a fan-out send: build prepares the request for one device
+// (for -if-changed and -output) and send posts it to several devices. capture is the -output
+// path, if any.
+type outgoing struct {
+	kind    string
+	build   func(device string) (*quote0.PreparedRequest, error)
+	send    func(ctx context.Context, devices []string, opts ...quote0.BatchOption) ([]quote0.BatchResult, error)
+	capture string
+}
+
+func textOutgoing(kind string, client *quote0.Client, req quote0.TextRequest) outgoing {
+	return outgoing{
+		kind: kind,
+		build: func(device string) (*quote0.PreparedRequest, error) {
+			req.DeviceID = device
+			return client.BuildText(req)
+		},
+		send: func(ctx context.Context, devices []string, opts ...quote0.BatchOption) ([]quote0.BatchResult, error) {
+			return client.BroadcastText(ctx, devices, req, opts...)
+		},
+	}
+}
+
+func imageOutgoing(kind string, client *quote0.Client, req quote0.ImageRequest) outgoing {
+	return outgoing{
+		kind: kind,
+		build: func(device string) (*quote0.PreparedRequest, error) {
+			req.DeviceID = device
+			return client.BuildImage(req)
+		},
+		send: func(ctx context.Context, devices []string, opts ...quote0.BatchOption) ([]quote0.BatchResult, error) {
+			return client.BroadcastImage(ctx, devices, req, opts...)
+		},
+	}
+}
+
+// deliver runs a fan-out send and reports it. With one device the output and error are those
+// of a plain send; with several, a line per device is printed and the command fails if any
+// device failed (or, with -any-success, only if every device failed). With -if-changed,
+// devices whose content is unchanged since their last successful send are skipped.
+func (c *cli) deliver(ctx context.Context, cf *commonFlags, devices []string, sf *sendFlags, out outgoing) error {
+	lines, err := c.fanOut(ctx, cf, devices, out)
+	if lines == nil {
+		return err
+	}
+	var firstErr error
+	failed := 0
+	for _, l := range lines {
+		if l.err != nil {
+			failed++
+			if firstErr == nil {
+				firstErr = l.err
+			}
+		}
+	}
+
+	if c.broadcast != nil {
+		return c.reportBroadcast(lines, sf, out.kind, failed, firstErr)
+	}
+	if len(devices) == 1 {
+		if firstErr != nil {
+			return firstErr
+		}
+		if *sf.asJSON {
+			return c.printJSON(lines[0])
+		}
+		fmt.Fprintln(c.stdout, lines[0].summary(out.kind))
+		return nil
+	}
+
+	if *sf.asJSON {
+		if err := c.printJSON(lines); err != nil {
+			return err
+		}
+	} else {
+		for _, l := range lines {
+			fmt.Fprintf(c.stdout, "%s: %s\n", l.Device, l.summary(out.kind))
+		}
+	}
+	if failed == 0 || (*sf.anySuccess && failed < len(lines)) {
+		return nil
+	}
+	return fmt.Errorf("%d of %d devices failed; first: %w", failed, len(lines), firstErr)
+}
+
+// fanOut sends out to the devices (only the changed ones with -if-changed) and returns a
+// result per device, in order. A nil result means nothing could be sent and err says why.
+func (c *cli) fanOut(ctx context.Context, cf *commonFlags, devices []string, out outgoing) ([]deviceResult, error) {
+	pending, hashes, err := c.changedDevices(cf, devices, out)
+	if err != nil {
+		return nil, err
+	}
+	var results []quote0.BatchResult
+	if len(pending) > 0 {
+		results, err = out.send(ctx, pending, c.batchOptions()...)
+		if len(results) == 0 {
+			return nil, err
+		}
+	}
+	lines := make([]deviceResult, len(devices))
+	next := 0
+	for i, id := range devices {
+		if _, changed := hashes[id]; hashes != nil && !changed {
+			lines[i] = deviceResult{Device: id, OK: true, Skipped: true}
+			continue
+		}
+		res := results[next]
+		next++
+		l := deviceResult{Device: id, OK: res.Err == nil, err: res.Err}
+		if res.Response != nil {
+			l.Code, l.Message = res.Response.Code, res.Response.Message
+		}
+		if res.Err != nil {
+			l.Error = res.Err.Error()
+		} else {
+			if hashes != nil {
+				c.saveSentHash(cf, id, hashes[id])
+			}
+			if out.capture != "" {
+				c.writeCapture(out, id, len(devices) > 1)
+			}
+		}
+		lines[i] = l
+	}
+	return lines, nil
+}
+
+// summary describes the result in a line, e.g. "Text sent (code=0 message=ok)".
+func (l deviceResult) summary(kind string) string {
+	switch {
+	case l.Skipped:
+		return kind + " skipped, unchanged"
+	case l.OK:
+		return fmt.Sprintf("%s sent (code=%d message=%s)", kind, l.Code, l.Message)
+	default:
+		return "FAILED: " + l.Error
+	}
+}
+
+func (c *cli) printJSON(v interface{}) error {
+	enc := json.NewEncoder(c.stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/1set/quote0"
+)
+
+// deviceAPI answers 404 for the devices in missing and code 0 otherwise.
+func deviceAPI(t *testing.T, c *cli, missing ...string) *[]string {
+	var mu sync.Mutex
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			DeviceID string `json:"deviceId"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		got = append(got, body.DeviceID)
+		mu.Unlock()
+		for _, m := range missing {
+			if m == body.DeviceID {
+				http.Error(w, `{"code":404,"message":"device not found"}`, http.StatusNotFound)
+				return
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"code":0,"message":"ok"}`)
+	}))
+	t.Cleanup(srv.Close)
+	c.clientOptions = []quote0.ClientOption{quote0.WithBaseURL(srv.URL), quote0.WithRateLimiter(nil)}
+	return &got
+}
+
+func TestMultiDevice(t *testing.T) {
+	c, _, stdout, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "ENV"})
+	got := deviceAPI(t, c)
+	if code := c.run([]string{"text", "-title", "Hi", "-device", "A", "-device", "B,C", "-device", "A"}); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if strings.Join(*got, ",") != "A,B,C" {
+		t.Fatalf("sent to %v", *got)
+	}
+	for _, want := range []string{"A: Text sent (code=0", "B: Text sent", "C: Text sent"} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("stdout %q lacks %q", stdout, want)
+		}
+	}
+}
+
+func TestMultiDevice_FailureRules(t *testing.T) {
+	data, _ := quote0.NewCanvas().PNG()
+	path := filepath.Join(t.TempDir(), "s.png")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	args := []string{"image", "-image-file", path, "-device", "A,B"}
+
+	c, _, stdout, _ := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok"})
+	deviceAPI(t, c, "B")
+	if code := c.run(args); code != exitDevice {
+		t.Fatalf("exit %d", code)
+	}
+	if !strings.Contains(stdout.String(), "B: FAILED") {
+		t.Fatalf("stdout %q", stdout)
+	}
+
+	c, _, _, _ = newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok"})
+	deviceAPI(t, c, "B")
+	if code := c.run(append(args, "-any-success")); code != 0 {
+		t.Fatalf("-any-success: exit %d", code)
+	}
+
+	c, _, _, _ = newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok"})
+	deviceAPI(t, c, "A", "B")
+	if code := c.run(append(args, "-any-success")); code != exitDevice {
+		t.Fatalf("all failed: exit %d", code)
+	}
+
+	c, _, stdout, _ = newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok"})
+	deviceAPI(t, c, "B")
+	c.run(append(args, "-json"))
+	var lines []deviceResult
+	if err := json.Unmarshal(stdout.Bytes(), &lines); err != nil || len(lines) != 2 || !lines[0].OK || lines[1].OK || lines[1].Error == "" {
+		t.Fatalf("json %v %q", err, stdout)
+	}
+}
+
+func TestMultiDevice_SingleDeviceCommands(t *testing.T) {
+	c, api, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok"})
+	if code := c.run([]string{"refresh", "-device", "A,B"}); code != exitUsage || !strings.Contains(stderr.String(), "refresh targets one device") {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if api.count() != 0 {
+		t.Fatalf("sent %v", api.bodies)
+	}
+
+	// A single device keeps the plain output, or one JSON object.
+	c, _, stdout, _ := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+	if code := c.run([]string{"text", "-title", "x", "-json"}); code != 0 {
+		t.Fatalf("exit %d", code)
+	}
+	var one deviceResult
+	if err := json.Unmarshal(stdout.Bytes(), &one); err != nil || one.Device != "D" || !one.OK {
+		t.Fatalf("json %v %q", err, stdout)
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"flag"
+	"strings"
+	"time"
+
+	"github.com/1set/quote0"
+)
+
+// fromJSONFlags may be combined with -from-json; the content comes from the file.
+var fromJSONFlags = map[string]bool{
+	"from-json": true, "token": true, "base-url": true, "device": true, "debug": true,
+	"dry-run": true, "timeout": true, "rate": true, "v": true, "verbose": true, "vv": true,
+	"lock": true, "lock-timeout": true, "any-success": true, "json": true,
+	"if-changed": true, "state-dir": true, "at": true, "in": true, "allow-past": true,
+	"log": true, "log-format": true, "log-max-size": true, "quiet": true, "output": true,
+}
+
+// checkFromJSON rejects content flags given together with -from-json.
+func checkFromJSON(fs *flag.FlagSet) error {
+	var extra []string
+	fs.Visit(func(f *flag.Flag) {
+		if !fromJSONFlags[f.Name] {
+			extra = append(extra, "-"+f.Name)
+		}
+	})
+	if len(extra) > 0 {
+		return usagef("-from-json sends the saved request as is; do not combine it with %s", strings.Join(extra, ", "))
+	}
+	return nil
+}
+
+// savedRequestClient builds the client for a request loaded with -from-json. An explicit
+// -device overrides the saved deviceId, which overrides QUOTE0_DEVICE.
+func (c *cli) savedRequestClient(cf *commonFlags, saved string) (*quote0.Client, []string, error) {
+	if saved = strings.TrimSpace(saved); saved != "" && !cf.device.explicit {
+		client, err := c.buildClient(cf, saved)
+		return client, []string{saved}, err
+	}
+	return c.newClientDevices(cf)
+}
+
+// sendTextFromJSON replays a text request saved as JSON.
+func (c *cli) sendTextFromJSON(path string, fs *flag.FlagSet, cf *commonFlags, sf *sendFlags, target time.Time, output string) error {
+	if err := checkFromJSON(fs); err != nil {
+		return err
+	}
+	req, err := quote0.LoadTextRequest(path)
+	if err != nil {
+		return err
+	}
+	client, devices, err := c.savedRequestClient(cf, req.DeviceID)
+	if err != nil {
+		return err
+	}
+	if *cf.dryRun {
+		return c.dryRunDevices(devices, sf, func(id string) (*quote0.PreparedRequest, error) {
+			req.DeviceID = id
+			return client.BuildText(req)
+		})
+	}
+	out := textOutgoing("Text", client, req)
+	out.capture = output
+	if err := c.waitToSend(cf, target, devices, out.build); err != nil {
+		return err
+	}
+	ctx, cancel := c.commandContext(cf)
+	defer cancel()
+	return c.deliver(ctx, cf, devices, sf, out)
+}
+
+// sendImageFromJSON replays an image request saved as JSON.
+func (c *cli) sendImageFromJSON(path string, fs *flag.FlagSet, cf *commonFlags, sf *sendFlags, target time.Time, output string) error {
+	if err := checkFromJSON(fs); err != nil {
+		return err
+	}
+	req, err := quote0.LoadImageRequest(path)
+	if err != nil {
+		return err
+	}
+	client, devices, err := c.savedRequestClient(cf, req.DeviceID)
+	if err != nil {
+		return err
+	}
+	if *cf.dryRun {
+		return c.dryRunDevices(devices, sf, func(id string) (*quote0.PreparedRequest, error) {
+			req.DeviceID = id
+			return client.BuildImage(req)
+		})
+	}
+	out := imageOutgoing("Image", client, req)
+	out.capture = output
+	if err := c.waitToSend(cf, target, devices, out.build); err != nil {
+		return err
+	}
+	ctx, cancel := c.commandContext(cf)
+	defer cancel()
+	return c.deliver(ctx, cf, devices, sf, out)
+}
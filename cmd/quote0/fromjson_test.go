@@ -0,0 +1,106 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/1set/quote0"
+)
+
+func TestFromJSON_RecordAndReplayText(t *testing.T) {
+	env := map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "ENV"}
+	c, api, stdout, stderr := newTestCLI(t, env)
+	if code := c.run([]string{"text", "-title", "Standup", "-message", "Room 4 & 5", "-device", "SAVED", "-dry-run", "-json"}); code != exitOK {
+		t.Fatalf("record: exit %d: %s", code, stderr)
+	}
+	if api.count() != 0 {
+		t.Fatal("dry run sent a request")
+	}
+	path := filepath.Join(t.TempDir(), "meeting.json")
+	if err := os.WriteFile(path, stdout.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		args   []string
+		device string
+	}{
+		{"saved device wins over env", nil, "SAVED"},
+		{"explicit device wins", []string{"-device", "OVERRIDE"}, "OVERRIDE"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, api, _, stderr := newTestCLI(t, env)
+			if code := c.run(append([]string{"text", "-from-json", path}, tt.args...)); code != exitOK {
+				t.Fatalf("exit %d: %s", code, stderr)
+			}
+			body := api.body(0)
+			if body["deviceId"] != tt.device || body["title"] != "Standup" || body["message"] != "Room 4 & 5" {
+				t.Fatalf("body %v", body)
+			}
+		})
+	}
+}
+
+func TestFromJSON_ImagePath(t *testing.T) {
+	dir := t.TempDir()
+	data, err := quote0.NewCanvas().PNG()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "board.png"), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "board.json")
+	if err := os.WriteFile(path, []byte(`{"imagePath":"board.png","ditherType":"NONE"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c, api, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D1"})
+	if code := c.run([]string{"image", "-from-json", path}); code != exitOK {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	body := api.body(0)
+	if body["deviceId"] != "D1" || body["ditherType"] != "NONE" || body["image"] == "" {
+		t.Fatalf("body %v", body)
+	}
+}
+
+func TestFromJSON_Errors(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.json")
+	if err := os.WriteFile(good, []byte(`{"title":"x"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	typo := filepath.Join(dir, "typo.json")
+	if err := os.WriteFile(typo, []byte(`{"titel":"x"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		args []string
+		code int
+		msg  string
+	}{
+		{"content flag", []string{"text", "-from-json", good, "-title", "y", "-kv", "a=1"}, exitUsage, "do not combine it with -kv, -title"},
+		{"image flag", []string{"image", "-from-json", good, "-dither-type", "NONE"}, exitUsage, "do not combine it with -dither-type"},
+		{"unknown field", []string{"text", "-from-json", typo}, exitError, `unknown field "titel"`},
+		{"no image", []string{"image", "-from-json", good}, exitError, `unknown field "title"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, api, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D1"})
+			if code := c.run(tt.args); code != tt.code {
+				t.Fatalf("exit %d, want %d: %s", code, tt.code, stderr)
+			}
+			if !strings.Contains(stderr.String(), tt.msg) {
+				t.Fatalf("stderr %q, want %q", stderr, tt.msg)
+			}
+			if api.count() != 0 {
+				t.Fatalf("sent %d requests", api.count())
+			}
+		})
+	}
+}
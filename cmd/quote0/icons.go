@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/1set/quote0"
+)
+
+// iconDescriptions are the `icons list` blurbs for the built-in set.
+var iconDescriptions = map[quote0.Icon]string{
+	quote0.IconSun:          "Clear sky; a sun with rays",
+	quote0.IconMoon:         "Clear night; a crescent moon",
+	quote0.IconCloud:        "Overcast; a single cloud",
+	quote0.IconPartlyCloudy: "Sun partly behind a cloud",
+	quote0.IconRain:         "Cloud with rain drops",
+	quote0.IconSnow:         "Cloud with snowflakes",
+	quote0.IconStorm:        "Cloud with a lightning bolt",
+	quote0.IconFog:          "Horizontal haze lines",
+	quote0.IconWarning:      "Exclamation mark in a triangle, for alerts",
+	quote0.IconCheck:        "Check mark, for success",
+}
+
+// iconSheetCols is the number of icons per row of `icons sheet`.
+const iconSheetCols = 5
+
+// runIcons browses the built-in icon set: list, export, sheet, and send. Only send uses
+// the network.
+func (c *cli) runIcons(args []string) error {
+	if len(args) == 0 {
+		return usagef("icons needs list, export NAME, sheet, or send NAME")
+	}
+	switch args[0] {
+	case "list":
+		return c.runIconsList(args[1:])
+	case "export":
+		return c.runIconsExport(args[1:])
+	case "sheet":
+		return c.runIconsSheet(args[1:])
+	case "send":
+		return c.runIconsSend(args[1:])
+	default:
+		return usagef("unknown icons command %q (want list, export, sheet, or send)", args[0])
+	}
+}
+
+func (c *cli) runIconsList(args []string) error {
+	if len(args) > 0 {
+		return usagef("icons list takes no arguments, got %q", args[0])
+	}
+	tw := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+	for _, name := range quote0.Icons() {
+		fmt.Fprintf(tw, "%s\t%s\n", name, iconDescriptions[name])
+	}
+	return tw.Flush()
+}
+
+func (c *cli) runIconsExport(args []string) error {
+	fs, _ := c.newFlagSet("icons export")
+	out := fs.String("out", "", "Write the 40x40 PNG here, or - for stdout")
+	name, rest := iconName(args)
+	if err := c.parseFlags(fs, rest); err != nil {
+		return err
+	}
+	if name == "" || *out == "" || fs.NArg() > 0 {
+		return usagef("usage: quote0 icons export NAME -out FILE")
+	}
+	img, err := lookupIcon(name)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return err
+	}
+	if *out == "-" {
+		_, err := c.stdout.Write(buf.Bytes())
+		return err
+	}
+	if err := os.WriteFile(*out, buf.Bytes(), 0o644); err != nil {
+		return err
+	}
+	fmt.Fprintf(c.stderr, "Wrote %s (%s, %dx%d)\n", *out, name, quote0.IconSize, quote0.IconSize)
+	return nil
+}
+
+func (c *cli) runIconsSheet(args []string) error {
+	fs, _ := c.newFlagSet("icons sheet")
+	out := fs.String("out", "", "Write the labeled contact sheet PNG here")
+	if err := c.parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *out == "" || fs.NArg() > 0 {
+		return usagef("usage: quote0 icons sheet -out FILE")
+	}
+	names := quote0.Icons()
+	labels := make([]string, len(names))
+	tiles := make([]*image.Gray, len(names))
+	for i, name := range names {
+		img, err := quote0.RenderIcon(name)
+		if err != nil {
+			return err
+		}
+		labels[i], tiles[i] = string(name), img
+	}
+	if err := writePNGFile(*out, contactSheet(labels, tiles, iconSheetCols)); err != nil {
+		return err
+	}
+	fmt.Fprintf(c.stderr, "Wrote %s (%d icons)\n", *out, len(names))
+	return nil
+}
+
+// runIconsSend is `text -icon` with a built-in icon: every other flag is passed to text.
+func (c *cli) runIconsSend(args []string) error {
+	name, rest := iconName(args)
+	if name == "" {
+		return usagef("usage: quote0 icons send NAME [text flags]")
+	}
+	if _, err := lookupIcon(name); err != nil {
+		return err
+	}
+	icon, err := quote0.IconBase64(quote0.Icon(name))
+	if err != nil {
+		return err
+	}
+	return c.runText(append([]string{"-icon", icon}, rest...))
+}
+
+// iconName takes the icon name off the front of args; it is empty when args start with a flag.
+func iconName(args []string) (string, []string) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return "", args
+	}
+	return args[0], args[1:]
+}
+
+// lookupIcon renders a built-in icon; an unknown name is a usage error suggesting close
+// matches.
+func lookupIcon(name string) (*image.Gray, error) {
+	img, err := quote0.RenderIcon(quote0.Icon(strings.ToLower(name)))
+	if err == nil {
+		return img, nil
+	}
+	var names []string
+	for _, n := range quote0.Icons() {
+		names = append(names, string(n))
+	}
+	if near := closeMatches(strings.ToLower(name), names); len(near) > 0 {
+		return nil, usagef("unknown icon %q; did you mean %s?", name, strings.Join(near, " or "))
+	}
+	return nil, usagef("unknown icon %q; quote0 icons list shows the names", name)
+}
+
+// closeMatches returns the candidates within two edits of name, or containing it, closest
+// first.
+func closeMatches(name string, candidates []string) []string {
+	type match struct {
+		name string
+		dist int
+	}
+	var ms []match
+	for _, cand := range candidates {
+		d := editDistance(name, cand)
+		if d <= 2 || len(name) >= 3 && strings.Contains(cand, name) {
+			ms = append(ms, match{cand, d})
+		}
+	}
+	sort.SliceStable(ms, func(i, j int) bool { return ms[i].dist < ms[j].dist })
+	out := make([]string, len(ms))
+	for i, m := range ms {
+		out[i] = m.name
+	}
+	return out
+}
+
+// editDistance is the Levenshtein distance between a and b, by rune.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur := make([]int, len(rb)+1)
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = cur[j-1] + 1
+			if v := prev[j] + 1; v < cur[j] {
+				cur[j] = v
+			}
+			if v := prev[j-1] + cost; v < cur[j] {
+				cur[j] = v
+			}
+		}
+		prev = cur
+	}
+	return prev[len(rb)]
+}
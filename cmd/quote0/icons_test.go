@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/1set/quote0"
+)
+
+func TestIconsList(t *testing.T) {
+	c, api, stdout, stderr := newTestCLI(t, nil)
+	if code := c.run([]string{"icons", "list"}); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) != len(quote0.Icons()) || api.count() != 0 {
+		t.Fatalf("%d lines, %d requests:\n%s", len(lines), api.count(), stdout)
+	}
+	for i, name := range quote0.Icons() {
+		if iconDescriptions[name] == "" {
+			t.Errorf("no description for %s", name)
+		}
+		if !strings.HasPrefix(lines[i], string(name)+" ") {
+			t.Errorf("line %d %q, want %s first", i, lines[i], name)
+		}
+	}
+}
+
+func TestIconsExport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "icon.png")
+	c, _, _, stderr := newTestCLI(t, nil)
+	if code := c.run([]string{"icons", "export", "storm", "-out", path}); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil || img.Bounds() != image.Rect(0, 0, quote0.IconSize, quote0.IconSize) {
+		t.Fatalf("exported %v, %v", img.Bounds(), err)
+	}
+
+	c, _, stdout, _ := newTestCLI(t, nil)
+	if code := c.run([]string{"icons", "export", "Storm", "-out", "-"}); code != 0 || !bytes.Equal(stdout.Bytes(), data) {
+		t.Fatalf("exit %d, stdout differs from the file", code)
+	}
+}
+
+func TestIconsSheet(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sheet.png")
+	c, _, _, stderr := newTestCLI(t, nil)
+	if code := c.run([]string{"icons", "sheet", "-out", path}); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	cfg, err := png.DecodeConfig(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	labelW := quote0.TextWidth(string(quote0.IconPartlyCloudy), 1)
+	if cfg.Width != sheetGap+iconSheetCols*(labelW+sheetGap) || cfg.Height != sheetGap+2*(quote0.IconSize+sheetLabelH+sheetGap) {
+		t.Fatalf("sheet %dx%d", cfg.Width, cfg.Height)
+	}
+}
+
+func TestIconsSend(t *testing.T) {
+	c, api, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+	if code := c.run([]string{"icons", "send", "check", "-title", "Backup", "-message", "ok"}); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	want, _ := quote0.IconBase64(quote0.IconCheck)
+	if b := api.body(0); b["icon"] != want || b["title"] != "Backup" {
+		t.Fatalf("body %v", b)
+	}
+}
+
+func TestIcons_UnknownName(t *testing.T) {
+	for _, tc := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"icons", "export", "strom", "-out", "x.png"}, `did you mean storm?`},
+		{[]string{"icons", "send", "cloudy", "-title", "x"}, `did you mean cloud or partly-cloudy?`},
+		{[]string{"icons", "export", "zebra", "-out", "x.png"}, "icons list shows the names"},
+		{[]string{"icons", "paint"}, "unknown icons command"},
+	} {
+		c, api, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+		if code := c.run(tc.args); code != exitUsage || api.count() != 0 {
+			t.Errorf("%v: exit %d, %d requests", tc.args, code, api.count())
+		}
+		if !strings.Contains(stderr.String(), tc.want) {
+			t.Errorf("%v: stderr %q, want %q", tc.args, stderr, tc.want)
+		}
+	}
+}
+
+func TestCloseMatches(t *testing.T) {
+	names := []string{"sun", "moon", "cloud", "partly-cloudy", "snow", "storm"}
+	for _, tc := range []struct {
+		name string
+		want []string
+	}{
+		{"son", []string{"sun", "moon", "snow"}},
+		{"clod", []string{"cloud"}},
+		{"loud", []string{"cloud", "partly-cloudy"}},
+		{"xyzzy", nil},
+	} {
+		got := closeMatches(tc.name, names)
+		if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+			t.Errorf("closeMatches(%q) = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/1set/quote0"
+)
+
+func TestText_IconText(t *testing.T) {
+	c, api, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+	if code := c.run([]string{"text", "-title", "Backup", "-icon-text", "DB", "-icon-invert"}); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	data, err := base64.StdEncoding.DecodeString(api.body(0)["icon"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := quote0.RenderMonogram("DB", true)
+	if gray, ok := got.(*image.Gray); !ok || !bytes.Equal(gray.Pix, want.Pix) {
+		t.Fatal("sent icon differs from the monogram")
+	}
+}
+
+func TestText_IconTextErrors(t *testing.T) {
+	for _, tc := range []struct {
+		args []string
+		code int
+		want string
+	}{
+		{[]string{"-icon-text", "ABC"}, exitValidation, "want 1 to 2"},
+		{[]string{"-icon-text", "DB", "-icon-url", "http://example.com/i.png"}, exitUsage, "only one of"},
+		{[]string{"-icon-invert"}, exitUsage, "-icon-invert applies to -icon-text"},
+	} {
+		c, api, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+		code := c.run(append([]string{"text", "-title", "x"}, tc.args...))
+		if code != tc.code || api.count() != 0 || !strings.Contains(stderr.String(), tc.want) {
+			t.Errorf("%v: exit %d, stderr %q", tc.args, code, stderr)
+		}
+	}
+}
+
+func TestPreview_IconText(t *testing.T) {
+	dir := t.TempDir()
+	render := func(name string, extra ...string) *image.Gray {
+		t.Helper()
+		c, _, _, stderr := newTestCLI(t, map[string]string{})
+		out := filepath.Join(dir, name)
+		if code := c.run(append([]string{"preview", "text", "-title", "x", "-out", out}, extra...)); code != 0 {
+			t.Fatalf("exit %d: %s", code, stderr)
+		}
+		f, err := os.Open(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		img, err := png.Decode(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gray := image.NewGray(img.Bounds())
+		for y := img.Bounds().Min.Y; y < img.Bounds().Max.Y; y++ {
+			for x := img.Bounds().Min.X; x < img.Bounds().Max.X; x++ {
+				gray.Set(x, y, img.At(x, y))
+			}
+		}
+		return gray
+	}
+	plain := render("plain.png")
+	icon := render("icon.png", "-icon-text", "⚠", "-icon-invert")
+	if bytes.Equal(plain.Pix, icon.Pix) {
+		t.Fatal("preview does not show the -icon-text icon")
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/1set/quote0"
+)
+
+func TestIconURL(t *testing.T) {
+	icon, _ := quote0.NewCanvasSize(quote0.IconSize, quote0.IconSize).PNG()
+	icons := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/db.png" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(icon)
+	}))
+	defer icons.Close()
+
+	c, api, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+	if code := c.run([]string{"text", "-title", "DB", "-icon-url", icons.URL + "/db.png"}); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if api.body(0)["icon"] != base64.StdEncoding.EncodeToString(icon) {
+		t.Fatalf("body %v", api.body(0))
+	}
+
+	// A failed download is not an API error and nothing is sent.
+	c, api, _, stderr = newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+	if code := c.run([]string{"text", "-title", "DB", "-icon-url", icons.URL + "/missing.png"}); code == 0 {
+		t.Fatal("expected failure")
+	}
+	if api.count() != 0 || !strings.Contains(stderr.String(), "could not fetch icon") || !strings.Contains(stderr.String(), "HTTP 404") {
+		t.Fatalf("stderr %q", stderr)
+	}
+
+	c, _, _, stderr = newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+	if code := c.run([]string{"text", "-icon", "aGk=", "-icon-url", icons.URL + "/db.png"}); code != exitUsage {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+}
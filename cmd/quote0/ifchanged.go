@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// stateDirectory returns the -state-dir, defaulting to quote0 under the user cache directory.
+func (c *cli) stateDirectory(cf *commonFlags) (string, error) {
+	if *cf.stateDir != "" {
+		return *cf.stateDir, nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", usagef("-if-changed: no user cache directory (%v); set -state-dir", err)
+	}
+	return filepath.Join(dir, "quote0"), nil
+}
+
+// statePath is the file holding the last sent request hash for device.
+func statePath(dir, device string) string {
+	return filepath.Join(dir, fileSafe(device)+".sha256")
+}
+
+// readState returns the hash saved at path, or "" when it is missing or not a SHA-256 hex
+// digest, so a damaged state file only costs one extra send.
+func readState(path string) string {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	h := strings.TrimSpace(string(b))
+	if len(h) != 64 {
+		return ""
+	}
+	if _, err := hex.DecodeString(h); err != nil {
+		return ""
+	}
+	return h
+}
+
+// writeState saves hash at path through a temporary file, so a crash never leaves half a hash.
+func writeState(path, hash string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.WriteString(hash + "\n")
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// changedDevices returns the devices to send to and, with -if-changed, the request hash of each
+// of them; a nil map means every device is sent and no state is kept.
+func (c *cli) changedDevices(cf *commonFlags, devices []string, out outgoing) ([]string, map[string]string, error) {
+	if !*cf.ifChanged {
+		return devices, nil, nil
+	}
+	dir, err := c.stateDirectory(cf)
+	if err != nil {
+		return nil, nil, err
+	}
+	var pending []string
+	hashes := make(map[string]string)
+	for _, id := range devices {
+		req, err := out.build(id)
+		if err != nil {
+			return nil, nil, err
+		}
+		h := req.Hash()
+		if readState(statePath(dir, id)) == h {
+			continue
+		}
+		hashes[id] = h
+		pending = append(pending, id)
+	}
+	return pending, hashes, nil
+}
+
+// saveSentHash records hash as the last successful send to device. The send itself succeeded,
+// so a failure here is only a warning: the next run sends once more.
+func (c *cli) saveSentHash(cf *commonFlags, device, hash string) {
+	dir, err := c.stateDirectory(cf)
+	if err == nil {
+		err = writeState(statePath(dir, device), hash)
+	}
+	if err != nil {
+		fmt.Fprintf(c.stderr, "warning: -if-changed state not saved: %v\n", err)
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/1set/quote0"
+)
+
+func TestIfChanged(t *testing.T) {
+	dir := t.TempDir()
+	c, api, stdout, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D1"})
+	send := func(title string) int {
+		stdout.Reset()
+		return c.run([]string{"text", "-if-changed", "-state-dir", dir, "-title", title})
+	}
+
+	// First run: no state yet, so the text is sent and its hash saved.
+	if code := send("a"); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if api.count() != 1 || !strings.Contains(stdout.String(), "Text sent") {
+		t.Fatalf("first run: %d requests, %q", api.count(), stdout)
+	}
+	saved, err := os.ReadFile(statePath(dir, "D1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Unchanged: nothing is sent and the command still succeeds.
+	if code := send("a"); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if api.count() != 1 || stdout.String() != "Text skipped, unchanged\n" {
+		t.Fatalf("unchanged: %d requests, %q", api.count(), stdout)
+	}
+
+	// Changed: sent again and the state moves on.
+	if code := send("b"); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if api.count() != 2 {
+		t.Fatalf("changed: %d requests", api.count())
+	}
+	if now, _ := os.ReadFile(statePath(dir, "D1")); string(now) == string(saved) {
+		t.Fatal("state not updated after a changed send")
+	}
+}
+
+func TestIfChanged_JSON(t *testing.T) {
+	dir := t.TempDir()
+	c, api, stdout, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok"})
+	args := []string{"text", "-if-changed", "-state-dir", dir, "-json", "-device", "D1,D2", "-title", "x"}
+	if code := c.run(args); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	// A corrupt state file counts as changed; D1's stays valid.
+	if err := os.WriteFile(statePath(dir, "D2"), []byte("garbage"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	stdout.Reset()
+	if code := c.run(args); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	var got []deviceResult
+	if err := json.Unmarshal(stdout.Bytes(), &got); err != nil {
+		t.Fatalf("%v: %s", err, stdout)
+	}
+	if len(got) != 2 || !got[0].Skipped || got[1].Skipped || !got[1].OK {
+		t.Fatalf("results %+v", got)
+	}
+	if api.count() != 3 || api.body(2)["deviceId"] != "D2" {
+		t.Fatalf("%d requests", api.count())
+	}
+}
+
+func TestIfChanged_FailedSendKeepsState(t *testing.T) {
+	dir := t.TempDir()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+	c, _, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D1"})
+	c.clientOptions = append(c.clientOptions, quote0.WithBaseURL(srv.URL))
+	if code := c.run([]string{"text", "-if-changed", "-state-dir", dir, "-title", "x"}); code == 0 {
+		t.Fatalf("failed send exited 0: %s", stderr)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "D1.sha256")); !os.IsNotExist(err) {
+		t.Fatalf("state written after a failed send: %v", err)
+	}
+}
+
+func TestReadState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "D.sha256")
+	if readState(path) != "" {
+		t.Fatal("missing file should read as no state")
+	}
+	h := strings.Repeat("ab", 32)
+	if err := writeState(path, h); err != nil {
+		t.Fatal(err)
+	}
+	if got := readState(path); got != h {
+		t.Fatalf("got %q", got)
+	}
+	for _, bad := range []string{"", "short", strings.Repeat("zz", 32)} {
+		if err := os.WriteFile(path, []byte(bad), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if got := readState(path); got != "" {
+			t.Errorf("%q read as %q", bad, got)
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestImage_Fit(t *testing.T) {
+	dir := t.TempDir()
+	photo := filepath.Join(dir, "photo.jpg")
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, 600, 300)), nil); err != nil {
+		t.Fatal(err)
+	}
+	_ = os.WriteFile(photo, buf.Bytes(), 0o644)
+
+	c, api, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+	if code := c.run([]string{"image", "-image-file", photo, "-fit", "contain", "-bg", "black", "-dither-type", "none"}); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	data, err := base64.StdEncoding.DecodeString(api.bodies[0]["image"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := png.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Width != 296 || cfg.Height != 152 || api.bodies[0]["ditherType"] != "NONE" {
+		t.Fatalf("sent %dx%d %v", cfg.Width, cfg.Height, api.bodies[0]["ditherType"])
+	}
+}
+
+func TestImage_SafeMargin(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "panel.png")
+	full := image.NewGray(image.Rect(0, 0, 296, 152))
+	for i := range full.Pix {
+		full.Pix[i] = 0x80
+	}
+	var buf bytes.Buffer
+	_ = png.Encode(&buf, full)
+	_ = os.WriteFile(path, buf.Bytes(), 0o644)
+
+	for _, tc := range []struct {
+		args []string
+		band uint8
+	}{
+		{[]string{"-border", "black"}, 0},
+		{[]string{"-border", "black", "-bg", "white"}, 255},
+		{nil, 255},
+	} {
+		c, api, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+		args := append([]string{"image", "-image-file", path, "-safe-margin", "4"}, tc.args...)
+		if code := c.run(args); code != 0 {
+			t.Fatalf("%v: exit %d: %s", tc.args, code, stderr)
+		}
+		data, _ := base64.StdEncoding.DecodeString(api.bodies[0]["image"].(string))
+		img, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		gray := img.(*image.Gray)
+		if b := gray.Bounds(); b.Dx() != 296 || b.Dy() != 152 {
+			t.Fatalf("%v: sent %v", tc.args, b)
+		}
+		if gray.GrayAt(2, 2).Y != tc.band || gray.GrayAt(148, 76).Y != 0x80 {
+			t.Errorf("%v: band %d, centre %d", tc.args, gray.GrayAt(2, 2).Y, gray.GrayAt(148, 76).Y)
+		}
+	}
+
+	c, _, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+	if code := c.run([]string{"image", "-image-file", path, "-safe-margin", "76"}); code != exitUsage || !strings.Contains(stderr.String(), "invalid -safe-margin") {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	c, api, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+	if code := c.run([]string{"image", "-image-file", path, "-safe-margin", "4", "-resample", "cubic"}); code != exitUsage || !strings.Contains(stderr.String(), `invalid -resample: unknown filter "cubic"`) || api.count() != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+}
+
+func TestImage_FitErrors(t *testing.T) {
+	dir := t.TempDir()
+	tiny := filepath.Join(dir, "tiny.png")
+	var buf bytes.Buffer
+	_ = png.Encode(&buf, image.NewGray(image.Rect(0, 0, 5, 4)))
+	_ = os.WriteFile(tiny, buf.Bytes(), 0o644)
+	gif := filepath.Join(dir, "anim.gif")
+	_ = os.WriteFile(gif, []byte("GIF89a\x01\x00\x01\x00"), 0o644)
+
+	for _, tc := range []struct {
+		args []string
+		want string
+		code int
+	}{
+		{[]string{"image", "-image-file", tiny, "-fit", "cover"}, "got 5x4", exitValidation},
+		{[]string{"image", "-image-file", gif, "-fit", "cover"}, "got gif", exitValidation},
+		{[]string{"image", "-image-file", tiny, "-fit", "zoom"}, "invalid -fit", exitUsage},
+		{[]string{"image", "-image-file", tiny, "-fit", "contain", "-bg", "red"}, "invalid -bg", exitUsage},
+	} {
+		c, api, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+		if code := c.run(tc.args); code != tc.code || !strings.Contains(stderr.String(), tc.want) {
+			t.Errorf("%v: exit %d, stderr %q", tc.args, code, stderr)
+		}
+		if len(api.bodies) != 0 {
+			t.Errorf("%v: sent a request", tc.args)
+		}
+	}
+}
+
+func TestImage_BorderAndRefreshSpellings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "panel.png")
+	var buf bytes.Buffer
+	_ = png.Encode(&buf, image.NewGray(image.Rect(0, 0, 296, 152)))
+	_ = os.WriteFile(path, buf.Bytes(), 0o644)
+
+	for _, tc := range []struct {
+		args    []string
+		border  interface{}
+		refresh interface{}
+	}{
+		{[]string{"-border", "black"}, float64(1), true},
+		{[]string{"-border", "WHITE", "-refresh=off"}, nil, false},
+		{[]string{"-border", "1", "-refresh=no"}, float64(1), false},
+		{[]string{"-refresh=yes"}, nil, true},
+	} {
+		c, api, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+		if code := c.run(append([]string{"image", "-image-file", path}, tc.args...)); code != 0 {
+			t.Fatalf("%v: exit %d: %s", tc.args, code, stderr)
+		}
+		if body := api.body(0); body["border"] != tc.border || body["refreshNow"] != tc.refresh {
+			t.Errorf("%v: border %v refreshNow %v", tc.args, body["border"], body["refreshNow"])
+		}
+	}
+
+	for _, args := range [][]string{{"-border", "red"}, {"-refresh=maybe"}} {
+		c, api, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+		code := c.run(append([]string{"image", "-image-file", path}, args...))
+		if code != exitUsage || api.count() != 0 || !strings.Contains(stderr.String(), "want ") {
+			t.Errorf("%v: exit %d, stderr %q", args, code, stderr)
+		}
+	}
+}
+
+func TestImage_Adjustments(t *testing.T) {
+	dir := t.TempDir()
+	photo := filepath.Join(dir, "photo.png")
+	ramp := image.NewGray(image.Rect(0, 0, 400, 200))
+	for i := range ramp.Pix {
+		ramp.Pix[i] = uint8(i % 400 * 255 / 399)
+	}
+	var buf bytes.Buffer
+	_ = png.Encode(&buf, ramp)
+	_ = os.WriteFile(photo, buf.Bytes(), 0o644)
+	out := filepath.Join(dir, "out.png")
+
+	c, api, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_DEVICE": "D"})
+	args := []string{"image", "-image-file", photo, "-dry-run", "-out", out, "-fit", "cover", "-rotate", "180",
+		"-gamma", "1.5", "-sharpen", "0.5", "-invert", "-threshold", "128", "-dither-type", "none"}
+	if code := c.run(args); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if api.count() != 0 || strings.Contains(stderr.String(), "warning") {
+		t.Fatalf("%d requests, stderr %q", api.count(), stderr)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gray, ok := img.(*image.Gray)
+	if !ok || gray.Bounds().Dx() != 296 || gray.Bounds().Dy() != 152 {
+		t.Fatalf("wrote %T %v", img, img.Bounds())
+	}
+	// Rotated and inverted, the ramp is black on the left again; thresholded, only 0 and 255.
+	for i, v := range gray.Pix {
+		if v != 0 && v != 255 {
+			t.Fatalf("pixel %d is %d", i, v)
+		}
+	}
+	if gray.GrayAt(2, 70).Y != 0 || gray.GrayAt(293, 70).Y != 255 {
+		t.Fatalf("ends %d, %d", gray.GrayAt(2, 70).Y, gray.GrayAt(293, 70).Y)
+	}
+}
+
+func TestImage_AdjustmentChecks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "panel.png")
+	var buf bytes.Buffer
+	_ = png.Encode(&buf, image.NewGray(image.Rect(0, 0, 296, 152)))
+	_ = os.WriteFile(path, buf.Bytes(), 0o644)
+
+	c, api, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+	if code := c.run([]string{"image", "-image-file", path, "-threshold", "100"}); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if api.count() != 1 || !strings.Contains(stderr.String(), "warning: -dither-type: DIFFUSION dithers") {
+		t.Fatalf("%d requests, stderr %q", api.count(), stderr)
+	}
+
+	for _, tc := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"-gamma", "0"}, "invalid -gamma"},
+		{[]string{"-contrast", "-1"}, "invalid -contrast"},
+		{[]string{"-rotate", "45"}, "invalid -rotate"},
+		{[]string{"-threshold", "300"}, "invalid -threshold"},
+		{[]string{"-out", "x.png"}, "add -dry-run"},
+	} {
+		c, api, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+		code := c.run(append([]string{"image", "-image-file", path}, tc.args...))
+		if code != exitUsage || api.count() != 0 || !strings.Contains(stderr.String(), tc.want) {
+			t.Errorf("%v: exit %d, stderr %q", tc.args, code, stderr)
+		}
+	}
+}
+
+func TestImage_TransparentPNG(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sticker.png")
+	sticker := image.NewNRGBA(image.Rect(0, 0, 200, 100))
+	for i := 0; i < len(sticker.Pix); i += 4 {
+		sticker.Pix[i] = 0xff // transparent red
+	}
+	var buf bytes.Buffer
+	_ = png.Encode(&buf, sticker)
+	_ = os.WriteFile(path, buf.Bytes(), 0o644)
+
+	for _, tc := range []struct {
+		args []string
+		want uint8
+	}{
+		{nil, 255},
+		{[]string{"-border", "black"}, 0},
+		{[]string{"-border", "black", "-bg", "white"}, 255},
+		{[]string{"-invert"}, 0},
+	} {
+		c, api, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+		args := append([]string{"image", "-image-file", path, "-fit", "cover", "-dither-type", "none"}, tc.args...)
+		if code := c.run(args); code != 0 {
+			t.Fatalf("%v: exit %d: %s", tc.args, code, stderr)
+		}
+		data, _ := base64.StdEncoding.DecodeString(api.bodies[0]["image"].(string))
+		img, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := img.(*image.Gray).GrayAt(148, 76).Y; got != tc.want {
+			t.Errorf("%v: centre %d, want %d", tc.args, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/jpeg"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/1set/quote0"
+)
+
+func TestImageURL(t *testing.T) {
+	screen, _ := quote0.NewCanvas().PNG()
+	var photo bytes.Buffer
+	if err := jpeg.Encode(&photo, image.NewGray(image.Rect(0, 0, 600, 300)), nil); err != nil {
+		t.Fatal(err)
+	}
+	images := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/panel.png":
+			_, _ = w.Write(screen)
+		case "/photo.jpg":
+			_, _ = w.Write(photo.Bytes())
+		case "/slow.png":
+			time.Sleep(200 * time.Millisecond)
+			_, _ = w.Write(screen)
+		case "/page":
+			_, _ = w.Write([]byte("<html>"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer images.Close()
+	env := map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"}
+
+	c, api, _, stderr := newTestCLI(t, env)
+	if code := c.run([]string{"image", "-url", images.URL + "/panel.png"}); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if api.body(0)["image"] != base64.StdEncoding.EncodeToString(screen) {
+		t.Fatalf("body %v", api.body(0))
+	}
+
+	// A photo is fitted like a file.
+	c, api, _, stderr = newTestCLI(t, env)
+	if code := c.run([]string{"image", "-url", images.URL + "/photo.jpg", "-fit", "cover"}); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	raw, _ := base64.StdEncoding.DecodeString(api.body(0)["image"].(string))
+	if cfg, _, err := image.DecodeConfig(bytes.NewReader(raw)); err != nil || cfg.Width != quote0.ScreenWidth || cfg.Height != quote0.ScreenHeight {
+		t.Fatalf("sent %dx%d, %v", cfg.Width, cfg.Height, err)
+	}
+
+	for _, tc := range []struct {
+		name string
+		args []string
+		want int
+	}{
+		{"missing", []string{"-url", images.URL + "/missing.png"}, exitFetch},
+		{"timeout", []string{"-url", images.URL + "/slow.png", "-timeout", "50ms"}, exitFetch},
+		{"not an image", []string{"-url", images.URL + "/page"}, exitValidation},
+		{"with -image-file", []string{"-url", images.URL + "/panel.png", "-image-file", "x.png"}, exitUsage},
+		{"with -image", []string{"-url", images.URL + "/panel.png", "-image", "aGk="}, exitUsage},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c, api, _, stderr := newTestCLI(t, env)
+			if code := c.run(append([]string{"image"}, tc.args...)); code != tc.want || api.count() != 0 {
+				t.Fatalf("exit %d, want %d, %d requests: %s", code, tc.want, api.count(), stderr)
+			}
+			if tc.want == exitFetch && !strings.Contains(stderr.String(), "could not fetch image") {
+				t.Errorf("stderr %q", stderr)
+			}
+		})
+	}
+}
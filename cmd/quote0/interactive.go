@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"image/png"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/1set/quote0"
+)
+
+// errAborted ends `text -i` at end of input; nothing has been sent at that point.
+var errAborted = errors.New("input ended; nothing sent")
+
+// isTerminal reports whether r is an interactive terminal.
+func isTerminal(r io.Reader) bool {
+	f, ok := r.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// stdinIsTerminal reports whether the CLI reads from a terminal.
+func (c *cli) stdinIsTerminal() bool {
+	if c.tty != nil {
+		return c.tty()
+	}
+	return isTerminal(c.stdin)
+}
+
+// prompter asks for input on the terminal. Prompts go to stderr so stdout stays the command
+// result. Ctrl-C keeps its default behavior and ends the process; since nothing is sent
+// before the final confirmation, an interrupted session sends nothing.
+type prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+// line prints label and reads one line without its line ending; errAborted at end of input.
+func (p *prompter) line(label string) (string, error) {
+	fmt.Fprint(p.out, label)
+	s, err := p.in.ReadString('\n')
+	if err != nil && (err != io.EOF || s == "") {
+		if err == io.EOF {
+			fmt.Fprintln(p.out)
+			return "", errAborted
+		}
+		return "", err
+	}
+	return strings.TrimRight(s, "\r\n"), nil
+}
+
+// promptText asks for the title, message, and signature of tf, reporting each field's budget.
+func (c *cli) promptText(p *prompter, tf *textFlags) error {
+	title, err := p.line("Title: ")
+	if err != nil {
+		return err
+	}
+	*tf.title = strings.TrimSpace(title)
+	fmt.Fprintf(p.out, "  %s\n", budget(*tf.title, quote0.MaxTitleRunes))
+
+	fmt.Fprintln(p.out, `Message (end with a line containing only "."):`)
+	var lines []string
+	for {
+		l, err := p.line("> ")
+		if err != nil {
+			return err
+		}
+		if l == "." {
+			break
+		}
+		lines = append(lines, l)
+	}
+	*tf.message = strings.TrimRight(strings.Join(lines, "\n"), "\n ")
+	fmt.Fprintf(p.out, "  %s, %d/3 lines\n", budget(*tf.message, quote0.MaxMessageRunes), len(lines))
+
+	label := "Signature: "
+	if *tf.autoSignature || *tf.signatureFormat != "" {
+		label = "Signature (empty for the auto signature): "
+	}
+	sig, err := p.line(label)
+	if err != nil {
+		return err
+	}
+	*tf.signature = strings.TrimSpace(sig)
+	if *tf.signature != "" {
+		fmt.Fprintf(p.out, "  %s\n", budget(*tf.signature, quote0.MaxSignatureRunes))
+	}
+	return nil
+}
+
+// budget describes how much of a field's character budget s uses.
+func budget(s string, max int) string {
+	n := utf8.RuneCountInString(s)
+	if n > max {
+		return fmt.Sprintf("%d/%d characters (too long; will be cut)", n, max)
+	}
+	return fmt.Sprintf("%d/%d characters", n, max)
+}
+
+// confirmText shows what will be sent with any problems the SDK checks find, and asks for
+// confirmation. "p" writes a preview PNG to a temporary file and asks again.
+func (c *cli) confirmText(p *prompter, req quote0.TextRequest, devices []string) (bool, error) {
+	fmt.Fprintf(p.out, "\nTo %s:\n  Title:     %s\n  Message:   %s\n  Signature: %s\n",
+		strings.Join(devices, ", "), req.Title, strings.ReplaceAll(req.Message, "\n", "\n             "), req.Signature)
+	for _, prob := range quote0.CheckText(req) {
+		fmt.Fprintf(p.out, "  %s: %s: %s\n", prob.Severity, prob.Field, prob.Message)
+	}
+	for {
+		answer, err := p.line("Send? [y/N, p to preview] ")
+		if err != nil {
+			return false, err
+		}
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "y", "yes":
+			return true, nil
+		case "p", "preview":
+			path, err := writePreviewTemp(req)
+			if err != nil {
+				fmt.Fprintf(p.out, "  preview failed: %v\n", err)
+				continue
+			}
+			fmt.Fprintf(p.out, "  preview written to %s\n", path)
+		default:
+			return false, nil
+		}
+	}
+}
+
+// writePreviewTemp renders req with PreviewText into a new temporary PNG and returns its path.
+func writePreviewTemp(req quote0.TextRequest) (string, error) {
+	img, err := quote0.PreviewText(req)
+	if err != nil {
+		return "", err
+	}
+	f, err := os.CreateTemp("", "quote0-preview-*.png")
+	if err != nil {
+		return "", err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return "", err
+	}
+	return f.Name(), f.Close()
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// interactiveCLI runs `text -i` on a pretend terminal and returns the prompts and exit code.
+func interactiveCLI(t *testing.T, input string) (*fakeAPI, string, int) {
+	t.Helper()
+	c, api, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+	c.stdin = strings.NewReader(input)
+	c.tty = func() bool { return true }
+	code := c.run([]string{"text", "-i"})
+	return api, stderr.String(), code
+}
+
+func TestInteractive_Send(t *testing.T) {
+	api, out, code := interactiveCLI(t, "Deploy\nv2.3 is live\nrollback: make undo\n.\nops\ny\n")
+	if code != 0 {
+		t.Fatalf("exit %d: %q", code, out)
+	}
+	body := api.body(0)
+	if body["title"] != "Deploy" || body["message"] != "v2.3 is live\nrollback: make undo" || body["signature"] != "ops" {
+		t.Fatalf("body %v", body)
+	}
+	for _, want := range []string{"6/28 characters", "2/3 lines", "To D:", "Send? [y/N"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("prompt output lacks %q: %q", want, out)
+		}
+	}
+}
+
+func TestInteractive_NothingSent(t *testing.T) {
+	for name, input := range map[string]string{
+		"declined":        "Deploy\nok\n.\n\nn\n",
+		"eof in message":  "Deploy\nhalf a mess",
+		"eof at confirm":  "Deploy\nok\n.\nops\n",
+		"eof immediately": "",
+	} {
+		api, out, code := interactiveCLI(t, input)
+		if api.count() != 0 {
+			t.Errorf("%s: sent %v", name, api.bodies)
+		}
+		if name == "declined" {
+			if !strings.Contains(out, "Not sent.") || code != 0 {
+				t.Errorf("%s: exit %d: %q", name, code, out)
+			}
+		} else if !strings.Contains(out, "nothing sent") || code != exitError {
+			t.Errorf("%s: exit %d: %q", name, code, out)
+		}
+	}
+}
+
+func TestInteractive_TooLongWarns(t *testing.T) {
+	_, out, _ := interactiveCLI(t, strings.Repeat("x", 30)+"\n.\n\nn\n")
+	if !strings.Contains(out, "30/28 characters (too long; will be cut)") || !strings.Contains(out, "error: title:") {
+		t.Fatalf("output %q", out)
+	}
+}
+
+func TestInteractive_Usage(t *testing.T) {
+	c, api, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+	if code := c.run([]string{"text", "-i"}); code != exitUsage || !strings.Contains(stderr.String(), "-i needs a terminal") {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	c, _, _, stderr = newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+	c.tty = func() bool { return true }
+	if code := c.run([]string{"text", "-i", "-title", "x"}); code != exitUsage {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if api.count() != 0 {
+		t.Fatal("sent")
+	}
+}
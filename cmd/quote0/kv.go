@@ -0,0 +1,57 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/1set/quote0"
+)
+
+// kvList is the repeatable -kv KEY=VALUE flag.
+type kvList struct {
+	pairs []quote0.KeyValue
+}
+
+func (l *kvList) String() string {
+	if l == nil {
+		return ""
+	}
+	parts := make([]string, len(l.pairs))
+	for i, p := range l.pairs {
+		parts[i] = p.Key + "=" + p.Value
+	}
+	return strings.Join(parts, ",")
+}
+
+func (l *kvList) Set(s string) error {
+	key, value, ok := strings.Cut(s, "=")
+	if !ok || strings.TrimSpace(key) == "" {
+		return fmt.Errorf("want KEY=VALUE, got %q", s)
+	}
+	l.pairs = append(l.pairs, quote0.KeyValue{Key: key, Value: value})
+	return nil
+}
+
+// kvMessage formats the -kv pairs as the message. A message from -message or -message-file
+// cannot be combined with them.
+func (f *textFlags) kvMessage(c *cli, cf *commonFlags) (string, error) {
+	if *f.message != "" || *f.messageFile != "" {
+		return "", usagef("-kv builds the message; do not combine it with -message or -message-file")
+	}
+	pairs := f.kv.pairs
+	msg, err := quote0.FormatKeyValues(pairs,
+		quote0.WithKeyValueSeparator(*f.kvSeparator),
+		quote0.WithKeyValueAlign(quote0.KeyValueAlign(strings.ToLower(strings.TrimSpace(*f.kvAlign)))),
+		quote0.WithKeyValueOverflow(*f.dropOverflow))
+	if errors.Is(err, quote0.ErrTooManyPairs) {
+		return "", fmt.Errorf("%w (pass -drop-overflow to show the first %d)", err, quote0.MessageLines)
+	}
+	if err != nil {
+		return "", usagef("-kv-align: %v", err)
+	}
+	if dropped := len(pairs) - quote0.MessageLines; dropped > 0 && (*cf.verbose > 0 || *cf.debug) {
+		fmt.Fprintf(c.stderr, "Dropped %d -kv pairs that do not fit\n", dropped)
+	}
+	return msg, nil
+}
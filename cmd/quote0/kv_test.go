@@ -0,0 +1,57 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestText_KV(t *testing.T) {
+	c, api, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D1"})
+	if code := c.run([]string{"text", "-title", "Server", "-kv", "CPU=43%", "-kv", "Mem=71%", "-kv", "Disk=88%"}); code != exitOK {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if got, want := api.body(0)["message"], "CPU:  43%\nMem:  71%\nDisk: 88%"; got != want {
+		t.Fatalf("message %q, want %q", got, want)
+	}
+
+	c, api, _, stderr = newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D1"})
+	args := []string{"text", "-kv", "a=1", "-kv", "b=2", "-kv", "c=3", "-kv", "d=4", "-drop-overflow", "-kv-separator", "=", "-kv-align", "none", "-v"}
+	if code := c.run(args); code != exitOK {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if got, want := api.body(0)["message"], "a=1\nb=2\nc=3"; got != want {
+		t.Fatalf("message %q, want %q", got, want)
+	}
+	if !strings.Contains(stderr.String(), "Dropped 1 -kv pairs") {
+		t.Fatalf("stderr %q", stderr)
+	}
+}
+
+func TestText_KVErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		code int
+		msg  string
+	}{
+		{"overflow", []string{"-kv", "a=1", "-kv", "b=2", "-kv", "c=3", "-kv", "d=4"}, exitValidation, "4 pairs, 3 fit (pass -drop-overflow"},
+		{"with message", []string{"-kv", "a=1", "-message", "hi"}, exitUsage, "do not combine it with -message"},
+		{"with message file", []string{"-kv", "a=1", "-message-file", "-"}, exitUsage, "do not combine it with -message"},
+		{"no equals", []string{"-kv", "CPU"}, exitUsage, `want KEY=VALUE, got "CPU"`},
+		{"bad align", []string{"-kv", "a=1", "-kv-align", "center"}, exitUsage, `unknown key/value alignment "center"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, api, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D1"})
+			if code := c.run(append([]string{"text"}, tt.args...)); code != tt.code {
+				t.Fatalf("exit %d, want %d: %s", code, tt.code, stderr)
+			}
+			if !strings.Contains(stderr.String(), tt.msg) {
+				t.Fatalf("stderr %q, want %q", stderr, tt.msg)
+			}
+			if api.count() != 0 {
+				t.Fatalf("sent %d requests", api.count())
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/1set/quote0"
+	"github.com/1set/quote0/quote0test"
+)
+
+func TestImage_LinkQR(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dash.png")
+	black := image.NewGray(image.Rect(0, 0, 296, 152))
+	var buf bytes.Buffer
+	_ = png.Encode(&buf, black)
+	_ = os.WriteFile(path, buf.Bytes(), 0o644)
+
+	const link = "https://grafana.local/d/x"
+	c, api, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+	if code := c.run([]string{"image", "-image-file", path, "-link", link, "-link-qr", "Bottom-Right"}); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	body := api.body(0)
+	if body["link"] != link {
+		t.Fatalf("link %v", body["link"])
+	}
+	data, _ := base64.StdEncoding.DecodeString(body["image"].(string))
+	sent, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The same overlay drawn by the SDK on the same background.
+	want := image.NewGray(black.Rect)
+	if _, err := quote0.OverlayQR(want, link, quote0.QRBottomRight, 58); err != nil {
+		t.Fatal(err)
+	}
+	quote0test.AssertImagesEqual(t, sent, want, 0)
+}
+
+func TestImage_LinkQRErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dash.png")
+	var buf bytes.Buffer
+	_ = png.Encode(&buf, image.NewGray(image.Rect(0, 0, 100, 50)))
+	_ = os.WriteFile(path, buf.Bytes(), 0o644)
+	long := "https://example.com/" + strings.Repeat("a", quote0.MaxQRBytes)
+
+	for _, tc := range []struct {
+		args []string
+		want string
+		code int
+	}{
+		{[]string{"-link-qr", "bottom-right"}, "add -link", exitUsage},
+		{[]string{"-link", "x", "-link-qr", "middle"}, "invalid -link-qr", exitUsage},
+		{[]string{"-link", "x", "-link-qr", "top-left", "-link-qr-size", "0"}, "invalid -link-qr-size", exitUsage},
+		{[]string{"-link", long, "-link-qr", "top-left"}, "shorten the link", exitUsage},
+		{[]string{"-link", "x", "-link-qr", "top-left"}, "use a fit mode", exitValidation},
+	} {
+		c, api, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+		args := append([]string{"image", "-image-file", path}, tc.args...)
+		if code := c.run(args); code != tc.code || !strings.Contains(stderr.String(), tc.want) {
+			t.Errorf("%v: exit %d, stderr %q", tc.args, code, stderr)
+		}
+		if len(api.bodies) != 0 {
+			t.Errorf("%v: sent a request", tc.args)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// lockPoll is how often a waiting run retries a held lock.
+const lockPoll = 100 * time.Millisecond
+
+// lockError reports a lock that is still held when -lock-timeout runs out.
+type lockError struct {
+	path   string
+	pid    int
+	waited time.Duration
+}
+
+func (e lockError) Error() string {
+	holder := "another run"
+	if e.pid > 0 {
+		holder = fmt.Sprintf("pid %d", e.pid)
+	}
+	if e.waited > 0 {
+		return fmt.Sprintf("lock %s still held by %s after %s", e.path, holder, e.waited)
+	}
+	return fmt.Sprintf("lock %s is held by %s (use -lock-timeout to wait)", e.path, holder)
+}
+
+// lockPath resolves -lock: "auto" is a file under the temp directory keyed by device.
+func lockPath(flagValue, device string) string {
+	if flagValue != "auto" {
+		return flagValue
+	}
+	name := "quote0.lock"
+	if device != "" {
+		name = "quote0-" + fileSafe(device) + ".lock"
+	}
+	return filepath.Join(os.TempDir(), name)
+}
+
+// fileSafe maps a device ID to a string usable as part of a file name.
+func fileSafe(device string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' {
+			return r
+		}
+		return '_'
+	}, device)
+}
+
+// acquireLock takes the lock at path, waiting up to timeout while another live run holds
+// it. The lock is held through the operating system (see tryLock), so a lock left by a
+// process that is gone is taken over. The returned func releases the lock.
+func acquireLock(ctx context.Context, path string, timeout time.Duration, waiting func(pid int)) (func(), error) {
+	start := time.Now()
+	notified := false
+	for {
+		release, pid, err := tryLock(path)
+		if err != nil || release != nil {
+			return release, err
+		}
+		waited := time.Since(start)
+		if waited >= timeout {
+			return nil, lockError{path: path, pid: pid, waited: timeout}
+		}
+		if !notified && waiting != nil {
+			waiting(pid)
+			notified = true
+		}
+		wait := lockPoll
+		if rest := timeout - waited; rest < wait {
+			wait = rest
+		}
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil, ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// writeLockPID replaces the contents of a lock file just taken with this process's PID, so
+// waiting runs can name the holder.
+func writeLockPID(f *os.File) error {
+	if err := f.Truncate(0); err != nil {
+		return err
+	}
+	_, err := f.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+	return err
+}
+
+// readLockPID returns the PID written in a lock file, or 0 if it has none yet.
+func readLockPID(f *os.File) int {
+	buf := make([]byte, 32)
+	n, _ := f.ReadAt(buf, 0)
+	pid, err := strconv.Atoi(strings.TrimSpace(string(buf[:n])))
+	if err != nil || pid <= 0 {
+		return 0
+	}
+	return pid
+}
+
+// lock takes the -lock for a sending command, keyed by device; it is released when run
+// returns. Dry runs do not lock.
+func (c *cli) lock(cf *commonFlags, device string) error {
+	if *cf.lock == "" || *cf.dryRun || c.unlock != nil {
+		return nil
+	}
+	if *cf.lockTimeout < 0 {
+		return usagef("-lock-timeout must not be negative")
+	}
+	path := lockPath(*cf.lock, device)
+	release, err := acquireLock(c.context(), path, *cf.lockTimeout, func(pid int) {
+		if *cf.verbose > 0 || *cf.debug {
+			fmt.Fprintf(c.stderr, "Waiting up to %s for lock %s (pid %d)\n", *cf.lockTimeout, path, pid)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	c.unlock = release
+	return nil
+}
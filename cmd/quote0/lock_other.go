@@ -0,0 +1,68 @@
+//go:build !aix && !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// lockUnreadableAge is how old a lock file without a valid PID must be before it is taken
+// over; younger files may belong to a run that has not written its PID yet.
+const lockUnreadableAge = time.Minute
+
+// tryLock creates path exclusively without waiting and keeps it open while the lock is held.
+// On Windows an open file cannot be removed, so the takeover of a lock whose PID is gone
+// fails while any run still holds it and the caller keeps waiting; the file is removed on
+// release. It returns a release func when the lock was taken, or the holder's PID (0 if
+// unknown) when another run has it.
+func tryLock(path string) (release func(), holder int, err error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o644)
+	if err == nil {
+		if err := writeLockPID(f); err != nil {
+			f.Close()
+			os.Remove(path)
+			return nil, 0, fmt.Errorf("lock %s: %w", path, err)
+		}
+		return func() {
+			f.Close()
+			os.Remove(path)
+		}, 0, nil
+	}
+	if !errors.Is(err, os.ErrExist) {
+		return nil, 0, fmt.Errorf("lock: %w", err)
+	}
+	pid, stale := inspectLock(path)
+	if stale && os.Remove(path) == nil {
+		return tryLock(path)
+	}
+	return nil, pid, nil
+}
+
+// inspectLock reads the PID in a lock file and reports whether the lock is stale: its process
+// is gone, or it has no valid PID and is too old to be a lock still being written.
+func inspectLock(path string) (pid int, stale bool) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, false // released meanwhile; retry the create
+	}
+	if pid, err = strconv.Atoi(strings.TrimSpace(string(data))); err == nil && pid > 0 {
+		return pid, !processAlive(pid)
+	}
+	info, err := os.Stat(path)
+	return 0, err == nil && time.Since(info.ModTime()) > lockUnreadableAge
+}
+
+// processAlive reports whether pid exists; on Windows FindProcess fails for exited processes.
+func processAlive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	p.Release()
+	return true
+}
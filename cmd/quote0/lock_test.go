@@ -0,0 +1,291 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/1set/quote0"
+)
+
+// slowAPI answers after a delay and records the most requests it saw in flight at once.
+type slowAPI struct {
+	mu                  sync.Mutex
+	inFlight, max, seen int
+}
+
+func (s *slowAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.inFlight++
+	s.seen++
+	if s.inFlight > s.max {
+		s.max = s.inFlight
+	}
+	s.mu.Unlock()
+	time.Sleep(150 * time.Millisecond)
+	s.mu.Lock()
+	s.inFlight--
+	s.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = io.WriteString(w, `{"code":0,"message":"ok"}`)
+}
+
+func newLockCLI(url string) (*cli, *bytes.Buffer) {
+	var stdout, stderr bytes.Buffer
+	env := map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D1"}
+	return &cli{
+		stdin:         strings.NewReader(""),
+		stdout:        &stdout,
+		stderr:        &stderr,
+		getenv:        func(k string) string { return env[k] },
+		clientOptions: []quote0.ClientOption{quote0.WithBaseURL(url), quote0.WithRateLimiter(nil)},
+	}, &stderr
+}
+
+func TestLock_SerializesRuns(t *testing.T) {
+	api := &slowAPI{}
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+	path := filepath.Join(t.TempDir(), "q0.lock")
+
+	var wg sync.WaitGroup
+	codes := make([]int, 2)
+	logs := make([]*bytes.Buffer, 2)
+	for i := range codes {
+		c, stderr := newLockCLI(srv.URL)
+		logs[i] = stderr
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			codes[i] = c.run([]string{"text", "-title", "run " + strconv.Itoa(i), "-lock", path, "-lock-timeout", "5s"})
+		}(i)
+	}
+	wg.Wait()
+	for i, code := range codes {
+		if code != exitOK {
+			t.Fatalf("run %d exit %d: %s", i, code, logs[i])
+		}
+	}
+	if api.seen != 2 || api.max != 1 {
+		t.Fatalf("requests %d, max in flight %d; want 2 and 1", api.seen, api.max)
+	}
+	expectLockFree(t, path)
+}
+
+func TestLock_Held(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "q0.lock")
+	holder := holdLockInChild(t, path)
+	c, api, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D1", "QUOTE0_LOCK": path})
+	if code := c.run([]string{"text", "-title", "x"}); code != exitLocked {
+		t.Fatalf("exit %d, want %d: %s", code, exitLocked, stderr)
+	}
+	if want := "is held by pid " + strconv.Itoa(holder.Process.Pid); !strings.Contains(stderr.String(), want) {
+		t.Fatalf("stderr %q, want %q", stderr, want)
+	}
+
+	stderr.Reset()
+	start := time.Now()
+	if code := c.run([]string{"text", "-title", "x", "-lock-timeout", "250ms", "-v"}); code != exitLocked {
+		t.Fatalf("exit %d, want %d: %s", code, exitLocked, stderr)
+	}
+	if time.Since(start) < 250*time.Millisecond {
+		t.Fatalf("gave up after %s", time.Since(start))
+	}
+	if out := stderr.String(); !strings.Contains(out, "Waiting up to 250ms") || !strings.Contains(out, "still held") {
+		t.Fatalf("stderr %q", out)
+	}
+	if api.count() != 0 {
+		t.Fatalf("sent %d requests while locked", api.count())
+	}
+	if data, err := os.ReadFile(path); err != nil || strings.TrimSpace(string(data)) != strconv.Itoa(holder.Process.Pid) {
+		t.Fatalf("held lock changed: %q, %v", data, err)
+	}
+}
+
+func TestLock_StaleTakeover(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "q0.lock")
+	if err := os.WriteFile(path, []byte(strconv.Itoa(deadPID(t))), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c, api, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D1"})
+	if code := c.run([]string{"text", "-title", "x", "-lock", path}); code != exitOK {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if api.count() != 1 {
+		t.Fatalf("requests %d", api.count())
+	}
+	expectLockFree(t, path)
+}
+
+// deadPID returns the PID of a process that has exited.
+func deadPID(t *testing.T) int {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	if err := cmd.Run(); err != nil {
+		t.Fatal(err)
+	}
+	return cmd.Process.Pid
+}
+
+func TestLock_ConcurrentTakeovers(t *testing.T) {
+	dead := strconv.Itoa(deadPID(t))
+	for round := 0; round < 50; round++ {
+		path := filepath.Join(t.TempDir(), "q0.lock")
+		if err := os.WriteFile(path, []byte(dead), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		var releases []func()
+		start := make(chan struct{})
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-start
+				release, err := acquireLock(context.Background(), path, 0, nil)
+				if err == nil {
+					mu.Lock()
+					releases = append(releases, release)
+					mu.Unlock()
+				}
+			}()
+		}
+		close(start)
+		wg.Wait()
+		if len(releases) != 1 {
+			t.Fatalf("round %d: %d runs took over the stale lock, want 1", round, len(releases))
+		}
+		releases[0]()
+	}
+}
+
+// TestLockHelperProcess is not a test: run as a child by holdLockInChild, it takes the lock
+// named by QUOTE0_LOCK_HELPER, reports it on stdout, and holds it until killed.
+func TestLockHelperProcess(t *testing.T) {
+	path := os.Getenv("QUOTE0_LOCK_HELPER")
+	if path == "" {
+		t.Skip("helper process")
+	}
+	if _, err := acquireLock(context.Background(), path, 0, nil); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	fmt.Println("locked")
+	select {}
+}
+
+// holdLockInChild starts another process holding the lock at path.
+func holdLockInChild(t *testing.T, path string) *exec.Cmd {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], "-test.run=^TestLockHelperProcess$")
+	cmd.Env = append(os.Environ(), "QUOTE0_LOCK_HELPER="+path)
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = cmd.Process.Kill(); _ = cmd.Wait() })
+	line, _ := bufio.NewReader(out).ReadString('\n')
+	if line != "locked\n" {
+		t.Fatalf("helper did not take the lock: %q", line)
+	}
+	return cmd
+}
+
+// expectLockFree fails unless the lock at path can be taken at once.
+func expectLockFree(t *testing.T, path string) {
+	t.Helper()
+	release, err := acquireLock(context.Background(), path, 0, nil)
+	if err != nil {
+		t.Fatalf("lock not released: %v", err)
+	}
+	release()
+}
+
+func TestLock_ThreeRuns(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "q0.lock")
+	holder := holdLockInChild(t, path)
+
+	// Two more runs find the live lock at once; neither may take it or remove it.
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			release, err := acquireLock(context.Background(), path, 0, nil)
+			if release != nil {
+				release()
+			}
+			errs[i] = err
+		}(i)
+	}
+	wg.Wait()
+	for i, err := range errs {
+		var le lockError
+		if !errors.As(err, &le) || le.pid != holder.Process.Pid {
+			t.Fatalf("run %d: %v, want the lock held by pid %d", i+2, err, holder.Process.Pid)
+		}
+	}
+
+	// The holder crashes; the two waiting runs then take the lock one after the other.
+	_ = holder.Process.Kill()
+	_ = holder.Wait()
+	var mu sync.Mutex
+	inside, most := 0, 0
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			release, err := acquireLock(context.Background(), path, 5*time.Second, nil)
+			errs[i] = err
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			inside++
+			if inside > most {
+				most = inside
+			}
+			mu.Unlock()
+			time.Sleep(50 * time.Millisecond)
+			mu.Lock()
+			inside--
+			mu.Unlock()
+			release()
+		}(i)
+	}
+	wg.Wait()
+	if errs[0] != nil || errs[1] != nil || most != 1 {
+		t.Fatalf("errors %v, at most %d holders; want none and 1", errs, most)
+	}
+	expectLockFree(t, path)
+}
+
+func TestLockPath(t *testing.T) {
+	if got := lockPath("/run/q0.lock", "D1"); got != "/run/q0.lock" {
+		t.Fatalf("explicit path %q", got)
+	}
+	if got, want := lockPath("auto", "AB:12/x"), filepath.Join(os.TempDir(), "quote0-AB_12_x.lock"); got != want {
+		t.Fatalf("auto %q, want %q", got, want)
+	}
+	if got, want := lockPath("auto", ""), filepath.Join(os.TempDir(), "quote0.lock"); got != want {
+		t.Fatalf("auto without device %q, want %q", got, want)
+	}
+}
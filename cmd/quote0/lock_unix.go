@@ -0,0 +1,38 @@
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// tryLock takes an exclusive flock on path without waiting. The file is never removed, so
+// every run locks the same file; the kernel drops the lock when its holder exits, however it
+// exits, which is how a crashed run's lock is taken over. It returns a release func when the
+// lock was taken, or the holder's PID (0 if unknown) when another run has it.
+func tryLock(path string) (release func(), holder int, err error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, 0, fmt.Errorf("lock: %w", err)
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		pid := readLockPID(f)
+		f.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, pid, nil
+		}
+		return nil, 0, fmt.Errorf("lock %s: %w", path, err)
+	}
+	if err := writeLockPID(f); err != nil {
+		f.Close()
+		return nil, 0, fmt.Errorf("lock %s: %w", path, err)
+	}
+	return func() {
+		// Clear the PID while still holding the lock; closing the file releases it.
+		_ = f.Truncate(0)
+		f.Close()
+	}, 0, nil
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// byteSize is a flag.Value for sizes such as 512K, 10M, or a plain byte count.
+type byteSize int64
+
+func (b *byteSize) String() string {
+	switch n := int64(*b); {
+	case n > 0 && n%(1<<20) == 0:
+		return strconv.FormatInt(n>>20, 10) + "M"
+	case n > 0 && n%(1<<10) == 0:
+		return strconv.FormatInt(n>>10, 10) + "K"
+	default:
+		return strconv.FormatInt(n, 10)
+	}
+}
+
+func (b *byteSize) Set(s string) error {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	shift := 0
+	switch {
+	case strings.HasSuffix(s, "K"):
+		shift = 10
+	case strings.HasSuffix(s, "M"):
+		shift = 20
+	case strings.HasSuffix(s, "G"):
+		shift = 30
+	}
+	if shift > 0 {
+		s = s[:len(s)-1]
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 {
+		return fmt.Errorf("want a size such as 512K or 10M")
+	}
+	*b = byteSize(n << shift)
+	return nil
+}
+
+// logRecord is one line of a -log-format json file.
+type logRecord struct {
+	Time    string `json:"time"`
+	Command string `json:"command"`
+	Stream  string `json:"stream,omitempty"`
+	Msg     string `json:"msg,omitempty"`
+	Exit    *int   `json:"exit,omitempty"`
+}
+
+// logSink appends everything a command prints to the -log file, a timestamped record per line,
+// with the token redacted. When the file would grow past maxSize it is moved to PATH.1 and a
+// new one is started, so at most twice maxSize stays on disk.
+type logSink struct {
+	mu      sync.Mutex
+	path    string
+	json    bool
+	maxSize int64
+	command string
+	token   string
+	now     func() time.Time
+	file    *os.File
+	size    int64
+	streams []*logStream
+	failed  bool
+	stderr  io.Writer
+}
+
+// openLog opens (creating its directory) the -log file for appending.
+func openLog(path, format string, maxSize int64) (*logSink, error) {
+	switch format {
+	case "text", "json":
+	default:
+		return nil, usagef("-log-format must be text or json, not %q", format)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("-log: %w", err)
+	}
+	l := &logSink{path: path, json: format == "json", maxSize: maxSize}
+	if err := l.open(os.O_APPEND); err != nil {
+		return nil, fmt.Errorf("-log: %w", err)
+	}
+	return l, nil
+}
+
+func (l *logSink) open(mode int) error {
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|mode, 0o600)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	l.file, l.size = f, info.Size()
+	return nil
+}
+
+// stream returns a writer that logs each line written to it under name (stdout or stderr).
+func (l *logSink) stream(name string) io.Writer {
+	s := &logStream{sink: l, name: name}
+	l.streams = append(l.streams, s)
+	return s
+}
+
+// write appends one record, rolling the file over first if it would outgrow maxSize. A write
+// error is reported once; the command itself carries on.
+func (l *logSink) write(rec logRecord) {
+	if l.token != "" {
+		rec.Msg = strings.ReplaceAll(rec.Msg, l.token, "[redacted]")
+	}
+	rec.Time, rec.Command = l.now().Format(time.RFC3339), l.command
+	var line []byte
+	if l.json {
+		line, _ = json.Marshal(rec)
+	} else if rec.Exit != nil {
+		line = []byte(fmt.Sprintf("%s %s exit %d", rec.Time, rec.Command, *rec.Exit))
+	} else {
+		line = []byte(fmt.Sprintf("%s %s %s: %s", rec.Time, rec.Command, rec.Stream, rec.Msg))
+	}
+	line = append(line, '\n')
+	err := l.rollover(int64(len(line)))
+	if err == nil {
+		_, err = l.file.Write(line)
+		l.size += int64(len(line))
+	}
+	if err != nil && !l.failed {
+		l.failed = true
+		fmt.Fprintf(l.stderr, "warning: -log: %v\n", err)
+	}
+}
+
+func (l *logSink) rollover(next int64) error {
+	if l.maxSize <= 0 || l.size == 0 || l.size+next <= l.maxSize {
+		return nil
+	}
+	l.file.Close()
+	if err := os.Rename(l.path, l.path+".1"); err != nil {
+		return err
+	}
+	return l.open(os.O_TRUNC)
+}
+
+// close logs any unterminated output and the exit code, then closes the file.
+func (l *logSink) close(code int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, s := range l.streams {
+		if len(s.buf) > 0 {
+			l.write(logRecord{Stream: s.name, Msg: string(s.buf)})
+			s.buf = nil
+		}
+	}
+	l.write(logRecord{Exit: &code})
+	l.file.Close()
+}
+
+// logStream splits one output stream into lines for its logSink.
+type logStream struct {
+	sink *logSink
+	name string
+	buf  []byte
+}
+
+func (s *logStream) Write(p []byte) (int, error) {
+	s.sink.mu.Lock()
+	defer s.sink.mu.Unlock()
+	s.buf = append(s.buf, p...)
+	for {
+		i := strings.IndexByte(string(s.buf), '\n')
+		if i < 0 {
+			break
+		}
+		s.sink.write(logRecord{Stream: s.name, Msg: strings.TrimRight(string(s.buf[:i]), "\r")})
+		s.buf = s.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// setupOutput applies -quiet and -log from fs, if it has them: stdout is dropped with -quiet,
+// and both streams are copied to the log. run restores the streams afterwards.
+func (c *cli) setupOutput(fs *flag.FlagSet) error {
+	if c.logSink != nil {
+		return nil
+	}
+	if f := fs.Lookup("quiet"); f != nil && f.Value.String() == "true" {
+		c.stdout = io.Discard
+	}
+	f := fs.Lookup("log")
+	if f == nil || f.Value.String() == "" {
+		return nil
+	}
+	l, err := openLog(f.Value.String(), fs.Lookup("log-format").Value.String(), int64(*fs.Lookup("log-max-size").Value.(*byteSize)))
+	if err != nil {
+		return err
+	}
+	l.command, l.now, l.stderr = fs.Name(), c.clock, c.stderr
+	if t := fs.Lookup("token"); t != nil {
+		l.token = strings.TrimSpace(t.Value.String())
+	}
+	c.logSink = l
+	c.stdout = io.MultiWriter(c.stdout, l.stream("stdout"))
+	c.stderr = io.MultiWriter(c.stderr, l.stream("stderr"))
+	return nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const logToken = "tok-0123456789abcdef0123456789"
+
+func TestLog_Text(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "q0.log")
+	c, _, stdout, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": logToken, "QUOTE0_DEVICE": "D1"})
+	for i := 0; i < 2; i++ {
+		if code := c.run([]string{"refresh", "-log", path, "-quiet", "-vv"}); code != 0 {
+			t.Fatalf("exit %d: %s", code, stderr)
+		}
+	}
+	if stdout.Len() != 0 {
+		t.Fatalf("-quiet printed %q", stdout)
+	}
+	if !strings.Contains(stderr.String(), "POST") {
+		t.Fatalf("stderr not passed through: %q", stderr)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := string(data)
+	if strings.Count(log, " refresh exit 0\n") != 2 || !strings.Contains(log, " refresh stdout: Refresh") || !strings.Contains(log, " refresh stderr: ") {
+		t.Fatalf("log:\n%s", log)
+	}
+	if strings.Contains(log, logToken) {
+		t.Fatal("token written to the log")
+	}
+}
+
+func TestLog_JSONRedactsToken(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "q0.log")
+	c, _, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": logToken})
+	// The error echoes -device; a token pasted there must still not reach the log.
+	if code := c.run([]string{"refresh", "-log", path, "-log-format", "json", "-device", "A," + logToken}); code != exitUsage {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	var last logRecord
+	for _, line := range lines {
+		if err := json.Unmarshal([]byte(line), &last); err != nil {
+			t.Fatalf("%v: %s", err, line)
+		}
+	}
+	if last.Exit == nil || *last.Exit != exitUsage || last.Command != "refresh" {
+		t.Fatalf("last record %+v", last)
+	}
+	if strings.Contains(string(data), logToken) {
+		t.Fatalf("token written to the log:\n%s", data)
+	}
+}
+
+func TestLog_Rollover(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "q0.log")
+	c, _, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D1"})
+	for i := 0; i < 5; i++ {
+		if code := c.run([]string{"refresh", "-log", path, "-log-max-size", "150"}); code != 0 {
+			t.Fatalf("exit %d: %s", code, stderr)
+		}
+	}
+	for _, p := range []string{path, path + ".1"} {
+		info, err := os.Stat(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Size() > 150 {
+			t.Errorf("%s is %d bytes", p, info.Size())
+		}
+	}
+}
+
+func TestByteSize(t *testing.T) {
+	for in, want := range map[string]int64{"0": 0, "512": 512, "64k": 64 << 10, "10M": 10 << 20, "1G": 1 << 30} {
+		var b byteSize
+		if err := b.Set(in); err != nil || int64(b) != want {
+			t.Errorf("%s: %d, %v", in, b, err)
+		}
+	}
+	for _, bad := range []string{"", "M", "-1", "1T"} {
+		var b byteSize
+		if err := b.Set(bad); err == nil {
+			t.Errorf("%q accepted", bad)
+		}
+	}
+	if b := byteSize(1 << 20); b.String() != "1M" {
+		t.Errorf("String() = %s", b.String())
+	}
+}
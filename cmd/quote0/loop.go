@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image/png"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/1set/quote0"
+)
+
+// runLoop cycles the PNG and JPEG files of -dir through the SDK slideshow, re-listing the
+// directory before every pass.
+func (c *cli) runLoop(args []string) error {
+	fs, cf := c.newFlagSet("loop")
+	imf := addImageFlags(fs)
+	dir := fs.String("dir", "", "Directory of PNG (or JPEG, with -fit) frames")
+	every := fs.Duration("every", 15*time.Minute, "How long each frame stays up")
+	shuffle := fs.Bool("shuffle", false, "Shuffle the frames on every pass")
+	once := fs.Bool("once", false, "Play a single pass, then exit")
+	if err := c.parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *cf.dryRun {
+		return usagef("-dry-run is not supported by loop")
+	}
+	if strings.TrimSpace(*dir) == "" {
+		return usagef("loop needs -dir DIR")
+	}
+	if *imf.image != "" || *imf.imageFile != "" {
+		return usagef("loop sends the files in -dir; -image and -image-file are not accepted")
+	}
+	if *every <= 0 {
+		return usagef("-every must be positive")
+	}
+	meta, err := imf.meta()
+	if err != nil {
+		return err
+	}
+	if info, err := os.Stat(*dir); err != nil {
+		return err
+	} else if !info.IsDir() {
+		return usagef("-dir %s is not a directory", *dir)
+	}
+	client, err := c.newClient(cf)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := c.commandContext(cf)
+	defer cancel()
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	opts := []quote0.SlideShowOption{
+		quote0.WithSlideInterval(*every),
+		quote0.WithSlideCallback(func(ev quote0.SlideEvent) {
+			stamp := ev.At.Format("15:04:05")
+			if ev.Err != nil {
+				fmt.Fprintf(c.stderr, "%s %s: %v\n", stamp, ev.Slide.Name, ev.Err)
+				return
+			}
+			fmt.Fprintf(c.stdout, "%s %s sent (code=%d message=%s)\n", stamp, ev.Slide.Name, ev.Response.Code, ev.Response.Message)
+		}),
+	}
+	if *shuffle {
+		opts = append(opts, quote0.WithSlideShuffle())
+	}
+	if *once {
+		opts = append(opts, quote0.WithSlidePasses(1))
+	}
+	err = client.SlideShow(ctx, func(context.Context) ([]quote0.Slide, error) {
+		return c.loadFrames(*dir, imf, meta)
+	}, opts...)
+	if errors.Is(err, quote0.ErrNoSlides) {
+		return fmt.Errorf("no valid PNG or JPEG frames in %s", *dir)
+	}
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
+
+// loadFrames reads every PNG and JPEG in dir, in name order, and returns those that can be
+// sent; the rest are skipped with a warning on stderr.
+func (c *cli) loadFrames(dir string, imf *imageFlags, meta quote0.ImageRequest) ([]quote0.Slide, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		switch strings.ToLower(filepath.Ext(e.Name())) {
+		case ".png", ".jpg", ".jpeg":
+			if !e.IsDir() {
+				names = append(names, e.Name())
+			}
+		}
+	}
+	sort.Strings(names)
+
+	var slides []quote0.Slide
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err == nil {
+			data, err = frameData(data, imf)
+		}
+		if err != nil {
+			fmt.Fprintf(c.stderr, "warning: skipping %s: %v\n", name, err)
+			continue
+		}
+		req := meta
+		req.ImageBytes = data
+		slides = append(slides, quote0.Slide{Name: name, Image: req})
+	}
+	return slides, nil
+}
+
+// frameData returns the PNG to send for one frame: -fit converts PNG or JPEG of any size,
+// otherwise the frame must already be a 296x152 PNG.
+func frameData(data []byte, imf *imageFlags) ([]byte, error) {
+	if strings.TrimSpace(*imf.fit) != "" || strings.TrimSpace(*imf.linkQR) != "" {
+		return imf.process(data)
+	}
+	cfg, err := png.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		if _, format, derr := quote0.DecodeImage(data); derr == nil && format == "jpeg" {
+			return nil, errors.New("JPEG frames need -fit")
+		}
+		return nil, fmt.Errorf("%w: %v", quote0.ErrInvalidImage, err)
+	}
+	if cfg.Width != quote0.ScreenWidth || cfg.Height != quote0.ScreenHeight {
+		return nil, fmt.Errorf("%w: got %dx%d (use -fit to resize)", quote0.ErrImageSize, cfg.Width, cfg.Height)
+	}
+	return data, nil
+}
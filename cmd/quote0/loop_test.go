@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/1set/quote0"
+)
+
+func TestLoop_Once(t *testing.T) {
+	dir := t.TempDir()
+	frame, _ := quote0.NewCanvas().PNG()
+	_ = os.WriteFile(filepath.Join(dir, "b.png"), frame, 0o644)
+	_ = os.WriteFile(filepath.Join(dir, "a.png"), frame, 0o644)
+	_ = os.WriteFile(filepath.Join(dir, "broken.png"), []byte("nope"), 0o644)
+	_ = os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("ignored"), 0o644)
+	var jpg bytes.Buffer
+	_ = jpeg.Encode(&jpg, image.NewGray(image.Rect(0, 0, 64, 48)), nil)
+	_ = os.WriteFile(filepath.Join(dir, "photo.jpg"), jpg.Bytes(), 0o644)
+
+	c, api, stdout, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+	if code := c.run([]string{"loop", "-dir", dir, "-once", "-every", "1ms", "-link", "https://x"}); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if api.count() != 2 || api.body(0)["link"] != "https://x" {
+		t.Fatalf("bodies %v", api.bodies)
+	}
+	out := stdout.String()
+	if strings.Index(out, "a.png sent") > strings.Index(out, "b.png sent") || !strings.Contains(out, "b.png sent") {
+		t.Fatalf("stdout %q", out)
+	}
+	for _, want := range []string{"skipping broken.png", "skipping photo.jpg: JPEG frames need -fit"} {
+		if !strings.Contains(stderr.String(), want) {
+			t.Errorf("stderr %q lacks %q", stderr, want)
+		}
+	}
+
+	// With -fit the JPEG is converted and sent too.
+	c, api, _, stderr = newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+	if code := c.run([]string{"loop", "-dir", dir, "-once", "-every", "1ms", "-fit", "contain"}); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if api.count() != 3 {
+		t.Fatalf("sent %d frames", api.count())
+	}
+}
+
+func TestLoop_Errors(t *testing.T) {
+	empty := t.TempDir()
+	for _, tc := range []struct {
+		args []string
+		code int
+	}{
+		{[]string{"loop"}, exitUsage},
+		{[]string{"loop", "-dir", empty, "-image-file", "a.png"}, exitUsage},
+		{[]string{"loop", "-dir", empty, "-every", "0s"}, exitUsage},
+		{[]string{"loop", "-dir", empty, "-once"}, exitError},
+	} {
+		c, api, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+		if code := c.run(tc.args); code != tc.code {
+			t.Errorf("%v: exit %d, stderr %q", tc.args, code, stderr)
+		}
+		if api.count() != 0 {
+			t.Errorf("%v: sent %v", tc.args, api.bodies)
+		}
+	}
+}
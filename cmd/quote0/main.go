@@ -3,150 +3,1229 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"encoding/base64"
 	"errors"
 	"flag"
 	"fmt"
+	"image/png"
+	"io"
+	"net/http"
+	"net/url"
 	"os"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/1set/quote0"
 )
 
 func main() {
-	if len(os.Args) < 2 {
-		printUsage()
-		os.Exit(1)
+	os.Exit(newCLI().run(os.Args[1:]))
+}
+
+// cli carries the process environment so commands can be driven from tests.
+type cli struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+	getenv func(string) string
+	// environ lists the environment for `template -env`; nil means an empty environment.
+	environ func() []string
+	// ctx is the parent of every command's context; nil means context.Background.
+	ctx context.Context
+	// clientOptions are appended to every client the CLI builds (tests point them at a fake server).
+	clientOptions []quote0.ClientOption
+	// timeout is the -timeout applied by commandContext, kept to explain a deadline error.
+	timeout time.Duration
+	// now and hostname feed auto signatures; nil means time.Now and os.Hostname.
+	now      func() time.Time
+	hostname func() (string, error)
+	// tty reports whether stdin is a terminal, for `text -i`; nil checks stdin itself.
+	tty func() bool
+	// unlock releases the -lock taken by the running command, if any.
+	unlock func()
+	// after is the timer behind -at and -in waits; nil means time.After.
+	after func(time.Duration) <-chan time.Time
+	// logSink is the -log file of the running command, if any.
+	logSink *logSink
+	// broadcast holds the `broadcast` options while it runs text or image; nil otherwise.
+	broadcast *broadcastFlags
+	// lookupHost resolves names for `doctor`; nil means net.DefaultResolver.
+	lookupHost func(ctx context.Context, host string) ([]string, error)
+	// rateWarning prints the -rate 0 warning once, however many clients are built.
+	rateWarning sync.Once
+}
+
+func newCLI() *cli {
+	return &cli{stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr, getenv: os.Getenv, environ: os.Environ}
+}
+
+// Exit codes, documented in the usage text.
+const (
+	exitOK         = 0
+	exitError      = 1
+	exitUsage      = 2
+	exitValidation = 3
+	exitAuth       = 4
+	exitRateLimit  = 5
+	exitDevice     = 6
+	exitNetwork    = 7
+	exitLocked     = 8
+	exitFetch      = 9
+)
+
+// usageError marks bad invocations (unknown commands, flag errors, conflicting or missing flags).
+type usageError struct{ error }
+
+func (e usageError) Unwrap() error { return e.error }
+
+func usagef(format string, args ...interface{}) error {
+	return usageError{fmt.Errorf(format, args...)}
+}
+
+// parseFlags parses args into fs, marking failures as usage errors, then fills flags that
+// were not given from their QUOTE0_ variables. The API base URL is checked here, so a bad
+// -base-url or QUOTE0_BASE_URL fails before any input is read.
+func (c *cli) parseFlags(fs *flag.FlagSet, args []string) error {
+	annotateEnv(fs)
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return usageError{err}
+	}
+	if err := c.applyEnv(fs); err != nil {
+		return err
+	}
+	if f := fs.Lookup("base-url"); f != nil {
+		if err := checkBaseURL(f.Value.String()); err != nil {
+			source := "QUOTE0_BASE_URL"
+			fs.Visit(func(set *flag.Flag) {
+				if set == f {
+					source = "-base-url"
+				}
+			})
+			return usagef("%s: %v", source, err)
+		}
+	}
+	return c.setupOutput(fs)
+}
+
+// checkBaseURL accepts an empty value (the default host) or an absolute http(s) URL.
+func checkBaseURL(raw string) error {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%q is not an http(s) URL", raw)
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return fmt.Errorf("%q must not have a query or fragment", raw)
+	}
+	return nil
+}
+
+// exitCode maps err to the process exit code using the SDK's error classification.
+func exitCode(err error) int {
+	var ue usageError
+	var le lockError
+	var df doctorFailed
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return exitOK
+	case errors.As(err, &ue):
+		return exitUsage
+	case errors.As(err, &le):
+		return exitLocked
+	case errors.As(err, &df):
+		return df.exitCode()
+	case errors.Is(err, quote0.ErrImageFetch), errors.Is(err, quote0.ErrIconFetch):
+		return exitFetch
+	case quote0.IsValidationError(err), isValidationFailure(err):
+		return exitValidation
+	case quote0.IsAuthError(err):
+		return exitAuth
+	case quote0.IsRateLimitError(err):
+		return exitRateLimit
+	case quote0.IsDeviceError(err):
+		return exitDevice
+	case quote0.IsNetworkError(err):
+		return exitNetwork
+	default:
+		return exitError
+	}
+}
+
+func (c *cli) clock() time.Time {
+	if c.now == nil {
+		return time.Now()
+	}
+	return c.now()
+}
+
+// signatureTokens are the {host} and {user} values of -signature-format; unknown values are
+// left empty.
+func (c *cli) signatureTokens() quote0.SignatureTokens {
+	hostname := c.hostname
+	if hostname == nil {
+		hostname = os.Hostname
+	}
+	host, _ := hostname()
+	user := c.getenv("USER")
+	if user == "" {
+		user = c.getenv("USERNAME")
+	}
+	return quote0.SignatureTokens{Host: host, User: user}
+}
+
+func (c *cli) context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
 	}
+	return c.ctx
+}
+
+// commandContext is the context for one whole command: -timeout, when set, is a total budget
+// covering rate-limiter waits, every host tried, and batch delays, not a per-request limit.
+func (c *cli) commandContext(cf *commonFlags) (context.Context, context.CancelFunc) {
+	if *cf.timeout <= 0 {
+		return context.WithCancel(c.context())
+	}
+	c.timeout = *cf.timeout
+	return context.WithTimeout(c.context(), *cf.timeout)
+}
+
+// timeoutError reports an expired -timeout; it unwraps to the underlying error so the
+// network-failure exit code applies.
+type timeoutError struct {
+	after time.Duration
+	err   error
+}
+
+func (e timeoutError) Error() string { return fmt.Sprintf("timed out after %s", e.after) }
+func (e timeoutError) Unwrap() error { return e.err }
+
+// run executes one command line and returns the process exit code.
+func (c *cli) run(args []string) int {
+	if len(args) < 1 {
+		c.printUsage()
+		return exitUsage
+	}
+	stdout, stderr := c.stdout, c.stderr
+	defer func() { c.stdout, c.stderr = stdout, stderr }()
 	var err error
-	switch os.Args[1] {
+	switch args[0] {
 	case "text":
-		err = runText(os.Args[2:])
+		err = c.runText(args[1:])
 	case "image":
-		err = runImage(os.Args[2:])
+		err = c.runImage(args[1:])
+	case "refresh":
+		err = c.runRefresh(args[1:])
+	case "preview":
+		err = c.runPreview(args[1:])
+	case "batch":
+		err = c.runBatch(args[1:])
+	case "watch":
+		err = c.runWatch(args[1:])
+	case "loop":
+		err = c.runLoop(args[1:])
+	case "template":
+		err = c.runTemplate(args[1:])
+	case "status":
+		err = c.runStatus(args[1:])
+	case "validate":
+		err = c.runValidate(args[1:])
+	case "tail":
+		err = c.runTail(args[1:])
+	case "monitor":
+		err = c.runMonitor(args[1:])
+	case "convert":
+		err = c.runConvert(args[1:])
+	case "chart":
+		err = c.runChart(args[1:])
+	case "dither-sheet":
+		err = c.runDitherSheet(args[1:])
+	case "demo":
+		err = c.runDemo(args[1:])
+	case "doctor":
+		err = c.runDoctor(args[1:])
+	case "send":
+		err = c.runSend(args[1:])
+	case "replay":
+		err = c.runReplay(args[1:])
+	case "icons":
+		err = c.runIcons(args[1:])
+	case "broadcast":
+		err = c.runBroadcast(args[1:])
 	case "-h", "--help", "help":
-		printUsage()
-		return
+		c.printUsage()
+		return exitOK
 	default:
-		printUsage()
-		err = fmt.Errorf("unknown command %q", os.Args[1])
+		c.printUsage()
+		err = usagef("unknown command %q", args[0])
+	}
+	if c.unlock != nil {
+		c.unlock()
+		c.unlock = nil
+	}
+	if c.timeout > 0 && errors.Is(err, context.DeadlineExceeded) {
+		err = timeoutError{after: c.timeout, err: err}
 	}
+	if err != nil && !errors.Is(err, flag.ErrHelp) {
+		fmt.Fprintf(c.stderr, "q0: %v\n", err)
+	}
+	code := exitCode(err)
+	if c.logSink != nil {
+		c.logSink.close(code)
+		c.logSink = nil
+	}
+	return code
+}
+
+// commonFlags are registered on every command that talks to the API.
+type commonFlags struct {
+	command string
+	token   *string
+	baseURL *string
+	device  *deviceList
+	debug   *bool
+	dryRun  *bool
+	timeout *time.Duration
+	rate    *time.Duration
+	verbose *verbosity
+	// lock is the -lock path ("auto" for one per device), held while the command runs.
+	lock        *string
+	lockTimeout *time.Duration
+	// ifChanged skips devices whose request hash matches the one saved under stateDir.
+	ifChanged *bool
+	stateDir  *string
+}
+
+// verbosity counts -v flags: -v logs one line per HTTP request and response, -vv (or -v -v)
+// adds headers and bodies. -verbose is an alias of -v.
+type verbosity int
+
+func (v *verbosity) String() string { return strconv.Itoa(int(*v)) }
+
+func (v *verbosity) Set(s string) error {
+	on, err := strconv.ParseBool(s)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "q0: %v\n", err)
-		os.Exit(1)
+		return err
 	}
+	if !on {
+		*v = 0
+	} else if *v < verbosity(quote0.DebugFull) {
+		*v++
+	}
+	return nil
 }
 
-func runText(args []string) error {
-	fs := flag.NewFlagSet("text", flag.ContinueOnError)
-	token := fs.String("token", os.Getenv("QUOTE0_TOKEN"), "API token; or set QUOTE0_TOKEN")
-	device := fs.String("device", os.Getenv("QUOTE0_DEVICE"), "Device serial; or set QUOTE0_DEVICE")
-	title := fs.String("title", "", "Title (optional)")
-	message := fs.String("message", "", "Message (optional)")
-	signature := fs.String("signature", "", "Signature (optional; defaults to hostname@MM-DD HH:MM:SS if empty)")
-	useDefaultSig := fs.Bool("auto-signature", false, "Use auto-generated signature if -signature is empty")
-	icon := fs.String("icon", "", "Base64 40x40 PNG icon (optional)")
-	iconFile := fs.String("icon-file", "", "Path to 40x40 PNG icon (optional)")
-	link := fs.String("link", "", "Optional URL")
-	refresh := fs.Bool("refresh", true, "Set refreshNow=true")
-	debug := fs.Bool("debug", false, "Enable debug mode (logs request/response to stderr)")
-	if err := fs.Parse(args); err != nil {
+func (v *verbosity) IsBoolFlag() bool { return true }
+
+// twice is the -vv flag.
+type twice struct{ v *verbosity }
+
+func (t twice) String() string { return "" }
+
+func (t twice) Set(s string) error {
+	if err := t.v.Set(s); err != nil {
 		return err
 	}
-	if strings.TrimSpace(*token) == "" {
-		return errors.New("missing API token (use -token or QUOTE0_TOKEN)")
+	return t.v.Set(s)
+}
+
+func (twice) IsBoolFlag() bool { return true }
+
+// switchFlag is a boolean flag that also accepts yes/no and on/off.
+type switchFlag bool
+
+func (s *switchFlag) String() string {
+	if s == nil {
+		return "false"
 	}
-	if strings.TrimSpace(*device) == "" {
-		return errors.New("missing device serial (use -device or QUOTE0_DEVICE)")
+	return strconv.FormatBool(bool(*s))
+}
+
+func (s *switchFlag) Set(v string) error {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "yes", "on":
+		*s = true
+	case "no", "off":
+		*s = false
+	default:
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("want true/false, yes/no, or on/off")
+		}
+		*s = switchFlag(b)
 	}
+	return nil
+}
+
+func (s *switchFlag) IsBoolFlag() bool { return true }
 
-	iconData, err := loadBase64(*icon, *iconFile, "icon")
+// addRefreshFlag registers -refresh, which sets refreshNow and defaults to true.
+func addRefreshFlag(fs *flag.FlagSet) *bool {
+	on := true
+	fs.Var((*switchFlag)(&on), "refresh", "Set refreshNow: true|false, yes|no, or on|off")
+	return &on
+}
+
+// borderFlag is -border: white or black (case-insensitive), or 0 or 1.
+type borderFlag quote0.BorderColor
+
+func (b *borderFlag) String() string {
+	if b == nil {
+		return quote0.BorderWhite.String()
+	}
+	return quote0.BorderColor(*b).String()
+}
+
+func (b *borderFlag) Set(v string) error {
+	color, err := quote0.ParseBorderColor(v)
 	if err != nil {
-		return err
+		return fmt.Errorf("want white, black, 0, or 1")
+	}
+	*b = borderFlag(color)
+	return nil
+}
+
+func (c *cli) newFlagSet(name string) (*flag.FlagSet, *commonFlags) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	device := newDeviceList(c.getenv("QUOTE0_DEVICE"))
+	fs.Var(device, "device", "Device serial; repeat or comma-separate to send to several (text, image); or set QUOTE0_DEVICE")
+	var v verbosity
+	fs.Var(&v, "v", "Log HTTP requests and responses to stderr; repeat (or -vv) to include headers and bodies")
+	fs.Var(&v, "verbose", "Same as -v")
+	fs.Var(twice{&v}, "vv", "Same as -v -v")
+	// -log and -quiet take effect in parseFlags, before the command reads anything else.
+	fs.String("log", "", "Append the command's output and diagnostics, timestamped, to this file")
+	fs.String("log-format", "text", "Format of -log records: text or json")
+	logMax := byteSize(1 << 20)
+	fs.Var(&logMax, "log-max-size", "Move -log to PATH.1 and start over when it would pass this size; 0 never")
+	fs.Bool("quiet", false, "Print nothing on stdout (-log still records it)")
+	return fs, &commonFlags{
+		token:       fs.String("token", c.getenv("QUOTE0_TOKEN"), "API token; or set QUOTE0_TOKEN"),
+		baseURL:     fs.String("base-url", c.getenv("QUOTE0_BASE_URL"), "API base URL, e.g. a staging relay; or set QUOTE0_BASE_URL"),
+		command:     name,
+		device:      device,
+		debug:       fs.Bool("debug", false, "Enable debug mode (logs request/response to stderr)"),
+		dryRun:      fs.Bool("dry-run", false, "Validate and print the payload instead of sending it (no token needed)"),
+		timeout:     fs.Duration("timeout", 0, "Give up on the whole command after this long (default no limit)"),
+		rate:        fs.Duration("rate", time.Second, "Minimum interval between API requests; 0 disables the limiter (the official API enforces 1 QPS)"),
+		verbose:     &v,
+		lock:        fs.String("lock", c.getenv("QUOTE0_LOCK"), "Lock file that serializes runs, or auto for one per device under the temp directory; or set QUOTE0_LOCK"),
+		lockTimeout: fs.Duration("lock-timeout", 0, "How long to wait for a held -lock (default 0: exit 8 at once)"),
+		ifChanged:   fs.Bool("if-changed", false, "Skip devices whose content is unchanged since their last successful send"),
+		stateDir:    fs.String("state-dir", "", "Where -if-changed keeps request hashes (default quote0 under the user cache directory)"),
+	}
+}
+
+// newClient validates the token and device flags and builds a client for them.
+func (c *cli) newClient(cf *commonFlags) (*quote0.Client, error) {
+	if len(cf.device.ids) == 0 {
+		return nil, usagef("missing device serial (use -device or QUOTE0_DEVICE)")
+	}
+	return c.newClientAnyDevice(cf)
+}
+
+// newClientDevices is newClient for commands that fan out to every -device; the first one is
+// the client default.
+func (c *cli) newClientDevices(cf *commonFlags) (*quote0.Client, []string, error) {
+	if len(cf.device.ids) <= 1 {
+		client, err := c.newClient(cf)
+		return client, cf.device.ids, err
 	}
+	client, err := c.buildClient(cf, cf.device.ids[0])
+	return client, cf.device.ids, err
+}
 
-	client, err := quote0.NewClient(*token, quote0.WithDefaultDeviceID(*device), quote0.WithDebug(*debug))
+// newClientAnyDevice is newClient for commands whose requests may name their own devices;
+// the default device is optional.
+func (c *cli) newClientAnyDevice(cf *commonFlags) (*quote0.Client, error) {
+	device, err := cf.device.single(cf.command)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	return c.buildClient(cf, device)
+}
 
-	// Generate default signature if requested and signature is empty
-	sig := strings.TrimSpace(*signature)
-	if sig == "" && *useDefaultSig {
-		sig = time.Now().Format("2006-01-02 15:04:05")
+func (c *cli) buildClient(cf *commonFlags, device string) (*quote0.Client, error) {
+	token := *cf.token
+	if strings.TrimSpace(token) == "" {
+		if !*cf.dryRun {
+			return nil, usagef("missing API token (use -token or QUOTE0_TOKEN)")
+		}
+		token = "dry-run" // never sent
+	}
+	level := quote0.DebugLevel(*cf.verbose)
+	if *cf.debug {
+		level = quote0.DebugFull
+	}
+	if *cf.rate < 0 {
+		return nil, usagef("-rate must not be negative")
+	}
+	limiter := quote0.RateLimiter(nil)
+	if *cf.rate > 0 {
+		limiter = quote0.NewFixedIntervalLimiter(*cf.rate)
+	} else if !*cf.dryRun {
+		c.rateWarning.Do(func() {
+			fmt.Fprintln(c.stderr, "WARNING: -rate 0 disables client-side rate limiting; the official API enforces 1 request per second and rejects faster clients with 429")
+		})
+	}
+	opts := []quote0.ClientOption{
+		quote0.WithDefaultDeviceID(device),
+		quote0.WithDebugWriter(c.stderr, level),
+		quote0.WithRateLimiter(limiter),
+	}
+	if base := strings.TrimSpace(*cf.baseURL); base != "" {
+		opts = append(opts, quote0.WithBaseURL(base))
 	}
+	if err := c.lock(cf, device); err != nil {
+		return nil, err
+	}
+	if level > quote0.DebugOff {
+		fmt.Fprintf(c.stderr, "API host: %s\n", apiHost(*cf.baseURL))
+	}
+	return quote0.NewClient(token, append(opts, c.clientOptions...)...)
+}
 
-	req := quote0.TextRequest{
-		RefreshNow: quote0.Bool(*refresh),
-		Title:      *title,
-		Message:    *message,
+// apiHost names the host a base URL (empty for the default) sends to.
+func apiHost(base string) string {
+	if strings.TrimSpace(base) == "" {
+		base = quote0.DefaultBaseURL
+	}
+	if u, err := url.Parse(strings.TrimSpace(base)); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return base
+}
+
+// textFlags are the content flags of `text`, shared with `preview text`.
+type textFlags struct {
+	title, message, signature             *string
+	titleFile, messageFile, signatureFile *string
+	autoSignature                         *bool
+	signatureFormat, signatureTZ          *string
+	icon, iconFile, iconURL, link         *string
+	iconText                              *string
+	iconInvert                            *bool
+	refresh                               *bool
+	kv                                    *kvList
+	kvSeparator, kvAlign                  *string
+	dropOverflow                          *bool
+}
+
+func addTextFlags(fs *flag.FlagSet) *textFlags {
+	kv := new(kvList)
+	fs.Var(kv, "kv", "KEY=VALUE line of the message, aligned with the others; repeatable (up to 3)")
+	return &textFlags{
+		title:           fs.String("title", "", "Title (optional)"),
+		message:         fs.String("message", "", "Message (optional)"),
+		signature:       fs.String("signature", "", "Signature (optional; defaults to hostname@MM-DD HH:MM:SS if empty)"),
+		titleFile:       fs.String("title-file", "", "Read the title from a UTF-8 file (- for stdin)"),
+		messageFile:     fs.String("message-file", "", "Read the message from a UTF-8 file (- for stdin)"),
+		signatureFile:   fs.String("signature-file", "", "Read the signature from a UTF-8 file (- for stdin)"),
+		autoSignature:   fs.Bool("auto-signature", false, "Use auto-generated signature if -signature is empty"),
+		signatureFormat: fs.String("signature-format", "", "Go time layout with {host} and {user} tokens for the auto signature (implies -auto-signature)"),
+		signatureTZ:     fs.String("signature-tz", "", "Time zone of the auto signature, e.g. UTC or Europe/Berlin (default local)"),
+		icon:            fs.String("icon", "", "Base64 40x40 PNG icon (optional)"),
+		iconFile:        fs.String("icon-file", "", "Path to 40x40 PNG icon, or - for stdin (optional)"),
+		iconURL:         fs.String("icon-url", "", "Download the 40x40 PNG icon from this URL (optional)"),
+		iconText:        fs.String("icon-text", "", "Draw one or two characters, e.g. DB, as the icon (optional)"),
+		iconInvert:      fs.Bool("icon-invert", false, "Draw -icon-text white on black"),
+		link:            fs.String("link", "", "Optional URL"),
+		refresh:         addRefreshFlag(fs),
+		kv:              kv,
+		kvSeparator:     fs.String("kv-separator", ": ", "Text between key and value of -kv lines"),
+		kvAlign:         fs.String("kv-align", string(quote0.AlignColumns), "Alignment of -kv lines: columns|right|none"),
+		dropOverflow:    fs.Bool("drop-overflow", false, "Drop -kv pairs beyond the 3 message lines instead of failing"),
+	}
+}
+
+// request builds the text payload, reading any -*-file flag named "-" from stdin.
+// DeviceID is left to the client default.
+func (f *textFlags) request(c *cli, cf *commonFlags) (quote0.TextRequest, error) {
+	stdin := c.stdin
+	// The flags are copied, not filled in, so -every can read the files again.
+	title, message, signature := *f.title, *f.message, *f.signature
+	fileFlags := []struct {
+		label         string
+		literal, path *string
+	}{
+		{"title", &title, f.titleFile},
+		{"message", &message, f.messageFile},
+		{"signature", &signature, f.signatureFile},
+	}
+	if len(f.kv.pairs) > 0 {
+		msg, err := f.kvMessage(c, cf)
+		if err != nil {
+			return quote0.TextRequest{}, err
+		}
+		message = msg
+	}
+	fromStdin := ""
+	for _, ff := range fileFlags {
+		if *ff.path == "" {
+			continue
+		}
+		if *ff.literal != "" {
+			return quote0.TextRequest{}, usagef("provide either -%s or -%s-file, not both", ff.label, ff.label)
+		}
+		if *ff.path == "-" {
+			if fromStdin != "" {
+				return quote0.TextRequest{}, usagef("only one flag can read stdin: -%s-file and -%s-file both use -", fromStdin, ff.label)
+			}
+			fromStdin = ff.label
+		}
+		text, err := readTextFile(*ff.path, stdin)
+		if err != nil {
+			return quote0.TextRequest{}, usagef("-%s-file: %v", ff.label, err)
+		}
+		*ff.literal = text
+	}
+
+	iconSources := 0
+	for _, v := range []string{*f.iconURL, *f.iconText} {
+		if v != "" {
+			iconSources++
+		}
+	}
+	if *f.icon != "" || *f.iconFile != "" {
+		iconSources++
+	}
+	if iconSources > 1 {
+		return quote0.TextRequest{}, usagef("provide only one of -icon, -icon-file, -icon-url, or -icon-text")
+	}
+	if *f.iconInvert && *f.iconText == "" {
+		return quote0.TextRequest{}, usagef("-icon-invert applies to -icon-text")
+	}
+	var iconData string
+	var iconBytes []byte
+	if *f.iconText != "" {
+		img, err := quote0.RenderMonogram(*f.iconText, *f.iconInvert)
+		if err != nil {
+			return quote0.TextRequest{}, fmt.Errorf("-icon-text: %w", err)
+		}
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			return quote0.TextRequest{}, err
+		}
+		iconBytes = buf.Bytes()
+	} else if *f.iconFile == "-" {
+		if *f.icon != "" {
+			return quote0.TextRequest{}, usagef("provide either -icon or -icon-file, not both")
+		}
+		if fromStdin != "" {
+			return quote0.TextRequest{}, usagef("only one flag can read stdin: -%s-file and -icon-file both use -", fromStdin)
+		}
+		data, err := readStdinBytes(stdin, maxIconStdin)
+		if err != nil {
+			return quote0.TextRequest{}, usagef("-icon-file: %v", err)
+		}
+		iconBytes = data
+	} else {
+		var err error
+		if iconData, err = loadBase64(*f.icon, *f.iconFile, "icon"); err != nil {
+			return quote0.TextRequest{}, err
+		}
+	}
+	// Generate default signature if requested and signature is empty
+	sig := strings.TrimSpace(signature)
+	if sig == "" && (*f.autoSignature || *f.signatureFormat != "") {
+		var err error
+		if sig, err = f.autoSignatureText(c, cf); err != nil {
+			return quote0.TextRequest{}, err
+		}
+	}
+	return quote0.TextRequest{
+		RefreshNow: quote0.Bool(*f.refresh),
+		Title:      title,
+		Message:    message,
 		Signature:  sig,
 		Icon:       iconData,
-		Link:       *link,
+		IconBytes:  iconBytes,
+		Link:       *f.link,
+	}, nil
+}
+
+// autoSignatureText renders -signature-format (default quote0.DefaultSignatureFormat) at the
+// current time in -signature-tz. A signature cut to fit the corner is reported with -v.
+func (f *textFlags) autoSignatureText(c *cli, cf *commonFlags) (string, error) {
+	loc, err := signatureLocation(*f.signatureTZ)
+	if err != nil {
+		return "", err
+	}
+	sig, truncated := quote0.FormatSignature(*f.signatureFormat, c.clock().In(loc), c.signatureTokens())
+	if truncated && (*cf.verbose > 0 || *cf.debug) {
+		fmt.Fprintf(c.stderr, "warning: auto signature truncated to %d characters: %q\n", quote0.MaxSignatureRunes, sig)
+	}
+	return sig, nil
+}
+
+// signatureLocation resolves -signature-tz; empty means local time.
+func signatureLocation(tz string) (*time.Location, error) {
+	if tz == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, usagef("-signature-tz: %v", err)
+	}
+	return loc, nil
+}
+
+// fetchIcon downloads -icon-url into req.IconBytes. The API is not involved, so failures are
+// reported as "could not fetch icon" rather than as send errors.
+func (f *textFlags) fetchIcon(ctx context.Context, hc *http.Client, req *quote0.TextRequest) error {
+	if *f.iconURL == "" {
+		return nil
 	}
-	resp, err := client.SendText(context.Background(), req)
+	data, err := quote0.FetchIcon(ctx, hc, *f.iconURL)
 	if err != nil {
 		return err
 	}
-	fmt.Printf("Text sent (code=%d message=%s)\n", resp.Code, resp.Message)
+	req.IconBytes = data
 	return nil
 }
 
-func runImage(args []string) error {
-	fs := flag.NewFlagSet("image", flag.ContinueOnError)
-	token := fs.String("token", os.Getenv("QUOTE0_TOKEN"), "API token; or set QUOTE0_TOKEN")
-	device := fs.String("device", os.Getenv("QUOTE0_DEVICE"), "Device serial; or set QUOTE0_DEVICE")
-	image := fs.String("image", "", "Base64 296x152 PNG")
-	imageFile := fs.String("image-file", "", "Path to 296x152 PNG (base64 encoded internally)")
-	link := fs.String("link", "", "Optional URL")
-	border := fs.Int("border", 0, "Screen edge color: 0=white (default), 1=black")
-	ditherType := fs.String("dither-type", "", "Dither type (NONE|DIFFUSION|ORDERED)")
-	ditherKernel := fs.String("dither-kernel", "", "Dither kernel (FLOYD_STEINBERG, ATKINSON, ...)")
-	refresh := fs.Bool("refresh", true, "Set refreshNow=true")
-	debug := fs.Bool("debug", false, "Enable debug mode (logs request/response to stderr)")
-	if err := fs.Parse(args); err != nil {
+func (c *cli) runText(args []string) error {
+	fs, cf := c.newFlagSet("text")
+	tf := addTextFlags(fs)
+	sf := addSendFlags(fs)
+	sched := addScheduleFlags(fs)
+	rf := addRepeatFlags(fs)
+	interactive := fs.Bool("i", false, "Prompt for the title, message, and signature on the terminal, then confirm")
+	fromJSON := fs.String("from-json", "", "Send a text request saved as JSON (e.g. by -dry-run -json) instead of content flags")
+	output := addOutputFlag(fs)
+	if err := c.parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := checkOutput(*output, cf); err != nil {
+		return err
+	}
+	if err := rf.check(sf, tf.titleFile, tf.messageFile, tf.signatureFile, tf.iconFile); err != nil {
 		return err
 	}
+	if *rf.every > 0 && *interactive {
+		return usagef("-i does not combine with -every")
+	}
+	target, err := sched.target(c.clock())
+	if err != nil {
+		return err
+	}
+	if *fromJSON != "" {
+		return c.sendTextFromJSON(*fromJSON, fs, cf, sf, target, *output)
+	}
+	var p *prompter
+	if *interactive {
+		if !c.stdinIsTerminal() {
+			return usagef("-i needs a terminal on stdin; use -title, -message, and -signature (or -message-file -) instead")
+		}
+		for _, f := range []*string{tf.title, tf.message, tf.signature, tf.titleFile, tf.messageFile, tf.signatureFile} {
+			if *f != "" {
+				return usagef("-i prompts for the title, message, and signature; do not also pass them as flags")
+			}
+		}
+		if len(tf.kv.pairs) > 0 {
+			return usagef("-i prompts for the message; do not also pass -kv")
+		}
+		if *tf.iconFile == "-" {
+			return usagef("-i reads the terminal; -icon-file - cannot also use stdin")
+		}
+		p = &prompter{in: bufio.NewReader(c.stdin), out: c.stderr}
+		if err := c.promptText(p, tf); err != nil {
+			return err
+		}
+	}
+	req, err := tf.request(c, cf)
+	if err != nil {
+		return err
+	}
+	client, devices, err := c.newClientDevices(cf)
+	if err != nil {
+		return err
+	}
+	// Confirm before -timeout starts counting; the preview shows no -icon-url icon yet.
+	if p != nil && !*cf.dryRun {
+		ok, err := c.confirmText(p, req, devices)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Fprintln(c.stderr, "Not sent.")
+			return nil
+		}
+	}
+	if !*cf.dryRun {
+		out := textOutgoing("Text", client, req)
+		out.capture = *output
+		if err := c.waitToSend(cf, target, devices, out.build); err != nil {
+			return err
+		}
+	}
+	ctx, cancel := c.commandContext(cf)
+	defer cancel()
+	if *rf.every > 0 && !*cf.dryRun {
+		return c.repeat(ctx, cf, rf, devices, func(ctx context.Context) (outgoing, error) {
+			req, err := tf.request(c, cf)
+			if err == nil {
+				err = tf.fetchIcon(ctx, client.HTTPClient(), &req)
+			}
+			out := textOutgoing("Text", client, req)
+			out.capture = *output
+			return out, err
+		})
+	}
+	if err := tf.fetchIcon(ctx, client.HTTPClient(), &req); err != nil {
+		return err
+	}
+	if *cf.dryRun {
+		return c.dryRunDevices(devices, sf, func(id string) (*quote0.PreparedRequest, error) {
+			req.DeviceID = id
+			return client.BuildText(req)
+		})
+	}
+	out := textOutgoing("Text", client, req)
+	out.capture = *output
+	return c.deliver(ctx, cf, devices, sf, out)
+}
+
+// imageFlags are the content flags of `image`, shared with `preview image`.
+type imageFlags struct {
+	image, imageFile, link   *string
+	border                   *borderFlag
+	ditherType, ditherKernel *string
+	fit, bg, resample        *string
+	refresh                  *bool
+	grayscale, invert        *bool
+	rotate, threshold        *int
+	contrast, gamma, sharpen *float64
+	linkQR                   *string
+	linkQRSize               *int
+	safeMargin               *int
+}
 
-	if strings.TrimSpace(*token) == "" {
-		return errors.New("missing API token (use -token or QUOTE0_TOKEN)")
+func addImageFlags(fs *flag.FlagSet) *imageFlags {
+	return &imageFlags{
+		image:        fs.String("image", "", "Base64 296x152 PNG"),
+		imageFile:    fs.String("image-file", "", "Path to 296x152 PNG, or - for stdin (base64 encoded internally)"),
+		link:         fs.String("link", "", "Optional URL"),
+		border:       addBorderFlag(fs),
+		ditherType:   fs.String("dither-type", "", "Dither type (NONE|DIFFUSION|ORDERED)"),
+		ditherKernel: fs.String("dither-kernel", "", "Dither kernel (FLOYD_STEINBERG, ATKINSON, ...)"),
+		fit:          fs.String("fit", "", "Resize any PNG/JPEG to 296x152: contain|cover|stretch (default off)"),
+		resample:     fs.String("resample", "", "Scaling filter for -fit and -safe-margin: nearest|box|bilinear (default box)"),
+		bg:           fs.String("bg", "", "Padding color for -fit contain and -safe-margin: white|black (default white, or the -border color with -safe-margin)"),
+		refresh:      addRefreshFlag(fs),
+		grayscale:    fs.Bool("grayscale", false, "Convert to grayscale locally before upload (implied by -fit and the adjustments below)"),
+		invert:       fs.Bool("invert", false, "Swap black and white"),
+		rotate:       fs.Int("rotate", 0, "Rotate clockwise by 90, 180, or 270 degrees before fitting"),
+		contrast:     fs.Float64("contrast", 1, "Contrast factor: above 1 stronger, below 1 flatter"),
+		gamma:        fs.Float64("gamma", 1, "Gamma: above 1 brightens mid-tones, below 1 darkens them"),
+		sharpen:      fs.Float64("sharpen", 0, "Unsharp-mask amount, e.g. 0.5 to keep thin lines crisp (0 off)"),
+		threshold:    fs.Int("threshold", 0, "Make pixels darker than 1-255 black and the rest white (0 off; pair with -dither-type NONE)"),
+		linkQR:       fs.String("link-qr", "", "Draw a QR code of -link in this corner: top-left|top-right|bottom-left|bottom-right"),
+		linkQRSize:   fs.Int("link-qr-size", 58, "Size in pixels of the -link-qr code, quiet zone included; longer links draw larger"),
+		safeMargin:   fs.Int("safe-margin", 0, "Shrink the image into an inset of this many pixels on every side, for bezels that clip the edge"),
 	}
-	if strings.TrimSpace(*device) == "" {
-		return errors.New("missing device serial (use -device or QUOTE0_DEVICE)")
+}
+
+// processOptions returns the local pipeline for the flags and whether any step was asked for.
+func (f *imageFlags) processOptions() ([]quote0.ProcessOption, bool) {
+	bg := quote0.White
+	switch {
+	case strings.EqualFold(strings.TrimSpace(*f.bg), "black"):
+		bg = quote0.Black
+	case strings.TrimSpace(*f.bg) == "" && *f.safeMargin > 0:
+		// The band blends into the border the device draws around it.
+		bg = quote0.BorderColor(*f.border).Gray()
 	}
+	// Transparent pixels take the -bg color, or blend into a black border when it is unset.
+	matte := bg
+	if strings.TrimSpace(*f.bg) == "" && quote0.BorderColor(*f.border) == quote0.BorderBlack {
+		matte = quote0.Black
+	}
+	fit := quote0.FitMode(strings.ToLower(strings.TrimSpace(*f.fit)))
+	opts := []quote0.ProcessOption{quote0.WithAlphaBackground(matte),
+		quote0.WithFit(fit), quote0.WithBackground(bg), quote0.WithRotation(*f.rotate),
+		quote0.WithContrast(*f.contrast), quote0.WithGamma(*f.gamma), quote0.WithSharpen(*f.sharpen),
+		quote0.WithSafeMargin(*f.safeMargin), quote0.WithResample(quote0.ResampleFilter(*f.resample)),
+	}
+	if *f.invert {
+		opts = append(opts, quote0.WithInvert())
+	}
+	if *f.threshold > 0 && *f.threshold <= 255 {
+		opts = append(opts, quote0.WithThreshold(uint8(*f.threshold)))
+	}
+	active := fit != quote0.FitNone || *f.grayscale || *f.invert || *f.rotate != 0 || *f.threshold != 0 ||
+		*f.contrast != 1 || *f.gamma != 1 || *f.sharpen != 0 || *f.safeMargin != 0
+	return opts, active
+}
 
-	if strings.TrimSpace(*image) != "" && strings.TrimSpace(*imageFile) != "" {
-		return fmt.Errorf("provide either -image or -image-file, not both")
+// transforms reports whether the flags ask for any local change to the image.
+func (f *imageFlags) transforms() bool {
+	_, active := f.processOptions()
+	return active || strings.TrimSpace(*f.linkQR) != ""
+}
+
+// checkLinkQR validates -link-qr and -link-qr-size.
+func (f *imageFlags) checkLinkQR() error {
+	corner := strings.ToLower(strings.TrimSpace(*f.linkQR))
+	if corner == "" {
+		return nil
+	}
+	switch quote0.QRCorner(corner) {
+	case quote0.QRTopLeft, quote0.QRTopRight, quote0.QRBottomLeft, quote0.QRBottomRight:
+	default:
+		return usagef("invalid -link-qr %q (want top-left, top-right, bottom-left, or bottom-right)", *f.linkQR)
+	}
+	if strings.TrimSpace(*f.link) == "" {
+		return usagef("-link-qr draws the -link URL; add -link")
 	}
-	if strings.TrimSpace(*image) == "" && strings.TrimSpace(*imageFile) == "" {
-		return errors.New("provide -image or -image-file")
+	if *f.linkQRSize <= 0 {
+		return usagef("invalid -link-qr-size %d (want a positive number of pixels)", *f.linkQRSize)
 	}
+	if n := len(*f.link); n > quote0.MaxQRBytes {
+		return usagef("-link is %d bytes, too long for -link-qr (at most %d); shorten the link", n, quote0.MaxQRBytes)
+	}
+	return nil
+}
 
-	client, err := quote0.NewClient(*token, quote0.WithDefaultDeviceID(*device), quote0.WithDebug(*debug))
+// processingFlags maps CheckProcessing fields to the flags that set them.
+var processingFlags = map[string]string{"rotation": "rotate", "safeMargin": "safe-margin", "ditherType": "dither-type"}
+
+// checkProcessing validates the adjustment flags; CheckProcessing warnings are returned for
+// the caller to show.
+func (f *imageFlags) checkProcessing(ditherType quote0.DitherType) ([]quote0.Problem, error) {
+	if *f.threshold < 0 || *f.threshold > 255 {
+		return nil, usagef("invalid -threshold %d (want 1 to 255, or 0 for off)", *f.threshold)
+	}
+	opts, _ := f.processOptions()
+	var warnings []quote0.Problem
+	for _, p := range quote0.CheckProcessing(ditherType, opts...) {
+		name := p.Field
+		if n, ok := processingFlags[name]; ok {
+			name = n
+		}
+		if p.Severity == quote0.SeverityError {
+			return nil, usagef("invalid -%s: %s", name, p.Message)
+		}
+		warnings = append(warnings, p)
+	}
+	return warnings, nil
+}
+
+func addBorderFlag(fs *flag.FlagSet) *borderFlag {
+	b := new(borderFlag)
+	fs.Var(b, "border", "Screen edge color: white (or 0) or black (or 1)")
+	return b
+}
+
+// request builds the image payload; DeviceID is left to the client default. -image-file -
+// reads the image from stdin.
+func (f *imageFlags) request(stdin io.Reader) (quote0.ImageRequest, error) {
+	if strings.TrimSpace(*f.image) != "" && strings.TrimSpace(*f.imageFile) != "" {
+		return quote0.ImageRequest{}, usagef("provide either -image or -image-file, not both")
+	}
+	if strings.TrimSpace(*f.image) == "" && strings.TrimSpace(*f.imageFile) == "" {
+		return quote0.ImageRequest{}, usagef("provide -image or -image-file")
+	}
+	req, err := f.meta()
 	if err != nil {
-		return err
+		return req, err
+	}
+	switch {
+	case strings.TrimSpace(*f.image) != "":
+		req.Image = *f.image
+	case *f.imageFile == "-":
+		data, err := readStdinBytes(stdin, maxImageStdin)
+		if err != nil {
+			return req, usagef("-image-file: %v", err)
+		}
+		req.ImageBytes = data
+	default:
+		req.ImagePath = *f.imageFile
+	}
+	return f.processed(req)
+}
+
+// fetch downloads -url with hc as the image of the request, then runs the local pipeline as
+// for -image-file.
+func (f *imageFlags) fetch(ctx context.Context, hc *http.Client, url string) (quote0.ImageRequest, error) {
+	req, err := f.meta()
+	if err != nil {
+		return req, err
+	}
+	if req.ImageBytes, err = quote0.FetchImage(ctx, hc, url); err != nil {
+		return req, err
+	}
+	return f.processed(req)
+}
+
+// processed applies -fit, -rotate, the tone adjustments, and -link-qr to the image of req, if
+// any were asked for, leaving the result in ImageBytes.
+func (f *imageFlags) processed(req quote0.ImageRequest) (quote0.ImageRequest, error) {
+	if !f.transforms() {
+		return req, nil
+	}
+	data, err := imageData(req)
+	if err != nil {
+		return req, err
+	}
+	if data, err = f.process(data); err != nil {
+		return req, err
 	}
+	req.Image, req.ImagePath, req.ImageBytes = "", "", data
+	return req, nil
+}
 
+// meta returns the display options of the image flags without any image content, after
+// checking the -fit and -bg values.
+func (f *imageFlags) meta() (quote0.ImageRequest, error) {
 	req := quote0.ImageRequest{
-		RefreshNow:   quote0.Bool(*refresh),
-		Link:         *link,
-		Border:       quote0.BorderColor(*border),
-		DitherType:   quote0.DitherType(strings.ToUpper(strings.TrimSpace(*ditherType))),
-		DitherKernel: quote0.DitherKernel(strings.ToUpper(strings.TrimSpace(*ditherKernel))),
-	}
-	if strings.TrimSpace(*image) != "" {
-		req.Image = *image
-	} else {
-		req.ImagePath = *imageFile
+		RefreshNow:   quote0.Bool(*f.refresh),
+		Link:         *f.link,
+		Border:       quote0.BorderColor(*f.border),
+		DitherType:   quote0.DitherType(strings.ToUpper(strings.TrimSpace(*f.ditherType))),
+		DitherKernel: quote0.DitherKernel(strings.ToUpper(strings.TrimSpace(*f.ditherKernel))),
+	}
+	if err := checkFit(*f.fit, *f.bg); err != nil {
+		return req, err
+	}
+	if err := f.checkLinkQR(); err != nil {
+		return req, err
+	}
+	_, err := f.checkProcessing(req.DitherType)
+	return req, err
+}
+
+// checkFit validates the -fit and -bg values.
+func checkFit(fit, bg string) error {
+	switch quote0.FitMode(strings.ToLower(strings.TrimSpace(fit))) {
+	case quote0.FitNone, quote0.FitContain, quote0.FitCover, quote0.FitStretch:
+	default:
+		return usagef("invalid -fit %q (want contain, cover, or stretch)", fit)
+	}
+	switch strings.ToLower(strings.TrimSpace(bg)) {
+	case "", "white", "black":
+	default:
+		return usagef("invalid -bg %q (want white or black)", bg)
+	}
+	return nil
+}
+
+// process runs the local pipeline (-rotate, -fit, the tone adjustments, then -link-qr) on PNG
+// or JPEG data and returns the PNG to send. Without any of those flags the data is returned
+// unchanged.
+func (f *imageFlags) process(data []byte) ([]byte, error) {
+	if !f.transforms() {
+		return data, nil
+	}
+	opts, _ := f.processOptions()
+	src, _, err := quote0.DecodeImage(data)
+	if err != nil {
+		return nil, err
 	}
-	resp, err := client.SendImage(context.Background(), req)
+	img, err := quote0.ProcessImage(src, opts...)
 	if err != nil {
+		return nil, err
+	}
+	if corner := strings.ToLower(strings.TrimSpace(*f.linkQR)); corner != "" {
+		// The code goes on after the tone steps so -invert and -threshold leave it scannable.
+		if _, err := quote0.OverlayQR(img, *f.link, quote0.QRCorner(corner), *f.linkQRSize); err != nil {
+			return nil, err
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// imageData returns the raw bytes behind the -image or -image-file flag.
+func imageData(req quote0.ImageRequest) ([]byte, error) {
+	if req.Image != "" {
+		data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(req.Image))
+		if err != nil {
+			return nil, usagef("-image is not valid base64: %v", err)
+		}
+		return data, nil
+	}
+	if len(req.ImageBytes) > 0 {
+		return req.ImageBytes, nil
+	}
+	return os.ReadFile(req.ImagePath)
+}
+
+func (c *cli) runImage(args []string) error {
+	fs, cf := c.newFlagSet("image")
+	imf := addImageFlags(fs)
+	sf := addSendFlags(fs)
+	sched := addScheduleFlags(fs)
+	rf := addRepeatFlags(fs)
+	fromJSON := fs.String("from-json", "", "Send an image request saved as JSON (e.g. by -dry-run -json) instead of content flags")
+	outPath := fs.String("out", "", "With -dry-run, also write the processed PNG to this file")
+	output := addOutputFlag(fs)
+	imageURL := fs.String("url", "", "Download the PNG or JPEG to send from this URL; -timeout bounds the download")
+	if err := c.parseFlags(fs, args); err != nil {
 		return err
 	}
-	fmt.Printf("Image sent (code=%d message=%s)\n", resp.Code, resp.Message)
-	return nil
+	if *imageURL != "" && (strings.TrimSpace(*imf.image) != "" || strings.TrimSpace(*imf.imageFile) != "") {
+		return usagef("provide only one of -image, -image-file, and -url")
+	}
+	if err := checkOutput(*output, cf); err != nil {
+		return err
+	}
+	if err := rf.check(sf, imf.imageFile); err != nil {
+		return err
+	}
+	if *outPath != "" && !*cf.dryRun {
+		return usagef("-out writes the processed image instead of sending it; add -dry-run")
+	}
+	target, err := sched.target(c.clock())
+	if err != nil {
+		return err
+	}
+	if *fromJSON != "" {
+		return c.sendImageFromJSON(*fromJSON, fs, cf, sf, target, *output)
+	}
+	var req quote0.ImageRequest
+	if *imageURL == "" {
+		if req, err = imf.request(c.stdin); err != nil {
+			return err
+		}
+	} else if req, err = imf.meta(); err != nil {
+		return err
+	}
+	warnings, _ := imf.checkProcessing(req.DitherType)
+	for _, w := range warnings {
+		fmt.Fprintf(c.stderr, "warning: -%s: %s\n", processingFlags[w.Field], w.Message)
+	}
+	client, devices, err := c.newClientDevices(cf)
+	if err != nil {
+		return err
+	}
+	// The download counts against -timeout like the send that follows.
+	next := func(ctx context.Context) (quote0.ImageRequest, error) {
+		if *imageURL != "" {
+			return imf.fetch(ctx, client.HTTPClient(), *imageURL)
+		}
+		return imf.request(c.stdin)
+	}
+	if *imageURL != "" {
+		ctx, cancel := c.commandContext(cf)
+		req, err = next(ctx)
+		cancel()
+		if err != nil {
+			return err
+		}
+	}
+	if *cf.dryRun {
+		if *outPath != "" {
+			data, err := imageData(req)
+			if err != nil {
+				return err
+			}
+			if err := os.WriteFile(*outPath, data, 0o644); err != nil {
+				return err
+			}
+			fmt.Fprintf(c.stderr, "Wrote %s (%d bytes)\n", *outPath, len(data))
+		}
+		return c.dryRunDevices(devices, sf, func(id string) (*quote0.PreparedRequest, error) {
+			req.DeviceID = id
+			return client.BuildImage(req)
+		})
+	}
+	out := imageOutgoing("Image", client, req)
+	out.capture = *output
+	if err := c.waitToSend(cf, target, devices, out.build); err != nil {
+		return err
+	}
+	ctx, cancel := c.commandContext(cf)
+	defer cancel()
+	if *rf.every > 0 {
+		return c.repeat(ctx, cf, rf, devices, func(ctx context.Context) (outgoing, error) {
+			req, err := next(ctx)
+			out := imageOutgoing("Image", client, req)
+			out.capture = *output
+			return out, err
+		})
+	}
+	return c.deliver(ctx, cf, devices, sf, out)
+}
+
+// maxTextFile caps -*-file input; the panel shows a few hundred characters at most.
+const maxTextFile = 16 << 10
+
+// readTextFile reads UTF-8 text from path ("-" for stdin) and trims one trailing newline.
+// Binary or oversized input is rejected rather than sent to the panel.
+func readTextFile(path string, stdin io.Reader) (string, error) {
+	var r io.Reader
+	if path == "-" {
+		r = stdin
+	} else {
+		f, err := os.Open(path)
+		if err != nil {
+			return "", err
+		}
+		defer f.Close()
+		r = f
+	}
+	data, err := io.ReadAll(io.LimitReader(r, maxTextFile+1))
+	if err != nil {
+		return "", err
+	}
+	if len(data) > maxTextFile {
+		return "", fmt.Errorf("input is larger than %d bytes", maxTextFile)
+	}
+	if !utf8.Valid(data) || bytes.IndexByte(data, 0) >= 0 {
+		return "", errors.New("input is not UTF-8 text")
+	}
+	s := string(data)
+	if strings.HasSuffix(s, "\r\n") {
+		s = s[:len(s)-2]
+	} else {
+		s = strings.TrimSuffix(s, "\n")
+	}
+	return s, nil
+}
+
+// Limits for binary stdin input; images are small 1-bit-ish PNGs, but -fit accepts photos.
+const (
+	maxImageStdin = 16 << 20
+	maxIconStdin  = 1 << 20
+)
+
+// readStdinBytes reads binary data piped into the CLI, refusing an interactive terminal so a
+// forgotten pipe does not hang waiting for input.
+func readStdinBytes(stdin io.Reader, limit int64) ([]byte, error) {
+	if isTerminal(stdin) {
+		return nil, errors.New("stdin is a terminal; pipe the PNG data in (e.g. render | quote0 image -image-file -)")
+	}
+	data, err := io.ReadAll(io.LimitReader(stdin, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("input is larger than %d bytes", limit)
+	}
+	if len(data) == 0 {
+		return nil, errors.New("stdin is empty")
+	}
+	return data, nil
 }
 
 func loadBase64(raw, file, label string) (string, error) {
@@ -154,7 +1233,7 @@ func loadBase64(raw, file, label string) (string, error) {
 	file = strings.TrimSpace(file)
 	switch {
 	case raw != "" && file != "":
-		return "", fmt.Errorf("provide either -%s or -%s-file, not both", label, label)
+		return "", usagef("provide either -%s or -%s-file, not both", label, label)
 	case raw != "":
 		return raw, nil
 	case file != "":
@@ -168,39 +1247,329 @@ func loadBase64(raw, file, label string) (string, error) {
 	}
 }
 
-func printUsage() {
-	fmt.Fprintf(os.Stderr, `quote0 - Quote/0 SDK CLI
+func (c *cli) printUsage() {
+	fmt.Fprintf(c.stderr, `quote0 - Quote/0 SDK CLI
 
 Usage:
-  quote0 text  [flags]
-  quote0 image [flags]
+  quote0 send    FILE|-|TEXT... [-as text|image] [flags]
+  quote0 text    [flags]
+  quote0 image   [flags]
+  quote0 refresh [flags]
+  quote0 preview text|image [flags] [-out FILE]
+  quote0 validate text|image [flags] [-json]
+  quote0 convert -in FILE -out FILE [-fit M] [-rotate D] [-dither D] [-format png|raw]
+  quote0 batch   -file PLAN.jsonl [flags]
+  quote0 watch   -image-file FILE|-text-file FILE [flags]
+  quote0 loop    -dir DIR [flags]
+  quote0 template -title-tpl T|-message-tpl T [-data FILE] [-env] [flags]
+  quote0 status  [-fields LIST] [-every D] [flags]
+  quote0 tail    [-title T] [-lines N] [-every D] [flags] < STREAM
+  quote0 monitor [-title T] [-lines N] [-head] [-every D] [flags] -- COMMAND [ARG...]
+  quote0 chart   [-column N|NAME] [-title T] [-type sparkline|bar] [-out FILE] [flags] < CSV
+  quote0 dither-sheet -in FILE [-out FILE] [-rank] [-send] [flags]
+  quote0 demo    [-pattern P] [-cell N] [-out FILE] [flags]
+  quote0 doctor  [-offline] [-skip-ping] [flags]
+  quote0 replay  [-device D] [-json] [flags] FILE...
+  quote0 broadcast text|image [-devices-from FILE] [-concurrency N] [-best-effort] [flags]
+  quote0 icons   list | export NAME -out FILE | sheet -out FILE | send NAME [text flags]
 
 Common flags:
   -token       API token (or set QUOTE0_TOKEN)
-  -device      Device serial (or set QUOTE0_DEVICE)
+  -base-url    API base URL, e.g. a staging relay (or set QUOTE0_BASE_URL; default
+               https://dot.mindreset.tech). -v prints the host in use
+  -device      Device serial (or set QUOTE0_DEVICE). text and image accept several: repeat the
+               flag or comma-separate; a line per device is printed and the command fails if any
+               device failed (-any-success: only if all failed). -json prints the result(s) as JSON
   -debug       Enable debug mode (logs request/response details to stderr)
+  -v, -vv      Log each HTTP request/response to stderr (-v one line each, -vv with headers and
+               bodies; -verbose is -v). The token is masked and base64 images shown as a length
+  -dry-run     Validate and print the endpoint, device, and JSON payload instead of sending
+               (text, image, refresh, batch, status; no token needed, base64 fields are abbreviated).
+               text and image with -json print the complete payload, replayable with -from-json
+  -rate        Minimum interval between API requests (default 1s), shared by every send of the
+               command: batch items, loop frames, watch updates, and multi-device sends. Raise it
+               for flaky links; 0 disables the limiter for relays without limits (the official API
+               enforces 1 QPS, so expect 429 errors there)
+  -timeout     Total time budget for the command, including rate-limit waits, host failover,
+               and batch delays (e.g. 10s; exit code 7 on expiry; default no limit)
+  -lock        Hold an exclusive lock file while sending, so overlapping cron runs take turns
+               (or set QUOTE0_LOCK). auto uses quote0-DEVICE.lock under the temp directory.
+               A lock whose process is gone is taken over
+  -lock-timeout
+               How long to wait for a held lock (default 0: exit 8 at once)
+  -if-changed  Send only to devices whose request differs from the last successful send to them;
+               the others print "skipped, unchanged" (-json: "skipped": true) and exit 0. The
+               hash is saved only after a successful send; a missing or corrupt one counts as changed
+  -state-dir   Where -if-changed keeps one hash per device (default quote0 under the user cache
+               directory, e.g. ~/.cache/quote0)
+  -log         Append everything the command prints, including -v/-debug output and errors, to
+               a file (directories are created), one timestamped record per line and an exit
+               record per run. The token is never written
+  -log-format  text (default) or json (time, command, stream, msg; exit on the last record)
+  -log-max-size
+               Move the log to PATH.1 and start over when it would pass this size (default 1M;
+               K, M, G suffixes; 0 never)
+  -quiet       Print nothing on stdout; errors still go to stderr, and -log records everything
+
+Environment:
+  Every flag falls back to a QUOTE0_ variable named after it (-image-file: QUOTE0_IMAGE_FILE,
+  -dither-type: QUOTE0_DITHER_TYPE); -h lists each name. A flag on the command line wins, also
+  over the variables of flags it excludes (-message-file over QUOTE0_MESSAGE), and an empty
+  variable counts as unset.
 
 Text flags:
   -title          Title displayed on the first line (optional)
   -message        Message displayed on the next three lines (optional)
   -signature      Signature displayed at bottom-right corner (optional)
+  -title-file, -message-file, -signature-file
+                  Read the field from a UTF-8 file, or - for stdin (one trailing newline is trimmed)
   -auto-signature Use auto-generated signature (YYYY-MM-DD HH:MM:SS) if -signature is empty
+  -signature-format
+                  Go time layout for the auto signature, with {host} and {user} tokens, e.g.
+                  '{host}@01-02 15:04' (implies -auto-signature; cut to fit the corner, noted with -v)
+  -signature-tz   Time zone for the auto signature, e.g. UTC or Asia/Tokyo (default local)
   -icon           Base64 40x40 PNG icon displayed at bottom-left corner (optional)
-  -icon-file      Path to 40x40 PNG icon (optional)
+  -kv             KEY=VALUE message line; repeat for up to 3 aligned lines (not with -message)
+  -kv-separator   Text between key and value (default ": ")
+  -kv-align       columns (values line up, default), right (values at the line end), or none
+  -drop-overflow  Drop -kv pairs beyond 3 lines instead of failing (noted with -v)
+  -from-json      Send a request saved as JSON (e.g. by -dry-run -json) instead of the flags
+                  above. Unknown fields are rejected; "iconPath" (text) and "imagePath" (image)
+                  load files relative to the JSON file; -device overrides its deviceId
+  -output         After a successful send, save the request with a "capture" object (time,
+                  device, endpoint) to this file for replay or -from-json; with several devices,
+                  one file each, named FILE-DEVICE.json
+  -icon-file      Path to 40x40 PNG icon, or - for stdin (optional)
+  -icon-url       Download the 40x40 PNG icon from a URL; -timeout bounds the download (optional)
+  -icon-text      Draw one or two characters (e.g. DB, ⚠, ✓) as the icon; -icon-invert draws them
+                  white on black. Only one of -icon, -icon-file, -icon-url, and -icon-text
   -link           URL (optional)
-  -refresh        true|false (default true)
+  -refresh        true|false, yes|no, or on|off (default true; write -refresh=no)
+  -i              (text only) Prompt on the terminal for the title, message (end with a lone
+                  "."), and signature, showing each field's budget; then review the summary and
+                  confirm (p writes a preview PNG). Nothing is sent on EOF, Ctrl-C, or "no"
 
 Image flags:
   -image         Base64 296x152 PNG
-  -image-file    Path to 296x152 PNG (SDK encodes base64 internally)
-  -border        Screen edge color: 0=white (default), 1=black
+  -image-file    Path to 296x152 PNG, or - for stdin (SDK encodes base64 internally)
+  -url           (image only) Download the PNG or JPEG from a URL (up to 16 MiB); the flags
+                 below apply as for a file, -timeout bounds the download, and a failed
+                 download exits 9
+  -border        Screen edge color: white (default) or black, case-insensitive; 0 and 1 also work
   -dither-type   NONE|DIFFUSION|ORDERED (default: DIFFUSION with FLOYD_STEINBERG)
   -dither-kernel Kernel for DIFFUSION type. Options:
                  FLOYD_STEINBERG (default), ATKINSON, BURKES, SIERRA2, STUCKI,
                  JARVIS_JUDICE_NINKE, DIFFUSION_ROW, DIFFUSION_COLUMN,
                  DIFFUSION_2D, THRESHOLD
+  -fit           Resize any PNG/JPEG to 296x152: contain|cover|stretch (default off)
+  -bg            Padding color for -fit contain and -safe-margin: white or black (default
+                 white, or the -border color for the -safe-margin band). Transparent pixels
+                 of the image are flattened onto it too, or onto black with -border black
+  -resample      Scaling filter for -fit and -safe-margin: box (default) averages, nearest
+                 keeps hard edges but can drop thin lines, bilinear blends smoothly. Box and
+                 bilinear soften line art when shrinking; add -sharpen 0.5 to restore it
+  -safe-margin   Shrink the image into an inset of N pixels on every side and fill the band
+                 around it, for units whose bezel clips the edge (0 off, at most 75)
+  -rotate, -contrast, -gamma, -sharpen, -invert, -threshold
+                 Adjust the image locally before upload, always in this order: -rotate
+                 (90|180|270), -fit, -contrast F (1 = as is), -gamma F (>1 brightens mid-tones),
+                 -sharpen F (unsharp mask, 0 off), -invert, -threshold N (1-255: darker pixels
+                 black, the rest white; warns unless -dither-type NONE). Any of them, or
+                 -grayscale, converts the image to grayscale; without -fit it must be 296x152
+  -out           (image only) With -dry-run, write the processed PNG here to inspect it
   -link          URL (optional)
-  -refresh       true|false (default true)
+  -link-qr       Draw a QR code of -link in a corner (top-left, top-right, bottom-left, or
+                 bottom-right) after the adjustments above, so the link can be scanned off the
+                 panel. The image must be 296x152 or use -fit
+  -link-qr-size  Code size in pixels, quiet zone included (default 58); a link too long for
+                 that size is drawn larger, at 2 pixels per module. Links over 213 bytes fail
+  -refresh       true|false, yes|no, or on|off (default true; write -refresh=no)
+  -from-json     Send an image request saved as JSON instead of these flags (see text)
+  -output        Save the sent request to this file (see text)
+
+Scheduling and repeating (text and image):
+  -at            Send at a time: RFC 3339 (2025-06-01T09:00:00+02:00), or HH:MM for its next
+                 occurrence in local time. The content is checked at once, then the command
+                 waits (-v prints a countdown; Ctrl-C sends nothing). -timeout covers the send only
+  -in            Send after a duration, e.g. 90m (not with -at)
+  -allow-past    Send at once when an RFC 3339 -at has passed, instead of exiting 2
+  -every         Send again after each interval until Ctrl-C, e.g. 10m, reading the content
+                 files (and -icon-url) anew each time; with -if-changed only changes are sent.
+                 Prints a line per send and a summary (sent, skipped, failed) at the end
+  -fail-fast     With -every, stop at the first failure (exit code as for one send); by
+                 default failures are reported and the loop goes on
+
+Refresh:
+  Repaints the display without changing its content (empty text payload with refreshNow=true).
+  Takes only the common flags.
+
+Preview:
+  Renders text or image flags locally to a 296x152 PNG without sending; no token needed.
+  -out           Output path, or - for stdout (default preview.png)
+
+Validate:
+  Runs every local check on text or image flags without a token, device, or network: layout
+  fit (title, message lines, signature), icon and image PNG dimensions, dither type/kernel
+  compatibility, and link format. Prints one line per problem (or a report with -json) and
+  exits 3 if any is an error; warnings, such as a kernel ignored by the dither type, do not fail.
+
+Convert:
+  Runs the local image pipeline and writes the result instead of sending it; no token needed.
+  Steps: decode PNG/JPEG, rotate, fit to 296x152, grayscale, dither. Send the result later
+  with -dither-type NONE so the server does not dither it again.
+  -in, -out           Input and output paths (- for stdin/stdout)
+  -fit, -bg           As for image (without -fit the input must be 296x152 after rotation)
+  -resample           Scaling filter for -fit: nearest, box (default), or bilinear
+  -rotate             Clockwise rotation: 90, 180, or 270
+  -grayscale          Accepted for readability; output is always grayscale
+  -dither             none, ordered, diffusion, or a kernel (floyd_steinberg, atkinson, ...);
+                      default keeps gray levels
+  -serpentine         Scan alternate rows right to left with diffusion kernels; fewer streaks,
+                      but no longer what the server would produce
+  -format             png (default), or raw: packed 1-bit rows, MSB first, 1 = black
+                      (37 bytes per row; without -dither, gray levels below 128 are black)
+
+Batch:
+  Sends a JSONL plan, one {"type":"text"|"image","request":{...},"delay":"30s"} per line.
+  The plan is validated before anything is sent; delay is waited before that item.
+  -file               Plan path, or - for stdin
+  -continue-on-error  Keep going after a failed item (default: stop)
+  -json               Print the per-line summary as JSON instead of a table
+
+Watch:
+  Re-sends a file whenever it changes (mtime/size polling, debounced, unchanged content skipped).
+  -image-file takes every image flag (e.g. -fit); -text-file sends the first line as title and
+  the rest as message. A file briefly missing during an atomic rename is waited for.
+  -interval           Poll interval (default 2s)
+  -debounce           Quiet period before sending a change (default 1s)
+  -signature          Signature for -text-file sends
+
+Loop:
+  Cycles the PNG files of -dir (JPEGs and other sizes too with -fit) as a photo frame, until
+  Ctrl-C. The directory is re-read before every pass; invalid files are skipped with a warning.
+  Takes every image flag except -image and -image-file.
+  -every              How long each frame stays up (default 15m)
+  -shuffle            Shuffle the frames on every pass
+  -once               Play a single pass, then exit
+
+Template:
+  Renders Go text/template sources with the display helpers (truncate, upper, lower, trim,
+  default, join, round, percent, bar, date) and sends the result as text. Templates are
+  checked before anything is sent; errors name the template and position. -dry-run prints
+  the rendered fields.
+  -title-tpl, -message-tpl, -signature-tpl   Field templates
+  -data               JSON file (- for stdin) used as the template data (.)
+  -env                Expose environment variables as .Env (data must be a JSON object)
+  -markdown           Strip Markdown (emphasis, code, links, list markers) from the rendered
+                      fields; the first link is used when -link is not set
+  -icon, -icon-file, -link, -refresh         As for text
+
+Status:
+  Sends host vitals: hostname as title, then IP and uptime, load averages, and disk usage,
+  with the collection time as signature. Values the platform cannot provide are left out.
+  -dry-run prints the payload; -dry-run -json prints the collected values and display lines.
+  -fields             Lines to show: host, ip, uptime, load, disk (comma-separated; default all)
+  -every              Keep resending at this interval until Ctrl-C; failures are logged and retried
+  -disk               Filesystem for the disk line (default /)
+
+Tail:
+  Shows the latest lines read from stdin, e.g. journalctl -f | quote0 tail -title web-1. Lines
+  are stripped of terminal escapes and cut to the screen width; an update is sent when the
+  shown lines change, at most once per -every. End of input sends a final update and exits.
+  -title              Fixed title
+  -lines              How many of the latest lines to show (default 3)
+  -every              Minimum time between updates (default 30s)
+  -signature-format, -signature-tz   Auto signature, as for text (default local date and time)
+  -link               URL (optional)
+  -markdown           Strip Markdown from each line, e.g. for a stream of chat messages
+
+Monitor:
+  Runs a command and shows its output, e.g. quote0 monitor -every 5m -title Backups -- restic
+  snapshots --last. Output lines are sanitized as for tail and cut to the screen width. A
+  non-zero exit, a signal, or -command-timeout shows "FAILED (...)" as the first line with the
+  warning icon. With -every the command runs again each time; add -if-changed so unchanged
+  output does not refresh the panel. The command's stderr passes through.
+  -title              Fixed title (default the command line)
+  -lines              How many output lines to show (default 3)
+  -head               Show the first -lines lines instead of the last
+  -command-timeout    Kill the command after this long (default 1m)
+  -every, -fail-fast  Repeat as for text
+  -signature, -link   Optional signature and URL
+
+Chart:
+  Plots one CSV column, e.g. cat temps.csv | quote0 chart -column 2 -title "Outdoor °C". The
+  first row is a header when -column names it or its cell is not a number. Non-numeric cells
+  are skipped (counted with -v); a column without numbers fails instead of sending a blank chart.
+  -input              CSV file, or - for stdin (default)
+  -column             1-based index (default 1) or header name (case-insensitive)
+  -delimiter          Field separator (default ,; \t for tab)
+  -title              Header text; the last value is shown on the right
+  -type               sparkline (default) or bar
+  -min, -max          Fix the axis ends (default fitted to the data)
+  -auto-scale         Fit the axis to the data (default true); false includes zero
+  -out                Write the PNG instead of sending it (no token needed)
+  -link, -border, -refresh   As for image
+
+Dither sheet:
+  Dithers one picture with every mode and kernel to pick one before sending. Local only and
+  no token needed unless -send.
+  -in                 Input PNG or JPEG, or - for stdin
+  -fit, -bg           How to reach 296x152 first (default cover)
+  -out                Contact sheet PNG: every variant at full size, labeled
+  -rank               Print the variants ranked by tone error (blurred RMS difference from the
+                      gray image; lower keeps tones better, but judge the texture by eye)
+  -send               Send a one-screen sheet of small tiles, dithered at tile size
+
+Demo:
+  Sends a built-in test pattern to tell panel artifacts from pipeline ones, e.g. quote0 demo
+  -pattern grid. The patterns are exact 296x152 images.
+  -pattern            grid (default; border, grid, center crosshair, larger top-left corner
+                      marker), checkerboard, gradient-h, gradient-v, black, or white
+  -cell               Checkerboard cell size or grid spacing, 1-76 px (default 8 and 16)
+  -dither-type        Default NONE; gradients keep the server default so its dithering shows
+  -out                Write the PNG instead of sending it (no token needed)
+  -link, -border, -refresh   As for image
+
+Send:
+  Sends a PNG or JPEG file (or - for stdin) as an image, fitted to 296x152 with -fit contain
+  when its size differs; other input, or the arguments themselves, as text split into title
+  (first line) and message. Flags may come before or after the input; use -- before text
+  that starts with -.
+  -as                 text or image: skip the magic-byte detection
+
+Broadcast:
+  Runs text or image for every device of -device (or QUOTE0_DEVICE) or -devices-from and
+  prints a DEVICE/RESULT/DETAIL table, or with -json an array even for one device. Fails if
+  any device failed unless -best-effort.
+  -devices-from       File of serials, one per line (# comments), or - for stdin
+  -concurrency        Sends in flight at once (default 1); only useful where the rate limit
+                      allows it, e.g. -rate 0 on a relay
+  -best-effort        Exit 0 whatever the per-device results
+
+Icons:
+  Browses the built-in 40x40 icon set (no token needed except for send).
+  list                Print each name with a short description
+  export NAME -out F  Write the icon as a PNG (- for stdout)
+  sheet -out F        Write every icon, labeled, on one contact sheet
+  send NAME ...       Send text with the icon; the other flags are those of text
+  An unknown NAME fails with the closest names.
+
+Replay:
+  Sends files saved by -output (or -dry-run -json) again, in order and rate limited, each to
+  its saved device unless -device names others. A line (or with -json, an object) is printed
+  per file; a failed file does not stop the rest, but the command then fails.
+
+Doctor:
+  Checks the token and device formats, DNS and TLS to the base URL, then pings each device,
+  and prints the effective config (token redacted) and a pass/warn/fail table with hints.
+  Exits 0 if all passed, 1 on warnings only, else the code of the first failure.
+  -offline            Check the configuration only; no network access
+  -skip-ping          Skip the ping: it is a refresh, so the display may repaint once
+
+Exit codes:
+  0 success, 1 other failure, 2 usage or flag error, 3 validation error, 4 authentication error,
+  5 rate limited, 6 device error (unknown or unbound device), 7 network or transport error,
+  8 the -lock is held by another run, 9 -url or -icon-url could not be downloaded
 
 Notes:
   - Text layout is fixed (296x152px): title on first line, message on next 3 lines, icon at bottom-left, signature at bottom-right.
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/1set/quote0"
+)
+
+// fakeAPI records every JSON body posted to it and answers with code 0.
+type fakeAPI struct {
+	mu     sync.Mutex
+	paths  []string
+	bodies []map[string]interface{}
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body map[string]interface{}
+	_ = json.NewDecoder(r.Body).Decode(&body)
+	f.mu.Lock()
+	f.paths = append(f.paths, r.URL.Path)
+	f.bodies = append(f.bodies, body)
+	f.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = io.WriteString(w, `{"code":0,"message":"ok"}`)
+}
+
+func (f *fakeAPI) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.bodies)
+}
+
+func (f *fakeAPI) body(i int) map[string]interface{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.bodies[i]
+}
+
+// newTestCLI returns a cli wired to a fake API server and an environment map.
+func newTestCLI(t *testing.T, env map[string]string) (*cli, *fakeAPI, *bytes.Buffer, *bytes.Buffer) {
+	t.Helper()
+	api := &fakeAPI{}
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+	var stdout, stderr bytes.Buffer
+	c := &cli{
+		stdin:  strings.NewReader(""),
+		stdout: &stdout,
+		stderr: &stderr,
+		getenv: func(k string) string { return env[k] },
+		environ: func() []string {
+			var kv []string
+			for k, v := range env {
+				kv = append(kv, k+"="+v)
+			}
+			return kv
+		},
+		clientOptions: []quote0.ClientOption{quote0.WithBaseURL(srv.URL), quote0.WithRateLimiter(nil)},
+	}
+	return c, api, &stdout, &stderr
+}
+
+func TestRefresh(t *testing.T) {
+	c, api, stdout, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "ENV"})
+	if code := c.run([]string{"refresh", "-device", "D1"}); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if len(api.bodies) != 1 || api.paths[0] != "/api/open/text" {
+		t.Fatalf("requests %v", api.paths)
+	}
+	body := api.bodies[0]
+	if body["refreshNow"] != true || body["deviceId"] != "D1" {
+		t.Fatalf("body %v", body)
+	}
+	for _, k := range []string{"title", "message", "signature", "icon", "link"} {
+		if _, ok := body[k]; ok {
+			t.Errorf("unexpected %q in %v", k, body)
+		}
+	}
+	if !strings.Contains(stdout.String(), "Refresh sent (code=0") {
+		t.Fatalf("stdout %q", stdout)
+	}
+
+	// Without -device the env default is used.
+	if code := c.run([]string{"refresh"}); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if api.bodies[1]["deviceId"] != "ENV" {
+		t.Fatalf("body %v", api.bodies[1])
+	}
+}
+
+func TestRefresh_RejectsContent(t *testing.T) {
+	for _, args := range [][]string{
+		{"refresh", "-title", "x"},
+		{"refresh", "-image-file", "a.png"},
+		{"refresh", "extra"},
+	} {
+		c, api, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+		if code := c.run(args); code == 0 {
+			t.Fatalf("%v: expected failure", args)
+		}
+		if len(api.bodies) != 0 {
+			t.Fatalf("%v: sent %v", args, api.bodies)
+		}
+		if !strings.Contains(stderr.String(), "refresh") {
+			t.Fatalf("%v: stderr %q", args, stderr)
+		}
+	}
+}
+
+func TestRefresh_MissingToken(t *testing.T) {
+	c, api, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_DEVICE": "D"})
+	if code := c.run([]string{"refresh"}); code != exitUsage {
+		t.Fatalf("exit %d", code)
+	}
+	if len(api.bodies) != 0 || !strings.Contains(stderr.String(), "missing API token") {
+		t.Fatalf("stderr %q", stderr)
+	}
+}
+
+func TestText(t *testing.T) {
+	c, api, stdout, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+	if code := c.run([]string{"text", "-title", "Hi", "-message", "there"}); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if api.bodies[0]["title"] != "Hi" || api.bodies[0]["message"] != "there" {
+		t.Fatalf("body %v", api.bodies[0])
+	}
+	if !strings.Contains(stdout.String(), "Text sent") {
+		t.Fatalf("stdout %q", stdout)
+	}
+}
+
+func TestText_MessageFromStdin(t *testing.T) {
+	c, api, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+	c.stdin = strings.NewReader("line one\nline two\n")
+	if code := c.run([]string{"text", "-title", "Log", "-message-file", "-"}); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if got := api.bodies[0]["message"]; got != "line one\nline two" {
+		t.Fatalf("message %q", got)
+	}
+}
+
+func TestText_FileFlags(t *testing.T) {
+	dir := t.TempDir()
+	sigPath := filepath.Join(dir, "sig.txt")
+	_ = os.WriteFile(sigPath, []byte("— ops\r\n"), 0o644)
+	binPath := filepath.Join(dir, "bin")
+	_ = os.WriteFile(binPath, []byte{0x89, 'P', 'N', 'G', 0, 0xff}, 0o644)
+	bigPath := filepath.Join(dir, "big")
+	_ = os.WriteFile(bigPath, bytes.Repeat([]byte("a"), maxTextFile+1), 0o644)
+
+	c, api, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+	if code := c.run([]string{"text", "-signature-file", sigPath}); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if got := api.bodies[0]["signature"]; got != "— ops" {
+		t.Fatalf("signature %q", got)
+	}
+
+	for _, tc := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"text", "-message", "x", "-message-file", sigPath}, "provide either -message or -message-file, not both"},
+		{[]string{"text", "-title-file", "-", "-message-file", "-"}, "only one flag can read stdin"},
+		{[]string{"text", "-message-file", binPath}, "not UTF-8 text"},
+		{[]string{"text", "-message-file", bigPath}, "larger than"},
+		{[]string{"text", "-title-file", filepath.Join(dir, "missing")}, "-title-file"},
+	} {
+		c, api, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+		if code := c.run(tc.args); code == 0 || !strings.Contains(stderr.String(), tc.want) {
+			t.Errorf("%v: exit %d, stderr %q", tc.args, code, stderr)
+		}
+		if len(api.bodies) != 0 {
+			t.Errorf("%v: sent %v", tc.args, api.bodies)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/1set/quote0"
+)
+
+// maxMonitorOutput caps how much of a monitored command's stdout is kept. In the default tail
+// mode the last bytes are kept, with -head the first, so a chatty command always yields the
+// same lines for the same output.
+const maxMonitorOutput = 64 << 10
+
+// runMonitor runs a command (once, or every -every) and shows its output on the display.
+func (c *cli) runMonitor(args []string) error {
+	fs, cf := c.newFlagSet("monitor")
+	sf := addSendFlags(fs)
+	rf := addRepeatFlags(fs)
+	title := fs.String("title", "", "Fixed title (default the command line)")
+	lines := fs.Int("lines", quote0.MessageLines, "How many output lines to show")
+	head := fs.Bool("head", false, "Show the first -lines lines instead of the last")
+	cmdTimeout := fs.Duration("command-timeout", time.Minute, "Kill the command if it runs longer than this")
+	signature := fs.String("signature", "", "Optional signature")
+	link := fs.String("link", "", "Optional URL")
+	if err := c.parseFlags(fs, args); err != nil {
+		return err
+	}
+	command := fs.Args()
+	if len(command) == 0 {
+		return usagef("monitor needs a command after --, e.g. quote0 monitor -- df -h /")
+	}
+	if *lines <= 0 {
+		return usagef("-lines must be positive")
+	}
+	if *cmdTimeout <= 0 {
+		return usagef("-command-timeout must be positive")
+	}
+	if err := rf.check(sf); err != nil {
+		return err
+	}
+	m := monitor{
+		command: command,
+		timeout: *cmdTimeout,
+		lines:   *lines,
+		head:    *head,
+		stderr:  c.stderr,
+		template: quote0.TextRequest{
+			RefreshNow: quote0.Bool(true),
+			Title:      *title,
+			Signature:  *signature,
+			Link:       *link,
+		},
+	}
+	if m.template.Title == "" {
+		m.template.Title = quote0.Truncate(strings.Join(command, " "), quote0.MaxTitleRunes)
+	}
+	client, devices, err := c.newClientDevices(cf)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := c.commandContext(cf)
+	defer cancel()
+	next := func(ctx context.Context) (outgoing, error) {
+		req, err := m.request(ctx)
+		return textOutgoing("Monitor", client, req), err
+	}
+	if *rf.every > 0 && !*cf.dryRun {
+		return c.repeat(ctx, cf, rf, devices, next)
+	}
+	req, err := m.request(ctx)
+	if err != nil {
+		return err
+	}
+	if *cf.dryRun {
+		return c.dryRunDevices(devices, sf, func(id string) (*quote0.PreparedRequest, error) {
+			req.DeviceID = id
+			return client.BuildText(req)
+		})
+	}
+	return c.deliver(ctx, cf, devices, sf, textOutgoing("Monitor", client, req))
+}
+
+// monitor runs the command of `monitor` and turns its output into a text request.
+type monitor struct {
+	command  []string
+	timeout  time.Duration
+	lines    int
+	head     bool
+	stderr   io.Writer
+	template quote0.TextRequest
+}
+
+// request runs the command once. A non-zero exit, a signal, or the -command-timeout is shown
+// as a "FAILED (...)" first line with the warning icon; a command that cannot be started is an
+// error. The command's stderr passes through to ours.
+func (m *monitor) request(ctx context.Context) (quote0.TextRequest, error) {
+	runCtx, cancel := context.WithTimeout(ctx, m.timeout)
+	defer cancel()
+	out := &capWriter{max: maxMonitorOutput, keepHead: m.head}
+	cmd := exec.CommandContext(runCtx, m.command[0], m.command[1:]...)
+	cmd.Stdout = out
+	cmd.Stderr = m.stderr
+	err := cmd.Run()
+	if ctx.Err() != nil {
+		return quote0.TextRequest{}, ctx.Err()
+	}
+	status := ""
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case errors.Is(runCtx.Err(), context.DeadlineExceeded):
+		status = fmt.Sprintf("FAILED (timed out after %s)", m.timeout)
+	case errors.As(err, &exitErr) && exitErr.ExitCode() >= 0:
+		status = fmt.Sprintf("FAILED (exit %d)", exitErr.ExitCode())
+	case errors.As(err, &exitErr):
+		status = fmt.Sprintf("FAILED (%s)", exitErr.ProcessState)
+	default:
+		return quote0.TextRequest{}, fmt.Errorf("monitor: %w", err)
+	}
+	req := m.template
+	req.Message = monitorMessage(out.Bytes(), m.lines, m.head, status)
+	if status != "" {
+		icon, err := quote0.IconBase64(quote0.IconWarning)
+		if err != nil {
+			return quote0.TextRequest{}, err
+		}
+		req.Icon = icon
+	}
+	return req, nil
+}
+
+// monitorMessage fits command output into a message: lines are sanitized (see
+// quote0.SanitizeLine), blank ones dropped, and the first or last n kept, each cut to a
+// message line. status, when set, takes the first line.
+func monitorMessage(output []byte, n int, head bool, status string) string {
+	var kept []string
+	for _, line := range strings.Split(string(output), "\n") {
+		if line = quote0.SanitizeLine(line); line != "" {
+			kept = append(kept, line)
+		}
+	}
+	if status != "" {
+		n--
+	}
+	if len(kept) > n {
+		if head {
+			kept = kept[:n]
+		} else {
+			kept = kept[len(kept)-n:]
+		}
+	}
+	if status != "" {
+		kept = append([]string{status}, kept...)
+	}
+	if len(kept) == 0 {
+		return "(no output)"
+	}
+	for i, line := range kept {
+		kept[i] = quote0.Truncate(line, quote0.MaxMessageLineRunes)
+	}
+	return quote0.Truncate(strings.Join(kept, "\n"), quote0.MaxMessageRunes)
+}
+
+// capWriter keeps at most max bytes written to it: the first ones with keepHead, otherwise
+// the last ones. Writes never fail, so the command is not cut off by a full pipe.
+type capWriter struct {
+	buf      bytes.Buffer
+	max      int
+	keepHead bool
+}
+
+func (w *capWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	if w.keepHead {
+		if room := w.max - w.buf.Len(); room < len(p) {
+			p = p[:room]
+		}
+		w.buf.Write(p)
+		return n, nil
+	}
+	if len(p) >= w.max {
+		w.buf.Reset()
+		w.buf.Write(p[len(p)-w.max:])
+		return n, nil
+	}
+	if over := w.buf.Len() + len(p) - w.max; over > 0 {
+		w.buf.Next(over)
+	}
+	w.buf.Write(p)
+	return n, nil
+}
+
+func (w *capWriter) Bytes() []byte { return w.buf.Bytes() }
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+func needShell(t *testing.T) {
+	t.Helper()
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh")
+	}
+}
+
+func TestMonitor_LastLines(t *testing.T) {
+	needShell(t)
+	c, api, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D1"})
+	script := `printf 'one\n\ntwo\n\033[31mthree\033[0m\tred\nfour\n'; echo oops >&2`
+	if code := c.run([]string{"monitor", "-title", "Backups", "--", "sh", "-c", script}); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	body := api.body(0)
+	if body["title"] != "Backups" || body["message"] != "two\nthree red\nfour" || body["icon"] != nil {
+		t.Fatalf("body %v", body)
+	}
+	if !strings.Contains(stderr.String(), "oops") {
+		t.Fatalf("command stderr not passed through: %q", stderr)
+	}
+}
+
+func TestMonitor_Failure(t *testing.T) {
+	needShell(t)
+	c, api, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D1"})
+	if code := c.run([]string{"monitor", "-head", "--", "sh", "-c", "echo a; echo b; echo c; exit 2"}); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	body := api.body(0)
+	if body["message"] != "FAILED (exit 2)\na\nb" || body["icon"] == nil {
+		t.Fatalf("body %v", body)
+	}
+	if !strings.HasPrefix(body["title"].(string), "sh -c echo a;") {
+		t.Fatalf("default title %v", body["title"])
+	}
+}
+
+func TestMonitor_Timeout(t *testing.T) {
+	needShell(t)
+	c, api, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D1"})
+	start := time.Now()
+	if code := c.run([]string{"monitor", "-command-timeout", "200ms", "--", "sleep", "10"}); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Fatal("command was not killed")
+	}
+	if got := api.body(0)["message"]; got != "FAILED (timed out after 200ms)" {
+		t.Fatalf("message %v", got)
+	}
+}
+
+func TestMonitor_IfChanged(t *testing.T) {
+	needShell(t)
+	c, api, stdout, stderr := newEveryCLI(t, 3, nil)
+	args := []string{"monitor", "-every", "1m", "-if-changed", "-state-dir", t.TempDir(), "--", "echo", "same"}
+	if code := c.run(args); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if api.count() != 1 || !strings.HasSuffix(stdout.String(), "Stopped: 1 sent, 2 skipped, 0 failed\n") {
+		t.Fatalf("%d requests, output %q", api.count(), stdout)
+	}
+}
+
+func TestMonitor_Errors(t *testing.T) {
+	c, api, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D1"})
+	if code := c.run([]string{"monitor", "-title", "x"}); code != exitUsage {
+		t.Fatalf("no command: exit %d", code)
+	}
+	if code := c.run([]string{"monitor", "--", "quote0-no-such-command"}); code == 0 || api.count() != 0 {
+		t.Fatalf("missing command: exit %d, %d requests", code, api.count())
+	}
+	if !strings.Contains(stderr.String(), "quote0-no-such-command") {
+		t.Fatalf("stderr %q", stderr)
+	}
+}
+
+func TestMonitorMessage(t *testing.T) {
+	long := strings.Repeat("x", 50)
+	cases := []struct {
+		out    string
+		n      int
+		head   bool
+		status string
+		want   string
+	}{
+		{"", 3, false, "", "(no output)"},
+		{"a\nb\nc\nd", 2, true, "", "a\nb"},
+		{"a\nb\nc\nd", 1, false, "FAILED (exit 1)", "FAILED (exit 1)"},
+		{long, 3, false, "", strings.Repeat("x", 39) + "…"},
+	}
+	for _, tc := range cases {
+		if got := monitorMessage([]byte(tc.out), tc.n, tc.head, tc.status); got != tc.want {
+			t.Errorf("%q n=%d head=%v: %q, want %q", tc.out, tc.n, tc.head, got, tc.want)
+		}
+	}
+}
+
+func TestCapWriter(t *testing.T) {
+	tail := &capWriter{max: 5}
+	for _, s := range []string{"abc", "defg", "h"} {
+		tail.Write([]byte(s))
+	}
+	if got := string(tail.Bytes()); got != "defgh" {
+		t.Fatalf("tail %q", got)
+	}
+	tail.Write([]byte("0123456789"))
+	if got := string(tail.Bytes()); got != "56789" {
+		t.Fatalf("tail after a long write %q", got)
+	}
+	head := &capWriter{max: 5, keepHead: true}
+	for _, s := range []string{"abc", "defg", "h"} {
+		if n, err := head.Write([]byte(s)); n != len(s) || err != nil {
+			t.Fatalf("write %q: %d %v", s, n, err)
+		}
+	}
+	if got := string(head.Bytes()); got != "abcde" {
+		t.Fatalf("head %q", got)
+	}
+}
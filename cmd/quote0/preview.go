@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+
+	"github.com/1set/quote0"
+)
+
+// runPreview renders `text` or `image` flags locally and writes the PNG instead of sending it.
+// The common flags are accepted so a real command line can be previewed unchanged, but no
+// token or network access is needed.
+func (c *cli) runPreview(args []string) error {
+	if len(args) < 1 {
+		return usagef("preview needs a kind: quote0 preview text|image [flags]")
+	}
+	fs, cf := c.newFlagSet("preview " + args[0])
+	out := fs.String("out", "preview.png", "Output PNG path, or - for stdout")
+
+	var render func() (image.Image, error)
+	switch args[0] {
+	case "text":
+		tf := addTextFlags(fs)
+		render = func() (image.Image, error) {
+			req, err := tf.request(c, cf)
+			if err != nil {
+				return nil, err
+			}
+			if err := tf.fetchIcon(c.context(), nil, &req); err != nil {
+				return nil, err
+			}
+			return quote0.PreviewText(req)
+		}
+	case "image":
+		imf := addImageFlags(fs)
+		render = func() (image.Image, error) {
+			req, err := imf.request(c.stdin)
+			if err != nil {
+				return nil, err
+			}
+			return quote0.PreviewImage(req)
+		}
+	default:
+		return usagef("unknown preview kind %q (want text or image)", args[0])
+	}
+	if err := c.parseFlags(fs, args[1:]); err != nil {
+		return err
+	}
+
+	img, err := render()
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return err
+	}
+	if *out == "-" {
+		_, err = c.stdout.Write(buf.Bytes())
+		return err
+	}
+	if err := os.WriteFile(*out, buf.Bytes(), 0o644); err != nil {
+		return err
+	}
+	fmt.Fprintf(c.stdout, "Preview written to %s\n", *out)
+	return nil
+}
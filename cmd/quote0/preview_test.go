@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/1set/quote0"
+)
+
+func TestPreviewText_Stdout(t *testing.T) {
+	// No token or device in the environment: preview must not need them.
+	c, api, stdout, stderr := newTestCLI(t, nil)
+	if code := c.run([]string{"preview", "text", "-title", "Hello", "-message", "World", "-out", "-"}); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	img, err := png.Decode(bytes.NewReader(stdout.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != quote0.ScreenWidth || b.Dy() != quote0.ScreenHeight {
+		t.Fatalf("size %v", b)
+	}
+	if len(api.bodies) != 0 {
+		t.Fatal("preview must not send")
+	}
+}
+
+func TestPreviewImage_File(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "in.png")
+	data, err := quote0.NewCanvas().PNG()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(src, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "out.png")
+	c, _, stdout, stderr := newTestCLI(t, nil)
+	if code := c.run([]string{"preview", "image", "-image-file", src, "-dither-type", "ordered", "-out", out}); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if _, err := os.Stat(out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(stdout.String(), "Preview written to "+out) {
+		t.Fatalf("stdout %q", stdout)
+	}
+}
+
+func TestPreview_Errors(t *testing.T) {
+	dir := t.TempDir()
+	small := filepath.Join(dir, "small.png")
+	data, _ := quote0.NewCanvasSize(20, 10).PNG()
+	_ = os.WriteFile(small, data, 0o644)
+
+	for _, tc := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"preview"}, "needs a kind"},
+		{[]string{"preview", "video"}, "unknown preview kind"},
+		{[]string{"preview", "image", "-out", "-"}, "provide -image or -image-file"},
+		{[]string{"preview", "image", "-image-file", small, "-out", "-"}, "296x152 pixels: got 20x10"},
+		{[]string{"preview", "image", "-image-file", small, "-image", "x", "-out", "-"}, "not both"},
+		{[]string{"preview", "text", "-icon", "!!", "-out", "-"}, "not a valid PNG"},
+	} {
+		c, _, _, stderr := newTestCLI(t, nil)
+		if code := c.run(tc.args); code == 0 || !strings.Contains(stderr.String(), tc.want) {
+			t.Errorf("%v: exit %d, stderr %q", tc.args, code, stderr)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/1set/quote0"
+)
+
+// timedAPI records when each request arrives.
+type timedAPI struct {
+	fakeAPI
+	mu    sync.Mutex
+	times []time.Time
+}
+
+func (a *timedAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a.mu.Lock()
+	a.times = append(a.times, time.Now())
+	a.mu.Unlock()
+	a.fakeAPI.ServeHTTP(w, r)
+}
+
+// minGap is the shortest interval between consecutive requests.
+func (a *timedAPI) minGap() time.Duration {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	gap := time.Duration(1<<63 - 1)
+	for i := 1; i < len(a.times); i++ {
+		if d := a.times[i].Sub(a.times[i-1]); d < gap {
+			gap = d
+		}
+	}
+	return gap
+}
+
+// runRated sends to three devices with -rate, using the CLI's own limiter.
+func runRated(t *testing.T, rate string) (*timedAPI, string) {
+	t.Helper()
+	api := &timedAPI{}
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+	var stdout, stderr bytes.Buffer
+	c := &cli{
+		stdin:         strings.NewReader(""),
+		stdout:        &stdout,
+		stderr:        &stderr,
+		getenv:        func(k string) string { return map[string]string{"QUOTE0_TOKEN": "tok"}[k] },
+		clientOptions: []quote0.ClientOption{quote0.WithBaseURL(srv.URL)},
+	}
+	if code := c.run([]string{"text", "-title", "t", "-device", "A,B,C", "-rate", rate}); code != 0 {
+		t.Fatalf("-rate %s: exit %d: %s", rate, code, stderr.String())
+	}
+	if api.count() != 3 {
+		t.Fatalf("-rate %s: %d requests", rate, api.count())
+	}
+	return api, stderr.String()
+}
+
+func TestRate_Spacing(t *testing.T) {
+	slow, stderr := runRated(t, "150ms")
+	if gap := slow.minGap(); gap < 140*time.Millisecond {
+		t.Fatalf("-rate 150ms: requests %v apart", gap)
+	}
+	if strings.Contains(stderr, "WARNING") {
+		t.Fatalf("unexpected warning: %q", stderr)
+	}
+
+	fast, stderr := runRated(t, "0")
+	if gap := fast.minGap(); gap >= 140*time.Millisecond {
+		t.Fatalf("-rate 0: requests %v apart", gap)
+	}
+	if !strings.Contains(stderr, "WARNING: -rate 0 disables client-side rate limiting") {
+		t.Fatalf("stderr %q", stderr)
+	}
+}
+
+func TestRate_ZeroWarnsOnce(t *testing.T) {
+	c, _, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+	fs, cf := c.newFlagSet("text")
+	if err := fs.Parse([]string{"-rate", "0"}); err != nil {
+		t.Fatal(err)
+	}
+	// Commands such as replay and -from-json build a client per request.
+	for i := 0; i < 3; i++ {
+		if _, err := c.buildClient(cf, "D"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := strings.Count(stderr.String(), "WARNING: -rate 0"); n != 1 {
+		t.Fatalf("warned %d times: %q", n, stderr)
+	}
+
+	dry, api, _, dryErr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+	if code := dry.run([]string{"text", "-title", "t", "-rate", "0", "-dry-run"}); code != 0 || api.count() != 0 {
+		t.Fatalf("dry run: exit %d, %d requests: %s", code, api.count(), dryErr)
+	}
+	if strings.Contains(dryErr.String(), "WARNING") {
+		t.Fatalf("dry run warned: %q", dryErr)
+	}
+}
+
+func TestRate_Negative(t *testing.T) {
+	c, api, _, _ := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+	if code := c.run([]string{"text", "-title", "t", "-rate", "-1s"}); code != exitUsage || api.count() != 0 {
+		t.Fatalf("exit %d", code)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/1set/quote0"
+)
+
+// contentFlags are the text/image flags that refresh rejects so it is not mistaken for `text`.
+var contentFlags = []string{
+	"title", "message", "signature", "title-file", "message-file", "signature-file",
+	"auto-signature", "signature-format", "signature-tz", "icon", "icon-file", "icon-url", "link",
+	"image", "image-file", "border", "dither-type", "dither-kernel", "fit", "bg", "refresh",
+}
+
+func (c *cli) runRefresh(args []string) error {
+	// Register the content flags only to report a helpful error instead of "flag provided but not defined".
+	fs, cf := c.newFlagSet("refresh")
+	for _, name := range contentFlags {
+		fs.String(name, "", "not accepted by refresh")
+	}
+	if err := c.parseFlags(fs, args); err != nil {
+		return err
+	}
+	var rejected string
+	fs.Visit(func(f *flag.Flag) {
+		if rejected == "" && isContentFlag(f.Name) {
+			rejected = f.Name
+		}
+	})
+	if rejected != "" {
+		return usagef("refresh does not accept -%s; use `quote0 text` or `quote0 image` to change content", rejected)
+	}
+	if fs.NArg() > 0 {
+		return usagef("refresh takes no arguments, got %q", fs.Arg(0))
+	}
+
+	client, err := c.newClient(cf)
+	if err != nil {
+		return err
+	}
+	if *cf.dryRun {
+		return c.dryRun(client.BuildText(quote0.TextRequest{RefreshNow: quote0.Bool(true)}))
+	}
+	ctx, cancel := c.commandContext(cf)
+	defer cancel()
+	resp, err := client.Refresh(ctx, "")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(c.stdout, "Refresh sent (code=%d message=%s)\n", resp.Code, resp.Message)
+	return nil
+}
+
+func isContentFlag(name string) bool {
+	for _, n := range contentFlags {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
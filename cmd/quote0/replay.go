@@ -0,0 +1,244 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/1set/quote0"
+)
+
+// captureMeta is the "capture" object -output adds to a saved request; the request loaders
+// ignore it, so a capture also works with -from-json.
+type captureMeta struct {
+	Time     time.Time `json:"time"`
+	Kind     string    `json:"kind"`
+	Device   string    `json:"device"`
+	Endpoint string    `json:"endpoint"`
+}
+
+func addOutputFlag(fs *flag.FlagSet) *string {
+	return fs.String("output", "", "After a successful send, save the request with its time, device, and endpoint to this file (resend with replay)")
+}
+
+// checkOutput rejects -output where nothing is sent.
+func checkOutput(output string, cf *commonFlags) error {
+	if output != "" && *cf.dryRun {
+		return usagef("-output records a sent request; -dry-run -json prints it without sending")
+	}
+	return nil
+}
+
+// capturePath is where the request for device goes: path itself, or with several devices,
+// path with -DEVICE before the extension.
+func capturePath(path, device string, several bool) string {
+	if !several {
+		return path
+	}
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "-" + fileSafe(device) + ext
+}
+
+// writeCapture saves the request out sent to device. A failure is a warning: the send
+// itself succeeded.
+func (c *cli) writeCapture(out outgoing, device string, several bool) {
+	path := capturePath(out.capture, device, several)
+	if err := c.saveCapture(out, device, path); err != nil {
+		fmt.Fprintf(c.stderr, "warning: -output %s: %v\n", path, err)
+	}
+}
+
+func (c *cli) saveCapture(out outgoing, device, path string) error {
+	req, err := out.build(device)
+	if err != nil {
+		return err
+	}
+	raw, err := json.Marshal(req.Payload)
+	if err != nil {
+		return err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return err
+	}
+	kind := "text"
+	if _, ok := req.Payload.(*quote0.ImageRequest); ok {
+		kind = "image"
+	}
+	meta := captureMeta{Time: c.clock().UTC(), Kind: kind, Device: req.DeviceID, Endpoint: req.URL}
+	if fields["capture"], err = json.Marshal(meta); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(fields, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// readCapture returns the kind of request saved in path: the capture's kind, or for a plain
+// saved request, image when it has an image field.
+func readCapture(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	var saved struct {
+		Capture   *captureMeta    `json:"capture"`
+		Image     json.RawMessage `json:"image"`
+		ImagePath json.RawMessage `json:"imagePath"`
+	}
+	if err := json.NewDecoder(bytes.NewReader(data)).Decode(&saved); err != nil {
+		return "", fmt.Errorf("%s: %w", path, err)
+	}
+	switch {
+	case saved.Capture != nil && (saved.Capture.Kind == "text" || saved.Capture.Kind == "image"):
+		return saved.Capture.Kind, nil
+	case saved.Capture != nil:
+		return "", fmt.Errorf("%s: unknown capture kind %q", path, saved.Capture.Kind)
+	case saved.Image != nil || saved.ImagePath != nil:
+		return "image", nil
+	default:
+		return "text", nil
+	}
+}
+
+// replayResult is the outcome of one file of `replay`.
+type replayResult struct {
+	File    string         `json:"file"`
+	Kind    string         `json:"kind,omitempty"`
+	Results []deviceResult `json:"results,omitempty"`
+	Error   string         `json:"error,omitempty"`
+}
+
+// runReplay sends requests captured with -output (or saved with -dry-run -json) again, in
+// order, through one client so the rate limiter spaces them. Each file goes to its saved
+// device unless -device names others. A file that fails does not stop the rest; the command
+// fails if any file did.
+func (c *cli) runReplay(args []string) error {
+	fs, cf := c.newFlagSet("replay")
+	sf := addSendFlags(fs)
+	if err := c.parseFlags(fs, args); err != nil {
+		return err
+	}
+	files := fs.Args()
+	if len(files) == 0 {
+		return usagef("replay needs one or more files captured with -output")
+	}
+	var client *quote0.Client
+	var err error
+	if cf.device.explicit {
+		client, _, err = c.newClientDevices(cf)
+	} else {
+		client, err = c.buildClient(cf, "")
+	}
+	if err != nil {
+		return err
+	}
+	ctx, cancel := c.commandContext(cf)
+	defer cancel()
+
+	var results []replayResult
+	var firstErr error
+	failed := 0
+	for _, path := range files {
+		r := replayResult{File: path}
+		kind, lines, err := c.replayFile(ctx, cf, sf, client, path)
+		r.Kind, r.Results = kind, lines
+		for _, l := range lines {
+			if err == nil && l.err != nil {
+				err = l.err
+			}
+		}
+		if err != nil {
+			failed++
+			r.Error = err.Error()
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%s: %w", path, err)
+			}
+		}
+		results = append(results, r)
+		if !*sf.asJSON {
+			c.printReplay(r, err)
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	if *sf.asJSON {
+		if err := c.printJSON(results); err != nil {
+			return err
+		}
+	}
+	switch {
+	case failed == 0:
+		return nil
+	case len(files) == 1:
+		return firstErr
+	default:
+		return fmt.Errorf("%d of %d files failed; first: %w", failed, len(files), firstErr)
+	}
+}
+
+// replayFile loads one file and sends it to its devices. With -dry-run it prints the
+// request instead.
+func (c *cli) replayFile(ctx context.Context, cf *commonFlags, sf *sendFlags, client *quote0.Client, path string) (string, []deviceResult, error) {
+	kind, err := readCapture(path)
+	if err != nil {
+		return "", nil, err
+	}
+	var out outgoing
+	var saved string
+	if kind == "image" {
+		req, err := quote0.LoadImageRequest(path)
+		if err != nil {
+			return kind, nil, err
+		}
+		out, saved = imageOutgoing("Image", client, req), req.DeviceID
+	} else {
+		req, err := quote0.LoadTextRequest(path)
+		if err != nil {
+			return kind, nil, err
+		}
+		out, saved = textOutgoing("Text", client, req), req.DeviceID
+	}
+	devices := cf.device.ids
+	if !cf.device.explicit {
+		if strings.TrimSpace(saved) == "" {
+			return kind, nil, usagef("%s has no deviceId; pass -device", path)
+		}
+		devices = []string{saved}
+	}
+	if *cf.dryRun {
+		fmt.Fprintf(c.stdout, "%s:\n", path)
+		return kind, nil, c.dryRunDevices(devices, sf, out.build)
+	}
+	lines, err := c.fanOut(ctx, cf, devices, out)
+	if lines == nil && err == nil {
+		err = errors.New("nothing sent")
+	}
+	return kind, lines, err
+}
+
+func (c *cli) printReplay(r replayResult, err error) {
+	if r.Results == nil {
+		if err != nil {
+			fmt.Fprintf(c.stdout, "%s: FAILED: %v\n", r.File, err)
+		}
+		return
+	}
+	kind := "Text"
+	if r.Kind == "image" {
+		kind = "Image"
+	}
+	for _, l := range r.Results {
+		fmt.Fprintf(c.stdout, "%s: %s: %s\n", r.File, l.Device, l.summary(kind))
+	}
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/1set/quote0"
+)
+
+// rawAPI records the exact bytes of every request body.
+type rawAPI struct {
+	mu     sync.Mutex
+	bodies [][]byte
+	fail   string // a deviceId answered with 404
+}
+
+func (f *rawAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	data, _ := io.ReadAll(r.Body)
+	f.mu.Lock()
+	f.bodies = append(f.bodies, data)
+	f.mu.Unlock()
+	if f.fail != "" && bytes.Contains(data, []byte(`"deviceId":"`+f.fail+`"`)) {
+		w.WriteHeader(http.StatusNotFound)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = io.WriteString(w, `{"code":0,"message":"ok"}`)
+}
+
+func newRawCLI(t *testing.T, env map[string]string) (*cli, *rawAPI, *bytes.Buffer, *bytes.Buffer) {
+	t.Helper()
+	c, _, stdout, stderr := newTestCLI(t, env)
+	api := &rawAPI{}
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+	c.clientOptions = []quote0.ClientOption{quote0.WithBaseURL(srv.URL), quote0.WithRateLimiter(nil)}
+	c.now = func() time.Time { return time.Date(2025, 11, 8, 14, 0, 0, 0, time.UTC) }
+	return c, api, stdout, stderr
+}
+
+func TestOutputReplay_RoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	icon, _ := quote0.NewCanvasSize(quote0.IconSize, quote0.IconSize).PNG()
+	iconPath := filepath.Join(dir, "icon.png")
+	screen, _ := quote0.NewCanvas().PNG()
+	screenPath := filepath.Join(dir, "screen.png")
+	for path, data := range map[string][]byte{iconPath: icon, screenPath: screen} {
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	textCapture := filepath.Join(dir, "text.json")
+	imageCapture := filepath.Join(dir, "image.json")
+	env := map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D1"}
+
+	c, api, _, stderr := newRawCLI(t, env)
+	if code := c.run([]string{"text", "-title", "Standup", "-message", "Room 4", "-icon-file", iconPath, "-output", textCapture}); code != 0 {
+		t.Fatalf("text: exit %d: %s", code, stderr)
+	}
+	if code := c.run([]string{"image", "-image-file", screenPath, "-dither-type", "NONE", "-output", imageCapture}); code != 0 {
+		t.Fatalf("image: exit %d: %s", code, stderr)
+	}
+	sent := api.bodies
+
+	var saved struct {
+		Title   string      `json:"title"`
+		Capture captureMeta `json:"capture"`
+	}
+	data, err := os.ReadFile(textCapture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+	if saved.Title != "Standup" || saved.Capture.Kind != "text" || saved.Capture.Device != "D1" ||
+		!strings.HasSuffix(saved.Capture.Endpoint, "/api/open/text") || !saved.Capture.Time.Equal(c.now()) {
+		t.Fatalf("capture %s", data)
+	}
+
+	c, api, stdout, stderr := newRawCLI(t, map[string]string{"QUOTE0_TOKEN": "tok"})
+	if code := c.run([]string{"replay", textCapture, imageCapture}); code != 0 {
+		t.Fatalf("replay: exit %d: %s", code, stderr)
+	}
+	if len(api.bodies) != 2 {
+		t.Fatalf("%d requests", len(api.bodies))
+	}
+	for i := range sent {
+		if !bytes.Equal(api.bodies[i], sent[i]) {
+			t.Errorf("replayed body %d differs:\n%s\n%s", i, api.bodies[i], sent[i])
+		}
+	}
+	for _, want := range []string{textCapture + ": D1: Text sent", imageCapture + ": D1: Image sent"} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("stdout lacks %q:\n%s", want, stdout)
+		}
+	}
+
+	// -from-json accepts a capture too.
+	c, api, _, stderr = newRawCLI(t, env)
+	if code := c.run([]string{"text", "-from-json", textCapture}); code != 0 || !bytes.Equal(api.bodies[0], sent[0]) {
+		t.Fatalf("from-json: exit %d: %s", code, stderr)
+	}
+}
+
+func TestReplay_OtherDevice(t *testing.T) {
+	capture := filepath.Join(t.TempDir(), "text.json")
+	c, _, _, stderr := newRawCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D1"})
+	if code := c.run([]string{"text", "-title", "x", "-output", capture}); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	c, api, _, stderr := newRawCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "ENV"})
+	if code := c.run([]string{"replay", "-device", "D2,D3", capture}); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if len(api.bodies) != 2 || !bytes.Contains(api.bodies[0], []byte(`"deviceId":"D2"`)) || !bytes.Contains(api.bodies[1], []byte(`"deviceId":"D3"`)) {
+		t.Fatalf("bodies %q", api.bodies)
+	}
+}
+
+func TestOutput_SeveralDevices(t *testing.T) {
+	dir := t.TempDir()
+	c, api, _, _ := newRawCLI(t, map[string]string{"QUOTE0_TOKEN": "tok"})
+	api.fail = "BAD"
+	if code := c.run([]string{"text", "-title", "x", "-device", "D1,BAD", "-output", filepath.Join(dir, "sent.json")}); code != exitDevice {
+		t.Fatalf("exit %d", code)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "sent-D1.json")); err != nil {
+		t.Error(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "sent-BAD.json")); !os.IsNotExist(err) {
+		t.Errorf("captured a failed send: %v", err)
+	}
+}
+
+func TestReplay_PerFileResults(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, body string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	good := write("good.json", `{"deviceId":"D1","title":"one"}`)
+	bad := write("bad.json", `{"deviceId":"BAD","title":"two"}`)
+	typo := write("typo.json", `{"deviceId":"D1","titel":"three"}`)
+	last := write("last.json", `{"deviceId":"D1","title":"four"}`)
+
+	c, api, stdout, _ := newRawCLI(t, map[string]string{"QUOTE0_TOKEN": "tok"})
+	api.fail = "BAD"
+	if code := c.run([]string{"replay", "-json", good, bad, typo, last}); code != exitDevice {
+		t.Fatalf("exit %d", code)
+	}
+	if len(api.bodies) != 3 || !bytes.Contains(api.bodies[2], []byte(`"four"`)) {
+		t.Fatalf("bodies %q", api.bodies)
+	}
+	var results []replayResult
+	if err := json.Unmarshal(stdout.Bytes(), &results); err != nil {
+		t.Fatalf("%v: %s", err, stdout)
+	}
+	if len(results) != 4 || results[0].Error != "" || results[1].Error == "" ||
+		!strings.Contains(results[2].Error, "unknown field") || results[3].Error != "" {
+		t.Fatalf("results %+v", results)
+	}
+}
+
+func TestOutput_Usage(t *testing.T) {
+	c, _, _, _ := newRawCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D1"})
+	if code := c.run([]string{"text", "-title", "x", "-dry-run", "-output", "x.json"}); code != exitUsage {
+		t.Fatalf("-dry-run -output: exit %d", code)
+	}
+	if code := c.run([]string{"replay"}); code != exitUsage {
+		t.Fatalf("replay without files: exit %d", code)
+	}
+	path := filepath.Join(t.TempDir(), "nodevice.json")
+	if err := os.WriteFile(path, []byte(`{"title":"x"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if code := c.run([]string{"replay", path}); code != exitUsage {
+		t.Fatalf("no deviceId: exit %d", code)
+	}
+}
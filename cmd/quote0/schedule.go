@@ -0,0 +1,113 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/1set/quote0"
+)
+
+// scheduleFlags delay a `text` or `image` send to a later time.
+type scheduleFlags struct {
+	at        *string
+	in        *time.Duration
+	allowPast *bool
+}
+
+func addScheduleFlags(fs *flag.FlagSet) *scheduleFlags {
+	return &scheduleFlags{
+		at:        fs.String("at", "", "Send at this time: RFC 3339, or HH:MM for its next occurrence in local time"),
+		in:        fs.Duration("in", 0, "Send after this long (e.g. 90m)"),
+		allowPast: fs.Bool("allow-past", false, "Send at once when -at is in the past instead of failing"),
+	}
+}
+
+// target resolves -at or -in against now; the zero time means send at once.
+func (f *scheduleFlags) target(now time.Time) (time.Time, error) {
+	if *f.at != "" && *f.in != 0 {
+		return time.Time{}, usagef("provide either -at or -in, not both")
+	}
+	if *f.in < 0 {
+		return time.Time{}, usagef("-in must not be negative")
+	}
+	if *f.in > 0 {
+		return now.Add(*f.in), nil
+	}
+	if *f.at == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, *f.at); err == nil {
+		if !t.After(now) && !*f.allowPast {
+			return time.Time{}, usagef("-at %s is in the past (use -allow-past to send at once)", *f.at)
+		}
+		return t, nil
+	}
+	hm, err := time.Parse("15:04", *f.at)
+	if err != nil {
+		return time.Time{}, usagef("-at %q: want RFC 3339 (2006-01-02T15:04:05Z07:00) or HH:MM", *f.at)
+	}
+	t := time.Date(now.Year(), now.Month(), now.Day(), hm.Hour(), hm.Minute(), 0, 0, now.Location())
+	if !t.After(now) {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// countdownStep is how long to sleep before the next -v countdown line.
+func countdownStep(left time.Duration) time.Duration {
+	switch {
+	case left > time.Hour:
+		return 10 * time.Minute
+	case left > time.Minute:
+		return time.Minute
+	case left > 10*time.Second:
+		return 10 * time.Second
+	default:
+		return left
+	}
+}
+
+// timer returns a channel that fires after d; tests replace it through c.after.
+func (c *cli) timer(d time.Duration) <-chan time.Time {
+	if c.after == nil {
+		return time.After(d)
+	}
+	return c.after(d)
+}
+
+// waitToSend checks every device's request, so bad content fails now rather than at the target
+// time, then sleeps until target. It runs before commandContext: -timeout caps the send, not
+// the wait. With -v a countdown is printed; Ctrl-C sends nothing.
+func (c *cli) waitToSend(cf *commonFlags, target time.Time, devices []string, build func(device string) (*quote0.PreparedRequest, error)) error {
+	if target.IsZero() {
+		return nil
+	}
+	for _, id := range devices {
+		if _, err := build(id); err != nil {
+			return err
+		}
+	}
+	ctx, stop := signal.NotifyContext(c.context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	verbose := *cf.verbose > 0 || *cf.debug
+	for {
+		left := target.Sub(c.clock())
+		if left <= 0 {
+			return nil
+		}
+		step := left
+		if verbose {
+			fmt.Fprintf(c.stderr, "Sending at %s (in %s)\n", target.Format("2006-01-02 15:04:05"), left.Round(time.Second))
+			step = countdownStep(left)
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("not sent: %w", ctx.Err())
+		case <-c.timer(step):
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeClock drives c.now and c.after: every timer fires at once and moves the clock forward.
+type fakeClock struct {
+	t      time.Time
+	sleeps []time.Duration
+}
+
+func (f *fakeClock) now() time.Time { return f.t }
+
+func (f *fakeClock) after(d time.Duration) <-chan time.Time {
+	f.sleeps = append(f.sleeps, d)
+	f.t = f.t.Add(d)
+	ch := make(chan time.Time, 1)
+	ch <- f.t
+	return ch
+}
+
+func newScheduleCLI(t *testing.T, start time.Time) (*cli, *fakeAPI, *fakeClock, *strings.Builder) {
+	c, api, _, _ := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D1"})
+	clk := &fakeClock{t: start}
+	c.now, c.after = clk.now, clk.after
+	var log strings.Builder
+	c.stderr = &log
+	return c, api, clk, &log
+}
+
+func TestSchedule_In(t *testing.T) {
+	start := time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC)
+	c, api, clk, log := newScheduleCLI(t, start)
+	if code := c.run([]string{"text", "-title", "x", "-in", "90m"}); code != 0 {
+		t.Fatalf("exit %d: %s", code, log)
+	}
+	if api.count() != 1 || !clk.t.Equal(start.Add(90*time.Minute)) {
+		t.Fatalf("%d requests, clock %s", api.count(), clk.t)
+	}
+	if len(clk.sleeps) != 1 || log.Len() != 0 {
+		t.Fatalf("quiet wait slept %v, logged %q", clk.sleeps, log)
+	}
+}
+
+func TestSchedule_AtNextOccurrenceWithCountdown(t *testing.T) {
+	start := time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+	c, api, clk, log := newScheduleCLI(t, start)
+	// 09:00 has passed today, so the send waits for tomorrow.
+	if code := c.run([]string{"text", "-title", "x", "-at", "09:00", "-v"}); code != 0 {
+		t.Fatalf("exit %d: %s", code, log)
+	}
+	if want := time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC); !clk.t.Equal(want) || api.count() != 1 {
+		t.Fatalf("sent at %s (%d requests), want %s", clk.t, api.count(), want)
+	}
+	if !strings.Contains(log.String(), "Sending at 2025-06-02 09:00:00 (in 23h0m0s)") || len(clk.sleeps) < 3 {
+		t.Fatalf("countdown %q after %d sleeps", log, len(clk.sleeps))
+	}
+}
+
+func TestSchedule_Errors(t *testing.T) {
+	start := time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+	for _, args := range [][]string{
+		{"-at", "09:00", "-in", "1m"},
+		{"-in", "-1m"},
+		{"-at", "tomorrow"},
+		{"-at", "2025-06-01T09:00:00Z"},
+	} {
+		c, api, clk, log := newScheduleCLI(t, start)
+		if code := c.run(append([]string{"text", "-title", "x"}, args...)); code != exitUsage {
+			t.Errorf("%v: exit %d: %s", args, code, log)
+		}
+		if api.count() != 0 || len(clk.sleeps) != 0 {
+			t.Errorf("%v: sent or waited", args)
+		}
+	}
+}
+
+func TestSchedule_AllowPast(t *testing.T) {
+	c, api, clk, log := newScheduleCLI(t, time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC))
+	if code := c.run([]string{"text", "-title", "x", "-at", "2025-06-01T09:00:00Z", "-allow-past"}); code != 0 {
+		t.Fatalf("exit %d: %s", code, log)
+	}
+	if api.count() != 1 || len(clk.sleeps) != 0 {
+		t.Fatalf("%d requests after %v", api.count(), clk.sleeps)
+	}
+}
+
+func TestSchedule_ValidatesBeforeWaiting(t *testing.T) {
+	c, api, clk, log := newScheduleCLI(t, time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC))
+	if code := c.run([]string{"text", "-title", "\xff", "-in", "1h"}); code != exitValidation {
+		t.Fatalf("exit %d: %s", code, log)
+	}
+	if api.count() != 0 || len(clk.sleeps) != 0 {
+		t.Fatalf("sent or waited before failing: %v", clk.sleeps)
+	}
+}
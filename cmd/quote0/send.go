@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"image/png"
+	"net/http"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/1set/quote0"
+)
+
+// runSend sends whatever it is given: a PNG or JPEG file (or stdin, with -) goes out as an
+// image, fitted to the screen when its size differs; anything else is text split into title
+// and message by quote0.SplitTitleMessage. -as text|image overrides the sniffing.
+func (c *cli) runSend(args []string) error {
+	fs, cf := c.newFlagSet("send")
+	sf := addSendFlags(fs)
+	as := fs.String("as", "", "Send the input as text or image instead of detecting it")
+	flags, words := splitFlags(fs, args)
+	if err := c.parseFlags(fs, flags); err != nil {
+		return err
+	}
+	if len(words) == 0 {
+		return usagef("send needs a file, - for stdin, or the text to show")
+	}
+	kind := strings.ToLower(strings.TrimSpace(*as))
+	switch kind {
+	case "", "text", "image":
+	default:
+		return usagef("invalid -as %q (want text or image)", *as)
+	}
+	in, err := c.sendInput(words, kind)
+	if err != nil {
+		return err
+	}
+	client, devices, err := c.newClientDevices(cf)
+	if err != nil {
+		return err
+	}
+	var out outgoing
+	if in.image != nil {
+		data, err := fitToScreen(in.image)
+		if err != nil {
+			return err
+		}
+		out = imageOutgoing("Image", client, quote0.ImageRequest{RefreshNow: quote0.Bool(true), ImageBytes: data})
+	} else {
+		title, message := quote0.SplitTitleMessage(in.text)
+		if title == "" && message == "" {
+			return usagef("nothing to send: the text is blank")
+		}
+		out = textOutgoing("Text", client, quote0.TextRequest{RefreshNow: quote0.Bool(true), Title: title, Message: message})
+	}
+	if *cf.verbose > 0 {
+		fmt.Fprintf(c.stderr, "send: %s as %s\n", in.source, strings.ToLower(out.kind))
+	}
+	if *cf.dryRun {
+		return c.dryRunDevices(devices, sf, out.build)
+	}
+	ctx, cancel := c.commandContext(cf)
+	defer cancel()
+	return c.deliver(ctx, cf, devices, sf, out)
+}
+
+// sendInput is what `send` resolved its arguments to: image data or text, and where it
+// came from for -v.
+type sendInput struct {
+	image  []byte
+	text   string
+	source string
+}
+
+// sendInput reads the arguments of `send`. A single argument naming a file, or - for stdin,
+// is read and sniffed by its magic bytes; otherwise the words are the text itself.
+func (c *cli) sendInput(words []string, kind string) (sendInput, error) {
+	arg := words[0]
+	var data []byte
+	source := ""
+	switch {
+	case len(words) == 1 && arg == "-":
+		d, err := readStdinBytes(c.stdin, maxImageStdin)
+		if err != nil {
+			return sendInput{}, usagef("send -: %v", err)
+		}
+		data, source = d, "stdin"
+	case len(words) == 1 && isRegularFile(arg):
+		d, err := os.ReadFile(arg)
+		if err != nil {
+			return sendInput{}, err
+		}
+		data, source = d, arg
+	case kind == "image":
+		return sendInput{}, usagef("-as image needs a file or - for stdin, got %q", strings.Join(words, " "))
+	default:
+		return sendInput{text: strings.Join(words, " "), source: "argument"}, nil
+	}
+	if kind == "image" || kind == "" && isImageData(data) {
+		return sendInput{image: data, source: source}, nil
+	}
+	if !utf8.Valid(data) || bytes.IndexByte(data, 0) >= 0 {
+		return sendInput{}, usagef("%s is neither a PNG or JPEG image nor UTF-8 text", source)
+	}
+	if len(data) > maxTextFile {
+		return sendInput{}, usagef("%s: text is larger than %d bytes", source, maxTextFile)
+	}
+	return sendInput{text: string(data), source: source}, nil
+}
+
+func isRegularFile(path string) bool {
+	st, err := os.Stat(path)
+	return err == nil && st.Mode().IsRegular()
+}
+
+// isImageData reports whether data starts with the magic bytes of an image format. Formats
+// other than PNG and JPEG still count, so they fail with a clear unsupported-format error
+// instead of being sent as text.
+func isImageData(data []byte) bool {
+	return strings.HasPrefix(http.DetectContentType(data), "image/")
+}
+
+// fitToScreen returns data unchanged when it is already a 296x152 image, and otherwise the
+// image fitted with -fit contain on white, as `image -fit contain` would.
+func fitToScreen(data []byte) ([]byte, error) {
+	src, _, err := quote0.DecodeImage(data)
+	if err != nil {
+		return nil, err
+	}
+	if b := src.Bounds(); b.Dx() == quote0.ScreenWidth && b.Dy() == quote0.ScreenHeight {
+		return data, nil
+	}
+	img, err := quote0.ProcessImage(src, quote0.WithFit(quote0.FitContain), quote0.WithBackground(quote0.White))
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// splitFlags separates the flags of fs from the other arguments so flags may follow them
+// (quote0 send photo.png -device X). A flag that takes a value keeps the next argument
+// unless it is written -flag=value; everything after -- is an argument.
+func splitFlags(fs *flag.FlagSet, args []string) (flags, rest []string) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			return flags, append(rest, args[i+1:]...)
+		case len(arg) < 2 || arg[0] != '-':
+			rest = append(rest, arg)
+			continue
+		}
+		flags = append(flags, arg)
+		name := strings.TrimLeft(arg, "-")
+		if strings.Contains(name, "=") {
+			continue
+		}
+		f := fs.Lookup(name)
+		if f == nil {
+			continue // Parse reports it
+		}
+		if b, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && b.IsBoolFlag() {
+			continue
+		}
+		if i+1 < len(args) {
+			i++
+			flags = append(flags, args[i])
+		}
+	}
+	return flags, rest
+}
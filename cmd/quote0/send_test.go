@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/1set/quote0"
+)
+
+func TestSend_ImageFile(t *testing.T) {
+	data, _ := quote0.NewCanvas().PNG()
+	path := filepath.Join(t.TempDir(), "screen.dat") // detected by content, not extension
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c, api, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok"})
+	if code := c.run([]string{"send", path, "-device", "D1"}); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if api.paths[0] != "/api/open/image" || api.body(0)["deviceId"] != "D1" {
+		t.Fatalf("request %s %v", api.paths[0], api.body(0))
+	}
+	if api.body(0)["image"] != base64.StdEncoding.EncodeToString(data) {
+		t.Error("a 296x152 PNG should be sent unchanged")
+	}
+}
+
+func TestSend_PhotoIsFitted(t *testing.T) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, 600, 400)), nil); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "photo.jpg")
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c, api, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+	if code := c.run([]string{"send", path}); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	raw, _ := base64.StdEncoding.DecodeString(api.body(0)["image"].(string))
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(raw))
+	if err != nil || format != "png" || cfg.Width != quote0.ScreenWidth || cfg.Height != quote0.ScreenHeight {
+		t.Fatalf("sent %s %dx%d (%v)", format, cfg.Width, cfg.Height, err)
+	}
+}
+
+func TestSend_Text(t *testing.T) {
+	c, api, stdout, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+	if code := c.run([]string{"send", "Hello", "there"}); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if api.paths[0] != "/api/open/text" || api.body(0)["title"] != "Hello there" {
+		t.Fatalf("request %s %v", api.paths[0], api.body(0))
+	}
+	if !strings.Contains(stdout.String(), "Text sent") {
+		t.Errorf("stdout %q", stdout)
+	}
+
+	// Text starting with a dash goes after --.
+	c, api, _, stderr = newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+	if code := c.run([]string{"send", "--", "-5°C outside"}); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if api.body(0)["title"] != "-5°C outside" {
+		t.Fatalf("body %v", api.body(0))
+	}
+}
+
+func TestSend_TextFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "note.txt")
+	if err := os.WriteFile(path, []byte("Standup\nRoom 4, 10:00\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c, api, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+	if code := c.run([]string{"send", path}); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if b := api.body(0); b["title"] != "Standup" || b["message"] != "Room 4, 10:00" {
+		t.Fatalf("body %v", b)
+	}
+}
+
+func TestSend_Stdin(t *testing.T) {
+	c, api, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+	c.stdin = strings.NewReader("hi\n")
+	if code := c.run([]string{"send", "-"}); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if api.paths[0] != "/api/open/text" || api.body(0)["title"] != "hi" {
+		t.Fatalf("request %s %v", api.paths[0], api.body(0))
+	}
+
+	data, _ := quote0.NewCanvas().PNG()
+	c, api, _, stderr = newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+	c.stdin = bytes.NewReader(data)
+	if code := c.run([]string{"send", "-"}); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if api.paths[0] != "/api/open/image" {
+		t.Fatalf("request %s", api.paths[0])
+	}
+}
+
+func TestSend_As(t *testing.T) {
+	dir := t.TempDir()
+	text := filepath.Join(dir, "note.txt")
+	if err := os.WriteFile(text, []byte("plain words"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// -as text sends the contents of a text file, as detection would.
+	c, api, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+	if code := c.run([]string{"send", "-as", "text", text}); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if api.body(0)["title"] != "plain words" {
+		t.Fatalf("body %v", api.body(0))
+	}
+
+	// -as image on a text file fails as an unsupported image rather than sending text.
+	c, api, _, _ = newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+	if code := c.run([]string{"send", "-as", "image", text}); code != exitValidation || api.count() != 0 {
+		t.Fatalf("exit %d, %d requests", code, api.count())
+	}
+
+	// -as image needs a file.
+	c, _, _, _ = newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+	if code := c.run([]string{"send", "-as", "image", "no such file"}); code != exitUsage {
+		t.Fatalf("exit %d", code)
+	}
+
+	// -as text on an image file is refused: PNG bytes are not text.
+	data, _ := quote0.NewCanvas().PNG()
+	png := filepath.Join(dir, "screen.png")
+	if err := os.WriteFile(png, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c, api, _, _ = newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+	if code := c.run([]string{"send", "-as", "text", png}); code != exitUsage || api.count() != 0 {
+		t.Fatalf("exit %d, %d requests", code, api.count())
+	}
+
+	c, _, _, _ = newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+	if code := c.run([]string{"send", "-as", "video", png}); code != exitUsage {
+		t.Fatalf("exit %d", code)
+	}
+}
+
+func TestSend_DryRunJSON(t *testing.T) {
+	c, api, stdout, stderr := newTestCLI(t, nil)
+	if code := c.run([]string{"send", "-dry-run", "-json", "-device", "D", "Build", "green"}); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if api.count() != 0 || !strings.Contains(stdout.String(), `"title": "Build green"`) {
+		t.Fatalf("%d requests, stdout %s", api.count(), stdout)
+	}
+}
+
+func TestSend_Errors(t *testing.T) {
+	c, _, _, _ := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+	if code := c.run([]string{"send"}); code != exitUsage {
+		t.Fatalf("no input: exit %d", code)
+	}
+	bin := filepath.Join(t.TempDir(), "blob")
+	if err := os.WriteFile(bin, []byte{0, 1, 2, 0xff}, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c, api, _, _ := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+	if code := c.run([]string{"send", bin}); code != exitUsage || api.count() != 0 {
+		t.Fatalf("binary file: exit %d", code)
+	}
+}
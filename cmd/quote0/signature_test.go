@@ -0,0 +1,59 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+	_ "time/tzdata" // -signature-tz tests must not depend on the host's zoneinfo
+)
+
+func TestSignatureFormat(t *testing.T) {
+	at := time.Date(2025, 11, 10, 9, 30, 5, 0, time.UTC)
+	for _, tc := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"-auto-signature"}, "2025-11-10 09:30:05"},
+		{[]string{"-signature-format", "{host}@01-02 15:04:05"}, "nas-01@11-10 09:30:05"},
+		{[]string{"-signature-format", "{user} 15:04 MST", "-signature-tz", "Asia/Tokyo"}, "ops 18:30 JST"},
+		{[]string{"-signature", "manual", "-signature-format", "{host}"}, "manual"},
+	} {
+		c, api, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D", "USER": "ops"})
+		c.now = func() time.Time { return at }
+		c.hostname = func() (string, error) { return "nas-01", nil }
+		if code := c.run(append([]string{"text"}, tc.args...)); code != 0 {
+			t.Fatalf("%v: exit %d: %s", tc.args, code, stderr)
+		}
+		if got := api.body(0)["signature"]; got != tc.want {
+			t.Errorf("%v: signature %q, want %q", tc.args, got, tc.want)
+		}
+	}
+}
+
+func TestSignatureFormat_Truncated(t *testing.T) {
+	for _, verbose := range []bool{false, true} {
+		c, api, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+		c.now = func() time.Time { return time.Date(2025, 11, 10, 9, 30, 0, 0, time.UTC) }
+		c.hostname = func() (string, error) { return "build-runner-eu-west-17", nil }
+		args := []string{"text", "-signature-format", "{host} Monday 15:04", "-signature-tz", "UTC"}
+		if verbose {
+			args = append(args, "-v")
+		}
+		if code := c.run(args); code != 0 {
+			t.Fatalf("exit %d: %s", code, stderr)
+		}
+		if got := api.body(0)["signature"]; got != "build-runner-eu-west-17 M…" {
+			t.Errorf("signature %q", got)
+		}
+		if warned := strings.Contains(stderr.String(), "auto signature truncated"); warned != verbose {
+			t.Errorf("verbose=%v: stderr %q", verbose, stderr)
+		}
+	}
+}
+
+func TestSignatureFormat_BadTZ(t *testing.T) {
+	c, api, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+	if code := c.run([]string{"text", "-auto-signature", "-signature-tz", "Mars/Olympus"}); code != exitUsage || api.count() != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+}
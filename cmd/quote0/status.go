@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/1set/quote0"
+	"github.com/1set/quote0/hoststatus"
+)
+
+// runStatus sends host vitals collected by the hoststatus package, once or every -every.
+func (c *cli) runStatus(args []string) error {
+	fs, cf := c.newFlagSet("status")
+	fieldList := fs.String("fields", "", "Comma-separated lines to show: host, ip, uptime, load, disk (default all)")
+	every := fs.Duration("every", 0, "Keep running and resend the status at this interval (default send once)")
+	disk := fs.String("disk", "/", "Filesystem to report disk usage for")
+	sf := addSendFlags(fs)
+	if err := c.parseFlags(fs, args); err != nil {
+		return err
+	}
+	fields, err := hoststatus.ParseFields(*fieldList)
+	if err != nil {
+		return usagef("-fields: %v", err)
+	}
+	if *every < 0 {
+		return usagef("-every must not be negative")
+	}
+	if *every > 0 && *cf.dryRun {
+		return usagef("-every cannot be combined with -dry-run")
+	}
+	client, devices, err := c.newClientDevices(cf)
+	if err != nil {
+		return err
+	}
+	collect := func() (hoststatus.Status, quote0.TextRequest) {
+		s := hoststatus.Collect(hoststatus.WithDiskPath(*disk))
+		return s, hoststatus.BuildText(s, fields...)
+	}
+
+	if *cf.dryRun {
+		s, req := collect()
+		if *sf.asJSON {
+			return c.printJSON(statusDump{Status: s, Title: req.Title, Message: req.Message, Signature: req.Signature})
+		}
+		for _, id := range devices {
+			req.DeviceID = id
+			if err := c.dryRun(client.BuildText(req)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	ctx, cancel := c.commandContext(cf)
+	defer cancel()
+	send := func(ctx context.Context) error {
+		_, req := collect()
+		return c.deliver(ctx, cf, devices, sf, textOutgoing("Status", client, req))
+	}
+	if *every == 0 {
+		return send(ctx)
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ticker := time.NewTicker(*every)
+	defer ticker.Stop()
+	for {
+		// A failed send is logged and retried on the next tick; only ctx ends the loop.
+		if err := send(ctx); err != nil && ctx.Err() == nil {
+			fmt.Fprintf(c.stderr, "%s status: %v\n", time.Now().Format("15:04:05"), err)
+		}
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.Canceled) {
+				return nil
+			}
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// statusDump is the -dry-run -json output: the raw snapshot and the lines it would display.
+type statusDump struct {
+	Status    hoststatus.Status `json:"status"`
+	Title     string            `json:"title"`
+	Message   string            `json:"message"`
+	Signature string            `json:"signature"`
+}
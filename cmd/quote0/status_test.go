@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestStatus(t *testing.T) {
+	c, api, stdout, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+	if code := c.run([]string{"status", "-fields", "host"}); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	body := api.body(0)
+	if body["title"] == nil || body["message"] != nil || body["signature"] == nil {
+		t.Fatalf("body %v", body)
+	}
+	if !strings.HasPrefix(stdout.String(), "Status sent (code=0") {
+		t.Fatalf("stdout %q", stdout)
+	}
+}
+
+func TestStatus_DryRunJSON(t *testing.T) {
+	c, api, stdout, stderr := newTestCLI(t, map[string]string{"QUOTE0_DEVICE": "D"})
+	if code := c.run([]string{"status", "-dry-run", "-json"}); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	var out statusDump
+	if err := json.Unmarshal(stdout.Bytes(), &out); err != nil {
+		t.Fatalf("%v: %q", err, stdout)
+	}
+	if api.count() != 0 || out.Status.CollectedAt.IsZero() || out.Signature == "" {
+		t.Fatalf("out %+v", out)
+	}
+}
+
+func TestStatus_Every(t *testing.T) {
+	c, api, _, _ := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+	stdout, stderr := &syncBuffer{}, &syncBuffer{}
+	c.stdout, c.stderr = stdout, stderr
+	ctx, cancel := context.WithCancel(context.Background())
+	c.ctx = ctx
+	done := make(chan int)
+	go func() { done <- c.run([]string{"status", "-every", "5ms"}) }()
+
+	waitFor(t, func() bool { return api.count() >= 2 })
+	cancel()
+	if code := <-done; code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+}
+
+func TestStatus_Usage(t *testing.T) {
+	for _, args := range [][]string{
+		{"status", "-fields", "host,cpu"},
+		{"status", "-every", "-1s"},
+		{"status", "-every", "1m", "-dry-run"},
+	} {
+		c, api, _, _ := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+		if code := c.run(args); code != exitUsage || api.count() != 0 {
+			t.Errorf("%v: exit %d", args, code)
+		}
+	}
+}
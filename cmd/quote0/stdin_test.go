@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/1set/quote0"
+)
+
+func TestImageFile_Stdin(t *testing.T) {
+	data, _ := quote0.NewCanvas().PNG()
+	c, api, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+	c.stdin = bytes.NewReader(data)
+	if code := c.run([]string{"image", "-image-file", "-"}); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if api.body(0)["image"] != base64.StdEncoding.EncodeToString(data) {
+		t.Fatalf("body %v", api.body(0))
+	}
+}
+
+func TestIconFile_Stdin(t *testing.T) {
+	icon, _ := quote0.NewCanvasSize(quote0.IconSize, quote0.IconSize).PNG()
+	c, api, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+	c.stdin = bytes.NewReader(icon)
+	if code := c.run([]string{"text", "-title", "x", "-icon-file", "-"}); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if api.body(0)["icon"] != base64.StdEncoding.EncodeToString(icon) {
+		t.Fatalf("body %v", api.body(0))
+	}
+
+	// The SDK validates piped icons like any other IconBytes.
+	big, _ := quote0.NewCanvas().PNG()
+	c, api, _, stderr = newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+	c.stdin = bytes.NewReader(big)
+	if code := c.run([]string{"text", "-icon-file", "-"}); code != exitValidation || api.count() != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+}
+
+func TestStdinFlags_Errors(t *testing.T) {
+	for _, tc := range []struct {
+		args  []string
+		stdin string
+		want  string
+	}{
+		{[]string{"image", "-image", "aGk=", "-image-file", "-"}, "x", "not both"},
+		{[]string{"image", "-image-file", "-"}, "", "stdin is empty"},
+		{[]string{"text", "-icon", "aGk=", "-icon-file", "-"}, "x", "not both"},
+		{[]string{"text", "-message-file", "-", "-icon-file", "-"}, "x", "only one flag can read stdin"},
+		{[]string{"watch", "-image-file", "-"}, "x", "cannot be watched"},
+	} {
+		c, api, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+		c.stdin = strings.NewReader(tc.stdin)
+		if code := c.run(tc.args); code != exitUsage || !strings.Contains(stderr.String(), tc.want) {
+			t.Errorf("%v: exit %d, stderr %q", tc.args, code, stderr)
+		}
+		if api.count() != 0 {
+			t.Errorf("%v: sent %v", tc.args, api.bodies)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/1set/quote0"
+)
+
+// runTail streams stdin to the display through the SDK DisplayWriter.
+func (c *cli) runTail(args []string) error {
+	fs, cf := c.newFlagSet("tail")
+	title := fs.String("title", "", "Fixed title")
+	lines := fs.Int("lines", 3, "How many of the latest lines to show")
+	every := fs.Duration("every", 30*time.Second, "Minimum time between updates")
+	sigFormat := fs.String("signature-format", "", "Go time layout with {host} and {user} tokens for the auto signature")
+	sigTZ := fs.String("signature-tz", "", "Time zone of the auto signature (default local)")
+	link := fs.String("link", "", "Optional URL")
+	markdown := fs.Bool("markdown", false, "Strip Markdown from each line (list markers become bullets)")
+	if err := c.parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *cf.dryRun {
+		return usagef("-dry-run is not supported by tail")
+	}
+	if *lines <= 0 {
+		return usagef("-lines must be positive")
+	}
+	if *every <= 0 {
+		return usagef("-every must be positive")
+	}
+	loc, err := signatureLocation(*sigTZ)
+	if err != nil {
+		return err
+	}
+	client, err := c.newClient(cf)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := c.commandContext(cf)
+	defer cancel()
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	tokens := c.signatureTokens()
+	var mdOpt quote0.DisplayWriterOption
+	if *markdown {
+		mdOpt = quote0.WithDisplayMarkdown()
+	}
+	w := client.NewDisplayWriter(ctx,
+		mdOpt,
+		quote0.WithDisplayLines(*lines),
+		quote0.WithDisplayInterval(*every),
+		quote0.WithDisplayTemplate(quote0.TextRequest{RefreshNow: quote0.Bool(true), Title: *title, Link: *link}),
+		quote0.WithDisplaySignature(func(t time.Time) string {
+			sig, _ := quote0.FormatSignature(*sigFormat, t.In(loc), tokens)
+			return sig
+		}),
+		quote0.WithDisplayCallback(func(u quote0.DisplayUpdate) {
+			stamp := u.At.Format("15:04:05")
+			if u.Err != nil {
+				fmt.Fprintf(c.stderr, "%s update failed: %v\n", stamp, u.Err)
+				return
+			}
+			fmt.Fprintf(c.stdout, "%s update sent (code=%d message=%s)\n", stamp, u.Response.Code, u.Response.Message)
+		}),
+	)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := io.Copy(w, c.stdin)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		// End of input: show the last lines before exiting.
+		if closeErr := w.Close(); err == nil {
+			err = closeErr
+		}
+		return err
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.Canceled) {
+			return nil
+		}
+		return ctx.Err()
+	}
+}
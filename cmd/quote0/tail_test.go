@@ -0,0 +1,59 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTail(t *testing.T) {
+	c, api, _, _ := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+	stdout, stderr := &syncBuffer{}, &syncBuffer{}
+	c.stdout, c.stderr = stdout, stderr
+	c.hostname = func() (string, error) { return "web-1", nil }
+	pr, pw := io.Pipe()
+	c.stdin = pr
+	done := make(chan int)
+	go func() {
+		done <- c.run([]string{"tail", "-title", "journal", "-lines", "2", "-every", "1h", "-signature-format", "{host}"})
+	}()
+
+	_, _ = io.WriteString(pw, "boot\n\x1b[32mstarted\x1b[0m\n")
+	waitFor(t, func() bool { return api.count() == 1 })
+	// Within the interval: held back until end of input.
+	_, _ = io.WriteString(pw, "listening on :80\n"+strings.Repeat("y", 400)+"\n")
+	time.Sleep(20 * time.Millisecond)
+	if api.count() != 1 {
+		t.Fatalf("sent %d updates within the interval", api.count())
+	}
+	pw.Close()
+	if code := <-done; code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+
+	first, last := api.body(0), api.body(1)
+	if first["title"] != "journal" || first["message"] != "boot\nstarted" || first["signature"] != "web-1" {
+		t.Fatalf("first update %v", first)
+	}
+	msg := last["message"].(string)
+	if !strings.HasPrefix(msg, "listening on :80\nyyy") || !strings.HasSuffix(msg, "…") || len(msg) > 100 {
+		t.Fatalf("final update %q", msg)
+	}
+	if strings.Count(stdout.String(), "update sent") != 2 {
+		t.Fatalf("stdout %q", stdout)
+	}
+}
+
+func TestTail_Usage(t *testing.T) {
+	for _, args := range [][]string{
+		{"tail", "-lines", "0"},
+		{"tail", "-every", "0s"},
+		{"tail", "-dry-run"},
+	} {
+		c, _, _, _ := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+		if code := c.run(args); code != exitUsage {
+			t.Errorf("%v: exit %d", args, code)
+		}
+	}
+}
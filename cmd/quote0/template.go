@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/1set/quote0"
+)
+
+// maxTemplateData bounds -data; it is parsed in memory before anything is sent.
+const maxTemplateData = 4 << 20
+
+func (c *cli) runTemplate(args []string) error {
+	fs, cf := c.newFlagSet("template")
+	tpl := quote0.TextTemplate{}
+	fs.StringVar(&tpl.Title, "title-tpl", "", "Go template for the title")
+	fs.StringVar(&tpl.Message, "message-tpl", "", "Go template for the message")
+	fs.StringVar(&tpl.Signature, "signature-tpl", "", "Go template for the signature")
+	dataFile := fs.String("data", "", "JSON file used as template data, or - for stdin")
+	withEnv := fs.Bool("env", false, "Expose environment variables as .Env")
+	fs.BoolVar(&tpl.Markdown, "markdown", false, "Strip Markdown from the rendered fields; the first link fills -link")
+	icon := fs.String("icon", "", "Base64 40x40 PNG icon (optional)")
+	iconFile := fs.String("icon-file", "", "Path to 40x40 PNG icon (optional)")
+	link := fs.String("link", "", "Optional URL")
+	refresh := addRefreshFlag(fs)
+	sf := addSendFlags(fs)
+	if err := c.parseFlags(fs, args); err != nil {
+		return err
+	}
+	if tpl.Title == "" && tpl.Message == "" && tpl.Signature == "" {
+		return usagef("template needs at least one of -title-tpl, -message-tpl, or -signature-tpl")
+	}
+
+	data, err := c.templateData(*dataFile, *withEnv)
+	if err != nil {
+		return err
+	}
+	req, err := tpl.Render(data)
+	if err != nil {
+		return usagef("%v", err)
+	}
+	req.RefreshNow = quote0.Bool(*refresh)
+	if *link != "" {
+		req.Link = *link
+	}
+	if req.Icon, err = loadBase64(*icon, *iconFile, "icon"); err != nil {
+		return err
+	}
+
+	client, devices, err := c.newClientDevices(cf)
+	if err != nil {
+		return err
+	}
+	if *cf.dryRun {
+		for _, id := range devices {
+			req.DeviceID = id
+			if err := c.dryRun(client.BuildText(req)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	ctx, cancel := c.commandContext(cf)
+	defer cancel()
+	return c.deliver(ctx, cf, devices, sf, textOutgoing("Text", client, req))
+}
+
+// templateData decodes -data (an empty object when unset) and adds .Env for -env.
+func (c *cli) templateData(path string, withEnv bool) (interface{}, error) {
+	var data interface{} = map[string]interface{}{}
+	if path != "" {
+		var r io.Reader = c.stdin
+		if path != "-" {
+			f, err := os.Open(path)
+			if err != nil {
+				return nil, err
+			}
+			defer f.Close()
+			r = f
+		}
+		raw, err := io.ReadAll(io.LimitReader(r, maxTemplateData+1))
+		if err != nil {
+			return nil, err
+		}
+		if len(raw) > maxTemplateData {
+			return nil, usagef("-data: larger than %d bytes", maxTemplateData)
+		}
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		if err := dec.Decode(&data); err != nil {
+			return nil, usagef("-data: %v", err)
+		}
+		if dec.More() {
+			return nil, usagef("-data: unexpected data after the JSON value")
+		}
+	}
+	if !withEnv {
+		return data, nil
+	}
+	obj, ok := data.(map[string]interface{})
+	if !ok {
+		return nil, usagef("-env needs -data to be a JSON object")
+	}
+	env := map[string]string{}
+	if c.environ != nil {
+		for _, kv := range c.environ() {
+			if k, v, ok := strings.Cut(kv, "="); ok {
+				env[k] = v
+			}
+		}
+	}
+	obj["Env"] = env
+	return obj, nil
+}
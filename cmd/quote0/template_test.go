@@ -0,0 +1,67 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTemplate(t *testing.T) {
+	c, api, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D", "SITE": "eu-1"})
+	c.stdin = strings.NewReader(`{"Host":"db-1","CPU":0.42}`)
+	args := []string{"template", "-data", "-", "-env",
+		"-title-tpl", "{{.Host}} @ {{.Env.SITE}}",
+		"-message-tpl", "CPU {{percent .CPU}}",
+		"-link", "https://status"}
+	if code := c.run(args); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	body := api.body(0)
+	if body["title"] != "db-1 @ eu-1" || body["message"] != "CPU 42%" || body["link"] != "https://status" {
+		t.Fatalf("body %v", body)
+	}
+}
+
+func TestTemplate_Markdown(t *testing.T) {
+	c, api, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+	c.stdin = strings.NewReader(`{"alert":"**CPU** high on ` + "`web-1`" + `, see [graph](https://grafana/d/1)"}`)
+	if code := c.run([]string{"template", "-data", "-", "-markdown", "-message-tpl", "{{.alert}}"}); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	body := api.body(0)
+	if body["message"] != "CPU high on web-1, see graph" || body["link"] != "https://grafana/d/1" {
+		t.Fatalf("body %v", body)
+	}
+}
+
+func TestTemplate_DryRun(t *testing.T) {
+	c, api, stdout, stderr := newTestCLI(t, map[string]string{"QUOTE0_DEVICE": "D"})
+	if code := c.run([]string{"template", "-dry-run", "-title-tpl", `{{"up" | upper}}`}); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if api.count() != 0 || !strings.Contains(stdout.String(), `"title": "UP"`) {
+		t.Fatalf("stdout %q", stdout)
+	}
+}
+
+func TestTemplate_Errors(t *testing.T) {
+	for _, tc := range []struct {
+		args  []string
+		stdin string
+		want  string
+	}{
+		{[]string{"template"}, "", "at least one of"},
+		{[]string{"template", "-message-tpl", "{{.CPU"}, "", "template: message:1"},
+		{[]string{"template", "-data", "-", "-title-tpl", "{{.Nope}}"}, `{"Host":"x"}`, `template: title:1:2: executing "title"`},
+		{[]string{"template", "-data", "-", "-title-tpl", "x"}, `{"Host":`, "-data:"},
+		{[]string{"template", "-data", "-", "-env", "-title-tpl", "x"}, `[1,2]`, "JSON object"},
+	} {
+		c, api, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+		c.stdin = strings.NewReader(tc.stdin)
+		if code := c.run(tc.args); code != exitUsage || !strings.Contains(stderr.String(), tc.want) {
+			t.Errorf("%v: exit %d, stderr %q", tc.args, code, stderr)
+		}
+		if api.count() != 0 {
+			t.Errorf("%v: sent %v", tc.args, api.bodies)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/1set/quote0"
+)
+
+func TestTimeout_StallingServer(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	c, _, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+	c.clientOptions = []quote0.ClientOption{quote0.WithBaseURL(srv.URL), quote0.WithRateLimiter(nil)}
+	start := time.Now()
+	code := c.run([]string{"text", "-title", "x", "--timeout", "50ms"})
+	if code != exitNetwork {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("took %s", elapsed)
+	}
+	if !strings.Contains(stderr.String(), "timed out after 50ms") {
+		t.Fatalf("stderr %q", stderr)
+	}
+}
+
+func TestTimeout_CoversBatchDelay(t *testing.T) {
+	c, api, _, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+	c.stdin = strings.NewReader(`{"type":"text","request":{"title":"one"}}
+{"type":"text","request":{"title":"two"},"delay":"1h"}
+`)
+	if code := c.run([]string{"batch", "-file", "-", "-timeout", "50ms"}); code != exitNetwork {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if api.count() != 1 || !strings.Contains(stderr.String(), "timed out after 50ms") {
+		t.Fatalf("sent %d, stderr %q", api.count(), stderr)
+	}
+}
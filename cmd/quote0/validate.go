@@ -0,0 +1,96 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/1set/quote0"
+)
+
+// validationFailed reports content that failed `validate`; it maps to the validation exit code.
+type validationFailed struct{ error }
+
+// validateReport is the -json output of `validate`.
+type validateReport struct {
+	Kind     string           `json:"kind"`
+	OK       bool             `json:"ok"`
+	Problems []quote0.Problem `json:"problems"`
+}
+
+// runValidate runs the SDK's local content checks on `text` or `image` flags and reports every
+// problem. Like preview, it accepts the common flags but needs no token, device, or network.
+func (c *cli) runValidate(args []string) error {
+	if len(args) < 1 {
+		return usagef("validate needs a kind: quote0 validate text|image [flags]")
+	}
+	fs, cf := c.newFlagSet("validate " + args[0])
+	asJSON := fs.Bool("json", false, "Print the report as JSON")
+
+	var check func() ([]quote0.Problem, error)
+	switch args[0] {
+	case "text":
+		tf := addTextFlags(fs)
+		check = func() ([]quote0.Problem, error) {
+			if *tf.iconURL != "" {
+				return nil, usagef("validate makes no network requests; use -icon-file instead of -icon-url")
+			}
+			req, err := tf.request(c, cf)
+			if err != nil {
+				return nil, err
+			}
+			return quote0.CheckText(req), nil
+		}
+	case "image":
+		imf := addImageFlags(fs)
+		check = func() ([]quote0.Problem, error) {
+			req, err := imf.request(c.stdin)
+			if err != nil {
+				return nil, err
+			}
+			warnings, _ := imf.checkProcessing(req.DitherType)
+			return append(quote0.CheckImage(req), warnings...), nil
+		}
+	default:
+		return usagef("unknown validate kind %q (want text or image)", args[0])
+	}
+	if err := c.parseFlags(fs, args[1:]); err != nil {
+		return err
+	}
+
+	problems, err := check()
+	if err != nil {
+		return err
+	}
+	failed := quote0.HasErrors(problems)
+	if *asJSON {
+		if problems == nil {
+			problems = []quote0.Problem{}
+		}
+		if err := c.printJSON(validateReport{Kind: args[0], OK: !failed, Problems: problems}); err != nil {
+			return err
+		}
+	} else {
+		for _, p := range problems {
+			fmt.Fprintf(c.stdout, "%s: %s: %s\n", p.Severity, p.Field, p.Message)
+		}
+		if !failed {
+			fmt.Fprintf(c.stdout, "%s ok\n", args[0])
+		}
+	}
+	if failed {
+		errs := 0
+		for _, p := range problems {
+			if p.Severity == quote0.SeverityError {
+				errs++
+			}
+		}
+		return validationFailed{fmt.Errorf("%s failed validation (errors: %d)", args[0], errs)}
+	}
+	return nil
+}
+
+// isValidationFailure reports whether err came from `validate` finding problems.
+func isValidationFailure(err error) bool {
+	var vf validationFailed
+	return errors.As(err, &vf)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidate_TextClean(t *testing.T) {
+	// No token or device: validate must not need them.
+	c, api, stdout, stderr := newTestCLI(t, map[string]string{})
+	if code := c.run([]string{"validate", "text", "-title", "Build", "-message", "green", "-link", "https://ci"}); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if api.count() != 0 || stdout.String() != "text ok\n" {
+		t.Fatalf("stdout %q", stdout)
+	}
+}
+
+func TestValidate_TextProblems(t *testing.T) {
+	c, _, stdout, stderr := newTestCLI(t, map[string]string{})
+	code := c.run([]string{"validate", "text", "-title", strings.Repeat("t", 40), "-link", "not a url"})
+	if code != exitValidation {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	want := "error: title: 40 characters, at most 28 fit\nerror: link: \"not a url\" is not an absolute URL\n"
+	if stdout.String() != want {
+		t.Fatalf("stdout %q", stdout)
+	}
+	if !strings.Contains(stderr.String(), "text failed validation (errors: 2)") {
+		t.Fatalf("stderr %q", stderr)
+	}
+}
+
+func TestValidate_ImageJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "board.png")
+	var buf bytes.Buffer
+	_ = png.Encode(&buf, image.NewGray(image.Rect(0, 0, 296, 152)))
+	_ = os.WriteFile(path, buf.Bytes(), 0o644)
+
+	c, _, stdout, stderr := newTestCLI(t, map[string]string{})
+	if code := c.run([]string{"validate", "image", "-image-file", path, "-dither-type", "none", "-dither-kernel", "atkinson", "-json"}); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	var report validateReport
+	if err := json.Unmarshal(stdout.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if !report.OK || len(report.Problems) != 1 || report.Problems[0].Field != "ditherKernel" {
+		t.Fatalf("report %+v", report)
+	}
+
+	c, _, stdout, _ = newTestCLI(t, map[string]string{})
+	if code := c.run([]string{"validate", "image", "-image-file", path, "-threshold", "128", "-dither-type", "ordered", "-json"}); code != 0 {
+		t.Fatalf("threshold: exit %d", code)
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &report); err != nil || !report.OK || len(report.Problems) != 1 || report.Problems[0].Field != "ditherType" {
+		t.Fatalf("threshold report %+v (%v)", report, err)
+	}
+
+	c, _, stdout, _ = newTestCLI(t, map[string]string{})
+	if code := c.run([]string{"validate", "image", "-image-file", filepath.Join(t.TempDir(), "missing.png"), "-json"}); code != exitValidation {
+		t.Fatalf("exit %d", code)
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &report); err != nil || report.OK || report.Problems[0].Field != "image" {
+		t.Fatalf("report %+v (%v)", report, err)
+	}
+}
+
+func TestValidate_Usage(t *testing.T) {
+	for _, args := range [][]string{
+		{"validate"},
+		{"validate", "audio"},
+		{"validate", "text", "-icon-url", "https://icons/x.png"},
+	} {
+		c, _, _, _ := newTestCLI(t, map[string]string{})
+		if code := c.run(args); code != exitUsage {
+			t.Errorf("%v: exit %d", args, code)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/1set/quote0"
+)
+
+func TestVerbose_NeverLeaksToken(t *testing.T) {
+	const token = "dot_app_verysecrettoken0123456789"
+	data, _ := quote0.NewCanvas().PNG()
+	image := base64.StdEncoding.EncodeToString(data)
+	for _, tc := range []struct {
+		flags []string
+		want  string
+	}{
+		{[]string{"-v"}, "POST "},
+		{[]string{"-verbose"}, "POST "},
+		{[]string{"-vv"}, "========== REQUEST"},
+		{[]string{"-v", "-v"}, "========== REQUEST"},
+	} {
+		c, _, stdout, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": token, "QUOTE0_DEVICE": "D"})
+		args := append([]string{"image", "-image", image}, tc.flags...)
+		if code := c.run(args); code != 0 {
+			t.Fatalf("%v: exit %d: %s", tc.flags, code, stderr)
+		}
+		if !strings.Contains(stderr.String(), tc.want) {
+			t.Errorf("%v: stderr %q lacks %q", tc.flags, stderr, tc.want)
+		}
+		for _, out := range []string{stdout.String(), stderr.String()} {
+			if strings.Contains(out, token) || strings.Contains(out, image) {
+				t.Fatalf("%v: output leaks token or image: %q", tc.flags, out)
+			}
+			// Nothing after the dot_app_ prefix may show, not even a few characters.
+			secret := strings.TrimPrefix(token, "dot_app_")
+			for i := 0; i+4 <= len(secret); i++ {
+				if strings.Contains(out, secret[i:i+4]) {
+					t.Fatalf("%v: output leaks %q of the token: %q", tc.flags, secret[i:i+4], out)
+				}
+			}
+		}
+		if tc.want == "========== REQUEST" && !strings.Contains(stderr.String(), "Bearer dot_app_***") {
+			t.Errorf("%v: stderr %q lacks the masked token", tc.flags, stderr)
+		}
+		if strings.Contains(stdout.String(), "quote0-debug") {
+			t.Errorf("%v: diagnostics on stdout", tc.flags)
+		}
+	}
+}
+
+func TestVerbose_WithJSONSummary(t *testing.T) {
+	c, _, stdout, stderr := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+	c.stdin = strings.NewReader(`{"type":"text","request":{"title":"one"}}` + "\n")
+	if code := c.run([]string{"batch", "-file", "-", "-json", "-vv"}); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	var lines []batchLine
+	if err := json.Unmarshal(stdout.Bytes(), &lines); err != nil || len(lines) != 1 {
+		t.Fatalf("stdout is not the JSON summary: %v %q", err, stdout)
+	}
+	if !strings.Contains(stderr.String(), "Bearer [redacted]") {
+		t.Fatalf("stderr %q", stderr)
+	}
+}
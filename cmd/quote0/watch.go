@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image/png"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"github.com/1set/quote0"
+)
+
+// runWatch re-sends -image-file (with every image flag applied) or the contents of -text-file
+// whenever the file changes, until interrupted.
+func (c *cli) runWatch(args []string) error {
+	fs, cf := c.newFlagSet("watch")
+	imf := addImageFlags(fs)
+	textFile := fs.String("text-file", "", "Watch a UTF-8 text file; the first line is the title, the rest the message")
+	signature := fs.String("signature", "", "Signature for -text-file sends (optional)")
+	interval := fs.Duration("interval", 2*time.Second, "How often the file is polled")
+	debounce := fs.Duration("debounce", time.Second, "How long the file must stay unchanged before it is sent")
+	if err := c.parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *cf.dryRun {
+		return usagef("-dry-run is not supported by watch")
+	}
+	if *interval <= 0 || *debounce < 0 {
+		return usagef("-interval must be positive and -debounce not negative")
+	}
+
+	var path string
+	var send func(ctx context.Context, client *quote0.Client, data []byte) (*quote0.APIResponse, error)
+	switch {
+	case *textFile != "" && (*imf.imageFile != "" || *imf.image != ""):
+		return usagef("provide either -image-file or -text-file, not both")
+	case *imf.image != "":
+		return usagef("watch needs a file: use -image-file instead of -image")
+	case *imf.imageFile == "-" || *textFile == "-":
+		return usagef("watch needs a file path; stdin (-) cannot be watched")
+	case *imf.imageFile != "":
+		meta, err := imf.meta()
+		if err != nil {
+			return err
+		}
+		path = *imf.imageFile
+		send = func(ctx context.Context, client *quote0.Client, data []byte) (*quote0.APIResponse, error) {
+			data, err := imf.process(data)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := png.DecodeConfig(bytes.NewReader(data)); err != nil {
+				return nil, fmt.Errorf("%w: %v", quote0.ErrInvalidImage, err)
+			}
+			return client.SendImageBytes(ctx, data, meta)
+		}
+	case *textFile != "":
+		path = *textFile
+		send = func(ctx context.Context, client *quote0.Client, data []byte) (*quote0.APIResponse, error) {
+			if !utf8.Valid(data) || bytes.IndexByte(data, 0) >= 0 {
+				return nil, fmt.Errorf("%w: %s", quote0.ErrInvalidText, path)
+			}
+			title, message := quote0.SplitTitleMessage(string(data))
+			return client.SendText(ctx, quote0.TextRequest{
+				RefreshNow: quote0.Bool(*imf.refresh),
+				Title:      title,
+				Message:    message,
+				Signature:  strings.TrimSpace(*signature),
+				Link:       *imf.link,
+			})
+		}
+	default:
+		return usagef("watch needs -image-file or -text-file")
+	}
+
+	client, err := c.newClient(cf)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := c.commandContext(cf)
+	defer cancel()
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	name := filepath.Base(path)
+	var sent, skipped, failed int
+	fmt.Fprintf(c.stdout, "Watching %s every %s (Ctrl-C to stop)\n", path, *interval)
+	err = quote0.WatchFile(ctx, path, func(ctx context.Context, data []byte) (*quote0.APIResponse, error) {
+		return send(ctx, client, data)
+	},
+		quote0.WithWatchInterval(*interval),
+		quote0.WithWatchDebounce(*debounce),
+		quote0.WithWatchCallback(func(ev quote0.WatchEvent) {
+			stamp := ev.At.Format("15:04:05")
+			switch {
+			case ev.Err != nil:
+				failed++
+				fmt.Fprintf(c.stderr, "%s %s: %v\n", stamp, name, ev.Err)
+			case ev.Skipped:
+				skipped++
+				fmt.Fprintf(c.stdout, "%s %s unchanged, skipped\n", stamp, name)
+			default:
+				sent++
+				fmt.Fprintf(c.stdout, "%s %s sent (code=%d message=%s)\n", stamp, name, ev.Response.Code, ev.Response.Message)
+			}
+		}),
+	)
+	fmt.Fprintf(c.stdout, "Watch stopped: %d sent, %d skipped, %d failed\n", sent, skipped, failed)
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
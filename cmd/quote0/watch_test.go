@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/1set/quote0"
+)
+
+func TestWatch_TextFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "status.txt")
+	if err := os.WriteFile(path, []byte("Backups\nall green\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c, api, _, _ := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+	stdout, stderr := &syncBuffer{}, &syncBuffer{}
+	c.stdout, c.stderr = stdout, stderr
+	ctx, cancel := context.WithCancel(context.Background())
+	c.ctx = ctx
+	done := make(chan int)
+	go func() { done <- c.run([]string{"watch", "-text-file", path, "-interval", "5ms", "-debounce", "0"}) }()
+
+	waitFor(t, func() bool { return api.count() == 1 })
+	// Rewrite with new content and a distinct mtime.
+	_ = os.WriteFile(path, []byte("Backups\n1 failed\n"), 0o644)
+	_ = os.Chtimes(path, time.Now(), time.Now().Add(time.Minute))
+	waitFor(t, func() bool { return strings.Count(stdout.String(), " sent (") == 2 })
+	cancel()
+	if code := <-done; code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+
+	if api.body(0)["title"] != "Backups" || api.body(1)["message"] != "1 failed" {
+		t.Fatalf("bodies %v", api.bodies)
+	}
+	if !strings.Contains(stdout.String(), "Watch stopped: 2 sent, 0 skipped, 0 failed") {
+		t.Fatalf("stdout %q", stdout)
+	}
+}
+
+func TestWatch_ImageFileInvalidThenValid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "panel.png")
+	_ = os.WriteFile(path, []byte("not a png"), 0o644)
+	c, _, _, _ := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+	stdout, stderr := &syncBuffer{}, &syncBuffer{}
+	c.stdout, c.stderr = stdout, stderr
+	ctx, cancel := context.WithCancel(context.Background())
+	c.ctx = ctx
+	done := make(chan int)
+	go func() { done <- c.run([]string{"watch", "-image-file", path, "-interval", "5ms", "-debounce", "0"}) }()
+
+	waitFor(t, func() bool { return strings.Contains(stderr.String(), "not a valid PNG") })
+	data, _ := quote0.NewCanvas().PNG()
+	_ = os.WriteFile(path, data, 0o644)
+	_ = os.Chtimes(path, time.Now(), time.Now().Add(time.Minute))
+	// Wait for the reported send, not the request: cancelling before the response is read
+	// would count it as failed.
+	waitFor(t, func() bool { return strings.Contains(stdout.String(), " sent (") })
+	cancel()
+	if code := <-done; code != 0 {
+		t.Fatalf("exit %d", code)
+	}
+	if !strings.Contains(stdout.String(), "1 sent, 0 skipped, 1 failed") {
+		t.Fatalf("stdout %q", stdout)
+	}
+}
+
+func TestWatch_Usage(t *testing.T) {
+	for _, args := range [][]string{
+		{"watch"},
+		{"watch", "-image", "aGk="},
+		{"watch", "-image-file", "a.png", "-text-file", "b.txt"},
+		{"watch", "-image-file", "a.png", "-interval", "0s"},
+	} {
+		c, _, _, _ := newTestCLI(t, map[string]string{"QUOTE0_TOKEN": "tok", "QUOTE0_DEVICE": "D"})
+		if code := c.run(args); code != exitUsage {
+			t.Errorf("%v: exit %d", args, code)
+		}
+	}
+}
+
+// syncBuffer is a bytes.Buffer safe to read while the watcher goroutine writes to it.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for condition")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
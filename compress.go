@@ -0,0 +1,61 @@
+package quote0
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"sync/atomic"
+)
+
+// compressThreshold is the JSON body size above which WithRequestCompression gzips.
+const compressThreshold = 1 << 10
+
+// compressState is the WithRequestCompression state shared by all calls of a client.
+type compressState struct {
+	// rejected is set, atomically, once the server refused a gzip body.
+	rejected int32
+}
+
+// WithRequestCompression gzips request bodies larger than 1 KiB, such as image payloads, and
+// sends them with Content-Encoding: gzip; smaller text requests go out as they are. If the
+// server answers a compressed request with 400 or 415, the request is sent once more
+// uncompressed, waiting for the limiter again, and compression stays off for the rest of
+// the client's life. A WithRequestSigner signs the bytes actually sent; Hooks and debug logs
+// still see the JSON.
+func WithRequestCompression() ClientOption {
+	return func(c *Client) { c.compress = &compressState{} }
+}
+
+// wireBody returns the body to send for call and whether it is gzipped. The compressed form
+// is computed once per call and reused by its retries.
+func (c *Client) wireBody(call *apiCall) ([]byte, bool) {
+	if c.compress == nil || len(call.body) <= compressThreshold || atomic.LoadInt32(&c.compress.rejected) != 0 {
+		return call.body, false
+	}
+	if call.gzipped == nil {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(call.body); err != nil {
+			return call.body, false
+		}
+		if err := zw.Close(); err != nil {
+			return call.body, false
+		}
+		call.gzipped = buf.Bytes()
+	}
+	return call.gzipped, true
+}
+
+// compressionRejected reports whether err is the server refusing the gzip body call just
+// sent, and if so turns compression off for the client.
+func (c *Client) compressionRejected(call *apiCall, err error) bool {
+	if !call.compressed {
+		return false
+	}
+	var ae *APIError
+	if !errors.As(err, &ae) || (ae.StatusCode != 400 && ae.StatusCode != 415) {
+		return false
+	}
+	atomic.StoreInt32(&c.compress.rejected, 1)
+	return true
+}
@@ -0,0 +1,107 @@
+package quote0
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// compressServer decodes gzip bodies unless reject is non-zero, in which case it answers
+// gzip requests with that status. It records the encoding and decoded body of each request.
+func compressServer(t *testing.T, reject int) (*httptest.Server, *[]string, *[]string) {
+	t.Helper()
+	var encodings, bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		enc := r.Header.Get("Content-Encoding")
+		encodings = append(encodings, enc)
+		body := io.Reader(r.Body)
+		if enc == "gzip" {
+			if reject != 0 {
+				bodies = append(bodies, "")
+				w.WriteHeader(reject)
+				return
+			}
+			zr, err := gzip.NewReader(r.Body)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			body = zr
+		}
+		raw, _ := io.ReadAll(body)
+		bodies = append(bodies, string(raw))
+		okHandler(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &encodings, &bodies
+}
+
+// noisyPNG returns a full-screen PNG large enough to be compressed.
+func noisyPNG(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, noise(ScreenWidth, ScreenHeight)); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestWithRequestCompression_Accepted(t *testing.T) {
+	srv, encodings, bodies := compressServer(t, 0)
+	c, _ := NewClient("test", WithBaseURL(srv.URL), WithRateLimiter(nil), WithDefaultDeviceID("D"), WithRequestCompression())
+	ctx := context.Background()
+	img := noisyPNG(t)
+	if _, err := c.SendImage(ctx, ImageRequest{ImageBytes: img}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.SendText(ctx, TextRequest{Title: "small"}); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(*encodings, ",") != "gzip," {
+		t.Fatalf("encodings %q", *encodings)
+	}
+	var sent ImageRequest
+	if err := json.Unmarshal([]byte((*bodies)[0]), &sent); err != nil || sent.DeviceID != "D" || len(sent.Image) < compressThreshold {
+		t.Fatalf("decoded image body: %v", err)
+	}
+}
+
+func TestWithRequestCompression_Rejected(t *testing.T) {
+	for _, status := range []int{http.StatusUnsupportedMediaType, http.StatusBadRequest} {
+		srv, encodings, bodies := compressServer(t, status)
+		waits := 0
+		limiter := RateLimiterFunc(func(context.Context) error { waits++; return nil })
+		c, _ := NewClient("test", WithBaseURL(srv.URL), WithRateLimiter(limiter), WithDefaultDeviceID("D"), WithRequestCompression())
+		ctx := context.Background()
+		img := noisyPNG(t)
+		resp, err := c.SendImage(ctx, ImageRequest{ImageBytes: img})
+		if err != nil || resp.Code != 0 {
+			t.Fatalf("%d: %v", status, err)
+		}
+		if _, err := c.SendImage(ctx, ImageRequest{ImageBytes: img}); err != nil {
+			t.Fatal(err)
+		}
+		if strings.Join(*encodings, ",") != "gzip,," || waits != 3 || !strings.Contains((*bodies)[1], `"image":"`) {
+			t.Fatalf("%d: encodings %q after %d limiter waits", status, *encodings, waits)
+		}
+	}
+}
+
+func TestWithRequestCompression_OtherErrors(t *testing.T) {
+	srv, encodings, _ := compressServer(t, http.StatusInternalServerError)
+	c, _ := NewClient("test", WithBaseURL(srv.URL), WithRateLimiter(nil), WithDefaultDeviceID("D"), WithRequestCompression())
+	img := noisyPNG(t)
+	if _, err := c.SendImage(context.Background(), ImageRequest{ImageBytes: img}); err == nil {
+		t.Fatal("500 accepted")
+	}
+	if len(*encodings) != 1 {
+		t.Fatalf("a 500 was resent: %q", *encodings)
+	}
+}
@@ -0,0 +1,168 @@
+package quote0
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image/png"
+	"net/url"
+	"strings"
+)
+
+// ErrInvalidDataURI is returned for an Image or Icon field that starts with "data:" but is
+// not a well-formed data URI holding a complete image; the error names the defect.
+var ErrInvalidDataURI = errors.New("quote0: invalid data URI")
+
+// pngTrailer is the IEND chunk every complete PNG ends with.
+var pngTrailer = []byte("IEND\xaeB`\x82")
+
+// isDataURI reports whether s looks like a data URI rather than plain base64.
+func isDataURI(s string) bool {
+	s = strings.TrimSpace(s)
+	return len(s) >= 5 && strings.EqualFold(s[:5], "data:")
+}
+
+// ParseDataURI decodes a data URI of the form data:[<media type>][;base64],<data>, with base64
+// or percent-encoded data, and returns the media type (lowercase, without parameters;
+// "text/plain" when omitted) and the decoded bytes. Defects are reported as ErrInvalidDataURI
+// with what is wrong.
+func ParseDataURI(s string) (mediaType string, data []byte, err error) {
+	if mediaType, data, err = parseDataURI(s); err != nil {
+		return "", nil, fmt.Errorf("%w: %v", ErrInvalidDataURI, err)
+	}
+	return mediaType, data, nil
+}
+
+// parseDataURI is ParseDataURI with the defect as a plain error.
+func parseDataURI(s string) (mediaType string, data []byte, err error) {
+	s = strings.TrimSpace(s)
+	if !isDataURI(s) {
+		return "", nil, errors.New("missing the data: scheme")
+	}
+	header, body, ok := strings.Cut(s[5:], ",")
+	if !ok {
+		return "", nil, errors.New("missing the comma before the data")
+	}
+	params := strings.Split(header, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(params[0]))
+	if mediaType == "" {
+		mediaType = "text/plain"
+	} else if !strings.Contains(mediaType, "/") {
+		return "", nil, fmt.Errorf("media type %q is not type/subtype", params[0])
+	}
+	isBase64 := false
+	for _, p := range params[1:] {
+		if strings.EqualFold(strings.TrimSpace(p), "base64") {
+			isBase64 = true
+		}
+	}
+	if isBase64 {
+		// Whitespace and percent-encoded padding are common in pasted or URL-embedded URIs.
+		body = strings.Join(strings.Fields(body), "")
+		if unescaped, err := url.PathUnescape(body); err == nil {
+			body = unescaped
+		}
+		data, err = base64.StdEncoding.DecodeString(body)
+		if err != nil {
+			data, err = base64.RawStdEncoding.DecodeString(body)
+		}
+		if err != nil {
+			var corrupt base64.CorruptInputError
+			if errors.As(err, &corrupt) {
+				return "", nil, fmt.Errorf("bad base64 at data byte %d (truncated or corrupted?)", int64(corrupt))
+			}
+			return "", nil, fmt.Errorf("bad base64: %v", err)
+		}
+	} else {
+		unescaped, err := url.PathUnescape(body)
+		if err != nil {
+			return "", nil, fmt.Errorf("bad percent-encoding: %v", err)
+		}
+		data = []byte(unescaped)
+	}
+	if len(data) == 0 {
+		return "", nil, errors.New("no data after the comma")
+	}
+	return mediaType, data, nil
+}
+
+// decodeImageDataURI parses a data URI for field ("image" or "icon") and checks that it holds
+// a complete PNG or JPEG matching its media type. It returns the format name and the bytes.
+func decodeImageDataURI(field, s string) (format string, data []byte, err error) {
+	mediaType, data, err := parseDataURI(s)
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: %s: %v", ErrInvalidDataURI, field, err)
+	}
+	format = sniffFormat(data)
+	switch mediaType {
+	case "image/png", "image/jpeg", "image/jpg":
+	default:
+		return "", nil, fmt.Errorf("%w: %s: media type %s, want image/png", ErrInvalidDataURI, field, mediaType)
+	}
+	if declared := strings.TrimPrefix(strings.Replace(mediaType, "jpg", "jpeg", 1), "image/"); format != declared {
+		if format == "" {
+			format = "unrecognized data"
+		}
+		return "", nil, fmt.Errorf("%w: %s: declared %s but holds %s", ErrInvalidDataURI, field, mediaType, format)
+	}
+	if format == "png" {
+		if _, err := png.DecodeConfig(bytes.NewReader(data)); err != nil {
+			return "", nil, fmt.Errorf("%w: %s: bad png header: %v", ErrInvalidDataURI, field, err)
+		}
+		if !bytes.HasSuffix(data, pngTrailer) {
+			return "", nil, fmt.Errorf("%w: %s: png is truncated (%d bytes, no end chunk)", ErrInvalidDataURI, field, len(data))
+		}
+	}
+	return format, data, nil
+}
+
+// pngFromDataURI decodes a data URI that must hold a PNG, as for icons and previews.
+func pngFromDataURI(field, s string) ([]byte, error) {
+	format, data, err := decodeImageDataURI(field, s)
+	if err != nil {
+		return nil, err
+	}
+	if format != "png" {
+		return nil, fmt.Errorf("%w: %s: data URI holds %s", ErrUnsupportedFormat, field, format)
+	}
+	return data, nil
+}
+
+// WithDataURIConversion lets SendImage and BuildImage accept JPEG data URIs in
+// ImageRequest.Image by running them through ProcessImage, fitted with FitContain by default
+// and then opts, and sending the PNG result. Without it a JPEG data URI reports
+// ErrUnsupportedFormat. PNG data URIs never need it.
+func WithDataURIConversion(opts ...ProcessOption) ClientOption {
+	return func(c *Client) {
+		c.convertDataURI = true
+		c.dataURIProcess = append([]ProcessOption{WithFit(FitContain)}, opts...)
+	}
+}
+
+// imageFromDataURI returns the base64 PNG for a data URI in ImageRequest.Image.
+func (c *Client) imageFromDataURI(s string) (string, error) {
+	format, data, err := decodeImageDataURI("image", s)
+	if err != nil {
+		return "", err
+	}
+	if format == "jpeg" {
+		if !c.convertDataURI {
+			return "", fmt.Errorf("%w: image: data URI holds jpeg (see WithDataURIConversion)", ErrUnsupportedFormat)
+		}
+		src, _, err := DecodeImage(data)
+		if err != nil {
+			return "", err
+		}
+		img, err := ProcessImage(src, c.dataURIProcess...)
+		if err != nil {
+			return "", err
+		}
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			return "", fmt.Errorf("quote0: encode png: %w", err)
+		}
+		data = buf.Bytes()
+	}
+	return encodeBase64(data), nil
+}
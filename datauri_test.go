@@ -0,0 +1,129 @@
+package quote0
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func dataURI(mediaType string, data []byte) string {
+	return "data:" + mediaType + ";base64," + base64.StdEncoding.EncodeToString(data)
+}
+
+func TestParseDataURI(t *testing.T) {
+	tests := []struct {
+		in, mediaType, data string
+	}{
+		{"data:,Hello%2C%20World", "text/plain", "Hello, World"},
+		{"DATA:Image/PNG;base64,aGk=", "image/png", "hi"},
+		{"data:image/png;name=x.png;base64,aGk", "image/png", "hi"},
+		{" data:image/png;base64,aG\n k%3D ", "image/png", "hi"},
+	}
+	for _, tt := range tests {
+		mt, data, err := ParseDataURI(tt.in)
+		if err != nil || mt != tt.mediaType || string(data) != tt.data {
+			t.Errorf("%q: %q %q %v", tt.in, mt, data, err)
+		}
+	}
+	for in, want := range map[string]string{
+		"image/png;base64,aGk=":      "missing the data: scheme",
+		"data:image/png;base64":      "missing the comma",
+		"data:png;base64,aGk=":       "not type/subtype",
+		"data:image/png;base64,a@b=": "bad base64 at data byte 1",
+		"data:image/png,%zz":         "bad percent-encoding",
+		"data:image/png;base64,":     "no data",
+	} {
+		_, _, err := ParseDataURI(in)
+		if !errors.Is(err, ErrInvalidDataURI) || !strings.Contains(err.Error(), want) {
+			t.Errorf("%q: %v, want %q", in, err, want)
+		}
+	}
+}
+
+func TestSendImage_PNGDataURI(t *testing.T) {
+	s := &stagedServer{}
+	c := newStagedClient(t, s)
+	data := pngBytes(t, ScreenWidth, ScreenHeight)
+	if _, err := c.SendImage(context.Background(), ImageRequest{Image: dataURI("image/png", data)}); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.got[0]["image"]; got != base64.StdEncoding.EncodeToString(data) {
+		t.Fatalf("sent %.40v", got)
+	}
+	if ps := CheckImage(ImageRequest{Image: dataURI("image/png", data)}); len(ps) != 0 {
+		t.Fatalf("problems %v", ps)
+	}
+}
+
+func TestBuildImage_JPEGDataURI(t *testing.T) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, 400, 200)), nil); err != nil {
+		t.Fatal(err)
+	}
+	uri := dataURI("image/jpeg", buf.Bytes())
+
+	plain, _ := NewClient("test", WithDefaultDeviceID("D"))
+	if _, err := plain.BuildImage(ImageRequest{Image: uri}); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Fatalf("without conversion: %v", err)
+	}
+
+	c, _ := NewClient("test", WithDefaultDeviceID("D"), WithDataURIConversion(WithInvert()))
+	req, err := c.BuildImage(ImageRequest{Image: uri})
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := base64.StdEncoding.DecodeString(req.Payload.(*ImageRequest).Image)
+	img, err := png.Decode(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Contained with white bands, then inverted: the bands are black and the photo white.
+	gray := img.(*image.Gray)
+	if b := gray.Bounds(); b.Dx() != ScreenWidth || b.Dy() != ScreenHeight || gray.GrayAt(0, 0).Y != 0 || gray.GrayAt(148, 76).Y != 255 {
+		t.Fatalf("converted %v, corner %d, centre %d", b, gray.GrayAt(0, 0).Y, gray.GrayAt(148, 76).Y)
+	}
+}
+
+func TestBuildImage_BadDataURI(t *testing.T) {
+	c, _ := NewClient("test", WithDefaultDeviceID("D"))
+	data := pngBytes(t, ScreenWidth, ScreenHeight)
+	tests := map[string]string{
+		dataURI("image/png", data[:len(data)-20]):        "image: png is truncated",
+		dataURI("image/png", []byte("\xff\xd8\xff\xe0")): "declared image/png but holds jpeg",
+		dataURI("image/gif", []byte("GIF89a")):           "media type image/gif",
+		"data:image/png;base64,iVBORw0KGgo=!":            "bad base64",
+	}
+	for uri, want := range tests {
+		_, err := c.BuildImage(ImageRequest{Image: uri})
+		if !errors.Is(err, ErrInvalidDataURI) || !IsValidationError(err) || !strings.Contains(err.Error(), want) {
+			t.Errorf("%.40s: %v, want %q", uri, err, want)
+		}
+	}
+}
+
+func TestBuildText_IconDataURI(t *testing.T) {
+	c, _ := NewClient("test", WithDefaultDeviceID("D"))
+	icon := pngBytes(t, IconSize, IconSize)
+	req, err := c.BuildText(TextRequest{Title: "t", Icon: dataURI("image/png", icon)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := req.Payload.(*TextRequest).Icon; got != base64.StdEncoding.EncodeToString(icon) {
+		t.Fatalf("icon %.40s", got)
+	}
+	if _, err := c.BuildText(TextRequest{Icon: dataURI("image/png", pngBytes(t, 50, 50))}); !errors.Is(err, ErrIconSize) {
+		t.Fatalf("wrong size: %v", err)
+	}
+	if _, err := c.BuildText(TextRequest{Icon: "data:image/png;base64"}); !errors.Is(err, ErrInvalidDataURI) || !strings.Contains(err.Error(), "icon:") {
+		t.Fatalf("malformed: %v", err)
+	}
+	if _, err := PreviewText(TextRequest{Icon: dataURI("image/png", icon)}); err != nil {
+		t.Fatalf("preview: %v", err)
+	}
+}
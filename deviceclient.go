@@ -0,0 +1,102 @@
+package quote0
+
+import (
+	"context"
+	"strings"
+)
+
+// DeviceClient is a view of a Client bound to one device, returned by ForDevice. It shares
+// the parent's HTTP client, token, rate limiter, retry policy, hooks, and history, so any
+// number of device clients stay within the parent's rate budget. Calls target its device
+// unless the request names another through DeviceID or WithDevice.
+type DeviceClient struct {
+	c  *Client
+	id string
+}
+
+var _ Sender = (*DeviceClient)(nil)
+
+// ForDevice returns a DeviceClient that sends to deviceID through c. The binding takes
+// precedence over ContextWithDeviceID and the default device; a blank deviceID leaves both
+// in effect, like c itself.
+func (c *Client) ForDevice(deviceID string) *DeviceClient {
+	return &DeviceClient{c: c, id: strings.TrimSpace(deviceID)}
+}
+
+// DeviceID returns the device the client is bound to.
+func (d *DeviceClient) DeviceID() string { return d.id }
+
+// Client returns the parent client.
+func (d *DeviceClient) Client() *Client { return d.c }
+
+// bind returns ctx carrying the bound device for resolveDeviceID.
+func (d *DeviceClient) bind(ctx context.Context) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return ContextWithDeviceID(ctx, d.id)
+}
+
+// SendText sends text content to the bound device (see Client.SendText).
+func (d *DeviceClient) SendText(ctx context.Context, payload TextRequest, opts ...SendOption) (*APIResponse, error) {
+	return d.c.SendText(d.bind(ctx), payload, opts...)
+}
+
+// SendImage uploads an image to the bound device (see Client.SendImage).
+func (d *DeviceClient) SendImage(ctx context.Context, payload ImageRequest, opts ...SendOption) (*APIResponse, error) {
+	return d.c.SendImage(d.bind(ctx), payload, opts...)
+}
+
+// SendTextSimple is Client.SendTextSimple for the bound device.
+func (d *DeviceClient) SendTextSimple(title, message string, signature ...string) (*APIResponse, error) {
+	return d.SendTextSimpleContext(context.Background(), title, message, simpleSignature(signature)...)
+}
+
+// SendTextSimpleContext is Client.SendTextSimpleContext for the bound device.
+func (d *DeviceClient) SendTextSimpleContext(ctx context.Context, title, message string, opts ...SendOption) (*APIResponse, error) {
+	return d.c.SendTextSimpleContext(d.bind(ctx), title, message, opts...)
+}
+
+// SendImageSimple is Client.SendImageSimple for the bound device.
+func (d *DeviceClient) SendImageSimple(base64PNG string) (*APIResponse, error) {
+	return d.SendImageSimpleContext(context.Background(), base64PNG)
+}
+
+// SendImageSimpleContext is Client.SendImageSimpleContext for the bound device.
+func (d *DeviceClient) SendImageSimpleContext(ctx context.Context, base64PNG string, opts ...SendOption) (*APIResponse, error) {
+	return d.c.SendImageSimpleContext(d.bind(ctx), base64PNG, opts...)
+}
+
+// SendImageBytes is Client.SendImageBytes for the bound device.
+func (d *DeviceClient) SendImageBytes(ctx context.Context, png []byte, meta ImageRequest) (*APIResponse, error) {
+	return d.c.SendImageBytes(d.bind(ctx), png, meta)
+}
+
+// SendImageFile is Client.SendImageFile for the bound device.
+func (d *DeviceClient) SendImageFile(ctx context.Context, path string, meta ImageRequest) (*APIResponse, error) {
+	return d.c.SendImageFile(d.bind(ctx), path, meta)
+}
+
+// SendTextStaged is Client.SendTextStaged for the bound device.
+func (d *DeviceClient) SendTextStaged(ctx context.Context, payload TextRequest) (*APIResponse, error) {
+	return d.c.SendTextStaged(d.bind(ctx), payload)
+}
+
+// SendImageStaged is Client.SendImageStaged for the bound device.
+func (d *DeviceClient) SendImageStaged(ctx context.Context, payload ImageRequest) (*APIResponse, error) {
+	return d.c.SendImageStaged(d.bind(ctx), payload)
+}
+
+// Refresh asks the bound device to repaint (see Client.Refresh).
+func (d *DeviceClient) Refresh(ctx context.Context) (*APIResponse, error) {
+	return d.c.Refresh(d.bind(ctx), "")
+}
+
+// LastSent returns the most recent content the parent client sent to the bound device, or
+// to the default device when unbound.
+func (d *DeviceClient) LastSent() (SentRecord, bool) {
+	if d.id == "" {
+		return d.c.LastSent(d.c.GetDefaultDeviceID())
+	}
+	return d.c.LastSent(d.id)
+}
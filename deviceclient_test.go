@@ -0,0 +1,121 @@
+package quote0
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/1set/quote0/quote0test"
+)
+
+func TestForDevice_SharesLimiter(t *testing.T) {
+	var mu sync.Mutex
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			DeviceID string `json:"deviceId"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		got = append(got, body.DeviceID)
+		mu.Unlock()
+		okHandler(w, r)
+	}))
+	defer srv.Close()
+	clk := quote0test.NewFakeClock(time.Date(2025, 11, 10, 9, 0, 0, 0, time.UTC))
+	c, err := NewClient("test", WithBaseURL(srv.URL), WithDefaultDeviceID("DEFAULT"), WithClock(clk))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, b := c.ForDevice("A"), c.ForDevice(" B ")
+	if a.DeviceID() != "A" || b.DeviceID() != "B" || a.Client() != c {
+		t.Fatalf("bound to %q and %q", a.DeviceID(), b.DeviceID())
+	}
+	sent := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), got...)
+	}
+
+	if _, err := a.SendTextSimple("t", "m", "sig"); err != nil {
+		t.Fatal(err)
+	}
+	// B's call waits out the 1 QPS interval that A's call started on the shared limiter.
+	done := make(chan error, 1)
+	go func() {
+		_, err := b.SendImageSimple("AA==")
+		done <- err
+	}()
+	clk.BlockUntil(1)
+	if n := len(sent()); n != 1 {
+		t.Fatalf("second device sent before the interval: %d calls", n)
+	}
+	clk.Advance(time.Second)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	// A third sub-client queues behind both.
+	go func() {
+		_, err := c.ForDevice("C").Refresh(context.Background())
+		done <- err
+	}()
+	clk.BlockUntil(1)
+	clk.Advance(time.Second)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if s := sent(); len(s) != 3 || s[0] != "A" || s[1] != "B" || s[2] != "C" {
+		t.Fatalf("sent to %q", s)
+	}
+	if rec, ok := a.LastSent(); !ok || rec.Text == nil || rec.Text.Title != "t" || rec.Text.Signature != "sig" || !*rec.Text.RefreshNow {
+		t.Fatalf("LastSent = %+v, %v", rec, ok)
+	}
+}
+
+func TestForDevice_Precedence(t *testing.T) {
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			DeviceID string `json:"deviceId"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		got = append(got, body.DeviceID)
+		okHandler(w, r)
+	}))
+	defer srv.Close()
+	c, err := NewClient("test", WithBaseURL(srv.URL), WithRateLimiter(nil), WithDefaultDeviceID("DEFAULT"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := c.ForDevice("BOUND")
+	ctx := ContextWithDeviceID(context.Background(), "CTX")
+	calls := []func() error{
+		func() error { _, err := d.SendText(ctx, TextRequest{Title: "t"}); return err },
+		func() error { _, err := d.SendText(ctx, TextRequest{Title: "t", DeviceID: "FIELD"}); return err },
+		func() error {
+			_, err := d.SendImage(ctx, ImageRequest{Image: "AA=="}, WithDevice("OPTION"))
+			return err
+		},
+		func() error { _, err := c.ForDevice("").SendText(ctx, TextRequest{Title: "t"}); return err },
+		func() error {
+			_, err := c.ForDevice("").SendText(context.Background(), TextRequest{Title: "t"})
+			return err
+		},
+	}
+	for i, call := range calls {
+		if err := call(); err != nil {
+			t.Fatalf("call %d: %v", i, err)
+		}
+	}
+	want := []string{"BOUND", "FIELD", "OPTION", "CTX", "DEFAULT"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("sent to %q, want %q", got, want)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package quote0
+
+import (
+	"context"
+	"strings"
+)
+
+type deviceIDKey struct{}
+
+// ContextWithDeviceID returns a context whose API calls target device id when the request
+// names none, e.g. set by per-tenant middleware so business code can build requests without
+// knowing the routing. The request's DeviceID (or WithDevice) still wins, and the client's
+// default device applies only when neither is set. A blank id leaves ctx unchanged.
+func ContextWithDeviceID(ctx context.Context, id string) context.Context {
+	if id = strings.TrimSpace(id); id == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, deviceIDKey{}, id)
+}
+
+// DeviceIDFromContext returns the device set by ContextWithDeviceID, or "".
+func DeviceIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(deviceIDKey{}).(string)
+	return id
+}
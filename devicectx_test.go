@@ -0,0 +1,80 @@
+package quote0
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestContextWithDeviceID(t *testing.T) {
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			DeviceID string `json:"deviceId"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		got = append(got, body.DeviceID)
+		okHandler(w, r)
+	}))
+	defer srv.Close()
+	c, err := NewClient("test", WithBaseURL(srv.URL), WithRateLimiter(nil), WithDefaultDeviceID("DEFAULT"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bg := context.Background()
+	tenant := ContextWithDeviceID(bg, " TENANT ")
+	if id := DeviceIDFromContext(tenant); id != "TENANT" {
+		t.Fatalf("DeviceIDFromContext = %q", id)
+	}
+
+	steps := []struct {
+		name string
+		send func() error
+		want string
+	}{
+		{"field beats context", func() error {
+			_, err := c.SendText(tenant, TextRequest{Title: "t", DeviceID: "FIELD"})
+			return err
+		}, "FIELD"},
+		{"option beats context", func() error {
+			_, err := c.SendImage(tenant, ImageRequest{Image: "AA=="}, WithDevice("OPTION"))
+			return err
+		}, "OPTION"},
+		{"context beats default", func() error {
+			_, err := c.SendText(tenant, TextRequest{Title: "t"})
+			return err
+		}, "TENANT"},
+		{"blank context value ignored", func() error {
+			_, err := c.SendText(ContextWithDeviceID(bg, "  "), TextRequest{Title: "t"})
+			return err
+		}, "DEFAULT"},
+		{"blank value keeps outer one", func() error {
+			_, err := c.Refresh(ContextWithDeviceID(tenant, ""), "")
+			return err
+		}, "TENANT"},
+		{"batch item", func() error {
+			_, err := c.SendBatch(tenant, []BatchItem{{Text: &TextRequest{Title: "t"}}})
+			return err
+		}, "TENANT"},
+	}
+	for i, s := range steps {
+		if err := s.send(); err != nil {
+			t.Fatalf("%s: %v", s.name, err)
+		}
+		if got[i] != s.want {
+			t.Fatalf("%s: sent to %q, want %q", s.name, got[i], s.want)
+		}
+	}
+
+	// Without a default, the context alone is enough, and an empty one still fails.
+	c.SetDefaultDeviceID("")
+	if _, err := c.SendText(tenant, TextRequest{Title: "t"}); err != nil || got[len(got)-1] != "TENANT" {
+		t.Fatalf("context-only send: %v, sent to %q", err, got[len(got)-1])
+	}
+	if _, err := c.SendText(ContextWithDeviceID(bg, ""), TextRequest{Title: "t"}); !errors.Is(err, ErrDeviceIDMissing) {
+		t.Fatalf("no device: %v", err)
+	}
+}
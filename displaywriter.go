@@ -0,0 +1,257 @@
+package quote0
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+const (
+	defaultDisplayLines    = 3
+	defaultDisplayInterval = 30 * time.Second
+	// maxPartialLine bounds a line still waiting for its newline; longer input is shown as is.
+	maxPartialLine = 64 << 10
+)
+
+// ErrWriterClosed is returned by DisplayWriter.Write after Close.
+var ErrWriterClosed = errors.New("quote0: display writer is closed")
+
+// DisplayUpdate reports one send made by a DisplayWriter.
+type DisplayUpdate struct {
+	// Request is the text request that was sent.
+	Request TextRequest
+	// At is when the send finished.
+	At time.Time
+	// Response is the API response for a successful send.
+	Response *APIResponse
+	// Err is the send failure, if any.
+	Err error
+}
+
+// DisplayWriterOption tunes NewDisplayWriter.
+type DisplayWriterOption func(*displayWriterConfig)
+
+type displayWriterConfig struct {
+	lines     int
+	interval  time.Duration
+	template  TextRequest
+	signature func(time.Time) string
+	onUpdate  func(DisplayUpdate)
+	now       func() time.Time
+	markdown  bool
+}
+
+// WithDisplayLines keeps the last n lines on the display (default 3, the message area).
+func WithDisplayLines(n int) DisplayWriterOption {
+	return func(cfg *displayWriterConfig) {
+		if n > 0 {
+			cfg.lines = n
+		}
+	}
+}
+
+// WithDisplayInterval sends at most one update per d (default 30s).
+func WithDisplayInterval(d time.Duration) DisplayWriterOption {
+	return func(cfg *displayWriterConfig) {
+		if d > 0 {
+			cfg.interval = d
+		}
+	}
+}
+
+// WithDisplayTemplate sets the fixed fields of every update (title, icon, link, device,
+// refresh); its Message is replaced by the written lines.
+func WithDisplayTemplate(req TextRequest) DisplayWriterOption {
+	return func(cfg *displayWriterConfig) { cfg.template = req }
+}
+
+// WithDisplaySignature computes the signature of each update from its send time, for
+// example with FormatSignature. It overrides the template signature.
+func WithDisplaySignature(fn func(time.Time) string) DisplayWriterOption {
+	return func(cfg *displayWriterConfig) { cfg.signature = fn }
+}
+
+// WithDisplayCallback receives an event after every send. It runs with the writer locked;
+// it must not call the writer.
+func WithDisplayCallback(fn func(DisplayUpdate)) DisplayWriterOption {
+	return func(cfg *displayWriterConfig) { cfg.onUpdate = fn }
+}
+
+// WithDisplayMarkdown strips Markdown from each line with StripMarkdown before it is
+// sanitized, so list markers become "• " and links their labels. Lines are converted one at a
+// time: paragraphs are not joined and fenced code blocks are not tracked.
+func WithDisplayMarkdown() DisplayWriterOption {
+	return func(cfg *displayWriterConfig) { cfg.markdown = true }
+}
+
+// DisplayWriter is an io.WriteCloser that shows the latest lines written to it as the message
+// of a text screen. Lines are sanitized (terminal escapes and control characters removed)
+// and cut to the screen width; blank lines are skipped. An update is sent when the shown
+// lines change, at most once per interval: the first change is sent at once and later ones
+// when the interval has passed. Close sends any pending change.
+//
+// Send failures do not fail Write, so a stream keeps draining; they are reported through
+// the callback, and Close returns the error of its final send.
+type DisplayWriter struct {
+	client *Client
+	ctx    context.Context
+	cfg    displayWriterConfig
+
+	mu       sync.Mutex
+	partial  []byte
+	window   []string
+	sent     string
+	lastSend time.Time
+	timer    *time.Timer
+	closed   bool
+}
+
+// NewDisplayWriter returns a DisplayWriter that sends through c with ctx.
+func (c *Client) NewDisplayWriter(ctx context.Context, opts ...DisplayWriterOption) *DisplayWriter {
+	cfg := displayWriterConfig{
+		lines:    defaultDisplayLines,
+		interval: defaultDisplayInterval,
+		now:      time.Now,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&cfg)
+		}
+	}
+	return &DisplayWriter{client: c, ctx: ctx, cfg: cfg}
+}
+
+// Write adds p to the stream. Only complete lines are shown; a trailing partial line waits
+// for its newline or for Close.
+func (w *DisplayWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return 0, ErrWriterClosed
+	}
+	w.partial = append(w.partial, p...)
+	for {
+		i := bytes.IndexByte(w.partial, '\n')
+		if i < 0 {
+			break
+		}
+		w.addLine(string(w.partial[:i]))
+		w.partial = w.partial[i+1:]
+	}
+	if len(w.partial) > maxPartialLine {
+		w.addLine(string(w.partial))
+		w.partial = nil
+	}
+	w.schedule()
+	return len(p), nil
+}
+
+// Flush sends the shown lines now if they changed since the last update, ignoring the
+// interval, and returns the send error.
+func (w *DisplayWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.send()
+}
+
+// Close shows any trailing partial line, sends a pending change, and stops the writer.
+func (w *DisplayWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+	if len(w.partial) > 0 {
+		w.addLine(string(w.partial))
+		w.partial = nil
+	}
+	return w.send()
+}
+
+func (w *DisplayWriter) addLine(line string) {
+	if w.cfg.markdown {
+		line, _ = StripMarkdown(line)
+	}
+	line = FitText(SanitizeLine(line), ScreenWidth-2*textMargin, 1)
+	if line == "" {
+		return
+	}
+	w.window = append(w.window, line)
+	if len(w.window) > w.cfg.lines {
+		w.window = w.window[len(w.window)-w.cfg.lines:]
+	}
+}
+
+// schedule sends a change now when the interval has passed, or arms a timer for it.
+func (w *DisplayWriter) schedule() {
+	if w.message() == w.sent || w.timer != nil {
+		return
+	}
+	wait := w.cfg.interval - w.cfg.now().Sub(w.lastSend)
+	if w.lastSend.IsZero() || wait <= 0 {
+		_ = w.send()
+		return
+	}
+	w.timer = time.AfterFunc(wait, func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		w.timer = nil
+		if !w.closed && w.ctx.Err() == nil {
+			_ = w.send()
+		}
+	})
+}
+
+func (w *DisplayWriter) message() string { return strings.Join(w.window, "\n") }
+
+// send posts the shown lines if they changed; the caller holds w.mu.
+func (w *DisplayWriter) send() error {
+	msg := w.message()
+	if msg == w.sent {
+		return nil
+	}
+	req := w.cfg.template
+	req.Message = msg
+	if w.cfg.signature != nil {
+		req.Signature = w.cfg.signature(w.cfg.now())
+	}
+	resp, err := w.client.SendText(w.ctx, req)
+	w.lastSend = w.cfg.now()
+	if err == nil {
+		w.sent = msg
+	}
+	if w.cfg.onUpdate != nil {
+		w.cfg.onUpdate(DisplayUpdate{Request: req, At: w.lastSend, Response: resp, Err: err})
+	}
+	return err
+}
+
+// ansiEscape matches terminal escape sequences such as colors (CSI) and titles (OSC).
+var ansiEscape = regexp.MustCompile(`\x1b(\[[0-?]*[ -/]*[@-~]|\][^\x07\x1b]*(\x07|\x1b\\)|[@-_])`)
+
+// SanitizeLine prepares one line of program output for the display: terminal escape
+// sequences are removed, tabs become spaces, other control characters and invalid UTF-8 are
+// dropped, and runs of spaces are collapsed.
+func SanitizeLine(s string) string {
+	s = ansiEscape.ReplaceAllString(strings.ToValidUTF8(s, ""), "")
+	s = strings.Map(func(r rune) rune {
+		switch {
+		case r == '\t':
+			return ' '
+		case unicode.IsControl(r):
+			return -1
+		}
+		return r
+	}, s)
+	return singleLine(s)
+}
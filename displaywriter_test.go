@@ -0,0 +1,134 @@
+package quote0
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// textRecorder is a fake API that records every text request.
+type textRecorder struct {
+	mu   sync.Mutex
+	reqs []TextRequest
+}
+
+func (r *textRecorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	var tr TextRequest
+	_ = json.NewDecoder(req.Body).Decode(&tr)
+	r.mu.Lock()
+	r.reqs = append(r.reqs, tr)
+	r.mu.Unlock()
+	_, _ = io.WriteString(w, `{"code":0,"message":"ok"}`)
+}
+
+func (r *textRecorder) sent() []TextRequest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]TextRequest(nil), r.reqs...)
+}
+
+func newRecordingClient(t *testing.T) (*Client, *textRecorder) {
+	t.Helper()
+	rec := &textRecorder{}
+	srv := httptest.NewServer(rec)
+	t.Cleanup(srv.Close)
+	c, err := NewClient("test", WithBaseURL(srv.URL), WithDefaultDeviceID("D"), WithRateLimiter(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c, rec
+}
+
+func TestDisplayWriter(t *testing.T) {
+	c, rec := newRecordingClient(t)
+	var updates []DisplayUpdate
+	w := c.NewDisplayWriter(context.Background(),
+		WithDisplayInterval(time.Hour),
+		WithDisplayTemplate(TextRequest{Title: "journal", Signature: "ignored"}),
+		WithDisplaySignature(func(time.Time) string { return "now" }),
+		WithDisplayCallback(func(u DisplayUpdate) { updates = append(updates, u) }))
+
+	fmt.Fprint(w, "one\ntwo\n\n\x1b[31mthree\x1b[0m\tred\nfour\npart")
+	// The first change is sent at once; later ones wait for the interval or Close.
+	fmt.Fprint(w, "ial\nfive\n")
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	got := rec.sent()
+	if len(got) != 2 || len(updates) != 2 {
+		t.Fatalf("sent %d updates: %+v", len(got), got)
+	}
+	if got[0].Message != "two\nthree red\nfour" || got[0].Title != "journal" || got[0].Signature != "now" {
+		t.Fatalf("first update %+v", got[0])
+	}
+	if got[1].Message != "four\npartial\nfive" {
+		t.Fatalf("final update %q", got[1].Message)
+	}
+	if _, err := w.Write([]byte("x\n")); err != ErrWriterClosed {
+		t.Fatalf("write after close: %v", err)
+	}
+}
+
+func TestDisplayWriter_IntervalAndUnchanged(t *testing.T) {
+	c, rec := newRecordingClient(t)
+	w := c.NewDisplayWriter(context.Background(), WithDisplayInterval(20*time.Millisecond), WithDisplayLines(1))
+	fmt.Fprintln(w, "a")
+	fmt.Fprintln(w, "b")
+	fmt.Fprintln(w, "b") // same window as the pending change
+	deadline := time.Now().Add(5 * time.Second)
+	for len(rec.sent()) < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the interval update")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	// Nothing changed since the last update, so Close sends nothing.
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	got := rec.sent()
+	if len(got) != 2 || got[0].Message != "a" || got[1].Message != "b" {
+		t.Fatalf("sent %+v", got)
+	}
+}
+
+func TestDisplayWriter_LongLine(t *testing.T) {
+	c, rec := newRecordingClient(t)
+	w := c.NewDisplayWriter(context.Background())
+	fmt.Fprintln(w, strings.Repeat("x", 500))
+	_ = w.Close()
+	msg := rec.sent()[0].Message
+	if !strings.HasSuffix(msg, "…") || TextWidth(msg, 1) > ScreenWidth-2*textMargin {
+		t.Fatalf("message %q not cut to the screen width", msg)
+	}
+}
+
+func TestDisplayWriter_Markdown(t *testing.T) {
+	c, rec := newRecordingClient(t)
+	w := c.NewDisplayWriter(context.Background(), WithDisplayMarkdown())
+	fmt.Fprint(w, "# Deploy\n- **web** [ok](https://ci/1)\n\t* `db` _slow_\n")
+	_ = w.Close()
+	if msg := rec.sent()[0].Message; msg != "Deploy\n• web ok\n• db slow" {
+		t.Fatalf("message %q", msg)
+	}
+}
+
+func TestSanitizeLine(t *testing.T) {
+	for in, want := range map[string]string{
+		"\x1b[1;32mOK\x1b[0m  done":     "OK done",
+		"tab\there\r":                   "tab here",
+		"\x1b]0;title\x07bell\x00 zero": "bell zero",
+		"bad \xff utf8":                 "bad utf8",
+	} {
+		if got := SanitizeLine(in); got != want {
+			t.Errorf("SanitizeLine(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package quote0
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+)
+
+// ditherTap is one error-diffusion neighbor: offset (dx, dy) receives weight/divisor of the error.
+type ditherTap struct {
+	dx, dy int
+	weight float64
+}
+
+type diffusionKernel struct {
+	divisor float64
+	taps    []ditherTap
+}
+
+// diffusionKernels mirrors the kernels the server accepts for DitherDiffusion.
+// KernelThreshold has no taps: pixels are binarized without spreading error.
+var diffusionKernels = map[DitherKernel]diffusionKernel{
+	KernelFloydSteinberg: {16, []ditherTap{{1, 0, 7}, {-1, 1, 3}, {0, 1, 5}, {1, 1, 1}}},
+	KernelAtkinson:       {8, []ditherTap{{1, 0, 1}, {2, 0, 1}, {-1, 1, 1}, {0, 1, 1}, {1, 1, 1}, {0, 2, 1}}},
+	KernelBurkes:         {32, []ditherTap{{1, 0, 8}, {2, 0, 4}, {-2, 1, 2}, {-1, 1, 4}, {0, 1, 8}, {1, 1, 4}, {2, 1, 2}}},
+	KernelSierra2:        {16, []ditherTap{{1, 0, 4}, {2, 0, 3}, {-2, 1, 1}, {-1, 1, 2}, {0, 1, 3}, {1, 1, 2}, {2, 1, 1}}},
+	KernelStucki: {42, []ditherTap{
+		{1, 0, 8}, {2, 0, 4},
+		{-2, 1, 2}, {-1, 1, 4}, {0, 1, 8}, {1, 1, 4}, {2, 1, 2},
+		{-2, 2, 1}, {-1, 2, 2}, {0, 2, 4}, {1, 2, 2}, {2, 2, 1},
+	}},
+	KernelJarvisJudiceNinke: {48, []ditherTap{
+		{1, 0, 7}, {2, 0, 5},
+		{-2, 1, 3}, {-1, 1, 5}, {0, 1, 7}, {1, 1, 5}, {2, 1, 3},
+		{-2, 2, 1}, {-1, 2, 3}, {0, 2, 5}, {1, 2, 3}, {2, 2, 1},
+	}},
+	KernelDiffusionRow:    {1, []ditherTap{{1, 0, 1}}},
+	KernelDiffusionColumn: {1, []ditherTap{{0, 1, 1}}},
+	KernelDiffusion2D:     {2, []ditherTap{{1, 0, 1}, {0, 1, 1}}},
+	KernelThreshold:       {1, nil},
+}
+
+// DitherKernels lists the kernels accepted for DitherDiffusion, in a stable order.
+func DitherKernels() []DitherKernel {
+	return []DitherKernel{
+		KernelFloydSteinberg, KernelAtkinson, KernelBurkes, KernelSierra2, KernelStucki,
+		KernelJarvisJudiceNinke, KernelDiffusionRow, KernelDiffusionColumn, KernelDiffusion2D,
+		KernelThreshold,
+	}
+}
+
+// bayer4 is the 4x4 ordered-dither threshold matrix (values 0..15).
+var bayer4 = [4][4]int{
+	{0, 8, 2, 10},
+	{12, 4, 14, 6},
+	{3, 11, 1, 9},
+	{15, 7, 13, 5},
+}
+
+// DitherOption configures Dither.
+type DitherOption func(*ditherConfig)
+
+type ditherConfig struct {
+	serpentine bool
+}
+
+// WithSerpentine scans odd rows right to left, mirroring the kernel, for every DIFFUSION
+// kernel. The alternating direction breaks up the diagonal "worm" streaks that one-way
+// scanning leaves in smooth gradients. The server always scans left to right, so the result
+// no longer previews what it would send.
+func WithSerpentine(on bool) DitherOption {
+	return func(cfg *ditherConfig) { cfg.serpentine = on }
+}
+
+// Dither converts src to black and white the way the server does for the given settings,
+// so the result approximates what the panel shows. An empty DitherType means DIFFUSION and
+// an empty kernel means FLOYD_STEINBERG, matching the server defaults; the kernel is ignored
+// for ORDERED and NONE. Kernels added with RegisterKernel are accepted too. Unknown types,
+// and unknown kernels for DIFFUSION, are errors.
+func Dither(src image.Image, t DitherType, k DitherKernel, opts ...DitherOption) (*image.Gray, error) {
+	var cfg ditherConfig
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&cfg)
+		}
+	}
+	if t == "" {
+		t = DitherDiffusion
+	}
+	if k == "" {
+		k = KernelFloydSteinberg
+	}
+	b := src.Bounds()
+	out := image.NewGray(image.Rect(0, 0, b.Dx(), b.Dy()))
+	switch t {
+	case DitherNone:
+		eachGray(src, func(x, y int, v uint8) {
+			out.Pix[y*out.Stride+x] = threshold(int(v), 128)
+		})
+	case DitherOrdered:
+		eachGray(src, func(x, y int, v uint8) {
+			level := (bayer4[y%4][x%4]*2 + 1) * 256 / 32
+			out.Pix[y*out.Stride+x] = threshold(int(v), level)
+		})
+	case DitherDiffusion:
+		kernel, ok := lookupKernel(k)
+		if !ok {
+			return nil, fmt.Errorf("quote0: unknown dither kernel %q", k)
+		}
+		diffuse(src, out, kernel, cfg.serpentine)
+	default:
+		return nil, fmt.Errorf("quote0: unknown dither type %q", t)
+	}
+	return out, nil
+}
+
+// eachGray visits src in row order with coordinates relative to its bounds.
+func eachGray(src image.Image, fn func(x, y int, v uint8)) {
+	b := src.Bounds()
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			fn(x, y, color.GrayModel.Convert(src.At(b.Min.X+x, b.Min.Y+y)).(color.Gray).Y)
+		}
+	}
+}
+
+// diffuse spreads each pixel's quantization error to its unvisited neighbors. The share is
+// truncated towards zero, as integer division would, so the built-in kernels match the server.
+// With serpentine, odd rows run right to left with the kernel mirrored.
+func diffuse(src image.Image, out *image.Gray, k diffusionKernel, serpentine bool) {
+	w, h := out.Rect.Dx(), out.Rect.Dy()
+	levels := make([]int, w*h)
+	eachGray(src, func(x, y int, v uint8) { levels[y*w+x] = int(v) })
+	for y := 0; y < h; y++ {
+		dir := 1
+		if serpentine && y%2 == 1 {
+			dir = -1
+		}
+		for i := 0; i < w; i++ {
+			x := i
+			if dir < 0 {
+				x = w - 1 - i
+			}
+			old := levels[y*w+x]
+			px := threshold(old, 128)
+			out.Pix[y*out.Stride+x] = px
+			if len(k.taps) == 0 {
+				continue
+			}
+			e := float64(old - int(px))
+			for _, t := range k.taps {
+				nx, ny := x+t.dx*dir, y+t.dy
+				if nx >= 0 && nx < w && ny < h {
+					levels[ny*w+nx] += int(e * t.weight / k.divisor)
+				}
+			}
+		}
+	}
+}
+
+// PackMonochrome packs img into one bit per pixel, rows top to bottom, each row starting on
+// a byte boundary with the leftmost pixel in the most significant bit. A set bit is a black
+// pixel (gray level below 128); dither the image first to control how grays are mapped.
+// A 296x152 screen packs into 37 bytes per row, 5624 bytes in total.
+func PackMonochrome(img *image.Gray) []byte {
+	b := img.Bounds()
+	stride := (b.Dx() + 7) / 8
+	out := make([]byte, stride*b.Dy())
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			if img.GrayAt(b.Min.X+x, b.Min.Y+y).Y < 128 {
+				out[y*stride+x/8] |= 0x80 >> (x % 8)
+			}
+		}
+	}
+	return out
+}
+
+// toneRadius is the box-blur radius ToneError views images through, roughly the spread of
+// dither patterns at reading distance.
+const toneRadius = 2
+
+// ToneError estimates how far dithered departs from the tones of src when seen from a
+// distance: both are blurred with a 5x5 box filter and compared by root mean square, in gray
+// levels (0-255). Lower is better. The images must have the same size.
+func ToneError(src, dithered image.Image) (float64, error) {
+	sb, db := src.Bounds(), dithered.Bounds()
+	if sb.Dx() != db.Dx() || sb.Dy() != db.Dy() {
+		return 0, fmt.Errorf("quote0: tone error needs equal sizes, got %dx%d and %dx%d", sb.Dx(), sb.Dy(), db.Dx(), db.Dy())
+	}
+	w, h := sb.Dx(), sb.Dy()
+	if w == 0 || h == 0 {
+		return 0, nil
+	}
+	a, b := boxBlur(src, toneRadius), boxBlur(dithered, toneRadius)
+	var sum float64
+	for i := range a {
+		d := a[i] - b[i]
+		sum += d * d
+	}
+	return math.Sqrt(sum / float64(len(a))), nil
+}
+
+// boxBlur returns the mean gray level around each pixel, with the window clipped at the edges.
+func boxBlur(src image.Image, r int) []float64 {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	// Summed-area table with a zero row and column in front.
+	sat := make([]float64, (w+1)*(h+1))
+	eachGray(src, func(x, y int, v uint8) {
+		sat[(y+1)*(w+1)+x+1] = float64(v) + sat[y*(w+1)+x+1] + sat[(y+1)*(w+1)+x] - sat[y*(w+1)+x]
+	})
+	out := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		y0, y1 := maxInt(y-r, 0), minInt(y+r+1, h)
+		for x := 0; x < w; x++ {
+			x0, x1 := maxInt(x-r, 0), minInt(x+r+1, w)
+			sum := sat[y1*(w+1)+x1] - sat[y0*(w+1)+x1] - sat[y1*(w+1)+x0] + sat[y0*(w+1)+x0]
+			out[y*w+x] = sum / float64((x1-x0)*(y1-y0))
+		}
+	}
+	return out
+}
+
+func threshold(v, level int) uint8 {
+	if v >= level {
+		return White.Y
+	}
+	return Black.Y
+}
@@ -0,0 +1,15 @@
+package quote0
+
+// dryRunMessage is the APIResponse.Message of a call answered by WithDryRun.
+const dryRunMessage = "dry-run"
+
+// WithDryRun makes the client do everything a call does except send it: device resolution,
+// image loading and processing, validation, and JSON encoding all run and their errors are
+// returned as usual, but instead of an HTTP request the call returns
+// APIResponse{Code: 0, Message: "dry-run"} with RequestBody holding the exact JSON that
+// would have been posted and Attempts 0. The rate limiter, retries, failover, and Hooks are
+// not involved; LastSent, History, and WithLogger see the call as a success.
+// With dry-run set, NewClient accepts an empty API token.
+func WithDryRun() ClientOption {
+	return func(c *Client) { c.dryRun = true }
+}
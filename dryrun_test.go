@@ -0,0 +1,63 @@
+package quote0
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWithDryRun(t *testing.T) {
+	hit := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { hit = true }))
+	defer srv.Close()
+	limiter := RateLimiterFunc(func(context.Context) error { t.Error("limiter consulted"); return nil })
+	c, err := NewClient("", WithDryRun(), WithBaseURL(srv.URL), WithRateLimiter(limiter),
+		WithDefaultDeviceID("DEF"), WithHooks(HookFuncs{Before: func(context.Context, string, []byte) { t.Error("hook called") }}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	resp, err := c.SendText(ctx, TextRequest{Title: "Hi"}, WithRefresh(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Code != 0 || resp.Message != "dry-run" || resp.Attempts != 0 {
+		t.Fatalf("resp %+v", resp)
+	}
+	var text TextRequest
+	if err := json.Unmarshal(resp.RequestBody, &text); err != nil || text.DeviceID != "DEF" || text.Title != "Hi" || !*text.RefreshNow {
+		t.Fatalf("body %s, err %v", resp.RequestBody, err)
+	}
+
+	img := pngBytes(t, ScreenWidth, ScreenHeight)
+	resp, err = c.SendImage(ctx, ImageRequest{ImageBytes: img, DeviceID: "D2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var image struct{ Image, DeviceID string }
+	if err := json.Unmarshal(resp.RequestBody, &image); err != nil || image.DeviceID != "D2" || image.Image != base64.StdEncoding.EncodeToString(img) {
+		t.Fatalf("image body: %v", err)
+	}
+	if _, ok := c.LastSent("D2"); !ok {
+		t.Fatal("dry-run image not tracked by LastSent")
+	}
+
+	if _, err := c.SendImage(ctx, ImageRequest{}); !errors.Is(err, ErrImagePayloadMissing) {
+		t.Fatalf("missing image: %v", err)
+	}
+	noDefault, _ := NewClient("", WithDryRun())
+	if _, err := noDefault.SendText(ctx, TextRequest{Title: "x"}); !errors.Is(err, ErrDeviceIDMissing) {
+		t.Fatalf("missing device: %v", err)
+	}
+	if hit {
+		t.Fatal("dry-run reached the server")
+	}
+	if _, err := NewClient(""); err == nil {
+		t.Fatal("empty token accepted without dry-run")
+	}
+}
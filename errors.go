@@ -1,10 +1,14 @@
 package quote0
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"net"
+	"net/url"
 	"strconv"
 	"strings"
+	"time"
 )
 
 var (
@@ -16,6 +20,19 @@ var (
 	ErrTitleMissing = errors.New("quote0: title is required")
 	// ErrMessageMissing indicates message is required.
 	ErrMessageMissing = errors.New("quote0: message is required")
+	// ErrInvalidText indicates a text field is not valid UTF-8.
+	ErrInvalidText = errors.New("quote0: text must be valid UTF-8")
+	// ErrInvalidImage indicates an image or icon payload is not a decodable PNG.
+	ErrInvalidImage = errors.New("quote0: image is not a valid PNG")
+	// ErrImageSize indicates an image does not match the 296x152 screen.
+	ErrImageSize = errors.New("quote0: image must be 296x152 pixels")
+	// ErrIconSize indicates an icon is not 40x40 pixels.
+	ErrIconSize = errors.New("quote0: icon must be 40x40 pixels")
+	// ErrUnauthorized matches, through errors.Is, an APIError with HTTP status 401 or 403.
+	ErrUnauthorized = errors.New("quote0: token rejected")
+	// ErrDeviceNotFound matches, through errors.Is, an APIError with HTTP status 404, which the
+	// service returns for unknown devices or devices not bound to the token.
+	ErrDeviceNotFound = errors.New("quote0: device not found")
 )
 
 // APIError captures non-2xx responses. The service may return JSON or plain text (e.g. Chinese).
@@ -27,6 +44,15 @@ type APIError struct {
 	Message string
 	// RawBody keeps the original payload for debugging.
 	RawBody []byte
+	// Trace holds the trace header values sent with the failed request (see WithTraceHeader).
+	Trace map[string]string
+	// RequestID is the X-Request-ID the failed request was sent with.
+	RequestID string
+	// Timing breaks down the failed attempt; nil unless WithTiming is set.
+	Timing *Timing
+	// RetryAfter is the cooldown the client applied for a rate-limited response: the
+	// server's Retry-After, or the WithRateLimitPenalty default without one.
+	RetryAfter time.Duration
 }
 
 func (e *APIError) Error() string {
@@ -45,22 +71,60 @@ func (e *APIError) Error() string {
 	return b.String()
 }
 
-// IsRateLimitError returns true if err is an APIError with HTTP status 429 (Too Many Requests).
-func IsRateLimitError(err error) bool {
-	if ae, ok := err.(*APIError); ok {
-		return ae.StatusCode == 429
+// Is lets errors.Is match ErrUnauthorized for 401/403 and ErrDeviceNotFound for 404.
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.StatusCode == 401 || e.StatusCode == 403
+	case ErrDeviceNotFound:
+		return e.StatusCode == 404
 	}
 	return false
 }
 
-// IsAuthError returns true if err is an APIError with HTTP status 401 or 403 (authentication/authorization failure).
+// IsRateLimitError returns true if err is or wraps an APIError with HTTP status 429 (Too Many Requests).
+func IsRateLimitError(err error) bool {
+	var ae *APIError
+	return errors.As(err, &ae) && ae.StatusCode == 429
+}
+
+// IsAuthError returns true if err is or wraps an APIError with HTTP status 401 or 403 (authentication/authorization failure).
 func IsAuthError(err error) bool {
-	if ae, ok := err.(*APIError); ok {
-		return ae.StatusCode == 401 || ae.StatusCode == 403
+	var ae *APIError
+	return errors.As(err, &ae) && (ae.StatusCode == 401 || ae.StatusCode == 403)
+}
+
+// IsDeviceError returns true if err is or wraps an APIError with HTTP status 404, which the
+// service returns for unknown devices or devices not bound to the token.
+func IsDeviceError(err error) bool {
+	var ae *APIError
+	return errors.As(err, &ae) && ae.StatusCode == 404
+}
+
+// IsValidationError returns true if err was raised by the SDK's local payload checks before
+// anything was sent (missing device, missing image, invalid or wrongly sized PNG, ...).
+func IsValidationError(err error) bool {
+	for _, target := range []error{
+		ErrDeviceIDMissing, ErrImagePayloadMissing, ErrTitleMissing, ErrMessageMissing,
+		ErrInvalidText, ErrInvalidImage, ErrImageSize, ErrIconSize, ErrImageTooSmall, ErrUnsupportedFormat,
+		ErrTooManyPairs, ErrInvalidAdjustment, ErrMonogramText, ErrSegmentOverflow,
+		ErrInvalidDataURI, ErrInvalidPattern, ErrInvalidKernel,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
 	}
 	return false
 }
 
+// IsNetworkError returns true if err is a transport failure (DNS, connection, TLS, timeout)
+// rather than a response from the service.
+func IsNetworkError(err error) bool {
+	var ue *url.Error
+	var ne net.Error
+	return errors.As(err, &ue) || errors.As(err, &ne) || errors.Is(err, context.DeadlineExceeded)
+}
+
 func buildAPIError(status int, body []byte) error {
 	trimmed := strings.TrimSpace(string(body))
 	ae := &APIError{StatusCode: status, RawBody: body, Message: trimmed}
@@ -0,0 +1,36 @@
+package quote0_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/1set/quote0"
+)
+
+func ExampleAPIResponse_DecodeResult() {
+	// resp would come from SendText or SendImage.
+	resp := &quote0.APIResponse{Code: 0, Message: "ok", Result: json.RawMessage(`{"taskId":"t-42","queued":true}`)}
+
+	var result struct {
+		TaskID string `json:"taskId"`
+		Queued bool   `json:"queued"`
+	}
+	if err := resp.DecodeResult(&result); err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	fmt.Println(result.TaskID, result.Queued)
+	// Output: t-42 true
+}
+
+func ExampleAPIResponse_DecodeResult_noResult() {
+	// A plain-text answer such as "发送成功" leaves Result empty.
+	resp := &quote0.APIResponse{StatusCode: 200, Message: "发送成功"}
+
+	var result map[string]interface{}
+	if err := resp.DecodeResult(&result); errors.Is(err, quote0.ErrNoResult) {
+		fmt.Println("no result:", resp.Message)
+	}
+	// Output: no result: 发送成功
+}
@@ -0,0 +1,130 @@
+package quote0
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultFailbackInterval is how long the client sticks to a fallback host before
+// probing the preferred base URL again.
+const defaultFailbackInterval = time.Minute
+
+// HostAttempt records the failure of one base URL during a failover sequence.
+type HostAttempt struct {
+	// BaseURL is the host that was tried.
+	BaseURL string
+	// Err is the transport error observed for that host.
+	Err error
+}
+
+// FailoverError is returned when every configured base URL failed with a transport error.
+// It unwraps to the last attempt's error.
+type FailoverError struct {
+	// Attempts lists each host tried, in order.
+	Attempts []HostAttempt
+}
+
+func (e *FailoverError) Error() string {
+	b := strings.Builder{}
+	b.WriteString("quote0: all base URLs failed")
+	for i, a := range e.Attempts {
+		if i == 0 {
+			b.WriteString(": ")
+		} else {
+			b.WriteString("; ")
+		}
+		b.WriteString(a.BaseURL)
+		b.WriteString(": ")
+		b.WriteString(a.Err.Error())
+	}
+	return b.String()
+}
+
+// Unwrap returns the last attempt's error so errors.Is/As see the final cause.
+func (e *FailoverError) Unwrap() error {
+	if len(e.Attempts) == 0 {
+		return nil
+	}
+	return e.Attempts[len(e.Attempts)-1].Err
+}
+
+// failoverState remembers which host last worked and when the client left the preferred one.
+type failoverState struct {
+	mu       sync.Mutex
+	active   int
+	leftAt   time.Time
+	interval time.Duration
+}
+
+// WithFallbackBaseURLs adds hosts tried in order when the preferred base URL fails with a
+// transport error (connection refused, DNS failure, reset, timeout). API answers such as
+// 4xx/5xx never trigger failover. The last working host is reused for subsequent calls and
+// the preferred host is re-probed periodically. Every attempt passes through the limiter.
+func WithFallbackBaseURLs(urls ...string) ClientOption {
+	return func(c *Client) {
+		for _, u := range urls {
+			if strings.TrimSpace(u) == "" {
+				continue
+			}
+			base, err := sanitizeBaseURL(u)
+			if err != nil {
+				c.optionError(err)
+				return
+			}
+			c.fallbackURLs = append(c.fallbackURLs, base)
+		}
+	}
+}
+
+// hostOrder returns the hosts to try for the next call, starting from the active one.
+// Once the failback interval has elapsed the preferred host is tried first again.
+func (c *Client) hostOrder() ([]string, []int) {
+	hosts := make([]string, 0, len(c.fallbackURLs)+1)
+	hosts = append(hosts, c.baseURL)
+	hosts = append(hosts, c.fallbackURLs...)
+
+	c.failover.mu.Lock()
+	start := c.failover.active
+	if start != 0 && c.clock.Now().Sub(c.failover.leftAt) >= c.failover.interval {
+		start = 0
+	}
+	c.failover.mu.Unlock()
+
+	order := make([]int, len(hosts))
+	for i := range order {
+		order[i] = (start + i) % len(hosts)
+	}
+	return hosts, order
+}
+
+// markHost records the host that answered. Switching to a fallback, or landing on it again
+// after a failed probe of the preferred host, restarts the probe timer.
+func (c *Client) markHost(idx int) {
+	c.failover.mu.Lock()
+	if idx != 0 && (c.failover.active != idx || c.clock.Now().Sub(c.failover.leftAt) >= c.failover.interval) {
+		c.failover.leftAt = c.clock.Now()
+	}
+	c.failover.active = idx
+	c.failover.mu.Unlock()
+}
+
+func (c *Client) doWithFailover(ctx context.Context, call *apiCall) (*APIResponse, error) {
+	hosts, order := c.hostOrder()
+	var attempts []HostAttempt
+	for _, idx := range order {
+		resp, transport, err := c.attempt(ctx, hosts[idx], call)
+		if transport {
+			attempts = append(attempts, HostAttempt{BaseURL: hosts[idx], Err: err})
+			continue
+		}
+		if err == nil || call.answered {
+			// The host answered, so it is reachable even if the call still failed, say with
+			// an API error or an oversized response.
+			c.markHost(idx)
+		}
+		return resp, err
+	}
+	return nil, &FailoverError{Attempts: attempts}
+}
@@ -0,0 +1,178 @@
+package quote0
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// flakyServer answers normally until down is set, then drops connections without a response.
+type flakyServer struct {
+	down int32
+	hits int32
+}
+
+func (s *flakyServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	atomic.AddInt32(&s.hits, 1)
+	if atomic.LoadInt32(&s.down) == 1 {
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err == nil {
+			conn.Close()
+		}
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = io.WriteString(w, `{"code":0,"message":"ok"}`)
+}
+
+// counts reads both servers' hit counters; dropped connections give no happens-before edge.
+func counts(a, b *flakyServer) (int32, int32) {
+	return atomic.LoadInt32(&a.hits), atomic.LoadInt32(&b.hits)
+}
+
+func TestFallbackBaseURLs_FailoverAndFailback(t *testing.T) {
+	relay := &flakyServer{}
+	official := &flakyServer{}
+	relaySrv := httptest.NewServer(relay)
+	defer relaySrv.Close()
+	officialSrv := httptest.NewServer(official)
+	defer officialSrv.Close()
+
+	var waits int32
+	limiter := RateLimiterFunc(func(context.Context) error {
+		atomic.AddInt32(&waits, 1)
+		return nil
+	})
+	c, err := NewClient("test",
+		WithBaseURL(relaySrv.URL),
+		WithFallbackBaseURLs(officialSrv.URL+"/"),
+		WithRateLimiter(limiter),
+		WithDefaultDeviceID("D"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	send := func() {
+		t.Helper()
+		if _, err := c.SendText(context.Background(), TextRequest{Message: "m"}); err != nil {
+			t.Fatalf("SendText: %v", err)
+		}
+	}
+
+	send()
+	if r, o := counts(relay, official); r != 1 || o != 0 {
+		t.Fatalf("healthy relay: relay=%d official=%d", r, o)
+	}
+
+	// Relay goes down mid-run: the same call is retried on the official host.
+	atomic.StoreInt32(&relay.down, 1)
+	send()
+	if r, o := counts(relay, official); r != 2 || o != 1 {
+		t.Fatalf("failover: relay=%d official=%d", r, o)
+	}
+	if w := atomic.LoadInt32(&waits); w != 3 {
+		t.Fatalf("every attempt must wait on the limiter, waits=%d", w)
+	}
+
+	// The working host is remembered; the relay is not retried before the probe interval.
+	send()
+	if r, o := counts(relay, official); r != 2 || o != 2 {
+		t.Fatalf("sticky fallback: relay=%d official=%d", r, o)
+	}
+
+	// Relay recovers; once the probe interval elapses the preferred host is used again.
+	atomic.StoreInt32(&relay.down, 0)
+	c.failover.mu.Lock()
+	c.failover.interval = 0
+	c.failover.mu.Unlock()
+	send()
+	if r, o := counts(relay, official); r != 3 || o != 2 {
+		t.Fatalf("failback: relay=%d official=%d", r, o)
+	}
+	c.failover.mu.Lock()
+	active := c.failover.active
+	c.failover.mu.Unlock()
+	if active != 0 {
+		t.Fatalf("active host=%d, want preferred", active)
+	}
+}
+
+func TestFallbackBaseURLs_AllHostsDown(t *testing.T) {
+	a := httptest.NewServer(http.NotFoundHandler())
+	b := httptest.NewServer(http.NotFoundHandler())
+	aURL, bURL := a.URL, b.URL
+	a.Close()
+	b.Close()
+
+	c, err := NewClient("test", WithBaseURL(aURL), WithFallbackBaseURLs(bURL), WithRateLimiter(nil), WithDefaultDeviceID("D"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.SendText(context.Background(), TextRequest{})
+	var fe *FailoverError
+	if !errors.As(err, &fe) {
+		t.Fatalf("want FailoverError, got %T %v", err, err)
+	}
+	if len(fe.Attempts) != 2 || fe.Attempts[0].BaseURL != aURL || fe.Attempts[1].BaseURL != bURL {
+		t.Fatalf("attempts: %+v", fe.Attempts)
+	}
+	if msg := err.Error(); !strings.Contains(msg, aURL) || !strings.Contains(msg, bURL) {
+		t.Fatalf("error should enumerate hosts: %s", msg)
+	}
+}
+
+func TestFallbackBaseURLs_APIErrorDoesNotFailover(t *testing.T) {
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = io.WriteString(w, "maintenance")
+	}))
+	defer primary.Close()
+	var fallbackHits int32
+	fallback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fallbackHits, 1)
+	}))
+	defer fallback.Close()
+
+	c, err := NewClient("test", WithBaseURL(primary.URL), WithFallbackBaseURLs(fallback.URL), WithRateLimiter(nil), WithDefaultDeviceID("D"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.SendText(context.Background(), TextRequest{})
+	if ae, ok := err.(*APIError); !ok || ae.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("want 503 APIError, got %v", err)
+	}
+	if hits := atomic.LoadInt32(&fallbackHits); hits != 0 {
+		t.Fatalf("fallback should not be used for API errors, hits=%d", hits)
+	}
+}
+
+func TestFallbackBaseURLs_OversizedResponseMarksHost(t *testing.T) {
+	relay := &flakyServer{down: 1}
+	relaySrv := httptest.NewServer(relay)
+	defer relaySrv.Close()
+	official := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, strings.Repeat("x", 64))
+	}))
+	defer official.Close()
+
+	c, err := NewClient("test", WithBaseURL(relaySrv.URL), WithFallbackBaseURLs(official.URL),
+		WithRateLimiter(nil), WithDefaultDeviceID("D"), WithMaxResponseBytes(16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		_, err = c.SendText(context.Background(), TextRequest{})
+		var tooLarge *ResponseTooLargeError
+		if !errors.As(err, &tooLarge) {
+			t.Fatalf("send %d: want ResponseTooLargeError, got %v", i, err)
+		}
+	}
+	// The fallback answered the first call, so the second one goes straight to it.
+	if hits := atomic.LoadInt32(&relay.hits); hits != 1 {
+		t.Fatalf("relay hits=%d, want 1", hits)
+	}
+}
@@ -0,0 +1,201 @@
+// Package feed turns RSS 2.0 and Atom feeds into Quote/0 text screens.
+//
+// ParseFeed reads either format into a common Item, and Ticker polls a feed URL and sends
+// the newest unseen item to a device. Only the Go standard library is used.
+package feed
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrUnknownFormat indicates the document is well-formed XML but neither RSS nor Atom.
+var ErrUnknownFormat = errors.New("feed: document is neither RSS nor Atom")
+
+// Item is a feed entry normalized across RSS and Atom.
+type Item struct {
+	// ID is the RSS guid or Atom id; it falls back to Link, then Title, when absent.
+	ID string
+	// Title is the plain-text headline with markup removed and entities decoded.
+	Title string
+	// Summary is the plain-text description (RSS) or summary/content (Atom).
+	Summary string
+	// Published is the publication time (Atom falls back to updated); zero when unknown.
+	Published time.Time
+	// Link is the item URL.
+	Link string
+}
+
+type rawText struct {
+	Body string `xml:",chardata"`
+}
+
+type rssItem struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	Description string `xml:"description"`
+	PubDate     string `xml:"pubDate"`
+	GUID        string `xml:"guid"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+}
+
+type atomEntry struct {
+	ID        string     `xml:"id"`
+	Title     rawText    `xml:"title"`
+	Summary   rawText    `xml:"summary"`
+	Content   rawText    `xml:"content"`
+	Published string     `xml:"published"`
+	Updated   string     `xml:"updated"`
+	Links     []atomLink `xml:"link"`
+}
+
+type rawDocument struct {
+	XMLName xml.Name
+	Channel struct {
+		Items []rssItem `xml:"item"`
+	} `xml:"channel"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+// ParseFeed decodes an RSS 2.0 or Atom document. Items keep document order; use Newest to
+// pick the most recent one. HTML named entities (such as &nbsp;) are accepted even though
+// they are not defined by XML, since real-world feeds use them freely.
+func ParseFeed(r io.Reader) ([]Item, error) {
+	dec := xml.NewDecoder(r)
+	dec.Strict = false
+	dec.Entity = xml.HTMLEntity
+	var doc rawDocument
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("feed: parse: %w", err)
+	}
+	switch strings.ToLower(doc.XMLName.Local) {
+	case "rss":
+		items := make([]Item, 0, len(doc.Channel.Items))
+		for _, it := range doc.Channel.Items {
+			items = append(items, fromRSS(it))
+		}
+		return items, nil
+	case "feed":
+		items := make([]Item, 0, len(doc.Entries))
+		for _, e := range doc.Entries {
+			items = append(items, fromAtom(e))
+		}
+		return items, nil
+	default:
+		return nil, ErrUnknownFormat
+	}
+}
+
+// Newest returns the item with the latest Published time. Items without dates lose to dated
+// ones; when no item has a date, the first item (feeds list newest first) is returned.
+func Newest(items []Item) (Item, bool) {
+	if len(items) == 0 {
+		return Item{}, false
+	}
+	sorted := make([]Item, len(items))
+	copy(sorted, items)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Published.After(sorted[j].Published)
+	})
+	return sorted[0], true
+}
+
+func fromRSS(it rssItem) Item {
+	item := Item{
+		ID:        strings.TrimSpace(it.GUID),
+		Title:     plainText(it.Title),
+		Summary:   plainText(it.Description),
+		Published: parseTime(it.PubDate),
+		Link:      strings.TrimSpace(it.Link),
+	}
+	return withFallbackID(item)
+}
+
+func fromAtom(e atomEntry) Item {
+	summary := e.Summary.Body
+	if strings.TrimSpace(summary) == "" {
+		summary = e.Content.Body
+	}
+	published := parseTime(e.Published)
+	if published.IsZero() {
+		published = parseTime(e.Updated)
+	}
+	item := Item{
+		ID:        strings.TrimSpace(e.ID),
+		Title:     plainText(e.Title.Body),
+		Summary:   plainText(summary),
+		Published: published,
+		Link:      atomAlternate(e.Links),
+	}
+	return withFallbackID(item)
+}
+
+// atomAlternate picks the rel="alternate" link, or the first link without a rel.
+func atomAlternate(links []atomLink) string {
+	for _, l := range links {
+		if l.Rel == "" || l.Rel == "alternate" {
+			return strings.TrimSpace(l.Href)
+		}
+	}
+	return ""
+}
+
+func withFallbackID(item Item) Item {
+	if item.ID == "" {
+		item.ID = item.Link
+	}
+	if item.ID == "" {
+		item.ID = item.Title
+	}
+	return item
+}
+
+var (
+	tagPattern        = regexp.MustCompile(`<[^>]*>`)
+	whitespacePattern = regexp.MustCompile(`\s+`)
+)
+
+// plainText strips markup, decodes (possibly double-escaped) entities, and collapses whitespace.
+func plainText(s string) string {
+	s = html.UnescapeString(s)
+	s = tagPattern.ReplaceAllString(s, " ")
+	s = html.UnescapeString(s)
+	s = strings.ReplaceAll(s, "\u00a0", " ")
+	return strings.TrimSpace(whitespacePattern.ReplaceAllString(s, " "))
+}
+
+var timeLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	time.RFC3339,
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+func parseTime(s string) time.Time {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,148 @@
+package feed
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/1set/quote0"
+)
+
+func parseFixture(t *testing.T, name string) []Item {
+	t.Helper()
+	f, err := os.Open("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	items, err := ParseFeed(f)
+	if err != nil {
+		t.Fatalf("ParseFeed(%s): %v", name, err)
+	}
+	return items
+}
+
+func TestParseFeed_RSS(t *testing.T) {
+	items := parseFixture(t, "rss.xml")
+	if len(items) != 2 {
+		t.Fatalf("items=%d", len(items))
+	}
+	got := items[1]
+	if got.Title != "Deploys & rollbacks — a primer" {
+		t.Fatalf("title=%q", got.Title)
+	}
+	if got.Summary != "How we ship safely ." {
+		t.Fatalf("summary=%q", got.Summary)
+	}
+	if got.ID != "post-2" || got.Link != "https://ops.example.com/primer" {
+		t.Fatalf("item=%+v", got)
+	}
+	if want := time.Date(2025, 11, 4, 8, 30, 0, 0, time.UTC); !got.Published.Equal(want) {
+		t.Fatalf("published=%v", got.Published)
+	}
+	newest, _ := Newest(items)
+	if newest.ID != "post-2" {
+		t.Fatalf("newest=%s", newest.ID)
+	}
+}
+
+func TestParseFeed_Atom(t *testing.T) {
+	items := parseFixture(t, "atom.xml")
+	if len(items) != 2 {
+		t.Fatalf("items=%d", len(items))
+	}
+	first := items[0]
+	if first.Title != "v2.0 released" || first.Summary != "Big changes." {
+		t.Fatalf("first=%+v", first)
+	}
+	if first.Link != "https://example.com/v2" || first.ID != "urn:uuid:v2" {
+		t.Fatalf("link/id=%q %q", first.Link, first.ID)
+	}
+	if first.Published.IsZero() {
+		t.Fatal("updated should back-fill published")
+	}
+	if items[1].Summary != "Small fixes" || items[1].Link != "https://example.com/v19" {
+		t.Fatalf("second=%+v", items[1])
+	}
+}
+
+func TestParseFeed_Malformed(t *testing.T) {
+	cases := map[string]string{
+		"truncated": `<rss><channel><item><title>x`,
+		"not xml":   `{"items":[]}`,
+		"unknown":   `<html><body>hi</body></html>`,
+	}
+	for name, doc := range cases {
+		if _, err := ParseFeed(strings.NewReader(doc)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+	if _, err := ParseFeed(strings.NewReader(`<opml/>`)); err != ErrUnknownFormat {
+		t.Errorf("want ErrUnknownFormat, got %v", err)
+	}
+}
+
+func TestTicker_SendsNewestAndSkipsUnchanged(t *testing.T) {
+	feedDoc, err := os.ReadFile("testdata/rss.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	feedSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/rss+xml")
+		_, _ = w.Write(feedDoc)
+	}))
+	defer feedSrv.Close()
+
+	var sent []quote0.TextRequest
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req quote0.TextRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		sent = append(sent, req)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"code":0}`)
+	}))
+	defer api.Close()
+
+	client, err := quote0.NewClient("test", quote0.WithBaseURL(api.URL), quote0.WithRateLimiter(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ticker := NewTicker(client, feedSrv.URL, WithDeviceID("D"), WithLocation(time.UTC))
+
+	for i, want := range []bool{true, false} {
+		did, err := ticker.Poll(context.Background())
+		if err != nil {
+			t.Fatalf("poll %d: %v", i, err)
+		}
+		if did != want {
+			t.Fatalf("poll %d sent=%v want %v", i, did, want)
+		}
+	}
+	if len(sent) != 1 {
+		t.Fatalf("sent=%d", len(sent))
+	}
+	got := sent[0]
+	if got.DeviceID != "D" || got.Title != "Deploys & rollbacks — a primer" || got.Signature != "11-04 08:30" || got.Link != "https://ops.example.com/primer" {
+		t.Fatalf("sent=%+v", got)
+	}
+}
+
+func TestTicker_FetchErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "gone", http.StatusGone)
+	}))
+	defer srv.Close()
+	client, err := quote0.NewClient("test", quote0.WithRateLimiter(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewTicker(client, srv.URL).Poll(context.Background()); err == nil || !strings.Contains(err.Error(), "410") {
+		t.Fatalf("want status error, got %v", err)
+	}
+}
@@ -0,0 +1,174 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/1set/quote0"
+)
+
+const (
+	defaultInterval        = 15 * time.Minute
+	defaultSignatureLayout = "01-02 15:04"
+	maxFeedSize            = 4 << 20 // 4 MiB guard
+)
+
+// Option configures a Ticker.
+type Option func(*Ticker)
+
+// WithInterval sets how often the feed is fetched (default 15 minutes).
+func WithInterval(d time.Duration) Option {
+	return func(t *Ticker) {
+		if d > 0 {
+			t.interval = d
+		}
+	}
+}
+
+// WithDeviceID targets a specific device instead of the client's default.
+func WithDeviceID(deviceID string) Option {
+	return func(t *Ticker) { t.deviceID = deviceID }
+}
+
+// WithSignatureLayout sets the time layout used to render the published time as the
+// signature (default "01-02 15:04"). An empty layout omits the signature.
+func WithSignatureLayout(layout string) Option {
+	return func(t *Ticker) { t.sigLayout = layout }
+}
+
+// WithLocation renders published times in loc (default time.Local).
+func WithLocation(loc *time.Location) Option {
+	return func(t *Ticker) {
+		if loc != nil {
+			t.loc = loc
+		}
+	}
+}
+
+// WithErrorHandler receives fetch, parse, and send errors from Run, which otherwise keeps going.
+func WithErrorHandler(fn func(error)) Option {
+	return func(t *Ticker) { t.onError = fn }
+}
+
+// Ticker periodically fetches a feed and sends its newest unseen item to a Quote/0 device.
+type Ticker struct {
+	client    *quote0.Client
+	url       string
+	interval  time.Duration
+	deviceID  string
+	sigLayout string
+	loc       *time.Location
+	onError   func(error)
+
+	mu   sync.Mutex
+	seen map[string]bool
+}
+
+// NewTicker creates a ticker for the feed at url. Feeds are fetched with the client's
+// HTTP client so they share its timeout and transport settings.
+func NewTicker(client *quote0.Client, url string, opts ...Option) *Ticker {
+	t := &Ticker{
+		client:    client,
+		url:       url,
+		interval:  defaultInterval,
+		sigLayout: defaultSignatureLayout,
+		loc:       time.Local,
+		seen:      make(map[string]bool),
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(t)
+		}
+	}
+	return t
+}
+
+// Run polls immediately and then on every interval until ctx ends, returning ctx.Err().
+func (t *Ticker) Run(ctx context.Context) error {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+		if _, err := t.Poll(ctx); err != nil && t.onError != nil {
+			t.onError(err)
+		}
+		timer.Reset(t.interval)
+	}
+}
+
+// Poll fetches the feed once and sends the newest item if it has not been sent before.
+// It reports whether a send happened. An item is only marked as seen after a successful send.
+func (t *Ticker) Poll(ctx context.Context) (bool, error) {
+	items, err := t.fetch(ctx)
+	if err != nil {
+		return false, err
+	}
+	item, ok := Newest(items)
+	if !ok {
+		return false, nil
+	}
+	t.mu.Lock()
+	seen := t.seen[item.ID]
+	t.mu.Unlock()
+	if seen {
+		return false, nil
+	}
+	if _, err := t.client.SendText(ctx, t.Format(item)); err != nil {
+		return false, err
+	}
+	t.mu.Lock()
+	t.seen[item.ID] = true
+	t.mu.Unlock()
+	return true, nil
+}
+
+// Format converts an item into the text request the ticker sends: title, summary as message,
+// published time as signature, and the item URL as link.
+func (t *Ticker) Format(item Item) quote0.TextRequest {
+	req := quote0.TextRequest{
+		RefreshNow: quote0.Bool(true),
+		DeviceID:   t.deviceID,
+		Title:      item.Title,
+		Message:    item.Summary,
+		Link:       item.Link,
+	}
+	if t.sigLayout != "" && !item.Published.IsZero() {
+		req.Signature = item.Published.In(t.loc).Format(t.sigLayout)
+	}
+	return req
+}
+
+func (t *Ticker) fetch(ctx context.Context) ([]Item, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, t.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("feed: build request: %w", err)
+	}
+	req.Header.Set("Accept", "application/rss+xml, application/atom+xml, application/xml;q=0.9, */*;q=0.8")
+	hc := t.client.HTTPClient()
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("feed: fetch: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("feed: fetch: unexpected status %s", resp.Status)
+	}
+	limited := io.LimitReader(resp.Body, maxFeedSize+1)
+	items, err := ParseFeed(limited)
+	if err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, fmt.Errorf("feed: document truncated or larger than %d bytes: %w", maxFeedSize, err)
+		}
+		return nil, err
+	}
+	return items, nil
+}
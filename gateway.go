@@ -0,0 +1,186 @@
+package quote0
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// defaultGatewayMaxBody bounds inbound request bodies; a full-screen PNG in base64 is well below this.
+const defaultGatewayMaxBody = 1 << 20 // 1 MiB
+
+// GatewayOption configures the handler returned by NewGatewayHandler.
+type GatewayOption func(*gatewayConfig)
+
+type gatewayConfig struct {
+	secret  string
+	maxBody int64
+}
+
+// WithGatewaySecret requires inbound requests to carry "Authorization: Bearer <secret>".
+// An empty secret leaves the gateway open (suitable only for trusted networks).
+func WithGatewaySecret(secret string) GatewayOption {
+	return func(cfg *gatewayConfig) { cfg.secret = strings.TrimSpace(secret) }
+}
+
+// WithGatewayMaxBodyBytes caps the size of inbound request bodies (default 1 MiB).
+// Larger bodies are rejected with 413 Request Entity Too Large.
+func WithGatewayMaxBodyBytes(n int64) GatewayOption {
+	return func(cfg *gatewayConfig) {
+		if n > 0 {
+			cfg.maxBody = n
+		}
+	}
+}
+
+// gatewayError is the JSON body returned for failed gateway requests.
+type gatewayError struct {
+	Error          string `json:"error"`
+	UpstreamStatus int    `json:"upstreamStatus,omitempty"`
+	UpstreamCode   string `json:"upstreamCode,omitempty"`
+}
+
+type gatewayHandler struct {
+	client *Client
+	cfg    gatewayConfig
+	mux    *http.ServeMux
+}
+
+// NewGatewayHandler returns an http.Handler that forwards posted content to a device through
+// client, so simple HTTP callers can drive the display without holding the API token.
+//
+// Routes:
+//   - POST /text accepts a TextRequest JSON body
+//   - POST /image accepts an ImageRequest JSON body (base64 "image" field)
+//   - POST /slack accepts a Slack incoming-webhook payload and answers "ok" (see below)
+//
+// deviceId is optional and falls back to the client's default device. Requests are validated
+// locally before forwarding; the upstream APIResponse is relayed as JSON on success, and
+// failures return {"error": "..."} with 400 (invalid request), 401 (bad secret),
+// 413 (body too large), 429 (upstream rate limit), 502 (upstream or transport failure),
+// or 504 (upstream timeout).
+//
+// The /slack route lets existing Slack-style alerting post to the display unchanged: "text"
+// (or the first attachment's text) is converted from mrkdwn to plain text, the first
+// attachment title becomes the title, "username" the signature, and the first linked URL
+// the Link. Content that exceeds the layout is truncated rather than rejected. The device
+// is taken from the deviceId query parameter or the client's default.
+func NewGatewayHandler(client *Client, opts ...GatewayOption) http.Handler {
+	h := &gatewayHandler{client: client, cfg: gatewayConfig{maxBody: defaultGatewayMaxBody}}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&h.cfg)
+		}
+	}
+	h.mux = http.NewServeMux()
+	h.mux.HandleFunc("/text", h.handleText)
+	h.mux.HandleFunc("/image", h.handleImage)
+	h.mux.HandleFunc("/slack", h.handleSlack)
+	return h
+}
+
+func (h *gatewayHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.cfg.secret != "" && !h.authorized(r) {
+		writeGatewayJSON(w, http.StatusUnauthorized, gatewayError{Error: "missing or invalid gateway secret"})
+		return
+	}
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *gatewayHandler) authorized(r *http.Request) bool {
+	got := strings.TrimSpace(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	return subtle.ConstantTimeCompare([]byte(got), []byte(h.cfg.secret)) == 1
+}
+
+func (h *gatewayHandler) handleText(w http.ResponseWriter, r *http.Request) {
+	var req TextRequest
+	if !h.decode(w, r, &req) {
+		return
+	}
+	resp, err := h.client.SendText(r.Context(), req)
+	h.relay(w, resp, err)
+}
+
+func (h *gatewayHandler) handleImage(w http.ResponseWriter, r *http.Request) {
+	var req ImageRequest
+	if !h.decode(w, r, &req) {
+		return
+	}
+	resp, err := h.client.SendImage(r.Context(), req)
+	h.relay(w, resp, err)
+}
+
+// readBody enforces the method and body limit and returns the raw body.
+// It writes the error response itself and reports whether the handler should continue.
+func (h *gatewayHandler) readBody(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeGatewayJSON(w, http.StatusMethodNotAllowed, gatewayError{Error: "method not allowed; use POST"})
+		return nil, false
+	}
+	// Read one byte past the limit so oversized bodies are detected rather than truncated.
+	raw, err := io.ReadAll(io.LimitReader(r.Body, h.cfg.maxBody+1))
+	if err != nil {
+		writeGatewayJSON(w, http.StatusBadRequest, gatewayError{Error: "read request body: " + err.Error()})
+		return nil, false
+	}
+	if int64(len(raw)) > h.cfg.maxBody {
+		writeGatewayJSON(w, http.StatusRequestEntityTooLarge, gatewayError{Error: "request body too large"})
+		return nil, false
+	}
+	return raw, true
+}
+
+// decode reads the body like readBody and unmarshals it as JSON into v.
+func (h *gatewayHandler) decode(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	raw, ok := h.readBody(w, r)
+	if !ok {
+		return false
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		writeGatewayJSON(w, http.StatusBadRequest, gatewayError{Error: "invalid JSON body: " + err.Error()})
+		return false
+	}
+	return true
+}
+
+// relay writes the upstream response or maps err to a gateway status code.
+func (h *gatewayHandler) relay(w http.ResponseWriter, resp *APIResponse, err error) {
+	if err == nil {
+		writeGatewayJSON(w, http.StatusOK, resp)
+		return
+	}
+	status, body := gatewayStatus(err)
+	writeGatewayJSON(w, status, body)
+}
+
+// gatewayStatus classifies a send error into an inbound HTTP status and error body.
+func gatewayStatus(err error) (int, gatewayError) {
+	body := gatewayError{Error: err.Error()}
+	var ae *APIError
+	switch {
+	case IsValidationError(err):
+		return http.StatusBadRequest, body
+	case errors.As(err, &ae):
+		body.UpstreamStatus = ae.StatusCode
+		body.UpstreamCode = ae.Code
+		if ae.StatusCode == http.StatusTooManyRequests {
+			return http.StatusTooManyRequests, body
+		}
+		return http.StatusBadGateway, body
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout, body
+	default:
+		return http.StatusBadGateway, body
+	}
+}
+
+func writeGatewayJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,144 @@
+package quote0
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newGatewayPair starts a fake upstream API and a gateway in front of it.
+func newGatewayPair(t *testing.T, upstream http.HandlerFunc, opts ...GatewayOption) *httptest.Server {
+	t.Helper()
+	api := httptest.NewServer(upstream)
+	t.Cleanup(api.Close)
+	c, err := NewClient("secret-token", WithBaseURL(api.URL), WithRateLimiter(nil), WithDefaultDeviceID("DEF"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gw := httptest.NewServer(NewGatewayHandler(c, opts...))
+	t.Cleanup(gw.Close)
+	return gw
+}
+
+func postGateway(t *testing.T, url, secret, body string) (int, map[string]interface{}) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret != "" {
+		req.Header.Set("Authorization", "Bearer "+secret)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var out map[string]interface{}
+	_ = json.NewDecoder(resp.Body).Decode(&out)
+	return resp.StatusCode, out
+}
+
+func TestGateway_ForwardsText(t *testing.T) {
+	var got TextRequest
+	var auth string
+	gw := newGatewayPair(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != textEndpoint {
+			t.Errorf("path=%s", r.URL.Path)
+		}
+		auth = r.Header.Get("Authorization")
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"code":0,"message":"ok","result":{"n":1}}`)
+	}, WithGatewaySecret("s3"))
+
+	status, body := postGateway(t, gw.URL+"/text", "s3", `{"title":"T","message":"M"}`)
+	if status != http.StatusOK {
+		t.Fatalf("status=%d body=%v", status, body)
+	}
+	if body["message"] != "ok" || body["result"] == nil {
+		t.Fatalf("relayed body=%v", body)
+	}
+	if got.DeviceID != "DEF" || got.Title != "T" || got.Message != "M" {
+		t.Fatalf("upstream got %+v", got)
+	}
+	if auth != "Bearer secret-token" {
+		t.Fatalf("upstream auth=%q", auth)
+	}
+}
+
+func TestGateway_ForwardsImage(t *testing.T) {
+	var got ImageRequest
+	gw := newGatewayPair(t, func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"code":0}`)
+	})
+	status, _ := postGateway(t, gw.URL+"/image", "", `{"deviceId":"X","image":"aGVsbG8=","border":1}`)
+	if status != http.StatusOK {
+		t.Fatalf("status=%d", status)
+	}
+	if got.DeviceID != "X" || got.Image != "aGVsbG8=" || got.Border != BorderBlack {
+		t.Fatalf("upstream got %+v", got)
+	}
+}
+
+func TestGateway_Errors(t *testing.T) {
+	upstreamCalls := 0
+	gw := newGatewayPair(t, func(w http.ResponseWriter, r *http.Request) {
+		upstreamCalls++
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = io.WriteString(w, `{"code":429,"message":"slow down"}`)
+	}, WithGatewaySecret("s3"), WithGatewayMaxBodyBytes(64))
+
+	cases := []struct {
+		name   string
+		path   string
+		secret string
+		body   string
+		want   int
+	}{
+		{"bad secret", "/text", "nope", `{}`, http.StatusUnauthorized},
+		{"missing secret", "/text", "", `{}`, http.StatusUnauthorized},
+		{"bad json", "/text", "s3", `{`, http.StatusBadRequest},
+		{"missing image", "/image", "s3", `{}`, http.StatusBadRequest},
+		{"too large", "/text", "s3", `{"message":"` + strings.Repeat("x", 100) + `"}`, http.StatusRequestEntityTooLarge},
+		{"upstream 429", "/text", "s3", `{"message":"hi"}`, http.StatusTooManyRequests},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			status, body := postGateway(t, gw.URL+tc.path, tc.secret, tc.body)
+			if status != tc.want {
+				t.Fatalf("status=%d want %d body=%v", status, tc.want, body)
+			}
+			if _, ok := body["error"].(string); !ok {
+				t.Fatalf("missing structured error: %v", body)
+			}
+		})
+	}
+	if upstreamCalls != 1 {
+		t.Fatalf("only the valid request should reach upstream, calls=%d", upstreamCalls)
+	}
+}
+
+func TestGateway_MethodNotAllowed(t *testing.T) {
+	c, err := NewClient("t", WithRateLimiter(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	NewGatewayHandler(c).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/text", nil))
+	if rec.Code != http.StatusMethodNotAllowed || rec.Header().Get("Allow") != http.MethodPost {
+		t.Fatalf("code=%d allow=%q", rec.Code, rec.Header().Get("Allow"))
+	}
+}
+
+func TestGatewayStatus_Timeout(t *testing.T) {
+	if status, _ := gatewayStatus(context.DeadlineExceeded); status != http.StatusGatewayTimeout {
+		t.Fatalf("status=%d", status)
+	}
+}
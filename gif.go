@@ -0,0 +1,140 @@
+package quote0
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"io"
+	"time"
+)
+
+// DefaultMinFrameDuration is the shortest time FramesFromGIF keeps a frame up. E-ink refreshes
+// take seconds and the API allows about one call per second, so faster animation cannot be
+// shown faithfully anyway.
+const DefaultMinFrameDuration = 15 * time.Second
+
+// ErrNoFrames is returned by FramesFromGIF for a GIF without frames.
+var ErrNoFrames = errors.New("quote0: gif has no frames")
+
+// GIFOption configures FramesFromGIF.
+type GIFOption func(*gifConfig)
+
+type gifConfig struct {
+	minDuration time.Duration
+	process     []ProcessOption
+	template    ImageRequest
+}
+
+// WithMinFrameDuration raises every frame duration to at least d (default
+// DefaultMinFrameDuration). Zero keeps the durations stored in the GIF; negative is ignored.
+func WithMinFrameDuration(d time.Duration) GIFOption {
+	return func(cfg *gifConfig) {
+		if d >= 0 {
+			cfg.minDuration = d
+		}
+	}
+}
+
+// WithFrameProcessing passes opts to ProcessImage for every frame, after the default
+// WithFit(FitContain), so a different fit mode or tone adjustments can be chosen.
+func WithFrameProcessing(opts ...ProcessOption) GIFOption {
+	return func(cfg *gifConfig) { cfg.process = append(cfg.process, opts...) }
+}
+
+// WithFrameRequest uses req as the template for every returned request, for example to set
+// DeviceID, Link, Border, or the dither settings. Its image fields are replaced.
+func WithFrameRequest(req ImageRequest) GIFOption {
+	return func(cfg *gifConfig) { cfg.template = req }
+}
+
+// FramesFromGIF decodes an animated GIF into one image request per frame, ready for SendBatch
+// or a SlideShow, along with how long each frame should stay up.
+//
+// Frames are composed the way a browser plays the GIF: each frame is drawn over the previous
+// canvas and then disposed of by its disposal method (left in place, cleared to transparent,
+// or restored to the previous canvas). Transparent areas show as white. Each composed frame
+// then goes through ProcessImage, fitted with FitContain by default, and is PNG-encoded into
+// ImageBytes. Durations come from the GIF delays, raised to the configured minimum.
+func FramesFromGIF(r io.Reader, opts ...GIFOption) ([]ImageRequest, []time.Duration, error) {
+	cfg := gifConfig{minDuration: DefaultMinFrameDuration}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&cfg)
+		}
+	}
+	g, err := gif.DecodeAll(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: gif: %v", ErrInvalidImage, err)
+	}
+	if len(g.Image) == 0 {
+		return nil, nil, ErrNoFrames
+	}
+	process := append([]ProcessOption{WithFit(FitContain)}, cfg.process...)
+
+	frames := composeGIF(g)
+	reqs := make([]ImageRequest, len(frames))
+	durations := make([]time.Duration, len(frames))
+	for i, frame := range frames {
+		img, err := ProcessImage(frame, process...)
+		if err != nil {
+			return nil, nil, fmt.Errorf("quote0: gif frame %d: %w", i, err)
+		}
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, nil, fmt.Errorf("quote0: encode png: %w", err)
+		}
+		req := cfg.template
+		req.Image, req.ImagePath, req.ImageBytes = "", "", buf.Bytes()
+		reqs[i] = req
+
+		d := time.Duration(g.Delay[i]) * 10 * time.Millisecond
+		if d < cfg.minDuration {
+			d = cfg.minDuration
+		}
+		durations[i] = d
+	}
+	return reqs, durations, nil
+}
+
+// composeGIF renders every frame of g onto the logical screen, honoring disposal methods, and
+// returns the frames flattened onto white.
+func composeGIF(g *gif.GIF) []image.Image {
+	screen := image.Rect(0, 0, g.Config.Width, g.Config.Height)
+	if screen.Empty() {
+		// Some encoders leave the logical screen size unset; use the union of the frames.
+		for _, p := range g.Image {
+			screen = screen.Union(p.Bounds())
+		}
+	}
+	canvas := image.NewRGBA(screen)
+	var saved *image.RGBA
+	out := make([]image.Image, len(g.Image))
+	for i, p := range g.Image {
+		disposal := byte(0)
+		if i < len(g.Disposal) {
+			disposal = g.Disposal[i]
+		}
+		if disposal == gif.DisposalPrevious {
+			saved = image.NewRGBA(screen)
+			copy(saved.Pix, canvas.Pix)
+		}
+		draw.Draw(canvas, p.Bounds(), p, p.Bounds().Min, draw.Over)
+
+		flat := image.NewRGBA(screen)
+		draw.Draw(flat, screen, image.White, image.Point{}, draw.Src)
+		draw.Draw(flat, screen, canvas, screen.Min, draw.Over)
+		out[i] = flat
+
+		switch disposal {
+		case gif.DisposalBackground:
+			draw.Draw(canvas, p.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			copy(canvas.Pix, saved.Pix)
+		}
+	}
+	return out
+}
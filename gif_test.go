@@ -0,0 +1,121 @@
+package quote0
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// gifFixture builds a 16x8 animation that exercises every disposal method:
+//
+//	0: all black, kept
+//	1: left half white, then cleared to transparent
+//	2: right half white, then restored to the previous canvas
+//	3: a black 4x4 square at the top left
+func gifFixture(t *testing.T) []byte {
+	t.Helper()
+	pal := color.Palette{color.Black, color.White, color.Transparent}
+	frame := func(r image.Rectangle, idx uint8) *image.Paletted {
+		p := image.NewPaletted(r, pal)
+		for i := range p.Pix {
+			p.Pix[i] = idx
+		}
+		return p
+	}
+	g := &gif.GIF{
+		Image: []*image.Paletted{
+			frame(image.Rect(0, 0, 16, 8), 0),
+			frame(image.Rect(0, 0, 8, 8), 1),
+			frame(image.Rect(8, 0, 16, 8), 1),
+			frame(image.Rect(0, 0, 4, 4), 0),
+		},
+		Delay:    []int{0, 2000, 100, 3000},
+		Disposal: []byte{gif.DisposalNone, gif.DisposalBackground, gif.DisposalPrevious, gif.DisposalNone},
+		Config:   image.Config{Width: 16, Height: 8},
+	}
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, g); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestComposeGIF_Disposal(t *testing.T) {
+	g, err := gif.DecodeAll(bytes.NewReader(gifFixture(t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	frames := composeGIF(g)
+	if len(frames) != 4 {
+		t.Fatalf("frames %d", len(frames))
+	}
+	gray := func(img image.Image, x, y int) uint8 {
+		return color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y
+	}
+	want := []struct {
+		frame, x, y int
+		v           uint8
+	}{
+		{0, 2, 2, 0}, {0, 12, 2, 0},
+		{1, 2, 2, 255}, {1, 12, 2, 0},
+		// Frame 1 was cleared to transparent, which flattens to white.
+		{2, 2, 2, 255}, {2, 12, 2, 255},
+		// Frame 2 was undone, so the right half is black again.
+		{3, 2, 2, 0}, {3, 6, 6, 255}, {3, 12, 2, 0},
+	}
+	for _, w := range want {
+		if got := gray(frames[w.frame], w.x, w.y); got != w.v {
+			t.Errorf("frame %d at (%d,%d) = %d, want %d", w.frame, w.x, w.y, got, w.v)
+		}
+	}
+}
+
+func TestFramesFromGIF(t *testing.T) {
+	data := gifFixture(t)
+	reqs, durations, err := FramesFromGIF(bytes.NewReader(data), WithFrameRequest(ImageRequest{DeviceID: "D", Link: "l", Image: "stale"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reqs) != 4 {
+		t.Fatalf("requests %d", len(reqs))
+	}
+	want := []time.Duration{15 * time.Second, 20 * time.Second, 15 * time.Second, 30 * time.Second}
+	if !reflect.DeepEqual(durations, want) {
+		t.Fatalf("durations %v", durations)
+	}
+	for i, req := range reqs {
+		if req.DeviceID != "D" || req.Link != "l" || req.Image != "" {
+			t.Fatalf("request %d: %+v", i, req)
+		}
+		cfg, err := png.DecodeConfig(bytes.NewReader(req.ImageBytes))
+		if err != nil || cfg.Width != ScreenWidth || cfg.Height != ScreenHeight {
+			t.Fatalf("frame %d: %v %dx%d", i, err, cfg.Width, cfg.Height)
+		}
+	}
+
+	_, durations, err = FramesFromGIF(bytes.NewReader(data), WithMinFrameDuration(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []time.Duration{0, 20 * time.Second, time.Second, 30 * time.Second}
+	if !reflect.DeepEqual(durations, want) {
+		t.Fatalf("unclamped durations %v", durations)
+	}
+}
+
+func TestFramesFromGIF_Errors(t *testing.T) {
+	if _, _, err := FramesFromGIF(strings.NewReader("not a gif")); !errors.Is(err, ErrInvalidImage) {
+		t.Fatalf("garbage: %v", err)
+	}
+	_, _, err := FramesFromGIF(bytes.NewReader(gifFixture(t)), WithFrameProcessing(WithFit(FitNone)))
+	if !errors.Is(err, ErrImageSize) {
+		t.Fatalf("fit none: %v", err)
+	}
+}
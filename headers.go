@@ -0,0 +1,34 @@
+package quote0
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// WithExtraHeaders sets fixed headers on every request, e.g. a tag an egress proxy requires.
+// They are applied after the client's own headers and before WithTraceHeader and
+// WithCallHeader values, which win on a clash. h is copied, so changing it later does not
+// affect the client; repeating the option merges, a later value replacing an earlier one
+// for the same name. An empty name or a reserved header (Authorization, Content-Type,
+// User-Agent) makes NewClient return an error.
+func WithExtraHeaders(h http.Header) ClientOption {
+	return func(c *Client) {
+		for name, values := range h {
+			canon := http.CanonicalHeaderKey(strings.TrimSpace(name))
+			switch canon {
+			case "":
+				c.optionError(errors.New("quote0: extra header name is required"))
+				return
+			case "Authorization", "Content-Type", "User-Agent":
+				c.optionError(fmt.Errorf("quote0: extra header %s is reserved", canon))
+				return
+			}
+			if c.extraHeaders == nil {
+				c.extraHeaders = make(http.Header, len(h))
+			}
+			c.extraHeaders[canon] = append([]string(nil), values...)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package quote0
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWithExtraHeaders(t *testing.T) {
+	var seen http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = r.Header.Clone()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"code":0}`)
+	}))
+	defer srv.Close()
+
+	h := http.Header{"x-org-tag": {"blue"}, "X-Env": {"a", "b"}}
+	c, err := NewClient("test", WithBaseURL(srv.URL), WithRateLimiter(nil), WithDefaultDeviceID("D"),
+		WithExtraHeaders(h), WithExtraHeaders(http.Header{"X-Env": {"prod"}}),
+		WithTraceHeader("X-Trace", func(context.Context) string { return "t1" }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.Set("X-Org-Tag", "changed")
+	if _, err := c.SendText(context.Background(), TextRequest{Title: "t"}, WithCallHeader("X-Env", "call")); err != nil {
+		t.Fatal(err)
+	}
+	if seen.Get("X-Org-Tag") != "blue" || seen.Get("X-Trace") != "t1" || seen.Get("Authorization") != "Bearer test" {
+		t.Fatalf("headers %v", seen)
+	}
+	if got := seen.Values("X-Env"); len(got) != 1 || got[0] != "call" {
+		t.Fatalf("X-Env %v", got)
+	}
+	if _, err := c.SendText(context.Background(), TextRequest{Title: "t"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := seen.Values("X-Env"); len(got) != 1 || got[0] != "prod" {
+		t.Fatalf("X-Env %v", got)
+	}
+
+	for _, bad := range []http.Header{{"authorization": {"x"}}, {"Content-Type": {"text/plain"}}, {" ": {"x"}}} {
+		if _, err := NewClient("test", WithExtraHeaders(bad)); err == nil {
+			t.Errorf("accepted %v", bad)
+		}
+	}
+	if _, err := NewClient("test", WithExtraHeaders(nil)); err != nil {
+		t.Fatal(err)
+	}
+}
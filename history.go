@@ -0,0 +1,183 @@
+package quote0
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// MaxHistory is the largest WithHistory size.
+const MaxHistory = 1000
+
+// Limits on the text kept per HistoryEntry, so the buffer stays small whatever the payloads.
+const (
+	maxHistorySummary = 512
+	maxHistoryError   = 256
+)
+
+// Outcomes recorded in HistoryEntry.Outcome.
+const (
+	OutcomeOK        = "ok"
+	OutcomeRateLimit = "rate_limit"
+	OutcomeAuth      = "auth"
+	OutcomeDevice    = "device"
+	OutcomeAPI       = "api_error"
+	OutcomeNetwork   = "network"
+	OutcomeCanceled  = "canceled"
+	OutcomeError     = "error"
+)
+
+// HistoryEntry describes one completed API call (see WithHistory).
+type HistoryEntry struct {
+	// Time is when the call finished.
+	Time time.Time
+	// Duration covers the whole call, limiter wait and fallback hosts included.
+	Duration time.Duration
+	// Endpoint is the API path, e.g. "/api/open/text".
+	Endpoint string
+	// DeviceID is the device the call addressed.
+	DeviceID string
+	// RequestHash is the first 16 hex digits of the SHA-256 of the JSON body sent, so
+	// repeated payloads are easy to spot.
+	RequestHash string
+	// Summary is the JSON body with image and icon data replaced by their length, cut to
+	// 512 bytes.
+	Summary string
+	// StatusCode is the HTTP status, or 0 if no response arrived.
+	StatusCode int
+	// Code is the API code of a successful response.
+	Code int
+	// Outcome classifies the result: OutcomeOK, OutcomeRateLimit, OutcomeAuth, OutcomeDevice,
+	// OutcomeAPI, OutcomeNetwork, OutcomeCanceled, or OutcomeError.
+	Outcome string
+	// Err is the error message, cut to 256 bytes; empty on success.
+	Err string
+}
+
+// history is a fixed-size ring of HistoryEntry values.
+type history struct {
+	mu      sync.Mutex
+	entries []HistoryEntry
+	next    int
+	full    bool
+}
+
+// WithHistory keeps the last n completed API calls for Client.History, which helps explain
+// what reached the panel. Entries hold hashes and short summaries rather than payloads, so
+// memory stays bounded. 0 disables it (the default); n above MaxHistory or below 0 makes
+// NewClient return an error.
+func WithHistory(n int) ClientOption {
+	return func(c *Client) {
+		if n < 0 || n > MaxHistory {
+			c.optionError(fmt.Errorf("quote0: history size must be 0 to %d, got %d", MaxHistory, n))
+			return
+		}
+		c.history = nil
+		if n > 0 {
+			c.history = &history{entries: make([]HistoryEntry, n)}
+		}
+	}
+}
+
+// History returns the recorded calls, oldest first. It is nil unless WithHistory is set, and
+// the slice is a copy the caller owns.
+func (c *Client) History() []HistoryEntry {
+	h := c.history
+	if h == nil {
+		return nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	out := make([]HistoryEntry, 0, len(h.entries))
+	if h.full {
+		out = append(out, h.entries[h.next:]...)
+	}
+	return append(out, h.entries[:h.next]...)
+}
+
+// ClearHistory forgets the recorded calls.
+func (c *Client) ClearHistory() {
+	h := c.history
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	for i := range h.entries {
+		h.entries[i] = HistoryEntry{}
+	}
+	h.next, h.full = 0, false
+	h.mu.Unlock()
+}
+
+// recordHistory adds the outcome of one doJSON call when history is enabled.
+func (c *Client) recordHistory(call *apiCall, start time.Time, resp *APIResponse, err error) {
+	h := c.history
+	if h == nil {
+		return
+	}
+	sum := sha256.Sum256(call.body)
+	e := HistoryEntry{
+		Time:        time.Now(),
+		Endpoint:    call.endpoint,
+		DeviceID:    call.deviceID,
+		RequestHash: hex.EncodeToString(sum[:8]),
+		Summary:     truncateUTF8(string(elideBase64Fields(call.body)), maxHistorySummary),
+		Outcome:     classifyOutcome(err),
+	}
+	e.Duration = e.Time.Sub(start)
+	if resp != nil {
+		e.StatusCode, e.Code = resp.StatusCode, resp.Code
+	}
+	var ae *APIError
+	if errors.As(err, &ae) {
+		e.StatusCode = ae.StatusCode
+	}
+	if err != nil {
+		e.Err = truncateUTF8(err.Error(), maxHistoryError)
+	}
+	h.mu.Lock()
+	h.entries[h.next] = e
+	h.next++
+	if h.next == len(h.entries) {
+		h.next, h.full = 0, true
+	}
+	h.mu.Unlock()
+}
+
+// classifyOutcome maps a doJSON error to a HistoryEntry outcome.
+func classifyOutcome(err error) string {
+	switch {
+	case err == nil:
+		return OutcomeOK
+	case errors.Is(err, context.Canceled):
+		return OutcomeCanceled
+	case IsRateLimitError(err):
+		return OutcomeRateLimit
+	case IsAuthError(err):
+		return OutcomeAuth
+	case IsDeviceError(err):
+		return OutcomeDevice
+	case errors.As(err, new(*APIError)):
+		return OutcomeAPI
+	case IsNetworkError(err):
+		return OutcomeNetwork
+	}
+	return OutcomeError
+}
+
+// truncateUTF8 cuts s to at most n bytes without splitting a rune, marking the cut with "...".
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	cut := n - len("...")
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + "..."
+}
@@ -0,0 +1,151 @@
+package quote0
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func newHistoryClient(t *testing.T, n int, handler http.HandlerFunc) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	c, err := NewClient("test", WithBaseURL(srv.URL), WithRateLimiter(nil), WithDefaultDeviceID("D"), WithHistory(n))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func okHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = io.WriteString(w, `{"code":0,"message":"ok"}`)
+}
+
+func TestHistory_RingKeepsNewest(t *testing.T) {
+	c := newHistoryClient(t, 3, okHandler)
+	for i := 0; i < 5; i++ {
+		if _, err := c.SendText(context.Background(), TextRequest{Title: fmt.Sprintf("t%d", i), Message: "m"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	h := c.History()
+	if len(h) != 3 {
+		t.Fatalf("%d entries", len(h))
+	}
+	for i, e := range h {
+		if !strings.Contains(e.Summary, fmt.Sprintf(`"t%d"`, i+2)) {
+			t.Fatalf("entry %d: %+v", i, e)
+		}
+		if e.Endpoint != textEndpoint || e.DeviceID != "D" || e.Outcome != OutcomeOK || e.StatusCode != 200 || len(e.RequestHash) != 16 {
+			t.Fatalf("entry %d: %+v", i, e)
+		}
+	}
+	if h[0].RequestHash == h[1].RequestHash || h[2].Time.Before(h[0].Time) {
+		t.Fatalf("hashes or order: %+v", h)
+	}
+
+	h[0].Summary = "changed"
+	if c.History()[0].Summary == "changed" {
+		t.Fatal("History returned shared entries")
+	}
+	c.ClearHistory()
+	if h := c.History(); len(h) != 0 {
+		t.Fatalf("after clear: %+v", h)
+	}
+}
+
+func TestHistory_BoundedEntries(t *testing.T) {
+	c := newHistoryClient(t, 2, okHandler)
+	req := ImageRequest{ImageBytes: pngBytes(t, ScreenWidth, ScreenHeight), Link: "https://example.com/" + strings.Repeat("x", 4000)}
+	if _, err := c.SendImage(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	e := c.History()[0]
+	if len(e.Summary) > maxHistorySummary || !strings.Contains(e.Summary, "bytes base64>") || !strings.HasSuffix(e.Summary, "...") {
+		t.Fatalf("summary %d bytes: %q", len(e.Summary), e.Summary)
+	}
+	if e.Endpoint != imageEndpoint {
+		t.Fatalf("endpoint %q", e.Endpoint)
+	}
+}
+
+func TestHistory_Outcomes(t *testing.T) {
+	status := http.StatusTooManyRequests
+	c := newHistoryClient(t, 10, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		_, _ = io.WriteString(w, strings.Repeat("slow down ", 100))
+	})
+	ctx := context.Background()
+	for _, s := range []int{http.StatusTooManyRequests, http.StatusUnauthorized, http.StatusNotFound, http.StatusInternalServerError} {
+		status = s
+		_, _ = c.SendText(ctx, TextRequest{Title: "t", Message: "m"})
+	}
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, _ = c.SendText(canceled, TextRequest{Title: "t", Message: "m"})
+	// Local validation failures never reach the API and are not recorded.
+	_, _ = c.SendImage(ctx, ImageRequest{})
+
+	h := c.History()
+	want := []struct {
+		outcome string
+		status  int
+	}{{OutcomeRateLimit, 429}, {OutcomeAuth, 401}, {OutcomeDevice, 404}, {OutcomeAPI, 500}, {OutcomeCanceled, 0}}
+	if len(h) != len(want) {
+		t.Fatalf("%d entries: %+v", len(h), h)
+	}
+	for i, w := range want {
+		if h[i].Outcome != w.outcome || h[i].StatusCode != w.status || h[i].Err == "" || len(h[i].Err) > maxHistoryError {
+			t.Errorf("entry %d: %+v, want %s %d", i, h[i], w.outcome, w.status)
+		}
+	}
+
+	nc, err := NewClient("test", WithBaseURL("http://127.0.0.1:1"), WithRateLimiter(nil), WithDefaultDeviceID("D"), WithHistory(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = nc.SendText(ctx, TextRequest{Title: "t", Message: "m"})
+	if h := nc.History(); len(h) != 1 || h[0].Outcome != OutcomeNetwork {
+		t.Fatalf("network: %+v", h)
+	}
+}
+
+func TestHistory_Concurrent(t *testing.T) {
+	c := newHistoryClient(t, 8, okHandler)
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, _ = c.SendText(context.Background(), TextRequest{Title: fmt.Sprint(i), Message: "m"})
+			_ = c.History()
+			if i%7 == 0 {
+				c.ClearHistory()
+			}
+		}(i)
+	}
+	wg.Wait()
+	if h := c.History(); len(h) > 8 {
+		t.Fatalf("%d entries", len(h))
+	}
+}
+
+func TestWithHistory_Disabled(t *testing.T) {
+	c := newHistoryClient(t, 0, okHandler)
+	_, _ = c.SendText(context.Background(), TextRequest{Title: "t", Message: "m"})
+	if h := c.History(); h != nil {
+		t.Fatalf("history without WithHistory: %+v", h)
+	}
+	c.ClearHistory()
+	for _, n := range []int{-1, MaxHistory + 1} {
+		if _, err := NewClient("test", WithHistory(n)); err == nil || !strings.Contains(err.Error(), "history size") {
+			t.Errorf("WithHistory(%d): %v", n, err)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package quote0
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Hooks observe every HTTP attempt the client makes, for logging and metrics. BeforeRequest
+// runs just before the request is sent, after any limiter wait, with the exact JSON body,
+// base64 image and icon data included; it must not modify payload. AfterResponse runs once
+// the response has been read or the attempt failed, with the HTTP status (0 when no response
+// arrived), the time since BeforeRequest, and the error the attempt produced (an *APIError
+// for non-2xx answers). Retries and fallback hosts call both again. Request headers, and so
+// the API token, are never passed to hooks.
+type Hooks interface {
+	BeforeRequest(ctx context.Context, endpoint string, payload []byte)
+	AfterResponse(ctx context.Context, endpoint string, status int, duration time.Duration, err error)
+}
+
+// HookFuncs adapts a pair of functions into Hooks; either may be nil.
+type HookFuncs struct {
+	Before func(ctx context.Context, endpoint string, payload []byte)
+	After  func(ctx context.Context, endpoint string, status int, duration time.Duration, err error)
+}
+
+// BeforeRequest calls f.Before when it is set.
+func (f HookFuncs) BeforeRequest(ctx context.Context, endpoint string, payload []byte) {
+	if f.Before != nil {
+		f.Before(ctx, endpoint, payload)
+	}
+}
+
+// AfterResponse calls f.After when it is set.
+func (f HookFuncs) AfterResponse(ctx context.Context, endpoint string, status int, duration time.Duration, err error) {
+	if f.After != nil {
+		f.After(ctx, endpoint, status, duration, err)
+	}
+}
+
+// WithHooks registers h to observe each HTTP attempt. The option may be repeated; hooks run
+// in registration order, both before and after the request. A nil h makes NewClient return an
+// error.
+func WithHooks(h Hooks) ClientOption {
+	return func(c *Client) {
+		if h == nil {
+			c.optionError(errors.New("quote0: hooks are nil"))
+			return
+		}
+		c.hooks = append(c.hooks, h)
+	}
+}
+
+// observe runs one HTTP attempt between the registered hooks.
+func (c *Client) observe(ctx context.Context, call *apiCall, send func() (*APIResponse, bool, error)) (*APIResponse, bool, error) {
+	if len(c.hooks) == 0 {
+		return send()
+	}
+	for _, h := range c.hooks {
+		h.BeforeRequest(ctx, call.endpoint, call.body)
+	}
+	start := time.Now()
+	resp, transport, err := send()
+	elapsed := time.Since(start)
+	status := 0
+	var ae *APIError
+	if resp != nil {
+		status = resp.StatusCode
+	} else if errors.As(err, &ae) {
+		status = ae.StatusCode
+	}
+	for _, h := range c.hooks {
+		h.AfterResponse(ctx, call.endpoint, status, elapsed, err)
+	}
+	return resp, transport, err
+}
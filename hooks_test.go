@@ -0,0 +1,85 @@
+package quote0
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithHooks_OrderAndPayload(t *testing.T) {
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = io.WriteString(w, `{"code":0}`)
+	}))
+	defer srv.Close()
+
+	var events []string
+	var payloads [][]byte
+	hook := func(name string) Hooks {
+		return HookFuncs{
+			Before: func(ctx context.Context, endpoint string, payload []byte) {
+				events = append(events, name+" before "+endpoint)
+				payloads = append(payloads, payload)
+			},
+			After: func(ctx context.Context, endpoint string, status int, d time.Duration, err error) {
+				events = append(events, fmt.Sprintf("%s after %s %d %v", name, endpoint, status, err != nil))
+			},
+		}
+	}
+	limiter := RateLimiterFunc(func(context.Context) error { events = append(events, "limiter"); return nil })
+	c, err := NewClient("dot_app_secret_token", WithBaseURL(srv.URL), WithRateLimiter(limiter), WithDefaultDeviceID("D"),
+		WithHooks(hook("a")), WithHooks(hook("b")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	img := pngBytes(t, ScreenWidth, ScreenHeight)
+	if _, err := c.SendImage(context.Background(), ImageRequest{ImageBytes: img}); err != nil {
+		t.Fatal(err)
+	}
+	status = http.StatusInternalServerError
+	_, _ = c.SendText(context.Background(), TextRequest{Title: "t"})
+
+	want := []string{
+		"limiter", "a before /api/open/image", "b before /api/open/image", "a after /api/open/image 200 false", "b after /api/open/image 200 false",
+		"limiter", "a before /api/open/text", "b before /api/open/text", "a after /api/open/text 500 true", "b after /api/open/text 500 true",
+	}
+	if strings.Join(events, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("events:\n%s", strings.Join(events, "\n"))
+	}
+	var body struct{ Image string }
+	if err := json.Unmarshal(payloads[0], &body); err != nil || len(body.Image) != (len(img)+2)/3*4 {
+		t.Fatalf("image payload %d chars, err %v", len(body.Image), err)
+	}
+	for _, p := range payloads {
+		if strings.Contains(string(p), "secret_token") || strings.Contains(string(p), "Bearer") {
+			t.Fatalf("payload exposes the token: %s", p)
+		}
+	}
+}
+
+func TestWithHooks_NetworkFailure(t *testing.T) {
+	var gotStatus = -1
+	var gotErr error
+	c, err := NewClient("test", WithBaseURL("http://127.0.0.1:1"), WithRateLimiter(nil), WithDefaultDeviceID("D"),
+		WithHooks(HookFuncs{After: func(_ context.Context, _ string, status int, _ time.Duration, err error) {
+			gotStatus, gotErr = status, err
+		}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.SendText(context.Background(), TextRequest{Title: "t"})
+	if gotStatus != 0 || gotErr == nil || gotErr != err || !IsNetworkError(gotErr) {
+		t.Fatalf("status %d, err %v (returned %v)", gotStatus, gotErr, err)
+	}
+	if _, err := NewClient("test", WithHooks(nil)); err == nil {
+		t.Fatal("nil hooks accepted")
+	}
+}
@@ -0,0 +1,6 @@
+//go:build !linux && !darwin && !freebsd
+
+package hoststatus
+
+// diskUsage is not collected on this platform.
+func diskUsage(string) (used, total uint64, ok bool) { return 0, 0, false }
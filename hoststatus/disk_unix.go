@@ -0,0 +1,19 @@
+//go:build linux || darwin || freebsd
+
+package hoststatus
+
+import "syscall"
+
+// diskUsage reports the used and total bytes of the filesystem holding path.
+func diskUsage(path string) (used, total uint64, ok bool) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, 0, false
+	}
+	bsize := uint64(st.Bsize)
+	total = uint64(st.Blocks) * bsize
+	if total == 0 {
+		return 0, 0, false
+	}
+	return total - uint64(st.Bfree)*bsize, total, true
+}
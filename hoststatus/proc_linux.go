@@ -0,0 +1,46 @@
+package hoststatus
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// uptime reads the seconds since boot from /proc/uptime.
+func uptime() time.Duration {
+	data, err := os.ReadFile("/proc/uptime")
+	if err != nil {
+		return 0
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return 0
+	}
+	secs, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0
+	}
+	return time.Duration(secs * float64(time.Second))
+}
+
+// loadAverage reads the 1, 5, and 15 minute load averages from /proc/loadavg.
+func loadAverage() []float64 {
+	data, err := os.ReadFile("/proc/loadavg")
+	if err != nil {
+		return nil
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) < 3 {
+		return nil
+	}
+	load := make([]float64, 3)
+	for i := range load {
+		v, err := strconv.ParseFloat(fields[i], 64)
+		if err != nil {
+			return nil
+		}
+		load[i] = v
+	}
+	return load
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+
+package hoststatus
+
+import "time"
+
+// uptime is not collected on this platform.
+func uptime() time.Duration { return 0 }
+
+// loadAverage is not collected on this platform.
+func loadAverage() []float64 { return nil }
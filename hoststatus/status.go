@@ -0,0 +1,210 @@
+// Package hoststatus collects basic host vitals (hostname, primary IP, uptime, load, and disk
+// usage) and formats them as a Quote/0 text screen. Values a platform cannot provide are left
+// at their zero value and skipped when formatting.
+package hoststatus
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/1set/quote0"
+)
+
+// Field selects a line of the status screen.
+type Field string
+
+// Fields of the status screen, in display order.
+const (
+	FieldHost   Field = "host"
+	FieldIP     Field = "ip"
+	FieldUptime Field = "uptime"
+	FieldLoad   Field = "load"
+	FieldDisk   Field = "disk"
+)
+
+// DefaultFields are shown when no fields are selected.
+var DefaultFields = []Field{FieldHost, FieldIP, FieldUptime, FieldLoad, FieldDisk}
+
+// SignatureLayout formats the collection time in the signature.
+const SignatureLayout = "01-02 15:04"
+
+// Status is one snapshot of host vitals. Zero values mean "not available on this platform".
+type Status struct {
+	Hostname string `json:"hostname,omitempty"`
+	// IP is the first global unicast IPv4 address of an interface that is up.
+	IP string `json:"ip,omitempty"`
+	// Uptime is the time since boot.
+	Uptime time.Duration `json:"uptime,omitempty"`
+	// Load holds the 1, 5, and 15 minute load averages; nil when unavailable.
+	Load []float64 `json:"load,omitempty"`
+	// DiskPath is the filesystem measured for DiskUsed and DiskTotal.
+	DiskPath  string `json:"diskPath,omitempty"`
+	DiskUsed  uint64 `json:"diskUsed,omitempty"`
+	DiskTotal uint64 `json:"diskTotal,omitempty"`
+	// CollectedAt is when the snapshot was taken.
+	CollectedAt time.Time `json:"collectedAt"`
+}
+
+// Option configures Collect.
+type Option func(*collector)
+
+type collector struct {
+	diskPath string
+	now      func() time.Time
+}
+
+// WithDiskPath measures the filesystem holding path (default "/").
+func WithDiskPath(path string) Option {
+	return func(c *collector) {
+		if path != "" {
+			c.diskPath = path
+		}
+	}
+}
+
+// Collect takes a snapshot of the host. It never fails: anything that cannot be read is
+// left empty.
+func Collect(opts ...Option) Status {
+	c := collector{diskPath: "/", now: time.Now}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&c)
+		}
+	}
+	s := Status{CollectedAt: c.now()}
+	s.Hostname, _ = os.Hostname()
+	s.IP = primaryIP()
+	s.Uptime = uptime()
+	s.Load = loadAverage()
+	if used, total, ok := diskUsage(c.diskPath); ok {
+		s.DiskPath, s.DiskUsed, s.DiskTotal = c.diskPath, used, total
+	}
+	return s
+}
+
+// ParseFields parses a comma-separated field list such as "host,load,disk". An empty list
+// returns DefaultFields.
+func ParseFields(list string) ([]Field, error) {
+	var fields []Field
+	for _, name := range strings.Split(list, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		f := Field(name)
+		switch f {
+		case FieldHost, FieldIP, FieldUptime, FieldLoad, FieldDisk:
+			fields = append(fields, f)
+		default:
+			return nil, fmt.Errorf("hoststatus: unknown field %q (want host, ip, uptime, load, or disk)", name)
+		}
+	}
+	if len(fields) == 0 {
+		return DefaultFields, nil
+	}
+	return fields, nil
+}
+
+// BuildText formats s as a text screen: the hostname as title, then IP and uptime, load, and
+// disk usage on the three message lines, with the collection time as signature. Only the
+// selected fields (DefaultFields when none) that have a value are shown.
+func BuildText(s Status, fields ...Field) quote0.TextRequest {
+	if len(fields) == 0 {
+		fields = DefaultFields
+	}
+	want := make(map[Field]bool, len(fields))
+	for _, f := range fields {
+		want[f] = true
+	}
+
+	var netLine []string
+	if want[FieldIP] && s.IP != "" {
+		netLine = append(netLine, s.IP)
+	}
+	if want[FieldUptime] && s.Uptime > 0 {
+		netLine = append(netLine, "up "+FormatUptime(s.Uptime))
+	}
+	var lines []string
+	if len(netLine) > 0 {
+		lines = append(lines, strings.Join(netLine, "  "))
+	}
+	if want[FieldLoad] && len(s.Load) == 3 {
+		lines = append(lines, fmt.Sprintf("load %.2f %.2f %.2f", s.Load[0], s.Load[1], s.Load[2]))
+	}
+	if want[FieldDisk] && s.DiskTotal > 0 {
+		pct := float64(s.DiskUsed) * 100 / float64(s.DiskTotal)
+		lines = append(lines, fmt.Sprintf("disk %s %.0f%% of %s", s.DiskPath, pct, FormatBytes(s.DiskTotal)))
+	}
+
+	req := quote0.TextRequest{
+		RefreshNow: quote0.Bool(true),
+		Message:    strings.Join(lines, "\n"),
+	}
+	if want[FieldHost] {
+		req.Title = quote0.Truncate(s.Hostname, quote0.MaxTitleRunes)
+	}
+	if !s.CollectedAt.IsZero() {
+		req.Signature = s.CollectedAt.Format(SignatureLayout)
+	}
+	return req
+}
+
+// FormatUptime renders d compactly: "3d 4h", "5h 12m", or "7m".
+func FormatUptime(d time.Duration) string {
+	days := int(d / (24 * time.Hour))
+	hours := int(d/time.Hour) % 24
+	mins := int(d/time.Minute) % 60
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd %dh", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%dh %dm", hours, mins)
+	default:
+		return fmt.Sprintf("%dm", mins)
+	}
+}
+
+// FormatBytes renders n with a binary unit suffix, e.g. "50G".
+func FormatBytes(n uint64) string {
+	const units = "KMGTPE"
+	if n < 1024 {
+		return fmt.Sprintf("%dB", n)
+	}
+	v, i := float64(n)/1024, 0
+	for v >= 1024 && i < len(units)-1 {
+		v /= 1024
+		i++
+	}
+	if v < 10 {
+		return fmt.Sprintf("%.1f%c", v, units[i])
+	}
+	return fmt.Sprintf("%.0f%c", v, units[i])
+}
+
+// primaryIP returns the first global unicast IPv4 address of an interface that is up.
+func primaryIP() string {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return ""
+	}
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, a := range addrs {
+			if ipn, ok := a.(*net.IPNet); ok {
+				if ip := ipn.IP.To4(); ip != nil && ip.IsGlobalUnicast() {
+					return ip.String()
+				}
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,86 @@
+package hoststatus
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildText(t *testing.T) {
+	s := Status{
+		Hostname:    "nas-01",
+		IP:          "192.168.1.20",
+		Uptime:      3*24*time.Hour + 4*time.Hour + 5*time.Minute,
+		Load:        []float64{0.5, 0.25, 0.125},
+		DiskPath:    "/",
+		DiskUsed:    30 << 30,
+		DiskTotal:   120 << 30,
+		CollectedAt: time.Date(2025, 11, 10, 9, 30, 0, 0, time.UTC),
+	}
+	req := BuildText(s)
+	if req.Title != "nas-01" || req.Signature != "11-10 09:30" {
+		t.Fatalf("title %q signature %q", req.Title, req.Signature)
+	}
+	want := "192.168.1.20  up 3d 4h\nload 0.50 0.25 0.12\ndisk / 25% of 120G"
+	if req.Message != want {
+		t.Fatalf("message %q, want %q", req.Message, want)
+	}
+	if req.RefreshNow == nil || !*req.RefreshNow {
+		t.Fatal("refreshNow not set")
+	}
+
+	req = BuildText(s, FieldLoad, FieldUptime)
+	if req.Title != "" || req.Message != "up 3d 4h\nload 0.50 0.25 0.12" {
+		t.Fatalf("selected fields: %+v", req)
+	}
+}
+
+func TestBuildText_SkipsMissing(t *testing.T) {
+	req := BuildText(Status{Hostname: "box", CollectedAt: time.Date(2025, 1, 2, 3, 4, 0, 0, time.UTC)})
+	if req.Title != "box" || req.Message != "" || req.Signature != "01-02 03:04" {
+		t.Fatalf("%+v", req)
+	}
+}
+
+func TestParseFields(t *testing.T) {
+	got, err := ParseFields(" Load, disk ")
+	if err != nil || len(got) != 2 || got[0] != FieldLoad || got[1] != FieldDisk {
+		t.Fatalf("got %v, %v", got, err)
+	}
+	if got, err := ParseFields(""); err != nil || len(got) != len(DefaultFields) {
+		t.Fatalf("empty: %v, %v", got, err)
+	}
+	if _, err := ParseFields("host,cpu"); err == nil || !strings.Contains(err.Error(), `"cpu"`) {
+		t.Fatalf("err %v", err)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		90 * time.Second:             "1m",
+		5*time.Hour + 12*time.Minute: "5h 12m",
+		50 * time.Hour:               "2d 2h",
+	} {
+		if got := FormatUptime(d); got != want {
+			t.Errorf("FormatUptime(%v) = %q, want %q", d, got, want)
+		}
+	}
+	for n, want := range map[uint64]string{512: "512B", 1536: "1.5K", 250 << 30: "250G"} {
+		if got := FormatBytes(n); got != want {
+			t.Errorf("FormatBytes(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
+
+func TestCollect(t *testing.T) {
+	s := Collect(WithDiskPath(t.TempDir()))
+	if s.CollectedAt.IsZero() {
+		t.Fatal("CollectedAt not set")
+	}
+	if s.DiskTotal > 0 && s.DiskUsed > s.DiskTotal {
+		t.Fatalf("disk used %d > total %d", s.DiskUsed, s.DiskTotal)
+	}
+	if s.Load != nil && len(s.Load) != 3 {
+		t.Fatalf("load %v", s.Load)
+	}
+}
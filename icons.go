@@ -0,0 +1,277 @@
+package quote0
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// IconSize is the edge length of icons in the text layout and of the built-in icon set.
+const IconSize = 40
+
+// Icon names a pictogram from the built-in set, drawn procedurally at IconSize.
+type Icon string
+
+// Built-in icons.
+const (
+	IconSun          Icon = "sun"
+	IconMoon         Icon = "moon"
+	IconCloud        Icon = "cloud"
+	IconPartlyCloudy Icon = "partly-cloudy"
+	IconRain         Icon = "rain"
+	IconSnow         Icon = "snow"
+	IconStorm        Icon = "storm"
+	IconFog          Icon = "fog"
+	IconWarning      Icon = "warning"
+	IconCheck        Icon = "check"
+)
+
+var (
+	// ErrUnknownIcon is returned for names outside the built-in set.
+	ErrUnknownIcon = errors.New("quote0: unknown icon")
+	// ErrIconFetch is returned by FetchIcon when the icon cannot be downloaded.
+	ErrIconFetch = errors.New("quote0: could not fetch icon")
+	// ErrMonogramText is returned by RenderMonogram for text it cannot draw.
+	ErrMonogramText = errors.New("quote0: invalid monogram text")
+)
+
+// MaxMonogramRunes is the most characters RenderMonogram fits legibly on an icon.
+const MaxMonogramRunes = 2
+
+// maxIconDownload caps FetchIcon responses; a 40x40 PNG is a few hundred bytes.
+const maxIconDownload = 1 << 20
+
+var iconPainters = map[Icon]func(c *Canvas){
+	IconSun:          paintSun,
+	IconMoon:         paintMoon,
+	IconCloud:        func(c *Canvas) { paintCloud(c, 0, 4) },
+	IconPartlyCloudy: paintPartlyCloudy,
+	IconRain:         func(c *Canvas) { paintCloud(c, 0, -4); paintDrops(c, false) },
+	IconSnow:         func(c *Canvas) { paintCloud(c, 0, -4); paintDrops(c, true) },
+	IconStorm:        paintStorm,
+	IconFog:          paintFog,
+	IconWarning:      paintWarning,
+	IconCheck:        paintCheck,
+}
+
+// Icons lists the names of the built-in set.
+func Icons() []Icon {
+	return []Icon{IconSun, IconMoon, IconCloud, IconPartlyCloudy, IconRain, IconSnow, IconStorm, IconFog, IconWarning, IconCheck}
+}
+
+// RenderIcon draws a built-in icon as a black-on-white IconSize x IconSize image.
+func RenderIcon(name Icon) (*image.Gray, error) {
+	paint, ok := iconPainters[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownIcon, name)
+	}
+	c := NewCanvasSize(IconSize, IconSize)
+	paint(c)
+	return c.Image(), nil
+}
+
+// IconBase64 renders a built-in icon as the base64 PNG expected by TextRequest.Icon.
+func IconBase64(name Icon) (string, error) {
+	img, err := RenderIcon(name)
+	if err != nil {
+		return "", err
+	}
+	data, err := (&Canvas{img: img}).PNG()
+	if err != nil {
+		return "", err
+	}
+	return encodeBase64(data), nil
+}
+
+// RenderMonogram draws one or two characters (surrounding spaces are ignored) as large as they
+// fit on an IconSize square: black on white, or white on black when inverted. Text that is
+// empty, longer than MaxMonogramRunes, or outside the built-in font reports ErrMonogramText.
+func RenderMonogram(text string, inverted bool) (*image.Gray, error) {
+	text = strings.TrimSpace(text)
+	n := utf8.RuneCountInString(text)
+	if n == 0 || n > MaxMonogramRunes {
+		return nil, fmt.Errorf("%w: %q has %d characters, want 1 to %d", ErrMonogramText, text, n, MaxMonogramRunes)
+	}
+	for _, r := range text {
+		if !hasGlyph(r) {
+			return nil, fmt.Errorf("%w: no glyph for %q", ErrMonogramText, r)
+		}
+	}
+	fg, bg := Black, White
+	if inverted {
+		fg, bg = White, Black
+	}
+	c := NewCanvasSize(IconSize, IconSize)
+	c.FillRect(c.Bounds(), bg)
+	const margin = 2
+	scale := 1
+	for TextWidth(text, scale+1) <= IconSize-2*margin && glyphH*(scale+1) <= IconSize-2*margin {
+		scale++
+	}
+	x := (IconSize - TextWidth(text, scale)) / 2
+	y := (IconSize - glyphH*scale) / 2
+	c.DrawText(x, y, text, scale, fg)
+	return c.Image(), nil
+}
+
+// FitIcon converts an arbitrary image (album art, avatars, logos) into an IconSize square:
+// transparent pixels are composited over white, then it is scaled with area averaging to fit
+// while keeping its aspect ratio, converted to gray, and centred on white.
+func FitIcon(src image.Image) *image.Gray {
+	c := NewCanvasSize(IconSize, IconSize)
+	if b := src.Bounds(); !b.Empty() {
+		scaleInto(c, containRect(b.Size(), c.Bounds()), flattenAlpha(src, White), b, ResampleBox)
+	}
+	return c.Image()
+}
+
+// FetchIcon downloads a 40x40 PNG icon from url for TextRequest.IconBytes, using hc (nil means
+// http.DefaultClient) and honoring ctx. Download failures, non-2xx statuses, and responses over
+// 1 MiB report ErrIconFetch; content that is not a 40x40 PNG reports ErrInvalidImage or
+// ErrIconSize.
+func FetchIcon(ctx context.Context, hc *http.Client, url string) ([]byte, error) {
+	data, err := download(ctx, hc, url, maxIconDownload, ErrIconFetch)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := png.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: icon from %s: %v", ErrInvalidImage, url, err)
+	}
+	if cfg.Width != IconSize || cfg.Height != IconSize {
+		return nil, fmt.Errorf("%w: icon from %s is %dx%d", ErrIconSize, url, cfg.Width, cfg.Height)
+	}
+	return data, nil
+}
+
+// download GETs url with hc (nil means http.DefaultClient), reading at most limit bytes.
+// Failures wrap sentinel.
+func download(ctx context.Context, hc *http.Client, url string, limit int64, sentinel error) ([]byte, error) {
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", sentinel, err)
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", sentinel, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("%w: %s: HTTP %d", sentinel, url, resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", sentinel, url, err)
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%w: %s: larger than %d bytes", sentinel, url, limit)
+	}
+	return data, nil
+}
+
+func max1(v int) int {
+	if v < 1 {
+		return 1
+	}
+	return v
+}
+
+func paintSun(c *Canvas) {
+	c.FillCircle(20, 20, 8, Black)
+	rays := [][4]int{{20, 2, 20, 7}, {20, 33, 20, 38}, {2, 20, 7, 20}, {33, 20, 38, 20},
+		{7, 7, 10, 10}, {30, 30, 33, 33}, {7, 33, 10, 30}, {30, 10, 33, 7}}
+	for _, r := range rays {
+		c.Line(r[0], r[1], r[2], r[3], Black)
+		c.Line(r[0]+1, r[1], r[2]+1, r[3], Black)
+	}
+}
+
+func paintMoon(c *Canvas) {
+	c.FillCircle(20, 20, 14, Black)
+	c.FillCircle(27, 15, 12, White)
+}
+
+// paintCloud draws a filled cloud outline shifted by (dx, dy) from its default position.
+func paintCloud(c *Canvas, dx, dy int) {
+	c.FillCircle(14+dx, 22+dy, 7, Black)
+	c.FillCircle(23+dx, 16+dy, 9, Black)
+	c.FillCircle(30+dx, 23+dy, 6, Black)
+	c.FillRect(image.Rect(8+dx, 22+dy, 35+dx, 30+dy), Black)
+	c.FillCircle(14+dx, 22+dy, 5, White)
+	c.FillCircle(23+dx, 16+dy, 7, White)
+	c.FillCircle(30+dx, 23+dy, 4, White)
+	c.FillRect(image.Rect(10+dx, 22+dy, 33+dx, 28+dy), White)
+}
+
+func paintPartlyCloudy(c *Canvas) {
+	c.FillCircle(13, 13, 7, Black)
+	for _, r := range [][4]int{{13, 1, 13, 4}, {1, 13, 4, 13}, {4, 4, 6, 6}, {22, 4, 20, 6}, {4, 22, 6, 20}} {
+		c.Line(r[0], r[1], r[2], r[3], Black)
+	}
+	c.FillCircle(18, 26, 8, White)
+	c.FillCircle(26, 21, 10, White)
+	c.FillRect(image.Rect(10, 26, 38, 36), White)
+	paintCloud(c, 3, 6)
+}
+
+func paintDrops(c *Canvas, snow bool) {
+	for i, x := range []int{12, 20, 28} {
+		y := 30 + (i%2)*3
+		if snow {
+			c.Line(x-2, y, x+2, y+4, Black)
+			c.Line(x-2, y+4, x+2, y, Black)
+			c.Line(x, y-1, x, y+5, Black)
+			continue
+		}
+		c.Line(x, y, x-2, y+6, Black)
+		c.Line(x+1, y, x-1, y+6, Black)
+	}
+}
+
+func paintStorm(c *Canvas) {
+	paintCloud(c, 0, -4)
+	bolt := [][4]int{{22, 27, 17, 33}, {17, 33, 22, 33}, {22, 33, 17, 39}}
+	for _, l := range bolt {
+		c.Line(l[0], l[1], l[2], l[3], Black)
+		c.Line(l[0]+1, l[1], l[2]+1, l[3], Black)
+	}
+}
+
+func paintFog(c *Canvas) {
+	for i, y := range []int{10, 17, 24, 31} {
+		x0 := 6 + (i%2)*4
+		c.FillRect(image.Rect(x0, y, x0+26, y+3), Black)
+	}
+}
+
+func paintWarning(c *Canvas) {
+	for y := 4; y < 36; y++ {
+		half := (y - 4) * 16 / 32
+		c.FillRect(image.Rect(20-half, y, 21+half, y+1), Black)
+	}
+	for y := 9; y < 33; y++ {
+		half := (y-9)*11/24 - 1
+		if half >= 0 {
+			c.FillRect(image.Rect(20-half, y, 21+half, y+1), White)
+		}
+	}
+	c.FillRect(image.Rect(19, 15, 22, 26), Black)
+	c.FillRect(image.Rect(19, 28, 22, 31), Black)
+}
+
+func paintCheck(c *Canvas) {
+	for i := 0; i < 3; i++ {
+		c.Line(7, 20+i, 16, 29+i, Black)
+		c.Line(16, 29+i, 33, 10+i, Black)
+	}
+}
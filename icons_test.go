@@ -0,0 +1,66 @@
+package quote0
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFetchIcon(t *testing.T) {
+	icon, _ := NewCanvasSize(IconSize, IconSize).PNG()
+	big, _ := NewCanvas().PNG()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok.png":
+			_, _ = w.Write(icon)
+		case "/big.png":
+			_, _ = w.Write(big)
+		case "/text":
+			_, _ = w.Write([]byte("hello"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	got, err := FetchIcon(context.Background(), srv.Client(), srv.URL+"/ok.png")
+	if err != nil || string(got) != string(icon) {
+		t.Fatalf("got %d bytes, %v", len(got), err)
+	}
+	for path, want := range map[string]error{
+		"/big.png": ErrIconSize,
+		"/text":    ErrInvalidImage,
+		"/missing": ErrIconFetch,
+	} {
+		if _, err := FetchIcon(context.Background(), nil, srv.URL+path); !errors.Is(err, want) {
+			t.Errorf("%s: want %v, got %v", path, want, err)
+		}
+	}
+	if _, err := FetchIcon(context.Background(), nil, "http://127.0.0.1:0/x.png"); !errors.Is(err, ErrIconFetch) {
+		t.Errorf("unreachable host: %v", err)
+	}
+}
+
+func TestRenderMonogram(t *testing.T) {
+	db, err := RenderMonogram("DB", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertGolden(t, "monogram_db", db)
+	warn, err := RenderMonogram(" ⚠ ", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertGolden(t, "monogram_warning_inverted", warn)
+	if b := warn.Bounds(); b.Dx() != IconSize || b.Dy() != IconSize || warn.GrayAt(0, 0) != Black {
+		t.Fatalf("inverted monogram %v, corner %v", b, warn.GrayAt(0, 0))
+	}
+
+	for _, text := range []string{"", "  ", "ABC", "日"} {
+		if _, err := RenderMonogram(text, false); !errors.Is(err, ErrMonogramText) || !IsValidationError(err) {
+			t.Errorf("%q: %v", text, err)
+		}
+	}
+}
@@ -2,6 +2,9 @@ package quote0
 
 import (
 	"context"
+	"fmt"
+	"image/color"
+	"strconv"
 	"strings"
 )
 
@@ -15,6 +18,38 @@ const (
 	BorderBlack BorderColor = 1
 )
 
+// String returns "white" or "black", or the number for other values.
+func (b BorderColor) String() string {
+	switch b {
+	case BorderWhite:
+		return "white"
+	case BorderBlack:
+		return "black"
+	}
+	return strconv.Itoa(int(b))
+}
+
+// Gray returns the color the border is drawn in, for example to fill a WithSafeMargin band
+// so it blends into the border: Black for BorderBlack and White otherwise.
+func (b BorderColor) Gray() color.Gray {
+	if b == BorderBlack {
+		return Black
+	}
+	return White
+}
+
+// ParseBorderColor parses a border color name ("white" or "black", case-insensitive) or its
+// API number ("0" or "1").
+func ParseBorderColor(s string) (BorderColor, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "white", "0":
+		return BorderWhite, nil
+	case "black", "1":
+		return BorderBlack, nil
+	}
+	return BorderWhite, fmt.Errorf("quote0: invalid border color %q (want white, black, 0, or 1)", s)
+}
+
 // DitherType enumerates server-accepted dithering modes.
 //
 // Server behavior (per official docs):
@@ -117,30 +152,42 @@ func (r ImageRequest) validate() error {
 	return nil
 }
 
-// SendImage uploads a base64-encoded image to the device. If DeviceID is empty, the
-// client's default device is used.
-func (c *Client) SendImage(ctx context.Context, payload ImageRequest) (*APIResponse, error) {
-	did, err := c.resolveDeviceID(payload.DeviceID)
-	if err != nil {
-		return nil, err
+// normalizeImage fills Image from ImageBytes or ImagePath when it is empty.
+// Precedence: Image (base64) > ImageBytes > ImagePath.
+func (r *ImageRequest) normalizeImage() error {
+	if strings.TrimSpace(r.Image) != "" {
+		return nil
 	}
-	payload.DeviceID = did
-	// Normalize image data: precedence -> Image (base64) > ImageBytes > ImagePath
-	if strings.TrimSpace(payload.Image) == "" {
-		if len(payload.ImageBytes) > 0 {
-			payload.Image = encodeBase64(payload.ImageBytes)
-		} else if p := strings.TrimSpace(payload.ImagePath); p != "" {
-			data, readErr := readFile(p)
-			if readErr != nil {
-				return nil, readErr
-			}
-			payload.Image = encodeBase64(data)
+	if len(r.ImageBytes) > 0 {
+		r.Image = encodeBase64(r.ImageBytes)
+	} else if p := strings.TrimSpace(r.ImagePath); p != "" {
+		data, err := readFile(p)
+		if err != nil {
+			return err
 		}
+		r.Image = encodeBase64(data)
 	}
-	if err := payload.validate(); err != nil {
+	return nil
+}
+
+// SendImage uploads a base64-encoded image to the device. If DeviceID is empty, the device
+// from ContextWithDeviceID or else the client's default device is used. opts override the
+// matching payload fields for this call (see SendOption).
+func (c *Client) SendImage(ctx context.Context, payload ImageRequest, opts ...SendOption) (*APIResponse, error) {
+	cfg := newSendConfig(opts)
+	cfg.applyImage(&payload)
+	ctx, cancel := cfg.context(ctx)
+	defer cancel()
+	req, err := c.buildImage(ctx, payload)
+	if err != nil {
 		return nil, err
 	}
-	return c.doJSON(ctx, imageEndpoint, payload)
+	img := req.Payload.(*ImageRequest)
+	resp, err := c.doJSON(ctx, imageEndpoint, img.DeviceID, img)
+	if err == nil {
+		c.recordSent(SentRecord{DeviceID: img.DeviceID, Image: img})
+	}
+	return resp, err
 }
 
 // SendImageToDevice is a convenience to target a specific device.
@@ -157,6 +204,15 @@ func (c *Client) SendImageSimple(base64PNG string) (*APIResponse, error) {
 	})
 }
 
+// SendImageSimpleContext sends base64PNG with immediate refresh like SendImageSimple, using
+// ctx and letting opts override the device, refresh, link, or timeout.
+func (c *Client) SendImageSimpleContext(ctx context.Context, base64PNG string, opts ...SendOption) (*APIResponse, error) {
+	return c.SendImage(ctx, ImageRequest{
+		RefreshNow: Bool(true),
+		Image:      base64PNG,
+	}, opts...)
+}
+
 // SendImageBytes is a convenience that accepts raw PNG bytes and performs base64 encoding internally.
 func (c *Client) SendImageBytes(ctx context.Context, png []byte, meta ImageRequest) (*APIResponse, error) {
 	meta.Image = ""
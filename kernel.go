@@ -0,0 +1,115 @@
+package quote0
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+)
+
+// ErrInvalidKernel is returned by RegisterKernel for a bad name, matrix, or divisor.
+var ErrInvalidKernel = errors.New("quote0: invalid dither kernel")
+
+// Limits on RegisterKernel matrices; the largest built-in kernels are 5 wide and 3 high.
+const (
+	MaxKernelWidth = 9
+	MaxKernelRows  = 5
+)
+
+var (
+	customKernelsMu sync.RWMutex
+	customKernels   = map[DitherKernel]diffusionKernel{}
+)
+
+// RegisterKernel adds an error-diffusion kernel for local dithering under name, selectable in
+// Dither and WithLocalDither with DitherDiffusion like the built-in kernels. Names are
+// case-insensitive and stored uppercase; registering a name again replaces the kernel, but
+// the built-in names are reserved. The server does not know custom kernels and CheckImage
+// reports them as errors in requests: dither locally and send with DitherNone.
+//
+// matrix starts at the current row and is centred on the current pixel: row 0 holds the
+// weights for the pixels to its right, and entries at or left of the centre column of row 0
+// must be zero, as those pixels are already done. Each neighbor receives weight/divisor of
+// the error. Rows must have the same odd width, up to MaxKernelWidth by MaxKernelRows, and
+// weights must be non-negative with a positive sum no greater than divisor. For example,
+// Floyd-Steinberg is {{0, 0, 7}, {3, 5, 1}} with divisor 16. Invalid kernels report
+// ErrInvalidKernel.
+func RegisterKernel(name string, matrix [][]float64, divisor float64) error {
+	k := DitherKernel(strings.ToUpper(strings.TrimSpace(name)))
+	if k == "" {
+		return fmt.Errorf("%w: empty name", ErrInvalidKernel)
+	}
+	if _, ok := diffusionKernels[k]; ok {
+		return fmt.Errorf("%w: %s is a built-in kernel", ErrInvalidKernel, k)
+	}
+	kernel, err := newKernel(matrix, divisor)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidKernel, k, err)
+	}
+	customKernelsMu.Lock()
+	customKernels[k] = kernel
+	customKernelsMu.Unlock()
+	return nil
+}
+
+// newKernel checks a RegisterKernel matrix and converts it to taps.
+func newKernel(matrix [][]float64, divisor float64) (diffusionKernel, error) {
+	if !(divisor > 0) || math.IsInf(divisor, 0) {
+		return diffusionKernel{}, fmt.Errorf("divisor must be positive, got %g", divisor)
+	}
+	if len(matrix) == 0 || len(matrix) > MaxKernelRows {
+		return diffusionKernel{}, fmt.Errorf("matrix has %d rows, want 1 to %d", len(matrix), MaxKernelRows)
+	}
+	width := len(matrix[0])
+	if width%2 == 0 || width > MaxKernelWidth {
+		return diffusionKernel{}, fmt.Errorf("rows are %d wide, want an odd width up to %d", width, MaxKernelWidth)
+	}
+	center := width / 2
+	var taps []ditherTap
+	var sum float64
+	for dy, row := range matrix {
+		if len(row) != width {
+			return diffusionKernel{}, fmt.Errorf("row %d is %d wide, row 0 is %d", dy, len(row), width)
+		}
+		for i, w := range row {
+			switch {
+			case w < 0 || math.IsNaN(w) || math.IsInf(w, 0):
+				return diffusionKernel{}, fmt.Errorf("weight %g at row %d, column %d must be a non-negative number", w, dy, i)
+			case w == 0:
+				continue
+			case dy == 0 && i <= center:
+				return diffusionKernel{}, fmt.Errorf("weight %g at row 0, column %d is at or left of the current pixel (column %d)", w, i, center)
+			}
+			taps = append(taps, ditherTap{dx: i - center, dy: dy, weight: w})
+			sum += w
+		}
+	}
+	if sum == 0 {
+		return diffusionKernel{}, errors.New("all weights are zero")
+	}
+	if sum > divisor {
+		return diffusionKernel{}, fmt.Errorf("weights sum to %g, more than the divisor %g", sum, divisor)
+	}
+	return diffusionKernel{divisor: divisor, taps: taps}, nil
+}
+
+// lookupKernel returns a built-in or registered kernel, ignoring case.
+func lookupKernel(k DitherKernel) (diffusionKernel, bool) {
+	k = DitherKernel(strings.ToUpper(string(k)))
+	if kernel, ok := diffusionKernels[k]; ok {
+		return kernel, true
+	}
+	customKernelsMu.RLock()
+	defer customKernelsMu.RUnlock()
+	kernel, ok := customKernels[k]
+	return kernel, ok
+}
+
+// isCustomKernel reports whether k was added with RegisterKernel.
+func isCustomKernel(k DitherKernel) bool {
+	customKernelsMu.RLock()
+	defer customKernelsMu.RUnlock()
+	_, ok := customKernels[k]
+	return ok
+}
@@ -0,0 +1,122 @@
+package quote0
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRegisterKernel(t *testing.T) {
+	// The matrix form of Floyd-Steinberg dithers exactly like the built-in.
+	if err := RegisterKernel("test_fs", [][]float64{{0, 0, 7}, {3, 5, 1}}, 16); err != nil {
+		t.Fatal(err)
+	}
+	src := mustPattern(t, PatternGradientH)
+	want, _ := Dither(src, DitherDiffusion, KernelFloydSteinberg)
+	got, err := Dither(src, DitherDiffusion, "Test_FS")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got.Pix) != string(want.Pix) {
+		t.Fatal("registered Floyd-Steinberg differs from the built-in")
+	}
+
+	for _, tt := range []struct {
+		name    string
+		matrix  [][]float64
+		divisor float64
+		msg     string
+	}{
+		{" ", [][]float64{{0, 0, 1}}, 1, "empty name"},
+		{"atkinson", [][]float64{{0, 0, 1}}, 1, "ATKINSON is a built-in kernel"},
+		{"k", [][]float64{{0, 0, 1}}, 0, "divisor must be positive"},
+		{"k", nil, 1, "matrix has 0 rows"},
+		{"k", [][]float64{{0, 1}}, 1, "odd width"},
+		{"k", [][]float64{{0, 0, 1}, {1, 1}}, 4, "row 1 is 2 wide"},
+		{"k", [][]float64{{0, 1, 1}}, 4, "at or left of the current pixel"},
+		{"k", [][]float64{{0, 0, -1}}, 4, "non-negative"},
+		{"k", [][]float64{{0, 0, 0}}, 4, "all weights are zero"},
+		{"k", [][]float64{{0, 0, 3}, {1, 1, 1}}, 4, "more than the divisor"},
+	} {
+		err := RegisterKernel(tt.name, tt.matrix, tt.divisor)
+		if !errors.Is(err, ErrInvalidKernel) || !IsValidationError(err) || !strings.Contains(err.Error(), tt.msg) {
+			t.Errorf("%q %v /%g: %v, want %q", tt.name, tt.matrix, tt.divisor, err, tt.msg)
+		}
+	}
+	if _, err := Dither(src, DitherDiffusion, "k"); err == nil {
+		t.Fatal("rejected kernel was registered")
+	}
+}
+
+func TestRegisterKernel_Golden(t *testing.T) {
+	// Sierra Lite.
+	if err := RegisterKernel("test_sierra_lite", [][]float64{{0, 0, 2}, {1, 1, 0}}, 4); err != nil {
+		t.Fatal(err)
+	}
+	img, err := Dither(mustPattern(t, PatternGradientH), DitherDiffusion, "TEST_SIERRA_LITE")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertGolden(t, "dither_custom_kernel", img)
+}
+
+func TestDither_Serpentine(t *testing.T) {
+	src := mustPattern(t, PatternGradientH)
+	img, err := Dither(src, DitherDiffusion, KernelFloydSteinberg, WithSerpentine(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertGolden(t, "dither_serpentine_fs", img)
+
+	plain, _ := Dither(src, DitherDiffusion, KernelFloydSteinberg, WithSerpentine(false))
+	if string(plain.Pix) == string(img.Pix) {
+		t.Fatal("serpentine scan changed nothing")
+	}
+	// Rows scanned right to left mirror the kernel, so the tones stay as faithful.
+	se, _ := ToneError(src, img)
+	pe, _ := ToneError(src, plain)
+	if se > pe*1.1 {
+		t.Fatalf("serpentine tone error %.2f, plain %.2f", se, pe)
+	}
+	// Ordered dithering has no scan order.
+	a, _ := Dither(src, DitherOrdered, "", WithSerpentine(true))
+	b, _ := Dither(src, DitherOrdered, "")
+	if string(a.Pix) != string(b.Pix) {
+		t.Fatal("serpentine changed ordered dithering")
+	}
+}
+
+func TestProcessImage_LocalDither(t *testing.T) {
+	if err := RegisterKernel("test_local", [][]float64{{0, 0, 1}}, 2); err != nil {
+		t.Fatal(err)
+	}
+	src := mustPattern(t, PatternGradientV)
+	img, err := ProcessImage(src, WithLocalDither("", "test_local", WithSerpentine(true)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := Dither(src, DitherDiffusion, "TEST_LOCAL", WithSerpentine(true))
+	if string(img.Pix) != string(want.Pix) {
+		t.Fatal("WithLocalDither differs from Dither")
+	}
+	if _, err := ProcessImage(src, WithLocalDither(DitherDiffusion, "nope")); err == nil {
+		t.Fatal("unknown kernel accepted")
+	}
+
+	ps := CheckProcessing("", WithLocalDither(DitherDiffusion, "test_local"))
+	if len(ps) != 1 || ps[0].Field != "ditherType" || !strings.Contains(ps[0].Message, "local dithering") {
+		t.Fatalf("server dither after local: %v", ps)
+	}
+	ps = CheckProcessing(DitherNone, WithLocalDither(DitherDiffusion, "nope"))
+	if len(ps) != 1 || ps[0].Field != "localDither" || !HasErrors(ps) {
+		t.Fatalf("unknown kernel: %v", ps)
+	}
+	ps = CheckImage(ImageRequest{DeviceID: "D", ImageBytes: pngBytes(t, ScreenWidth, ScreenHeight), DitherKernel: "test_local"})
+	found := false
+	for _, p := range ps {
+		found = found || p.Field == "ditherKernel" && strings.Contains(p.Message, "local kernel")
+	}
+	if !found {
+		t.Fatalf("custom kernel in a request: %v", ps)
+	}
+}
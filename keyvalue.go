@@ -0,0 +1,113 @@
+package quote0
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrTooManyPairs is returned by FormatKeyValues when the pairs need more lines than allowed.
+var ErrTooManyPairs = errors.New("quote0: too many key/value pairs for the message area")
+
+// KeyValue is one labelled value, such as {"CPU", "43%"}.
+type KeyValue struct {
+	Key, Value string
+}
+
+// KeyValueAlign selects how FormatKeyValues lines up pairs. The device font is proportional,
+// so alignment by rune count is close but not pixel exact.
+type KeyValueAlign string
+
+const (
+	// AlignColumns pads the keys so the values start in the same column (default).
+	AlignColumns KeyValueAlign = "columns"
+	// AlignRight pushes the values to the end of the line.
+	AlignRight KeyValueAlign = "right"
+	// AlignNone joins key, separator, and value without padding.
+	AlignNone KeyValueAlign = "none"
+)
+
+// KeyValueOption configures FormatKeyValues.
+type KeyValueOption func(*keyValueConfig)
+
+type keyValueConfig struct {
+	separator string
+	align     KeyValueAlign
+	lines     int
+	width     int
+	drop      bool
+}
+
+// WithKeyValueSeparator sets the text between key and value (default ": ").
+func WithKeyValueSeparator(sep string) KeyValueOption {
+	return func(cfg *keyValueConfig) { cfg.separator = sep }
+}
+
+// WithKeyValueAlign selects AlignColumns (default), AlignRight, or AlignNone.
+func WithKeyValueAlign(a KeyValueAlign) KeyValueOption {
+	return func(cfg *keyValueConfig) { cfg.align = a }
+}
+
+// WithKeyValueLines sets how many pairs fit (default MessageLines) and the line width in runes
+// (default MaxMessageLineRunes). Values below 1 keep the default.
+func WithKeyValueLines(lines, width int) KeyValueOption {
+	return func(cfg *keyValueConfig) {
+		if lines > 0 {
+			cfg.lines = lines
+		}
+		if width > 0 {
+			cfg.width = width
+		}
+	}
+}
+
+// WithKeyValueOverflow drops the pairs that do not fit instead of failing with ErrTooManyPairs.
+func WithKeyValueOverflow(drop bool) KeyValueOption {
+	return func(cfg *keyValueConfig) { cfg.drop = drop }
+}
+
+// FormatKeyValues formats pairs as aligned message lines, one pair per line, each cut to the
+// line width with a trailing "…". By default at most MessageLines pairs are accepted.
+func FormatKeyValues(pairs []KeyValue, opts ...KeyValueOption) (string, error) {
+	cfg := keyValueConfig{separator: ": ", align: AlignColumns, lines: MessageLines, width: MaxMessageLineRunes}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&cfg)
+		}
+	}
+	switch cfg.align {
+	case AlignColumns, AlignRight, AlignNone:
+	default:
+		return "", fmt.Errorf("quote0: unknown key/value alignment %q (want %s, %s, or %s)", cfg.align, AlignColumns, AlignRight, AlignNone)
+	}
+	if len(pairs) > cfg.lines {
+		if !cfg.drop {
+			return "", fmt.Errorf("%w: %d pairs, %d fit", ErrTooManyPairs, len(pairs), cfg.lines)
+		}
+		pairs = pairs[:cfg.lines]
+	}
+	keyWidth := 0
+	for _, p := range pairs {
+		if n := utf8.RuneCountInString(strings.TrimSpace(p.Key) + cfg.separator); n > keyWidth {
+			keyWidth = n
+		}
+	}
+	lines := make([]string, len(pairs))
+	for i, p := range pairs {
+		key := strings.TrimSpace(p.Key) + cfg.separator
+		value := strings.TrimSpace(p.Value)
+		pad := 0
+		switch cfg.align {
+		case AlignColumns:
+			pad = keyWidth - utf8.RuneCountInString(key)
+		case AlignRight:
+			pad = cfg.width - utf8.RuneCountInString(key) - utf8.RuneCountInString(value)
+		}
+		if pad < 0 {
+			pad = 0
+		}
+		lines[i] = Truncate(key+strings.Repeat(" ", pad)+value, cfg.width)
+	}
+	return strings.Join(lines, "\n"), nil
+}
@@ -0,0 +1,64 @@
+package quote0
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+var statusPairs = []KeyValue{{"CPU", "43%"}, {"Mem", "71%"}, {"Disk", "88%"}}
+
+func TestFormatKeyValues(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []KeyValueOption
+		want string
+	}{
+		{"columns", nil, "CPU:  43%\nMem:  71%\nDisk: 88%"},
+		{"separator", []KeyValueOption{WithKeyValueSeparator(" = ")}, "CPU =  43%\nMem =  71%\nDisk = 88%"},
+		{"none", []KeyValueOption{WithKeyValueAlign(AlignNone), WithKeyValueSeparator("=")}, "CPU=43%\nMem=71%\nDisk=88%"},
+		{"right", []KeyValueOption{WithKeyValueAlign(AlignRight), WithKeyValueLines(0, 12)}, "CPU:     43%\nMem:     71%\nDisk:    88%"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FormatKeyValues(statusPairs, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatKeyValues_Overflow(t *testing.T) {
+	pairs := append(statusPairs, KeyValue{"Load", "0.42"})
+	_, err := FormatKeyValues(pairs)
+	if !errors.Is(err, ErrTooManyPairs) || !IsValidationError(err) {
+		t.Fatalf("err %v", err)
+	}
+	if !strings.Contains(err.Error(), "4 pairs, 3 fit") {
+		t.Fatalf("err %v", err)
+	}
+	got, err := FormatKeyValues(pairs, WithKeyValueOverflow(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(got, "Load") || strings.Count(got, "\n") != 2 {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestFormatKeyValues_LongLinesAndBadAlign(t *testing.T) {
+	got, err := FormatKeyValues([]KeyValue{{"Build", strings.Repeat("x", 60)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len([]rune(got)); n != MaxMessageLineRunes || !strings.HasSuffix(got, "…") {
+		t.Fatalf("got %q (%d runes)", got, n)
+	}
+	if _, err := FormatKeyValues(statusPairs, WithKeyValueAlign("center")); err == nil {
+		t.Fatal("unknown alignment accepted")
+	}
+}
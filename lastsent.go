@@ -0,0 +1,97 @@
+package quote0
+
+import (
+	"sort"
+	"time"
+)
+
+// SentRecord describes the most recent successful send to a device.
+// Exactly one of Text or Image is set.
+type SentRecord struct {
+	// DeviceID is the device that received the content.
+	DeviceID string
+	// SentAt is when the API accepted the request.
+	SentAt time.Time
+	// Text is the normalized text payload, if the last send was text.
+	Text *TextRequest
+	// Image is the normalized image payload (base64 in Image), if the last send was an image.
+	Image *ImageRequest
+}
+
+// Kind returns "text" or "image" depending on which payload the record holds.
+func (r SentRecord) Kind() string {
+	if r.Image != nil {
+		return "image"
+	}
+	return "text"
+}
+
+// recordSent stores a copy of rec as the latest content for its device, so later changes to
+// the caller's request do not reach it.
+func (c *Client) recordSent(rec SentRecord) {
+	if rec.SentAt.IsZero() {
+		rec.SentAt = time.Now()
+	}
+	c.sentMu.Lock()
+	if c.lastSent == nil {
+		c.lastSent = make(map[string]SentRecord)
+	}
+	c.lastSent[rec.DeviceID] = rec.clone()
+	c.sentMu.Unlock()
+}
+
+// LastSent returns the most recent content successfully sent to deviceID by this client.
+// The returned payloads are deep copies, RefreshNow and byte slices included, and may be
+// modified freely.
+func (c *Client) LastSent(deviceID string) (SentRecord, bool) {
+	c.sentMu.RLock()
+	rec, ok := c.lastSent[deviceID]
+	c.sentMu.RUnlock()
+	if !ok {
+		return SentRecord{}, false
+	}
+	return rec.clone(), true
+}
+
+// clone copies the payloads of r, including what their pointer and slice fields refer to.
+func (r SentRecord) clone() SentRecord {
+	if r.Text != nil {
+		t := *r.Text
+		t.RefreshNow = cloneBool(t.RefreshNow)
+		t.IconBytes = cloneBytes(t.IconBytes)
+		r.Text = &t
+	}
+	if r.Image != nil {
+		img := *r.Image
+		img.RefreshNow = cloneBool(img.RefreshNow)
+		img.ImageBytes = cloneBytes(img.ImageBytes)
+		r.Image = &img
+	}
+	return r
+}
+
+func cloneBool(b *bool) *bool {
+	if b == nil {
+		return nil
+	}
+	return Bool(*b)
+}
+
+func cloneBytes(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+	return append([]byte(nil), b...)
+}
+
+// SentDevices lists, in sorted order, the devices this client has successfully sent content to.
+func (c *Client) SentDevices() []string {
+	c.sentMu.RLock()
+	ids := make([]string, 0, len(c.lastSent))
+	for id := range c.lastSent {
+		ids = append(ids, id)
+	}
+	c.sentMu.RUnlock()
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,82 @@
+package quote0
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Approximate text budgets of the fixed layout described on TextRequest. The device font is
+// proportional, so these are conservative rune counts rather than exact limits.
+const (
+	// MaxTitleRunes fits the single title line.
+	MaxTitleRunes = 28
+	// MaxMessageRunes fits the three message lines.
+	MaxMessageRunes = 120
+	// MaxSignatureRunes fits the bottom-right signature corner beside the icon.
+	MaxSignatureRunes = 26
+	// MessageLines is the number of lines in the message area.
+	MessageLines = 3
+	// MaxMessageLineRunes fits one message line.
+	MaxMessageLineRunes = MaxMessageRunes / MessageLines
+)
+
+// SplitTitleMessage splits free-form text into the title and message areas. The first line
+// becomes the title and the remaining lines the message; a single line that is too long for
+// the title goes to the message instead. Blank lines and surrounding whitespace are dropped,
+// and both parts are cut to MaxTitleRunes and MaxMessageRunes with a trailing ellipsis.
+func SplitTitleMessage(text string) (title, message string) {
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	switch {
+	case len(lines) == 0:
+		return "", ""
+	case len(lines) == 1 && utf8.RuneCountInString(lines[0]) > MaxTitleRunes:
+		return "", Truncate(lines[0], MaxMessageRunes)
+	}
+	return Truncate(lines[0], MaxTitleRunes), Truncate(strings.Join(lines[1:], "\n"), MaxMessageRunes)
+}
+
+// Truncate shortens s to at most n runes, replacing the tail with "…" when it is cut.
+func Truncate(s string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	r := []rune(s)
+	return strings.TrimRight(string(r[:n-1]), " \t\n") + "…"
+}
+
+// DisplayWidth returns the number of terminal-style display cells s occupies: East Asian wide
+// and fullwidth characters, such as CJK ideographs, count as two cells and everything else,
+// including ambiguous-width symbols, as one.
+func DisplayWidth(s string) int {
+	n := 0
+	for _, r := range s {
+		n += runeWidth(r)
+	}
+	return n
+}
+
+// runeWidth returns the number of display cells r takes, one or two.
+func runeWidth(r rune) int {
+	switch {
+	case r >= 0x1100 && r <= 0x115f, // Hangul Jamo initials
+		r >= 0x2e80 && r <= 0xa4cf && r != 0x303f, // CJK radicals through Yi
+		r >= 0xac00 && r <= 0xd7a3,                // Hangul syllables
+		r >= 0xf900 && r <= 0xfaff,                // CJK compatibility ideographs
+		r >= 0xfe30 && r <= 0xfe4f,                // CJK compatibility forms
+		r >= 0xff00 && r <= 0xff60,                // fullwidth forms
+		r >= 0xffe0 && r <= 0xffe6,                // fullwidth signs
+		r >= 0x1f300 && r <= 0x1f64f,              // pictographs and emoticons
+		r >= 0x1f900 && r <= 0x1f9ff,              // supplemental pictographs
+		r >= 0x20000 && r <= 0x3fffd:              // CJK extension planes
+		return 2
+	}
+	return 1
+}
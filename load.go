@@ -0,0 +1,86 @@
+package quote0
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LoadTextRequest reads a TextRequest saved as JSON with the API field names, such as the
+// payload printed by a dry run. Unknown fields are rejected so a typo cannot silently drop
+// content. Besides the API fields, "iconPath" names a 40x40 PNG that is loaded into
+// IconBytes; a relative path is resolved against the directory of the JSON file. A "capture"
+// object, the metadata the CLI's -output adds, is ignored.
+func LoadTextRequest(path string) (TextRequest, error) {
+	var saved struct {
+		TextRequest
+		IconPath string          `json:"iconPath"`
+		Capture  json.RawMessage `json:"capture"`
+	}
+	if err := loadJSON(path, &saved); err != nil {
+		return TextRequest{}, err
+	}
+	req := saved.TextRequest
+	if p := strings.TrimSpace(saved.IconPath); p != "" {
+		if req.Icon != "" {
+			return TextRequest{}, fmt.Errorf("quote0: %s: set either icon or iconPath, not both", path)
+		}
+		data, err := os.ReadFile(resolveRelative(path, p))
+		if err != nil {
+			return TextRequest{}, fmt.Errorf("quote0: %s: iconPath: %w", path, err)
+		}
+		req.IconBytes = data
+	}
+	return req, nil
+}
+
+// LoadImageRequest reads an ImageRequest saved as JSON with the API field names. Unknown
+// fields are rejected. Besides the API fields, "imagePath" names a PNG that is loaded like
+// ImageRequest.ImagePath; a relative path is resolved against the directory of the JSON file.
+// A "capture" object is ignored, as by LoadTextRequest.
+func LoadImageRequest(path string) (ImageRequest, error) {
+	var saved struct {
+		ImageRequest
+		ImagePath string          `json:"imagePath"`
+		Capture   json.RawMessage `json:"capture"`
+	}
+	if err := loadJSON(path, &saved); err != nil {
+		return ImageRequest{}, err
+	}
+	req := saved.ImageRequest
+	if p := strings.TrimSpace(saved.ImagePath); p != "" {
+		if req.Image != "" {
+			return ImageRequest{}, fmt.Errorf("quote0: %s: set either image or imagePath, not both", path)
+		}
+		req.ImagePath = resolveRelative(path, p)
+	}
+	return req, nil
+}
+
+func loadJSON(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("quote0: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("quote0: %s: %w", path, err)
+	}
+	if dec.More() {
+		return fmt.Errorf("quote0: %s: %w", path, errors.New("unexpected data after the JSON object"))
+	}
+	return nil
+}
+
+// resolveRelative resolves p against the directory of the file base.
+func resolveRelative(base, p string) string {
+	if filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(filepath.Dir(base), p)
+}
@@ -0,0 +1,105 @@
+package quote0
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, dir, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadTextRequest(t *testing.T) {
+	dir := t.TempDir()
+	icon, err := NewCanvasSize(IconSize, IconSize).PNG()
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, dir, "icons/bell.png", icon)
+	path := writeFile(t, dir, "meeting.json", []byte(`{"deviceId":"D1","title":"Standup","message":"10:00","refreshNow":true,"iconPath":"icons/bell.png"}`))
+	req, err := LoadTextRequest(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.DeviceID != "D1" || req.Title != "Standup" || req.Message != "10:00" || req.RefreshNow == nil || !*req.RefreshNow {
+		t.Fatalf("request %+v", req)
+	}
+	if string(req.IconBytes) != string(icon) {
+		t.Fatal("iconPath not loaded relative to the JSON file")
+	}
+}
+
+func TestLoadImageRequest(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "screens/board.json", []byte(`{"imagePath":"board.png","ditherType":"NONE","border":1}`))
+	req, err := LoadImageRequest(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "screens", "board.png"); req.ImagePath != want {
+		t.Fatalf("ImagePath %q, want %q", req.ImagePath, want)
+	}
+	if req.DitherType != DitherNone || req.Border != BorderBlack {
+		t.Fatalf("request %+v", req)
+	}
+	abs := filepath.Join(dir, "abs.png")
+	path = writeFile(t, dir, "abs.json", []byte(`{"imagePath":"`+filepath.ToSlash(abs)+`"}`))
+	if req, err = LoadImageRequest(path); err != nil || filepath.Clean(req.ImagePath) != abs {
+		t.Fatalf("absolute imagePath %q, %v", req.ImagePath, err)
+	}
+}
+
+func TestLoadRequest_Errors(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name, json, want string
+		image            bool
+	}{
+		{"unknown field", `{"titel":"x"}`, `unknown field "titel"`, false},
+		{"trailing data", `{"title":"x"} {}`, "unexpected data", false},
+		{"icon twice", `{"icon":"AAAA","iconPath":"a.png"}`, "either icon or iconPath", false},
+		{"missing icon", `{"iconPath":"nope.png"}`, "iconPath", false},
+		{"image twice", `{"image":"AAAA","imagePath":"a.png"}`, "either image or imagePath", true},
+		{"raw bytes field", `{"ImageBytes":"AAAA"}`, "unknown field", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFile(t, dir, strings.ReplaceAll(tt.name, " ", "_")+".json", []byte(tt.json))
+			var err error
+			if tt.image {
+				_, err = LoadImageRequest(path)
+			} else {
+				_, err = LoadTextRequest(path)
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("err %v, want %q", err, tt.want)
+			}
+		})
+	}
+	if _, err := LoadTextRequest(filepath.Join(dir, "missing.json")); err == nil {
+		t.Fatal("missing file accepted")
+	}
+}
+
+func TestLoadRequest_IgnoresCapture(t *testing.T) {
+	dir := t.TempDir()
+	capture := `"capture":{"time":"2025-11-08T14:00:00Z","device":"D1","endpoint":"https://example.test/api/open/text"}`
+	path := writeFile(t, dir, "text.json", []byte(`{"deviceId":"D1","title":"Standup",`+capture+`}`))
+	if req, err := LoadTextRequest(path); err != nil || req.Title != "Standup" {
+		t.Fatalf("text %+v, %v", req, err)
+	}
+	path = writeFile(t, dir, "image.json", []byte(`{"image":"AAAA",`+capture+`}`))
+	if req, err := LoadImageRequest(path); err != nil || req.Image != "AAAA" {
+		t.Fatalf("image %+v, %v", req, err)
+	}
+}
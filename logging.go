@@ -0,0 +1,44 @@
+package quote0
+
+import (
+	"context"
+	"time"
+)
+
+// slowLimiterWait is how long a limiter wait may take before WithLogger reports it.
+const slowLimiterWait = 500 * time.Millisecond
+
+// callLog describes one finished doJSON call for the client's logger.
+type callLog struct {
+	endpoint    string
+	deviceID    string
+	requestID   string
+	trace       map[string]string
+	refreshNow  *bool
+	payloadSize int
+	attempts    int
+	elapsed     time.Duration
+	resp        *APIResponse
+	err         error
+}
+
+// callLogger receives the client's structured log events. WithLogger, available when built
+// with Go 1.21 or later, installs one backed by log/slog; without it the client skips all
+// logging work.
+type callLogger interface {
+	logCall(ctx context.Context, l *callLog)
+	logLimiterWait(ctx context.Context, endpoint, deviceID string, waited time.Duration)
+}
+
+// refreshNowOf returns the refreshNow flag of a doJSON payload.
+func refreshNowOf(payload interface{}) *bool {
+	switch p := payload.(type) {
+	case *TextRequest:
+		return p.RefreshNow
+	case *ImageRequest:
+		return p.RefreshNow
+	case TextRequest:
+		return p.RefreshNow
+	}
+	return nil
+}
@@ -0,0 +1,412 @@
+package quote0
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MarkdownOption configures StripMarkdown.
+type MarkdownOption func(*markdownConfig)
+
+type markdownConfig struct {
+	bullet string
+}
+
+// WithMarkdownBullet sets the prefix for unordered list items (default "• ").
+func WithMarkdownBullet(prefix string) MarkdownOption {
+	return func(cfg *markdownConfig) { cfg.bullet = prefix }
+}
+
+// StripMarkdown converts Markdown to plain text for the small screen, so markup does not use
+// up the width: emphasis (*, _, ~~) and code spans are unwrapped, [label](url) and images
+// become their label or alt text, <url> autolinks their URL, heading, quote, and
+// horizontal-rule markers are dropped, and unordered list items start with "• " (ordered ones
+// keep their number). Paragraph lines are joined with spaces and blank lines removed; each
+// heading, list item, and code block line starts a new line. firstLink is the URL of the
+// first link or autolink, for TextRequest.Link.
+//
+// It handles the common subset of CommonMark rather than the full grammar, and any input,
+// however malformed, comes back as text: unmatched markers are kept as written.
+func StripMarkdown(s string, opts ...MarkdownOption) (plain, firstLink string) {
+	cfg := markdownConfig{bullet: "• "}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&cfg)
+		}
+	}
+	st := &markdownState{cfg: cfg}
+	for _, line := range strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n") {
+		st.line(line)
+	}
+	st.flush()
+	return strings.Join(st.out, "\n"), st.link
+}
+
+// markdownState collects output lines; cur is the line being joined from paragraph text.
+type markdownState struct {
+	cfg   markdownConfig
+	out   []string
+	cur   []string
+	open  bool // cur may take more paragraph text
+	fence string
+	link  string
+}
+
+func (st *markdownState) flush() {
+	if len(st.cur) > 0 {
+		if line := strings.Join(strings.Fields(strings.Join(st.cur, " ")), " "); line != "" {
+			st.out = append(st.out, line)
+		}
+	}
+	st.cur, st.open = nil, false
+}
+
+// start begins a new output line with text, which later paragraph lines may extend.
+func (st *markdownState) start(text string, open bool) {
+	st.flush()
+	st.cur, st.open = []string{text}, open
+}
+
+func (st *markdownState) line(raw string) {
+	trimmed := strings.TrimSpace(raw)
+	if st.fence != "" {
+		if strings.HasPrefix(trimmed, st.fence) && strings.Trim(trimmed, st.fence[:1]) == "" {
+			st.fence = ""
+		} else if code := strings.TrimRight(raw, " \t"); code != "" {
+			st.flush()
+			st.out = append(st.out, code)
+		}
+		return
+	}
+	if fence := fenceMarker(trimmed); fence != "" {
+		st.flush()
+		st.fence = fence
+		return
+	}
+	// Block quotes only lose their markers.
+	for strings.HasPrefix(trimmed, ">") {
+		trimmed = strings.TrimSpace(trimmed[1:])
+	}
+	hardBreak := strings.HasSuffix(raw, "  ") || strings.HasSuffix(trimmed, "\\")
+	trimmed = strings.TrimSuffix(trimmed, "\\")
+
+	switch {
+	case trimmed == "":
+		st.flush()
+	case isThematicBreak(trimmed):
+		st.flush()
+	case st.open && strings.Trim(trimmed, "=") == "":
+		// Setext heading underline: the paragraph above was the heading.
+		st.flush()
+	case headingLevel(trimmed) > 0:
+		text := strings.TrimLeft(trimmed, "#")
+		if t := strings.TrimRight(text, "#"); t == "" || strings.HasSuffix(t, " ") {
+			text = t
+		}
+		st.start(st.inline(text), false)
+	default:
+		if marker, rest, ok := listItem(trimmed); ok {
+			if marker == "" {
+				marker = st.cfg.bullet
+			}
+			st.start(marker+st.inline(rest), true)
+		} else if st.open {
+			st.cur = append(st.cur, st.inline(trimmed))
+		} else {
+			st.start(st.inline(trimmed), true)
+		}
+	}
+	if hardBreak {
+		st.flush()
+	}
+}
+
+// fenceMarker returns the fence (``` or ~~~, three or more) that line opens, if any.
+func fenceMarker(line string) string {
+	for _, c := range []string{"`", "~"} {
+		n := len(line) - len(strings.TrimLeft(line, c))
+		// A backtick fence's info string has no backticks; ```x``` is a code span.
+		if n >= 3 && !(c == "`" && strings.Contains(line[n:], c)) {
+			return strings.Repeat(c, n)
+		}
+	}
+	return ""
+}
+
+// isThematicBreak reports a line of three or more -, *, or _ (spaces allowed).
+func isThematicBreak(line string) bool {
+	c := line[0]
+	if c != '-' && c != '*' && c != '_' {
+		return false
+	}
+	n := 0
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case c:
+			n++
+		case ' ', '\t':
+		default:
+			return false
+		}
+	}
+	return n >= 3
+}
+
+// headingLevel returns 1-6 for an ATX heading ("# Title"), otherwise 0.
+func headingLevel(line string) int {
+	n := len(line) - len(strings.TrimLeft(line, "#"))
+	if n < 1 || n > 6 || (n < len(line) && line[n] != ' ' && line[n] != '\t') {
+		return 0
+	}
+	return n
+}
+
+// listItem splits "- item", "* item", "+ item", "1. item", or "1) item" into its display
+// marker ("" for unordered) and text.
+func listItem(line string) (marker, rest string, ok bool) {
+	if len(line) >= 2 && strings.ContainsRune("-*+", rune(line[0])) && (line[1] == ' ' || line[1] == '\t') {
+		return "", strings.TrimSpace(line[2:]), true
+	}
+	n := 0
+	for n < len(line) && n < 9 && line[n] >= '0' && line[n] <= '9' {
+		n++
+	}
+	if n > 0 && n+1 < len(line) && (line[n] == '.' || line[n] == ')') && (line[n+1] == ' ' || line[n+1] == '\t') {
+		return line[:n] + ". ", strings.TrimSpace(line[n+2:]), true
+	}
+	return "", "", false
+}
+
+// mdToken is a piece of inline text, or a run of emphasis delimiters still to be matched.
+type mdToken struct {
+	text              string
+	delim             byte
+	n                 int
+	canOpen, canClose bool
+}
+
+// inline unwraps the inline markup of one line.
+func (st *markdownState) inline(s string) string {
+	var toks []mdToken
+	var text strings.Builder
+	emit := func() {
+		if text.Len() > 0 {
+			toks = append(toks, mdToken{text: text.String()})
+			text.Reset()
+		}
+	}
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && isASCIIPunct(s[i+1]):
+			text.WriteByte(s[i+1])
+			i += 2
+		case c == '`':
+			n := runLength(s, i, '`')
+			if end := closingBackticks(s, i+n, n); end >= 0 {
+				code := s[i+n : end]
+				if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.TrimSpace(code) != "" {
+					code = code[1 : len(code)-1]
+				}
+				text.WriteString(code)
+				i = end + n
+			} else {
+				text.WriteString(s[i : i+n])
+				i += n
+			}
+		case c == '!' && i+1 < len(s) && s[i+1] == '[':
+			if label, _, end, ok := parseMarkdownLink(s, i+1); ok {
+				text.WriteString(st.inline(label))
+				i = end
+			} else {
+				text.WriteByte(c)
+				i++
+			}
+		case c == '[':
+			if label, url, end, ok := parseMarkdownLink(s, i); ok {
+				text.WriteString(st.inline(label))
+				if st.link == "" {
+					st.link = url
+				}
+				i = end
+			} else {
+				text.WriteByte(c)
+				i++
+			}
+		case c == '<':
+			if url, end, ok := parseAutolink(s, i); ok {
+				text.WriteString(url)
+				if st.link == "" {
+					st.link = url
+				}
+				i = end
+			} else {
+				text.WriteByte(c)
+				i++
+			}
+		case c == '*' || c == '_' || c == '~':
+			n := runLength(s, i, c)
+			if c == '~' && n != 2 {
+				text.WriteString(s[i : i+n])
+				i += n
+				continue
+			}
+			before, _ := utf8.DecodeLastRuneInString(s[:i])
+			after, _ := utf8.DecodeRuneInString(s[i+n:])
+			if i == 0 {
+				before = ' '
+			}
+			if i+n == len(s) {
+				after = ' '
+			}
+			left := !unicode.IsSpace(after)
+			right := !unicode.IsSpace(before)
+			canOpen, canClose := left, right
+			if c == '_' {
+				// No emphasis inside words such as snake_case.
+				canOpen = left && (!right || unicode.IsPunct(before))
+				canClose = right && (!left || unicode.IsPunct(after))
+			}
+			emit()
+			toks = append(toks, mdToken{delim: c, n: n, canOpen: canOpen, canClose: canClose})
+			i += n
+		default:
+			text.WriteByte(c)
+			i++
+		}
+	}
+	emit()
+	matchEmphasis(toks)
+
+	var b strings.Builder
+	for _, t := range toks {
+		if t.delim != 0 {
+			b.WriteString(strings.Repeat(string(t.delim), t.n))
+		} else {
+			b.WriteString(t.text)
+		}
+	}
+	return b.String()
+}
+
+// matchEmphasis pairs closing delimiter runs with the nearest opening run of the same
+// character and removes the matched markers; unmatched ones stay as text.
+func matchEmphasis(toks []mdToken) {
+	for i := range toks {
+		c := &toks[i]
+		if c.delim == 0 || !c.canClose {
+			continue
+		}
+		for j := i - 1; j >= 0 && c.n > 0; j-- {
+			o := &toks[j]
+			if o.delim != c.delim || !o.canOpen || o.n == 0 {
+				continue
+			}
+			k := minInt(o.n, c.n)
+			if c.delim == '~' {
+				k = 2
+			}
+			o.n -= k
+			c.n -= k
+			break
+		}
+	}
+}
+
+func runLength(s string, i int, c byte) int {
+	n := 0
+	for i+n < len(s) && s[i+n] == c {
+		n++
+	}
+	return n
+}
+
+// closingBackticks finds a run of exactly n backticks at or after i.
+func closingBackticks(s string, i, n int) int {
+	for i < len(s) {
+		j := strings.IndexByte(s[i:], '`')
+		if j < 0 {
+			return -1
+		}
+		i += j
+		m := runLength(s, i, '`')
+		if m == n {
+			return i
+		}
+		i += m
+	}
+	return -1
+}
+
+// parseMarkdownLink parses [label](url "title"), [label][ref], or [label][] at s[i] == '['.
+// url is empty for reference links. end is the index after the link.
+func parseMarkdownLink(s string, i int) (label, url string, end int, ok bool) {
+	rb := matchingBracket(s, i, '[', ']')
+	if rb < 0 || rb+1 >= len(s) {
+		return "", "", 0, false
+	}
+	label = s[i+1 : rb]
+	switch s[rb+1] {
+	case '(':
+		paren := matchingBracket(s, rb+1, '(', ')')
+		if paren < 0 {
+			return "", "", 0, false
+		}
+		dest := strings.TrimSpace(s[rb+2 : paren])
+		if strings.HasPrefix(dest, "<") {
+			if gt := strings.IndexByte(dest, '>'); gt > 0 {
+				dest = dest[1:gt]
+			}
+		} else if sp := strings.IndexAny(dest, " \t"); sp >= 0 {
+			dest = dest[:sp]
+		}
+		return label, dest, paren + 1, true
+	case '[':
+		ref := strings.IndexByte(s[rb+2:], ']')
+		if ref < 0 {
+			return "", "", 0, false
+		}
+		return label, "", rb + 2 + ref + 1, true
+	}
+	return "", "", 0, false
+}
+
+// matchingBracket returns the index of the bracket closing the one at s[i], honoring
+// nesting and backslash escapes, or -1.
+func matchingBracket(s string, i int, opening, closing byte) int {
+	depth := 0
+	for j := i; j < len(s); j++ {
+		switch s[j] {
+		case '\\':
+			j++
+		case opening:
+			depth++
+		case closing:
+			depth--
+			if depth == 0 {
+				return j
+			}
+		}
+	}
+	return -1
+}
+
+// parseAutolink parses <scheme:...> at s[i] == '<' for http, https, and mailto URLs.
+func parseAutolink(s string, i int) (url string, end int, ok bool) {
+	gt := strings.IndexByte(s[i:], '>')
+	if gt < 0 {
+		return "", 0, false
+	}
+	url = s[i+1 : i+gt]
+	lower := strings.ToLower(url)
+	if strings.ContainsAny(url, " \t<") ||
+		!(strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://") || strings.HasPrefix(lower, "mailto:")) {
+		return "", 0, false
+	}
+	return url, i + gt + 1, true
+}
+
+func isASCIIPunct(c byte) bool {
+	return c < utf8.RuneSelf && unicode.IsPunct(rune(c)) || strings.IndexByte("$+<=>^`|~", c) >= 0
+}
@@ -0,0 +1,72 @@
+package quote0
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestStripMarkdown(t *testing.T) {
+	tests := []struct {
+		name, in, want, link string
+	}{
+		{"emphasis", "**Disk** is *almost* __full__ and ~~fine~~ _now_", "Disk is almost full and fine now", ""},
+		{"nested emphasis", "***very*** **bold *and* italic**", "very bold and italic", ""},
+		{"intraword underscores", "check snake_case_name and 2 * 3 * 4", "check snake_case_name and 2 * 3 * 4", ""},
+		{"unmatched markers", "**open and *half", "**open and *half", ""},
+		{"code spans", "run `make test` or ``a `tick` b``", "run make test or a `tick` b", ""},
+		{"code keeps markup", "`**not bold**`", "**not bold**", ""},
+		{"links", "See [the runbook](https://wiki/run \"Runbook\") and [status](https://status)", "See the runbook and status", "https://wiki/run"},
+		{"angle link destination", "[docs](<https://x/a b>)", "docs", "https://x/a b"},
+		{"reference link", "[label][ref] and [other][]", "label and other", ""},
+		{"autolink", "Open <https://grafana/d/1> now", "Open https://grafana/d/1 now", "https://grafana/d/1"},
+		{"image", "![CPU graph](https://img/cpu.png) [more](https://m)", "CPU graph more", "https://m"},
+		{"escapes", `\*not emphasis\* and \[x\]`, "*not emphasis* and [x]", ""},
+		{"headings", "# Alert #\n## C#\nbody", "Alert\nC#\nbody", ""},
+		{"setext heading", "Title\n=====\nbody", "Title\nbody", ""},
+		{"lists", "- one\n* two\n+ three\n1. first\n2) second", "• one\n• two\n• three\n1. first\n2. second", ""},
+		{"list continuation", "- a long\n  item\n- next", "• a long item\n• next", ""},
+		{"paragraphs joined", "line one\nline two\n\n\nnext paragraph", "line one line two\nnext paragraph", ""},
+		{"hard break", "first  \nsecond\\\nthird", "first\nsecond\nthird", ""},
+		{"quote and rule", "> quoted **text**\n> > deeper\n---\n* * *\nafter", "quoted text deeper\nafter", ""},
+		{"fenced code", "```sh\n$ make   all\n\n```\n~~~\n*raw*\n~~~", "$ make   all\n*raw*", ""},
+		{"inline triple backticks", "```x``` done", "x done", ""},
+		{"unclosed fence", "```\ncode", "code", ""},
+		{"crlf", "a\r\nb", "a b", ""},
+		{"malformed", "[[unclosed](x ![ `` <http://a b> ~~~x~~~", "[[unclosed](x ![ `` <http://a b> ~~~x~~~", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, link := StripMarkdown(tt.in)
+			if got != tt.want || link != tt.link {
+				t.Fatalf("StripMarkdown(%q) = %q, %q; want %q, %q", tt.in, got, link, tt.want, tt.link)
+			}
+		})
+	}
+	if got, _ := StripMarkdown("- a\n- b", WithMarkdownBullet("* ")); got != "* a\n* b" {
+		t.Fatalf("custom bullet: %q", got)
+	}
+}
+
+func FuzzStripMarkdown(f *testing.F) {
+	for _, s := range []string{
+		"**bold** _it_ `code` [l](u) ![a](i) <https://x>", "# h\n- a\n1. b\n> q\n```\nc\n```",
+		"[[[", "***", "`", "\\", "[a](", "<", "_a_b_", "~~~~", "1.", "- ", "#######",
+	} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		plain, link := StripMarkdown(s)
+		if utf8.ValidString(s) && (!utf8.ValidString(plain) || !utf8.ValidString(link)) {
+			t.Fatalf("invalid UTF-8 from %q: %q %q", s, plain, link)
+		}
+		for _, line := range strings.Split(plain, "\n") {
+			if plain != "" && strings.TrimSpace(line) == "" {
+				t.Fatalf("blank line in %q from %q", plain, s)
+			}
+		}
+		if len(plain) > len(s)+len(strings.Split(s, "\n"))*len("• ") {
+			t.Fatalf("output %d bytes from %d", len(plain), len(s))
+		}
+	})
+}
@@ -0,0 +1,153 @@
+package quote0
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"strings"
+	"time"
+)
+
+// NowPlaying describes the current track for RenderNowPlaying. Only Title is required.
+type NowPlaying struct {
+	Title  string
+	Artist string
+	Album  string
+	// Elapsed and Duration drive the progress bar; it is omitted when Duration is zero.
+	Elapsed  time.Duration
+	Duration time.Duration
+	// Paused draws a pause marker instead of the play marker.
+	Paused bool
+	// Cover is optional album art; it is converted with FitIcon and drawn in the top-right corner.
+	Cover image.Image
+}
+
+// NowPlayingOption configures RenderNowPlaying.
+type NowPlayingOption func(*nowPlayingConfig)
+
+type nowPlayingConfig struct {
+	remaining bool
+}
+
+// WithNowPlayingRemaining shows the remaining time ("-1:05") instead of the track length.
+func WithNowPlayingRemaining(on bool) NowPlayingOption {
+	return func(cfg *nowPlayingConfig) { cfg.remaining = on }
+}
+
+// Now-playing layout metrics, in pixels.
+const (
+	npMargin     = 8
+	npTitleScale = 2
+	npBarY       = 112
+	npBarH       = 10
+	npMarkerW    = 12
+)
+
+// RenderNowPlaying composes a full-screen now-playing card: title, artist, and album (each
+// cut to the available width with "…"), optional cover art in the corner, and a progress
+// bar with a play or pause marker and elapsed/total times.
+func RenderNowPlaying(track NowPlaying, opts ...NowPlayingOption) (image.Image, error) {
+	var cfg nowPlayingConfig
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&cfg)
+		}
+	}
+	title := strings.TrimSpace(track.Title)
+	if title == "" {
+		return nil, ErrTitleMissing
+	}
+
+	c := NewCanvas()
+	textRight := ScreenWidth - npMargin
+	if track.Cover != nil {
+		x := ScreenWidth - npMargin - IconSize
+		c.DrawImage(image.Rect(x, npMargin, x+IconSize, npMargin+IconSize), FitIcon(track.Cover))
+		textRight = x - npMargin
+	}
+	width := textRight - npMargin
+
+	y := npMargin + 2
+	c.DrawText(npMargin, y, FitText(singleLine(title), width, npTitleScale), npTitleScale, Black)
+	y += glyphH*npTitleScale + 8
+	if artist := singleLine(track.Artist); artist != "" {
+		c.DrawText(npMargin, y, FitText(artist, width, npTitleScale), npTitleScale, Black)
+		y += glyphH*npTitleScale + 8
+	}
+	if album := singleLine(track.Album); album != "" {
+		if y+LineHeight > npMargin+IconSize {
+			width = ScreenWidth - 2*npMargin // below the cover the full width is free
+		}
+		c.DrawText(npMargin, y, FitText(album, width, 1), 1, Black)
+	}
+
+	barY := npBarY
+	marker := image.Rect(npMargin, barY-1, npMargin+npMarkerW, barY+npBarH+1)
+	if track.Paused {
+		c.FillRect(image.Rect(marker.Min.X+1, marker.Min.Y, marker.Min.X+5, marker.Max.Y), Black)
+		c.FillRect(image.Rect(marker.Max.X-5, marker.Min.Y, marker.Max.X-1, marker.Max.Y), Black)
+	} else {
+		h := marker.Dy()
+		for i := 0; i < npMarkerW-2; i++ {
+			inset := i * h / (2 * (npMarkerW - 2))
+			c.FillRect(image.Rect(marker.Min.X+1+i, marker.Min.Y+inset, marker.Min.X+2+i, marker.Max.Y-inset), Black)
+		}
+	}
+
+	if track.Duration > 0 {
+		bar := image.Rect(marker.Max.X+6, barY, ScreenWidth-npMargin, barY+npBarH)
+		c.StrokeRect(bar, 1, Black)
+		elapsed := track.Elapsed
+		if elapsed < 0 {
+			elapsed = 0
+		}
+		if elapsed > track.Duration {
+			elapsed = track.Duration
+		}
+		inner := bar.Inset(2)
+		fill := int(int64(inner.Dx()) * int64(elapsed) / int64(track.Duration))
+		c.FillRect(image.Rect(inner.Min.X, inner.Min.Y, inner.Min.X+fill, inner.Max.Y), Black)
+
+		right := formatTrackTime(track.Duration)
+		if cfg.remaining {
+			right = "-" + formatTrackTime(track.Duration-elapsed)
+		}
+		ty := bar.Max.Y + 6
+		c.DrawText(bar.Min.X, ty, formatTrackTime(elapsed), 1, Black)
+		c.DrawText(bar.Max.X-TextWidth(right, 1), ty, right, 1, Black)
+		if track.Paused {
+			label := "PAUSED"
+			c.DrawText(bar.Min.X+(bar.Dx()-TextWidth(label, 1))/2, ty, label, 1, Black)
+		}
+	} else if track.Paused {
+		c.DrawText(marker.Max.X+6, barY+1, "PAUSED", 1, Black)
+	}
+	return c.Image(), nil
+}
+
+// SendNowPlaying renders track and sends it as an image. meta supplies the device, border,
+// and other display options; dithering is disabled unless meta sets it, since the card is
+// already black and white.
+func (c *Client) SendNowPlaying(ctx context.Context, track NowPlaying, meta ImageRequest, opts ...NowPlayingOption) (*APIResponse, error) {
+	img, err := RenderNowPlaying(track, opts...)
+	if err != nil {
+		return nil, err
+	}
+	data, err := (&Canvas{img: img.(*image.Gray)}).PNG()
+	if err != nil {
+		return nil, err
+	}
+	if meta.DitherType == "" {
+		meta.DitherType = DitherNone
+	}
+	return c.SendImageBytes(ctx, data, meta)
+}
+
+// formatTrackTime renders m:ss, or h:mm:ss for tracks of an hour or more.
+func formatTrackTime(d time.Duration) string {
+	s := int(d.Round(time.Second) / time.Second)
+	if s >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", s/3600, s/60%60, s%60)
+	}
+	return fmt.Sprintf("%d:%02d", s/60, s%60)
+}
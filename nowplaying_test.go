@@ -0,0 +1,104 @@
+package quote0
+
+import (
+	"context"
+	"encoding/json"
+	"image"
+	"image/color"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/1set/quote0/quote0test"
+)
+
+// testCover is a 64x48 art fixture: a black disc on a mid-gray background.
+func testCover() image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, 64, 48))
+	for y := 0; y < 48; y++ {
+		for x := 0; x < 64; x++ {
+			c := color.RGBA{0x80, 0x80, 0x80, 0xff}
+			if dx, dy := x-32, y-24; dx*dx+dy*dy < 16*16 {
+				c = color.RGBA{0, 0, 0, 0xff}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestRenderNowPlaying_LongTitle(t *testing.T) {
+	img, err := RenderNowPlaying(NowPlaying{
+		Title:    "Symphony No. 9 in D minor, Op. 125 \"Choral\": IV. Presto",
+		Artist:   "Berliner Philharmoniker & Herbert von Karajan",
+		Album:    "Beethoven: The Complete Symphonies (Remastered 2014 Edition)",
+		Elapsed:  4*time.Minute + 5*time.Second,
+		Duration: 24*time.Minute + 10*time.Second,
+		Cover:    testCover(),
+	}, WithNowPlayingRemaining(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertGolden(t, "nowplaying_long_title", img)
+}
+
+func TestRenderNowPlaying_NoArtPaused(t *testing.T) {
+	img, err := RenderNowPlaying(NowPlaying{
+		Title:    "Intro",
+		Artist:   "The xx",
+		Elapsed:  90 * time.Second,
+		Duration: 128 * time.Second,
+		Paused:   true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertGolden(t, "nowplaying_no_art", img)
+
+	playing, err := RenderNowPlaying(NowPlaying{Title: "Intro", Artist: "The xx", Elapsed: 90 * time.Second, Duration: 128 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if quote0test.CountDiff(img, playing) == 0 {
+		t.Fatal("paused and playing cards must differ")
+	}
+}
+
+func TestRenderNowPlaying_RequiresTitle(t *testing.T) {
+	if _, err := RenderNowPlaying(NowPlaying{Artist: "x"}); err != ErrTitleMissing {
+		t.Fatalf("err=%v", err)
+	}
+}
+
+func TestSendNowPlaying(t *testing.T) {
+	var got ImageRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		_, _ = io.WriteString(w, `{"code":0}`)
+	}))
+	defer srv.Close()
+	c, err := NewClient("t", WithBaseURL(srv.URL), WithRateLimiter(nil), WithDefaultDeviceID("D"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.SendNowPlaying(context.Background(), NowPlaying{Title: "Song"}, ImageRequest{Border: BorderBlack}); err != nil {
+		t.Fatal(err)
+	}
+	if got.Image == "" || got.DitherType != DitherNone || got.Border != BorderBlack {
+		t.Fatalf("sent %+v", got)
+	}
+}
+
+func TestFormatTrackTime(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		0:                                     "0:00",
+		59*time.Second + 600*time.Millisecond: "1:00",
+		61 * time.Minute:                      "1:01:00",
+	} {
+		if got := formatTrackTime(d); got != want {
+			t.Errorf("formatTrackTime(%v)=%q want %q", d, got, want)
+		}
+	}
+}
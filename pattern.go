@@ -0,0 +1,167 @@
+package quote0
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"strings"
+)
+
+// PatternKind selects a TestPattern.
+type PatternKind string
+
+const (
+	// PatternCheckerboard alternates black and white square cells, black at the top-left.
+	PatternCheckerboard PatternKind = "checkerboard"
+	// PatternGradientH ramps from black at the left edge to white at the right.
+	PatternGradientH PatternKind = "gradient-h"
+	// PatternGradientV ramps from black at the top edge to white at the bottom.
+	PatternGradientV PatternKind = "gradient-v"
+	// PatternGrid draws a 1 px border, grid lines spaced from the center, a center crosshair,
+	// and a marker in each corner; the top-left marker is larger so rotation and mirroring
+	// show.
+	PatternGrid PatternKind = "grid"
+	// PatternBlack is an all-black screen.
+	PatternBlack PatternKind = "black"
+	// PatternWhite is an all-white screen.
+	PatternWhite PatternKind = "white"
+)
+
+// ErrInvalidPattern is returned by TestPattern for an unknown kind or cell size.
+var ErrInvalidPattern = errors.New("quote0: invalid test pattern")
+
+// PatternKinds lists every PatternKind, in a stable order.
+func PatternKinds() []PatternKind {
+	return []PatternKind{PatternCheckerboard, PatternGradientH, PatternGradientV, PatternGrid, PatternBlack, PatternWhite}
+}
+
+// MaxPatternCell is the largest cell size accepted by WithPatternCell: half the screen height.
+const MaxPatternCell = ScreenHeight / 2
+
+// Default cell sizes, in pixels. 8 divides both screen sides, so the checkerboard tiles evenly.
+const (
+	defaultCheckerCell = 8
+	defaultGridCell    = 16
+)
+
+// PatternOption configures TestPattern.
+type PatternOption func(*patternConfig)
+
+type patternConfig struct {
+	cell int
+}
+
+// WithPatternCell sets the checkerboard cell size or the grid line spacing, from 1 to
+// MaxPatternCell pixels; 0 keeps the default of 8 and 16. Other patterns ignore it.
+func WithPatternCell(px int) PatternOption {
+	return func(cfg *patternConfig) { cfg.cell = px }
+}
+
+// TestPattern draws a known screen for panel diagnostics: if a pattern sent as an image shows
+// artifacts, they come from the device or server rather than the caller's pipeline. The result
+// is a 296x152 *image.Gray that depends only on kind and opts, so it also serves as a test
+// fixture. An unknown kind or cell size reports ErrInvalidPattern.
+func TestPattern(kind PatternKind, opts ...PatternOption) (image.Image, error) {
+	var cfg patternConfig
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&cfg)
+		}
+	}
+	if cfg.cell < 0 || cfg.cell > MaxPatternCell {
+		return nil, fmt.Errorf("%w: cell size %d, want 1 to %d", ErrInvalidPattern, cfg.cell, MaxPatternCell)
+	}
+	c := NewCanvas()
+	img := c.Image()
+	switch kind {
+	case PatternCheckerboard:
+		cell := cfg.cell
+		if cell == 0 {
+			cell = defaultCheckerCell
+		}
+		for y := 0; y < ScreenHeight; y++ {
+			for x := 0; x < ScreenWidth; x++ {
+				if (x/cell+y/cell)%2 == 0 {
+					img.SetGray(x, y, Black)
+				}
+			}
+		}
+	case PatternGradientH:
+		for y := 0; y < ScreenHeight; y++ {
+			for x := 0; x < ScreenWidth; x++ {
+				img.SetGray(x, y, color.Gray{Y: uint8(x * 255 / (ScreenWidth - 1))})
+			}
+		}
+	case PatternGradientV:
+		for y := 0; y < ScreenHeight; y++ {
+			for x := 0; x < ScreenWidth; x++ {
+				img.SetGray(x, y, color.Gray{Y: uint8(y * 255 / (ScreenHeight - 1))})
+			}
+		}
+	case PatternGrid:
+		cell := cfg.cell
+		if cell == 0 {
+			cell = defaultGridCell
+		}
+		drawGridPattern(c, cell)
+	case PatternBlack:
+		c.FillRect(c.Bounds(), Black)
+	case PatternWhite:
+	default:
+		names := make([]string, 0, len(PatternKinds()))
+		for _, k := range PatternKinds() {
+			names = append(names, string(k))
+		}
+		return nil, fmt.Errorf("%w: unknown kind %q (want %s)", ErrInvalidPattern, kind, strings.Join(names, ", "))
+	}
+	return img, nil
+}
+
+// Grid pattern metrics, in pixels.
+const (
+	gridMarker      = 8
+	gridMarkerLarge = 12
+	gridCrossArm    = 12
+)
+
+// drawGridPattern draws PatternGrid with lines every cell pixels out from the center.
+func drawGridPattern(c *Canvas, cell int) {
+	cx, cy := ScreenWidth/2, ScreenHeight/2
+	for x := cx % cell; x < ScreenWidth; x += cell {
+		c.Line(x, 0, x, ScreenHeight-1, Black)
+	}
+	for y := cy % cell; y < ScreenHeight; y += cell {
+		c.Line(0, y, ScreenWidth-1, y, Black)
+	}
+	c.StrokeRect(c.Bounds(), 1, Black)
+
+	// A 3 px crosshair in a white square, so it stands out from the grid lines.
+	c.FillRect(image.Rect(cx-gridCrossArm-2, cy-gridCrossArm-2, cx+gridCrossArm+3, cy+gridCrossArm+3), White)
+	c.FillRect(image.Rect(cx-gridCrossArm, cy-1, cx+gridCrossArm+1, cy+2), Black)
+	c.FillRect(image.Rect(cx-1, cy-gridCrossArm, cx+2, cy+gridCrossArm+1), Black)
+
+	c.FillRect(image.Rect(0, 0, gridMarkerLarge, gridMarkerLarge), Black)
+	c.FillRect(image.Rect(ScreenWidth-gridMarker, 0, ScreenWidth, gridMarker), Black)
+	c.FillRect(image.Rect(0, ScreenHeight-gridMarker, gridMarker, ScreenHeight), Black)
+	c.FillRect(image.Rect(ScreenWidth-gridMarker, ScreenHeight-gridMarker, ScreenWidth, ScreenHeight), Black)
+}
+
+// SendTestPattern renders a TestPattern and sends it as an image. meta supplies the device,
+// border, and other display options. Dithering is disabled for the black-and-white patterns
+// unless meta sets it; the gradients keep the server default so its dithering can be judged.
+func (c *Client) SendTestPattern(ctx context.Context, kind PatternKind, meta ImageRequest, opts ...PatternOption) (*APIResponse, error) {
+	img, err := TestPattern(kind, opts...)
+	if err != nil {
+		return nil, err
+	}
+	data, err := (&Canvas{img: img.(*image.Gray)}).PNG()
+	if err != nil {
+		return nil, err
+	}
+	if meta.DitherType == "" && kind != PatternGradientH && kind != PatternGradientV {
+		meta.DitherType = DitherNone
+	}
+	return c.SendImageBytes(ctx, data, meta)
+}
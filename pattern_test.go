@@ -0,0 +1,110 @@
+package quote0
+
+import (
+	"context"
+	"errors"
+	"image"
+	"testing"
+)
+
+func mustPattern(t *testing.T, kind PatternKind, opts ...PatternOption) *image.Gray {
+	t.Helper()
+	img, err := TestPattern(kind, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return img.(*image.Gray)
+}
+
+func TestTestPattern_Golden(t *testing.T) {
+	assertGolden(t, "pattern_grid", mustPattern(t, PatternGrid))
+	assertGolden(t, "pattern_checkerboard_4", mustPattern(t, PatternCheckerboard, WithPatternCell(4)))
+}
+
+func TestTestPattern_Kinds(t *testing.T) {
+	for _, kind := range PatternKinds() {
+		a, b := mustPattern(t, kind), mustPattern(t, kind)
+		if a.Rect != image.Rect(0, 0, ScreenWidth, ScreenHeight) || string(a.Pix) != string(b.Pix) {
+			t.Fatalf("%s: %v, not deterministic", kind, a.Rect)
+		}
+	}
+	h, v := mustPattern(t, PatternGradientH), mustPattern(t, PatternGradientV)
+	if h.GrayAt(0, 9).Y != 0 || h.GrayAt(ScreenWidth-1, 9).Y != 255 || v.GrayAt(9, 0).Y != 0 || v.GrayAt(9, ScreenHeight-1).Y != 255 {
+		t.Fatal("gradients should run from black to white")
+	}
+	if mustPattern(t, PatternBlack).GrayAt(100, 100) != Black || mustPattern(t, PatternWhite).GrayAt(100, 100) != White {
+		t.Fatal("black and white screens")
+	}
+	checker := mustPattern(t, PatternCheckerboard)
+	if checker.GrayAt(0, 0) != Black || checker.GrayAt(8, 0) != White || checker.GrayAt(8, 8) != Black {
+		t.Fatal("checkerboard cells")
+	}
+
+	for _, opt := range []PatternOption{WithPatternCell(-1), WithPatternCell(MaxPatternCell + 1)} {
+		if _, err := TestPattern(PatternGrid, opt); !errors.Is(err, ErrInvalidPattern) || !IsValidationError(err) {
+			t.Fatalf("cell: %v", err)
+		}
+	}
+	if _, err := TestPattern("zigzag"); !errors.Is(err, ErrInvalidPattern) {
+		t.Fatalf("kind: %v", err)
+	}
+}
+
+func TestTestPattern_DitherFixtures(t *testing.T) {
+	// Black-and-white patterns come through every dither mode unchanged.
+	for _, kind := range []PatternKind{PatternCheckerboard, PatternGrid, PatternBlack, PatternWhite} {
+		src := mustPattern(t, kind, WithPatternCell(1))
+		for _, k := range DitherKernels() {
+			out, err := Dither(src, DitherDiffusion, k)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(out.Pix) != string(src.Pix) {
+				t.Fatalf("%s changed by %s", kind, k)
+			}
+		}
+	}
+	// A dithered gradient gets darker towards its black end.
+	out, err := Dither(mustPattern(t, PatternGradientH), DitherOrdered, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	black := func(x0, x1 int) (n int) {
+		for y := 0; y < ScreenHeight; y++ {
+			for x := x0; x < x1; x++ {
+				if out.GrayAt(x, y) == Black {
+					n++
+				}
+			}
+		}
+		return n
+	}
+	if l, m, r := black(0, 74), black(111, 185), black(222, 296); !(l > m && m > r) {
+		t.Fatalf("black pixels by third %d, %d, %d", l, m, r)
+	}
+}
+
+func TestClient_SendTestPattern(t *testing.T) {
+	s := &stagedServer{}
+	c := newStagedClient(t, s)
+	ctx := context.Background()
+	if _, err := c.SendTestPattern(ctx, PatternGrid, ImageRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.SendTestPattern(ctx, PatternGradientV, ImageRequest{Border: BorderBlack}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.SendTestPattern(ctx, "zigzag", ImageRequest{}); !errors.Is(err, ErrInvalidPattern) {
+		t.Fatalf("unknown kind: %v", err)
+	}
+	if len(s.got) != 2 || s.got[0]["ditherType"] != string(DitherNone) || s.got[1]["ditherType"] != nil || s.got[1]["border"] == nil {
+		t.Fatalf("bodies %v", s.got)
+	}
+	img, err := PreviewImage(ImageRequest{Image: s.got[0]["image"].(string)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(img.Pix) != string(mustPattern(t, PatternGrid).Pix) {
+		t.Fatal("sent grid differs from TestPattern")
+	}
+}
@@ -0,0 +1,120 @@
+package quote0
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultRateLimitPenalty is how long the limiter holds off after a rate-limited response
+	// without a Retry-After header (see WithRateLimitPenalty).
+	DefaultRateLimitPenalty = 5 * time.Second
+	// MaxRetryAfter caps the cooldown taken from a Retry-After header.
+	MaxRetryAfter = 10 * time.Minute
+)
+
+// rateLimitedBody is what the service writes, in Chinese ("request rate too high"), when it
+// throttles a caller.
+const rateLimitedBody = "频率过高"
+
+// PenalizableLimiter is implemented by limiters that can be told to hold off. After a
+// rate-limited response the client calls Penalize with the server's Retry-After, or the
+// WithRateLimitPenalty default, so callers queue behind the cooldown instead of hitting 429
+// again. FixedIntervalLimiter and FairLimiter implement it.
+type PenalizableLimiter interface {
+	Penalize(d time.Duration)
+}
+
+// Penalize delays the next slot until at least d from now; a later slot is kept. Calls
+// already waiting keep their turns. Non-positive d is ignored.
+func (l *FixedIntervalLimiter) Penalize(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	until := l.clock().Add(d)
+	if until.After(l.next) {
+		l.next = until
+	}
+	if until.After(l.floor) {
+		l.floor = until
+	}
+}
+
+// Penalize passes the cooldown on to the inner limiter when it implements PenalizableLimiter.
+func (l *FairLimiter) Penalize(d time.Duration) {
+	if p, ok := l.inner.(PenalizableLimiter); ok {
+		p.Penalize(d)
+	}
+}
+
+// WithRateLimitPenalty sets the cooldown applied to a PenalizableLimiter after a
+// rate-limited response (status 429, or the service's "频率过高" body) that has no usable
+// Retry-After header; the default is DefaultRateLimitPenalty and 0 applies none. A
+// Retry-After in seconds or as an HTTP date always wins, up to MaxRetryAfter. A negative d
+// makes NewClient return an error.
+func WithRateLimitPenalty(d time.Duration) ClientOption {
+	return func(c *Client) {
+		if d < 0 {
+			c.optionError(fmt.Errorf("quote0: rate limit penalty must not be negative, got %v", d))
+			return
+		}
+		c.penalty = d
+		c.penaltySet = true
+	}
+}
+
+// isRateLimited reports whether a failed response throttles the caller.
+func isRateLimited(status int, body []byte) bool {
+	return status == http.StatusTooManyRequests || strings.Contains(string(body), rateLimitedBody)
+}
+
+// penalize feeds a rate-limited response's cooldown to the limiter and returns it.
+func (c *Client) penalize(header http.Header, now time.Time) time.Duration {
+	d, ok := parseRetryAfter(header.Get("Retry-After"), now)
+	if !ok {
+		d = DefaultRateLimitPenalty
+		if c.penaltySet {
+			d = c.penalty
+		}
+	}
+	if p, ok := c.limiter.(PenalizableLimiter); ok && d > 0 {
+		p.Penalize(d)
+	}
+	return d
+}
+
+// parseRetryAfter reads a Retry-After value in delay seconds or as an HTTP date relative to
+// now, capped at MaxRetryAfter; ok is false when v is empty or malformed.
+func parseRetryAfter(v string, now time.Time) (d time.Duration, ok bool) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.ParseInt(v, 10, 64); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		d = time.Duration(secs) * time.Second
+		if secs > int64(MaxRetryAfter/time.Second) {
+			d = MaxRetryAfter
+		}
+		return d, true
+	}
+	t, err := http.ParseTime(v)
+	if err != nil {
+		return 0, false
+	}
+	d = t.Sub(now)
+	if d < 0 {
+		d = 0
+	}
+	if d > MaxRetryAfter {
+		d = MaxRetryAfter
+	}
+	return d, true
+}
@@ -0,0 +1,124 @@
+package quote0
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// penaltyRecorder is a limiter that never waits and records Penalize calls.
+type penaltyRecorder struct{ got []time.Duration }
+
+func (p *penaltyRecorder) Wait(context.Context) error { return nil }
+func (p *penaltyRecorder) Penalize(d time.Duration)   { p.got = append(p.got, d) }
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 11, 10, 9, 30, 0, 0, time.UTC)
+	for _, tt := range []struct {
+		in   string
+		want time.Duration
+		ok   bool
+	}{
+		{"3", 3 * time.Second, true},
+		{" 0 ", 0, true},
+		{"86400", MaxRetryAfter, true},
+		{now.Add(30 * time.Second).Format(http.TimeFormat), 30 * time.Second, true},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0, true},
+		{now.Add(time.Hour).Format(http.TimeFormat), MaxRetryAfter, true},
+		{"", 0, false},
+		{"-5", 0, false},
+		{"soon", 0, false},
+	} {
+		if d, ok := parseRetryAfter(tt.in, now); d != tt.want || ok != tt.ok {
+			t.Errorf("parseRetryAfter(%q) = %v, %v; want %v, %v", tt.in, d, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestFixedIntervalLimiter_Penalize(t *testing.T) {
+	l, clock := newFakeLimiter(time.Second)
+	ctx := context.Background()
+	if err := l.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+	l.Penalize(5 * time.Second)
+	l.Penalize(2 * time.Second) // an earlier cooldown does not shorten the later one
+	done := waitAsync(ctx, l)
+	s := clock.nextSleep(t)
+	if s.d != 5*time.Second {
+		t.Fatalf("slept %v, want 5s", s.d)
+	}
+	clock.advance(s.d)
+	s.fire <- time.Time{}
+	expectDone(t, done, nil)
+
+	// The interval follows the cooldown.
+	done = waitAsync(ctx, l)
+	if s := clock.nextSleep(t); s.d != time.Second {
+		t.Fatalf("slept %v after the cooldown, want 1s", s.d)
+	} else {
+		s.fire <- time.Time{}
+	}
+	expectDone(t, done, nil)
+
+	// FairLimiter forwards to its inner limiter.
+	inner := &penaltyRecorder{}
+	NewFairLimiter(inner).Penalize(time.Minute)
+	if len(inner.got) != 1 || inner.got[0] != time.Minute {
+		t.Fatalf("FairLimiter forwarded %v", inner.got)
+	}
+}
+
+func TestClient_PenalizesLimiterOnRateLimit(t *testing.T) {
+	var status int
+	var retryAfter, body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if retryAfter != "" {
+			w.Header().Set("Retry-After", retryAfter)
+		}
+		w.WriteHeader(status)
+		_, _ = io.WriteString(w, body)
+	}))
+	defer srv.Close()
+
+	for _, tt := range []struct {
+		name             string
+		status           int
+		retryAfter, body string
+		opts             []ClientOption
+		want             []time.Duration
+	}{
+		{"retry-after seconds", 429, "7", "slow down", nil, []time.Duration{7 * time.Second}},
+		{"no header", 429, "", "", nil, []time.Duration{DefaultRateLimitPenalty}},
+		{"chinese body", 503, "", "频率过高，请稍后再试", nil, []time.Duration{DefaultRateLimitPenalty}},
+		{"custom default", 429, "", "", []ClientOption{WithRateLimitPenalty(2 * time.Second)}, []time.Duration{2 * time.Second}},
+		{"penalty off", 429, "", "", []ClientOption{WithRateLimitPenalty(0)}, nil},
+		{"header beats off", 429, "1", "", []ClientOption{WithRateLimitPenalty(0)}, []time.Duration{time.Second}},
+		{"other errors", 500, "9", "boom", nil, nil},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			status, retryAfter, body = tt.status, tt.retryAfter, tt.body
+			rec := &penaltyRecorder{}
+			opts := append([]ClientOption{WithBaseURL(srv.URL), WithRateLimiter(rec), WithDefaultDeviceID("D")}, tt.opts...)
+			c, err := NewClient("test", opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = c.SendText(context.Background(), TextRequest{Title: "t"})
+			var ae *APIError
+			if !errors.As(err, &ae) {
+				t.Fatalf("err %v", err)
+			}
+			if len(rec.got) != len(tt.want) || len(tt.want) > 0 && (rec.got[0] != tt.want[0] || ae.RetryAfter != tt.want[0]) {
+				t.Fatalf("penalties %v, RetryAfter %v; want %v", rec.got, ae.RetryAfter, tt.want)
+			}
+		})
+	}
+	if _, err := NewClient("test", WithRateLimitPenalty(-time.Second)); err == nil {
+		t.Fatal("negative penalty accepted")
+	}
+}
@@ -0,0 +1,146 @@
+package quote0
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"image/png"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// previewEntry is the JSON shape served for a device's last sent content.
+type previewEntry struct {
+	DeviceID string    `json:"deviceId"`
+	Kind     string    `json:"kind"`
+	SentAt   time.Time `json:"sentAt"`
+	// Request is the payload as sent, with the base64 image replaced by ImageBytes.
+	Request    interface{} `json:"request,omitempty"`
+	ImageBytes int         `json:"imageBytes,omitempty"`
+}
+
+type previewHandler struct {
+	client *Client
+}
+
+// NewPreviewHandler returns an http.Handler that shows what each device currently displays,
+// based on the content last sent successfully through client. Mount it under a prefix with
+// http.StripPrefix (for example "/preview/").
+//
+// Routes:
+//   - GET /            JSON index of known devices and their last send time
+//   - GET /{id}.json   metadata of the last request for the device
+//   - GET /{id}.png    the last image sent to the device, or its last text request
+//     rendered with PreviewText
+//
+// A rendered text request is an approximation: the device lays out text with its own font.
+// Devices without history answer 404.
+func NewPreviewHandler(client *Client) http.Handler {
+	return &previewHandler{client: client}
+}
+
+func (h *previewHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	path := strings.TrimPrefix(r.URL.Path, "/")
+	switch {
+	case path == "":
+		h.serveIndex(w)
+	case strings.HasSuffix(path, ".json"):
+		h.serveJSON(w, strings.TrimSuffix(path, ".json"))
+	case strings.HasSuffix(path, ".png"):
+		h.servePNG(w, strings.TrimSuffix(path, ".png"))
+	default:
+		http.Error(w, "not found; use /, /{deviceId}.json, or /{deviceId}.png", http.StatusNotFound)
+	}
+}
+
+func (h *previewHandler) serveIndex(w http.ResponseWriter) {
+	entries := make([]previewEntry, 0)
+	for _, id := range h.client.SentDevices() {
+		if rec, ok := h.client.LastSent(id); ok {
+			entries = append(entries, previewEntry{DeviceID: id, Kind: rec.Kind(), SentAt: rec.SentAt})
+		}
+	}
+	writePreviewJSON(w, map[string]interface{}{"devices": entries})
+}
+
+func (h *previewHandler) serveJSON(w http.ResponseWriter, deviceID string) {
+	rec, ok := h.lookup(w, deviceID)
+	if !ok {
+		return
+	}
+	entry := previewEntry{DeviceID: rec.DeviceID, Kind: rec.Kind(), SentAt: rec.SentAt}
+	if rec.Image != nil {
+		if data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(rec.Image.Image)); err == nil {
+			entry.ImageBytes = len(data)
+		}
+		rec.Image.Image = ""
+		entry.Request = rec.Image
+	} else {
+		entry.Request = rec.Text
+	}
+	writePreviewJSON(w, entry)
+}
+
+func (h *previewHandler) servePNG(w http.ResponseWriter, deviceID string) {
+	rec, ok := h.lookup(w, deviceID)
+	if !ok {
+		return
+	}
+	if rec.Image == nil {
+		h.serveTextPNG(w, rec)
+		return
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(rec.Image.Image))
+	if err == nil {
+		_, err = png.DecodeConfig(bytes.NewReader(data))
+	}
+	if err != nil {
+		http.Error(w, "last image for device "+deviceID+" is not a valid PNG: "+err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Last-Modified", rec.SentAt.UTC().Format(http.TimeFormat))
+	_, _ = w.Write(data)
+}
+
+// serveTextPNG renders the record's text request with PreviewText.
+func (h *previewHandler) serveTextPNG(w http.ResponseWriter, rec SentRecord) {
+	img, err := PreviewText(*rec.Text)
+	if err != nil {
+		http.Error(w, "last text request for device "+rec.DeviceID+" cannot be rendered: "+err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Last-Modified", rec.SentAt.UTC().Format(http.TimeFormat))
+	_, _ = w.Write(buf.Bytes())
+}
+
+// lookup fetches the record for deviceID or writes a 404 naming the known devices.
+func (h *previewHandler) lookup(w http.ResponseWriter, deviceID string) (SentRecord, bool) {
+	rec, ok := h.client.LastSent(deviceID)
+	if ok {
+		return rec, true
+	}
+	msg := "no content has been sent to device " + deviceID + " through this client yet"
+	if known := h.client.SentDevices(); len(known) > 0 {
+		msg += "; known devices: " + strings.Join(known, ", ")
+	}
+	http.Error(w, msg, http.StatusNotFound)
+	return SentRecord{}, false
+}
+
+func writePreviewJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_ = json.NewEncoder(w).Encode(v)
+}
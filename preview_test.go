@@ -0,0 +1,146 @@
+package quote0
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/1set/quote0/quote0test"
+)
+
+func encodeTestPNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, w, h))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestPreviewHandler(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"code":0}`)
+	}))
+	defer api.Close()
+	c, err := NewClient("test", WithBaseURL(api.URL), WithRateLimiter(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pngData := encodeTestPNG(t, 296, 152)
+	if _, err := c.SendImageBytes(context.Background(), pngData, ImageRequest{DeviceID: "IMG", Link: "https://x"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.SendText(context.Background(), TextRequest{DeviceID: "TXT", Title: "hello"}); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(http.StripPrefix("/preview", NewPreviewHandler(c)))
+	defer srv.Close()
+	get := func(path string) (int, string, []byte) {
+		resp, err := http.Get(srv.URL + "/preview" + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, resp.Header.Get("Content-Type"), body
+	}
+
+	status, _, body := get("/")
+	var index struct {
+		Devices []previewEntry `json:"devices"`
+	}
+	if err := json.Unmarshal(body, &index); err != nil || status != http.StatusOK {
+		t.Fatalf("index status=%d err=%v body=%s", status, err, body)
+	}
+	if len(index.Devices) != 2 || index.Devices[0].DeviceID != "IMG" || index.Devices[1].Kind != "text" {
+		t.Fatalf("index=%+v", index.Devices)
+	}
+
+	status, ct, body := get("/IMG.png")
+	if status != http.StatusOK || ct != "image/png" || !bytes.Equal(body, pngData) {
+		t.Fatalf("png status=%d ct=%s len=%d", status, ct, len(body))
+	}
+
+	status, _, body = get("/IMG.json")
+	if status != http.StatusOK || !strings.Contains(string(body), `"link":"https://x"`) || strings.Contains(string(body), encodeBase64(pngData)) {
+		t.Fatalf("json status=%d body=%s", status, body)
+	}
+
+	status, _, body = get("/TXT.json")
+	if status != http.StatusOK || !strings.Contains(string(body), `"title":"hello"`) {
+		t.Fatalf("text json status=%d body=%s", status, body)
+	}
+	status, ct, body = get("/TXT.png")
+	if status != http.StatusOK || ct != "image/png" {
+		t.Fatalf("text png status=%d ct=%s body=%s", status, ct, body)
+	}
+	want, _ := PreviewText(TextRequest{DeviceID: "TXT", Title: "hello"})
+	got, err := png.Decode(bytes.NewReader(body))
+	if err != nil || got.Bounds() != want.Bounds() || quote0test.CountDiff(got, want) != 0 {
+		t.Fatalf("text png does not match PreviewText: %v", err)
+	}
+
+	status, _, body = get("/NOPE.png")
+	if status != http.StatusNotFound || !strings.Contains(string(body), "known devices: IMG, TXT") {
+		t.Fatalf("unknown status=%d body=%s", status, body)
+	}
+}
+
+func TestPreviewHandler_ConcurrentWithSends(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "ok")
+	}))
+	defer api.Close()
+	c, err := NewClient("test", WithBaseURL(api.URL), WithRateLimiter(nil), WithDefaultDeviceID("D"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := NewPreviewHandler(c)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, _ = c.SendText(context.Background(), TextRequest{Message: "m"})
+		}()
+		go func() {
+			defer wg.Done()
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/D.json", nil))
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		}()
+	}
+	wg.Wait()
+}
+
+func TestLastSent_DeepCopy(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"code":0}`)
+	}))
+	defer api.Close()
+	c, err := NewClient("test", WithBaseURL(api.URL), WithRateLimiter(nil), WithDefaultDeviceID("D"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	refresh := true
+	if _, err := c.SendText(context.Background(), TextRequest{Title: "t", RefreshNow: &refresh}); err != nil {
+		t.Fatal(err)
+	}
+	refresh = false // the caller reuses its flag
+
+	rec, _ := c.LastSent("D")
+	*rec.Text.RefreshNow = false
+	rec.Text.Title = "changed"
+	if again, _ := c.LastSent("D"); !*again.Text.RefreshNow || again.Text.Title != "t" {
+		t.Fatalf("stored record changed: %+v", again.Text)
+	}
+}
@@ -0,0 +1,435 @@
+package quote0
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/jpeg" // ProcessImage inputs may be JPEG
+	"math"
+	"net/http"
+	"strings"
+)
+
+// FitMode selects how ProcessImage maps an image of any size onto the screen.
+type FitMode string
+
+const (
+	// FitNone requires the image to already be 296x152 (default).
+	FitNone FitMode = ""
+	// FitContain scales the whole image to fit inside the screen and pads the rest with the
+	// background color.
+	FitContain FitMode = "contain"
+	// FitCover scales the image to fill the screen and crops the overflow around the centre.
+	FitCover FitMode = "cover"
+	// FitStretch scales each axis independently to the screen size, ignoring aspect ratio.
+	FitStretch FitMode = "stretch"
+)
+
+// MinFitSize is the smallest width and height ProcessImage will scale up.
+const MinFitSize = 8
+
+// MaxSafeMargin is the largest WithSafeMargin, which leaves a 2-pixel-high drawable strip.
+const MaxSafeMargin = ScreenHeight/2 - 1
+
+var (
+	// ErrUnsupportedFormat is returned by DecodeImage for data that is not PNG or JPEG.
+	ErrUnsupportedFormat = errors.New("quote0: unsupported image format (want PNG or JPEG)")
+	// ErrImageTooSmall is returned by ProcessImage for images below MinFitSize.
+	ErrImageTooSmall = errors.New("quote0: image is too small to fit")
+	// ErrInvalidAdjustment is returned by ProcessImage for out-of-range tone adjustments.
+	ErrInvalidAdjustment = errors.New("quote0: invalid image adjustment")
+	// ErrImageFetch is returned by FetchImage when the image cannot be downloaded.
+	ErrImageFetch = errors.New("quote0: could not fetch image")
+)
+
+// MaxImageDownload caps FetchImage responses; photos for ProcessImage are rarely larger.
+const MaxImageDownload = 16 << 20
+
+// ProcessOption configures ProcessImage.
+type ProcessOption func(*processConfig)
+
+type processConfig struct {
+	fit        FitMode
+	background color.Gray
+	rotation   int
+	contrast   float64
+	gamma      float64
+	sharpen    float64
+	invert     bool
+	threshold  uint8
+	margin     int
+	resample   ResampleFilter
+	matte      color.Gray
+	matteSet   bool
+	dither     DitherType
+	kernel     DitherKernel
+	ditherOpts []DitherOption
+}
+
+func newProcessConfig(opts []ProcessOption) processConfig {
+	cfg := processConfig{background: White, contrast: 1, gamma: 1}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&cfg)
+		}
+	}
+	return cfg
+}
+
+// WithFit selects the FitMode used to reach the screen size.
+func WithFit(mode FitMode) ProcessOption {
+	return func(cfg *processConfig) { cfg.fit = mode }
+}
+
+// WithBackground sets the padding color for FitContain (default White).
+func WithBackground(col color.Gray) ProcessOption {
+	return func(cfg *processConfig) { cfg.background = col }
+}
+
+// WithSafeMargin insets the drawable area by px pixels on every side, for units whose bezel
+// clips the outermost pixels: the image is fitted into the inset area and the band around it
+// is filled with the background color (see WithBackground; BorderColor.Gray matches the
+// request's Border). Without a fit mode the full-screen image is shrunk to fit inside the band.
+// The result is still 296x152. Margins that are negative or reach half the screen height are
+// invalid.
+func WithSafeMargin(px int) ProcessOption {
+	return func(cfg *processConfig) { cfg.margin = px }
+}
+
+// WithRotation rotates the source clockwise by degrees (a multiple of 90) before it is
+// fitted, for example to show a portrait photo on its side.
+func WithRotation(degrees int) ProcessOption {
+	return func(cfg *processConfig) { cfg.rotation = degrees }
+}
+
+// WithContrast scales gray levels away from (factor > 1) or towards (factor < 1) mid-gray;
+// 1 leaves them unchanged and negative factors are invalid.
+func WithContrast(factor float64) ProcessOption {
+	return func(cfg *processConfig) { cfg.contrast = factor }
+}
+
+// WithGamma applies a gamma curve: values above 1 brighten the mid-tones, below 1 darken
+// them. It must be positive; 1 leaves the image unchanged.
+func WithGamma(gamma float64) ProcessOption {
+	return func(cfg *processConfig) { cfg.gamma = gamma }
+}
+
+// WithSharpen adds amount times the difference from a 3x3 blur (an unsharp mask), which
+// keeps thin lines and text crisp after dithering. 0 disables it; negative is invalid. It
+// runs after scaling, so it also restores edges softened by WithResample.
+func WithSharpen(amount float64) ProcessOption {
+	return func(cfg *processConfig) { cfg.sharpen = amount }
+}
+
+// WithResample selects the filter used by the fit step (case-insensitive); ResampleAuto, the
+// default, uses ResampleBox. Box and bilinear downscales soften edges, which a light
+// WithSharpen (about 0.5) brings back before dithering; ResampleNearest keeps edges hard but
+// can drop thin lines, and sharpening a nearest upscale only exaggerates its steps. Unknown
+// filters are invalid.
+func WithResample(f ResampleFilter) ProcessOption {
+	return func(cfg *processConfig) { cfg.resample = normalizeResample(f) }
+}
+
+// WithInvert swaps black and white.
+func WithInvert() ProcessOption {
+	return func(cfg *processConfig) { cfg.invert = true }
+}
+
+// WithThreshold turns pixels darker than level black and the rest white, leaving nothing for
+// the server to dither; 0 disables it.
+func WithThreshold(level uint8) ProcessOption {
+	return func(cfg *processConfig) { cfg.threshold = level }
+}
+
+// WithLocalDither dithers the result to black and white with Dither as the last step, for
+// settings the server does not offer: kernels added with RegisterKernel, or WithSerpentine.
+// Send the result with DitherNone so the server leaves it as is.
+func WithLocalDither(t DitherType, k DitherKernel, opts ...DitherOption) ProcessOption {
+	return func(cfg *processConfig) {
+		cfg.dither, cfg.kernel = DitherType(strings.ToUpper(strings.TrimSpace(string(t)))), k
+		if cfg.dither == "" {
+			cfg.dither = DitherDiffusion
+		}
+		cfg.ditherOpts = opts
+	}
+}
+
+// DecodeImage decodes PNG or JPEG data and returns the image with its format name. Other
+// formats report ErrUnsupportedFormat naming the detected format when it is recognizable.
+func DecodeImage(data []byte) (image.Image, string, error) {
+	if f := sniffFormat(data); f != "png" && f != "jpeg" {
+		if f == "" {
+			return nil, "", ErrUnsupportedFormat
+		}
+		return nil, f, fmt.Errorf("%w: got %s", ErrUnsupportedFormat, f)
+	}
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, format, fmt.Errorf("%w: %s: %v", ErrInvalidImage, format, err)
+	}
+	return img, format, nil
+}
+
+// FetchImage downloads a PNG or JPEG from url for ImageRequest.ImageBytes or ProcessImage,
+// using hc (nil means http.DefaultClient) and honoring ctx. Download failures, non-2xx
+// statuses, and responses over MaxImageDownload report ErrImageFetch; other content reports
+// ErrUnsupportedFormat. The size is not checked, as the image may still be fitted.
+func FetchImage(ctx context.Context, hc *http.Client, url string) ([]byte, error) {
+	data, err := download(ctx, hc, url, MaxImageDownload, ErrImageFetch)
+	if err != nil {
+		return nil, err
+	}
+	if f := sniffFormat(data); f != "png" && f != "jpeg" {
+		if f == "" {
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, url)
+		}
+		return nil, fmt.Errorf("%w: %s is %s", ErrUnsupportedFormat, url, f)
+	}
+	return data, nil
+}
+
+// sniffFormat names common image formats by their magic bytes.
+func sniffFormat(data []byte) string {
+	for _, m := range []struct{ magic, name string }{
+		{"\x89PNG\r\n\x1a\n", "png"},
+		{"\xff\xd8\xff", "jpeg"},
+		{"GIF87a", "gif"},
+		{"GIF89a", "gif"},
+		{"BM", "bmp"},
+		{"II*\x00", "tiff"},
+		{"MM\x00*", "tiff"},
+	} {
+		if bytes.HasPrefix(data, []byte(m.magic)) {
+			return m.name
+		}
+	}
+	if len(data) >= 12 && string(data[:4]) == "RIFF" && string(data[8:12]) == "WEBP" {
+		return "webp"
+	}
+	return ""
+}
+
+// ProcessImage converts src into a grayscale 296x152 image ready to send: it is scaled with
+// area averaging (see WithResample) according to WithFit. Without a fit mode the image must already match the
+// screen, otherwise ErrImageSize is returned.
+//
+// The steps run in a fixed order, whatever the order of opts: alpha flattening (see
+// WithAlphaBackground), rotation, fit, contrast, gamma, sharpen, invert, threshold, local
+// dither.
+func ProcessImage(src image.Image, opts ...ProcessOption) (*image.Gray, error) {
+	cfg := newProcessConfig(opts)
+	if err := cfg.check(); err != nil {
+		return nil, err
+	}
+	matte := cfg.background
+	if cfg.matteSet {
+		matte = cfg.matte
+	}
+	src = flattenAlpha(src, matte)
+	switch ((cfg.rotation % 360) + 360) % 360 {
+	case 0:
+	case 90:
+		src = rotate(src, 1)
+	case 180:
+		src = rotate(src, 2)
+	case 270:
+		src = rotate(src, 3)
+	default:
+		return nil, fmt.Errorf("quote0: rotation must be a multiple of 90 degrees, got %d", cfg.rotation)
+	}
+	b := src.Bounds()
+	if cfg.fit == FitNone {
+		if b.Dx() != ScreenWidth || b.Dy() != ScreenHeight {
+			return nil, fmt.Errorf("%w: got %dx%d (use a fit mode to resize)", ErrImageSize, b.Dx(), b.Dy())
+		}
+	} else if b.Dx() < MinFitSize || b.Dy() < MinFitSize {
+		return nil, fmt.Errorf("%w: got %dx%d, need at least %dx%d", ErrImageTooSmall, b.Dx(), b.Dy(), MinFitSize, MinFitSize)
+	}
+
+	c := NewCanvas()
+	area := c.Bounds().Inset(cfg.margin)
+	if cfg.margin > 0 {
+		c.FillRect(c.Bounds(), cfg.background)
+	}
+	switch cfg.fit {
+	case FitNone:
+		if cfg.margin > 0 {
+			scaleInto(c, containRect(b.Size(), area), src, b, cfg.resample)
+		} else {
+			scaleInto(c, area, src, b, cfg.resample)
+		}
+	case FitStretch:
+		scaleInto(c, area, src, b, cfg.resample)
+	case FitContain:
+		c.FillRect(area, cfg.background)
+		scaleInto(c, containRect(b.Size(), area), src, b, cfg.resample)
+	case FitCover:
+		// Crop the source to the drawable area's aspect ratio, centred, then scale.
+		crop := b
+		if b.Dx()*area.Dy() > b.Dy()*area.Dx() {
+			w := b.Dy() * area.Dx() / area.Dy()
+			crop.Min.X += (b.Dx() - w) / 2
+			crop.Max.X = crop.Min.X + w
+		} else {
+			h := b.Dx() * area.Dy() / area.Dx()
+			crop.Min.Y += (b.Dy() - h) / 2
+			crop.Max.Y = crop.Min.Y + h
+		}
+		scaleInto(c, area, src, crop, cfg.resample)
+	default:
+		return nil, fmt.Errorf("quote0: unknown fit mode %q (want contain, cover, or stretch)", cfg.fit)
+	}
+	img := c.Image()
+	cfg.adjust(img)
+	if cfg.dither != "" {
+		return Dither(img, cfg.dither, cfg.kernel, cfg.ditherOpts...)
+	}
+	return img, nil
+}
+
+func (cfg processConfig) check() error {
+	switch {
+	case cfg.contrast < 0 || math.IsNaN(cfg.contrast):
+		return fmt.Errorf("%w: contrast must not be negative, got %g", ErrInvalidAdjustment, cfg.contrast)
+	case cfg.gamma <= 0 || math.IsNaN(cfg.gamma):
+		return fmt.Errorf("%w: gamma must be positive, got %g", ErrInvalidAdjustment, cfg.gamma)
+	case cfg.sharpen < 0 || math.IsNaN(cfg.sharpen):
+		return fmt.Errorf("%w: sharpen must not be negative, got %g", ErrInvalidAdjustment, cfg.sharpen)
+	case cfg.margin < 0 || cfg.margin > MaxSafeMargin:
+		return fmt.Errorf("%w: safe margin must be 0 to %d pixels, got %d", ErrInvalidAdjustment, MaxSafeMargin, cfg.margin)
+	case !cfg.resample.valid():
+		return fmt.Errorf("%w: unknown resample filter %q (want nearest, box, or bilinear)", ErrInvalidAdjustment, cfg.resample)
+	}
+	return nil
+}
+
+// adjust applies the tone steps in pipeline order: contrast and gamma, sharpen, then invert
+// and threshold.
+func (cfg processConfig) adjust(img *image.Gray) {
+	if cfg.contrast != 1 || cfg.gamma != 1 {
+		var lut [256]uint8
+		for v := range lut {
+			f := (float64(v)-127.5)*cfg.contrast + 127.5
+			f = 255 * math.Pow(clampUnit(f/255), 1/cfg.gamma)
+			lut[v] = uint8(math.Round(f))
+		}
+		for i, v := range img.Pix {
+			img.Pix[i] = lut[v]
+		}
+	}
+	if cfg.sharpen > 0 {
+		sharpen(img, cfg.sharpen)
+	}
+	if !cfg.invert && cfg.threshold == 0 {
+		return
+	}
+	for i, v := range img.Pix {
+		if cfg.invert {
+			v = 255 - v
+		}
+		if cfg.threshold > 0 {
+			v = threshold(int(v), int(cfg.threshold))
+		}
+		img.Pix[i] = v
+	}
+}
+
+// sharpen applies an unsharp mask with a 3x3 box blur, clamping at the image edges.
+func sharpen(img *image.Gray, amount float64) {
+	b := img.Bounds()
+	blurred := boxBlur(img, 1)
+	w := b.Dx()
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < w; x++ {
+			i := img.PixOffset(b.Min.X+x, b.Min.Y+y)
+			v := float64(img.Pix[i])
+			img.Pix[i] = uint8(math.Round(255 * clampUnit((v+amount*(v-blurred[y*w+x]))/255)))
+		}
+	}
+}
+
+// CheckProcessing reports ProcessImage options that are invalid, as errors, or that make the
+// given server-side dither type pointless, as warnings: a thresholded or locally dithered
+// image is already black and white, so only DitherNone leaves it as processed. Fields are
+// named after the options ("contrast", "gamma", "sharpen", "rotation", "safeMargin",
+// "resample", "localDither", "ditherType").
+func CheckProcessing(t DitherType, opts ...ProcessOption) []Problem {
+	cfg := newProcessConfig(opts)
+	var ps problems
+	if cfg.rotation%90 != 0 {
+		ps.add("rotation", SeverityError, fmt.Sprintf("must be a multiple of 90 degrees, got %d", cfg.rotation))
+	}
+	if cfg.contrast < 0 || math.IsNaN(cfg.contrast) {
+		ps.add("contrast", SeverityError, fmt.Sprintf("must not be negative, got %g", cfg.contrast))
+	}
+	if cfg.gamma <= 0 || math.IsNaN(cfg.gamma) {
+		ps.add("gamma", SeverityError, fmt.Sprintf("must be positive, got %g", cfg.gamma))
+	}
+	if cfg.sharpen < 0 || math.IsNaN(cfg.sharpen) {
+		ps.add("sharpen", SeverityError, fmt.Sprintf("must not be negative, got %g", cfg.sharpen))
+	}
+	if cfg.margin < 0 || cfg.margin > MaxSafeMargin {
+		ps.add("safeMargin", SeverityError, fmt.Sprintf("must be 0 to %d pixels, got %d", MaxSafeMargin, cfg.margin))
+	}
+	if !cfg.resample.valid() {
+		ps.add("resample", SeverityError, fmt.Sprintf("unknown filter %q (want nearest, box, or bilinear)", cfg.resample))
+	}
+	switch cfg.dither {
+	case "", DitherNone, DitherOrdered:
+	case DitherDiffusion:
+		if _, ok := lookupKernel(cfg.kernel); !ok && cfg.kernel != "" {
+			ps.add("localDither", SeverityError, fmt.Sprintf("unknown dither kernel %q (see RegisterKernel)", cfg.kernel))
+		}
+	default:
+		ps.add("localDither", SeverityError, fmt.Sprintf("unknown dither type %q", cfg.dither))
+	}
+	t = DitherType(strings.ToUpper(strings.TrimSpace(string(t))))
+	if (cfg.threshold > 0 || cfg.dither != "") && t != DitherNone {
+		if t == "" {
+			t = DitherDiffusion
+		}
+		what := "the threshold"
+		if cfg.dither != "" {
+			what = "local dithering"
+		}
+		ps.add("ditherType", SeverityWarning, fmt.Sprintf("%s dithers an image %s already made black and white; use NONE", t, what))
+	}
+	return ps
+}
+
+// rotate returns src turned clockwise by quarter turns (1 to 3) as a grayscale image.
+func rotate(src image.Image, quarter int) *image.Gray {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	size := image.Rect(0, 0, h, w)
+	if quarter == 2 {
+		size = image.Rect(0, 0, w, h)
+	}
+	out := image.NewGray(size)
+	eachGray(src, func(x, y int, v uint8) {
+		switch quarter {
+		case 1:
+			out.Pix[x*out.Stride+(h-1-y)] = v
+		case 2:
+			out.Pix[(h-1-y)*out.Stride+(w-1-x)] = v
+		case 3:
+			out.Pix[(w-1-x)*out.Stride+y] = v
+		}
+	})
+	return out
+}
+
+// containRect is the largest rectangle with the aspect ratio of size centred in dst.
+func containRect(size image.Point, dst image.Rectangle) image.Rectangle {
+	w, h := dst.Dx(), dst.Dy()
+	if size.X*h > size.Y*w {
+		h = max1(w * size.Y / size.X)
+	} else {
+		w = max1(h * size.X / size.Y)
+	}
+	min := dst.Min.Add(image.Pt((dst.Dx()-w)/2, (dst.Dy()-h)/2))
+	return image.Rectangle{Min: min, Max: min.Add(image.Pt(w, h))}
+}
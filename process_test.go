@@ -0,0 +1,354 @@
+package quote0
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// testBanner is a 400x100 fixture: a black left half and a white right half.
+func testBanner() image.Image {
+	img := image.NewGray(image.Rect(0, 0, 400, 100))
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 400; x++ {
+			if x >= 200 {
+				img.SetGray(x, y, White)
+			}
+		}
+	}
+	return img
+}
+
+func TestProcessImage_Fit(t *testing.T) {
+	src := testBanner()
+	at := func(img *image.Gray, x, y int) uint8 { return img.GrayAt(x, y).Y }
+
+	contain, err := ProcessImage(src, WithFit(FitContain), WithBackground(Black))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 400x100 contained in 296x152 is 296x74, centred with 39px bands.
+	if at(contain, 290, 10) != Black.Y || at(contain, 290, 76) != White.Y || at(contain, 5, 76) != Black.Y {
+		t.Fatal("contain: unexpected layout")
+	}
+
+	cover, err := ProcessImage(src, WithFit(FitCover))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if at(cover, 5, 5) != Black.Y || at(cover, 290, 146) != White.Y {
+		t.Fatal("cover: unexpected layout")
+	}
+
+	stretch, err := ProcessImage(src, WithFit(FitStretch))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if at(stretch, 147, 0) != Black.Y || at(stretch, 149, 151) != White.Y {
+		t.Fatal("stretch: unexpected layout")
+	}
+}
+
+func TestProcessImage_Errors(t *testing.T) {
+	if _, err := ProcessImage(testBanner()); !errors.Is(err, ErrImageSize) {
+		t.Fatalf("no fit: %v", err)
+	}
+	tiny := image.NewGray(image.Rect(0, 0, 4, 3))
+	_, err := ProcessImage(tiny, WithFit(FitCover))
+	if !errors.Is(err, ErrImageTooSmall) || !bytes.Contains([]byte(err.Error()), []byte("4x3")) {
+		t.Fatalf("tiny: %v", err)
+	}
+	if _, err := ProcessImage(testBanner(), WithFit("zoom")); err == nil {
+		t.Fatal("unknown mode should fail")
+	}
+}
+
+func TestProcessImage_Rotation(t *testing.T) {
+	// A 152x296 portrait: black top half, white bottom half.
+	portrait := image.NewGray(image.Rect(0, 0, 152, 296))
+	for y := 148; y < 296; y++ {
+		for x := 0; x < 152; x++ {
+			portrait.SetGray(x, y, White)
+		}
+	}
+	at := func(img *image.Gray, x, y int) uint8 { return img.GrayAt(x, y).Y }
+
+	// Clockwise: the top half ends up on the right.
+	cw, err := ProcessImage(portrait, WithRotation(90))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if at(cw, 290, 76) != Black.Y || at(cw, 5, 76) != White.Y {
+		t.Fatal("90: unexpected layout")
+	}
+	ccw, err := ProcessImage(portrait, WithRotation(-90))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if at(ccw, 5, 76) != Black.Y || at(ccw, 290, 76) != White.Y {
+		t.Fatal("-90: unexpected layout")
+	}
+	flipped, err := ProcessImage(testBanner(), WithRotation(180), WithFit(FitStretch))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if at(flipped, 5, 5) != White.Y || at(flipped, 290, 5) != Black.Y {
+		t.Fatal("180: unexpected layout")
+	}
+	if _, err := ProcessImage(portrait, WithRotation(45)); err == nil {
+		t.Fatal("45 degrees should fail")
+	}
+}
+
+func TestProcessImage_Adjustments(t *testing.T) {
+	// A horizontal ramp from black to white across the screen.
+	ramp := image.NewGray(image.Rect(0, 0, ScreenWidth, ScreenHeight))
+	for y := 0; y < ScreenHeight; y++ {
+		for x := 0; x < ScreenWidth; x++ {
+			ramp.SetGray(x, y, color.Gray{Y: uint8(x * 255 / (ScreenWidth - 1))})
+		}
+	}
+	at := func(img *image.Gray, x int) uint8 { return img.GrayAt(x, 76).Y }
+	run := func(opts ...ProcessOption) *image.Gray {
+		t.Helper()
+		img, err := ProcessImage(ramp, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return img
+	}
+
+	if img := run(WithInvert()); at(img, 0) != 255 || at(img, ScreenWidth-1) != 0 {
+		t.Errorf("invert: ends %d, %d", at(img, 0), at(img, ScreenWidth-1))
+	}
+	img := run(WithThreshold(128))
+	for x := 0; x < ScreenWidth; x++ {
+		if v := at(img, x); v != 0 && v != 255 || (v == 255) != (at(ramp, x) >= 128) {
+			t.Fatalf("threshold: x=%d is %d for %d", x, v, at(ramp, x))
+		}
+	}
+	if mid := at(run(WithContrast(2)), 70); mid >= at(ramp, 70) {
+		t.Errorf("contrast 2 should darken a dark gray: %d -> %d", at(ramp, 70), mid)
+	}
+	if flat := run(WithContrast(0)); at(flat, 0) != at(flat, ScreenWidth-1) {
+		t.Error("contrast 0 should flatten to one gray")
+	}
+	if g := at(run(WithGamma(2.2)), 148); g <= at(ramp, 148) {
+		t.Errorf("gamma 2.2 should brighten the mid-tones: %d -> %d", at(ramp, 148), g)
+	}
+	// Threshold runs after invert, whatever the option order.
+	if a, b := run(WithThreshold(100), WithInvert()), run(WithInvert(), WithThreshold(100)); !bytes.Equal(a.Pix, b.Pix) || at(a, 0) != 255 {
+		t.Error("pipeline order depends on option order")
+	}
+
+	// Sharpening steepens an edge: the pixels on either side move apart.
+	soft, err := ProcessImage(testBanner(), WithFit(FitStretch), WithContrast(0.5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sharp, err := ProcessImage(testBanner(), WithFit(FitStretch), WithContrast(0.5), WithSharpen(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	left, right := sharp.GrayAt(147, 76).Y, sharp.GrayAt(148, 76).Y
+	if left >= soft.GrayAt(147, 76).Y || right <= soft.GrayAt(148, 76).Y {
+		t.Errorf("sharpen: edge %d|%d, unsharpened %d|%d", left, right, soft.GrayAt(147, 76).Y, soft.GrayAt(148, 76).Y)
+	}
+
+	for _, opt := range []ProcessOption{WithContrast(-1), WithGamma(0), WithSharpen(-0.5)} {
+		if _, err := ProcessImage(ramp, opt); !errors.Is(err, ErrInvalidAdjustment) || !IsValidationError(err) {
+			t.Errorf("invalid adjustment: %v", err)
+		}
+	}
+}
+
+func TestCheckProcessing(t *testing.T) {
+	if ps := CheckProcessing(DitherNone, WithThreshold(128)); ps != nil {
+		t.Errorf("threshold with NONE: %v", ps)
+	}
+	if ps := CheckProcessing("", WithContrast(1.5)); ps != nil {
+		t.Errorf("contrast alone: %v", ps)
+	}
+	for _, dt := range []DitherType{"", DitherDiffusion, "ordered"} {
+		ps := CheckProcessing(dt, WithThreshold(128))
+		if len(ps) != 1 || ps[0].Field != "ditherType" || ps[0].Severity != SeverityWarning {
+			t.Errorf("threshold with %q: %v", dt, ps)
+		}
+	}
+	ps := CheckProcessing(DitherNone, WithRotation(45), WithGamma(-1), WithSharpen(-1), WithContrast(-1))
+	if len(ps) != 4 || !HasErrors(ps) {
+		t.Errorf("invalid options: %v", ps)
+	}
+}
+
+func TestPackMonochrome(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 10, 2))
+	for i := range img.Pix {
+		img.Pix[i] = White.Y
+	}
+	img.SetGray(0, 0, Black)
+	img.SetGray(9, 0, Black)
+	img.SetGray(3, 1, color.Gray{Y: 127})
+	got := PackMonochrome(img)
+	want := []byte{0x80, 0x40, 0x10, 0x00}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("PackMonochrome = % x, want % x", got, want)
+	}
+	if n := len(PackMonochrome(NewCanvas().Image())); n != 37*152 {
+		t.Fatalf("screen packs into %d bytes", n)
+	}
+}
+
+func TestDecodeImage(t *testing.T) {
+	var buf bytes.Buffer
+	src := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	src.Set(0, 0, color.White)
+	if err := jpeg.Encode(&buf, src, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, format, err := DecodeImage(buf.Bytes()); err != nil || format != "jpeg" {
+		t.Fatalf("jpeg: %q %v", format, err)
+	}
+	_, _, err := DecodeImage([]byte("GIF89a\x01\x00"))
+	if !errors.Is(err, ErrUnsupportedFormat) || !bytes.Contains([]byte(err.Error()), []byte("gif")) {
+		t.Fatalf("gif: %v", err)
+	}
+	if _, _, err := DecodeImage([]byte("hello")); err != ErrUnsupportedFormat {
+		t.Fatalf("unknown: %v", err)
+	}
+	if _, _, err := DecodeImage([]byte("\x89PNG\r\n\x1a\ntruncated")); !errors.Is(err, ErrInvalidImage) {
+		t.Fatalf("corrupt png: %v", err)
+	}
+}
+
+func TestToneError(t *testing.T) {
+	gray := image.NewGray(image.Rect(0, 0, 64, 32))
+	for i := range gray.Pix {
+		gray.Pix[i] = 128
+	}
+	if e, err := ToneError(gray, gray); err != nil || e != 0 {
+		t.Fatalf("identical images: %v, %v", e, err)
+	}
+	diffused, _ := Dither(gray, DitherDiffusion, KernelFloydSteinberg)
+	thresholded, _ := Dither(gray, DitherNone, "")
+	de, err := ToneError(gray, diffused)
+	if err != nil {
+		t.Fatal(err)
+	}
+	te, _ := ToneError(gray, thresholded)
+	if !(de < te) {
+		t.Fatalf("diffusion error %.1f not below threshold error %.1f for mid gray", de, te)
+	}
+	if _, err := ToneError(gray, image.NewGray(image.Rect(0, 0, 8, 8))); err == nil {
+		t.Fatal("size mismatch accepted")
+	}
+}
+
+func TestDitherKernels(t *testing.T) {
+	kernels := DitherKernels()
+	if len(kernels) != len(diffusionKernels) {
+		t.Fatalf("%d kernels listed, %d known", len(kernels), len(diffusionKernels))
+	}
+	for _, k := range kernels {
+		if _, ok := diffusionKernels[k]; !ok {
+			t.Fatalf("unknown kernel %s", k)
+		}
+	}
+}
+
+func TestFetchImage(t *testing.T) {
+	var photo, anim bytes.Buffer
+	if err := jpeg.Encode(&photo, testBanner(), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := gif.Encode(&anim, testBanner(), nil); err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/photo.jpg":
+			_, _ = w.Write(photo.Bytes())
+		case "/anim.gif":
+			_, _ = w.Write(anim.Bytes())
+		case "/huge":
+			_, _ = w.Write(make([]byte, MaxImageDownload+1))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	got, err := FetchImage(context.Background(), srv.Client(), srv.URL+"/photo.jpg")
+	if err != nil || !bytes.Equal(got, photo.Bytes()) {
+		t.Fatalf("got %d bytes, %v", len(got), err)
+	}
+	for path, want := range map[string]error{
+		"/anim.gif": ErrUnsupportedFormat,
+		"/huge":     ErrImageFetch,
+		"/missing":  ErrImageFetch,
+	} {
+		if _, err := FetchImage(context.Background(), nil, srv.URL+path); !errors.Is(err, want) {
+			t.Errorf("%s: want %v, got %v", path, want, err)
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := FetchImage(ctx, nil, srv.URL+"/photo.jpg"); !errors.Is(err, ErrImageFetch) {
+		t.Errorf("canceled: %v", err)
+	}
+}
+
+// safeMarginBanner is a full-screen render whose frame touches the screen edges.
+func safeMarginBanner() image.Image {
+	c := NewCanvas()
+	c.StrokeRect(c.Bounds(), 2, Black)
+	c.DrawText(20, 60, "BACKUP OK", 4, Black)
+	return c.Image()
+}
+
+func TestProcessImage_SafeMarginGolden(t *testing.T) {
+	for _, margin := range []int{0, 4} {
+		img, err := ProcessImage(safeMarginBanner(), WithSafeMargin(margin), WithBackground(BorderBlack.Gray()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertGolden(t, fmt.Sprintf("safe_margin_%d", margin), img)
+	}
+}
+
+func TestProcessImage_SafeMargin(t *testing.T) {
+	at := func(img *image.Gray, x, y int) uint8 { return img.GrayAt(x, y).Y }
+	img, err := ProcessImage(testBanner(), WithFit(FitStretch), WithSafeMargin(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != ScreenWidth || b.Dy() != ScreenHeight {
+		t.Fatalf("size %v", b)
+	}
+	// The band is white (the default background); the stretched banner starts inside it.
+	if at(img, 5, 76) != White.Y || at(img, 12, 76) != Black.Y || at(img, 12, 5) != White.Y || at(img, 283, 140) != White.Y {
+		t.Fatal("stretch: unexpected layout")
+	}
+	if _, err := ProcessImage(testBanner(), WithFit(FitCover), WithSafeMargin(MaxSafeMargin)); err != nil {
+		t.Fatalf("largest margin: %v", err)
+	}
+	for _, m := range []int{-1, MaxSafeMargin + 1, ScreenWidth / 2} {
+		if _, err := ProcessImage(testBanner(), WithFit(FitCover), WithSafeMargin(m)); !errors.Is(err, ErrInvalidAdjustment) {
+			t.Errorf("margin %d: %v", m, err)
+		}
+		if ps := CheckProcessing(DitherNone, WithSafeMargin(m)); len(ps) != 1 || ps[0].Field != "safeMargin" {
+			t.Errorf("margin %d: problems %v", m, ps)
+		}
+	}
+	if BorderWhite.Gray() != White || BorderBlack.Gray() != Black {
+		t.Fatal("border colors")
+	}
+}
@@ -0,0 +1,90 @@
+package quote0
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// ErrBarWidth is returned by TextProgressRequest for a bar that does not fit a message line.
+var ErrBarWidth = errors.New("quote0: progress bar width does not fit")
+
+// BarStyle selects the characters TextProgressBar draws with.
+type BarStyle string
+
+const (
+	// BarBlocks draws with shade block elements: "▓▓▓░░░" (default).
+	BarBlocks BarStyle = "blocks"
+	// BarASCII draws with "#" and "-".
+	BarASCII BarStyle = "ascii"
+	// BarBracketed draws with "#" and "-" between square brackets.
+	BarBracketed BarStyle = "bracketed"
+)
+
+// barGlyphs are the pieces of a bar style.
+type barGlyphs struct {
+	open, filled, empty, close string
+}
+
+func (s BarStyle) glyphs() (barGlyphs, bool) {
+	switch s {
+	case BarBlocks, "":
+		return barGlyphs{filled: "▓", empty: "░"}, true
+	case BarASCII:
+		return barGlyphs{filled: "#", empty: "-"}, true
+	case BarBracketed:
+		return barGlyphs{open: "[", filled: "#", empty: "-", close: "]"}, true
+	}
+	return barGlyphs{filled: "▓", empty: "░"}, false
+}
+
+// barSuffixWidth is the width of the percentage after the bar, " 100%", padded so it never
+// changes the bar length.
+const barSuffixWidth = 5
+
+// TextProgressBar draws fraction as a bar followed by its percentage, such as
+// "▓▓▓▓▓░░░░░  52%". width is the total in display cells (see DisplayWidth), including the
+// brackets and the percentage, so the result fits a line budget exactly; the bar shrinks to
+// nothing when width leaves no room for it. fraction is clamped to [0, 1], NaN counts as 0,
+// and unknown styles draw as BarBlocks.
+func TextProgressBar(fraction float64, width int, style BarStyle) string {
+	if math.IsNaN(fraction) || fraction < 0 {
+		fraction = 0
+	} else if fraction > 1 {
+		fraction = 1
+	}
+	g, _ := style.glyphs()
+	suffix := fmt.Sprintf(" %3d%%", int(math.Round(fraction*100)))
+	cell := DisplayWidth(g.filled)
+	cells := (width - barSuffixWidth - DisplayWidth(g.open+g.close)) / cell
+	if cells <= 0 {
+		return strings.TrimLeft(suffix, " ")
+	}
+	filled := int(math.Round(fraction * float64(cells)))
+	return g.open + strings.Repeat(g.filled, filled) + strings.Repeat(g.empty, cells-filled) + g.close + suffix
+}
+
+// TextProgressRequest builds a text request whose message shows label on the first line and a
+// TextProgressBar below it. width is the bar's total width in display cells; 0 uses a full
+// message line (MaxMessageLineRunes). Widths beyond a message line, or too narrow to draw any
+// bar, report ErrBarWidth, and an unknown style is an error. The label is cut to width runes.
+func TextProgressRequest(label string, fraction float64, width int, style BarStyle) (TextRequest, error) {
+	g, ok := style.glyphs()
+	if !ok {
+		return TextRequest{}, fmt.Errorf("quote0: unknown bar style %q (want %s, %s, or %s)", style, BarBlocks, BarASCII, BarBracketed)
+	}
+	if width == 0 {
+		width = MaxMessageLineRunes
+	}
+	minWidth := barSuffixWidth + DisplayWidth(g.open+g.close) + DisplayWidth(g.filled)
+	if width < minWidth || width > MaxMessageLineRunes {
+		return TextRequest{}, fmt.Errorf("%w: %d cells, want %d to %d", ErrBarWidth, width, minWidth, MaxMessageLineRunes)
+	}
+	bar := TextProgressBar(fraction, width, style)
+	label = Truncate(singleLine(label), width)
+	if label == "" {
+		return TextRequest{Message: bar}, nil
+	}
+	return TextRequest{Message: label + "\n" + bar}, nil
+}
@@ -0,0 +1,67 @@
+package quote0
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestTextProgressBar(t *testing.T) {
+	cases := []struct {
+		fraction float64
+		width    int
+		style    BarStyle
+		want     string
+	}{
+		{0, 15, BarBlocks, "░░░░░░░░░░   0%"},
+		{1.0 / 3, 15, BarBlocks, "▓▓▓░░░░░░░  33%"},
+		{0.52, 15, BarBlocks, "▓▓▓▓▓░░░░░  52%"},
+		{1, 15, BarBlocks, "▓▓▓▓▓▓▓▓▓▓ 100%"},
+		{1.0 / 3, 11, BarASCII, "##----  33%"},
+		{1.0 / 3, 11, BarBracketed, "[#---]  33%"},
+		{-0.5, 9, BarASCII, "----   0%"},
+		{2, 9, BarASCII, "#### 100%"},
+		{math.NaN(), 9, BarASCII, "----   0%"},
+		{0.5, 5, BarASCII, "50%"},
+		{0.5, 9, "unknown", "▓▓░░  50%"},
+	}
+	for _, tc := range cases {
+		got := TextProgressBar(tc.fraction, tc.width, tc.style)
+		if got != tc.want {
+			t.Errorf("TextProgressBar(%v, %d, %q) = %q, want %q", tc.fraction, tc.width, tc.style, got, tc.want)
+		}
+		if len(tc.want) > 0 && tc.width > barSuffixWidth && DisplayWidth(got) != tc.width {
+			t.Errorf("%q is %d cells, want %d", got, DisplayWidth(got), tc.width)
+		}
+	}
+}
+
+func TestTextProgressRequest(t *testing.T) {
+	req, err := TextProgressRequest("  Backup\nrunning ", 0.5, 0, BarBracketed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Backup running\n[#################----------------]  50%"
+	if req.Message != want {
+		t.Fatalf("message %q", req.Message)
+	}
+	if req, err := TextProgressRequest("", 1, 10, BarASCII); err != nil || req.Message != "##### 100%" {
+		t.Fatalf("no label: %q %v", req.Message, err)
+	}
+	for _, width := range []int{MaxMessageLineRunes + 1, 7, -1} {
+		if _, err := TextProgressRequest("x", 0.5, width, BarBracketed); !errors.Is(err, ErrBarWidth) {
+			t.Errorf("width %d: %v", width, err)
+		}
+	}
+	if _, err := TextProgressRequest("x", 0.5, 10, "fancy"); err == nil {
+		t.Fatal("unknown style accepted")
+	}
+}
+
+func TestDisplayWidth(t *testing.T) {
+	for s, want := range map[string]int{"": 0, "abc": 3, "进度": 4, "Ｑ1": 3, "▓░": 2} {
+		if got := DisplayWidth(s); got != want {
+			t.Errorf("DisplayWidth(%q) = %d, want %d", s, got, want)
+		}
+	}
+}
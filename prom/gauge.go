@@ -0,0 +1,94 @@
+package prom
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+)
+
+const (
+	screenW = 296
+	screenH = 152
+)
+
+// digitFont is a 3x5 bitmap font for the characters a formatted number can contain.
+// Each row is three bits, most significant bit on the left.
+var digitFont = map[rune][5]uint8{
+	'0': {7, 5, 5, 5, 7},
+	'1': {2, 6, 2, 2, 7},
+	'2': {7, 1, 7, 4, 7},
+	'3': {7, 1, 7, 1, 7},
+	'4': {5, 5, 7, 1, 1},
+	'5': {7, 4, 7, 1, 7},
+	'6': {7, 4, 7, 5, 7},
+	'7': {7, 1, 1, 1, 1},
+	'8': {7, 5, 7, 5, 7},
+	'9': {7, 5, 7, 1, 7},
+	'.': {0, 0, 0, 0, 2},
+	'-': {0, 0, 7, 0, 0},
+	'+': {0, 2, 7, 2, 0},
+}
+
+// renderGauge draws the number label above a horizontal bar filled in proportion to v
+// within [lo, hi]. Characters outside digitFont are skipped.
+func renderGauge(v, lo, hi float64, label string) ([]byte, error) {
+	img := image.NewGray(image.Rect(0, 0, screenW, screenH))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	black := color.Gray{}
+
+	// Label: scale glyphs down until the text fits with a 16px margin.
+	var glyphs [][5]uint8
+	for _, r := range label {
+		if g, ok := digitFont[r]; ok {
+			glyphs = append(glyphs, g)
+		}
+	}
+	scale := 10
+	for scale > 2 && len(glyphs)*4*scale-scale > screenW-32 {
+		scale--
+	}
+	width := len(glyphs)*4*scale - scale
+	x0, y0 := (screenW-width)/2, 16
+	for i, g := range glyphs {
+		for row := 0; row < 5; row++ {
+			for col := 0; col < 3; col++ {
+				if g[row]&(4>>col) != 0 {
+					fill(img, x0+(i*4+col)*scale, y0+row*scale, scale, scale, black)
+				}
+			}
+		}
+	}
+
+	// Bar: 2px frame, inner fill clamped to the range.
+	const bx, by, bw, bh = 16, 100, screenW - 32, 36
+	fill(img, bx, by, bw, 2, black)
+	fill(img, bx, by+bh-2, bw, 2, black)
+	fill(img, bx, by, 2, bh, black)
+	fill(img, bx+bw-2, by, 2, bh, black)
+	frac := (v - lo) / (hi - lo)
+	if math.IsNaN(frac) || frac < 0 {
+		frac = 0
+	}
+	if frac > 1 {
+		frac = 1
+	}
+	fill(img, bx+4, by+4, int(math.Round(frac*float64(bw-8))), bh-8, black)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func fill(img *image.Gray, x, y, w, h int, c color.Gray) {
+	for yy := y; yy < y+h; yy++ {
+		for xx := x; xx < x+w; xx++ {
+			img.SetGray(xx, yy, c)
+		}
+	}
+}
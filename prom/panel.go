@@ -0,0 +1,336 @@
+package prom
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/1set/quote0"
+)
+
+const (
+	defaultInterval = time.Minute
+	maxScrapeSize   = 8 << 20 // 8 MiB guard
+)
+
+// ErrNoData indicates the selected series is missing, NaN, or older than the stale limit.
+var ErrNoData = errors.New("prom: no data")
+
+// Option configures a Panel.
+type Option func(*Panel)
+
+// WithLabel adds an exact label matcher to the series selection.
+func WithLabel(name, value string) Option {
+	return func(p *Panel) { p.labels[name] = value }
+}
+
+// WithTitle sets the screen title (default: the metric name).
+func WithTitle(title string) Option {
+	return func(p *Panel) { p.title = title }
+}
+
+// WithUnit multiplies the value by scale and appends unit when formatting,
+// e.g. WithUnit("GiB", 1.0/(1<<30)) for byte gauges. A zero scale is treated as 1.
+func WithUnit(unit string, scale float64) Option {
+	return func(p *Panel) {
+		p.unit = unit
+		if scale != 0 {
+			p.scale = scale
+		}
+	}
+}
+
+// WithPrecision sets the number of decimals shown (default 1).
+func WithPrecision(n int) Option {
+	return func(p *Panel) {
+		if n >= 0 {
+			p.precision = n
+		}
+	}
+}
+
+// WithGauge makes Run send a gauge image scaled between lo and hi (after unit scaling)
+// instead of a text screen.
+func WithGauge(lo, hi float64) Option {
+	return func(p *Panel) {
+		if hi > lo {
+			p.gauge, p.lo, p.hi = true, lo, hi
+		}
+	}
+}
+
+// WithStaleAfter treats samples whose exporter timestamp is older than d as missing.
+// Samples without a timestamp are never stale. Zero disables the check (default).
+func WithStaleAfter(d time.Duration) Option {
+	return func(p *Panel) { p.staleAfter = d }
+}
+
+// WithDeviceID targets a specific device instead of the client's default.
+func WithDeviceID(deviceID string) Option {
+	return func(p *Panel) { p.deviceID = deviceID }
+}
+
+// WithInterval sets how often Run scrapes the endpoint (default 1 minute).
+func WithInterval(d time.Duration) Option {
+	return func(p *Panel) {
+		if d > 0 {
+			p.interval = d
+		}
+	}
+}
+
+// WithErrorHandler receives scrape, no-data, and send errors from Run, which otherwise keeps going.
+func WithErrorHandler(fn func(error)) Option {
+	return func(p *Panel) { p.onError = fn }
+}
+
+// Panel renders one Prometheus series on a Quote/0 device.
+type Panel struct {
+	client     *quote0.Client
+	url        string
+	metric     string
+	labels     map[string]string
+	title      string
+	unit       string
+	scale      float64
+	precision  int
+	gauge      bool
+	lo, hi     float64
+	staleAfter time.Duration
+	deviceID   string
+	interval   time.Duration
+	onError    func(error)
+	now        func() time.Time
+}
+
+// NewPanel creates a panel for metric scraped from url. Scrapes use the client's HTTP client
+// so they share its timeout and transport settings.
+func NewPanel(client *quote0.Client, url, metric string, opts ...Option) *Panel {
+	p := &Panel{
+		client:    client,
+		url:       url,
+		metric:    metric,
+		labels:    make(map[string]string),
+		title:     metric,
+		scale:     1,
+		precision: 1,
+		interval:  defaultInterval,
+		now:       time.Now,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(p)
+		}
+	}
+	return p
+}
+
+// Scrape fetches and parses the endpoint.
+func (p *Panel) Scrape(ctx context.Context) ([]Sample, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("prom: build request: %w", err)
+	}
+	req.Header.Set("Accept", "text/plain;version=0.0.4;q=1, */*;q=0.1")
+	resp, err := p.client.HTTPClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("prom: scrape: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("prom: scrape: unexpected status %s", resp.Status)
+	}
+	return ParseText(io.LimitReader(resp.Body, maxScrapeSize))
+}
+
+// Select returns the selected series from samples. It fails with ErrNoData when no series
+// matches, the value is NaN, or the sample is stale. When several series match, the one
+// with the lexically smallest label set is used so the choice is stable across scrapes.
+func (p *Panel) Select(samples []Sample) (Sample, error) {
+	var matches []Sample
+	for _, s := range samples {
+		if s.Matches(p.metric, p.labels) {
+			matches = append(matches, s)
+		}
+	}
+	if len(matches) == 0 {
+		return Sample{}, fmt.Errorf("%w: %s not found", ErrNoData, p.selector())
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return labelKey(matches[i]) < labelKey(matches[j]) })
+	s := matches[0]
+	if math.IsNaN(s.Value) {
+		return Sample{}, fmt.Errorf("%w: %s is NaN", ErrNoData, p.selector())
+	}
+	if p.staleAfter > 0 && !s.Timestamp.IsZero() && p.now().Sub(s.Timestamp) > p.staleAfter {
+		return Sample{}, fmt.Errorf("%w: %s is stale since %s", ErrNoData, p.selector(), s.Timestamp.Format(time.RFC3339))
+	}
+	return s, nil
+}
+
+// Text scrapes the endpoint and returns a text screen for the selected series. The request
+// is always usable: on any failure it is a "no data" screen and err explains why.
+func (p *Panel) Text(ctx context.Context) (quote0.TextRequest, error) {
+	s, err := p.sample(ctx)
+	if err != nil {
+		return p.noData(), err
+	}
+	return quote0.TextRequest{
+		RefreshNow: quote0.Bool(true),
+		DeviceID:   p.deviceID,
+		Title:      p.title,
+		Message:    p.FormatValue(s.Value),
+		Signature:  labelSignature(s.Labels),
+	}, nil
+}
+
+// Image scrapes the endpoint and returns a gauge image for the selected series using the
+// range from WithGauge (0–100 when unset). On failure it returns a nil request and the error;
+// Run falls back to the "no data" text screen in that case.
+func (p *Panel) Image(ctx context.Context) (*quote0.ImageRequest, error) {
+	s, err := p.sample(ctx)
+	if err != nil {
+		return nil, err
+	}
+	lo, hi := p.lo, p.hi
+	if !p.gauge {
+		lo, hi = 0, 100
+	}
+	v := s.Value * p.scale
+	png, err := renderGauge(v, lo, hi, strconv.FormatFloat(v, 'f', p.precision, 64))
+	if err != nil {
+		return nil, err
+	}
+	return &quote0.ImageRequest{
+		RefreshNow: quote0.Bool(true),
+		DeviceID:   p.deviceID,
+		ImageBytes: png,
+		DitherType: quote0.DitherNone,
+	}, nil
+}
+
+// FormatValue applies the unit scale, precision, and unit suffix.
+func (p *Panel) FormatValue(v float64) string {
+	out := strconv.FormatFloat(v*p.scale, 'f', p.precision, 64)
+	if p.unit != "" {
+		out += " " + p.unit
+	}
+	return out
+}
+
+// Run scrapes immediately and then on every interval until ctx ends, returning ctx.Err().
+// Screens are only sent when they change, so a missing metric shows "no data" once instead
+// of resending (or failing) every interval.
+func (p *Panel) Run(ctx context.Context) error {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	var last string
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+		key, err := p.poll(ctx, last)
+		if err != nil && p.onError != nil {
+			p.onError(err)
+		}
+		if key != "" {
+			last = key
+		}
+		timer.Reset(p.interval)
+	}
+}
+
+// poll sends the current screen if it differs from last and returns the new screen key
+// ("" when nothing was sent successfully). A no-data screen is still sent, and its cause is
+// returned alongside.
+func (p *Panel) poll(ctx context.Context, last string) (string, error) {
+	if p.gauge {
+		img, err := p.Image(ctx)
+		if err == nil {
+			key := "image:" + string(img.ImageBytes)
+			if key == last {
+				return "", nil
+			}
+			if _, err := p.client.SendImage(ctx, *img); err != nil {
+				return "", err
+			}
+			return key, nil
+		}
+		return p.sendText(ctx, p.noData(), last, err)
+	}
+	req, cause := p.Text(ctx)
+	return p.sendText(ctx, req, last, cause)
+}
+
+func (p *Panel) sendText(ctx context.Context, req quote0.TextRequest, last string, cause error) (string, error) {
+	key := "text:" + req.Title + "\x00" + req.Message + "\x00" + req.Signature
+	if key == last {
+		return "", cause
+	}
+	if _, err := p.client.SendText(ctx, req); err != nil {
+		return "", err
+	}
+	return key, cause
+}
+
+func (p *Panel) sample(ctx context.Context) (Sample, error) {
+	samples, err := p.Scrape(ctx)
+	if err != nil {
+		return Sample{}, err
+	}
+	return p.Select(samples)
+}
+
+func (p *Panel) noData() quote0.TextRequest {
+	return quote0.TextRequest{
+		RefreshNow: quote0.Bool(true),
+		DeviceID:   p.deviceID,
+		Title:      p.title,
+		Message:    "no data",
+		Signature:  p.selector(),
+	}
+}
+
+// selector renders the metric and matchers in PromQL style, e.g. up{job="node"}.
+func (p *Panel) selector() string {
+	if len(p.labels) == 0 {
+		return p.metric
+	}
+	keys := make([]string, 0, len(p.labels))
+	for k := range p.labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = k + "=" + strconv.Quote(p.labels[k])
+	}
+	return p.metric + "{" + strings.Join(parts, ",") + "}"
+}
+
+func labelKey(s Sample) string {
+	keys := make([]string, 0, len(s.Labels))
+	for k := range s.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(k + "=" + s.Labels[k] + "\x00")
+	}
+	return b.String()
+}
+
+// labelSignature shows the instance label when present, which is what users usually
+// need to tell panels apart.
+func labelSignature(labels map[string]string) string {
+	return labels["instance"]
+}
@@ -0,0 +1,178 @@
+// Package prom shows a single Prometheus metric on a Quote/0 display.
+//
+// ParseText is a small reader for the Prometheus text exposition format: it returns every
+// sample line (metric name, labels, value, optional timestamp) and ignores comments.
+// Histogram and summary series are returned as their raw _bucket/_sum/_count samples
+// without further interpretation. A Panel fetches an endpoint, selects one series, and
+// formats it as a text screen or a simple gauge image.
+package prom
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Sample is one line of exposition output.
+type Sample struct {
+	Name   string
+	Labels map[string]string
+	Value  float64
+	// Timestamp is zero when the exporter did not set one.
+	Timestamp time.Time
+}
+
+// Matches reports whether the sample has the given name and all of the label values.
+func (s Sample) Matches(name string, labels map[string]string) bool {
+	if s.Name != name {
+		return false
+	}
+	for k, v := range labels {
+		if s.Labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseText reads samples in the Prometheus text format. Blank lines and lines starting
+// with '#' (HELP, TYPE, and plain comments) are skipped.
+func ParseText(r io.Reader) ([]Sample, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1<<20)
+	var out []Sample
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		s, err := parseLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("prom: line %d: %w", n, err)
+		}
+		out = append(out, s)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("prom: read: %w", err)
+	}
+	return out, nil
+}
+
+func parseLine(line string) (Sample, error) {
+	var s Sample
+	i := strings.IndexAny(line, "{ \t")
+	if i < 0 {
+		return s, fmt.Errorf("missing value in %q", line)
+	}
+	s.Name = line[:i]
+	if !validName(s.Name) {
+		return s, fmt.Errorf("invalid metric name %q", s.Name)
+	}
+	rest := line[i:]
+	if rest[0] == '{' {
+		labels, n, err := parseLabels(rest)
+		if err != nil {
+			return s, err
+		}
+		s.Labels, rest = labels, rest[n:]
+	}
+	fields := strings.Fields(rest)
+	if len(fields) == 0 || len(fields) > 2 {
+		return s, fmt.Errorf("expected value and optional timestamp in %q", line)
+	}
+	v, err := parseValue(fields[0])
+	if err != nil {
+		return s, err
+	}
+	s.Value = v
+	if len(fields) == 2 {
+		ms, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return s, fmt.Errorf("invalid timestamp %q", fields[1])
+		}
+		s.Timestamp = time.UnixMilli(ms)
+	}
+	return s, nil
+}
+
+// parseLabels parses `{a="x",b="y\"z"}` at the start of s and returns the number of bytes consumed.
+func parseLabels(s string) (map[string]string, int, error) {
+	labels := make(map[string]string)
+	i := 1
+	for {
+		for i < len(s) && (s[i] == ' ' || s[i] == ',') {
+			i++
+		}
+		if i >= len(s) {
+			return nil, 0, fmt.Errorf("unterminated label set")
+		}
+		if s[i] == '}' {
+			return labels, i + 1, nil
+		}
+		eq := strings.IndexByte(s[i:], '=')
+		if eq < 0 {
+			return nil, 0, fmt.Errorf("label without value")
+		}
+		key := strings.TrimSpace(s[i : i+eq])
+		if !validName(key) {
+			return nil, 0, fmt.Errorf("invalid label name %q", key)
+		}
+		i += eq + 1
+		if i >= len(s) || s[i] != '"' {
+			return nil, 0, fmt.Errorf("label %q value must be quoted", key)
+		}
+		i++
+		var b strings.Builder
+		for ; i < len(s) && s[i] != '"'; i++ {
+			if s[i] == '\\' && i+1 < len(s) {
+				i++
+				switch s[i] {
+				case 'n':
+					b.WriteByte('\n')
+				default:
+					b.WriteByte(s[i])
+				}
+				continue
+			}
+			b.WriteByte(s[i])
+		}
+		if i >= len(s) {
+			return nil, 0, fmt.Errorf("unterminated value for label %q", key)
+		}
+		labels[key] = b.String()
+		i++
+	}
+}
+
+func parseValue(s string) (float64, error) {
+	switch s {
+	case "+Inf", "Inf":
+		return math.Inf(1), nil
+	case "-Inf":
+		return math.Inf(-1), nil
+	case "NaN":
+		return math.NaN(), nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+	return v, nil
+}
+
+func validName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		ok := r == '_' || r == ':' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (i > 0 && r >= '0' && r <= '9')
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
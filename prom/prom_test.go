@@ -0,0 +1,198 @@
+package prom
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"image/png"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/1set/quote0"
+)
+
+func loadFixture(t *testing.T) []Sample {
+	t.Helper()
+	f, err := os.Open("testdata/node_exporter.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	samples, err := ParseText(f)
+	if err != nil {
+		t.Fatalf("ParseText: %v", err)
+	}
+	return samples
+}
+
+func TestParseText_NodeExporter(t *testing.T) {
+	samples := loadFixture(t)
+	if len(samples) != 17 {
+		t.Fatalf("samples=%d", len(samples))
+	}
+	find := func(name string, labels map[string]string) Sample {
+		t.Helper()
+		for _, s := range samples {
+			if s.Matches(name, labels) {
+				return s
+			}
+		}
+		t.Fatalf("%s %v not found", name, labels)
+		return Sample{}
+	}
+	if s := find("node_filesystem_avail_bytes", map[string]string{"mountpoint": "/data"}); s.Value != 1073741824e3 || s.Labels["fstype"] != "xfs" {
+		t.Fatalf("filesystem sample=%+v", s)
+	}
+	if s := find("node_load1", nil); s.Value != 0.52 || len(s.Labels) != 0 {
+		t.Fatalf("load sample=%+v", s)
+	}
+	if s := find("node_hwmon_temp_celsius", map[string]string{"sensor": "temp2"}); !math.IsNaN(s.Value) {
+		t.Fatalf("NaN sample=%+v", s)
+	}
+	if s := find("http_request_duration_seconds_bucket", map[string]string{"le": "+Inf"}); s.Value != 144 {
+		t.Fatalf("bucket sample=%+v", s)
+	}
+	if s := find("node_uname_info", nil); s.Labels["version"] != "#1 SMP PREEMPT_DYNAMIC Debian 6.1.55-1 (2023-09-29)" {
+		t.Fatalf("uname labels=%v", s.Labels)
+	}
+	s := find("pushed_job_last_success_timestamp", nil)
+	if s.Labels["note"] != "a \"quoted\" \\ value\nwith newline" {
+		t.Fatalf("escaped label=%q", s.Labels["note"])
+	}
+	if !s.Timestamp.Equal(time.UnixMilli(1700000000000)) {
+		t.Fatalf("timestamp=%v", s.Timestamp)
+	}
+}
+
+func TestParseText_Malformed(t *testing.T) {
+	for name, line := range map[string]string{
+		"no value":         "node_load1",
+		"bad value":        "node_load1 high",
+		"unquoted label":   `up{job=node} 1`,
+		"unterminated set": `up{job="node" 1`,
+		"bad name":         `1up 1`,
+		"bad timestamp":    `up 1 yesterday`,
+	} {
+		if _, err := ParseText(strings.NewReader(line + "\n")); err == nil {
+			t.Errorf("%s: expected error for %q", name, line)
+		}
+	}
+}
+
+func TestPanel_SelectAndFormat(t *testing.T) {
+	samples := loadFixture(t)
+	p := NewPanel(nil, "", "node_filesystem_avail_bytes",
+		WithLabel("mountpoint", "/"), WithUnit("GiB", 1.0/(1<<30)), WithPrecision(2))
+	s, err := p.Select(samples)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := p.FormatValue(s.Value); got != "39.28 GiB" {
+		t.Fatalf("formatted=%q", got)
+	}
+
+	for name, p := range map[string]*Panel{
+		"missing": NewPanel(nil, "", "node_load5"),
+		"label":   NewPanel(nil, "", "node_load1", WithLabel("instance", "x")),
+		"nan":     NewPanel(nil, "", "node_hwmon_temp_celsius", WithLabel("sensor", "temp2")),
+		"stale":   NewPanel(nil, "", "pushed_job_last_success_timestamp", WithStaleAfter(time.Hour)),
+	} {
+		if _, err := p.Select(samples); !errors.Is(err, ErrNoData) {
+			t.Errorf("%s: want ErrNoData, got %v", name, err)
+		}
+	}
+}
+
+func newScrapeServers(t *testing.T, metrics *string) (*quote0.Client, string, *[]string) {
+	t.Helper()
+	exporter := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		_, _ = io.WriteString(w, *metrics)
+	}))
+	t.Cleanup(exporter.Close)
+	var sent []string
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		sent = append(sent, r.URL.Path+" "+string(body))
+		_, _ = io.WriteString(w, `{"code":0}`)
+	}))
+	t.Cleanup(api.Close)
+	client, err := quote0.NewClient("test", quote0.WithBaseURL(api.URL), quote0.WithRateLimiter(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, exporter.URL, &sent
+}
+
+func TestPanel_NoDataScreenSentOnce(t *testing.T) {
+	metrics := "node_load1 0.5\n"
+	client, url, sent := newScrapeServers(t, &metrics)
+	p := NewPanel(client, url, "node_load5", WithDeviceID("D"), WithTitle("Load"))
+
+	var errs []error
+	last := ""
+	for i := 0; i < 3; i++ {
+		key, err := p.poll(context.Background(), last)
+		errs = append(errs, err)
+		if key != "" {
+			last = key
+		}
+	}
+	if len(*sent) != 1 {
+		t.Fatalf("no-data screen should be sent once, sent=%d", len(*sent))
+	}
+	var req quote0.TextRequest
+	_ = json.Unmarshal([]byte(strings.SplitN((*sent)[0], " ", 2)[1]), &req)
+	if req.Title != "Load" || req.Message != "no data" || req.Signature != "node_load5" || req.DeviceID != "D" {
+		t.Fatalf("no-data screen=%+v", req)
+	}
+	for _, err := range errs {
+		if !errors.Is(err, ErrNoData) {
+			t.Fatalf("poll should report ErrNoData, got %v", err)
+		}
+	}
+
+	// The metric appears: the real value replaces the no-data screen.
+	metrics = "node_load5 1.25\n"
+	if _, err := p.poll(context.Background(), last); err != nil {
+		t.Fatal(err)
+	}
+	if len(*sent) != 2 || !strings.Contains((*sent)[1], `"message":"1.2"`) {
+		t.Fatalf("sent=%v", *sent)
+	}
+}
+
+func TestPanel_Gauge(t *testing.T) {
+	metrics := `node_hwmon_temp_celsius{sensor="temp1"} 47` + "\n"
+	client, url, sent := newScrapeServers(t, &metrics)
+	p := NewPanel(client, url, "node_hwmon_temp_celsius", WithGauge(20, 100), WithPrecision(0), WithDeviceID("D"))
+
+	img, err := p.Image(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := png.Decode(bytes.NewReader(img.ImageBytes))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := decoded.Bounds(); b.Dx() != 296 || b.Dy() != 152 {
+		t.Fatalf("gauge size %v", b)
+	}
+
+	if _, err := p.poll(context.Background(), ""); err != nil {
+		t.Fatal(err)
+	}
+	var req quote0.ImageRequest
+	_ = json.Unmarshal([]byte(strings.SplitN((*sent)[0], " ", 2)[1]), &req)
+	if !strings.HasSuffix(strings.SplitN((*sent)[0], " ", 2)[0], "/image") || req.Image != base64.StdEncoding.EncodeToString(img.ImageBytes) {
+		t.Fatalf("gauge not sent as image: %.80s", (*sent)[0])
+	}
+}
@@ -0,0 +1,540 @@
+package quote0
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"strings"
+)
+
+// ErrQRTooLong is returned by EncodeQR for data beyond MaxQRBytes.
+var ErrQRTooLong = errors.New("quote0: data too long for a QR code")
+
+// MaxQRBytes is the most data EncodeQR accepts: a version 10 code at error correction level M.
+// Larger codes would not stay readable on the 152-pixel-high panel anyway.
+const MaxQRBytes = 213
+
+// QRQuietZone is the blank margin, in modules, that DrawQR and OverlayQR keep around a code.
+const QRQuietZone = 4
+
+// QRCode is an encoded QR code symbol.
+type QRCode struct {
+	// Version is the symbol version, 1 to 10; it grows with the data.
+	Version int
+	// Size is the width and height in modules, 17+4*Version, without the quiet zone.
+	Size int
+	dark []bool
+}
+
+// Black reports whether the module at (x, y) is dark. Coordinates outside the symbol are light.
+func (q *QRCode) Black(x, y int) bool {
+	if x < 0 || y < 0 || x >= q.Size || y >= q.Size {
+		return false
+	}
+	return q.dark[y*q.Size+x]
+}
+
+// PixelSize returns the width and height in pixels of the code drawn at scale pixels per
+// module, including the quiet zone.
+func (q *QRCode) PixelSize(scale int) int {
+	return (q.Size + 2*QRQuietZone) * scale
+}
+
+// DrawQR paints q with its top-left corner, quiet zone included, at (x, y), scale pixels per
+// module, and returns the painted rectangle. The quiet zone is painted white.
+func (c *Canvas) DrawQR(x, y int, q *QRCode, scale int) image.Rectangle {
+	if scale < 1 {
+		scale = 1
+	}
+	n := q.PixelSize(scale)
+	r := image.Rect(x, y, x+n, y+n)
+	c.FillRect(r, White)
+	for my := 0; my < q.Size; my++ {
+		for mx := 0; mx < q.Size; mx++ {
+			if q.Black(mx, my) {
+				px := x + (mx+QRQuietZone)*scale
+				py := y + (my+QRQuietZone)*scale
+				c.FillRect(image.Rect(px, py, px+scale, py+scale), Black)
+			}
+		}
+	}
+	return r
+}
+
+// qrVersion holds the level M block structure of one version.
+type qrVersion struct {
+	// total is the number of codewords, data and error correction.
+	total int
+	// ecPerBlock is the number of error correction codewords in every block.
+	ecPerBlock int
+	// blocks is the number of blocks the codewords are split into.
+	blocks int
+	// align lists the alignment pattern centre coordinates.
+	align []int
+}
+
+// qrVersions lists versions 1 to 10 at error correction level M (ISO/IEC 18004 tables 9 and E.1).
+var qrVersions = []qrVersion{
+	{26, 10, 1, nil},
+	{44, 16, 1, []int{6, 18}},
+	{70, 26, 1, []int{6, 22}},
+	{100, 18, 2, []int{6, 26}},
+	{134, 24, 2, []int{6, 30}},
+	{172, 16, 4, []int{6, 34}},
+	{196, 18, 4, []int{6, 22, 38}},
+	{242, 22, 4, []int{6, 24, 42}},
+	{292, 22, 5, []int{6, 26, 46}},
+	{346, 26, 5, []int{6, 28, 50}},
+}
+
+// dataCodewords returns how many codewords of version v carry data.
+func (v qrVersion) dataCodewords() int {
+	return v.total - v.ecPerBlock*v.blocks
+}
+
+// EncodeQR encodes data in byte mode at error correction level M, choosing the smallest
+// version that holds it. Data longer than MaxQRBytes reports ErrQRTooLong.
+func EncodeQR(data string) (*QRCode, error) {
+	version := 0
+	for i, v := range qrVersions {
+		countBits := 8
+		if i+1 >= 10 {
+			countBits = 16
+		}
+		if 4+countBits+8*len(data) <= 8*v.dataCodewords() {
+			version = i + 1
+			break
+		}
+	}
+	if version == 0 {
+		return nil, fmt.Errorf("%w: %d bytes, at most %d fit", ErrQRTooLong, len(data), MaxQRBytes)
+	}
+	v := qrVersions[version-1]
+
+	// Byte mode segment, terminator, and padding.
+	var bits qrBits
+	bits.append(0x4, 4)
+	if version >= 10 {
+		bits.append(len(data), 16)
+	} else {
+		bits.append(len(data), 8)
+	}
+	for i := 0; i < len(data); i++ {
+		bits.append(int(data[i]), 8)
+	}
+	capacity := 8 * v.dataCodewords()
+	term := capacity - len(bits)
+	if term > 4 {
+		term = 4
+	}
+	bits.append(0, term)
+	bits.append(0, (8-len(bits)%8)%8)
+	for pad := 0xec; len(bits) < capacity; pad ^= 0xec ^ 0x11 {
+		bits.append(pad, 8)
+	}
+
+	m := newQRMatrix(version)
+	m.drawFunctionPatterns(v)
+	m.drawCodewords(qrInterleave(bits.bytes(), v))
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		m.applyMask(mask)
+		m.drawFormat(mask)
+		if p := m.penalty(); bestPenalty < 0 || p < bestPenalty {
+			best, bestPenalty = mask, p
+		}
+		m.applyMask(mask) // masking is its own inverse
+	}
+	m.applyMask(best)
+	m.drawFormat(best)
+	return &QRCode{Version: version, Size: m.size, dark: m.dark}, nil
+}
+
+// qrBits is a bit stream, most significant bit first.
+type qrBits []bool
+
+func (b *qrBits) append(v, n int) {
+	for i := n - 1; i >= 0; i-- {
+		*b = append(*b, v>>uint(i)&1 == 1)
+	}
+}
+
+func (b qrBits) bytes() []byte {
+	out := make([]byte, len(b)/8)
+	for i, bit := range b {
+		if bit {
+			out[i/8] |= 0x80 >> uint(i%8)
+		}
+	}
+	return out
+}
+
+// qrInterleave splits data into blocks, appends their error correction codewords, and
+// interleaves the result in transmission order. Shorter blocks come first.
+func qrInterleave(data []byte, v qrVersion) []byte {
+	shortLen := v.total / v.blocks
+	numShort := v.blocks - v.total%v.blocks
+	gen := rsGenerator(v.ecPerBlock)
+	blocks := make([][]byte, v.blocks)
+	k := 0
+	for i := range blocks {
+		n := shortLen - v.ecPerBlock
+		if i >= numShort {
+			n++
+		}
+		block := append([]byte(nil), data[k:k+n]...)
+		k += n
+		ec := rsRemainder(block, gen)
+		if i < numShort {
+			block = append(block, 0) // placeholder so every block has the same length
+		}
+		blocks[i] = append(block, ec...)
+	}
+	out := make([]byte, 0, v.total)
+	for i := 0; i <= shortLen; i++ {
+		for j, block := range blocks {
+			// Skip the placeholders of the short blocks.
+			if i != shortLen-v.ecPerBlock || j >= numShort {
+				out = append(out, block[i])
+			}
+		}
+	}
+	return out
+}
+
+// gfMul multiplies in GF(2^8) modulo the QR polynomial x^8+x^4+x^3+x^2+1.
+func gfMul(x, y byte) byte {
+	var z byte
+	for i := 7; i >= 0; i-- {
+		hi := z & 0x80
+		z <<= 1
+		if hi != 0 {
+			z ^= 0x1d
+		}
+		if y>>uint(i)&1 == 1 {
+			z ^= x
+		}
+	}
+	return z
+}
+
+// rsGenerator returns the coefficients, highest power first and the leading 1 omitted, of the
+// Reed-Solomon generator polynomial of the given degree.
+func rsGenerator(degree int) []byte {
+	gen := make([]byte, degree)
+	gen[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range gen {
+			gen[j] = gfMul(gen[j], root)
+			if j+1 < len(gen) {
+				gen[j] ^= gen[j+1]
+			}
+		}
+		root = gfMul(root, 0x02)
+	}
+	return gen
+}
+
+// rsRemainder returns the error correction codewords of data.
+func rsRemainder(data, gen []byte) []byte {
+	rem := make([]byte, len(gen))
+	for _, b := range data {
+		factor := b ^ rem[0]
+		copy(rem, rem[1:])
+		rem[len(rem)-1] = 0
+		for i, g := range gen {
+			rem[i] ^= gfMul(g, factor)
+		}
+	}
+	return rem
+}
+
+// qrMatrix is a symbol under construction.
+type qrMatrix struct {
+	version int
+	size    int
+	dark    []bool
+	// function marks finder, timing, alignment, format, and version modules, which hold no
+	// data and are not masked.
+	function []bool
+}
+
+func newQRMatrix(version int) *qrMatrix {
+	size := 17 + 4*version
+	return &qrMatrix{version: version, size: size, dark: make([]bool, size*size), function: make([]bool, size*size)}
+}
+
+func (m *qrMatrix) setFunction(x, y int, dark bool) {
+	m.dark[y*m.size+x] = dark
+	m.function[y*m.size+x] = true
+}
+
+func (m *qrMatrix) drawFunctionPatterns(v qrVersion) {
+	for i := 0; i < m.size; i++ {
+		m.setFunction(6, i, i%2 == 0)
+		m.setFunction(i, 6, i%2 == 0)
+	}
+	m.drawFinder(3, 3)
+	m.drawFinder(m.size-4, 3)
+	m.drawFinder(3, m.size-4)
+	last := len(v.align) - 1
+	for i, ay := range v.align {
+		for j, ax := range v.align {
+			// The corners overlapping the finder patterns have no alignment pattern.
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					m.setFunction(ax+dx, ay+dy, maxInt(abs(dx), abs(dy)) != 1)
+				}
+			}
+		}
+	}
+	m.drawFormat(0) // reserve the area; the real bits are drawn once the mask is chosen
+	m.drawVersion()
+}
+
+// drawFinder draws a finder pattern centred at (cx, cy) with its separator.
+func (m *qrMatrix) drawFinder(cx, cy int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			x, y := cx+dx, cy+dy
+			if x < 0 || y < 0 || x >= m.size || y >= m.size {
+				continue
+			}
+			d := maxInt(abs(dx), abs(dy))
+			m.setFunction(x, y, d != 2 && d != 4)
+		}
+	}
+}
+
+// drawFormat writes both copies of the format information for level M and mask, and the
+// dark module.
+func (m *qrMatrix) drawFormat(mask int) {
+	data := mask // level M is 00
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = rem<<1 ^ (rem>>9)*0x537
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+	bit := func(i int) bool { return bits>>uint(i)&1 == 1 }
+	for i := 0; i <= 5; i++ {
+		m.setFunction(8, i, bit(i))
+	}
+	m.setFunction(8, 7, bit(6))
+	m.setFunction(8, 8, bit(7))
+	m.setFunction(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		m.setFunction(14-i, 8, bit(i))
+	}
+	for i := 0; i < 8; i++ {
+		m.setFunction(m.size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		m.setFunction(8, m.size-15+i, bit(i))
+	}
+	m.setFunction(8, m.size-8, true)
+}
+
+// drawVersion writes the two copies of the version information, present from version 7.
+func (m *qrMatrix) drawVersion() {
+	if m.version < 7 {
+		return
+	}
+	rem := m.version
+	for i := 0; i < 12; i++ {
+		rem = rem<<1 ^ (rem>>11)*0x1f25
+	}
+	bits := m.version<<12 | rem
+	for i := 0; i < 18; i++ {
+		dark := bits>>uint(i)&1 == 1
+		a, b := m.size-11+i%3, i/3
+		m.setFunction(a, b, dark)
+		m.setFunction(b, a, dark)
+	}
+}
+
+// drawCodewords places data in the zigzag order, two columns at a time from the bottom right,
+// skipping function modules. Leftover modules (remainder bits) stay light.
+func (m *qrMatrix) drawCodewords(data []byte) {
+	i := 0
+	for right := m.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5 // the vertical timing pattern
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < m.size; vert++ {
+			y := vert
+			if upward {
+				y = m.size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				x := right - j
+				if m.function[y*m.size+x] || i >= len(data)*8 {
+					continue
+				}
+				m.dark[y*m.size+x] = data[i/8]>>uint(7-i%8)&1 == 1
+				i++
+			}
+		}
+	}
+}
+
+// applyMask flips the data modules selected by mask pattern 0 to 7.
+func (m *qrMatrix) applyMask(mask int) {
+	for y := 0; y < m.size; y++ {
+		for x := 0; x < m.size; x++ {
+			var flip bool
+			switch mask {
+			case 0:
+				flip = (x+y)%2 == 0
+			case 1:
+				flip = y%2 == 0
+			case 2:
+				flip = x%3 == 0
+			case 3:
+				flip = (x+y)%3 == 0
+			case 4:
+				flip = (x/3+y/2)%2 == 0
+			case 5:
+				flip = x*y%2+x*y%3 == 0
+			case 6:
+				flip = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				flip = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if flip && !m.function[y*m.size+x] {
+				m.dark[y*m.size+x] = !m.dark[y*m.size+x]
+			}
+		}
+	}
+}
+
+// penalty scores the symbol with the four rules of the standard; lower is better.
+func (m *qrMatrix) penalty() int {
+	at := func(x, y int) bool { return m.dark[y*m.size+x] }
+	score := 0
+	var rows, cols strings.Builder
+	for a := 0; a < m.size; a++ {
+		rows.Reset()
+		cols.Reset()
+		rowRun, colRun := 1, 1
+		for b := 0; b < m.size; b++ {
+			rows.WriteByte(qrDigit(at(b, a)))
+			cols.WriteByte(qrDigit(at(a, b)))
+			if b == 0 {
+				continue
+			}
+			// Rule 1: runs of five or more modules of one color.
+			if at(b, a) == at(b-1, a) {
+				rowRun++
+			} else {
+				rowRun = 1
+			}
+			if rowRun == 5 {
+				score += 3
+			} else if rowRun > 5 {
+				score++
+			}
+			if at(a, b) == at(a, b-1) {
+				colRun++
+			} else {
+				colRun = 1
+			}
+			if colRun == 5 {
+				score += 3
+			} else if colRun > 5 {
+				score++
+			}
+		}
+		// Rule 3: finder-like 1:1:3:1:1 patterns next to four light modules.
+		for _, line := range []string{rows.String(), cols.String()} {
+			for _, pat := range []string{"10111010000", "00001011101"} {
+				for i := 0; i+len(pat) <= len(line); i++ {
+					if line[i:i+len(pat)] == pat {
+						score += 40
+					}
+				}
+			}
+		}
+	}
+	dark := 0
+	for y := 0; y < m.size; y++ {
+		for x := 0; x < m.size; x++ {
+			if at(x, y) {
+				dark++
+			}
+			// Rule 2: 2x2 blocks of one color.
+			if x > 0 && y > 0 && at(x, y) == at(x-1, y) && at(x, y) == at(x, y-1) && at(x, y) == at(x-1, y-1) {
+				score += 3
+			}
+		}
+	}
+	// Rule 4: 10 points per 5% the dark share departs from half.
+	total := m.size * m.size
+	score += abs(dark*100/total-50) / 5 * 10
+	return score
+}
+
+func qrDigit(dark bool) byte {
+	if dark {
+		return '1'
+	}
+	return '0'
+}
+
+// QRCorner names the corner of the screen OverlayQR draws in.
+type QRCorner string
+
+const (
+	// QRTopLeft places the code in the top-left corner.
+	QRTopLeft QRCorner = "top-left"
+	// QRTopRight places the code in the top-right corner.
+	QRTopRight QRCorner = "top-right"
+	// QRBottomLeft places the code in the bottom-left corner.
+	QRBottomLeft QRCorner = "bottom-left"
+	// QRBottomRight places the code in the bottom-right corner.
+	QRBottomRight QRCorner = "bottom-right"
+)
+
+// minQRScale is the smallest module size OverlayQR draws; single-pixel modules do not scan
+// reliably off the panel.
+const minQRScale = 2
+
+// OverlayQR draws a QR code of data, quiet zone included, flush into corner of img and returns
+// the rectangle it covers. The code is drawn at the largest whole number of pixels per module
+// that fits within size pixels; data that needs more room than size allows is drawn at two
+// pixels per module instead, so the result may be larger than size. It fails when the code
+// does not fit on img at all or data is too long for EncodeQR.
+func OverlayQR(img *image.Gray, data string, corner QRCorner, size int) (image.Rectangle, error) {
+	q, err := EncodeQR(data)
+	if err != nil {
+		return image.Rectangle{}, err
+	}
+	scale := size / q.PixelSize(1)
+	if scale < minQRScale {
+		scale = minQRScale
+	}
+	n := q.PixelSize(scale)
+	b := img.Bounds()
+	if n > b.Dx() || n > b.Dy() {
+		return image.Rectangle{}, fmt.Errorf("quote0: a QR code of %d bytes needs %dx%d pixels, more than the %dx%d image", len(data), n, n, b.Dx(), b.Dy())
+	}
+	var at image.Point
+	switch corner {
+	case QRTopLeft:
+		at = b.Min
+	case QRTopRight:
+		at = image.Pt(b.Max.X-n, b.Min.Y)
+	case QRBottomLeft:
+		at = image.Pt(b.Min.X, b.Max.Y-n)
+	case QRBottomRight:
+		at = b.Max.Sub(image.Pt(n, n))
+	default:
+		return image.Rectangle{}, fmt.Errorf("quote0: unknown QR corner %q (want %s, %s, %s, or %s)", corner, QRTopLeft, QRTopRight, QRBottomLeft, QRBottomRight)
+	}
+	c := &Canvas{img: img}
+	return c.DrawQR(at.X, at.Y, q, scale), nil
+}
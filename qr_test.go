@@ -0,0 +1,98 @@
+package quote0
+
+import (
+	"errors"
+	"image"
+	"image/draw"
+	"strings"
+	"testing"
+)
+
+func TestEncodeQR_Versions(t *testing.T) {
+	cases := []struct {
+		n, version int
+	}{{1, 1}, {14, 1}, {15, 2}, {62, 4}, {63, 5}, {MaxQRBytes, 10}}
+	for _, tc := range cases {
+		q, err := EncodeQR(strings.Repeat("a", tc.n))
+		if err != nil {
+			t.Fatalf("%d bytes: %v", tc.n, err)
+		}
+		if q.Version != tc.version || q.Size != 17+4*tc.version {
+			t.Errorf("%d bytes: version %d size %d, want version %d", tc.n, q.Version, q.Size, tc.version)
+		}
+	}
+	if _, err := EncodeQR(strings.Repeat("a", MaxQRBytes+1)); !errors.Is(err, ErrQRTooLong) {
+		t.Fatalf("too long: %v", err)
+	}
+}
+
+func TestEncodeQR_FunctionPatterns(t *testing.T) {
+	q, err := EncodeQR("https://example.com/dashboards/42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Each finder has a dark ring, a light ring, and a dark 3x3 centre.
+	for _, c := range []image.Point{{3, 3}, {q.Size - 4, 3}, {3, q.Size - 4}} {
+		for d := -3; d <= 3; d++ {
+			want := abs(d) != 2
+			if q.Black(c.X+d, c.Y) != want || q.Black(c.X, c.Y+d) != want {
+				t.Fatalf("finder at %v broken at offset %d", c, d)
+			}
+		}
+	}
+	for i := 8; i < q.Size-8; i++ {
+		if q.Black(i, 6) != (i%2 == 0) || q.Black(6, i) != (i%2 == 0) {
+			t.Fatalf("timing pattern broken at %d", i)
+		}
+	}
+	if !q.Black(8, q.Size-8) {
+		t.Fatal("dark module missing")
+	}
+	if q.Black(-1, 0) || q.Black(q.Size, 0) {
+		t.Fatal("outside the symbol should be light")
+	}
+}
+
+func TestOverlayQR_Golden(t *testing.T) {
+	c := NewCanvas()
+	c.FillRect(c.Bounds(), Black)
+	r, err := OverlayQR(c.Image(), "https://grafana.local/d/x", QRBottomRight, 58)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 25 bytes need version 2: 25 modules plus the quiet zone, at 2 pixels each.
+	if want := image.Rect(ScreenWidth-66, ScreenHeight-66, ScreenWidth, ScreenHeight); r != want {
+		t.Fatalf("rect %v, want %v", r, want)
+	}
+	// The golden includes a strip of the untouched background around the code.
+	region := image.Rect(ScreenWidth-80, ScreenHeight-80, ScreenWidth, ScreenHeight)
+	corner := image.NewGray(image.Rect(0, 0, region.Dx(), region.Dy()))
+	draw.Draw(corner, corner.Bounds(), c.Image(), region.Min, draw.Src)
+	assertGolden(t, "qr_overlay_bottom_right", corner)
+}
+
+func TestOverlayQR_SizeAndCorners(t *testing.T) {
+	img := NewCanvas().Image()
+	// A version 1 code fits 58 pixels at 2 pixels per module, and 87 at 3.
+	for _, tc := range []struct {
+		corner QRCorner
+		size   int
+		want   image.Rectangle
+	}{
+		{QRTopLeft, 58, image.Rect(0, 0, 58, 58)},
+		{QRTopRight, 90, image.Rect(ScreenWidth-87, 0, ScreenWidth, 87)},
+		{QRBottomLeft, 10, image.Rect(0, ScreenHeight-58, 58, ScreenHeight)},
+	} {
+		r, err := OverlayQR(img, "x", tc.corner, tc.size)
+		if err != nil || r != tc.want {
+			t.Errorf("%s size %d: %v %v, want %v", tc.corner, tc.size, r, err, tc.want)
+		}
+	}
+	if _, err := OverlayQR(img, "x", "middle", 58); err == nil {
+		t.Fatal("unknown corner accepted")
+	}
+	small := image.NewGray(image.Rect(0, 0, 40, 40))
+	if _, err := OverlayQR(small, "x", QRTopLeft, 58); err == nil {
+		t.Fatal("code larger than the image accepted")
+	}
+}
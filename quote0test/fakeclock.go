@@ -0,0 +1,121 @@
+package quote0test
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// FakeClock is a manually advanced clock that satisfies quote0.Clock. Sleepers block until
+// Advance moves the time past their deadline, so tests of rate limiting, backoff, and
+// cooldowns run without real waits:
+//
+//	clk := quote0test.NewFakeClock(time.Now())
+//	c, _ := quote0.NewClient(key, quote0.WithClock(clk))
+//	go c.SendText(ctx, req)
+//	clk.BlockUntil(1)
+//	clk.Advance(time.Second)
+//
+// It is safe for concurrent use.
+type FakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []*fakeWaiter
+	// changed is closed and replaced whenever the set of waiters grows.
+	changed chan struct{}
+}
+
+type fakeWaiter struct {
+	until time.Time
+	done  chan struct{}
+}
+
+// NewFakeClock returns a FakeClock reading start.
+func NewFakeClock(start time.Time) *FakeClock {
+	return &FakeClock{now: start, changed: make(chan struct{})}
+}
+
+// Now returns the fake time.
+func (f *FakeClock) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// Sleep blocks until Advance reaches now+d or ctx ends. A non-positive d returns at once
+// unless ctx has already ended.
+func (f *FakeClock) Sleep(ctx context.Context, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if d <= 0 {
+		return nil
+	}
+	f.mu.Lock()
+	w := &fakeWaiter{until: f.now.Add(d), done: make(chan struct{})}
+	f.waiters = append(f.waiters, w)
+	close(f.changed)
+	f.changed = make(chan struct{})
+	f.mu.Unlock()
+
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		f.mu.Lock()
+		f.remove(w)
+		f.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// Advance moves the time forward by d and wakes every sleeper whose deadline has passed.
+func (f *FakeClock) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+	kept := f.waiters[:0]
+	for _, w := range f.waiters {
+		if w.until.After(f.now) {
+			kept = append(kept, w)
+			continue
+		}
+		close(w.done)
+	}
+	for i := len(kept); i < len(f.waiters); i++ {
+		f.waiters[i] = nil
+	}
+	f.waiters = kept
+}
+
+// BlockUntil waits until at least n goroutines are sleeping on the clock, so a test can
+// Advance only after the code under test has started its wait.
+func (f *FakeClock) BlockUntil(n int) {
+	for {
+		f.mu.Lock()
+		if len(f.waiters) >= n {
+			f.mu.Unlock()
+			return
+		}
+		changed := f.changed
+		f.mu.Unlock()
+		<-changed
+	}
+}
+
+// Sleepers returns the number of goroutines sleeping on the clock.
+func (f *FakeClock) Sleepers() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.waiters)
+}
+
+// remove drops w from the waiters; f.mu must be held.
+func (f *FakeClock) remove(w *fakeWaiter) {
+	for i, x := range f.waiters {
+		if x == w {
+			f.waiters = append(f.waiters[:i], f.waiters[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package quote0test
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2025, 11, 10, 9, 0, 0, 0, time.UTC)
+	clk := NewFakeClock(start)
+	if err := clk.Sleep(context.Background(), 0); err != nil {
+		t.Fatalf("Sleep(0) = %v", err)
+	}
+
+	done := make(chan error, 2)
+	go func() { done <- clk.Sleep(context.Background(), time.Second) }()
+	go func() { done <- clk.Sleep(context.Background(), time.Minute) }()
+	clk.BlockUntil(2)
+
+	clk.Advance(999 * time.Millisecond)
+	if n := clk.Sleepers(); n != 2 {
+		t.Fatalf("sleepers = %d before the deadline, want 2", n)
+	}
+	clk.Advance(time.Millisecond)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if n := clk.Sleepers(); n != 1 {
+		t.Fatalf("sleepers = %d, want 1", n)
+	}
+	clk.Advance(time.Hour)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if got, want := clk.Now(), start.Add(time.Hour+time.Second); !got.Equal(want) {
+		t.Fatalf("Now() = %v, want %v", got, want)
+	}
+}
+
+func TestFakeClock_SleepCanceled(t *testing.T) {
+	clk := NewFakeClock(time.Unix(0, 0))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- clk.Sleep(ctx, time.Second) }()
+	clk.BlockUntil(1)
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("Sleep = %v, want context.Canceled", err)
+	}
+	if n := clk.Sleepers(); n != 0 {
+		t.Fatalf("canceled sleeper still counted: %d", n)
+	}
+	if err := clk.Sleep(ctx, time.Second); err != context.Canceled {
+		t.Fatalf("Sleep on ended ctx = %v", err)
+	}
+}
@@ -0,0 +1,158 @@
+// Package quote0test provides test helpers for code that renders Quote/0 frames: image
+// comparison with a pixel tolerance, golden PNG files that -update rewrites, and diff images
+// that show where two renders disagree.
+//
+//	func TestBadge(t *testing.T) {
+//		img := renderBadge()
+//		quote0test.AssertGolden(t, "testdata/badge.png", img, 0)
+//	}
+//
+// Run `go test -update` after an intended rendering change to rewrite the golden files.
+//
+// FakeClock is a manually advanced clock for quote0.WithClock, so rate limiting and retry
+// backoff can be tested without real waits.
+package quote0test
+
+import (
+	"flag"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Update is the -update flag: AssertGolden writes the rendered image instead of comparing it.
+var Update = flag.Bool("update", false, "rewrite golden images instead of comparing against them")
+
+// Colors of DiffImage: mismatches are red, matching pixels a faded copy of want.
+var (
+	diffMismatch = color.RGBA{R: 0xff, A: 0xff}
+	diffFade     = 0xc0
+)
+
+// CountDiff returns the number of pixels whose gray levels differ between got and want, which
+// for the 1-bit frames the panel shows is the number of flipped pixels. Images of different
+// sizes are compared over their overlap, aligned at their top-left corners.
+func CountDiff(got, want image.Image) int {
+	gb, wb := got.Bounds(), want.Bounds()
+	w, h := minInt(gb.Dx(), wb.Dx()), minInt(gb.Dy(), wb.Dy())
+	n := 0
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if grayAt(got, gb.Min.X+x, gb.Min.Y+y) != grayAt(want, wb.Min.X+x, wb.Min.Y+y) {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// DiffImage returns an image the size of want with the pixels that differ in got drawn red
+// over a faded copy of want. Pixels outside got count as different.
+func DiffImage(got, want image.Image) *image.RGBA {
+	gb, wb := got.Bounds(), want.Bounds()
+	out := image.NewRGBA(image.Rect(0, 0, wb.Dx(), wb.Dy()))
+	for y := 0; y < wb.Dy(); y++ {
+		for x := 0; x < wb.Dx(); x++ {
+			v := grayAt(want, wb.Min.X+x, wb.Min.Y+y)
+			gx, gy := gb.Min.X+x, gb.Min.Y+y
+			if !image.Pt(gx, gy).In(gb) || grayAt(got, gx, gy) != v {
+				out.SetRGBA(x, y, diffMismatch)
+				continue
+			}
+			f := uint8(diffFade + int(v)*(0xff-diffFade)/0xff)
+			out.SetRGBA(x, y, color.RGBA{R: f, G: f, B: f, A: 0xff})
+		}
+	}
+	return out
+}
+
+// WriteDiff writes DiffImage(got, want) as a PNG to path, creating its directory.
+func WriteDiff(path string, got, want image.Image) error {
+	return writePNG(path, DiffImage(got, want))
+}
+
+// AssertImagesEqual fails t unless got and want have the same size and at most tolerance (a
+// fraction from 0 to 1) of their pixels differ; 0 requires identical gray levels. On a
+// mismatch the diff image is written to the temporary directory and its path logged.
+func AssertImagesEqual(t testing.TB, got, want image.Image, tolerance float64) {
+	t.Helper()
+	gb, wb := got.Bounds(), want.Bounds()
+	if gb.Dx() != wb.Dx() || gb.Dy() != wb.Dy() {
+		t.Fatalf("image size %dx%d, want %dx%d", gb.Dx(), gb.Dy(), wb.Dx(), wb.Dy())
+	}
+	diff := CountDiff(got, want)
+	total := wb.Dx() * wb.Dy()
+	if diff == 0 || float64(diff) <= tolerance*float64(total) {
+		return
+	}
+	path := filepath.Join(os.TempDir(), "quote0test", strings.NewReplacer("/", "_", " ", "_").Replace(t.Name())+".diff.png")
+	if err := WriteDiff(path, got, want); err != nil {
+		t.Logf("write diff image: %v", err)
+	} else {
+		t.Logf("diff image (mismatches in red): %s", path)
+	}
+	t.Fatalf("%d of %d pixels differ (%.2f%%, tolerance %.2f%%)", diff, total, 100*float64(diff)/float64(total), 100*tolerance)
+}
+
+// LoadGolden decodes the PNG at path, failing t with a hint to run -update when it is missing.
+func LoadGolden(t testing.TB, path string) image.Image {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open golden (run with -update to create): %v", err)
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		t.Fatalf("decode golden %s: %v", path, err)
+	}
+	return img
+}
+
+// AssertGolden compares got with the golden PNG at path using AssertImagesEqual. With -update
+// it writes got to path instead, creating the directory.
+func AssertGolden(t testing.TB, path string, got image.Image, tolerance float64) {
+	t.Helper()
+	if *Update {
+		if err := writePNG(path, got); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want := LoadGolden(t, path)
+	gb, wb := got.Bounds(), want.Bounds()
+	if gb.Dx() != wb.Dx() || gb.Dy() != wb.Dy() {
+		t.Fatalf("%s: image size %dx%d, golden %dx%d", path, gb.Dx(), gb.Dy(), wb.Dx(), wb.Dy())
+	}
+	AssertImagesEqual(t, got, want, tolerance)
+}
+
+func writePNG(path string, img image.Image) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func grayAt(img image.Image, x, y int) uint8 {
+	return color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,120 @@
+package quote0test
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// recorder captures the failure of an assertion run against it instead of the real test.
+type recorder struct {
+	testing.TB
+	failed bool
+	msg    string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Logf(format string, args ...interface{}) {}
+
+func (r *recorder) Fatal(args ...interface{}) { r.Fatalf("%s", fmt.Sprint(args...)) }
+
+func (r *recorder) Fatalf(format string, args ...interface{}) {
+	r.failed, r.msg = true, fmt.Sprintf(format, args...)
+	runtime.Goexit()
+}
+
+// run calls fn with a recorder on its own goroutine, so Fatalf can stop it.
+func run(t *testing.T, fn func(tb testing.TB)) *recorder {
+	r := &recorder{TB: t}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn(r)
+	}()
+	<-done
+	return r
+}
+
+// frame returns a w x h white image with the first n pixels black.
+func frame(w, h, n int) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+		if i < n {
+			img.Pix[i] = 0
+		}
+	}
+	return img
+}
+
+func TestAssertImagesEqual(t *testing.T) {
+	want := frame(10, 10, 0)
+	if r := run(t, func(tb testing.TB) { AssertImagesEqual(tb, frame(10, 10, 0), want, 0) }); r.failed {
+		t.Fatalf("equal images failed: %s", r.msg)
+	}
+	if r := run(t, func(tb testing.TB) { AssertImagesEqual(tb, frame(10, 10, 5), want, 0.05) }); r.failed {
+		t.Fatalf("5%% within tolerance failed: %s", r.msg)
+	}
+	r := run(t, func(tb testing.TB) { AssertImagesEqual(tb, frame(10, 10, 6), want, 0.05) })
+	if !r.failed || !strings.Contains(r.msg, "6 of 100 pixels differ") {
+		t.Fatalf("over tolerance: %v %q", r.failed, r.msg)
+	}
+	r = run(t, func(tb testing.TB) { AssertImagesEqual(tb, frame(12, 8, 0), want, 1) })
+	if !r.failed || r.msg != "image size 12x8, want 10x10" {
+		t.Fatalf("size mismatch: %v %q", r.failed, r.msg)
+	}
+}
+
+func TestCountDiffAndDiffImage(t *testing.T) {
+	got, want := frame(4, 2, 3), frame(4, 2, 1)
+	// Offset bounds are aligned at their top-left corners.
+	shifted := image.NewGray(image.Rect(5, 5, 9, 7))
+	copy(shifted.Pix, got.Pix)
+	if n := CountDiff(shifted, want); n != 2 {
+		t.Fatalf("diff %d", n)
+	}
+	d := DiffImage(got, want)
+	red := color.RGBA{R: 0xff, A: 0xff}
+	if d.RGBAAt(0, 0) == red || d.RGBAAt(1, 0) != red || d.RGBAAt(2, 0) != red || d.RGBAAt(3, 0) == red {
+		t.Fatalf("diff image row %v", d.Pix[:16])
+	}
+	if d.RGBAAt(0, 0).R >= d.RGBAAt(3, 0).R {
+		t.Fatal("black pixels should stay darker than white ones")
+	}
+	if small := DiffImage(frame(2, 2, 0), want); small.RGBAAt(3, 1) != red {
+		t.Fatal("pixels outside got should be marked")
+	}
+	path := filepath.Join(t.TempDir(), "out", "diff.png")
+	if err := WriteDiff(path, got, want); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAssertGolden(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "golden", "frame.png")
+	r := run(t, func(tb testing.TB) { AssertGolden(tb, path, frame(10, 10, 0), 0) })
+	if !r.failed || !strings.Contains(r.msg, "-update") {
+		t.Fatalf("missing golden: %v %q", r.failed, r.msg)
+	}
+
+	*Update = true
+	AssertGolden(t, path, frame(10, 10, 3), 0)
+	*Update = false
+	AssertGolden(t, path, frame(10, 10, 3), 0)
+	if img := LoadGolden(t, path); CountDiff(img, frame(10, 10, 3)) != 0 {
+		t.Fatal("golden does not round-trip")
+	}
+	r = run(t, func(tb testing.TB) { AssertGolden(tb, path, frame(10, 5, 3), 0) })
+	if !r.failed || !strings.Contains(r.msg, "image size 10x5, golden 10x10") {
+		t.Fatalf("size mismatch: %v %q", r.failed, r.msg)
+	}
+}
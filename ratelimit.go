@@ -12,6 +12,13 @@ type RateLimiter interface {
 	Wait(ctx context.Context) error
 }
 
+// KeyedRateLimiter is a RateLimiter that can tell callers apart. The client calls WaitKey
+// with the resolved device ID instead of Wait when its limiter implements this interface.
+type KeyedRateLimiter interface {
+	RateLimiter
+	WaitKey(ctx context.Context, key string) error
+}
+
 // RateLimiterFunc adapts a function into a RateLimiter.
 type RateLimiterFunc func(ctx context.Context) error
 
@@ -27,47 +34,293 @@ func (f RateLimiterFunc) Wait(ctx context.Context) error {
 // a minimum interval between requests. It is intentionally lightweight (mutex+timer)
 // so it can run inside tiny IoT gateways or embedded controllers without extra deps.
 // Use this for the official 1 QPS policy by passing time.Second, or customize as needed.
+// The result is a *FixedIntervalLimiter; use NewIntervalLimiter to get that type directly.
 func NewFixedIntervalLimiter(interval time.Duration) RateLimiter {
+	return NewIntervalLimiter(interval)
+}
+
+// NewIntervalLimiter is NewFixedIntervalLimiter returning the concrete limiter, whose
+// interval can be changed, and which can be paused, while it is in use.
+func NewIntervalLimiter(interval time.Duration) *FixedIntervalLimiter {
 	if interval <= 0 {
 		interval = time.Second
 	}
-	return &fixedIntervalLimiter{minInterval: interval}
+	return &FixedIntervalLimiter{minInterval: interval}
 }
 
-// fixedIntervalLimiter enforces a fixed minimum time interval between consecutive API calls.
+// FixedIntervalLimiter enforces a fixed minimum time interval between consecutive API calls.
 // It tracks the next allowed request time and blocks callers until that time arrives.
-type fixedIntervalLimiter struct {
+type FixedIntervalLimiter struct {
 	mu          sync.Mutex
 	next        time.Time
 	minInterval time.Duration
+	// resumed is non-nil while paused and closed by Resume.
+	resumed chan struct{}
+	// epoch counts Resume calls; slots reserved before one are void and taken again.
+	epoch uint64
+	// last is the slot of the latest call let through, and floor the earliest next slot set
+	// by Penalize or Reserve; Resume rebuilds the schedule from them.
+	last, floor time.Time
+
+	// clk is the time source; nil means the real clock.
+	clk Clock
 }
 
 // Wait blocks the caller until the rate limit allows the next request.
 // It respects context cancellation and returns ctx.Err() if the context is canceled before the wait completes.
-func (l *fixedIntervalLimiter) Wait(ctx context.Context) error {
+// While the limiter is paused, Wait blocks until Resume. Resume voids the turns handed out
+// before it, so callers whose turn came during the pause, and those still waiting for theirs,
+// queue again, each for one turn, one interval after the last call let through.
+func (l *FixedIntervalLimiter) Wait(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+		if resumed := l.resumed; resumed != nil {
+			l.mu.Unlock()
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-resumed:
+				continue
+			}
+		}
+		now := l.clock()
+		wait := time.Duration(0)
+		start := now
+		if !l.next.IsZero() && now.Before(l.next) {
+			wait = l.next.Sub(now)
+			start = l.next
+		}
+		l.next = start.Add(l.minInterval)
+		epoch := l.epoch
+		l.mu.Unlock()
+
+		if wait > 0 {
+			if err := l.sleep(ctx, wait); err != nil {
+				// If the caller's context is canceled, propagate that error so API calls stop immediately.
+				return err
+			}
+		}
+		l.mu.Lock()
+		granted := l.resumed == nil && l.epoch == epoch
+		if granted && start.After(l.last) {
+			l.last = start
+		}
+		l.mu.Unlock()
+		if granted {
+			// Caller may proceed with the actual HTTP request.
+			return nil
+		}
+	}
+}
+
+// SetInterval changes the minimum interval for waits that have not been scheduled yet; callers
+// already waiting keep their turn. The gap after the last scheduled call is adjusted too, so
+// a shorter interval applies at once. Non-positive values are ignored.
+func (l *FixedIntervalLimiter) SetInterval(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.next.IsZero() {
+		l.next = l.next.Add(d - l.minInterval)
+	}
+	l.minInterval = d
+}
+
+// Interval returns the current minimum interval.
+func (l *FixedIntervalLimiter) Interval() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.minInterval
+}
+
+// Pause holds every Wait until Resume or the caller's context ends. Pausing twice is the
+// same as once.
+func (l *FixedIntervalLimiter) Pause() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.resumed == nil {
+		l.resumed = make(chan struct{})
+	}
+}
+
+// Resume releases the waits held by Pause; they then take their turns at the usual interval,
+// the first one interval after the last call let through (or after a Penalize cooldown).
+func (l *FixedIntervalLimiter) Resume() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.resumed == nil {
+		return
+	}
+	close(l.resumed)
+	l.resumed = nil
+	l.epoch++
+	next := l.floor
+	if !l.last.IsZero() && l.last.Add(l.minInterval).After(next) {
+		next = l.last.Add(l.minInterval)
+	}
+	l.next = next
+}
+
+// Paused reports whether the limiter is paused.
+func (l *FixedIntervalLimiter) Paused() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.resumed != nil
+}
+
+// SetClock makes the limiter read the time and sleep through clk, e.g. a fake clock in
+// tests; nil restores the real clock. Set it before the limiter is in use.
+func (l *FixedIntervalLimiter) SetClock(clk Clock) {
+	l.mu.Lock()
+	l.clk = clk
+	l.mu.Unlock()
+}
+
+// hasClock reports whether SetClock installed a clock.
+func (l *FixedIntervalLimiter) hasClock() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.clk != nil
+}
+
+func (l *FixedIntervalLimiter) clock() time.Time {
+	if l.clk == nil {
+		return time.Now()
+	}
+	return l.clk.Now()
+}
+
+// sleep waits d or until ctx ends.
+func (l *FixedIntervalLimiter) sleep(ctx context.Context, d time.Duration) error {
 	l.mu.Lock()
-	now := time.Now()
-	wait := time.Duration(0)
-	start := now
-	if !l.next.IsZero() && now.Before(l.next) {
-		wait = l.next.Sub(now)
-		start = l.next
-	}
-	l.next = start.Add(l.minInterval)
+	clk := l.clk
 	l.mu.Unlock()
+	if clk == nil {
+		clk = systemClock{}
+	}
+	return clk.Sleep(ctx, d)
+}
 
-	if wait <= 0 {
+// waitLimiter waits for the client's limiter, keyed by deviceID when the limiter supports it.
+func (c *Client) waitLimiter(ctx context.Context, deviceID string) error {
+	switch l := c.limiter.(type) {
+	case nil:
 		return nil
+	case KeyedRateLimiter:
+		return l.WaitKey(ctx, deviceID)
+	default:
+		return l.Wait(ctx)
 	}
-	// Use a one-shot timer so concurrent callers queue without busy-waiting.
-	timer := time.NewTimer(wait)
-	defer timer.Stop()
+}
+
+// NewFairLimiter wraps inner so that callers waiting under different keys (device IDs, when
+// used as the client's limiter) take turns: slots are granted round-robin across the keys with
+// waiting callers, and in arrival order within a key. A device with many queued sends therefore
+// cannot starve the others. Only one caller at a time waits on inner, so inner sets the pace;
+// a nil inner grants slots as fast as callers take them.
+func NewFairLimiter(inner RateLimiter) *FairLimiter {
+	return &FairLimiter{inner: inner, queues: make(map[string][]*fairWaiter)}
+}
+
+// FairLimiter is the round-robin limiter returned by NewFairLimiter.
+type FairLimiter struct {
+	inner RateLimiter
+
+	mu     sync.Mutex
+	queues map[string][]*fairWaiter
+	// ring lists the keys with waiting callers in the order they get their next turn.
+	ring []string
+	// busy is set while a granted caller waits on inner.
+	busy bool
+}
+
+type fairWaiter struct {
+	// granted is closed when the waiter's turn comes.
+	granted chan struct{}
+}
+
+// Wait waits under the empty key.
+func (l *FairLimiter) Wait(ctx context.Context) error {
+	return l.WaitKey(ctx, "")
+}
+
+// WaitKey blocks until it is key's turn and inner allows the call, or until ctx ends.
+func (l *FairLimiter) WaitKey(ctx context.Context, key string) error {
+	w := &fairWaiter{granted: make(chan struct{})}
+	l.mu.Lock()
+	if len(l.queues[key]) == 0 {
+		l.ring = append(l.ring, key)
+	}
+	l.queues[key] = append(l.queues[key], w)
+	l.dispatch()
+	l.mu.Unlock()
+
 	select {
+	case <-w.granted:
 	case <-ctx.Done():
-		// If the caller's context is canceled, propagate that error so API calls stop immediately.
+		l.mu.Lock()
+		select {
+		case <-w.granted:
+			// The turn arrived while giving up; pass it on.
+			l.busy = false
+			l.dispatch()
+		default:
+			l.remove(key, w)
+		}
+		l.mu.Unlock()
 		return ctx.Err()
-	case <-timer.C:
-		// Timer fired, caller may proceed with the actual HTTP request.
-		return nil
+	}
+
+	var err error
+	if l.inner != nil {
+		err = l.inner.Wait(ctx)
+	}
+	l.mu.Lock()
+	l.busy = false
+	l.dispatch()
+	l.mu.Unlock()
+	return err
+}
+
+// dispatch grants the next turn when no caller holds one. l.mu must be held.
+func (l *FairLimiter) dispatch() {
+	if l.busy || len(l.ring) == 0 {
+		return
+	}
+	key := l.ring[0]
+	l.ring = l.ring[1:]
+	q := l.queues[key]
+	w := q[0]
+	if len(q) == 1 {
+		delete(l.queues, key)
+	} else {
+		l.queues[key] = q[1:]
+		l.ring = append(l.ring, key)
+	}
+	l.busy = true
+	close(w.granted)
+}
+
+// remove drops a waiter that gave up before its turn. l.mu must be held.
+func (l *FairLimiter) remove(key string, w *fairWaiter) {
+	q := l.queues[key]
+	for i, other := range q {
+		if other == w {
+			q = append(q[:i:i], q[i+1:]...)
+			break
+		}
+	}
+	if len(q) > 0 {
+		l.queues[key] = q
+		return
+	}
+	delete(l.queues, key)
+	for i, k := range l.ring {
+		if k == key {
+			l.ring = append(l.ring[:i:i], l.ring[i+1:]...)
+			break
+		}
 	}
 }
@@ -0,0 +1,398 @@
+package quote0
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/1set/quote0/quote0test"
+)
+
+// fakeLimiterClock records every sleep the limiter asks for; the test fires them.
+type fakeLimiterClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	sleeps chan limiterSleep
+}
+
+type limiterSleep struct {
+	d    time.Duration
+	fire chan time.Time
+}
+
+func newFakeLimiter(interval time.Duration) (*FixedIntervalLimiter, *fakeLimiterClock) {
+	clock := &fakeLimiterClock{now: time.Unix(1000, 0), sleeps: make(chan limiterSleep, 16)}
+	l := NewIntervalLimiter(interval)
+	l.SetClock(clock)
+	return l, clock
+}
+
+func (f *fakeLimiterClock) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *fakeLimiterClock) Sleep(ctx context.Context, d time.Duration) error {
+	ch := make(chan time.Time, 1)
+	f.sleeps <- limiterSleep{d, ch}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-ch:
+		return nil
+	}
+}
+
+func (f *fakeLimiterClock) advance(d time.Duration) {
+	f.mu.Lock()
+	f.now = f.now.Add(d)
+	f.mu.Unlock()
+}
+
+// nextSleep returns the next sleep the limiter started.
+func (f *fakeLimiterClock) nextSleep(t *testing.T) limiterSleep {
+	t.Helper()
+	select {
+	case s := <-f.sleeps:
+		return s
+	case <-time.After(5 * time.Second):
+		t.Fatal("limiter did not sleep")
+		return limiterSleep{}
+	}
+}
+
+// waitAsync runs l.Wait in the background and returns its result channel.
+func waitAsync(ctx context.Context, l RateLimiter) chan error {
+	done := make(chan error, 1)
+	go func() { done <- l.Wait(ctx) }()
+	return done
+}
+
+func expectDone(t *testing.T, done chan error, want error) {
+	t.Helper()
+	select {
+	case err := <-done:
+		if err != want {
+			t.Fatalf("Wait returned %v, want %v", err, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Wait did not return")
+	}
+}
+
+func expectBlocked(t *testing.T, done chan error) {
+	t.Helper()
+	select {
+	case err := <-done:
+		t.Fatalf("Wait returned %v while it should block", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestFixedIntervalLimiter_SetInterval(t *testing.T) {
+	ctx := context.Background()
+	l, clock := newFakeLimiter(time.Second)
+	if err := l.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// The second call is scheduled one interval later; changing the interval does not move it.
+	second := waitAsync(ctx, l)
+	s := clock.nextSleep(t)
+	if s.d != time.Second {
+		t.Fatalf("second wait %s, want 1s", s.d)
+	}
+	l.SetInterval(10 * time.Second)
+	if l.Interval() != 10*time.Second {
+		t.Fatalf("Interval %s", l.Interval())
+	}
+	clock.advance(time.Second)
+	s.fire <- clock.now
+	expectDone(t, second, nil)
+
+	// The third call waits the new interval after the second.
+	third := waitAsync(ctx, l)
+	if s = clock.nextSleep(t); s.d != 10*time.Second {
+		t.Fatalf("third wait %s, want 10s", s.d)
+	}
+	clock.advance(10 * time.Second)
+	s.fire <- clock.now
+	expectDone(t, third, nil)
+
+	// Speeding up applies to the gap after the last call at once.
+	l.SetInterval(2 * time.Second)
+	fourth := waitAsync(ctx, l)
+	if s = clock.nextSleep(t); s.d != 2*time.Second {
+		t.Fatalf("fourth wait %s, want 2s", s.d)
+	}
+	s.fire <- clock.now
+	expectDone(t, fourth, nil)
+
+	l.SetInterval(0)
+	l.SetInterval(-time.Second)
+	if l.Interval() != 2*time.Second {
+		t.Fatalf("non-positive interval applied: %s", l.Interval())
+	}
+}
+
+func TestFixedIntervalLimiter_PauseResume(t *testing.T) {
+	l, clock := newFakeLimiter(time.Second)
+	l.Pause()
+	l.Pause()
+	if !l.Paused() {
+		t.Fatal("not paused")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	canceled := waitAsync(ctx, l)
+	held := waitAsync(context.Background(), l)
+	expectBlocked(t, held)
+	cancel()
+	expectDone(t, canceled, context.Canceled)
+
+	l.Resume()
+	expectDone(t, held, nil)
+	if l.Paused() {
+		t.Fatal("still paused")
+	}
+	l.Resume() // no-op
+
+	// A caller whose turn comes during a pause waits for Resume, then queues again; its
+	// first turn is spent, so it goes as soon as an interval has passed since the last call.
+	queued := waitAsync(context.Background(), l)
+	s := clock.nextSleep(t)
+	l.Pause()
+	clock.advance(s.d)
+	s.fire <- clock.now
+	expectBlocked(t, queued)
+	clock.advance(time.Second / 2)
+	l.Resume()
+	expectDone(t, queued, nil)
+}
+
+// blockUntil is clk.BlockUntil(n) that fails the test instead of hanging.
+func blockUntil(t *testing.T, clk *quote0test.FakeClock, n int) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() { clk.BlockUntil(n); close(done) }()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("%d sleepers, want %d", clk.Sleepers(), n)
+	}
+}
+
+func TestFixedIntervalLimiter_SpacingAfterResume(t *testing.T) {
+	clk := quote0test.NewFakeClock(time.Date(2025, 11, 10, 9, 0, 0, 0, time.UTC))
+	start := clk.Now()
+	l := NewIntervalLimiter(time.Second)
+	l.SetClock(clk)
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Three callers queue for the next three turns, which all come during a pause.
+	granted := make(chan time.Time, 3)
+	for i := 0; i < 3; i++ {
+		go func() {
+			if err := l.Wait(context.Background()); err == nil {
+				granted <- clk.Now()
+			}
+		}()
+	}
+	clk.BlockUntil(3)
+	l.Pause()
+	clk.Advance(5 * time.Second)
+	l.Resume()
+
+	// After Resume they go one interval apart, starting at once.
+	var got []time.Duration
+	for i := 0; i < 3; i++ {
+		if i > 0 {
+			blockUntil(t, clk, 1)
+			clk.Advance(time.Second)
+		}
+		select {
+		case at := <-granted:
+			got = append(got, at.Sub(start))
+		case <-time.After(5 * time.Second):
+			t.Fatalf("turn %d not granted; got %v", i, got)
+		}
+	}
+	want := []time.Duration{5 * time.Second, 6 * time.Second, 7 * time.Second}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("turns at %v, want %v", got, want)
+		}
+	}
+}
+
+func TestFixedIntervalLimiter_Concurrent(t *testing.T) {
+	l := NewIntervalLimiter(time.Microsecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				if err := l.Wait(ctx); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	for i := 0; i < 50; i++ {
+		l.SetInterval(time.Duration(i%3+1) * time.Microsecond)
+		if i%10 == 0 {
+			l.Pause()
+			_ = l.Paused()
+			l.Resume()
+		}
+		_ = l.Interval()
+	}
+	wg.Wait()
+}
+
+// waiting returns how many callers are queued for a turn.
+func (l *FairLimiter) waiting() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	n := 0
+	for _, q := range l.queues {
+		n += len(q)
+	}
+	return n
+}
+
+func waitQueued(t *testing.T, l *FairLimiter, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for l.waiting() != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d callers queued, want %d", l.waiting(), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestFairLimiter_RoundRobin(t *testing.T) {
+	tick := make(chan struct{})
+	l := NewFairLimiter(RateLimiterFunc(func(ctx context.Context) error {
+		<-tick
+		return nil
+	}))
+	var mu sync.Mutex
+	var order []string
+	var wg sync.WaitGroup
+	send := func(key string) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := l.WaitKey(context.Background(), key); err != nil {
+				t.Error(err)
+				return
+			}
+			mu.Lock()
+			order = append(order, key)
+			mu.Unlock()
+		}()
+	}
+	// The chatty device A queues first; the first call is granted at once and holds inner.
+	for i, key := range []string{"A", "A", "A", "A", "B", "B", "C", "C"} {
+		send(key)
+		waitQueued(t, l, i)
+	}
+	for i := 1; i <= 8; i++ {
+		tick <- struct{}{}
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			mu.Lock()
+			n := len(order)
+			mu.Unlock()
+			if n == i {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("grant %d did not happen", i)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	wg.Wait()
+	if got := strings.Join(order, ""); got != "AABCABCA" {
+		t.Fatalf("grant order %s, want AABCABCA", got)
+	}
+}
+
+func TestFairLimiter_Cancel(t *testing.T) {
+	tick := make(chan struct{})
+	entered := make(chan struct{}, 1)
+	l := NewFairLimiter(RateLimiterFunc(func(ctx context.Context) error {
+		entered <- struct{}{}
+		select {
+		case <-tick:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}))
+	first := make(chan error, 1)
+	go func() { first <- l.WaitKey(context.Background(), "A") }()
+	<-entered
+	ctx, cancel := context.WithCancel(context.Background())
+	canceled := make(chan error, 1)
+	go func() { canceled <- l.WaitKey(ctx, "B") }()
+	waitQueued(t, l, 1)
+	last := make(chan error, 1)
+	go func() { last <- l.WaitKey(context.Background(), "C") }()
+	waitQueued(t, l, 2)
+
+	cancel()
+	expectDone(t, canceled, context.Canceled)
+	waitQueued(t, l, 1)
+	tick <- struct{}{}
+	expectDone(t, first, nil)
+	<-entered
+	tick <- struct{}{}
+	expectDone(t, last, nil)
+	if l.waiting() != 0 {
+		t.Fatalf("%d callers left", l.waiting())
+	}
+}
+
+func TestClient_KeyedLimiterGetsDevice(t *testing.T) {
+	var keys []string
+	keyed := &recordingKeyedLimiter{keys: &keys}
+	s := &stagedServer{}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+	c, err := NewClient("test", WithBaseURL(srv.URL), WithDefaultDeviceID("DEF"), WithRateLimiter(keyed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	_, _ = c.SendText(ctx, TextRequest{Title: "t"})
+	_, _ = c.SendImage(ctx, ImageRequest{DeviceID: "IMG", Image: "aGk="})
+	_, _ = c.Refresh(ctx, "R")
+	if got := strings.Join(keys, ","); got != "DEF,IMG,R" {
+		t.Fatalf("keys %s", got)
+	}
+}
+
+type recordingKeyedLimiter struct {
+	keys *[]string
+}
+
+func (l *recordingKeyedLimiter) Wait(ctx context.Context) error { return l.WaitKey(ctx, "") }
+
+func (l *recordingKeyedLimiter) WaitKey(ctx context.Context, key string) error {
+	*l.keys = append(*l.keys, key)
+	return nil
+}
@@ -0,0 +1,148 @@
+package quote0
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/png"
+	"strings"
+)
+
+// Text layout metrics used by PreviewText, in pixels.
+const (
+	textMargin       = 8
+	textTitleScale   = 2
+	textMessageTop   = 34
+	textMessagePitch = 14
+	textMessageLines = 3
+)
+
+// PreviewText renders an approximation of how the device lays out req: the title on the
+// first line, the message wrapped onto the next three lines, the icon at the bottom-left,
+// and the signature at the bottom-right. The device uses its own proportional font, so line
+// breaks may differ slightly; the built-in font draws characters it lacks as '?'.
+//
+// No device is needed. An icon that is not a PNG reports ErrInvalidImage; icons of the
+// wrong size are fitted with FitIcon.
+func PreviewText(req TextRequest) (*image.Gray, error) {
+	var icon *image.Gray
+	if s := strings.TrimSpace(req.Icon); s != "" {
+		img, err := decodePNGBase64("icon", s)
+		if err != nil {
+			return nil, err
+		}
+		icon = FitIcon(img)
+	} else if len(req.IconBytes) > 0 {
+		img, err := png.Decode(bytes.NewReader(req.IconBytes))
+		if err != nil {
+			return nil, fmt.Errorf("%w: icon: %v", ErrInvalidImage, err)
+		}
+		icon = FitIcon(img)
+	}
+
+	c := NewCanvas()
+	width := ScreenWidth - 2*textMargin
+	if title := singleLine(req.Title); title != "" {
+		c.DrawText(textMargin, textMargin+2, FitText(title, width, textTitleScale), textTitleScale, Black)
+	}
+	for i, line := range wrapText(req.Message, width, 1, textMessageLines) {
+		c.DrawText(textMargin, textMessageTop+i*textMessagePitch, line, 1, Black)
+	}
+
+	bottom := ScreenHeight - textMargin
+	sigLeft := textMargin
+	if icon != nil {
+		c.DrawImage(image.Rect(textMargin, bottom-IconSize, textMargin+IconSize, bottom), icon)
+		sigLeft += IconSize + textMargin
+	}
+	if sig := singleLine(req.Signature); sig != "" {
+		sig = FitText(sig, ScreenWidth-textMargin-sigLeft, 1)
+		c.DrawText(ScreenWidth-textMargin-TextWidth(sig, 1), bottom-glyphH, sig, 1, Black)
+	}
+	return c.Image(), nil
+}
+
+// PreviewImage performs the same payload handling as SendImage (Image, then ImageBytes, then
+// ImagePath) and returns the image dithered locally with req's DitherType and DitherKernel,
+// approximating the panel output. It reports ErrImagePayloadMissing, ErrInvalidImage, or
+// ErrImageSize for payloads the device cannot show. DeviceID is not required.
+func PreviewImage(req ImageRequest) (*image.Gray, error) {
+	if err := req.normalizeImage(); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(req.Image) == "" {
+		return nil, ErrImagePayloadMissing
+	}
+	img, err := decodePNGBase64("image", req.Image)
+	if err != nil {
+		return nil, err
+	}
+	if b := img.Bounds(); b.Dx() != ScreenWidth || b.Dy() != ScreenHeight {
+		return nil, fmt.Errorf("%w: got %dx%d", ErrImageSize, b.Dx(), b.Dy())
+	}
+	return Dither(img, req.DitherType, req.DitherKernel)
+}
+
+// decodePNGBase64 decodes the base64 PNG, or PNG data URI, of field ("image" or "icon").
+func decodePNGBase64(field, s string) (image.Image, error) {
+	var data []byte
+	var err error
+	if isDataURI(s) {
+		data, err = pngFromDataURI(field, s)
+	} else if data, err = base64.StdEncoding.DecodeString(strings.TrimSpace(s)); err != nil {
+		err = fmt.Errorf("%w: bad base64: %v", ErrInvalidImage, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+	return img, nil
+}
+
+// wrapText breaks s into at most maxLines lines no wider than width at scale. Explicit line
+// breaks are kept, words longer than a line are split, and the last line ends with "…"
+// when text remains.
+func wrapText(s string, width, scale, maxLines int) []string {
+	var lines []string
+	for _, para := range strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n") {
+		line := ""
+		for _, word := range strings.Fields(para) {
+			candidate := word
+			if line != "" {
+				candidate = line + " " + word
+			}
+			if TextWidth(candidate, scale) <= width {
+				line = candidate
+				continue
+			}
+			if line != "" {
+				lines = append(lines, line)
+			}
+			for r := []rune(word); ; {
+				n := len(r)
+				for n > 1 && TextWidth(string(r[:n]), scale) > width {
+					n--
+				}
+				if n == len(r) {
+					line = string(r)
+					break
+				}
+				lines = append(lines, string(r[:n]))
+				r = r[n:]
+			}
+		}
+		lines = append(lines, line)
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) > maxLines {
+		lines = lines[:maxLines]
+		lines[maxLines-1] = FitText(lines[maxLines-1]+"…", width, scale)
+	}
+	return lines
+}
@@ -0,0 +1,103 @@
+package quote0
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"reflect"
+	"testing"
+)
+
+func TestPreviewText(t *testing.T) {
+	icon, err := IconBase64(IconCheck)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := PreviewText(TextRequest{
+		Title:     "Build #1289 passed",
+		Message:   "All 412 tests green on main. Deploy to staging starts in 5 minutes; production rollout follows after the smoke checks and the usual approval from the on-call reviewer.",
+		Signature: "CI 14:02",
+		Icon:      icon,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertGolden(t, "preview_text", img)
+
+	if _, err := PreviewText(TextRequest{Icon: "not base64!"}); !errors.Is(err, ErrInvalidImage) {
+		t.Fatalf("bad icon: %v", err)
+	}
+}
+
+func TestPreviewImage(t *testing.T) {
+	gradient := NewCanvas()
+	for x := 0; x < ScreenWidth; x++ {
+		gradient.FillRect(image.Rect(x, 0, x+1, ScreenHeight), color.Gray{Y: uint8(x * 255 / (ScreenWidth - 1))})
+	}
+	data, err := gradient.PNG()
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := PreviewImage(ImageRequest{ImageBytes: data, DitherType: DitherOrdered})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertGolden(t, "preview_image_ordered", img)
+
+	small, _ := NewCanvasSize(10, 10).PNG()
+	if _, err := PreviewImage(ImageRequest{ImageBytes: small}); !errors.Is(err, ErrImageSize) {
+		t.Fatalf("small image: %v", err)
+	}
+	if _, err := PreviewImage(ImageRequest{}); err != ErrImagePayloadMissing {
+		t.Fatalf("empty: %v", err)
+	}
+	if _, err := PreviewImage(ImageRequest{ImageBytes: []byte("GIF89a")}); !errors.Is(err, ErrInvalidImage) {
+		t.Fatalf("not png: %v", err)
+	}
+}
+
+func TestDither(t *testing.T) {
+	mid := NewCanvasSize(8, 8)
+	mid.FillRect(mid.Bounds(), color.Gray{Y: 0x80})
+	for _, tc := range []struct {
+		t DitherType
+		k DitherKernel
+	}{
+		{"", ""}, {DitherDiffusion, KernelAtkinson}, {DitherDiffusion, KernelStucki}, {DitherOrdered, ""},
+	} {
+		out, err := Dither(mid.Image(), tc.t, tc.k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		black := 0
+		for _, p := range out.Pix {
+			if p == Black.Y {
+				black++
+			}
+		}
+		if black < 16 || black > 48 {
+			t.Errorf("%s/%s: %d of 64 pixels black for 50%% gray", tc.t, tc.k, black)
+		}
+	}
+	if out, _ := Dither(mid.Image(), DitherNone, "bogus"); out == nil || out.Pix[0] != White.Y {
+		t.Fatal("NONE should threshold and ignore the kernel")
+	}
+	if _, err := Dither(mid.Image(), DitherDiffusion, "bogus"); err == nil {
+		t.Fatal("unknown kernel should fail")
+	}
+	if _, err := Dither(mid.Image(), "SPIRAL", ""); err == nil {
+		t.Fatal("unknown type should fail")
+	}
+}
+
+func TestWrapText(t *testing.T) {
+	got := wrapText("one two three\n\nfour", TextWidth("one two", 1), 1, 5)
+	want := []string{"one two", "three", "", "four"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrap %q", got)
+	}
+	got = wrapText("abcdefghij", TextWidth("abcd", 1), 1, 2)
+	if !reflect.DeepEqual(got, []string{"abcd", "efg…"}) {
+		t.Fatalf("split %q", got)
+	}
+}
@@ -0,0 +1,47 @@
+package quote0
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+)
+
+// RequestIDHeader carries the per-call request ID to the API.
+const RequestIDHeader = "X-Request-ID"
+
+type requestIDKey struct{}
+
+// WithRequestID returns a context whose API calls send id as their X-Request-ID instead of a
+// generated one, e.g. to propagate the ID of the incoming HTTP request that triggered them.
+// A blank id leaves ctx unchanged.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	if id = strings.TrimSpace(id); id == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the ID set by WithRequestID, or "".
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// WithCallRequestID sends id as this call's X-Request-ID, like a context from WithRequestID.
+func WithCallRequestID(id string) SendOption {
+	return func(cfg *sendConfig) { cfg.requestID = strings.TrimSpace(id) }
+}
+
+// callRequestID returns the caller's request ID for ctx, or a new random one: 16 bytes from
+// crypto/rand in hex. Every attempt of a call, retries included, shares it.
+func callRequestID(ctx context.Context) string {
+	if id := RequestIDFromContext(ctx); id != "" {
+		return id
+	}
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b[:])
+}
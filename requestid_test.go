@@ -0,0 +1,59 @@
+package quote0
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRequestID(t *testing.T) {
+	var sent []string
+	fail := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sent = append(sent, r.Header.Get(RequestIDHeader))
+		if fail {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		okHandler(w, r)
+	}))
+	defer srv.Close()
+	c, err := NewClient("test", WithBaseURL(srv.URL), WithRateLimiter(nil), WithDefaultDeviceID("D"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	a, err := c.SendText(ctx, TextRequest{Title: "t"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := c.SendText(ctx, TextRequest{Title: "t"})
+	if len(a.RequestID) != 32 || a.RequestID != sent[0] || b.RequestID != sent[1] || a.RequestID == b.RequestID {
+		t.Fatalf("generated IDs %q, %q; sent %q", a.RequestID, b.RequestID, sent)
+	}
+
+	resp, err := c.SendText(WithRequestID(ctx, "upstream-7"), TextRequest{Title: "t"})
+	if err != nil || resp.RequestID != "upstream-7" || sent[2] != "upstream-7" {
+		t.Fatalf("context ID: %q sent %q, err %v", resp.RequestID, sent[2], err)
+	}
+	resp, err = c.SendText(WithRequestID(ctx, "outer"), TextRequest{Title: "t"}, WithCallRequestID("call-1"))
+	if err != nil || resp.RequestID != "call-1" || sent[3] != "call-1" {
+		t.Fatalf("option ID: %q sent %q, err %v", resp.RequestID, sent[3], err)
+	}
+	if RequestIDFromContext(WithRequestID(ctx, " ")) != "" {
+		t.Fatal("blank ID stored")
+	}
+
+	// A failure carries the ID, and retries reuse it.
+	fail = true
+	rc, _ := NewClient("test", WithBaseURL(srv.URL), WithRateLimiter(nil), WithDefaultDeviceID("D"), WithRetry(2, time.Millisecond))
+	_, err = rc.SendText(ctx, TextRequest{Title: "t"})
+	var ae *APIError
+	if !errors.As(err, &ae) || ae.RequestID == "" || ae.RequestID != sent[4] || sent[5] != sent[4] {
+		t.Fatalf("err %v, sent %q", err, sent[4:])
+	}
+}
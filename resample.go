@@ -0,0 +1,232 @@
+package quote0
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"strings"
+)
+
+// ResampleFilter selects how ProcessImage and FitIcon compute pixels when scaling.
+type ResampleFilter string
+
+const (
+	// ResampleAuto picks ResampleBox, which averages when scaling down and repeats pixels
+	// when scaling up; integer upscales therefore match ResampleNearest (default).
+	ResampleAuto ResampleFilter = ""
+	// ResampleNearest takes the source pixel under the centre of each destination pixel. It
+	// keeps edges hard and levels exact, but drops or doubles thin lines when scaling down.
+	ResampleNearest ResampleFilter = "nearest"
+	// ResampleBox averages the source pixels that map onto each destination pixel.
+	ResampleBox ResampleFilter = "box"
+	// ResampleBilinear weights source pixels by distance with a triangle filter widened to the
+	// scale factor, so downscales blend smoothly and upscales have no blocky steps.
+	ResampleBilinear ResampleFilter = "bilinear"
+)
+
+// ResampleFilters lists the filters WithResample accepts besides ResampleAuto.
+func ResampleFilters() []ResampleFilter {
+	return []ResampleFilter{ResampleNearest, ResampleBox, ResampleBilinear}
+}
+
+func (f ResampleFilter) valid() bool {
+	switch f {
+	case ResampleAuto, ResampleNearest, ResampleBox, ResampleBilinear:
+		return true
+	}
+	return false
+}
+
+func normalizeResample(f ResampleFilter) ResampleFilter {
+	return ResampleFilter(strings.ToLower(strings.TrimSpace(string(f))))
+}
+
+// scaleInto draws the sr part of src into dst on c with filter f (ResampleAuto or one of
+// ResampleFilters). Sampling never reads outside sr: edge pixels are clamped, not faded.
+func scaleInto(c *Canvas, dst image.Rectangle, src image.Image, sr image.Rectangle, f ResampleFilter) {
+	w, h := dst.Dx(), dst.Dy()
+	if w <= 0 || h <= 0 || sr.Empty() {
+		return
+	}
+	g := grayRegion(src, sr)
+	out := image.NewGray(image.Rect(0, 0, w, h))
+	switch f {
+	case ResampleNearest:
+		resampleNearest(out, g)
+	case ResampleBilinear:
+		resampleBilinear(out, g)
+	default:
+		if g.Rect.Dx()%w == 0 && g.Rect.Dy()%h == 0 {
+			resampleBoxInteger(out, g)
+		} else {
+			resampleBox(out, g)
+		}
+	}
+	r := dst.Intersect(c.img.Rect)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		i := out.PixOffset(r.Min.X-dst.Min.X, y-dst.Min.Y)
+		copy(c.img.Pix[c.img.PixOffset(r.Min.X, y):], out.Pix[i:i+r.Dx()])
+	}
+}
+
+// grayRegion returns the sr part of src as gray pixels, sharing them when src is already gray.
+func grayRegion(src image.Image, sr image.Rectangle) *image.Gray {
+	if g, ok := src.(*image.Gray); ok {
+		return g.SubImage(sr).(*image.Gray)
+	}
+	g := image.NewGray(sr)
+	for y := sr.Min.Y; y < sr.Max.Y; y++ {
+		row := g.Pix[g.PixOffset(sr.Min.X, y):]
+		for x := sr.Min.X; x < sr.Max.X; x++ {
+			row[x-sr.Min.X] = color.GrayModel.Convert(src.At(x, y)).(color.Gray).Y
+		}
+	}
+	return g
+}
+
+// spans returns, for each of n destination pixels along an axis of length size, the source
+// range [lo[i], hi[i]) it covers, at least one pixel wide.
+func spans(size, n int) (lo, hi []int) {
+	lo, hi = make([]int, n), make([]int, n)
+	for i := range lo {
+		lo[i] = i * size / n
+		hi[i] = lo[i] + max1((i+1)*size/n-lo[i])
+	}
+	return lo, hi
+}
+
+// resampleBox averages whole source pixels; scaling up, each span is one pixel wide and the
+// pixel is repeated.
+func resampleBox(out, g *image.Gray) {
+	w, h := out.Rect.Dx(), out.Rect.Dy()
+	xlo, xhi := spans(g.Rect.Dx(), w)
+	ylo, yhi := spans(g.Rect.Dy(), h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var sum uint64
+			for sy := ylo[y]; sy < yhi[y]; sy++ {
+				row := g.Pix[sy*g.Stride:]
+				for sx := xlo[x]; sx < xhi[x]; sx++ {
+					sum += uint64(row[sx])
+				}
+			}
+			n := uint64((yhi[y] - ylo[y]) * (xhi[x] - xlo[x]))
+			out.Pix[y*out.Stride+x] = uint8(sum / n)
+		}
+	}
+}
+
+// resampleBoxInteger is resampleBox for whole-number downscales: every block has the same
+// size, so source rows are summed into per-column totals in one pass.
+func resampleBoxInteger(out, g *image.Gray) {
+	w, h := out.Rect.Dx(), out.Rect.Dy()
+	kx, ky := g.Rect.Dx()/w, g.Rect.Dy()/h
+	n := uint64(kx * ky)
+	sums := make([]uint64, w)
+	for y := 0; y < h; y++ {
+		for i := range sums {
+			sums[i] = 0
+		}
+		for sy := y * ky; sy < (y+1)*ky; sy++ {
+			row := g.Pix[sy*g.Stride : sy*g.Stride+g.Rect.Dx()]
+			for sx, v := range row {
+				sums[sx/kx] += uint64(v)
+			}
+		}
+		for x, s := range sums {
+			out.Pix[y*out.Stride+x] = uint8(s / n)
+		}
+	}
+}
+
+// resampleNearest samples the source pixel under each destination pixel's centre. A row that
+// samples the same source row as the one before is copied, which covers integer upscales.
+func resampleNearest(out, g *image.Gray) {
+	w, h := out.Rect.Dx(), out.Rect.Dy()
+	sw, sh := g.Rect.Dx(), g.Rect.Dy()
+	xs := make([]int, w)
+	for x := range xs {
+		xs[x] = (2*x + 1) * sw / (2 * w)
+	}
+	prev := -1
+	for y := 0; y < h; y++ {
+		sy := (2*y + 1) * sh / (2 * h)
+		dst := out.Pix[y*out.Stride : y*out.Stride+w]
+		if sy == prev {
+			copy(dst, out.Pix[(y-1)*out.Stride:])
+			continue
+		}
+		row := g.Pix[sy*g.Stride:]
+		for x, sx := range xs {
+			dst[x] = row[sx]
+		}
+		prev = sy
+	}
+}
+
+// filterTaps are the source pixels and normalized weights for one destination pixel.
+type filterTaps struct {
+	first   int
+	weights []float64
+}
+
+// triangleTaps computes bilinear weights along an axis of length size scaled to n pixels.
+// The triangle widens with the scale factor when shrinking, so every source pixel counts.
+// Taps outside the source are dropped and the rest renormalized, which clamps at the edges.
+func triangleTaps(size, n int) []filterTaps {
+	scale := float64(size) / float64(n)
+	support := math.Max(scale, 1)
+	taps := make([]filterTaps, n)
+	for i := range taps {
+		center := (float64(i)+0.5)*scale - 0.5
+		lo := int(math.Max(math.Ceil(center-support), 0))
+		hi := int(math.Min(math.Floor(center+support), float64(size-1)))
+		ws := make([]float64, 0, hi-lo+1)
+		var sum float64
+		for j := lo; j <= hi; j++ {
+			wt := 1 - math.Abs(float64(j)-center)/support
+			if wt < 0 {
+				wt = 0
+			}
+			ws = append(ws, wt)
+			sum += wt
+		}
+		if sum == 0 {
+			// A single tap exactly support away: take the nearest pixel.
+			ws, sum = []float64{1}, 1
+			lo = int(math.Min(math.Max(math.Round(center), 0), float64(size-1)))
+		}
+		for k := range ws {
+			ws[k] /= sum
+		}
+		taps[i] = filterTaps{first: lo, weights: ws}
+	}
+	return taps
+}
+
+// resampleBilinear filters rows, then columns, with triangleTaps.
+func resampleBilinear(out, g *image.Gray) {
+	w, h := out.Rect.Dx(), out.Rect.Dy()
+	sh := g.Rect.Dy()
+	xt, yt := triangleTaps(g.Rect.Dx(), w), triangleTaps(sh, h)
+	tmp := make([]float64, sh*w)
+	for sy := 0; sy < sh; sy++ {
+		row := g.Pix[sy*g.Stride:]
+		for x, t := range xt {
+			var v float64
+			for k, wt := range t.weights {
+				v += wt * float64(row[t.first+k])
+			}
+			tmp[sy*w+x] = v
+		}
+	}
+	for y, t := range yt {
+		for x := 0; x < w; x++ {
+			var v float64
+			for k, wt := range t.weights {
+				v += wt * tmp[(t.first+k)*w+x]
+			}
+			out.Pix[y*out.Stride+x] = uint8(math.Round(math.Min(math.Max(v, 0), 255)))
+		}
+	}
+}
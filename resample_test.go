@@ -0,0 +1,132 @@
+package quote0
+
+import (
+	"errors"
+	"image"
+	"math/rand"
+	"testing"
+)
+
+// lineArt is a 740x380 fixture (2.5 times the screen) of 1-pixel lines: a border, a grid every
+// 10 pixels, and two diagonals, which a downscale either drops, keeps, or blends.
+func lineArt() image.Image {
+	c := NewCanvasSize(740, 380)
+	c.StrokeRect(c.Bounds(), 1, Black)
+	for x := 5; x < 740; x += 10 {
+		c.Line(x, 0, x, 379, Black)
+	}
+	for y := 5; y < 380; y += 10 {
+		c.Line(0, y, 739, y, Black)
+	}
+	c.Line(0, 0, 739, 379, Black)
+	c.Line(0, 379, 739, 0, Black)
+	return c.Image()
+}
+
+func noise(w, h int) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	rand.New(rand.NewSource(1)).Read(img.Pix)
+	return img
+}
+
+func TestResample_LineArtGolden(t *testing.T) {
+	src := lineArt()
+	nearest, err := ProcessImage(src, WithFit(FitStretch), WithResample(ResampleNearest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertGolden(t, "resample_nearest", nearest)
+	bilinear, err := ProcessImage(src, WithFit(FitStretch), WithResample("Bilinear"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertGolden(t, "resample_bilinear", bilinear)
+
+	// Nearest keeps only pure black and white; bilinear blends the lines into grays.
+	levels := func(img *image.Gray) map[uint8]bool {
+		m := map[uint8]bool{}
+		for _, v := range img.Pix {
+			m[v] = true
+		}
+		return m
+	}
+	if n := len(levels(nearest)); n != 2 {
+		t.Fatalf("nearest produced %d levels", n)
+	}
+	if n := len(levels(bilinear)); n < 10 {
+		t.Fatalf("bilinear produced %d levels", n)
+	}
+}
+
+func TestResample_IntegerFastPaths(t *testing.T) {
+	// Whole-number downscales take resampleBoxInteger, which must match the general box filter.
+	src := noise(2*ScreenWidth, 3*ScreenHeight)
+	fast := image.NewGray(image.Rect(0, 0, ScreenWidth, ScreenHeight))
+	slow := image.NewGray(fast.Rect)
+	resampleBoxInteger(fast, src)
+	resampleBox(slow, src)
+	if string(fast.Pix) != string(slow.Pix) {
+		t.Fatal("integer box downscale differs from the general box filter")
+	}
+	auto, _ := ProcessImage(src, WithFit(FitStretch))
+	if string(auto.Pix) != string(slow.Pix) {
+		t.Fatal("ResampleAuto downscale is not box")
+	}
+
+	// Integer upscales: the default repeats pixels exactly as nearest does.
+	small := noise(ScreenWidth/4, ScreenHeight/4)
+	auto, _ = ProcessImage(small, WithFit(FitStretch))
+	nearest, _ := ProcessImage(small, WithFit(FitStretch), WithResample(ResampleNearest))
+	if string(auto.Pix) != string(nearest.Pix) {
+		t.Fatal("integer upscale: auto differs from nearest")
+	}
+	if auto.GrayAt(5, 9) != small.GrayAt(1, 2) {
+		t.Fatal("integer upscale does not repeat source pixels")
+	}
+}
+
+func TestResample_Edges(t *testing.T) {
+	// A flat image stays flat up to the edges with every filter and direction.
+	for _, size := range []image.Point{{100, 60}, {1000, 500}, {ScreenWidth + 3, ScreenHeight - 7}} {
+		src := image.NewGray(image.Rect(0, 0, size.X, size.Y))
+		for i := range src.Pix {
+			src.Pix[i] = 77
+		}
+		for _, f := range ResampleFilters() {
+			img, err := ProcessImage(src, WithFit(FitStretch), WithResample(f))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, v := range img.Pix {
+				if v != 77 {
+					t.Fatalf("%s %v: pixel %d is %d", f, size, i, v)
+				}
+			}
+		}
+	}
+	// Sampling stays inside a crop: FitCover of a banner with a black frame outside the crop.
+	src := NewCanvasSize(1200, 304)
+	src.FillRect(image.Rect(0, 0, 304, 304), Black)
+	src.FillRect(image.Rect(896, 0, 1200, 304), Black)
+	img, err := ProcessImage(src.Image(), WithFit(FitCover), WithResample(ResampleBilinear))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.GrayAt(0, 70).Y != White.Y || img.GrayAt(ScreenWidth-1, 70).Y != White.Y {
+		t.Fatal("bilinear read pixels outside the crop")
+	}
+}
+
+func TestResample_Invalid(t *testing.T) {
+	_, err := ProcessImage(lineArt(), WithFit(FitContain), WithResample("lanczos"))
+	if !errors.Is(err, ErrInvalidAdjustment) {
+		t.Fatalf("unknown filter: %v", err)
+	}
+	ps := CheckProcessing(DitherNone, WithResample("lanczos"))
+	if len(ps) != 1 || ps[0].Field != "resample" || !HasErrors(ps) {
+		t.Fatalf("CheckProcessing: %v", ps)
+	}
+	if ps := CheckProcessing(DitherNone, WithResample(" BOX ")); len(ps) != 0 {
+		t.Fatalf("box: %v", ps)
+	}
+}
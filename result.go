@@ -0,0 +1,31 @@
+package quote0
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrNoResult is returned by DecodeResult when the response has no result payload: the field
+// was absent or null, or the body was plain text.
+var ErrNoResult = errors.New("quote0: response has no result")
+
+// maxResultSnippet caps how much of a result DecodeResult quotes in its errors.
+const maxResultSnippet = 200
+
+// DecodeResult unmarshals the response's result field into v. It returns ErrNoResult when
+// there is nothing to decode, and a decode failure wrapped with the start of the raw result.
+func (r *APIResponse) DecodeResult(v interface{}) error {
+	if r == nil {
+		return ErrNoResult
+	}
+	raw := bytes.TrimSpace(r.Result)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return ErrNoResult
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("quote0: decode result %s: %w", truncateUTF8(string(raw), maxResultSnippet), err)
+	}
+	return nil
+}
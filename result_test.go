@@ -0,0 +1,42 @@
+package quote0
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestAPIResponse_DecodeResult(t *testing.T) {
+	var out struct {
+		Battery int `json:"battery"`
+	}
+	for _, tt := range []struct {
+		name string
+		resp *APIResponse
+		want error
+	}{
+		{"nil response", nil, ErrNoResult},
+		{"absent", &APIResponse{}, ErrNoResult},
+		{"null", &APIResponse{Result: json.RawMessage(" null ")}, ErrNoResult},
+		{"plain text", parseResponse(&http.Response{StatusCode: 200, Header: http.Header{}}, []byte("发送成功")), ErrNoResult},
+	} {
+		if err := tt.resp.DecodeResult(&out); !errors.Is(err, tt.want) {
+			t.Errorf("%s: %v", tt.name, err)
+		}
+	}
+
+	resp := parseResponse(&http.Response{StatusCode: 200, Header: http.Header{"Content-Type": {"application/json"}}},
+		[]byte(`{"code":0,"message":"ok","result":{"battery":87}}`))
+	if err := resp.DecodeResult(&out); err != nil || out.Battery != 87 {
+		t.Fatalf("battery %d, err %v", out.Battery, err)
+	}
+
+	resp.Result = json.RawMessage(`{"battery":"` + strings.Repeat("x", 500) + `"}`)
+	err := resp.DecodeResult(&out)
+	var typeErr *json.UnmarshalTypeError
+	if !errors.As(err, &typeErr) || !strings.Contains(err.Error(), `{"battery":"xxx`) || len(err.Error()) > 400 {
+		t.Fatalf("decode error %v", err)
+	}
+}
@@ -0,0 +1,87 @@
+package quote0
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// MaxRetryDelay caps the backoff between WithRetry attempts.
+const MaxRetryDelay = 30 * time.Second
+
+// retryPolicy is the WithRetry configuration; attempts 0 means no retries.
+type retryPolicy struct {
+	attempts  int
+	baseDelay time.Duration
+}
+
+// RetryError is returned when a call still failed after WithRetry tried it more than once.
+// It unwraps to the last attempt's error, so errors.As finds an *APIError and IsNetworkError
+// and the other helpers see the final cause.
+type RetryError struct {
+	// Attempts is how many times the call was sent.
+	Attempts int
+	// Err is the last attempt's error.
+	Err error
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("quote0: failed after %d attempts: %v", e.Attempts, e.Err)
+}
+
+// Unwrap returns the last attempt's error.
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+// WithRetry sends a call up to maxAttempts times when it fails transiently: network errors,
+// 5xx responses, and 429 Too Many Requests. Other 4xx answers and local errors are returned
+// at once. Before retry n the client sleeps about baseDelay * 2^(n-1), capped at
+// MaxRetryDelay, with up to half of it randomized so many clients do not retry in step. Each
+// attempt waits for the rate limiter again, and the caller's context cancels both the wait
+// and the backoff.
+//
+// A call that fails after retrying returns a *RetryError holding the attempt count, and
+// APIResponse.Attempts reports it for calls that eventually succeeded. maxAttempts 1
+// disables retries; maxAttempts below 1 or a negative baseDelay makes NewClient return an
+// error. With WithFallbackBaseURLs, every attempt tries the hosts in turn.
+func WithRetry(maxAttempts int, baseDelay time.Duration) ClientOption {
+	return func(c *Client) {
+		if maxAttempts < 1 || baseDelay < 0 {
+			c.optionError(fmt.Errorf("quote0: retry needs at least 1 attempt and a non-negative delay, got %d and %v", maxAttempts, baseDelay))
+			return
+		}
+		c.retry = retryPolicy{attempts: maxAttempts, baseDelay: baseDelay}
+	}
+}
+
+// delay returns the backoff before retry n (1 for the first retry): the exponential step
+// with its upper half jittered.
+func (p retryPolicy) delay(n int) time.Duration {
+	d := p.baseDelay
+	for i := 1; i < n && d < MaxRetryDelay; i++ {
+		d *= 2
+	}
+	if d > MaxRetryDelay {
+		d = MaxRetryDelay
+	}
+	if half := int64(d / 2); half > 0 {
+		d = time.Duration(half + rand.Int63n(half+1))
+	}
+	return d
+}
+
+// retryable reports whether err is worth another attempt: a transport failure not caused by
+// ctx, a 5xx, or a 429.
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var ae *APIError
+	if errors.As(err, &ae) {
+		return ae.StatusCode >= 500 || ae.StatusCode == 429
+	}
+	return IsNetworkError(err)
+}
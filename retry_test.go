@@ -0,0 +1,126 @@
+package quote0
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// scriptedServer answers with statuses in turn (0 drops the connection) and 200 afterwards.
+func scriptedServer(t *testing.T, statuses ...int) (*httptest.Server, *int32) {
+	t.Helper()
+	var n int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		i := int(atomic.AddInt32(&n, 1)) - 1
+		if i < len(statuses) {
+			if statuses[i] == 0 {
+				conn, _, _ := w.(http.Hijacker).Hijack()
+				conn.Close()
+				return
+			}
+			w.WriteHeader(statuses[i])
+			_, _ = io.WriteString(w, "nope")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"code":0}`)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &n
+}
+
+func TestWithRetry_RecoversFromTransientFailures(t *testing.T) {
+	srv, hits := scriptedServer(t, http.StatusBadGateway, 0, http.StatusTooManyRequests)
+	var waits int32
+	limiter := RateLimiterFunc(func(context.Context) error { atomic.AddInt32(&waits, 1); return nil })
+	c, err := NewClient("test", WithBaseURL(srv.URL), WithRateLimiter(limiter), WithDefaultDeviceID("D"),
+		WithRetry(4, time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := c.SendText(context.Background(), TextRequest{Title: "t"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Attempts != 4 || atomic.LoadInt32(hits) != 4 || atomic.LoadInt32(&waits) != 4 {
+		t.Fatalf("attempts %d, hits %d, limiter waits %d", resp.Attempts, *hits, waits)
+	}
+}
+
+func TestWithRetry_GivesUp(t *testing.T) {
+	srv, hits := scriptedServer(t, 503, 503, 503, 503)
+	c, _ := NewClient("test", WithBaseURL(srv.URL), WithRateLimiter(nil), WithDefaultDeviceID("D"), WithRetry(3, time.Millisecond))
+	_, err := c.SendText(context.Background(), TextRequest{Title: "t"})
+	var re *RetryError
+	var ae *APIError
+	if !errors.As(err, &re) || re.Attempts != 3 || !errors.As(err, &ae) || ae.StatusCode != 503 {
+		t.Fatalf("err %v", err)
+	}
+	if !strings.Contains(err.Error(), "after 3 attempts") || atomic.LoadInt32(hits) != 3 {
+		t.Fatalf("err %v after %d requests", err, *hits)
+	}
+}
+
+func TestWithRetry_NotOnClientErrors(t *testing.T) {
+	for _, status := range []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound} {
+		srv, hits := scriptedServer(t, status)
+		c, _ := NewClient("test", WithBaseURL(srv.URL), WithRateLimiter(nil), WithDefaultDeviceID("D"), WithRetry(5, time.Millisecond))
+		_, err := c.SendText(context.Background(), TextRequest{Title: "t"})
+		var ae *APIError
+		if !errors.As(err, &ae) || ae.StatusCode != status || atomic.LoadInt32(hits) != 1 {
+			t.Fatalf("%d: err %v after %d requests", status, err, *hits)
+		}
+		if errors.As(err, new(*RetryError)) {
+			t.Fatalf("%d: single attempt wrapped: %v", status, err)
+		}
+	}
+	// Local validation errors are not sent at all.
+	c, _ := NewClient("test", WithRateLimiter(nil), WithRetry(5, time.Millisecond))
+	if _, err := c.SendText(context.Background(), TextRequest{Title: "t"}); !errors.Is(err, ErrDeviceIDMissing) {
+		t.Fatalf("err %v", err)
+	}
+}
+
+func TestWithRetry_ContextStopsBackoff(t *testing.T) {
+	srv, hits := scriptedServer(t, 500, 500)
+	c, _ := NewClient("test", WithBaseURL(srv.URL), WithRateLimiter(nil), WithDefaultDeviceID("D"), WithRetry(3, time.Hour))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := c.SendText(ctx, TextRequest{Title: "t"})
+	var ae *APIError
+	if !errors.As(err, &ae) || ae.StatusCode != 500 || atomic.LoadInt32(hits) != 1 {
+		t.Fatalf("err %v after %d requests", err, *hits)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Fatal("backoff ignored the context")
+	}
+}
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	p := retryPolicy{attempts: 10, baseDelay: 100 * time.Millisecond}
+	for n, want := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 4: 800 * time.Millisecond, 20: MaxRetryDelay} {
+		for i := 0; i < 20; i++ {
+			if d := p.delay(n); d < want/2 || d > want {
+				t.Fatalf("delay(%d) = %v, want %v to %v", n, d, want/2, want)
+			}
+		}
+	}
+	if d := (retryPolicy{attempts: 2}).delay(1); d != 0 {
+		t.Fatalf("zero base delay: %v", d)
+	}
+	for _, tt := range []struct {
+		n int
+		d time.Duration
+	}{{0, time.Second}, {3, -time.Second}} {
+		if _, err := NewClient("test", WithRetry(tt.n, tt.d)); err == nil {
+			t.Errorf("WithRetry(%d, %v) accepted", tt.n, tt.d)
+		}
+	}
+}
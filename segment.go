@@ -0,0 +1,123 @@
+package quote0
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// ErrSegmentOverflow is returned by SegmentBuilder.Build when a mandatory segment (priority 0)
+// does not fit.
+var ErrSegmentOverflow = errors.New("quote0: mandatory segment does not fit")
+
+// Segment is one piece of content added to a SegmentBuilder.
+type Segment struct {
+	// Text is the content; surrounding whitespace is ignored and a title is joined onto one line.
+	Text string
+	// Priority orders the segments: 0 is mandatory and higher numbers are less important.
+	// Negative priorities count as 0.
+	Priority int
+	// Title places the segment in the title instead of the message (see SegmentTitle).
+	Title bool
+}
+
+// SegmentOption configures one segment added to a SegmentBuilder.
+type SegmentOption func(*Segment)
+
+// SegmentTitle makes the segment a title candidate. Only one title is shown: the most
+// important title segment that fits on the title line.
+func SegmentTitle() SegmentOption {
+	return func(s *Segment) { s.Title = true }
+}
+
+// SegmentBuilder assembles a text request from segments of different importance, dropping the
+// least important ones when they do not all fit instead of truncating everything. The zero
+// value is ready to use.
+type SegmentBuilder struct {
+	segments []Segment
+}
+
+// Add appends a segment with the given priority (0 mandatory, higher numbers less important)
+// and returns b for chaining.
+func (b *SegmentBuilder) Add(text string, priority int, opts ...SegmentOption) *SegmentBuilder {
+	s := Segment{Text: text, Priority: priority}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&s)
+		}
+	}
+	if s.Priority < 0 {
+		s.Priority = 0
+	}
+	b.segments = append(b.segments, s)
+	return b
+}
+
+// Build lays the segments out as a title and the message lines, measured with the same font
+// metrics as PreviewText so nothing is cut off with "…".
+//
+// Segments are considered in priority order, ties in the order they were added, and each is
+// kept when it still fits alongside those already kept; a segment that does not fit is
+// dropped whole, and less important ones may still fill the room it leaves. Kept message
+// segments appear in the order they were added, each starting a new line and wrapping at word
+// boundaries. A mandatory segment that does not fit, even alone, reports ErrSegmentOverflow.
+//
+// The returned request has only Title and Message set. dropped lists the segments left out,
+// in the order they were added; blank segments are ignored.
+func (b *SegmentBuilder) Build() (req TextRequest, dropped []Segment, err error) {
+	order := make([]int, len(b.segments))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return b.segments[order[i]].Priority < b.segments[order[j]].Priority
+	})
+
+	width := ScreenWidth - 2*textMargin
+	title := -1
+	kept := make([]bool, len(b.segments))
+	for _, i := range order {
+		s := b.segments[i]
+		if strings.TrimSpace(s.Text) == "" {
+			continue
+		}
+		if s.Title {
+			if title < 0 && TextWidth(singleLine(s.Text), textTitleScale) <= width {
+				title = i
+				kept[i] = true
+			}
+		} else {
+			kept[i] = true
+			if len(wrapText(b.message(kept), width, 1, math.MaxInt32)) > textMessageLines {
+				kept[i] = false
+			}
+		}
+		if !kept[i] && s.Priority == 0 {
+			return TextRequest{}, nil, fmt.Errorf("%w: %q", ErrSegmentOverflow, Truncate(singleLine(s.Text), 30))
+		}
+	}
+
+	for i, s := range b.segments {
+		if !kept[i] && strings.TrimSpace(s.Text) != "" {
+			dropped = append(dropped, s)
+		}
+	}
+	if title >= 0 {
+		req.Title = singleLine(b.segments[title].Text)
+	}
+	req.Message = b.message(kept)
+	return req, dropped, nil
+}
+
+// message joins the kept message segments in the order they were added.
+func (b *SegmentBuilder) message(kept []bool) string {
+	var parts []string
+	for i, s := range b.segments {
+		if kept[i] && !s.Title {
+			parts = append(parts, strings.TrimSpace(s.Text))
+		}
+	}
+	return strings.Join(parts, "\n")
+}
@@ -0,0 +1,100 @@
+package quote0
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// A message line holds 46 characters of the built-in font, the title 23.
+func words(n int) string { return strings.TrimSpace(strings.Repeat("word ", n)) }
+
+func TestSegmentBuilder_Capacity(t *testing.T) {
+	tests := []struct {
+		name        string
+		build       func(b *SegmentBuilder)
+		wantMessage string
+		wantDropped []string
+	}{
+		{"all fit", func(b *SegmentBuilder) {
+			b.Add("Disk 91% on /var", 0).Add("cpu 12% mem 40%", 1).Add("joke of the day", 2)
+		}, "Disk 91% on /var\ncpu 12% mem 40%\njoke of the day", nil},
+		{"lowest priority dropped", func(b *SegmentBuilder) {
+			// 12 words wrap onto two lines, leaving one for the metrics.
+			b.Add("joke of the day", 2).Add(words(12), 0).Add("cpu 12%", 1)
+		}, words(12) + "\ncpu 12%", []string{"joke of the day"}},
+		{"smaller segment fills the gap", func(b *SegmentBuilder) {
+			b.Add(words(12), 0).Add(words(12), 1).Add("joke", 2)
+		}, words(12) + "\njoke", []string{words(12)}},
+		{"ties keep the first added", func(b *SegmentBuilder) {
+			b.Add(words(12), 0).Add("first", 1).Add("second", 1)
+		}, words(12) + "\nfirst", []string{"second"}},
+		{"oversized optional segment dropped", func(b *SegmentBuilder) {
+			b.Add(words(40), 1).Add("fits", 2)
+		}, "fits", []string{words(40)}},
+		{"blank segments ignored", func(b *SegmentBuilder) {
+			b.Add("  ", 0).Add("\n", 1).Add(" x ", 1)
+		}, "x", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b SegmentBuilder
+			tt.build(&b)
+			req, dropped, err := b.Build()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if req.Message != tt.wantMessage {
+				t.Fatalf("message\n%s\nwant\n%s", req.Message, tt.wantMessage)
+			}
+			var texts []string
+			for _, s := range dropped {
+				texts = append(texts, s.Text)
+			}
+			if !reflect.DeepEqual(texts, tt.wantDropped) {
+				t.Fatalf("dropped %q, want %q", texts, tt.wantDropped)
+			}
+			// The built message renders without an ellipsis.
+			if lines := wrapText(req.Message, ScreenWidth-2*textMargin, 1, textMessageLines); strings.Contains(strings.Join(lines, ""), "…") {
+				t.Fatalf("message overflows: %q", lines)
+			}
+		})
+	}
+}
+
+func TestSegmentBuilder_Title(t *testing.T) {
+	var b SegmentBuilder
+	b.Add("a title far too long for the title line", 1, SegmentTitle()).
+		Add("msg", 0).
+		Add("Backups  OK", 2, SegmentTitle()).
+		Add("Other title", 3, SegmentTitle())
+	req, dropped, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Title != "Backups OK" || req.Message != "msg" {
+		t.Fatalf("request %+v", req)
+	}
+	if len(dropped) != 2 || !dropped[0].Title || dropped[1].Text != "Other title" {
+		t.Fatalf("dropped %+v", dropped)
+	}
+}
+
+func TestSegmentBuilder_Mandatory(t *testing.T) {
+	var b SegmentBuilder
+	_, _, err := b.Add(words(40), 0).Build()
+	if !errors.Is(err, ErrSegmentOverflow) || !IsValidationError(err) {
+		t.Fatalf("oversized mandatory: %v", err)
+	}
+	// Mandatory segments are placed first, so they fail only when together they overflow.
+	b = SegmentBuilder{}
+	_, _, err = b.Add("optional", 1).Add(words(12), 0).Add(words(12), -1).Build()
+	if !errors.Is(err, ErrSegmentOverflow) {
+		t.Fatalf("two mandatory segments: %v", err)
+	}
+	b = SegmentBuilder{}
+	if _, _, err = b.Add("one", 0, SegmentTitle()).Add("two", 0, SegmentTitle()).Build(); !errors.Is(err, ErrSegmentOverflow) {
+		t.Fatalf("two mandatory titles: %v", err)
+	}
+}
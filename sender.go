@@ -0,0 +1,82 @@
+package quote0
+
+import (
+	"context"
+	"sync"
+)
+
+// Sender is the core of Client: the two calls that put content on a device. Code that only
+// sends can depend on a Sender and be tested with a RecordingSender instead of an HTTP server.
+// The convenience methods (SendTextSimple, SendImageBytes, ...) stay on Client.
+type Sender interface {
+	SendText(ctx context.Context, payload TextRequest, opts ...SendOption) (*APIResponse, error)
+	SendImage(ctx context.Context, payload ImageRequest, opts ...SendOption) (*APIResponse, error)
+}
+
+var _ Sender = (*Client)(nil)
+
+// RecordingSender is a Sender for tests that records every request and answers with a canned
+// response, without any network or validation. SendOptions are applied to the recorded
+// request as Client would, so a WithDevice override shows up in its DeviceID.
+// The zero value answers every call with success. It is safe for concurrent use.
+type RecordingSender struct {
+	// Response is returned (as a copy) by every call; nil means {Code: 0, Message: "ok",
+	// StatusCode: 200}.
+	Response *APIResponse
+	// Err, when set, is returned instead of a response.
+	Err error
+
+	mu     sync.Mutex
+	texts  []TextRequest
+	images []ImageRequest
+}
+
+// SendText records payload and returns the canned answer.
+func (s *RecordingSender) SendText(ctx context.Context, payload TextRequest, opts ...SendOption) (*APIResponse, error) {
+	newSendConfig(opts).applyText(&payload)
+	s.mu.Lock()
+	s.texts = append(s.texts, payload)
+	s.mu.Unlock()
+	return s.answer()
+}
+
+// SendImage records payload and returns the canned answer.
+func (s *RecordingSender) SendImage(ctx context.Context, payload ImageRequest, opts ...SendOption) (*APIResponse, error) {
+	newSendConfig(opts).applyImage(&payload)
+	s.mu.Lock()
+	s.images = append(s.images, payload)
+	s.mu.Unlock()
+	return s.answer()
+}
+
+// Texts returns the text requests recorded so far, oldest first.
+func (s *RecordingSender) Texts() []TextRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]TextRequest(nil), s.texts...)
+}
+
+// Images returns the image requests recorded so far, oldest first.
+func (s *RecordingSender) Images() []ImageRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]ImageRequest(nil), s.images...)
+}
+
+// Reset forgets the recorded requests.
+func (s *RecordingSender) Reset() {
+	s.mu.Lock()
+	s.texts, s.images = nil, nil
+	s.mu.Unlock()
+}
+
+func (s *RecordingSender) answer() (*APIResponse, error) {
+	if s.Err != nil {
+		return nil, s.Err
+	}
+	if s.Response == nil {
+		return &APIResponse{Message: "ok", StatusCode: 200, Attempts: 1}, nil
+	}
+	resp := *s.Response
+	return &resp, nil
+}
@@ -0,0 +1,64 @@
+package quote0
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+// notify is application code that only needs a Sender.
+func notify(ctx context.Context, s Sender, msg string) error {
+	_, err := s.SendText(ctx, TextRequest{Title: "Alert", Message: msg}, WithDevice("OPS"))
+	return err
+}
+
+func TestRecordingSender(t *testing.T) {
+	var s RecordingSender
+	if err := notify(context.Background(), &s, "disk full"); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := s.SendImage(context.Background(), ImageRequest{Link: "a"}, WithRefresh(true))
+	if err != nil || resp.Code != 0 || resp.StatusCode != 200 {
+		t.Fatalf("resp %+v, err %v", resp, err)
+	}
+	texts, images := s.Texts(), s.Images()
+	if len(texts) != 1 || texts[0].DeviceID != "OPS" || texts[0].Message != "disk full" {
+		t.Fatalf("texts %+v", texts)
+	}
+	if len(images) != 1 || images[0].RefreshNow == nil || !*images[0].RefreshNow {
+		t.Fatalf("images %+v", images)
+	}
+
+	s.Reset()
+	s.Response = &APIResponse{Code: 7, Message: "queued"}
+	resp, _ = s.SendImage(context.Background(), ImageRequest{})
+	resp.Message = "mutated"
+	if resp, _ := s.SendImage(context.Background(), ImageRequest{}); resp.Code != 7 || resp.Message != "queued" {
+		t.Fatalf("canned response %+v", resp)
+	}
+	boom := errors.New("boom")
+	s.Err = boom
+	if err := notify(context.Background(), &s, "x"); err != boom {
+		t.Fatalf("err %v", err)
+	}
+	if len(s.Texts()) != 1 || len(s.Images()) != 2 {
+		t.Fatalf("after reset: %d texts, %d images", len(s.Texts()), len(s.Images()))
+	}
+}
+
+func TestRecordingSender_Concurrent(t *testing.T) {
+	var s RecordingSender
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = s.SendText(context.Background(), TextRequest{})
+		}()
+	}
+	wg.Wait()
+	if n := len(s.Texts()); n != 20 {
+		t.Fatalf("recorded %d", n)
+	}
+}
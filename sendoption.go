@@ -0,0 +1,162 @@
+package quote0
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// SendOption adjusts a single SendText or SendImage call. Options are applied after the
+// request struct fields, so an option wins over the matching field; the client's default
+// device is used only when neither the struct nor WithDevice names one.
+type SendOption func(*sendConfig)
+
+type sendConfig struct {
+	deviceID  *string
+	refresh   *bool
+	link      *string
+	signature *string
+	timeout   time.Duration
+	headers   http.Header
+	noLimit   bool
+	requestID string
+}
+
+// WithDevice targets deviceID, overriding the request's DeviceID. An empty deviceID leaves
+// the request unchanged.
+func WithDevice(deviceID string) SendOption {
+	return func(cfg *sendConfig) {
+		if id := strings.TrimSpace(deviceID); id != "" {
+			cfg.deviceID = &id
+		}
+	}
+}
+
+// WithRefresh sets refreshNow, overriding the request's RefreshNow.
+func WithRefresh(refresh bool) SendOption {
+	return func(cfg *sendConfig) { cfg.refresh = Bool(refresh) }
+}
+
+// WithLink sets the link opened by the companion app, overriding the request's Link.
+// An empty url clears it.
+func WithLink(url string) SendOption {
+	return func(cfg *sendConfig) { cfg.link = &url }
+}
+
+// WithTimeout bounds the call, including the rate limiter wait, by d on top of any deadline
+// already on the context. Non-positive values are ignored.
+func WithTimeout(d time.Duration) SendOption {
+	return func(cfg *sendConfig) {
+		if d > 0 {
+			cfg.timeout = d
+		}
+	}
+}
+
+// WithCallHeader sets an extra HTTP header on this call only, e.g. a tenant or request ID
+// for a relay in front of the API. It is applied after WithTraceHeader values and before a
+// WithRequestSigner runs, so it is signed too; repeating a name replaces the value. Empty
+// names and the headers the client owns (Authorization, Content-Type, User-Agent) are
+// ignored.
+func WithCallHeader(name, value string) SendOption {
+	return func(cfg *sendConfig) {
+		name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+		switch name {
+		case "", "Authorization", "Content-Type", "User-Agent":
+			return
+		}
+		if cfg.headers == nil {
+			cfg.headers = make(http.Header)
+		}
+		cfg.headers.Set(name, value)
+	}
+}
+
+// WithoutRateLimit sends this call without waiting for the client's rate limiter, exactly
+// like a context marked with WithUrgent: it is counted by UrgentCalls, reported to
+// WithUrgentHook, and still reserved on the limiter's schedule. Other calls keep waiting.
+func WithoutRateLimit() SendOption {
+	return func(cfg *sendConfig) { cfg.noLimit = true }
+}
+
+// simpleSignature turns the variadic signature of the SendTextSimple helpers into an option
+// setting it, or none when omitted.
+func simpleSignature(signature []string) []SendOption {
+	if len(signature) == 0 {
+		return nil
+	}
+	sig := signature[0]
+	return []SendOption{func(cfg *sendConfig) { cfg.signature = &sig }}
+}
+
+// newSendConfig applies opts in order; later options win.
+func newSendConfig(opts []SendOption) sendConfig {
+	var cfg sendConfig
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&cfg)
+		}
+	}
+	return cfg
+}
+
+// applyText copies the configured overrides onto r.
+func (cfg sendConfig) applyText(r *TextRequest) {
+	if cfg.deviceID != nil {
+		r.DeviceID = *cfg.deviceID
+	}
+	if cfg.refresh != nil {
+		r.RefreshNow = Bool(*cfg.refresh)
+	}
+	if cfg.link != nil {
+		r.Link = *cfg.link
+	}
+	if cfg.signature != nil {
+		r.Signature = *cfg.signature
+	}
+}
+
+// applyImage copies the configured overrides onto r.
+func (cfg sendConfig) applyImage(r *ImageRequest) {
+	if cfg.deviceID != nil {
+		r.DeviceID = *cfg.deviceID
+	}
+	if cfg.refresh != nil {
+		r.RefreshNow = Bool(*cfg.refresh)
+	}
+	if cfg.link != nil {
+		r.Link = *cfg.link
+	}
+}
+
+// callHeadersKey carries WithCallHeader values from SendText/SendImage to doJSON.
+type callHeadersKey struct{}
+
+// callHeaders returns the WithCallHeader values carried by ctx, if any.
+func callHeaders(ctx context.Context) http.Header {
+	h, _ := ctx.Value(callHeadersKey{}).(http.Header)
+	return h
+}
+
+// context derives the call context: the WithTimeout deadline, WithCallHeader values,
+// WithoutRateLimit, and WithCallRequestID travel on it, so options never touch the shared
+// client.
+func (cfg sendConfig) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if len(cfg.headers) > 0 {
+		ctx = context.WithValue(ctx, callHeadersKey{}, cfg.headers)
+	}
+	if cfg.noLimit {
+		ctx = WithUrgent(ctx)
+	}
+	if cfg.requestID != "" {
+		ctx = WithRequestID(ctx, cfg.requestID)
+	}
+	if cfg.timeout > 0 {
+		return context.WithTimeout(ctx, cfg.timeout)
+	}
+	return ctx, func() {}
+}
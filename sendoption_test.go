@@ -0,0 +1,162 @@
+package quote0
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSendOption_Precedence(t *testing.T) {
+	s := &stagedServer{}
+	c := newStagedClient(t, s)
+	ctx := context.Background()
+
+	cases := []struct {
+		name    string
+		req     TextRequest
+		opts    []SendOption
+		device  string
+		refresh interface{}
+		link    interface{}
+	}{
+		{"client default", TextRequest{}, nil, "DEF", nil, nil},
+		{"struct over default", TextRequest{DeviceID: "S"}, nil, "S", nil, nil},
+		{"option over struct", TextRequest{DeviceID: "S"}, []SendOption{WithDevice("O")}, "O", nil, nil},
+		{"option over default", TextRequest{}, []SendOption{WithDevice("O")}, "O", nil, nil},
+		{"empty option keeps struct", TextRequest{DeviceID: "S"}, []SendOption{WithDevice(" ")}, "S", nil, nil},
+		{"refresh option over struct", TextRequest{RefreshNow: Bool(true)}, []SendOption{WithRefresh(false)}, "DEF", false, nil},
+		{"link option over struct", TextRequest{Link: "a"}, []SendOption{WithLink("b")}, "DEF", nil, "b"},
+		{"later option wins", TextRequest{}, []SendOption{WithRefresh(false), nil, WithRefresh(true)}, "DEF", true, nil},
+	}
+	for _, tc := range cases {
+		s.got = nil
+		if _, err := c.SendText(ctx, tc.req, tc.opts...); err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		body := s.got[0]
+		if body["deviceId"] != tc.device || body["refreshNow"] != tc.refresh || body["link"] != tc.link {
+			t.Fatalf("%s: payload %v", tc.name, body)
+		}
+	}
+}
+
+func TestSendOption_Image(t *testing.T) {
+	s := &stagedServer{}
+	c := newStagedClient(t, s)
+	if _, err := c.SendImageSimpleContext(context.Background(), "aGk=", WithDevice("X"), WithRefresh(false), WithLink("u")); err != nil {
+		t.Fatal(err)
+	}
+	body := s.got[0]
+	if body["deviceId"] != "X" || body["refreshNow"] != false || body["link"] != "u" {
+		t.Fatalf("payload %v", body)
+	}
+	if _, ok := c.LastSent("X"); !ok {
+		t.Fatal("send not recorded under the option device")
+	}
+}
+
+func TestSendTextSimpleContext(t *testing.T) {
+	s := &stagedServer{}
+	c := newStagedClient(t, s)
+	if _, err := c.SendTextSimpleContext(context.Background(), "t", "m"); err != nil {
+		t.Fatal(err)
+	}
+	body := s.got[0]
+	if body["deviceId"] != "DEF" || body["refreshNow"] != true || body["title"] != "t" || body["message"] != "m" {
+		t.Fatalf("payload %v", body)
+	}
+}
+
+func TestSendOption_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	c, err := NewClient("test", WithBaseURL(srv.URL), WithDefaultDeviceID("DEF"), WithRateLimiter(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	_, err = c.SendText(context.Background(), TextRequest{Title: "t"}, WithTimeout(50*time.Millisecond))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err %v", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Fatal("timeout not applied")
+	}
+}
+
+func TestSendOption_PerCallIsolation(t *testing.T) {
+	type seen struct{ title, tenant, relay string }
+	got := make(chan seen, 4)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body TextRequest
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		got <- seen{body.Title, r.Header.Get("X-Tenant"), r.Header.Get("X-Relay")}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"code":0}`)
+	}))
+	defer srv.Close()
+
+	// The limiter holds every waiting call until released.
+	release := make(chan struct{})
+	limiter := RateLimiterFunc(func(ctx context.Context) error {
+		select {
+		case <-release:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	c, err := NewClient("test", WithBaseURL(srv.URL), WithDefaultDeviceID("DEF"), WithRateLimiter(limiter))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	errs := make(chan error, 2)
+	go func() {
+		_, err := c.SendText(ctx, TextRequest{Title: "queued"}, WithCallHeader("x-tenant", "b"))
+		errs <- err
+	}()
+	go func() {
+		_, err := c.SendText(ctx, TextRequest{Title: "urgent"}, WithCallHeader("X-Tenant", "a"),
+			WithCallHeader("X-Relay", "1"), WithCallHeader("Authorization", "Bearer other"), WithoutRateLimit())
+		errs <- err
+	}()
+
+	if s := <-got; s != (seen{"urgent", "a", "1"}) {
+		t.Fatalf("first call %+v", s)
+	}
+	if err := <-errs; err != nil {
+		t.Fatal(err)
+	}
+	close(release)
+	if s := <-got; s != (seen{"queued", "b", ""}) {
+		t.Fatalf("queued call %+v", s)
+	}
+	if err := <-errs; err != nil {
+		t.Fatal(err)
+	}
+	if c.UrgentCalls() != 1 {
+		t.Fatalf("urgent calls %d", c.UrgentCalls())
+	}
+
+	// Nothing stuck to the client.
+	if _, err := c.SendText(ctx, TextRequest{Title: "plain"}); err != nil {
+		t.Fatal(err)
+	}
+	if s := <-got; s != (seen{"plain", "", ""}) {
+		t.Fatalf("plain call %+v", s)
+	}
+}
@@ -0,0 +1,55 @@
+package quote0
+
+import (
+	"strings"
+	"time"
+)
+
+// DefaultSignatureFormat is the signature layout used when none is given: the local date and
+// time, e.g. "2025-11-10 09:30:00".
+const DefaultSignatureFormat = "2006-01-02 15:04:05"
+
+// SignatureTokens are the values substituted for the {host} and {user} tokens of a
+// signature format.
+type SignatureTokens struct {
+	Host string
+	User string
+}
+
+// FormatSignature renders format, a Go time layout that may also contain the tokens {host}
+// and {user}, for t (in t's location). Tokens are substituted after the layout is applied, so
+// host and user names are never read as layout elements; an empty format uses
+// DefaultSignatureFormat. Results longer than MaxSignatureRunes are cut with Truncate, and
+// truncated reports that.
+func FormatSignature(format string, t time.Time, tok SignatureTokens) (sig string, truncated bool) {
+	if format == "" {
+		format = DefaultSignatureFormat
+	}
+	var b strings.Builder
+	for format != "" {
+		i, token, value := nextSignatureToken(format, tok)
+		if i < 0 {
+			b.WriteString(t.Format(format))
+			break
+		}
+		b.WriteString(t.Format(format[:i]))
+		b.WriteString(value)
+		format = format[i+len(token):]
+	}
+	sig = strings.TrimSpace(b.String())
+	if out := Truncate(sig, MaxSignatureRunes); out != sig {
+		return out, true
+	}
+	return sig, false
+}
+
+// nextSignatureToken finds the first token in format; i is -1 when there is none.
+func nextSignatureToken(format string, tok SignatureTokens) (i int, token, value string) {
+	i = -1
+	for _, tv := range [][2]string{{"{host}", tok.Host}, {"{user}", tok.User}} {
+		if j := strings.Index(format, tv[0]); j >= 0 && (i < 0 || j < i) {
+			i, token, value = j, tv[0], tv[1]
+		}
+	}
+	return i, token, value
+}
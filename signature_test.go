@@ -0,0 +1,27 @@
+package quote0
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatSignature(t *testing.T) {
+	at := time.Date(2025, 11, 10, 9, 30, 5, 0, time.UTC)
+	// "Mon" and "15" inside the host name must not be read as layout elements.
+	tok := SignatureTokens{Host: "Mon15", User: "ops"}
+	for _, tc := range []struct {
+		format, want string
+		truncated    bool
+	}{
+		{"", "2025-11-10 09:30:05", false},
+		{"{host}@01-02 15:04:05", "Mon15@11-10 09:30:05", false},
+		{"{user}@{host} Jan 2", "ops@Mon15 Nov 10", false},
+		{"{host} {user} {host}", "Mon15 ops Mon15", false},
+		{"Monday, January 2 2006 15:04:05 MST", "Monday, November 10 2025…", true},
+	} {
+		got, truncated := FormatSignature(tc.format, at, tok)
+		if got != tc.want || truncated != tc.truncated {
+			t.Errorf("FormatSignature(%q) = %q, %v; want %q, %v", tc.format, got, truncated, tc.want, tc.truncated)
+		}
+	}
+}
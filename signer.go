@@ -0,0 +1,56 @@
+package quote0
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultSignerHeader is the header HMACSHA256Signer sets when given an empty name.
+const DefaultSignerHeader = "X-Quote0-Signature"
+
+// RequestSigner adds authentication to an outgoing API request, typically a header computed
+// over body, the exact bytes that will be sent.
+type RequestSigner func(req *http.Request, body []byte) error
+
+// WithRequestSigner calls sign for every HTTP attempt, for relays in front of the API that
+// require signed requests. It runs after the limiter wait and after the SDK has set its own
+// headers (Authorization, Content-Type, User-Agent, trace headers), just before the request
+// is sent, so it may add or override headers; debug logs show the signed request. Request
+// bodies are always fully buffered JSON, so body is complete and sign may read it freely.
+//
+// An error from sign aborts the call with that error wrapped and is not retried on fallback
+// hosts. The limiter slot is already spent by then, so a failing signer still counts against
+// the rate limit. Each fallback host gets a fresh signature. nil removes the signer.
+func WithRequestSigner(sign RequestSigner) ClientOption {
+	return func(c *Client) { c.signer = sign }
+}
+
+// HMACSHA256Signer signs requests for WithRequestSigner with an HMAC-SHA256 of the current
+// Unix time and the body, setting headerName (DefaultSignerHeader if empty) to
+// "t=<unix seconds>,v1=<hex digest>". The digest covers the timestamp, a ".", and the body,
+// so a relay can reject stale or altered requests. An empty secret makes every call fail.
+func HMACSHA256Signer(secret []byte, headerName string) RequestSigner {
+	key := append([]byte(nil), secret...)
+	name := strings.TrimSpace(headerName)
+	if name == "" {
+		name = DefaultSignerHeader
+	}
+	return func(req *http.Request, body []byte) error {
+		if len(key) == 0 {
+			return errors.New("quote0: HMAC signer secret is empty")
+		}
+		ts := strconv.FormatInt(time.Now().Unix(), 10)
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(ts))
+		mac.Write([]byte("."))
+		mac.Write(body)
+		req.Header.Set(name, "t="+ts+",v1="+hex.EncodeToString(mac.Sum(nil)))
+		return nil
+	}
+}
@@ -0,0 +1,137 @@
+package quote0
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// verifyRelaySignature checks a HMACSHA256Signer header the way a relay would.
+func verifyRelaySignature(secret []byte, header string, body []byte, maxAge time.Duration) error {
+	var ts, sig string
+	for _, part := range strings.Split(header, ",") {
+		if v := strings.TrimPrefix(part, "t="); v != part {
+			ts = v
+		} else if v := strings.TrimPrefix(part, "v1="); v != part {
+			sig = v
+		}
+	}
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return errors.New("bad timestamp")
+	}
+	if age := time.Since(time.Unix(unix, 0)); age > maxAge || age < -maxAge {
+		return errors.New("stale")
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(ts + "."))
+	mac.Write(body)
+	want := hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(sig), []byte(want)) {
+		return errors.New("bad signature")
+	}
+	return nil
+}
+
+func newRelay(t *testing.T, secret []byte, header string) (*httptest.Server, *[]error) {
+	t.Helper()
+	var results []error
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		err := verifyRelaySignature(secret, r.Header.Get(header), body, time.Minute)
+		results = append(results, err)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"code":0}`)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &results
+}
+
+func TestHMACSHA256Signer_RelayAccepts(t *testing.T) {
+	secret := []byte("relay-secret")
+	srv, results := newRelay(t, secret, DefaultSignerHeader)
+	c, err := NewClient("test", WithBaseURL(srv.URL), WithRateLimiter(nil), WithDefaultDeviceID("D"),
+		WithRequestSigner(HMACSHA256Signer(secret, "")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if _, err := c.SendText(ctx, TextRequest{Title: "signed", Message: "über"}); err != nil {
+		t.Fatal(err)
+	}
+	// A full-size image body is signed byte for byte as sent.
+	if _, err := c.SendImage(ctx, ImageRequest{ImageBytes: pngBytes(t, ScreenWidth, ScreenHeight)}); err != nil {
+		t.Fatal(err)
+	}
+	if len(*results) != 2 {
+		t.Fatalf("relay saw %d requests", len(*results))
+	}
+
+	// A client with the wrong secret is turned away.
+	bad, _ := NewClient("test", WithBaseURL(srv.URL), WithRateLimiter(nil), WithDefaultDeviceID("D"),
+		WithRequestSigner(HMACSHA256Signer([]byte("wrong"), DefaultSignerHeader)))
+	if _, err := bad.SendText(ctx, TextRequest{Title: "t"}); !IsAuthError(err) {
+		t.Fatalf("wrong secret: %v", err)
+	}
+}
+
+func TestWithRequestSigner_SeesFinalRequest(t *testing.T) {
+	var sent []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sent, _ = io.ReadAll(r.Body)
+		if r.Header.Get("X-Relay") != "yes" {
+			t.Errorf("signer header missing: %v", r.Header)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"code":0}`)
+	}))
+	defer srv.Close()
+	var signed []byte
+	sign := func(req *http.Request, body []byte) error {
+		if req.Header.Get("Authorization") != "Bearer test" || req.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("standard headers not set before signing: %v", req.Header)
+		}
+		signed = append([]byte(nil), body...)
+		req.Header.Set("X-Relay", "yes")
+		return nil
+	}
+	c, _ := NewClient("test", WithBaseURL(srv.URL), WithRateLimiter(nil), WithDefaultDeviceID("D"), WithRequestSigner(sign))
+	if _, err := c.SendText(context.Background(), TextRequest{Title: "t", Message: "m"}); err != nil {
+		t.Fatal(err)
+	}
+	if string(signed) != string(sent) || len(sent) == 0 {
+		t.Fatalf("signed %q, sent %q", signed, sent)
+	}
+}
+
+func TestWithRequestSigner_ErrorAbortsAfterLimiter(t *testing.T) {
+	var hits, waits int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { hits++ }))
+	defer srv.Close()
+	limiter := RateLimiterFunc(func(context.Context) error { waits++; return nil })
+	c, _ := NewClient("test", WithBaseURL(srv.URL), WithRateLimiter(limiter), WithDefaultDeviceID("D"),
+		WithRequestSigner(HMACSHA256Signer(nil, "X-Sig")))
+	_, err := c.SendText(context.Background(), TextRequest{Title: "t"})
+	if err == nil || !strings.Contains(err.Error(), "sign request") || !strings.Contains(err.Error(), "secret is empty") {
+		t.Fatalf("err %v", err)
+	}
+	if hits != 0 || waits != 1 {
+		t.Fatalf("%d requests sent, %d limiter waits", hits, waits)
+	}
+	if IsNetworkError(err) {
+		t.Fatal("signer failure classified as a network error")
+	}
+}
@@ -0,0 +1,146 @@
+package quote0
+
+import (
+	"encoding/json"
+	"html"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// slackPayload is the subset of a Slack incoming-webhook message the gateway understands.
+type slackPayload struct {
+	Text        string            `json:"text"`
+	Username    string            `json:"username"`
+	Attachments []slackAttachment `json:"attachments"`
+}
+
+type slackAttachment struct {
+	Fallback  string `json:"fallback"`
+	Pretext   string `json:"pretext"`
+	Title     string `json:"title"`
+	TitleLink string `json:"title_link"`
+	Text      string `json:"text"`
+}
+
+// slackAngle matches Slack's angle-bracket escapes: <url>, <url|label>, <@U123>, <#C1|name>, <!here>.
+var slackAngle = regexp.MustCompile(`<([^<>|]*)(?:\|([^<>]*))?>`)
+
+// slackEmphasis matches *bold*, _italic_, ~strike~, and `code` spans around non-space text.
+var slackEmphasis = regexp.MustCompile("([*_~`])([^*_~`\\s](?:[^*_~`\\n]*[^*_~`\\s])?)([*_~`])")
+
+// handleSlack accepts a Slack incoming-webhook payload, either as a JSON body or as the
+// legacy form field "payload". The target device may be given as ?deviceId=; it otherwise
+// falls back to the client's default. On success the body is "ok", as Slack senders expect.
+func (h *gatewayHandler) handleSlack(w http.ResponseWriter, r *http.Request) {
+	raw, ok := h.readBody(w, r)
+	if !ok {
+		return
+	}
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		form, err := url.ParseQuery(string(raw))
+		if err != nil {
+			writeGatewayJSON(w, http.StatusBadRequest, gatewayError{Error: "invalid form body: " + err.Error()})
+			return
+		}
+		raw = []byte(form.Get("payload"))
+	}
+	var p slackPayload
+	if err := json.Unmarshal(raw, &p); err != nil {
+		writeGatewayJSON(w, http.StatusBadRequest, gatewayError{Error: "invalid_payload: " + err.Error()})
+		return
+	}
+	req, ok := slackToText(p)
+	if !ok {
+		writeGatewayJSON(w, http.StatusBadRequest, gatewayError{Error: "no_text"})
+		return
+	}
+	req.DeviceID = r.URL.Query().Get("deviceId")
+	if _, err := h.client.SendText(r.Context(), req); err != nil {
+		status, body := gatewayStatus(err)
+		writeGatewayJSON(w, status, body)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = w.Write([]byte("ok"))
+}
+
+// slackToText maps a webhook payload onto the text layout. The first attachment's title
+// becomes the title when present; otherwise the text is split by SplitTitleMessage.
+// Oversized content is truncated. It reports false when there is nothing to show.
+func slackToText(p slackPayload) (TextRequest, bool) {
+	var att slackAttachment
+	if len(p.Attachments) > 0 {
+		att = p.Attachments[0]
+	}
+	text := p.Text
+	if strings.TrimSpace(text) == "" {
+		text = firstNonEmpty(att.Text, att.Pretext, att.Fallback)
+	}
+	body, link := slackPlain(text)
+
+	req := TextRequest{RefreshNow: Bool(true), Signature: Truncate(strings.TrimSpace(p.Username), MaxTitleRunes)}
+	if title, titleLink := slackPlain(att.Title); title != "" {
+		req.Title = Truncate(singleLine(title), MaxTitleRunes)
+		req.Message = Truncate(body, MaxMessageRunes)
+		link = firstNonEmpty(strings.TrimSpace(att.TitleLink), titleLink, link)
+	} else {
+		req.Title, req.Message = SplitTitleMessage(body)
+	}
+	req.Link = link
+	if req.Title == "" && req.Message == "" {
+		return TextRequest{}, false
+	}
+	return req, true
+}
+
+// slackPlain converts Slack mrkdwn to plain text and returns the first linked URL.
+func slackPlain(s string) (string, string) {
+	var link string
+	s = slackAngle.ReplaceAllStringFunc(s, func(m string) string {
+		parts := slackAngle.FindStringSubmatch(m)
+		target, label := parts[1], parts[2]
+		switch {
+		case strings.HasPrefix(target, "@"), strings.HasPrefix(target, "#"):
+			if label != "" {
+				return target[:1] + label
+			}
+			return target
+		case strings.HasPrefix(target, "!"):
+			if label != "" {
+				return label
+			}
+			return "@" + strings.TrimPrefix(target, "!")
+		}
+		if link == "" {
+			link = target
+		}
+		if label != "" {
+			return label
+		}
+		return strings.TrimPrefix(target, "mailto:")
+	})
+	s = slackEmphasis.ReplaceAllStringFunc(s, func(m string) string {
+		parts := slackEmphasis.FindStringSubmatch(m)
+		if parts[1] != parts[3] {
+			return m
+		}
+		return parts[2]
+	})
+	s = strings.ReplaceAll(s, "```", "")
+	return strings.TrimSpace(html.UnescapeString(s)), link
+}
+
+func singleLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
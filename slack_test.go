@@ -0,0 +1,127 @@
+package quote0
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestSlackToText(t *testing.T) {
+	cases := []struct {
+		name    string
+		payload string
+		want    TextRequest
+	}{
+		{
+			name:    "plain text",
+			payload: `{"text":"Disk almost full on nas"}`,
+			want:    TextRequest{Title: "Disk almost full on nas"},
+		},
+		{
+			name:    "alertmanager style with link and username",
+			payload: `{"username":"Alertmanager","text":"*[FIRING:1] HighLoad*\nnode_load1 &gt; 4 on <https://grafana.example.com/d/abc|nas dashboard>"}`,
+			want: TextRequest{
+				Title:     "[FIRING:1] HighLoad",
+				Message:   "node_load1 > 4 on nas dashboard",
+				Signature: "Alertmanager",
+				Link:      "https://grafana.example.com/d/abc",
+			},
+		},
+		{
+			name: "attachment title from a CI notifier",
+			payload: `{"text":"","attachments":[{"fallback":"Build #42 failed","title":"Build #42 failed",
+				"title_link":"https://ci.example.com/42","text":"_main_ by <@U123|alice>: ` + "`go test`" + ` exited 1"}]}`,
+			want: TextRequest{
+				Title:   "Build #42 failed",
+				Message: "main by @alice: go test exited 1",
+				Link:    "https://ci.example.com/42",
+			},
+		},
+		{
+			name:    "mentions and bare links",
+			payload: `{"text":"<!here> deploy done\nsee <https://status.example.com> ~old~ notes in <#C01|ops>"}`,
+			want: TextRequest{
+				Title:   "@here deploy done",
+				Message: "see https://status.example.com old notes in #ops",
+				Link:    "https://status.example.com",
+			},
+		},
+		{
+			name:    "oversized text is truncated",
+			payload: `{"text":"` + strings.Repeat("word ", 80) + `"}`,
+			want:    TextRequest{Message: strings.TrimSpace(strings.Repeat("word ", 24)) + "…"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var p slackPayload
+			if err := json.Unmarshal([]byte(tc.payload), &p); err != nil {
+				t.Fatal(err)
+			}
+			got, ok := slackToText(p)
+			if !ok {
+				t.Fatal("payload rejected")
+			}
+			if got.Title != tc.want.Title || got.Message != tc.want.Message || got.Signature != tc.want.Signature || got.Link != tc.want.Link {
+				t.Fatalf("got  %+v\nwant %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestGateway_Slack(t *testing.T) {
+	var got TextRequest
+	gw := newGatewayPair(t, func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		_, _ = io.WriteString(w, `{"code":0}`)
+	})
+
+	resp, err := http.Post(gw.URL+"/slack?deviceId=ABC", "application/json", strings.NewReader(`{"text":"Backup OK\nall 3 volumes"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(body) != "ok" {
+		t.Fatalf("status=%d body=%q", resp.StatusCode, body)
+	}
+	if got.DeviceID != "ABC" || got.Title != "Backup OK" || got.Message != "all 3 volumes" {
+		t.Fatalf("forwarded %+v", got)
+	}
+
+	// Legacy senders post the JSON in a "payload" form field.
+	form := url.Values{"payload": {`{"text":"from form"}`}}
+	resp, err = http.PostForm(gw.URL+"/slack", form)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || got.DeviceID != "DEF" || got.Title != "from form" {
+		t.Fatalf("form: status=%d forwarded %+v", resp.StatusCode, got)
+	}
+
+	for _, payload := range []string{`{"text":"  "}`, `not json`} {
+		if status, _ := postGateway(t, gw.URL+"/slack", "", payload); status != http.StatusBadRequest {
+			t.Errorf("%s: status=%d", payload, status)
+		}
+	}
+}
+
+func TestSplitTitleMessage(t *testing.T) {
+	cases := []struct{ in, title, msg string }{
+		{"", "", ""},
+		{"Hello", "Hello", ""},
+		{"Title\r\n\r\nline one\nline two", "Title", "line one\nline two"},
+		{strings.Repeat("x", 40), "", strings.Repeat("x", 40)},
+		{strings.Repeat("t", 40) + "\nbody", strings.Repeat("t", 27) + "…", "body"},
+	}
+	for _, tc := range cases {
+		title, msg := SplitTitleMessage(tc.in)
+		if title != tc.title || msg != tc.msg {
+			t.Errorf("SplitTitleMessage(%q) = %q, %q", tc.in, title, msg)
+		}
+	}
+}
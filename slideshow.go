@@ -0,0 +1,139 @@
+package quote0
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+)
+
+const defaultSlideInterval = time.Minute
+
+// ErrNoSlides is returned by SlideShow when a pass has nothing to show.
+var ErrNoSlides = errors.New("quote0: slideshow has no slides")
+
+// Slide is one image shown by SlideShow.
+type Slide struct {
+	// Name identifies the slide in events, for example its file name.
+	Name string
+	// Image is the payload sent for the slide; an empty DeviceID uses the client default.
+	Image ImageRequest
+	// Duration overrides the show interval for this slide when positive.
+	Duration time.Duration
+}
+
+// SlideEvent reports one send made by SlideShow.
+type SlideEvent struct {
+	// Slide is the slide that was sent.
+	Slide Slide
+	// Pass counts complete cycles through the slides, starting at 1.
+	Pass int
+	// At is when the send finished.
+	At time.Time
+	// Response is the API response for a successful send.
+	Response *APIResponse
+	// Err is the send failure, if any.
+	Err error
+}
+
+// SlideShowOption tunes SlideShow.
+type SlideShowOption func(*slideShowConfig)
+
+type slideShowConfig struct {
+	interval time.Duration
+	passes   int
+	shuffle  func([]Slide)
+	onEvent  func(SlideEvent)
+	after    func(time.Duration) <-chan time.Time
+}
+
+// WithSlideInterval sets how long each slide stays up before the next is sent (default 1m).
+func WithSlideInterval(d time.Duration) SlideShowOption {
+	return func(cfg *slideShowConfig) {
+		if d > 0 {
+			cfg.interval = d
+		}
+	}
+}
+
+// WithSlidePasses stops the show after n complete passes; zero (the default) loops until
+// the context ends.
+func WithSlidePasses(n int) SlideShowOption {
+	return func(cfg *slideShowConfig) {
+		if n >= 0 {
+			cfg.passes = n
+		}
+	}
+}
+
+// WithSlideShuffle shuffles the slides at the start of every pass.
+func WithSlideShuffle() SlideShowOption {
+	return func(cfg *slideShowConfig) {
+		rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+		cfg.shuffle = func(s []Slide) {
+			rnd.Shuffle(len(s), func(i, j int) { s[i], s[j] = s[j], s[i] })
+		}
+	}
+}
+
+// WithSlideCallback receives an event after every send.
+// It runs on the slideshow goroutine; slow callbacks delay the show.
+func WithSlideCallback(fn func(SlideEvent)) SlideShowOption {
+	return func(cfg *slideShowConfig) { cfg.onEvent = fn }
+}
+
+// SlideShow cycles through images, sending one every interval through the client (so its rate
+// limiter applies) until ctx ends or the configured number of passes completes. slides is
+// called at the start of every pass, so a source that lists a directory picks up new files.
+// A failed send is reported through the callback and the show moves on to the next slide;
+// an error from slides or an empty pass (ErrNoSlides) stops the show. When ctx ends the
+// returned error is ctx.Err().
+func (c *Client) SlideShow(ctx context.Context, slides func(ctx context.Context) ([]Slide, error), opts ...SlideShowOption) error {
+	if slides == nil {
+		return errors.New("quote0: slideshow requires a slide source")
+	}
+	cfg := slideShowConfig{
+		interval: defaultSlideInterval,
+		after:    time.After,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&cfg)
+		}
+	}
+
+	for pass := 1; cfg.passes == 0 || pass <= cfg.passes; pass++ {
+		list, err := slides(ctx)
+		if err != nil {
+			return err
+		}
+		if len(list) == 0 {
+			return ErrNoSlides
+		}
+		if cfg.shuffle != nil {
+			cfg.shuffle(list)
+		}
+		for i, s := range list {
+			resp, err := c.SendImage(ctx, s.Image)
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+			if cfg.onEvent != nil {
+				cfg.onEvent(SlideEvent{Slide: s, Pass: pass, At: time.Now(), Response: resp, Err: err})
+			}
+			if pass == cfg.passes && i == len(list)-1 {
+				break // nothing follows the final slide
+			}
+			d := cfg.interval
+			if s.Duration > 0 {
+				d = s.Duration
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-cfg.after(d):
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package quote0
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSlideShow(t *testing.T) {
+	var mu sync.Mutex
+	var links []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ImageRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		mu.Lock()
+		links = append(links, req.Link)
+		mu.Unlock()
+		_, _ = io.WriteString(w, "ok")
+	}))
+	defer srv.Close()
+	c, err := NewClient("test", WithBaseURL(srv.URL), WithDefaultDeviceID("D"), WithRateLimiter(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	slide := func(name string) Slide {
+		return Slide{Name: name, Image: ImageRequest{Image: "aGk=", Link: name}}
+	}
+	passes := 0
+	source := func(context.Context) ([]Slide, error) {
+		passes++
+		list := []Slide{slide("a"), {Name: "empty"}}
+		if passes > 1 {
+			// A file that appeared between passes, shown for longer.
+			s := slide("b")
+			s.Duration = time.Hour
+			list = append(list, s)
+		}
+		return list, nil
+	}
+	var waits []time.Duration
+	var events []SlideEvent
+	err = c.SlideShow(context.Background(), source,
+		WithSlidePasses(2),
+		WithSlideInterval(time.Minute),
+		WithSlideCallback(func(ev SlideEvent) { events = append(events, ev) }),
+		func(cfg *slideShowConfig) {
+			cfg.after = func(d time.Duration) <-chan time.Time {
+				waits = append(waits, d)
+				ch := make(chan time.Time, 1)
+				ch <- time.Time{}
+				return ch
+			}
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 5 || events[4].Pass != 2 || events[4].Slide.Name != "b" {
+		t.Fatalf("events %+v", events)
+	}
+	if !errors.Is(events[1].Err, ErrImagePayloadMissing) {
+		t.Fatalf("empty slide: %v", events[1].Err)
+	}
+	if len(links) != 3 || links[0] != "a" || links[2] != "b" {
+		t.Fatalf("sent %v", links)
+	}
+	// No wait follows the final slide of the final pass.
+	if len(waits) != 4 || waits[0] != time.Minute {
+		t.Fatalf("waits %v", waits)
+	}
+}
+
+func TestSlideShow_StopsOnEmptySourceOrCancel(t *testing.T) {
+	c, err := NewClient("test", WithDefaultDeviceID("D"), WithRateLimiter(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	empty := func(context.Context) ([]Slide, error) { return nil, nil }
+	if err := c.SlideShow(context.Background(), empty); !errors.Is(err, ErrNoSlides) {
+		t.Fatalf("want ErrNoSlides, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	one := func(context.Context) ([]Slide, error) { return []Slide{{Name: "x"}}, nil }
+	if err := c.SlideShow(ctx, one); !errors.Is(err, context.Canceled) {
+		t.Fatalf("want context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+//go:build go1.21
+
+package quote0
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sort"
+	"time"
+)
+
+// WithLogger sends structured logs to logger: one entry per API call with the endpoint,
+// device ID, request ID, trace header values (a "trace" group, see WithTraceHeader),
+// refreshNow, payload size, HTTP status, API code, attempts, and elapsed time (Info on
+// success, Error with the parsed APIError fields on failure), and a Warn entry whenever the
+// rate limiter holds a call for more than 500ms. A nil logger disables logging, the default,
+// which costs nothing per call. It needs Go 1.21 or later.
+func WithLogger(logger *slog.Logger) ClientOption {
+	return func(c *Client) {
+		c.logger = nil
+		if logger != nil {
+			c.logger = slogLogger{logger}
+		}
+	}
+}
+
+// slogLogger is the log/slog callLogger.
+type slogLogger struct {
+	l *slog.Logger
+}
+
+func (s slogLogger) logCall(ctx context.Context, l *callLog) {
+	attrs := []slog.Attr{
+		slog.String("endpoint", l.endpoint),
+		slog.String("device", l.deviceID),
+		slog.String("requestID", l.requestID),
+		slog.Int("payloadBytes", l.payloadSize),
+		slog.Duration("elapsed", l.elapsed),
+	}
+	if l.refreshNow != nil {
+		attrs = append(attrs, slog.Bool("refreshNow", *l.refreshNow))
+	}
+	if l.attempts > 1 {
+		attrs = append(attrs, slog.Int("attempts", l.attempts))
+	}
+	if len(l.trace) > 0 {
+		names := make([]string, 0, len(l.trace))
+		for name := range l.trace {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		trace := make([]interface{}, 0, len(names))
+		for _, name := range names {
+			trace = append(trace, slog.String(name, l.trace[name]))
+		}
+		attrs = append(attrs, slog.Group("trace", trace...))
+	}
+	var ae *APIError
+	switch {
+	case errors.As(l.err, &ae):
+		// With WithStrictCode a response comes with the APIError; its fields describe both.
+		attrs = append(attrs, slog.Int("status", ae.StatusCode), slog.String("code", ae.Code), slog.String("message", ae.Message))
+		if ae.RetryAfter > 0 {
+			attrs = append(attrs, slog.Duration("retryAfter", ae.RetryAfter))
+		}
+	case l.resp != nil:
+		attrs = append(attrs, slog.Int("status", l.resp.StatusCode), slog.Int("code", l.resp.Code))
+	}
+	if l.err == nil {
+		s.l.LogAttrs(ctx, slog.LevelInfo, "quote0 request", attrs...)
+		return
+	}
+	attrs = append(attrs, slog.String("error", l.err.Error()))
+	s.l.LogAttrs(ctx, slog.LevelError, "quote0 request failed", attrs...)
+}
+
+func (s slogLogger) logLimiterWait(ctx context.Context, endpoint, deviceID string, waited time.Duration) {
+	s.l.LogAttrs(ctx, slog.LevelWarn, "quote0 rate limiter wait",
+		slog.String("endpoint", endpoint), slog.String("device", deviceID), slog.Duration("waited", waited))
+}
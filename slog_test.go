@@ -0,0 +1,125 @@
+//go:build go1.21
+
+package quote0
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func logEntries(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var out []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var m map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &m); err != nil {
+			t.Fatalf("%v: %s", err, line)
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+func TestWithLogger_Requests(t *testing.T) {
+	fail := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = io.WriteString(w, `{"code":429,"message":"频率过高"}`)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"code":0,"message":"ok"}`)
+	}))
+	defer srv.Close()
+	var buf bytes.Buffer
+	c, err := NewClient("test", WithBaseURL(srv.URL), WithRateLimiter(nil), WithDefaultDeviceID("D"),
+		WithLogger(slog.New(slog.NewJSONHandler(&buf, nil))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.SendText(context.Background(), TextRequest{Title: "t", RefreshNow: Bool(true)}); err != nil {
+		t.Fatal(err)
+	}
+	fail = true
+	_, _ = c.SendText(context.Background(), TextRequest{Title: "t"})
+
+	entries := logEntries(t, &buf)
+	if len(entries) != 2 {
+		t.Fatalf("%d entries: %s", len(entries), buf.String())
+	}
+	ok, bad := entries[0], entries[1]
+	if ok["level"] != "INFO" || ok["endpoint"] != textEndpoint || ok["device"] != "D" || ok["refreshNow"] != true ||
+		ok["status"] != float64(200) || len(ok["requestID"].(string)) != 32 || ok["code"] != float64(0) || ok["payloadBytes"].(float64) < 10 || ok["elapsed"] == nil {
+		t.Fatalf("success entry %v", ok)
+	}
+	if bad["level"] != "ERROR" || bad["status"] != float64(429) || bad["code"] != "429" || bad["message"] != "频率过高" || bad["error"] == nil {
+		t.Fatalf("failure entry %v", bad)
+	}
+	if _, ok := bad["refreshNow"]; ok {
+		t.Fatalf("unset refreshNow logged: %v", bad)
+	}
+}
+
+func TestWithLogger_StrictCodeAndTrace(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"code":7,"message":"device offline"}`)
+	}))
+	defer srv.Close()
+	var buf bytes.Buffer
+	c, err := NewClient("test", WithBaseURL(srv.URL), WithRateLimiter(nil), WithDefaultDeviceID("D"), WithStrictCode(),
+		WithTraceHeader("X-Trace-Id", func(context.Context) string { return "trace-1" }),
+		WithLogger(slog.New(slog.NewJSONHandler(&buf, nil))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.SendText(context.Background(), TextRequest{Title: "t"}); err == nil {
+		t.Fatal("non-zero code accepted")
+	}
+	line := buf.String()
+	for _, key := range []string{`"status":`, `"code":`} {
+		if n := strings.Count(line, key); n != 1 {
+			t.Fatalf("%s logged %d times: %s", key, n, line)
+		}
+	}
+	entry := logEntries(t, &buf)[0]
+	trace, _ := entry["trace"].(map[string]interface{})
+	if entry["status"] != float64(200) || entry["code"] != "7" || entry["message"] != "device offline" || trace["X-Trace-Id"] != "trace-1" {
+		t.Fatalf("entry %v", entry)
+	}
+}
+
+func TestWithLogger_SlowLimiter(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(okHandler))
+	defer srv.Close()
+	var buf bytes.Buffer
+	slow := RateLimiterFunc(func(context.Context) error { time.Sleep(slowLimiterWait + 100*time.Millisecond); return nil })
+	c, _ := NewClient("test", WithBaseURL(srv.URL), WithRateLimiter(slow), WithDefaultDeviceID("D"),
+		WithLogger(slog.New(slog.NewJSONHandler(&buf, nil))))
+	if _, err := c.SendText(context.Background(), TextRequest{Title: "t"}); err != nil {
+		t.Fatal(err)
+	}
+	entries := logEntries(t, &buf)
+	if len(entries) != 2 || entries[0]["level"] != "WARN" || entries[0]["msg"] != "quote0 rate limiter wait" || entries[0]["device"] != "D" {
+		t.Fatalf("entries %v", entries)
+	}
+}
+
+func TestWithLogger_NilDisables(t *testing.T) {
+	c, _ := NewClient("test", WithLogger(slog.Default()), WithLogger(nil))
+	if c.logger != nil {
+		t.Fatal("WithLogger(nil) kept the logger")
+	}
+}
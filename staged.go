@@ -0,0 +1,143 @@
+package quote0
+
+import "context"
+
+// Staged sends push content with refreshNow=false so the device stores it without repainting;
+// a later FlushRefresh makes all staged changes visible in one refresh. This keeps the panel
+// from flickering through each step of a multi-part update.
+//
+// Staging always sends an explicit refreshNow=false, whatever the request carried, so it also
+// overrides a RefreshNow set by a template or saved request. PreparedRequest.Hash covers the
+// refreshNow field, so a staged request and the same request with refreshNow=true hash
+// differently; callers that skip unchanged content by hash should compare like with like.
+
+// SendTextStaged sends payload like SendText but with refreshNow forced to false.
+// LastSent records the staged content even though the panel still shows the previous one.
+func (c *Client) SendTextStaged(ctx context.Context, payload TextRequest) (*APIResponse, error) {
+	payload.RefreshNow = Bool(false)
+	return c.SendText(ctx, payload)
+}
+
+// SendImageStaged sends payload like SendImage but with refreshNow forced to false.
+// LastSent records the staged content even though the panel still shows the previous one.
+func (c *Client) SendImageStaged(ctx context.Context, payload ImageRequest) (*APIResponse, error) {
+	payload.RefreshNow = Bool(false)
+	return c.SendImage(ctx, payload)
+}
+
+// FlushRefresh sends one refresh-only call (see Refresh) to each distinct device in deviceIDs,
+// or to the client's default device when none are given. Empty IDs also mean the default
+// device. Results are returned in order of first appearance, with Index pointing into
+// deviceIDs. The error is nil when every refresh succeeded, ctx.Err() when the context ended
+// first, and a *BatchError otherwise.
+func (c *Client) FlushRefresh(ctx context.Context, deviceIDs ...string) ([]BatchResult, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if len(deviceIDs) == 0 {
+		deviceIDs = []string{""}
+	}
+	results := make([]BatchResult, 0, len(deviceIDs))
+	seen := make(map[string]bool, len(deviceIDs))
+	var failed []BatchResult
+	for i, id := range deviceIDs {
+		r := BatchResult{Index: i}
+		did, err := c.resolveDeviceID(ctx, id)
+		switch {
+		case err != nil:
+			r.Err = err
+		case seen[did]:
+			continue
+		default:
+			seen[did] = true
+			r.DeviceID = did
+			if r.Err = ctx.Err(); r.Err == nil {
+				r.Response, r.Err = c.Refresh(ctx, did)
+			}
+		}
+		results = append(results, r)
+		if r.Err != nil {
+			failed = append(failed, r)
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return results, err
+	}
+	if len(failed) > 0 {
+		return results, &BatchError{Failed: failed, Total: len(results)}
+	}
+	return results, nil
+}
+
+// StagedBatch collects text and image sends that are staged together and made visible with
+// a single refresh per device. Build one with Client.NewStagedBatch.
+type StagedBatch struct {
+	client *Client
+	items  []BatchItem
+}
+
+// StagedResult reports the outcome of StagedBatch.Send.
+type StagedResult struct {
+	// Items holds one result per staged item, in the order the items were added.
+	Items []BatchResult
+	// Refreshes holds one result per device that was refreshed, in order of first appearance.
+	Refreshes []BatchResult
+}
+
+// NewStagedBatch returns an empty staged batch that sends through c.
+func (c *Client) NewStagedBatch() *StagedBatch {
+	return &StagedBatch{client: c}
+}
+
+// AddText queues a text send. An empty DeviceID targets the client's default device.
+func (b *StagedBatch) AddText(req TextRequest) *StagedBatch {
+	req.RefreshNow = Bool(false)
+	b.items = append(b.items, BatchItem{Text: &req})
+	return b
+}
+
+// AddImage queues an image send. An empty DeviceID targets the client's default device.
+func (b *StagedBatch) AddImage(req ImageRequest) *StagedBatch {
+	req.RefreshNow = Bool(false)
+	b.items = append(b.items, BatchItem{Image: &req})
+	return b
+}
+
+// Len returns the number of queued items.
+func (b *StagedBatch) Len() int {
+	return len(b.items)
+}
+
+// Send stages every queued item through SendBatch with opts, then refreshes each device that
+// accepted at least one item. Devices whose items all failed are not refreshed, so their
+// panels keep showing the previous content. Nothing is refreshed when the context ends
+// during staging.
+//
+// The error is ctx.Err() when the context ended, the *BatchError of the staged items when
+// any of them failed, and otherwise the error of FlushRefresh.
+func (b *StagedBatch) Send(ctx context.Context, opts ...BatchOption) (*StagedResult, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	out := &StagedResult{}
+	items, itemErr := b.client.SendBatch(ctx, b.items, opts...)
+	out.Items = items
+	if err := ctx.Err(); err != nil {
+		return out, err
+	}
+	var devices []string
+	for _, r := range items {
+		if r.Err == nil {
+			devices = append(devices, r.DeviceID)
+		}
+	}
+	if len(devices) == 0 {
+		return out, itemErr
+	}
+	refreshes, flushErr := b.client.FlushRefresh(ctx, devices...)
+	out.Refreshes = refreshes
+	if itemErr != nil {
+		return out, itemErr
+	}
+	return out, flushErr
+}
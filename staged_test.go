@@ -0,0 +1,179 @@
+package quote0
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// stagedServer records every decoded request body in arrival order and fails requests for
+// the devices listed in fail.
+type stagedServer struct {
+	mu   sync.Mutex
+	got  []map[string]interface{}
+	fail map[string]bool
+}
+
+func (s *stagedServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body map[string]interface{}
+	_ = json.NewDecoder(r.Body).Decode(&body)
+	s.mu.Lock()
+	s.got = append(s.got, body)
+	s.mu.Unlock()
+	if id, _ := body["deviceId"].(string); s.fail[id] {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = io.WriteString(w, "bad device")
+		return
+	}
+	_, _ = io.WriteString(w, `{"code":0}`)
+}
+
+func newStagedClient(t *testing.T, s *stagedServer) *Client {
+	t.Helper()
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+	c, err := NewClient("test", WithBaseURL(srv.URL), WithDefaultDeviceID("DEF"), WithRateLimiter(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestSendStaged_ForcesNoRefresh(t *testing.T) {
+	s := &stagedServer{}
+	c := newStagedClient(t, s)
+	ctx := context.Background()
+	if _, err := c.SendTextStaged(ctx, TextRequest{Title: "t", RefreshNow: Bool(true)}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.SendImageStaged(ctx, ImageRequest{Image: "aGk=", RefreshNow: Bool(true)}); err != nil {
+		t.Fatal(err)
+	}
+	for i, body := range s.got {
+		if v, ok := body["refreshNow"]; !ok || v != false {
+			t.Fatalf("request %d refreshNow=%v present=%v", i, v, ok)
+		}
+	}
+	if rec, ok := c.LastSent("DEF"); !ok || rec.Image == nil {
+		t.Fatalf("staged image not recorded: %+v", rec)
+	}
+}
+
+func TestSendStaged_HashDiffersFromRefreshed(t *testing.T) {
+	c, err := NewClient("test", WithDefaultDeviceID("DEF"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	refreshed, _ := c.BuildText(TextRequest{Title: "t", RefreshNow: Bool(true)})
+	staged, _ := c.BuildText(TextRequest{Title: "t", RefreshNow: Bool(false)})
+	if refreshed.Hash() == staged.Hash() {
+		t.Fatal("staged and refreshed requests should hash differently")
+	}
+}
+
+func TestFlushRefresh_DedupesAndDefaults(t *testing.T) {
+	s := &stagedServer{}
+	c := newStagedClient(t, s)
+	results, err := c.FlushRefresh(context.Background(), "A", "", "A", "DEF", "B")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, r := range results {
+		ids = append(ids, r.DeviceID)
+	}
+	if len(ids) != 3 || ids[0] != "A" || ids[1] != "DEF" || ids[2] != "B" {
+		t.Fatalf("devices %v", ids)
+	}
+	if results[2].Index != 4 {
+		t.Fatalf("index %d", results[2].Index)
+	}
+	for _, body := range s.got {
+		if len(body) != 2 || body["refreshNow"] != true {
+			t.Fatalf("refresh payload %v", body)
+		}
+	}
+
+	s.got = nil
+	if _, err := c.FlushRefresh(context.Background()); err != nil || len(s.got) != 1 || s.got[0]["deviceId"] != "DEF" {
+		t.Fatalf("default flush err=%v got=%v", err, s.got)
+	}
+}
+
+func TestFlushRefresh_Errors(t *testing.T) {
+	s := &stagedServer{fail: map[string]bool{"BAD": true}}
+	c := newStagedClient(t, s)
+	c.SetDefaultDeviceID("")
+	results, err := c.FlushRefresh(context.Background(), "", "BAD", "OK")
+	var be *BatchError
+	if !errors.As(err, &be) || len(be.Failed) != 2 || be.Total != 3 {
+		t.Fatalf("err %v", err)
+	}
+	if !errors.Is(results[0].Err, ErrDeviceIDMissing) || results[2].Err != nil {
+		t.Fatalf("results %+v", results)
+	}
+}
+
+func TestStagedBatch_SingleRefreshPerDevice(t *testing.T) {
+	s := &stagedServer{}
+	c := newStagedClient(t, s)
+	b := c.NewStagedBatch().
+		AddText(TextRequest{Title: "one", RefreshNow: Bool(true)}).
+		AddText(TextRequest{DeviceID: "B", Title: "two"}).
+		AddImage(ImageRequest{Image: "aGk="})
+	if b.Len() != 3 {
+		t.Fatalf("len %d", b.Len())
+	}
+	res, err := b.Send(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Items) != 3 || len(res.Refreshes) != 2 {
+		t.Fatalf("result %+v", res)
+	}
+	if len(s.got) != 5 {
+		t.Fatalf("requests %d", len(s.got))
+	}
+	for _, body := range s.got[:3] {
+		if body["refreshNow"] != false {
+			t.Fatalf("staged payload %v", body)
+		}
+	}
+	for _, body := range s.got[3:] {
+		if len(body) != 2 || body["refreshNow"] != true {
+			t.Fatalf("refresh payload %v", body)
+		}
+	}
+}
+
+func TestStagedBatch_FailedDeviceNotRefreshed(t *testing.T) {
+	s := &stagedServer{fail: map[string]bool{"BAD": true}}
+	c := newStagedClient(t, s)
+	res, err := c.NewStagedBatch().
+		AddText(TextRequest{DeviceID: "BAD", Title: "x"}).
+		AddText(TextRequest{DeviceID: "OK", Title: "y"}).
+		Send(context.Background())
+	var be *BatchError
+	if !errors.As(err, &be) || len(be.Failed) != 1 || be.Failed[0].DeviceID != "BAD" {
+		t.Fatalf("err %v", err)
+	}
+	if len(res.Refreshes) != 1 || res.Refreshes[0].DeviceID != "OK" || res.Refreshes[0].Err != nil {
+		t.Fatalf("refreshes %+v", res.Refreshes)
+	}
+}
+
+func TestStagedBatch_CanceledSkipsRefresh(t *testing.T) {
+	s := &stagedServer{}
+	c := newStagedClient(t, s)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	res, err := c.NewStagedBatch().AddText(TextRequest{Title: "x"}).Send(ctx)
+	if !errors.Is(err, context.Canceled) || len(res.Refreshes) != 0 || len(s.got) != 0 {
+		t.Fatalf("err=%v res=%+v requests=%d", err, res, len(s.got))
+	}
+}
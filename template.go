@@ -0,0 +1,193 @@
+package quote0
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// TextTemplate holds Go text/template sources for the text fields of a TextRequest. Empty
+// sources leave their field empty. Templates can use the DisplayFuncs helpers plus Funcs.
+type TextTemplate struct {
+	Title     string
+	Message   string
+	Signature string
+	// Funcs adds to or overrides DisplayFuncs.
+	Funcs template.FuncMap
+	// Markdown passes the rendered fields through StripMarkdown, for data that arrives as
+	// Markdown; the first link found becomes the request's Link.
+	Markdown bool
+}
+
+// Render parses and executes every field template with data and returns the resulting text
+// request (DeviceID and the other fields are left for the caller). Errors name the failing
+// template ("title", "message", or "signature") and the position within it; all templates
+// are parsed before any is executed. Missing map keys are errors rather than "<no value>".
+func (t TextTemplate) Render(data interface{}) (TextRequest, error) {
+	funcs := DisplayFuncs()
+	for name, fn := range t.Funcs {
+		funcs[name] = fn
+	}
+	fields := []struct {
+		name, src string
+		tpl       *template.Template
+		out       string
+	}{
+		{name: "title", src: t.Title},
+		{name: "message", src: t.Message},
+		{name: "signature", src: t.Signature},
+	}
+	for i := range fields {
+		f := &fields[i]
+		if f.src == "" {
+			continue
+		}
+		tpl, err := template.New(f.name).Funcs(funcs).Option("missingkey=error").Parse(f.src)
+		if err != nil {
+			return TextRequest{}, err
+		}
+		f.tpl = tpl
+	}
+	for i := range fields {
+		f := &fields[i]
+		if f.tpl == nil {
+			continue
+		}
+		var buf bytes.Buffer
+		if err := f.tpl.Execute(&buf, data); err != nil {
+			return TextRequest{}, err
+		}
+		f.out = strings.TrimSpace(buf.String())
+	}
+	req := TextRequest{Title: fields[0].out, Message: fields[1].out, Signature: fields[2].out}
+	if t.Markdown {
+		var links [3]string
+		req.Title, links[0] = StripMarkdown(req.Title)
+		req.Message, links[1] = StripMarkdown(req.Message)
+		req.Signature, links[2] = StripMarkdown(req.Signature)
+		for _, l := range links {
+			if l != "" {
+				req.Link = l
+				break
+			}
+		}
+	}
+	return req, nil
+}
+
+// DisplayFuncs returns the template helpers suited to the small screen:
+//
+//	truncate N S   cut S to N runes with "…" (see Truncate)
+//	upper, lower, trim
+//	default D V    D when V is empty or zero
+//	join SEP LIST  join a list of values with SEP
+//	round N X      X formatted with N decimals
+//	percent X      X (a 0–1 fraction) as a whole percentage, e.g. "42%"
+//	bar N X        an N-cell bar filled to the 0–1 fraction X, e.g. "███░░"
+//	date LAYOUT T  a time.Time, RFC 3339 string, or Unix seconds formatted with LAYOUT
+//
+// Numbers may be any Go numeric type or a numeric string, as decoded JSON provides.
+func DisplayFuncs() template.FuncMap {
+	return template.FuncMap{
+		"truncate": func(n int, s string) string { return Truncate(s, n) },
+		"upper":    strings.ToUpper,
+		"lower":    strings.ToLower,
+		"trim":     strings.TrimSpace,
+		"default":  templateDefault,
+		"join":     templateJoin,
+		"round": func(places int, x interface{}) (string, error) {
+			f, err := templateFloat(x)
+			return strconv.FormatFloat(f, 'f', places, 64), err
+		},
+		"percent": func(x interface{}) (string, error) {
+			f, err := templateFloat(x)
+			return strconv.Itoa(int(math.Round(f*100))) + "%", err
+		},
+		"bar": func(n int, x interface{}) (string, error) {
+			f, err := templateFloat(x)
+			filled := int(math.Round(math.Max(0, math.Min(1, f)) * float64(n)))
+			return strings.Repeat("█", filled) + strings.Repeat("░", n-filled), err
+		},
+		"date": templateDate,
+	}
+}
+
+func templateDefault(def, v interface{}) interface{} {
+	if v == nil {
+		return def
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		if rv.Len() == 0 {
+			return def
+		}
+	default:
+		if rv.IsZero() {
+			return def
+		}
+	}
+	return v
+}
+
+func templateJoin(sep string, list interface{}) (string, error) {
+	rv := reflect.ValueOf(list)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return "", fmt.Errorf("join: want a list, got %T", list)
+	}
+	parts := make([]string, rv.Len())
+	for i := range parts {
+		parts[i] = fmt.Sprint(rv.Index(i).Interface())
+	}
+	return strings.Join(parts, sep), nil
+}
+
+func templateFloat(x interface{}) (float64, error) {
+	switch v := x.(type) {
+	case float64:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case string, fmt.Stringer: // fmt.Stringer covers json.Number
+		f, err := strconv.ParseFloat(strings.TrimSpace(fmt.Sprint(v)), 64)
+		if err != nil {
+			return 0, fmt.Errorf("not a number: %q", fmt.Sprint(v))
+		}
+		return f, nil
+	}
+	rv := reflect.ValueOf(x)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), nil
+	}
+	return 0, fmt.Errorf("not a number: %v (%T)", x, x)
+}
+
+func templateDate(layout string, t interface{}) (string, error) {
+	switch v := t.(type) {
+	case time.Time:
+		return v.Format(layout), nil
+	case string:
+		parsed, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return "", err
+		}
+		return parsed.Format(layout), nil
+	}
+	secs, err := templateFloat(t)
+	if err != nil {
+		return "", fmt.Errorf("date: %v", err)
+	}
+	return time.Unix(int64(secs), 0).Format(layout), nil
+}
@@ -0,0 +1,69 @@
+package quote0
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTextTemplate_Render(t *testing.T) {
+	var data map[string]interface{}
+	_ = json.Unmarshal([]byte(`{"host":"db-1","cpu":0.423,"disks":["sda","sdb"],"load":1.5,"at":"2024-05-01T09:30:00Z","note":""}`), &data)
+	req, err := TextTemplate{
+		Title:     `{{.host | upper}}`,
+		Message:   "CPU {{percent .cpu}} {{bar 5 .cpu}}\nload {{round 1 .load}} on {{join \",\" .disks}}\n{{default \"-\" .note}}",
+		Signature: `{{date "15:04" .at}}`,
+	}.Render(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Title != "DB-1" || req.Signature != "09:30" {
+		t.Fatalf("got %+v", req)
+	}
+	if want := "CPU 42% ██░░░\nload 1.5 on sda,sdb\n-"; req.Message != want {
+		t.Fatalf("message %q, want %q", req.Message, want)
+	}
+
+	req, err = TextTemplate{Title: `{{.host | truncate 3}}`}.Render(map[string]string{"host": "database"})
+	if err != nil || req.Title != "da…" || req.Message != "" {
+		t.Fatalf("got %+v, %v", req, err)
+	}
+	req, _ = TextTemplate{Title: `{{date "2006" .}}`}.Render(time.Date(2031, 1, 1, 0, 0, 0, 0, time.UTC))
+	if req.Title != "2031" {
+		t.Fatalf("got %+v", req)
+	}
+}
+
+func TestTextTemplate_Markdown(t *testing.T) {
+	req, err := TextTemplate{
+		Title:    "## {{.title}}",
+		Message:  "{{.body}}",
+		Markdown: true,
+	}.Render(map[string]string{
+		"title": "**Disk** alert",
+		"body":  "- `/var` at *91%*\n- see [runbook](https://wiki/disk)",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Title != "Disk alert" || req.Message != "• /var at 91%\n• see runbook" || req.Link != "https://wiki/disk" {
+		t.Fatalf("got %+v", req)
+	}
+}
+
+func TestTextTemplate_Errors(t *testing.T) {
+	for _, tc := range []struct {
+		tpl  TextTemplate
+		want string
+	}{
+		{TextTemplate{Title: "ok", Message: "{{.x"}, "template: message:1:"},
+		{TextTemplate{Message: "ok", Signature: "{{.missing}}"}, `template: signature:1:2: executing "signature"`},
+		{TextTemplate{Title: "{{percent .x}}"}, "not a number"},
+	} {
+		_, err := tc.tpl.Render(map[string]interface{}{"x": "abc"})
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%+v: got %v, want %q", tc.tpl, err, tc.want)
+		}
+	}
+}
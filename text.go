@@ -1,8 +1,12 @@
 package quote0
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"image/png"
 	"strings"
+	"unicode/utf8"
 )
 
 // TextRequest matches the /api/open/text payload.
@@ -29,28 +33,93 @@ type TextRequest struct {
 	Signature string `json:"signature,omitempty"`
 	// Icon is a base64-encoded 40x40px PNG shown at the bottom-left corner. Optional.
 	Icon string `json:"icon,omitempty"`
+	// IconBytes allows providing a raw 40x40px PNG icon; the SDK checks it and base64-encodes internally.
+	// Ignored when Icon is set.
+	IconBytes []byte `json:"-"`
 	// Link is an optional URL that the Quote/0 companion app can open when interacting with the device.
 	Link string `json:"link,omitempty"`
 }
 
+// normalizeIcon fills Icon from IconBytes when it is empty, rejecting data that is not a
+// 40x40 PNG.
+func (r *TextRequest) normalizeIcon() error {
+	if strings.TrimSpace(r.Icon) != "" || len(r.IconBytes) == 0 {
+		r.IconBytes = nil
+		return nil
+	}
+	cfg, err := png.DecodeConfig(bytes.NewReader(r.IconBytes))
+	if err != nil {
+		return fmt.Errorf("%w: icon: %v", ErrInvalidImage, err)
+	}
+	if cfg.Width != IconSize || cfg.Height != IconSize {
+		return fmt.Errorf("%w: got %dx%d (see FitIcon)", ErrIconSize, cfg.Width, cfg.Height)
+	}
+	r.Icon, r.IconBytes = encodeBase64(r.IconBytes), nil
+	return nil
+}
+
 func (r TextRequest) validate() error {
 	if strings.TrimSpace(r.DeviceID) == "" {
 		return ErrDeviceIDMissing
 	}
+	// JSON encoding would silently replace invalid bytes with U+FFFD on the panel.
+	for _, s := range []string{r.Title, r.Message, r.Signature} {
+		if !utf8.ValidString(s) {
+			return ErrInvalidText
+		}
+	}
 	return nil
 }
 
-// SendText sends text content. If DeviceID is empty, the client's default device is used.
-func (c *Client) SendText(ctx context.Context, payload TextRequest) (*APIResponse, error) {
-	did, err := c.resolveDeviceID(payload.DeviceID)
+// SendText sends text content. If DeviceID is empty, the device from ContextWithDeviceID or
+// else the client's default device is used.
+// opts override the matching payload fields for this call (see SendOption).
+func (c *Client) SendText(ctx context.Context, payload TextRequest, opts ...SendOption) (*APIResponse, error) {
+	cfg := newSendConfig(opts)
+	cfg.applyText(&payload)
+	ctx, cancel := cfg.context(ctx)
+	defer cancel()
+	req, err := c.buildText(ctx, payload)
 	if err != nil {
 		return nil, err
 	}
-	payload.DeviceID = did
-	if err := payload.validate(); err != nil {
+	text := req.Payload.(*TextRequest)
+	resp, err := c.doJSON(ctx, textEndpoint, text.DeviceID, text)
+	if err == nil {
+		c.recordSent(SentRecord{DeviceID: text.DeviceID, Text: text})
+	}
+	return resp, err
+}
+
+// Refresh asks the device to repaint without changing its content by sending an empty text
+// payload with refreshNow=true. An empty deviceID uses the client's default device.
+// The content tracked by LastSent is left unchanged.
+func (c *Client) Refresh(ctx context.Context, deviceID string) (*APIResponse, error) {
+	did, err := c.resolveDeviceID(ctx, deviceID)
+	if err != nil {
 		return nil, err
 	}
-	return c.doJSON(ctx, textEndpoint, payload)
+	return c.doJSON(ctx, textEndpoint, did, TextRequest{RefreshNow: Bool(true), DeviceID: did})
+}
+
+// CheckAuth verifies the token and the default device without changing the display, e.g.
+// before a deployment's first update. It sends one text call to the default device with
+// refreshNow=false and no content fields: {"refreshNow":false,"deviceId":"..."}. The screen
+// is not repainted, but the call counts against the API's rate limit, goes through the
+// client's limiter, hooks, and history like any other, and the service may keep the empty
+// payload as the device's pending text content. LastSent is not changed.
+//
+// It returns nil when the call is accepted, an error matching ErrUnauthorized (errors.Is)
+// when the token is rejected, one matching ErrDeviceNotFound when the device is unknown or
+// not bound to the token, ErrDeviceIDMissing without a default device, and the usual
+// network or API error otherwise. Cancelling ctx stops the limiter wait and the request.
+func (c *Client) CheckAuth(ctx context.Context) error {
+	did, err := c.resolveDeviceID(ctx, "")
+	if err != nil {
+		return err
+	}
+	_, err = c.doJSON(ctx, textEndpoint, did, TextRequest{RefreshNow: Bool(false), DeviceID: did})
+	return err
 }
 
 // SendTextToDevice is a convenience to target a specific device.
@@ -62,14 +131,15 @@ func (c *Client) SendTextToDevice(ctx context.Context, deviceID string, payload
 // SendTextSimple is a convenience helper using Background context and immediate refresh.
 // Title and message are optional. Signature is variadic; when omitted, no signature is sent.
 func (c *Client) SendTextSimple(title, message string, signature ...string) (*APIResponse, error) {
-	sig := ""
-	if len(signature) > 0 {
-		sig = signature[0]
-	}
-	return c.SendText(context.Background(), TextRequest{
+	return c.SendTextSimpleContext(context.Background(), title, message, simpleSignature(signature)...)
+}
+
+// SendTextSimpleContext sends title and message with immediate refresh like SendTextSimple,
+// using ctx and letting opts override the device, refresh, link, or timeout.
+func (c *Client) SendTextSimpleContext(ctx context.Context, title, message string, opts ...SendOption) (*APIResponse, error) {
+	return c.SendText(ctx, TextRequest{
 		RefreshNow: Bool(true),
 		Title:      title,
 		Message:    message,
-		Signature:  sig,
-	})
+	}, opts...)
 }
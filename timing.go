@@ -0,0 +1,130 @@
+package quote0
+
+import (
+	"crypto/tls"
+	"errors"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Timing breaks down where the time of one HTTP attempt went (see WithTiming). Phases that
+// did not happen are zero: DNS for an IP host, and DNS, Connect, and TLS on a reused
+// connection.
+type Timing struct {
+	// DNS is the name lookup.
+	DNS time.Duration
+	// Connect is the TCP connection setup.
+	Connect time.Duration
+	// TLS is the TLS handshake.
+	TLS time.Duration
+	// FirstByte runs from sending the request to the first response byte, connection setup
+	// included; FirstByte minus the phases above approximates server processing.
+	FirstByte time.Duration
+	// Total runs from sending the request to reading the whole response body.
+	Total time.Duration
+	// Reused reports whether an idle keep-alive connection was used.
+	Reused bool
+}
+
+// WithTiming records a Timing for every HTTP attempt through net/http/httptrace. It is set
+// on APIResponse.Timing and, for a failed attempt, on APIError.Timing; TimingOf also finds it
+// on transport errors such as timeouts. With retries or failover it describes the last
+// attempt. Without this option no trace is attached and Timing stays nil.
+func WithTiming() ClientOption {
+	return func(c *Client) { c.timing = true }
+}
+
+// TimingOf returns the Timing carried by err, from an *APIError or a transport failure of a
+// client with WithTiming, or nil.
+func TimingOf(err error) *Timing {
+	var ae *APIError
+	if errors.As(err, &ae) && ae.Timing != nil {
+		return ae.Timing
+	}
+	var te *timingError
+	if errors.As(err, &te) {
+		return te.timing
+	}
+	return nil
+}
+
+// timingError attaches a Timing to a transport error without changing its message.
+type timingError struct {
+	timing *Timing
+	err    error
+}
+
+func (e *timingError) Error() string { return e.err.Error() }
+func (e *timingError) Unwrap() error { return e.err }
+
+// timingTrace collects the httptrace events of one attempt. A nil *timingTrace records
+// nothing, so callers need not check WithTiming.
+type timingTrace struct {
+	mu                         sync.Mutex
+	start, dnsStart, connStart time.Time
+	tlsStart, firstByte        time.Time
+	dns, connect, handshake    time.Duration
+	reused                     bool
+}
+
+// clientTrace returns the hooks feeding t.
+func (t *timingTrace) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { t.mark(&t.dnsStart) },
+		DNSDone:  func(httptrace.DNSDoneInfo) { t.since(&t.dns, &t.dnsStart) },
+		// With several addresses the dialer may race connections; the last one done wins.
+		ConnectStart:         func(string, string) { t.mark(&t.connStart) },
+		ConnectDone:          func(string, string, error) { t.since(&t.connect, &t.connStart) },
+		TLSHandshakeStart:    func() { t.mark(&t.tlsStart) },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { t.since(&t.handshake, &t.tlsStart) },
+		GotConn:              func(info httptrace.GotConnInfo) { t.mu.Lock(); t.reused = info.Reused; t.mu.Unlock() },
+		GotFirstResponseByte: func() { t.mark(&t.firstByte) },
+	}
+}
+
+func (t *timingTrace) mark(at *time.Time) {
+	t.mu.Lock()
+	*at = time.Now()
+	t.mu.Unlock()
+}
+
+func (t *timingTrace) since(d *time.Duration, from *time.Time) {
+	t.mu.Lock()
+	if !from.IsZero() {
+		*d = time.Since(*from)
+	}
+	t.mu.Unlock()
+}
+
+// begin marks the moment the request is handed to the HTTP client.
+func (t *timingTrace) begin() {
+	if t != nil {
+		t.mark(&t.start)
+	}
+}
+
+// result returns the Timing so far, with Total ending now.
+func (t *timingTrace) result() *Timing {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := &Timing{Total: time.Since(t.start), Reused: t.reused}
+	if !t.firstByte.IsZero() {
+		out.FirstByte = t.firstByte.Sub(t.start)
+	}
+	if !t.reused {
+		out.DNS, out.Connect, out.TLS = t.dns, t.connect, t.handshake
+	}
+	return out
+}
+
+// wrap attaches the Timing to a transport error.
+func (t *timingTrace) wrap(err error) error {
+	if t == nil {
+		return err
+	}
+	return &timingError{timing: t.result(), err: err}
+}
@@ -0,0 +1,74 @@
+package quote0
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWithTiming(t *testing.T) {
+	delay, status := 20*time.Millisecond, http.StatusOK
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(delay)
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+		okHandler(w, r)
+	}))
+	defer srv.Close()
+	c, err := NewClient("test", WithBaseURL(srv.URL), WithHTTPClient(srv.Client()), WithRateLimiter(nil),
+		WithDefaultDeviceID("D"), WithTiming())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	resp, err := c.SendText(ctx, TextRequest{Title: "t"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tm := resp.Timing
+	if tm == nil || tm.Reused || tm.Connect <= 0 || tm.TLS <= 0 || tm.FirstByte < delay || tm.Total < tm.FirstByte || tm.DNS != 0 {
+		t.Fatalf("fresh connection timing %+v", tm)
+	}
+
+	resp, err = c.SendText(ctx, TextRequest{Title: "t"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tm := resp.Timing; !tm.Reused || tm.Connect != 0 || tm.TLS != 0 || tm.FirstByte < delay {
+		t.Fatalf("reused connection timing %+v", tm)
+	}
+
+	status = http.StatusInternalServerError
+	_, err = c.SendText(ctx, TextRequest{Title: "t"})
+	var ae *APIError
+	if !errors.As(err, &ae) || ae.Timing == nil || ae.Timing.FirstByte < delay || TimingOf(err) != ae.Timing {
+		t.Fatalf("API error %v", err)
+	}
+
+	delay = 300 * time.Millisecond
+	tctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	_, err = c.SendText(tctx, TextRequest{Title: "t"})
+	if tm := TimingOf(err); !IsNetworkError(err) || tm == nil || tm.FirstByte != 0 || tm.Total < 40*time.Millisecond {
+		t.Fatalf("timeout %v, timing %+v", err, tm)
+	}
+}
+
+func TestWithTiming_Off(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(okHandler))
+	defer srv.Close()
+	c, _ := NewClient("test", WithBaseURL(srv.URL), WithRateLimiter(nil), WithDefaultDeviceID("D"))
+	resp, err := c.SendText(context.Background(), TextRequest{Title: "t"})
+	if err != nil || resp.Timing != nil {
+		t.Fatalf("timing %+v, err %v", resp.Timing, err)
+	}
+	if TimingOf(errors.New("x")) != nil {
+		t.Fatal("timing from a plain error")
+	}
+}
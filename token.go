@@ -0,0 +1,61 @@
+package quote0
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrEmptyToken is returned when a WithTokenSource function yields a blank token.
+var ErrEmptyToken = errors.New("quote0: token source returned an empty token")
+
+// SetAPIKey replaces the API token used by later calls, e.g. after a secrets manager rotated
+// it; the limiter, history, and other client state are kept. Calls already in flight finish
+// with the token they started with. A blank key is ignored. A WithTokenSource function, when
+// set, takes precedence over this key.
+func (c *Client) SetAPIKey(apiKey string) {
+	apiKey = strings.TrimSpace(apiKey)
+	if apiKey == "" {
+		return
+	}
+	c.mu.Lock()
+	c.apiKey = apiKey
+	c.mu.Unlock()
+}
+
+// WithTokenSource fetches the API token from fn at the start of every call instead of using
+// the fixed key, so rotated tokens are picked up without rebuilding the client. fn runs once
+// per call, before the rate limiter, and retries and fallback hosts reuse its token; it must
+// be safe for concurrent use and should cache the token itself if fetching is expensive. An
+// error from fn, or a blank token (ErrEmptyToken), fails the call without using a limiter
+// slot. With a token source NewClient accepts an empty apiKey; a nil fn makes it return an
+// error.
+func WithTokenSource(fn func(ctx context.Context) (string, error)) ClientOption {
+	return func(c *Client) {
+		if fn == nil {
+			c.optionError(errors.New("quote0: token source is nil"))
+			return
+		}
+		c.tokenSource = fn
+	}
+}
+
+// currentToken returns the token for one call: from the token source when set, otherwise
+// the key given to NewClient or SetAPIKey.
+func (c *Client) currentToken(ctx context.Context) (string, error) {
+	c.mu.RLock()
+	key, source := c.apiKey, c.tokenSource
+	c.mu.RUnlock()
+	if source == nil {
+		return key, nil
+	}
+	token, err := source(ctx)
+	if err != nil {
+		return "", fmt.Errorf("quote0: token source: %w", err)
+	}
+	if token = strings.TrimSpace(token); token == "" {
+		return "", ErrEmptyToken
+	}
+	return token, nil
+}
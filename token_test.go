@@ -0,0 +1,100 @@
+package quote0
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestSetAPIKey_Concurrent(t *testing.T) {
+	var bad int32
+	var mu sync.Mutex
+	seen := map[string]bool{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if auth != "Bearer dot_app_old" && auth != "Bearer dot_app_new" {
+			atomic.AddInt32(&bad, 1)
+		}
+		mu.Lock()
+		seen[auth] = true
+		mu.Unlock()
+		okHandler(w, r)
+	}))
+	defer srv.Close()
+	c, err := NewClient("dot_app_old", WithBaseURL(srv.URL), WithRateLimiter(nil), WithDefaultDeviceID("D"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if i == 10 {
+				c.SetAPIKey(" dot_app_new ")
+			}
+			if _, err := c.SendText(context.Background(), TextRequest{Title: "t"}); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	c.SetAPIKey("")
+	if _, err := c.SendText(context.Background(), TextRequest{Title: "t"}); err != nil {
+		t.Fatal(err)
+	}
+	if bad != 0 || !seen["Bearer dot_app_new"] {
+		t.Fatalf("%d unexpected tokens, seen %v", bad, seen)
+	}
+}
+
+func TestWithTokenSource(t *testing.T) {
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get("Authorization"))
+		okHandler(w, r)
+	}))
+	defer srv.Close()
+	var waits int
+	limiter := RateLimiterFunc(func(context.Context) error { waits++; return nil })
+	tokens := []string{"tok1", "tok2", "", "tok3"}
+	fetchErr := errors.New("vault sealed")
+	calls := 0
+	source := func(ctx context.Context) (string, error) {
+		calls++
+		if calls == 5 {
+			return "", fetchErr
+		}
+		return tokens[calls-1], nil
+	}
+	c, err := NewClient("", WithBaseURL(srv.URL), WithRateLimiter(limiter), WithDefaultDeviceID("D"), WithTokenSource(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetAPIKey("ignored")
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if _, err := c.SendText(ctx, TextRequest{Title: "t"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := c.SendText(ctx, TextRequest{Title: "t"}); !errors.Is(err, ErrEmptyToken) {
+		t.Fatalf("empty token: %v", err)
+	}
+	if _, err := c.SendText(ctx, TextRequest{Title: "t"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.SendText(ctx, TextRequest{Title: "t"}); !errors.Is(err, fetchErr) {
+		t.Fatalf("source error: %v", err)
+	}
+	if len(got) != 3 || got[0] != "Bearer tok1" || got[1] != "Bearer tok2" || got[2] != "Bearer tok3" || waits != 3 {
+		t.Fatalf("sent %v after %d limiter waits", got, waits)
+	}
+	if _, err := NewClient("", WithTokenSource(nil)); err == nil {
+		t.Fatal("nil token source accepted")
+	}
+}
@@ -0,0 +1,54 @@
+package quote0
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// traceHeader pairs an outgoing header name with the function that extracts its value.
+type traceHeader struct {
+	name    string
+	extract func(ctx context.Context) string
+}
+
+// WithTraceHeader stamps each request with headerName set to extract(ctx) when the extracted
+// value is non-empty, so Quote/0 calls line up with the caller's distributed traces.
+// The extractor runs once per call; the same values are attached to APIError.Trace on failure
+// and logged by WithLogger.
+// The option may be repeated to register several headers. An empty name, a nil extractor, or
+// a reserved header (Authorization, Content-Type, User-Agent) makes NewClient return an error.
+func WithTraceHeader(headerName string, extract func(ctx context.Context) string) ClientOption {
+	return func(c *Client) {
+		name := http.CanonicalHeaderKey(strings.TrimSpace(headerName))
+		switch {
+		case name == "":
+			c.optionError(errors.New("quote0: trace header name is required"))
+		case extract == nil:
+			c.optionError(fmt.Errorf("quote0: trace header %s: extractor is nil", name))
+		case name == "Authorization" || name == "Content-Type" || name == "User-Agent":
+			c.optionError(fmt.Errorf("quote0: trace header %s is reserved", name))
+		default:
+			c.traceHeaders = append(c.traceHeaders, traceHeader{name: name, extract: extract})
+		}
+	}
+}
+
+// traceValues runs the registered extractors against ctx, keeping non-empty values.
+// It returns nil when no trace headers are registered or none produced a value.
+func (c *Client) traceValues(ctx context.Context) map[string]string {
+	var out map[string]string
+	for _, th := range c.traceHeaders {
+		v := strings.TrimSpace(th.extract(ctx))
+		if v == "" {
+			continue
+		}
+		if out == nil {
+			out = make(map[string]string, len(c.traceHeaders))
+		}
+		out[th.name] = v
+	}
+	return out
+}
@@ -0,0 +1,90 @@
+package quote0
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type traceKey struct{}
+
+func traceFromContext(ctx context.Context) string {
+	v, _ := ctx.Value(traceKey{}).(string)
+	return v
+}
+
+func TestWithTraceHeader_OnlyWhenContextCarriesValue(t *testing.T) {
+	var seen []http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = append(seen, r.Header.Clone())
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"code":0}`)
+	}))
+	defer srv.Close()
+
+	c, err := NewClient("test",
+		WithBaseURL(srv.URL),
+		WithRateLimiter(nil),
+		WithDefaultDeviceID("D"),
+		WithTraceHeader("x-trace-id", traceFromContext),
+		WithTraceHeader("X-Tenant", func(context.Context) string { return "acme" }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.SendText(context.Background(), TextRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.WithValue(context.Background(), traceKey{}, "abc123")
+	if _, err := c.SendText(ctx, TextRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(seen) != 2 {
+		t.Fatalf("requests=%d", len(seen))
+	}
+	if _, ok := seen[0]["X-Trace-Id"]; ok {
+		t.Fatalf("trace header sent without context value: %v", seen[0])
+	}
+	if got := seen[1].Get("X-Trace-Id"); got != "abc123" {
+		t.Fatalf("X-Trace-Id=%q", got)
+	}
+	if seen[0].Get("X-Tenant") != "acme" || seen[1].Get("X-Tenant") != "acme" {
+		t.Fatalf("second trace header missing: %v %v", seen[0], seen[1])
+	}
+}
+
+func TestWithTraceHeader_APIErrorCarriesTrace(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = io.WriteString(w, "bad")
+	}))
+	defer srv.Close()
+
+	c, err := NewClient("test", WithBaseURL(srv.URL), WithRateLimiter(nil), WithTraceHeader("X-Trace-Id", traceFromContext))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.WithValue(context.Background(), traceKey{}, "t-1")
+	_, err = c.SendText(ctx, TextRequest{DeviceID: "D"})
+	ae, ok := err.(*APIError)
+	if !ok {
+		t.Fatalf("want APIError, got %v", err)
+	}
+	if ae.Trace["X-Trace-Id"] != "t-1" {
+		t.Fatalf("trace=%v", ae.Trace)
+	}
+}
+
+func TestWithTraceHeader_RejectsInvalid(t *testing.T) {
+	cases := map[string]ClientOption{
+		"nil extractor": WithTraceHeader("X-Trace-Id", nil),
+		"empty name":    WithTraceHeader(" ", traceFromContext),
+		"reserved":      WithTraceHeader("authorization", traceFromContext),
+	}
+	for name, opt := range cases {
+		if _, err := NewClient("test", opt); err == nil {
+			t.Errorf("%s: expected NewClient error", name)
+		}
+	}
+}
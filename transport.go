@@ -0,0 +1,49 @@
+package quote0
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+)
+
+// WithTLSConfig uses cfg for the TLS connections to the API, e.g. to trust the private CA of
+// a TLS-inspecting gateway through RootCAs. Only the TLS settings change: the transport keeps
+// its timeouts, keep-alives, and HTTP/2 support, and WithProxy and WithHTTPTimeout still
+// apply. cfg is copied, so changing it later has no effect; nil drops an earlier config.
+//
+// Like WithProxy, it applies to a WithHTTPClient client through a copy of its
+// *http.Transport, and NewClient returns an error for a client with another Transport type.
+func WithTLSConfig(cfg *tls.Config) ClientOption {
+	return func(c *Client) {
+		if cfg == nil {
+			c.tlsConfig = nil
+			return
+		}
+		c.tlsConfig = cfg.Clone()
+	}
+}
+
+// buildTransport replaces the HTTP client with a copy whose transport carries the
+// WithProxy and WithTLSConfig settings, so neither option clobbers the other and the
+// caller's client and transport are left untouched.
+func (c *Client) buildTransport() error {
+	var tr *http.Transport
+	switch t := c.http.Transport.(type) {
+	case nil:
+		tr = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		tr = t.Clone()
+	default:
+		return fmt.Errorf("quote0: WithProxy and WithTLSConfig need an *http.Transport, the HTTP client has %T", t)
+	}
+	if c.proxy != nil {
+		tr.Proxy = http.ProxyURL(c.proxy)
+	}
+	if c.tlsConfig != nil {
+		tr.TLSClientConfig = c.tlsConfig
+	}
+	hc := *c.http
+	hc.Transport = tr
+	c.http = &hc
+	return nil
+}
@@ -0,0 +1,67 @@
+package quote0
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWithTLSConfig_PrivateCA(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"code":0}`)
+	}))
+	defer srv.Close()
+	pool := x509.NewCertPool()
+	pool.AddCert(srv.Certificate())
+
+	send := func(opts ...ClientOption) error {
+		c, err := NewClient("test", append(opts, WithBaseURL(srv.URL), WithRateLimiter(nil), WithDefaultDeviceID("D"))...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = c.SendText(context.Background(), TextRequest{Title: "t"})
+		return err
+	}
+	if err := send(); !IsNetworkError(err) {
+		t.Fatalf("untrusted CA: %v", err)
+	}
+	cfg := &tls.Config{RootCAs: pool}
+	if err := send(WithTLSConfig(cfg)); err != nil {
+		t.Fatal(err)
+	}
+	if err := send(WithHTTPClient(&http.Client{Transport: &http.Transport{}}), WithTLSConfig(cfg)); err != nil {
+		t.Fatal(err)
+	}
+	if err := send(WithTLSConfig(cfg), WithTLSConfig(nil)); !IsNetworkError(err) {
+		t.Fatalf("dropped config still trusted the CA: %v", err)
+	}
+}
+
+func TestBuildTransport_CombinesOptions(t *testing.T) {
+	cfg := &tls.Config{ServerName: "gw.corp"}
+	c, err := NewClient("test", WithTLSConfig(cfg), WithProxy("http://proxy.corp:3128"), WithHTTPTimeout(5*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.ServerName = "changed"
+	tr, ok := c.http.Transport.(*http.Transport)
+	if !ok || tr == http.DefaultTransport {
+		t.Fatalf("transport %T", c.http.Transport)
+	}
+	req, _ := http.NewRequest(http.MethodPost, DefaultBaseURL, nil)
+	if u, err := tr.Proxy(req); err != nil || u.Host != "proxy.corp:3128" {
+		t.Fatalf("proxy %v, %v", u, err)
+	}
+	if tr.TLSClientConfig.ServerName != "gw.corp" || c.http.Timeout != 5*time.Second {
+		t.Fatalf("server name %q, timeout %v", tr.TLSClientConfig.ServerName, c.http.Timeout)
+	}
+	if tr.IdleConnTimeout == 0 || tr.TLSHandshakeTimeout == 0 {
+		t.Fatal("default transport settings lost")
+	}
+}
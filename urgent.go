@@ -0,0 +1,85 @@
+package quote0
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+type urgentKey struct{}
+
+// WithUrgent marks ctx so that API calls made with it skip the client's rate limiter wait, for
+// example to show a smoke alarm at once instead of after the queued slideshow frames.
+//
+// Reserve it for genuinely rare events. The call still counts toward the limiter's schedule
+// when the limiter implements RateLimitReserver, so the next normal call waits a full interval
+// after it, but the server enforces its own limit and may still answer 429 Too Many Requests.
+// Every urgent call is reported to the WithUrgentHook callbacks and counted by UrgentCalls so
+// overuse is visible.
+func WithUrgent(ctx context.Context) context.Context {
+	return context.WithValue(ctx, urgentKey{}, true)
+}
+
+// IsUrgent reports whether ctx was marked by WithUrgent.
+func IsUrgent(ctx context.Context) bool {
+	urgent, _ := ctx.Value(urgentKey{}).(bool)
+	return urgent
+}
+
+// RateLimitReserver is implemented by limiters that can record a call made without waiting,
+// such as an urgent one (see WithUrgent), so that calls after it keep their spacing.
+// FixedIntervalLimiter and FairLimiter implement it.
+type RateLimitReserver interface {
+	Reserve()
+}
+
+// Reserve records a call made now without waiting: the next Wait is scheduled at least one
+// interval from now. Calls already waiting keep their turns.
+func (l *FixedIntervalLimiter) Reserve() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	next := l.clock().Add(l.minInterval)
+	if next.After(l.next) {
+		l.next = next
+	}
+	if next.After(l.floor) {
+		l.floor = next
+	}
+}
+
+// Reserve passes the reservation on to the inner limiter when it implements RateLimitReserver.
+func (l *FairLimiter) Reserve() {
+	if r, ok := l.inner.(RateLimitReserver); ok {
+		r.Reserve()
+	}
+}
+
+// WithUrgentHook registers fn to be called before every urgent API call (see WithUrgent) with
+// the call's context, endpoint path, and device ID, for example to count them in metrics or
+// log who skipped the queue. The option may be repeated; a nil fn is ignored.
+func WithUrgentHook(fn func(ctx context.Context, endpoint, deviceID string)) ClientOption {
+	return func(c *Client) {
+		if fn != nil {
+			c.urgentHooks = append(c.urgentHooks, fn)
+		}
+	}
+}
+
+// UrgentCalls returns how many API calls skipped the rate limiter because of WithUrgent.
+func (c *Client) UrgentCalls() int64 {
+	return atomic.LoadInt64(&c.urgentCalls)
+}
+
+// skipLimiter records an urgent call in place of waiting for the limiter.
+func (c *Client) skipLimiter(ctx context.Context, call *apiCall) {
+	atomic.AddInt64(&c.urgentCalls, 1)
+	if r, ok := c.limiter.(RateLimitReserver); ok {
+		r.Reserve()
+	}
+	if c.debug > DebugOff {
+		c.debugLogger().Printf("%s urgent call to %s skips the rate limiter", time.Now().Format("15:04:05.000"), call.endpoint)
+	}
+	for _, fn := range c.urgentHooks {
+		fn(ctx, call.endpoint, call.deviceID)
+	}
+}
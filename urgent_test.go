@@ -0,0 +1,84 @@
+package quote0
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClient_UrgentSkipsLimiter(t *testing.T) {
+	l, clock := newFakeLimiter(time.Second)
+	s := &stagedServer{}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+	var hooked []string
+	c, err := NewClient("test", WithBaseURL(srv.URL), WithDefaultDeviceID("DEF"), WithRateLimiter(l),
+		WithUrgentHook(func(ctx context.Context, endpoint, deviceID string) {
+			hooked = append(hooked, endpoint+" "+deviceID)
+		}), WithUrgentHook(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if _, err := c.SendText(ctx, TextRequest{Title: "frame"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Half an interval later the alarm goes out without waiting for the limiter.
+	clock.advance(500 * time.Millisecond)
+	if _, err := c.SendText(WithUrgent(ctx), TextRequest{Title: "smoke"}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case s := <-clock.sleeps:
+		t.Fatalf("urgent call waited %s", s.d)
+	default:
+	}
+	if len(hooked) != 1 || hooked[0] != "/api/open/text DEF" || c.UrgentCalls() != 1 {
+		t.Fatalf("hooks %q, urgent calls %d", hooked, c.UrgentCalls())
+	}
+
+	// The next normal call waits a full interval after the urgent one, not after the first.
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.SendText(ctx, TextRequest{Title: "frame"})
+		done <- err
+	}()
+	sl := clock.nextSleep(t)
+	if sl.d != time.Second {
+		t.Fatalf("normal call after urgent waited %s, want 1s", sl.d)
+	}
+	clock.advance(sl.d)
+	sl.fire <- clock.now
+	expectDone(t, done, nil)
+	if len(s.got) != 3 || c.UrgentCalls() != 1 {
+		t.Fatalf("%d requests, %d urgent", len(s.got), c.UrgentCalls())
+	}
+}
+
+func TestIsUrgent(t *testing.T) {
+	ctx := context.Background()
+	if IsUrgent(ctx) || !IsUrgent(WithUrgent(ctx)) {
+		t.Fatal("IsUrgent")
+	}
+	child, cancel := context.WithCancel(WithUrgent(ctx))
+	defer cancel()
+	if !IsUrgent(child) {
+		t.Fatal("derived contexts stay urgent")
+	}
+}
+
+func TestFairLimiter_Reserve(t *testing.T) {
+	inner, clock := newFakeLimiter(time.Second)
+	l := NewFairLimiter(inner)
+	l.Reserve()
+	done := waitAsync(context.Background(), l)
+	s := clock.nextSleep(t)
+	if s.d != time.Second {
+		t.Fatalf("wait after reserve %s, want 1s", s.d)
+	}
+	s.fire <- clock.now
+	expectDone(t, done, nil)
+	NewFairLimiter(nil).Reserve() // nothing to reserve on
+}
@@ -0,0 +1,163 @@
+package quote0
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"image/png"
+	"os"
+	"time"
+)
+
+const (
+	defaultWatchInterval = 2 * time.Second
+	defaultWatchDebounce = time.Second
+)
+
+// WatchEvent reports one decision made by WatchImageFile or WatchFile.
+type WatchEvent struct {
+	// Path is the watched file.
+	Path string
+	// At is when the decision was made.
+	At time.Time
+	// Skipped is true when the file changed on disk but its content matched the last send.
+	Skipped bool
+	// Response is the API response for a successful send.
+	Response *APIResponse
+	// Err is the read, validation, or send failure, if any.
+	Err error
+}
+
+// WatchOption tunes WatchImageFile and WatchFile.
+type WatchOption func(*watchConfig)
+
+type watchConfig struct {
+	interval time.Duration
+	debounce time.Duration
+	onEvent  func(WatchEvent)
+	now      func() time.Time
+	after    func(time.Duration) <-chan time.Time
+}
+
+// WithWatchInterval sets how often the file's modification time and size are polled (default 2s).
+func WithWatchInterval(d time.Duration) WatchOption {
+	return func(cfg *watchConfig) {
+		if d > 0 {
+			cfg.interval = d
+		}
+	}
+}
+
+// WithWatchDebounce sets how long the file must stay unchanged before it is sent (default 1s),
+// so a burst of writes results in a single send. Zero sends on the first stable poll.
+func WithWatchDebounce(d time.Duration) WatchOption {
+	return func(cfg *watchConfig) {
+		if d >= 0 {
+			cfg.debounce = d
+		}
+	}
+}
+
+// WithWatchCallback receives an event for every send, skip, or failure.
+// It runs on the watcher goroutine; slow callbacks delay the next poll.
+func WithWatchCallback(fn func(WatchEvent)) WatchOption {
+	return func(cfg *watchConfig) { cfg.onEvent = fn }
+}
+
+// fileSignature is the cheap change detector compared on every poll.
+type fileSignature struct {
+	modTime time.Time
+	size    int64
+}
+
+// WatchImageFile polls path and sends it as an image whenever its content changes, until ctx
+// ends (the returned error is then ctx.Err()). The file is sent once at start, then again only
+// after its modification time or size changes and stays stable for the debounce period.
+// Content identical to the last successful send is skipped.
+//
+// Writers that replace the file via atomic rename, or that leave it briefly missing, are
+// handled by waiting for the next poll; a file that is not a decodable PNG is reported through
+// the callback and retried after its next change. meta supplies the device and display options;
+// its Image, ImageBytes, and ImagePath fields are ignored.
+func WatchImageFile(ctx context.Context, client *Client, path string, meta ImageRequest, opts ...WatchOption) error {
+	if client == nil {
+		return errors.New("quote0: watch requires a client")
+	}
+	meta.Image, meta.ImageBytes, meta.ImagePath = "", nil, ""
+	return WatchFile(ctx, path, func(ctx context.Context, data []byte) (*APIResponse, error) {
+		if _, err := png.DecodeConfig(bytes.NewReader(data)); err != nil {
+			return nil, fmt.Errorf("%w: watched file: %v", ErrInvalidImage, err)
+		}
+		return client.SendImageBytes(ctx, data, meta)
+	}, opts...)
+}
+
+// WatchFile is the polling loop behind WatchImageFile for any kind of content: send is called
+// with the file's bytes at start and after every debounced change, unless they equal the last
+// successfully sent content. A send failing with a validation error (see IsValidationError)
+// waits for the next change; other failures are retried on the next poll.
+func WatchFile(ctx context.Context, path string, send func(ctx context.Context, data []byte) (*APIResponse, error), opts ...WatchOption) error {
+	if send == nil {
+		return errors.New("quote0: watch requires a send function")
+	}
+	cfg := watchConfig{
+		interval: defaultWatchInterval,
+		debounce: defaultWatchDebounce,
+		now:      time.Now,
+		after:    time.After,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&cfg)
+		}
+	}
+
+	var (
+		seen      fileSignature
+		pending   = true // send whatever is present at start
+		changedAt = cfg.now()
+		lastHash  [sha256.Size]byte
+		sentOnce  bool
+	)
+	for {
+		if info, statErr := os.Stat(path); statErr == nil {
+			sig := fileSignature{modTime: info.ModTime(), size: info.Size()}
+			if sig != seen {
+				seen = sig
+				pending = true
+				changedAt = cfg.now()
+			} else if pending && cfg.now().Sub(changedAt) >= cfg.debounce {
+				pending = false
+				ev := WatchEvent{Path: path, At: cfg.now()}
+				data, readErr := readFile(path)
+				hash := sha256.Sum256(data)
+				switch {
+				case readErr != nil:
+					ev.Err = readErr
+				case sentOnce && hash == lastHash:
+					ev.Skipped = true
+				default:
+					ev.Response, ev.Err = send(ctx, data)
+					if ev.Err == nil {
+						lastHash, sentOnce = hash, true
+					} else if !IsValidationError(ev.Err) {
+						// Retry a failed send on the next poll even if the file stays unchanged.
+						pending = true
+					}
+				}
+				if cfg.onEvent != nil {
+					cfg.onEvent(ev)
+				}
+			}
+		}
+		// A missing file (mid-rename) keeps the previous signature; the next stat decides.
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-cfg.after(cfg.interval):
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package quote0
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// fakeWatchClock hands control of every poll to the test: each wait is delivered on polls,
+// and the watcher blocks until the test fires it.
+type fakeWatchClock struct {
+	now   time.Time
+	polls chan chan time.Time
+}
+
+func (f *fakeWatchClock) option() WatchOption {
+	return func(cfg *watchConfig) {
+		cfg.now = func() time.Time { return f.now }
+		cfg.after = func(time.Duration) <-chan time.Time {
+			ch := make(chan time.Time, 1)
+			f.polls <- ch
+			return ch
+		}
+	}
+}
+
+// tick advances the fake time by d and lets the watcher run exactly one more poll.
+func (f *fakeWatchClock) tick(t *testing.T, d time.Duration) {
+	t.Helper()
+	select {
+	case ch := <-f.polls:
+		f.now = f.now.Add(d)
+		ch <- f.now
+	case <-time.After(5 * time.Second):
+		t.Fatal("watcher did not wait for the next poll")
+	}
+}
+
+func TestWatchImageFile(t *testing.T) {
+	var sent []ImageRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ImageRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		sent = append(sent, req)
+		_, _ = io.WriteString(w, "ok")
+	}))
+	defer srv.Close()
+	c, err := NewClient("test", WithBaseURL(srv.URL), WithRateLimiter(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "panel.png")
+	v1 := encodeTestPNG(t, 296, 152)
+	v2 := encodeTestPNG(t, 10, 10)
+	if err := os.WriteFile(path, v1, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	clock := &fakeWatchClock{now: time.Unix(1000, 0), polls: make(chan chan time.Time)}
+	events := make(chan WatchEvent, 16)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- WatchImageFile(ctx, c, path, ImageRequest{DeviceID: "D", Border: BorderBlack},
+			WithWatchDebounce(3*time.Second),
+			WithWatchCallback(func(ev WatchEvent) { events <- ev }),
+			clock.option())
+	}()
+	expectEvent := func(skipped bool) WatchEvent {
+		t.Helper()
+		select {
+		case ev := <-events:
+			if ev.Err != nil || ev.Skipped != skipped {
+				t.Fatalf("event=%+v, want skipped=%v", ev, skipped)
+			}
+			return ev
+		default:
+			t.Fatalf("expected an event (skipped=%v)", skipped)
+		}
+		return WatchEvent{}
+	}
+	expectNone := func() {
+		t.Helper()
+		select {
+		case ev := <-events:
+			t.Fatalf("unexpected event %+v", ev)
+		default:
+		}
+	}
+
+	// Initial content is sent once it has been stable for the debounce period.
+	clock.tick(t, time.Second)
+	expectNone()
+	clock.tick(t, 3*time.Second)
+	clock.tick(t, time.Second) // wait until the send poll has completed
+	expectEvent(false)
+
+	// Rewriting identical bytes changes mtime but the content hash matches: skipped.
+	mod := time.Unix(5000, 0)
+	if err := os.Chtimes(path, mod, mod); err != nil {
+		t.Fatal(err)
+	}
+	clock.tick(t, time.Second)
+	clock.tick(t, 3*time.Second)
+	clock.tick(t, time.Second)
+	expectEvent(true)
+
+	// Atomic-rename writer: the file disappears briefly, then a new version appears.
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	clock.tick(t, time.Second)
+	expectNone()
+	tmp := filepath.Join(dir, ".panel.tmp")
+	if err := os.WriteFile(tmp, v2, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatal(err)
+	}
+	clock.tick(t, time.Second)
+	clock.tick(t, time.Second)
+	expectNone() // still inside the debounce window
+	clock.tick(t, 3*time.Second)
+	clock.tick(t, time.Second)
+	expectEvent(false)
+
+	cancel()
+	<-clock.polls // release the pending wait without firing it
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("watch returned %v", err)
+	}
+	if len(sent) != 2 || sent[0].Image != encodeBase64(v1) || sent[1].Image != encodeBase64(v2) {
+		t.Fatalf("sent %d images", len(sent))
+	}
+	if sent[1].DeviceID != "D" || sent[1].Border != BorderBlack {
+		t.Fatalf("metadata lost: %+v", sent[1])
+	}
+}
+
+func TestWatchImageFile_InvalidPNGReported(t *testing.T) {
+	c, err := NewClient("test", WithRateLimiter(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "half.png")
+	if err := os.WriteFile(path, []byte("\x89PNG partial"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var got WatchEvent
+	err = WatchImageFile(ctx, c, path, ImageRequest{DeviceID: "D"},
+		WithWatchInterval(time.Millisecond),
+		WithWatchDebounce(0),
+		WithWatchCallback(func(ev WatchEvent) {
+			got = ev
+			cancel()
+		}))
+	if err != context.Canceled {
+		t.Fatalf("err=%v", err)
+	}
+	if got.Err == nil {
+		t.Fatal("expected validation error for a partial PNG")
+	}
+}
@@ -0,0 +1,187 @@
+package quote0
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"math"
+	"strings"
+)
+
+// Units selects how RenderWeatherCard formats temperatures.
+type Units int
+
+const (
+	// Metric shows degrees Celsius (default).
+	Metric Units = iota
+	// Imperial converts to degrees Fahrenheit.
+	Imperial
+)
+
+// WeatherData is the input to RenderWeatherCard. Temperatures are in degrees Celsius.
+// Only Temperature is required; empty optional fields collapse their region of the card.
+type WeatherData struct {
+	// Location is shown in the header bar.
+	Location string
+	// Condition picks the icon from the built-in set, e.g. IconRain.
+	Condition Icon
+	// Description is a short condition text such as "Light rain".
+	Description string
+	Temperature float64
+	High        *float64
+	Low         *float64
+	// Hourly is the upcoming temperature trend drawn as a sparkline; NaN entries are gaps.
+	Hourly []float64
+}
+
+// WeatherOption configures RenderWeatherCard.
+type WeatherOption func(*weatherConfig)
+
+type weatherConfig struct {
+	units  Units
+	accent bool
+}
+
+// WithWeatherUnits selects Metric (default) or Imperial formatting.
+func WithWeatherUnits(u Units) WeatherOption {
+	return func(cfg *weatherConfig) { cfg.units = u }
+}
+
+// WithWeatherAccent draws the header as an inverted (white on black) bar when true (default)
+// and as black text over a rule when false.
+func WithWeatherAccent(on bool) WeatherOption {
+	return func(cfg *weatherConfig) { cfg.accent = on }
+}
+
+// ErrInvalidTemperature is returned when the current temperature is NaN or infinite.
+var ErrInvalidTemperature = errors.New("quote0: weather temperature must be a finite number")
+
+// Card layout metrics, in pixels.
+const (
+	weatherMargin      = 8
+	weatherHeaderH     = 20
+	weatherSparkH      = 26
+	weatherIconScale   = 2
+	weatherTempScale   = 6
+	weatherDetailScale = 2
+)
+
+// RenderWeatherCard composes a full-screen weather card: a header with the location, the
+// condition icon, a large current temperature, description and high/low, and an hourly trend
+// sparkline along the bottom. Send it with ImageRequest.ImageBytes after encoding, or use
+// Canvas.PNG via the returned image.
+func RenderWeatherCard(data WeatherData, opts ...WeatherOption) (image.Image, error) {
+	cfg := weatherConfig{units: Metric, accent: true}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&cfg)
+		}
+	}
+	if math.IsNaN(data.Temperature) || math.IsInf(data.Temperature, 0) {
+		return nil, ErrInvalidTemperature
+	}
+	var icon *image.Gray
+	if data.Condition != "" {
+		var err error
+		if icon, err = RenderIcon(data.Condition); err != nil {
+			return nil, err
+		}
+	}
+
+	c := NewCanvas()
+	top, bottom := 0, ScreenHeight
+
+	if loc := strings.TrimSpace(data.Location); loc != "" {
+		header := image.Rect(0, 0, ScreenWidth, weatherHeaderH)
+		text := FitText(loc, ScreenWidth-2*weatherMargin, weatherDetailScale)
+		ty := (weatherHeaderH - glyphH*weatherDetailScale) / 2
+		if cfg.accent {
+			c.FillRect(header, Black)
+			c.DrawText(weatherMargin, ty, text, weatherDetailScale, White)
+		} else {
+			c.DrawText(weatherMargin, ty, text, weatherDetailScale, Black)
+			c.FillRect(image.Rect(weatherMargin, weatherHeaderH-2, ScreenWidth-weatherMargin, weatherHeaderH-1), Black)
+		}
+		top = weatherHeaderH
+	}
+
+	if finiteCount(data.Hourly) >= 2 {
+		spark := image.Rect(weatherMargin, ScreenHeight-weatherMargin-weatherSparkH+4, ScreenWidth-weatherMargin, ScreenHeight-weatherMargin)
+		c.FillRect(image.Rect(weatherMargin, spark.Min.Y-5, ScreenWidth-weatherMargin, spark.Min.Y-4), Black)
+		c.DrawSparkline(spark, data.Hourly, Black)
+		bottom = spark.Min.Y - 6
+	}
+
+	x := weatherMargin
+	if icon != nil {
+		size := IconSize * weatherIconScale
+		if avail := bottom - top - 4; size > avail {
+			size = avail
+		}
+		iy := top + (bottom-top-size)/2
+		c.DrawImage(image.Rect(x, iy, x+size, iy+size), icon)
+		x += size + weatherMargin
+	}
+
+	temp := formatTemperature(data.Temperature, cfg.units, true)
+	var details []string
+	if d := strings.TrimSpace(data.Description); d != "" {
+		details = append(details, d)
+	}
+	var hl []string
+	if data.High != nil {
+		hl = append(hl, "H "+formatTemperature(*data.High, cfg.units, false))
+	}
+	if data.Low != nil {
+		hl = append(hl, "L "+formatTemperature(*data.Low, cfg.units, false))
+	}
+	if len(hl) > 0 {
+		details = append(details, strings.Join(hl, "  "))
+	}
+
+	tempScale := weatherTempScale
+	for tempScale > 2 && x+TextWidth(temp, tempScale) > ScreenWidth-weatherMargin {
+		tempScale--
+	}
+	lineGap := 4
+	blockH := glyphH * tempScale
+	for range details {
+		blockH += lineGap + glyphH*weatherDetailScale
+	}
+	y := top + (bottom-top-blockH)/2
+	if y < top {
+		y = top
+	}
+	c.DrawText(x, y, temp, tempScale, Black)
+	y += glyphH * tempScale
+	width := ScreenWidth - weatherMargin - x
+	for _, line := range details {
+		y += lineGap
+		c.DrawText(x, y, FitText(line, width, weatherDetailScale), weatherDetailScale, Black)
+		y += glyphH * weatherDetailScale
+	}
+	return c.Image(), nil
+}
+
+// formatTemperature rounds to whole degrees; the unit letter is only shown when withUnit is set.
+func formatTemperature(celsius float64, u Units, withUnit bool) string {
+	v, unit := celsius, "C"
+	if u == Imperial {
+		v, unit = celsius*9/5+32, "F"
+	}
+	s := fmt.Sprintf("%d°", int(math.Round(v)))
+	if withUnit {
+		s += unit
+	}
+	return s
+}
+
+func finiteCount(values []float64) int {
+	n := 0
+	for _, v := range values {
+		if !math.IsNaN(v) && !math.IsInf(v, 0) {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,85 @@
+package quote0
+
+import (
+	"image"
+	"math"
+	"path/filepath"
+	"testing"
+
+	"github.com/1set/quote0/quote0test"
+)
+
+// assertGolden compares img with testdata/<name>.png pixel by pixel (as gray levels).
+// Run with -update to regenerate the file after an intended rendering change.
+func assertGolden(t *testing.T, name string, img image.Image) {
+	t.Helper()
+	quote0test.AssertGolden(t, filepath.Join("testdata", name+".png"), img, 0)
+}
+
+func float(v float64) *float64 { return &v }
+
+func TestRenderWeatherCard_Full(t *testing.T) {
+	img, err := RenderWeatherCard(WeatherData{
+		Location:    "Berlin",
+		Condition:   IconRain,
+		Description: "Light rain",
+		Temperature: 12.4,
+		High:        float(15),
+		Low:         float(8.6),
+		Hourly:      []float64{12, 13, 14, math.NaN(), 15, 14, 12, 11, 10, 9},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != ScreenWidth || b.Dy() != ScreenHeight {
+		t.Fatalf("size %v", b)
+	}
+	assertGolden(t, "weather_full", img)
+}
+
+func TestRenderWeatherCard_Minimal(t *testing.T) {
+	img, err := RenderWeatherCard(WeatherData{Temperature: 21}, WithWeatherUnits(Imperial), WithWeatherAccent(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertGolden(t, "weather_minimal", img)
+}
+
+func TestRenderWeatherCard_Errors(t *testing.T) {
+	if _, err := RenderWeatherCard(WeatherData{Temperature: math.NaN()}); err != ErrInvalidTemperature {
+		t.Fatalf("NaN temperature: %v", err)
+	}
+	if _, err := RenderWeatherCard(WeatherData{Condition: "tornado"}); err == nil {
+		t.Fatal("unknown condition should fail")
+	}
+}
+
+func TestFormatTemperature(t *testing.T) {
+	cases := []struct {
+		c    float64
+		u    Units
+		want string
+	}{
+		{21, Metric, "21°C"},
+		{-0.4, Metric, "0°C"},
+		{21, Imperial, "70°F"},
+		{-40, Imperial, "-40°F"},
+	}
+	for _, tc := range cases {
+		if got := formatTemperature(tc.c, tc.u, true); got != tc.want {
+			t.Errorf("formatTemperature(%v, %v) = %q, want %q", tc.c, tc.u, got, tc.want)
+		}
+	}
+}
+
+func TestFitText(t *testing.T) {
+	if got := FitText("Berlin", 100, 1); got != "Berlin" {
+		t.Fatalf("fits: %q", got)
+	}
+	if got := FitText("San Francisco Bay", TextWidth("San Fr…", 2), 2); got != "San Fr…" {
+		t.Fatalf("cut: %q", got)
+	}
+	if got := FitText("abc", 3, 1); got != "" {
+		t.Fatalf("too narrow: %q", got)
+	}
+}
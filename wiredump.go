@@ -0,0 +1,48 @@
+package quote0
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"time"
+)
+
+// dumpRequest writes req as httputil.DumpRequestOut sees it, with the token masked and the
+// base64 image and icon fields elided. The dump works on a clone, so req.Body is untouched;
+// body is the payload it carries.
+func (c *Client) dumpRequest(req *http.Request, body []byte) {
+	r := req.Clone(req.Context())
+	// A stand-in body keeps the real Content-Length, which differs from len(body) when gzipped.
+	r.Body, r.GetBody = io.NopCloser(bytes.NewReader(body)), nil
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		r.Header.Set("Authorization", maskAuthorization(auth))
+	}
+	head, err := httputil.DumpRequestOut(r, false)
+	if err != nil {
+		c.debugLogger().Printf("dump request: %v", err)
+		return
+	}
+	fmt.Fprintf(c.wireOut(), "[quote0-debug] >>> request\n%s%s\n", head, elideBase64Fields(body))
+}
+
+// dumpResponse writes the status line and headers of resp followed by body, the bytes
+// already read from it.
+func (c *Client) dumpResponse(resp *http.Response, body []byte, elapsed time.Duration) {
+	head, err := httputil.DumpResponse(resp, false)
+	if err != nil {
+		c.debugLogger().Printf("dump response: %v", err)
+		return
+	}
+	fmt.Fprintf(c.wireOut(), "[quote0-debug] <<< response in %v\n%s%s\n", elapsed.Round(time.Millisecond), head, body)
+}
+
+// wireOut is the debug writer, stderr unless WithDebugWriter set one.
+func (c *Client) wireOut() io.Writer {
+	if c.debugOut != nil {
+		return c.debugOut
+	}
+	return os.Stderr
+}